@@ -0,0 +1,94 @@
+// Package apierror defines the JSON error body returned by every API endpoint.
+// Clients branch on Code; Message stays under the "error" key for display.
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifies an error independently of its message
+type Code string
+
+// Generic codes, one per HTTP status the API returns
+const (
+	CodeBadRequest     Code = "bad_request"
+	CodeInvalidRequest Code = "invalid_request" // body or query failed to bind
+	CodeUnauthorized   Code = "unauthorized"
+	CodeForbidden      Code = "forbidden"
+	CodeNotFound       Code = "not_found"
+	CodeConflict       Code = "conflict"
+	CodeTooLarge       Code = "payload_too_large"
+	CodeInternal       Code = "internal_error"
+	CodeNotSupported   Code = "not_supported"  // unavailable on this host's OS or hardware
+	CodeUpstream       Code = "upstream_error" // an integration or remote host failed
+	CodeUnavailable    Code = "unavailable"
+)
+
+// Specific codes the frontend reacts to
+const (
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeAccountDisabled    Code = "account_disabled"
+	CodeAccountLocked      Code = "account_locked"
+	CodeTokenInvalid       Code = "token_invalid"
+	CodePasswordExpired    Code = "password_expired"
+	CodePasswordPolicy     Code = "password_policy"
+	CodeAdminRequired      Code = "admin_required"
+	CodeFeatureDisabled    Code = "feature_disabled"
+	CodeNotConfigured      Code = "not_configured"
+	CodeSessionTimeout     Code = "session_timeout" // a terminal hit its idle or maximum duration
+	CodeHostUnreachable    Code = "host_unreachable"
+	CodePermissionDenied   Code = "permission_denied" // a remote host rejected the login or sudo
+	CodeHostKeyMismatch    Code = "host_key_mismatch" // a remote host presented another SSH key than the pinned one
+)
+
+// RequestIDKey is the gin context key holding the request ID
+const RequestIDKey = "requestId"
+
+// Error is the body of every error response
+type Error struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"error"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Respond writes an error response and aborts the handler chain.
+// details is optional and usually the underlying err.Error().
+func Respond(c *gin.Context, status int, code Code, message string, details ...string) {
+	body := Error{
+		Code:      code,
+		Message:   message,
+		RequestID: c.GetString(RequestIDKey),
+	}
+	if len(details) > 0 {
+		body.Details = details[0]
+	}
+	c.AbortWithStatusJSON(status, body)
+}
+
+// CodeForStatus returns the generic code for an HTTP status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusNotImplemented:
+		return CodeNotSupported
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
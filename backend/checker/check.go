@@ -0,0 +1,163 @@
+// Package checker runs the service checks: HTTP, TCP, ping and native
+// database checks. It only depends on the models and the database drivers,
+// so the probe agent stays small.
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Status       string // online, offline, error or disabled
+	StatusCode   int
+	ResponseTime int64 // in milliseconds
+	Error        string
+	CheckedAt    time.Time
+
+	// Timings is the phase breakdown of HTTP checks
+	Timings *models.CheckTimings
+	// Database holds latency, replication and size details of database checks
+	Database *models.DatabaseHealth
+}
+
+// Run checks a service once. Secrets such as the client key and database
+// password must be in plain text.
+func Run(clients *Clients, svc models.ServiceConfig) Result {
+	result := Result{
+		Status:    "offline",
+		CheckedAt: time.Now(),
+	}
+
+	if !svc.IsActive {
+		result.Status = "disabled"
+		return result
+	}
+
+	start := time.Now()
+	timeout := Timeout(svc)
+
+	switch svc.Method {
+	case "TCP":
+		// TCP port check
+		host := svc.URL
+		if svc.Port > 0 {
+			host = fmt.Sprintf("%s:%d", svc.URL, svc.Port)
+		}
+		conn, err := clients.Dial(svc, host, timeout)
+		if err == nil {
+			conn.Close()
+			result.Status = "online"
+		}
+	case MethodPostgres, MethodMySQL, MethodRedis:
+		// Native database check: real connection, credentials and query
+		health, err := runDatabaseCheck(clients, svc)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Status = "online"
+			result.Database = health
+		}
+	case "PING":
+		// Simple TCP ping to common ports, sharing the timeout between them
+		host := svc.URL
+		ports := []string{"80", "443", "22"}
+		for _, port := range ports {
+			conn, err := clients.Dial(svc, net.JoinHostPort(host, port), timeout/time.Duration(len(ports)))
+			if err == nil {
+				conn.Close()
+				result.Status = "online"
+				break
+			}
+		}
+	default:
+		// HTTP/HTTPS check
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// Trace each phase so a slow check can be attributed to DNS,
+		// connection setup, TLS or the application itself
+		timings := &models.CheckTimings{}
+		var dnsStart, connectStart, tlsStart time.Time
+		trace := &httptrace.ClientTrace{
+			DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+			DNSDone: func(httptrace.DNSDoneInfo) {
+				timings.DNSLookup = time.Since(dnsStart).Milliseconds()
+			},
+			ConnectStart: func(string, string) { connectStart = time.Now() },
+			ConnectDone: func(string, string, error) {
+				timings.TCPConnect = time.Since(connectStart).Milliseconds()
+			},
+			TLSHandshakeStart: func() { tlsStart = time.Now() },
+			TLSHandshakeDone: func(tls.ConnectionState, error) {
+				timings.TLSHandshake = time.Since(tlsStart).Milliseconds()
+			},
+			GotConn: func(info httptrace.GotConnInfo) {
+				timings.ConnectionReused = info.Reused
+			},
+			GotFirstResponseByte: func() {
+				timings.TTFB = time.Since(start).Milliseconds()
+			},
+		}
+		ctx = httptrace.WithClientTrace(ctx, trace)
+
+		req, err := http.NewRequestWithContext(ctx, "HEAD", svc.URL, nil)
+		if err != nil {
+			// Fallback to GET if HEAD fails
+			req, err = http.NewRequestWithContext(ctx, "GET", svc.URL, nil)
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+				return result
+			}
+		}
+
+		// Set user agent to avoid bot detection
+		req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+
+		client, err := clients.For(svc)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+			return result
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			result.Status = "offline"
+			result.Error = err.Error()
+		} else {
+			defer resp.Body.Close()
+			result.StatusCode = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+				result.Status = "online"
+			} else if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+				result.Status = "error"
+			} else {
+				result.Status = "offline"
+			}
+		}
+
+		timings.Total = time.Since(start).Milliseconds()
+		result.Timings = timings
+	}
+
+	result.ResponseTime = time.Since(start).Milliseconds()
+	return result
+}
+
+// Timeout returns the service's check timeout, 10 seconds when unset
+func Timeout(svc models.ServiceConfig) time.Duration {
+	if svc.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(svc.Timeout) * time.Second
+}
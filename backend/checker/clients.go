@@ -0,0 +1,290 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+	xproxy "golang.org/x/net/proxy"
+)
+
+// Clients hands out HTTP clients for service checks. Services without
+// custom TLS or proxy settings share one client; each distinct configuration
+// gets its own transport so settings never leak between services sharing a pool.
+type Clients struct {
+	base  *http.Client
+	proxy string // global check proxy, "" uses the environment
+
+	mu     sync.Mutex
+	custom map[string]*http.Client
+}
+
+// maxCustomClients bounds the per-configuration client cache
+const maxCustomClients = 100
+
+// ProxyDirect as a service proxy bypasses the global check proxy
+const ProxyDirect = "direct"
+
+// NewClients creates a new client cache. globalProxy applies to every
+// service without its own proxy; empty falls back to HTTP_PROXY/HTTPS_PROXY.
+func NewClients(globalProxy string) *Clients {
+	return &Clients{
+		base:   newClient(nil, proxyFunc(globalProxy)),
+		proxy:  globalProxy,
+		custom: make(map[string]*http.Client),
+	}
+}
+
+// newClient returns an HTTP client for service checks with the given TLS
+// and proxy settings. Requests are bounded by the service's timeout through
+// their context.
+func newClient(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               proxy,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     30 * time.Second,
+			DisableKeepAlives:   false,
+			TLSClientConfig:     tlsConfig,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse // Don't follow redirects
+		},
+	}
+}
+
+// hasCustomTLS returns true if the service overrides any TLS setting
+func hasCustomTLS(svc models.ServiceConfig) bool {
+	return svc.TLSSkipVerify || svc.TLSServerName != "" || svc.CABundle != "" || svc.ClientCert != ""
+}
+
+// proxyFor returns the proxy setting that applies to svc
+func (c *Clients) proxyFor(svc models.ServiceConfig) string {
+	if svc.ProxyURL != "" {
+		return svc.ProxyURL
+	}
+	return c.proxy
+}
+
+// For returns the client to check svc with
+func (c *Clients) For(svc models.ServiceConfig) (*http.Client, error) {
+	proxy := c.proxyFor(svc)
+	if !hasCustomTLS(svc) && proxy == c.proxy {
+		return c.base, nil
+	}
+	if err := ValidateProxyURL(proxy); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%t|%s|%s|%s|%s|%s", svc.TLSSkipVerify, svc.TLSServerName, svc.CABundle, svc.ClientCert, svc.ClientKey, proxy)))
+	key := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.custom[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.custom) >= maxCustomClients {
+		for k, old := range c.custom {
+			old.CloseIdleConnections()
+			delete(c.custom, k)
+		}
+	}
+	client := newClient(tlsConfig, proxyFunc(proxy))
+	c.custom[key] = client
+	return client, nil
+}
+
+// buildTLSConfig builds the TLS settings for a service
+func buildTLSConfig(svc models.ServiceConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: svc.TLSSkipVerify,
+		ServerName:         svc.TLSServerName,
+	}
+
+	if svc.CABundle != "" {
+		// Trust the private CA in addition to the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(svc.CABundle)) {
+			return nil, fmt.Errorf("CA bundle contains no valid PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if svc.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(svc.ClientCert), []byte(svc.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// ValidateCABundle checks that a PEM bundle contains at least one certificate
+func ValidateCABundle(bundle string) error {
+	if bundle == "" {
+		return nil
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(bundle)) {
+		return fmt.Errorf("CA bundle contains no valid PEM certificates")
+	}
+	return nil
+}
+
+// ValidateClientCertificate checks that a PEM certificate and private key form a pair
+func ValidateClientCertificate(cert, key string) error {
+	if key == "" {
+		return fmt.Errorf("client certificate requires a private key")
+	}
+	if _, err := tls.X509KeyPair([]byte(cert), []byte(key)); err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return nil
+}
+
+// proxyFunc returns the transport proxy function for a proxy setting
+func proxyFunc(proxy string) func(*http.Request) (*url.URL, error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment
+	case ProxyDirect:
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// ValidateProxyURL checks a proxy setting: empty, "direct", or an
+// http, https, socks5 or socks5h URL
+func ValidateProxyURL(proxy string) error {
+	if proxy == "" || proxy == ProxyDirect {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL must include a host")
+	}
+	return nil
+}
+
+// Dial opens a TCP connection for port and ping checks, through the
+// service's proxy when one applies. SOCKS proxies dial directly;
+// HTTP proxies are asked to open a CONNECT tunnel.
+func (c *Clients) Dial(svc models.ServiceConfig, address string, timeout time.Duration) (net.Conn, error) {
+	proxy := c.proxyFor(svc)
+	if proxy == "" || proxy == ProxyDirect {
+		return net.DialTimeout("tcp", address, timeout)
+	}
+	if err := ValidateProxyURL(proxy); err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(proxy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if u.Scheme == "socks5" || u.Scheme == "socks5h" {
+		dialer, err := xproxy.FromURL(u, &net.Dialer{Timeout: timeout})
+		if err != nil {
+			return nil, err
+		}
+		if cd, ok := dialer.(xproxy.ContextDialer); ok {
+			return cd.DialContext(ctx, "tcp", address)
+		}
+		return dialer.Dial("tcp", address)
+	}
+
+	return dialHTTPConnect(ctx, u, address, timeout)
+}
+
+// dialHTTPConnect opens a tunnel to address through an HTTP(S) proxy
+func dialHTTPConnect(ctx context.Context, proxy *url.URL, address string, timeout time.Duration) (net.Conn, error) {
+	host := proxy.Host
+	if proxy.Port() == "" {
+		if proxy.Scheme == "https" {
+			host = net.JoinHostPort(proxy.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: timeout}
+	if proxy.Scheme == "https" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: proxy.Hostname()}}).DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT failed: %s", resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,401 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/homelab/backend/models"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Native database check methods
+const (
+	MethodPostgres = "POSTGRES"
+	MethodMySQL    = "MYSQL"
+	MethodRedis    = "REDIS"
+)
+
+// IsDatabaseMethod returns true for the native database check methods
+func IsDatabaseMethod(method string) bool {
+	return method == MethodPostgres || method == MethodMySQL || method == MethodRedis
+}
+
+// runDatabaseCheck connects with the service's credentials, runs a trivial
+// query and collects version, replication and size details. Redis goes
+// through the check proxy; PostgreSQL and MySQL connect directly.
+func runDatabaseCheck(clients *Clients, svc models.ServiceConfig) (*models.DatabaseHealth, error) {
+	timeout := Timeout(svc)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch svc.Method {
+	case MethodPostgres:
+		return checkPostgres(ctx, svc, svc.DBPassword)
+	case MethodMySQL:
+		return checkMySQL(ctx, svc, svc.DBPassword)
+	case MethodRedis:
+		return checkRedis(ctx, clients, svc, svc.DBPassword, timeout)
+	}
+	return nil, fmt.Errorf("unknown database method %q", svc.Method)
+}
+
+// databaseURL parses the service URL, adding the default scheme and port
+func databaseURL(svc models.ServiceConfig, scheme string, port int) (*url.URL, error) {
+	raw := svc.URL
+	if !strings.Contains(raw, "://") {
+		raw = scheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %v", err)
+	}
+	if u.Port() == "" {
+		if svc.Port > 0 {
+			port = svc.Port
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	}
+	return u, nil
+}
+
+// pingSQL opens a connection and times the connect and SELECT 1 separately
+func pingSQL(ctx context.Context, driver, dsn string, health *models.DatabaseHealth) (*sql.DB, *sql.Conn, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	start := time.Now()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	health.ConnectMs = time.Since(start).Milliseconds()
+
+	start = time.Now()
+	var one int
+	if err := conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		conn.Close()
+		db.Close()
+		return nil, nil, err
+	}
+	health.QueryMs = time.Since(start).Milliseconds()
+	return db, conn, nil
+}
+
+// checkPostgres checks a PostgreSQL server. URL: postgres://user@host:5432/db?sslmode=disable
+func checkPostgres(ctx context.Context, svc models.ServiceConfig, password string) (*models.DatabaseHealth, error) {
+	u, err := databaseURL(svc, "postgres", 5432)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" {
+		u.User = url.UserPassword(u.User.Username(), password)
+	}
+
+	health := &models.DatabaseHealth{Engine: "postgres"}
+	db, conn, err := pingSQL(ctx, "pgx", u.String(), health)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer conn.Close()
+
+	conn.QueryRowContext(ctx, "SHOW server_version").Scan(&health.Version)
+
+	var inRecovery bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err == nil {
+		health.Role = "primary"
+		if inRecovery {
+			health.Role = "replica"
+			var lag sql.NullFloat64
+			err := conn.QueryRowContext(ctx,
+				"SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())").Scan(&lag)
+			if err == nil && lag.Valid {
+				health.ReplicationLag = &lag.Float64
+			}
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT datname, pg_database_size(datname) FROM pg_database WHERE NOT datistemplate ORDER BY 2 DESC LIMIT 20")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var size models.DatabaseSize
+			if rows.Scan(&size.Name, &size.Bytes) == nil {
+				health.Sizes = append(health.Sizes, size)
+			}
+		}
+	}
+
+	return health, nil
+}
+
+// checkMySQL checks a MySQL or MariaDB server. URL: mysql://user@host:3306/db
+func checkMySQL(ctx context.Context, svc models.ServiceConfig, password string) (*models.DatabaseHealth, error) {
+	u, err := databaseURL(svc, "mysql", 3306)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = u.Host
+	cfg.User = u.User.Username()
+	cfg.Passwd = password
+	if p, ok := u.User.Password(); ok && password == "" {
+		cfg.Passwd = p
+	}
+	cfg.DBName = strings.TrimPrefix(u.Path, "/")
+	if deadline, ok := ctx.Deadline(); ok {
+		cfg.Timeout = time.Until(deadline)
+	}
+
+	health := &models.DatabaseHealth{Engine: "mysql"}
+	db, conn, err := pingSQL(ctx, "mysql", cfg.FormatDSN(), health)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer conn.Close()
+
+	conn.QueryRowContext(ctx, "SELECT VERSION()").Scan(&health.Version)
+
+	// SHOW REPLICA STATUS needs MySQL 8.0.22+; older servers and MariaDB use SLAVE
+	health.Role = "primary"
+	status, err := queryRowMap(ctx, conn, "SHOW REPLICA STATUS")
+	if err != nil {
+		status, err = queryRowMap(ctx, conn, "SHOW SLAVE STATUS")
+	}
+	if err == nil && status != nil {
+		health.Role = "replica"
+		for _, key := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+			if v, ok := status[key]; ok {
+				if lag, err := strconv.ParseFloat(v, 64); err == nil {
+					health.ReplicationLag = &lag
+				}
+				break
+			}
+		}
+		for _, key := range []string{"Replica_IO_Running", "Slave_IO_Running"} {
+			if v, ok := status[key]; ok {
+				health.Replication = "io_running=" + v
+				break
+			}
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx,
+		"SELECT table_schema, SUM(data_length + index_length) FROM information_schema.tables "+
+			"GROUP BY table_schema ORDER BY 2 DESC LIMIT 20")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var size models.DatabaseSize
+			var bytes sql.NullInt64
+			if rows.Scan(&size.Name, &bytes) == nil {
+				size.Bytes = bytes.Int64
+				health.Sizes = append(health.Sizes, size)
+			}
+		}
+	}
+
+	return health, nil
+}
+
+// queryRowMap returns the first row of a query as column -> value, or nil when empty
+func queryRowMap(ctx context.Context, conn *sql.Conn, query string) (map[string]string, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]string, len(columns))
+	for i, col := range columns {
+		row[col] = string(values[i])
+	}
+	return row, nil
+}
+
+// checkRedis checks a Redis server over RESP. URL: redis://[user@]host:6379 (rediss:// for TLS)
+func checkRedis(ctx context.Context, clients *Clients, svc models.ServiceConfig, password string, timeout time.Duration) (*models.DatabaseHealth, error) {
+	u, err := databaseURL(svc, "redis", 6379)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := u.User.Password(); ok && password == "" {
+		password = p
+	}
+
+	health := &models.DatabaseHealth{Engine: "redis"}
+	start := time.Now()
+	conn, err := clients.Dial(svc, u.Host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if u.Scheme == "rediss" {
+		tlsConfig, err := buildTLSConfig(svc)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	r := bufio.NewReader(conn)
+	if password != "" {
+		args := []string{"AUTH", password}
+		if user := u.User.Username(); user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if _, err := redisCommand(conn, r, args...); err != nil {
+			return nil, err
+		}
+	}
+	health.ConnectMs = time.Since(start).Milliseconds()
+
+	start = time.Now()
+	pong, err := redisCommand(conn, r, "PING")
+	if err != nil {
+		return nil, err
+	}
+	if pong != "PONG" {
+		return nil, fmt.Errorf("unexpected PING reply %q", pong)
+	}
+	health.QueryMs = time.Since(start).Milliseconds()
+
+	info, err := redisCommand(conn, r, "INFO")
+	if err != nil {
+		// INFO may be disabled with rename-command; PING already succeeded
+		return health, nil
+	}
+	fields := parseRedisInfo(info)
+	health.Version = fields["redis_version"]
+	switch fields["role"] {
+	case "master":
+		health.Role = "primary"
+	case "slave":
+		health.Role = "replica"
+		health.Replication = "link_" + fields["master_link_status"]
+		if lag, err := strconv.ParseFloat(fields["master_last_io_seconds_ago"], 64); err == nil && lag >= 0 {
+			health.ReplicationLag = &lag
+		}
+	}
+	if used, err := strconv.ParseInt(fields["used_memory"], 10, 64); err == nil {
+		health.Sizes = append(health.Sizes, models.DatabaseSize{Name: "memory", Bytes: used})
+	}
+	for key, value := range fields {
+		// Keyspace lines look like db0:keys=12,expires=0,avg_ttl=0
+		if !strings.HasPrefix(key, "db") {
+			continue
+		}
+		if keys, ok := strings.CutPrefix(strings.Split(value, ",")[0], "keys="); ok {
+			n, _ := strconv.ParseInt(keys, 10, 64)
+			health.Sizes = append(health.Sizes, models.DatabaseSize{Name: key, Keys: n})
+		}
+	}
+	sort.SliceStable(health.Sizes, func(i, j int) bool {
+		return health.Sizes[i].Keys > health.Sizes[j].Keys
+	})
+
+	return health, nil
+}
+
+// redisCommand sends a command and reads a simple, error or bulk string reply
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return line[1:], nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return "", err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
+
+// parseRedisInfo parses INFO output into key -> value
+func parseRedisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
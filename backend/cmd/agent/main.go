@@ -0,0 +1,115 @@
+// Command agent collects the metrics of the machine it runs on and pushes
+// them to the homelab backend, for hosts the backend can't read over SSH.
+// The backend shows them as the metrics of the device the agent was
+// enrolled for.
+//
+// Configuration (environment):
+//
+//	AGENT_SERVER_URL  backend URL, e.g. https://homelab.example.com
+//	AGENT_TOKEN       enrollment token from POST /api/devices/:id/agent
+//	AGENT_INTERVAL    seconds between reports (default 30, at least 5)
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/collector"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+type agent struct {
+	serverURL string
+	token     string
+	client    *http.Client
+	hostname  string
+	platform  string
+}
+
+func main() {
+	serverURL := strings.TrimRight(os.Getenv("AGENT_SERVER_URL"), "/")
+	token := os.Getenv("AGENT_TOKEN")
+	if serverURL == "" || token == "" {
+		log.Fatal("AGENT_SERVER_URL and AGENT_TOKEN must be set")
+	}
+
+	interval, err := strconv.Atoi(os.Getenv("AGENT_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 30
+	}
+	if interval < 5 {
+		interval = 5
+	}
+
+	a := &agent{
+		serverURL: serverURL,
+		token:     token,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if info, err := host.Info(); err == nil {
+		a.hostname = info.Hostname
+		a.platform = strings.TrimSpace(info.Platform + " " + info.PlatformVersion)
+	}
+
+	log.Printf("Metrics agent %s reporting to %s every %ds", config.Version, serverURL, interval)
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := a.report(); err != nil {
+			log.Printf("Report failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// report collects the host's metrics and pushes them to the backend.
+// Interfaces are picked by the default interface settings.
+func (a *agent) report() error {
+	metrics, err := collector.System(models.DefaultNetworkInterfaceSettings())
+	if err != nil {
+		return fmt.Errorf("collecting metrics: %w", err)
+	}
+
+	data, err := json.Marshal(models.AgentReport{
+		Version:  config.Version,
+		Hostname: a.hostname,
+		Platform: a.platform,
+		Metrics:  *metrics,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", a.serverURL+"/api/ingest/metrics", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Details string `json:"details"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("POST /api/ingest/metrics returned %d: %s %s", resp.StatusCode, apiErr.Error, apiErr.Details)
+	}
+	return nil
+}
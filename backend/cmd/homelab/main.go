@@ -0,0 +1,363 @@
+// Command homelab administers a homelab backend's database: versioned schema
+// migrations, seeding, admin accounts, JWT secret rotation and vacuuming.
+// It reads the same .env and environment as the backend.
+//
+//	homelab migrate up [n]      apply pending migrations, or the next n
+//	homelab migrate down [n]    revert the latest n migrations (default 1)
+//	homelab migrate to <v>      apply or revert migrations to version v
+//	homelab migrate status      list migrations and when they were applied
+//	homelab seed                migrate, then seed demo data
+//	homelab reset               drop everything, migrate and seed
+//	homelab create-admin        create an admin, or promote and reset a user
+//	homelab rotate-jwt-secret   write a new JWT_SECRET and revoke sessions
+//	homelab vacuum              delete expired sessions, reclaim space
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"golang.org/x/term"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Homelab admin tool
+
+Usage:
+  homelab <command> [arguments]
+
+Commands:
+  migrate up [n]       Apply pending migrations, or only the next n
+  migrate down [n]     Revert the latest n migrations (default 1)
+  migrate to <v>       Apply or revert migrations until version v is the
+                       latest applied; 0 reverts everything
+  migrate status       List migrations and when they were applied
+  seed                 Apply migrations and seed demo data
+  reset                Drop all tables, migrate and seed (WARNING: deletes all data)
+  create-admin         Create an admin user, or promote an existing one
+                       -email, -username, -name; password from
+                       HOMELAB_ADMIN_PASSWORD or prompted
+  rotate-jwt-secret    Write a new JWT_SECRET to the env file and revoke all
+                       sessions; -env picks the file (default .env)
+  vacuum               Delete expired sessions and reclaim database space
+
+Examples:
+  homelab migrate up
+  homelab migrate down 1
+  homelab create-admin -email ops@example.com -username ops`)
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+	if command == "help" || command == "-h" || command == "--help" {
+		usage()
+		return
+	}
+
+	cfg := config.Load()
+	config.AppConfig = cfg
+	if _, err := database.Connect(cfg); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	var err error
+	switch command {
+	case "migrate":
+		err = migrate(args)
+	case "seed":
+		if err = database.Migrate(); err == nil {
+			err = database.Seed()
+		}
+	case "reset":
+		log.Println("WARNING: This will delete all data!")
+		log.Println("Press Ctrl+C within 5 seconds to cancel...")
+		time.Sleep(5 * time.Second)
+		err = database.ResetDatabase()
+	case "create-admin":
+		err = createAdmin(args)
+	case "rotate-jwt-secret":
+		err = rotateJWTSecret(args)
+	case "vacuum":
+		err = vacuum()
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", command, err)
+	}
+}
+
+// migrate runs the migrate subcommands
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected up, down, to or status")
+	}
+	if args[0] == "to" {
+		if len(args) < 2 {
+			return fmt.Errorf("migrate to needs a version")
+		}
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		applied, reverted, err := database.MigrateTo(uint(version))
+		log.Printf("Applied %d and reverted %d migrations", applied, reverted)
+		return err
+	}
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid number of migrations %q", args[1])
+		}
+		steps = n
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(steps)
+		log.Printf("Applied %d migrations", applied)
+		return err
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := database.MigrateDown(steps)
+		log.Printf("Reverted %d migrations", reverted)
+		return err
+	case "status":
+		states, err := database.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		version, err := database.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Schema version %d, this build's latest is %d\n\n", version, database.LatestVersion())
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				applied += " (unknown to this build)"
+			}
+			fmt.Printf("%4d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q (use up, down, to or status)", args[0])
+}
+
+// createAdmin creates an admin account. An existing user with the email is
+// promoted to admin, reactivated and given the new password, which recovers
+// a locked-out installation.
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := fs.String("email", "", "email address (required)")
+	username := fs.String("username", "", "username (defaults to the part of the email before @)")
+	name := fs.String("name", "Administrator", "display name")
+	fs.Parse(args)
+
+	if *email == "" || !strings.Contains(*email, "@") {
+		return fmt.Errorf("-email is required")
+	}
+	if *username == "" {
+		*username, _, _ = strings.Cut(*email, "@")
+	}
+	if _, err := database.MigrateUp(0); err != nil {
+		return err
+	}
+
+	password, err := adminPassword()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var user models.User
+	if err := database.DB.Where("email = ?", *email).First(&user).Error; err == nil {
+		user.Password = password
+		if err := user.HashPassword(); err != nil {
+			return err
+		}
+		if err := database.DB.Model(&user).Updates(map[string]interface{}{
+			"password":            user.Password,
+			"role":                "admin",
+			"is_active":           true,
+			"failed_logins":       0,
+			"locked_until":        nil,
+			"password_changed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		log.Printf("Promoted %s (%s) to admin and reset the password", user.Username, user.Email)
+		return nil
+	}
+
+	user = models.User{
+		Email:             *email,
+		Username:          *username,
+		Password:          password, // hashed by BeforeCreate
+		Name:              *name,
+		Role:              "admin",
+		IsActive:          true,
+		PasswordChangedAt: &now,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		return err
+	}
+	log.Printf("Created admin %s (%s)", user.Username, user.Email)
+	return nil
+}
+
+// adminPassword reads the password from HOMELAB_ADMIN_PASSWORD, or prompts
+// for it twice on a terminal
+func adminPassword() (string, error) {
+	if password := os.Getenv("HOMELAB_ADMIN_PASSWORD"); password != "" {
+		return checkAdminPassword(password)
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("set HOMELAB_ADMIN_PASSWORD or run in a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeat, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(password) != string(repeat) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return checkAdminPassword(string(password))
+}
+
+func checkAdminPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", fmt.Errorf("password must be at least 8 characters")
+	}
+	return password, nil
+}
+
+// rotateJWTSecret writes a new random JWT_SECRET to the env file and revokes
+// every session. When ENCRYPTION_KEY is unset it is derived from the JWT
+// secret, so the old secret is written as ENCRYPTION_KEY to keep stored
+// credentials readable.
+func rotateJWTSecret(args []string) error {
+	fs := flag.NewFlagSet("rotate-jwt-secret", flag.ExitOnError)
+	envFile := fs.String("env", ".env", "env file the backend reads")
+	fs.Parse(args)
+
+	secret := make([]byte, 48)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	values := map[string]string{"JWT_SECRET": base64.RawURLEncoding.EncodeToString(secret)}
+	if os.Getenv("ENCRYPTION_KEY") == "" {
+		values["ENCRYPTION_KEY"] = config.AppConfig.EncryptionKey
+	}
+	if err := setEnvValues(*envFile, values); err != nil {
+		return err
+	}
+
+	revoked := database.DB.Where("expires_at > ?", time.Now()).Delete(&models.Session{})
+	if revoked.Error != nil {
+		return revoked.Error
+	}
+
+	log.Printf("Wrote a new JWT_SECRET to %s and revoked %d sessions", *envFile, revoked.RowsAffected)
+	if _, ok := values["ENCRYPTION_KEY"]; ok {
+		log.Printf("ENCRYPTION_KEY was derived from the old secret; it is now set in %s so stored credentials stay readable", *envFile)
+	}
+	log.Println("Restart the backend to use the new secret. Everyone has to sign in again.")
+	return nil
+}
+
+// setEnvValues sets keys in an env file, replacing their lines in place and
+// appending the ones it does not have. Other lines are left untouched.
+func setEnvValues(path string, values map[string]string) error {
+	var lines []string
+	mode := os.FileMode(0600)
+	if f, err := os.Open(path); err == nil {
+		if info, err := f.Stat(); err == nil {
+			mode = info.Mode().Perm()
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	done := make(map[string]bool, len(values))
+	for i, line := range lines {
+		key, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		key = strings.TrimSpace(key)
+		if value, set := values[key]; ok && set && !done[key] {
+			lines[i] = key + "=" + value
+			done[key] = true
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !done[key] {
+			lines = append(lines, key+"="+values[key])
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// vacuum deletes expired sessions and reclaims space
+func vacuum() error {
+	result, err := database.Vacuum()
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted %d expired sessions", result.ExpiredSessions)
+	if result.SizeBefore > 0 {
+		log.Printf("Database size %.1f MB → %.1f MB", float64(result.SizeBefore)/(1<<20), float64(result.SizeAfter)/(1<<20))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Command probe-agent runs the service checks assigned to its location and
+// reports the results back to the homelab backend. Deploy it outside the LAN
+// (e.g. on a VPS) to verify services are reachable from the internet.
+//
+// Configuration (environment):
+//
+//	PROBE_SERVER_URL  backend URL, e.g. https://homelab.example.com
+//	PROBE_TOKEN       agent token from POST /api/probes/agents
+//	PROBE_INTERVAL    seconds between rounds (default 60)
+//	PROBE_PROXY_URL   optional http(s)/socks5 proxy for checks
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/checker"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+type agent struct {
+	serverURL    string
+	token        string
+	apiClient    *http.Client
+	checkClients *checker.Clients
+}
+
+func main() {
+	serverURL := strings.TrimRight(os.Getenv("PROBE_SERVER_URL"), "/")
+	token := os.Getenv("PROBE_TOKEN")
+	if serverURL == "" || token == "" {
+		log.Fatal("PROBE_SERVER_URL and PROBE_TOKEN must be set")
+	}
+
+	interval, err := strconv.Atoi(os.Getenv("PROBE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 60
+	}
+
+	proxyURL := os.Getenv("PROBE_PROXY_URL")
+	if err := checker.ValidateProxyURL(proxyURL); err != nil {
+		log.Fatalf("PROBE_PROXY_URL: %v", err)
+	}
+
+	a := &agent{
+		serverURL:    serverURL,
+		token:        token,
+		apiClient:    &http.Client{Timeout: 30 * time.Second},
+		checkClients: checker.NewClients(proxyURL),
+	}
+
+	log.Printf("Probe agent %s reporting to %s every %ds", config.Version, serverURL, interval)
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := a.round(); err != nil {
+			log.Printf("Probe round failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// round fetches assignments, runs them concurrently and reports the results
+func (a *agent) round() error {
+	var assignments struct {
+		Location string              `json:"location"`
+		Checks   []models.ProbeCheck `json:"checks"`
+	}
+	if err := a.do("GET", "/api/probe/checks", nil, &assignments); err != nil {
+		return err
+	}
+
+	results := make([]models.ProbeResult, len(assignments.Checks))
+	var wg sync.WaitGroup
+	for i, check := range assignments.Checks {
+		wg.Add(1)
+		go func(idx int, check models.ProbeCheck) {
+			defer wg.Done()
+			result := checker.Run(a.checkClients, models.ServiceConfig{
+				ID:           check.ServiceID,
+				Name:         check.Name,
+				URL:          check.URL,
+				Method:       check.Method,
+				Port:         check.Port,
+				Timeout:      check.Timeout,
+				ExpectedCode: check.ExpectedCode,
+				IsActive:     true,
+
+				TLSSkipVerify: check.TLSSkipVerify,
+				TLSServerName: check.TLSServerName,
+				CABundle:      check.CABundle,
+				ClientCert:    check.ClientCert,
+				ClientKey:     check.ClientKey,
+				DBPassword:    check.DBPassword,
+			})
+			results[idx] = models.ProbeResult{
+				ServiceID:    check.ServiceID,
+				Status:       result.Status,
+				StatusCode:   result.StatusCode,
+				ResponseTime: result.ResponseTime,
+				Error:        result.Error,
+				Timings:      result.Timings,
+				CheckedAt:    result.CheckedAt,
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	var reply struct {
+		Stored int `json:"stored"`
+	}
+	report := models.ProbeReport{Version: config.Version, Results: results}
+	if err := a.do("POST", "/api/probe/results", report, &reply); err != nil {
+		return err
+	}
+	log.Printf("[%s] reported %d/%d results", assignments.Location, reply.Stored, len(results))
+	return nil
+}
+
+// do sends an authenticated JSON request to the backend
+func (a *agent) do(method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, a.serverURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Details string `json:"details"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%s %s returned %d: %s %s", method, path, resp.StatusCode, apiErr.Error, apiErr.Details)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
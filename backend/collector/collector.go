@@ -0,0 +1,287 @@
+// Package collector reads the metrics of the host it runs on. It only
+// depends on gopsutil and the models, so the push agent stays small.
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/models"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// System returns the host's CPU, memory, disk and network metrics, with the
+// network interfaces the settings show
+func System(settings models.NetworkInterfaceSettings) (*models.SystemMetrics, error) {
+	cpuMetrics, err := CPU()
+	if err != nil {
+		return nil, err
+	}
+
+	memMetrics, err := Memory()
+	if err != nil {
+		return nil, err
+	}
+
+	diskMetrics, err := Disks()
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces, err := NetworkInterfaces(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	uptime, _ := host.Uptime()
+
+	return &models.SystemMetrics{
+		CPU:       *cpuMetrics,
+		Memory:    *memMetrics,
+		Disk:      diskMetrics,
+		Network:   Shown(interfaces),
+		Uptime:    uptime,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// Shown returns the interfaces the settings don't hide
+func Shown(interfaces []models.NetworkMetrics) []models.NetworkMetrics {
+	shown := make([]models.NetworkMetrics, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if !iface.Hidden {
+			shown = append(shown, iface)
+		}
+	}
+	return shown
+}
+
+// CPU returns the usage, model and frequencies of the CPU
+func CPU() (*models.CPUMetrics, error) {
+	percentages, err := cpu.Percent(time.Millisecond*200, true)
+	if err != nil {
+		return nil, err
+	}
+
+	before, _ := cpu.Times(false)
+	overallPercent, err := cpu.Percent(time.Millisecond*200, false)
+	if err != nil {
+		return nil, err
+	}
+	after, _ := cpu.Times(false)
+
+	var usagePercent float64
+	if len(overallPercent) > 0 {
+		usagePercent = overallPercent[0]
+	}
+
+	info, _ := cpu.Info()
+	var modelName string
+	var frequency float64
+	if len(info) > 0 {
+		modelName = info[0].ModelName
+		frequency = info[0].Mhz
+	}
+
+	cores, _ := cpu.Counts(false)
+	logicalCores, _ := cpu.Counts(true)
+
+	return &models.CPUMetrics{
+		UsagePercent: usagePercent,
+		Cores:        cores,
+		LogicalCores: logicalCores,
+		ModelName:    modelName,
+		Frequency:    frequency,
+		PerCoreUsage: percentages,
+		PerCoreFreq:  perCoreFrequency(),
+		StealPercent: stealPercent(before, after),
+	}, nil
+}
+
+// stealPercent returns the share of CPU time stolen by the hypervisor between two samples
+func stealPercent(before, after []cpu.TimesStat) float64 {
+	if len(before) == 0 || len(after) == 0 {
+		return 0
+	}
+	total := func(t cpu.TimesStat) float64 {
+		return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	}
+	elapsed := total(after[0]) - total(before[0])
+	if elapsed <= 0 {
+		return 0
+	}
+	return (after[0].Steal - before[0].Steal) / elapsed * 100
+}
+
+// perCoreFrequency returns the current frequency of each logical core in MHz.
+// Linux only: read from cpufreq, falling back to /proc/cpuinfo in VMs without it.
+func perCoreFrequency() []float64 {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	var freqs []float64
+	for i := 0; ; i++ {
+		data, err := os.ReadFile(fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq/scaling_cur_freq", i))
+		if err != nil {
+			break
+		}
+		khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			break
+		}
+		freqs = append(freqs, khz/1000)
+	}
+	if len(freqs) > 0 {
+		return freqs
+	}
+
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "cpu MHz") {
+			continue
+		}
+		if i := strings.Index(line, ":"); i >= 0 {
+			if mhz, err := strconv.ParseFloat(strings.TrimSpace(line[i+1:]), 64); err == nil {
+				freqs = append(freqs, mhz)
+			}
+		}
+	}
+	return freqs
+}
+
+// Memory returns physical memory and swap usage
+func Memory() (*models.MemoryMetrics, error) {
+	vmem, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, err
+	}
+
+	swap, _ := mem.SwapMemory()
+
+	return &models.MemoryMetrics{
+		Total:       vmem.Total,
+		Used:        vmem.Used,
+		Free:        vmem.Free,
+		Available:   vmem.Available,
+		UsedPercent: vmem.UsedPercent,
+		SwapTotal:   swap.Total,
+		SwapUsed:    swap.Used,
+		SwapFree:    swap.Free,
+		SwapPercent: swap.UsedPercent,
+		SwapIn:      swap.Sin,
+		SwapOut:     swap.Sout,
+	}, nil
+}
+
+// Disks returns the usage and IO counters of every mounted filesystem
+func Disks() ([]models.DiskMetrics, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []models.DiskMetrics
+	ioStats, _ := disk.IOCounters()
+
+	for _, p := range partitions {
+		// A hung network mount would block statfs, and with it this whole
+		// request; the mount health check reports it instead
+		var usage *disk.UsageStat
+		if IsNetworkFstype(p.Fstype) {
+			usage, err = UsageWithTimeout(p.Mountpoint, 2*time.Second)
+		} else {
+			usage, err = disk.Usage(p.Mountpoint)
+		}
+		if err != nil {
+			continue
+		}
+
+		// Skip special filesystems
+		if usage.Total == 0 {
+			continue
+		}
+
+		dm := models.DiskMetrics{
+			Device:      p.Device,
+			MountPoint:  p.Mountpoint,
+			Fstype:      p.Fstype,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+			UsedPercent: usage.UsedPercent,
+		}
+
+		// Add IO stats if available
+		if io, ok := ioStats[p.Device]; ok {
+			dm.ReadBytes = io.ReadBytes
+			dm.WriteBytes = io.WriteBytes
+		}
+
+		metrics = append(metrics, dm)
+	}
+
+	return metrics, nil
+}
+
+// NetworkInterfaces returns the counters of every interface, named and
+// marked hidden by the interface settings
+func NetworkInterfaces(settings models.NetworkInterfaceSettings) ([]models.NetworkMetrics, error) {
+	interfaces, err := net.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []models.NetworkMetrics
+	for _, iface := range interfaces {
+		// Skip loopback on non-Windows systems
+		if runtime.GOOS != "windows" && iface.Name == "lo" {
+			continue
+		}
+		// Skip virtual interfaces
+		if iface.BytesSent == 0 && iface.BytesRecv == 0 {
+			continue
+		}
+
+		metrics = append(metrics, models.NetworkMetrics{
+			Interface:   iface.Name,
+			Name:        settings.Names[iface.Name],
+			Hidden:      !InterfaceShown(settings, iface.Name),
+			BytesSent:   iface.BytesSent,
+			BytesRecv:   iface.BytesRecv,
+			PacketsSent: iface.PacketsSent,
+			PacketsRecv: iface.PacketsRecv,
+			ErrorsIn:    iface.Errin,
+			ErrorsOut:   iface.Errout,
+			DropIn:      iface.Dropin,
+			DropOut:     iface.Dropout,
+		})
+	}
+
+	return metrics, nil
+}
+
+// InterfaceShown reports whether network metrics show an interface
+func InterfaceShown(settings models.NetworkInterfaceSettings, iface string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, iface); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(settings.Include) == 0 || matches(settings.Include)) && !matches(settings.Exclude)
+}
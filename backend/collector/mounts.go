@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// networkFstypes are network filesystems, whose statfs can hang
+var networkFstypes = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true,
+	"fuse.sshfs": true, "9p": true, "glusterfs": true, "ceph": true,
+}
+
+// ErrStatfsTimeout is returned when statfs on a mount does not return in time
+var ErrStatfsTimeout = errors.New("statfs timed out")
+
+// statfsInflight tracks mounts with a statfs call still blocked, so a hung
+// mount does not accumulate goroutines
+var statfsInflight sync.Map
+
+// UsageWithTimeout runs disk.Usage with a timeout. A hung NFS mount can block
+// statfs indefinitely; the call is abandoned rather than waited on.
+func UsageWithTimeout(path string, timeout time.Duration) (*disk.UsageStat, error) {
+	if _, busy := statfsInflight.LoadOrStore(path, true); busy {
+		return nil, ErrStatfsTimeout
+	}
+
+	type result struct {
+		usage *disk.UsageStat
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		usage, err := disk.Usage(path)
+		statfsInflight.Delete(path)
+		done <- result{usage, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.usage, r.err
+	case <-time.After(timeout):
+		return nil, ErrStatfsTimeout
+	}
+}
+
+// IsNetworkFstype returns true for NFS, SMB and similar filesystems
+func IsNetworkFstype(fstype string) bool {
+	return networkFstypes[strings.ToLower(fstype)]
+}
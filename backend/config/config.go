@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -26,13 +27,176 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiryHours int
 
+	// Key for secrets stored encrypted in the database
+	EncryptionKey string
+
+	// Directory of server-side SSH private keys devices may reference by
+	// name; empty disables them
+	SSHKeyDir string
+
 	// CORS
 	FrontendURL string
+
+	// Firewall integration
+	FirewallProvider    string // auto, ufw, nftables, opnsense, pfsense, none
+	FirewallAPIURL      string
+	FirewallAPIKey      string
+	FirewallAPISecret   string
+	FirewallAPIInsecure bool
+
+	// Intrusion prevention (fail2ban / CrowdSec)
+	BanProvider     string // auto, fail2ban, crowdsec, none
+	CrowdSecLAPIURL string
+	CrowdSecAPIKey  string
+
+	// Trivy image scanning
+	TrivyEnabled       bool
+	TrivyPath          string
+	TrivyIntervalHours int
+
+	// Hours between registry checks for newer container images; 0 disables
+	ImageUpdateIntervalHours int
+
+	// Update checker
+	UpdateCheckEnabled bool
+	UpdateCheckRepo    string
+
+	// Network ping targets ("name=host" or "host", comma separated)
+	PingTargets string
+	PingCount   int
+
+	// Proxy for service checks (http, https, socks5), empty uses HTTP_PROXY
+	CheckProxyURL string
+
+	// Continuous latency probing
+	LatencyProbeInterval int // seconds, 0 disables
+	LatencyRetentionDays int
+
+	// Embedded throughput test server
+	ThroughputServerEnabled bool
+	ThroughputPort          int
+
+	// Daily state snapshots
+	SnapshotIntervalHours int // 0 disables
+	SnapshotRetentionDays int
+
+	// Database maintenance (hours between runs, 0 disables); soft-deleted
+	// rows are purged SoftDeleteRetentionDays after deletion
+	MaintenanceIntervalHours int
+	SoftDeleteRetentionDays  int
+
+	// Log error rate monitoring
+	LogSource       string // auto, journald, syslog, none
+	LogSpikeMinimum int    // errors per minute before a spike is reported
+
+	// Network mount health checks
+	MountCheckPaths   string // comma separated mount points that must be present
+	MountCheckTimeout int    // seconds
+
+	// Response cache for sessions, service status and summaries
+	CacheBackend string // none, memory or redis
+	RedisURL     string
+
+	// Stateless JWT mode: access tokens are checked by signature only and
+	// renewed with refresh tokens that last JWTExpiryHours
+	AuthStateless      bool
+	AccessTokenMinutes int
+
+	// Built-in TLS termination: a certificate pair, or Let's Encrypt for TLSDomains
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSDomains       string // comma separated
+	TLSCacheDir      string
+	HTTPRedirectPort string // plain HTTP listener redirecting to HTTPS; empty disables
+	HSTSMaxAge       int    // seconds, 0 disables
+
+	// Request body limits in bytes; uploads get the larger limit
+	MaxBodySize   int64
+	MaxUploadSize int64
+
+	// Active devices are pinged every DevicePingInterval seconds
+	DevicePingInterval int
+
+	// Devices with SNMP enabled are polled every SNMPPollInterval seconds; 0 disables
+	SNMPPollInterval int
+
+	// Devices unseen for StaleDeviceDays are listed in the weekly digest
+	StaleDeviceDays        int
+	StaleDeviceAutoArchive bool
+
+	// Device inventory
+	AttachmentDir        string
+	WarrantyReminderDays int
+
+	// DNS record checks; no resolvers means the system resolver
+	DNSResolvers     string
+	DNSCheckInterval int // seconds, 0 disables the background check
+
+	// Headless browser thumbnails of HTTP services
+	ScreenshotEnabled  bool
+	ScreenshotInterval int // minutes
+	ScreenshotDir      string
+	ChromeURL          string // remote DevTools websocket; empty launches a local Chrome
+
+	// Terminal session limits in minutes, 0 disables a limit
+	TerminalIdleTimeout int
+	TerminalMaxDuration int
+
+	// Energy-saving suggestions: device activity sampling and electricity price
+	ActivitySampleInterval int // minutes, 0 disables sampling
+	EnergyPricePerKWh      float64
+	EnergyCurrency         string
+
+	// Host metrics history kept in the database
+	MetricsRetentionDays int
+	// Devices with metrics collection enabled are read over SSH every
+	// RemoteMetricsInterval seconds; 0 disables it
+	RemoteMetricsInterval int
+	// Bearer token Prometheus scrapes GET /metrics with; empty disables it
+	PrometheusToken string
+
+	// External time series sink for host and container samples: "influxdb"
+	// (v2 write API at MetricsSinkURL) or "timescaledb" (Postgres DSN in
+	// MetricsSinkURL); empty disables it. MetricsSinkOnly stops storing the
+	// samples in the history tables.
+	MetricsSink              string
+	MetricsSinkURL           string
+	MetricsSinkToken         string
+	MetricsSinkOrg           string
+	MetricsSinkBucket        string
+	MetricsSinkRetentionDays int // 0 keeps samples forever
+	MetricsSinkOnly          bool
+
+	// Background service checks; 0 disables the scheduler
+	CheckWorkers int
+	// Consecutive failed checks before a service is reported down, and
+	// consecutive successful ones before it is reported up again. Services
+	// can override both.
+	CheckFailureThreshold  int
+	CheckRecoveryThreshold int
+
+	// Alert rule evaluation interval in seconds; 0 disables alerting
+	AlertEvalInterval int
+	// Seconds alerts are held back to be sent together, grouped by the
+	// device they depend on; 0 sends each alert on its own
+	AlertGroupWindow int
+
+	// Email notifications; an empty SMTPHost disables email
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTLS      string // starttls, tls or none
 }
 
 // Global config instance
 var AppConfig *Config
 
+// Version is the backend version, set at build time with
+// -ldflags "-X github.com/homelab/backend/config.Version=v1.2.3"
+var Version = "dev"
+
 // Load reads configuration from environment variables
 func Load() *Config {
 	// Load .env file if exists
@@ -60,6 +224,21 @@ func Load() *Config {
 		DBName:       getEnv("DB_NAME", ""),
 		JWTSecret:    jwtSecret,
 		FrontendURL:  getEnv("FRONTEND_URL", "http://localhost:3000"),
+
+		FirewallProvider:    getEnv("FIREWALL_PROVIDER", "auto"),
+		FirewallAPIURL:      getEnv("FIREWALL_API_URL", ""),
+		FirewallAPIKey:      getEnv("FIREWALL_API_KEY", ""),
+		FirewallAPISecret:   getEnv("FIREWALL_API_SECRET", ""),
+		FirewallAPIInsecure: getEnv("FIREWALL_API_INSECURE", "false") == "true",
+
+		BanProvider:     getEnv("BAN_PROVIDER", "auto"),
+		CrowdSecLAPIURL: getEnv("CROWDSEC_LAPI_URL", ""),
+		CrowdSecAPIKey:  getEnv("CROWDSEC_API_KEY", ""),
+
+		UpdateCheckEnabled: getEnv("UPDATE_CHECK_ENABLED", "true") == "true",
+		UpdateCheckRepo:    getEnv("UPDATE_CHECK_REPO", "SyafiqMSI/homelab-monitoring"),
+
+		PingTargets: getEnv("PING_TARGETS", "Google DNS=8.8.8.8"),
 	}
 
 	// Parse JWT expiry hours
@@ -69,10 +248,284 @@ func Load() *Config {
 	}
 	config.JWTExpiryHours = expiryHours
 
+	config.EncryptionKey = getEnv("ENCRYPTION_KEY", "")
+	if config.EncryptionKey == "" {
+		config.EncryptionKey = jwtSecret
+		log.Println("WARNING: ENCRYPTION_KEY is not set, deriving it from JWT_SECRET")
+	}
+	config.SSHKeyDir = getEnv("SSH_KEY_DIR", "")
+
+	config.TrivyEnabled = getEnv("TRIVY_ENABLED", "false") == "true"
+	config.TrivyPath = getEnv("TRIVY_PATH", "trivy")
+	trivyInterval, err := strconv.Atoi(getEnv("TRIVY_SCAN_INTERVAL_HOURS", "24"))
+	if err != nil || trivyInterval <= 0 {
+		trivyInterval = 24
+	}
+	config.TrivyIntervalHours = trivyInterval
+
+	imageUpdateInterval, err := strconv.Atoi(getEnv("IMAGE_UPDATE_INTERVAL_HOURS", "12"))
+	if err != nil || imageUpdateInterval < 0 {
+		imageUpdateInterval = 12
+	}
+	config.ImageUpdateIntervalHours = imageUpdateInterval
+
+	pingCount, err := strconv.Atoi(getEnv("PING_COUNT", "3"))
+	if err != nil || pingCount <= 0 {
+		pingCount = 3
+	}
+	config.PingCount = pingCount
+
+	config.CheckProxyURL = getEnv("CHECK_PROXY_URL", "")
+
+	probeInterval, err := strconv.Atoi(getEnv("LATENCY_PROBE_INTERVAL", "60"))
+	if err != nil || probeInterval < 0 {
+		probeInterval = 60
+	}
+	config.LatencyProbeInterval = probeInterval
+
+	latencyRetention, err := strconv.Atoi(getEnv("LATENCY_RETENTION_DAYS", "30"))
+	if err != nil || latencyRetention <= 0 {
+		latencyRetention = 30
+	}
+	config.LatencyRetentionDays = latencyRetention
+
+	config.ThroughputServerEnabled = getEnv("THROUGHPUT_SERVER_ENABLED", "false") == "true"
+	throughputPort, err := strconv.Atoi(getEnv("THROUGHPUT_PORT", "5201"))
+	if err != nil || throughputPort <= 0 {
+		throughputPort = 5201
+	}
+	config.ThroughputPort = throughputPort
+
+	snapshotInterval, err := strconv.Atoi(getEnv("SNAPSHOT_INTERVAL_HOURS", "24"))
+	if err != nil || snapshotInterval < 0 {
+		snapshotInterval = 24
+	}
+	config.SnapshotIntervalHours = snapshotInterval
+
+	snapshotRetention, err := strconv.Atoi(getEnv("SNAPSHOT_RETENTION_DAYS", "90"))
+	if err != nil || snapshotRetention <= 0 {
+		snapshotRetention = 90
+	}
+	config.SnapshotRetentionDays = snapshotRetention
+
+	maintenanceInterval, err := strconv.Atoi(getEnv("MAINTENANCE_INTERVAL_HOURS", "24"))
+	if err != nil || maintenanceInterval < 0 {
+		maintenanceInterval = 24
+	}
+	config.MaintenanceIntervalHours = maintenanceInterval
+
+	softDeleteRetention, err := strconv.Atoi(getEnv("SOFT_DELETE_RETENTION_DAYS", "30"))
+	if err != nil || softDeleteRetention <= 0 {
+		softDeleteRetention = 30
+	}
+	config.SoftDeleteRetentionDays = softDeleteRetention
+
+	config.LogSource = getEnv("LOG_SOURCE", "auto")
+	logSpike, err := strconv.Atoi(getEnv("LOG_SPIKE_MIN_ERRORS", "10"))
+	if err != nil || logSpike <= 0 {
+		logSpike = 10
+	}
+	config.LogSpikeMinimum = logSpike
+
+	config.MountCheckPaths = getEnv("MOUNT_CHECK_PATHS", "")
+	mountTimeout, err := strconv.Atoi(getEnv("MOUNT_CHECK_TIMEOUT", "5"))
+	if err != nil || mountTimeout <= 0 {
+		mountTimeout = 5
+	}
+	config.MountCheckTimeout = mountTimeout
+
+	config.CacheBackend = getEnv("CACHE_BACKEND", "none")
+	config.RedisURL = getEnv("REDIS_URL", "redis://localhost:6379/0")
+
+	config.AuthStateless = getEnv("AUTH_MODE", "session") == "stateless"
+	accessMinutes, err := strconv.Atoi(getEnv("ACCESS_TOKEN_MINUTES", "15"))
+	if err != nil || accessMinutes <= 0 {
+		accessMinutes = 15
+	}
+	config.AccessTokenMinutes = accessMinutes
+
+	config.TLSCertFile = getEnv("TLS_CERT_FILE", "")
+	config.TLSKeyFile = getEnv("TLS_KEY_FILE", "")
+	config.TLSDomains = getEnv("TLS_DOMAINS", "")
+	config.TLSCacheDir = getEnv("TLS_CACHE_DIR", "./certs")
+	config.HTTPRedirectPort = getEnv("HTTP_REDIRECT_PORT", "")
+	hstsMaxAge, err := strconv.Atoi(getEnv("HSTS_MAX_AGE", "31536000"))
+	if err != nil || hstsMaxAge < 0 {
+		hstsMaxAge = 31536000
+	}
+	config.HSTSMaxAge = hstsMaxAge
+
+	maxBodyMB, err := strconv.Atoi(getEnv("MAX_BODY_SIZE_MB", "1"))
+	if err != nil || maxBodyMB <= 0 {
+		maxBodyMB = 1
+	}
+	config.MaxBodySize = int64(maxBodyMB) << 20
+
+	maxUploadMB, err := strconv.Atoi(getEnv("MAX_UPLOAD_SIZE_MB", "10"))
+	if err != nil || maxUploadMB <= 0 {
+		maxUploadMB = 10
+	}
+	config.MaxUploadSize = int64(maxUploadMB) << 20
+
+	// Pings are cached for 30s and availability treats gaps over 5 minutes
+	// as unobserved, which bounds the interval
+	pingInterval, err := strconv.Atoi(getEnv("DEVICE_PING_INTERVAL", "60"))
+	if err != nil || pingInterval <= 0 {
+		pingInterval = 60
+	}
+	config.DevicePingInterval = min(max(pingInterval, 30), 300)
+
+	snmpInterval, err := strconv.Atoi(getEnv("SNMP_POLL_INTERVAL", "60"))
+	if err != nil || snmpInterval < 0 {
+		snmpInterval = 60
+	}
+	if snmpInterval > 0 {
+		// Counter rates need samples well inside a 32-bit counter's wrap
+		snmpInterval = min(max(snmpInterval, 10), 600)
+	}
+	config.SNMPPollInterval = snmpInterval
+
+	staleDays, err := strconv.Atoi(getEnv("STALE_DEVICE_DAYS", "30"))
+	if err != nil || staleDays <= 0 {
+		staleDays = 30
+	}
+	config.StaleDeviceDays = staleDays
+	config.StaleDeviceAutoArchive = getEnv("STALE_DEVICE_AUTO_ARCHIVE", "false") == "true"
+
+	config.AttachmentDir = getEnv("ATTACHMENT_DIR", "./data/attachments")
+	warrantyDays, err := strconv.Atoi(getEnv("WARRANTY_REMINDER_DAYS", "30"))
+	if err != nil || warrantyDays < 0 {
+		warrantyDays = 30
+	}
+	config.WarrantyReminderDays = warrantyDays
+
+	config.DNSResolvers = getEnv("DNS_RESOLVERS", "")
+	dnsInterval, err := strconv.Atoi(getEnv("DNS_CHECK_INTERVAL", "300"))
+	if err != nil || dnsInterval < 0 {
+		dnsInterval = 300
+	}
+	config.DNSCheckInterval = dnsInterval
+
+	config.ScreenshotEnabled = getEnv("SCREENSHOT_ENABLED", "false") == "true"
+	screenshotInterval, err := strconv.Atoi(getEnv("SCREENSHOT_INTERVAL", "60"))
+	if err != nil || screenshotInterval <= 0 {
+		screenshotInterval = 60
+	}
+	config.ScreenshotInterval = screenshotInterval
+	config.ScreenshotDir = getEnv("SCREENSHOT_DIR", "./data/screenshots")
+	config.ChromeURL = getEnv("CHROME_URL", "")
+
+	terminalIdle, err := strconv.Atoi(getEnv("TERMINAL_IDLE_TIMEOUT", "15"))
+	if err != nil || terminalIdle < 0 {
+		terminalIdle = 15
+	}
+	config.TerminalIdleTimeout = terminalIdle
+	terminalMax, err := strconv.Atoi(getEnv("TERMINAL_MAX_DURATION", "240"))
+	if err != nil || terminalMax < 0 {
+		terminalMax = 240
+	}
+	config.TerminalMaxDuration = terminalMax
+
+	activityInterval, err := strconv.Atoi(getEnv("ACTIVITY_SAMPLE_INTERVAL", "5"))
+	if err != nil || activityInterval < 0 {
+		activityInterval = 5
+	}
+	config.ActivitySampleInterval = activityInterval
+	energyPrice, err := strconv.ParseFloat(getEnv("ENERGY_PRICE_PER_KWH", "0.15"), 64)
+	if err != nil || energyPrice < 0 {
+		energyPrice = 0.15
+	}
+	config.EnergyPricePerKWh = energyPrice
+	config.EnergyCurrency = getEnv("ENERGY_CURRENCY", "USD")
+
+	metricsRetention, err := strconv.Atoi(getEnv("METRICS_RETENTION_DAYS", "30"))
+	if err != nil || metricsRetention <= 0 {
+		metricsRetention = 30
+	}
+	config.MetricsRetentionDays = metricsRetention
+
+	remoteMetricsInterval, err := strconv.Atoi(getEnv("REMOTE_METRICS_INTERVAL", "60"))
+	if err != nil || remoteMetricsInterval < 0 {
+		remoteMetricsInterval = 60
+	}
+	if remoteMetricsInterval > 0 {
+		remoteMetricsInterval = max(remoteMetricsInterval, 30)
+	}
+	config.RemoteMetricsInterval = remoteMetricsInterval
+	config.PrometheusToken = getEnv("PROMETHEUS_TOKEN", "")
+
+	config.MetricsSink = strings.ToLower(getEnv("METRICS_SINK", ""))
+	if config.MetricsSink != "influxdb" && config.MetricsSink != "timescaledb" {
+		config.MetricsSink = ""
+	}
+	config.MetricsSinkURL = getEnv("METRICS_SINK_URL", "")
+	config.MetricsSinkToken = getEnv("METRICS_SINK_TOKEN", "")
+	config.MetricsSinkOrg = getEnv("METRICS_SINK_ORG", "")
+	config.MetricsSinkBucket = getEnv("METRICS_SINK_BUCKET", "homelab")
+	sinkRetention, err := strconv.Atoi(getEnv("METRICS_SINK_RETENTION_DAYS", "0"))
+	if err != nil || sinkRetention < 0 {
+		sinkRetention = 0
+	}
+	config.MetricsSinkRetentionDays = sinkRetention
+	config.MetricsSinkOnly = config.MetricsSink != "" && getEnv("METRICS_SINK_ONLY", "false") == "true"
+
+	checkWorkers, err := strconv.Atoi(getEnv("CHECK_WORKERS", "10"))
+	if err != nil || checkWorkers < 0 {
+		checkWorkers = 10
+	}
+	config.CheckWorkers = checkWorkers
+
+	failureThreshold, err := strconv.Atoi(getEnv("CHECK_FAILURE_THRESHOLD", "3"))
+	if err != nil || failureThreshold < 1 {
+		failureThreshold = 3
+	}
+	config.CheckFailureThreshold = failureThreshold
+
+	recoveryThreshold, err := strconv.Atoi(getEnv("CHECK_RECOVERY_THRESHOLD", "2"))
+	if err != nil || recoveryThreshold < 1 {
+		recoveryThreshold = 2
+	}
+	config.CheckRecoveryThreshold = recoveryThreshold
+
+	alertInterval, err := strconv.Atoi(getEnv("ALERT_EVAL_INTERVAL", "30"))
+	if err != nil || alertInterval < 0 {
+		alertInterval = 30
+	}
+	config.AlertEvalInterval = alertInterval
+
+	groupWindow, err := strconv.Atoi(getEnv("ALERT_GROUP_WINDOW", "60"))
+	if err != nil || groupWindow < 0 {
+		groupWindow = 60
+	}
+	config.AlertGroupWindow = groupWindow
+
+	config.SMTPHost = getEnv("SMTP_HOST", "")
+	config.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	config.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	config.SMTPFrom = getEnv("SMTP_FROM", config.SMTPUsername)
+	config.SMTPTLS = strings.ToLower(getEnv("SMTP_TLS", "starttls"))
+	if config.SMTPTLS != "tls" && config.SMTPTLS != "none" {
+		config.SMTPTLS = "starttls"
+	}
+	defaultSMTPPort := "587"
+	if config.SMTPTLS == "tls" {
+		defaultSMTPPort = "465"
+	}
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", defaultSMTPPort))
+	if err != nil || smtpPort <= 0 {
+		smtpPort, _ = strconv.Atoi(defaultSMTPPort)
+	}
+	config.SMTPPort = smtpPort
+
 	AppConfig = config
 	return config
 }
 
+// TLSEnabled returns true if the server terminates TLS itself
+func (c *Config) TLSEnabled() bool {
+	return c.TLSDomains != "" || (c.TLSCertFile != "" && c.TLSKeyFile != "")
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
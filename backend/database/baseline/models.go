@@ -0,0 +1,749 @@
+// Package baseline freezes the schema of the baseline migration. These are
+// the models as they were when versioned migrations were introduced, kept
+// with their struct names so GORM derives the same table, index and
+// constraint names. Never change them; later schema changes are migrations.
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Models are the baseline tables in creation order
+var Models = []interface{}{
+	&User{},
+	&Session{},
+	&Device{},
+	&ServiceConfig{},
+	&Event{},
+	&ImageScan{},
+	&LatencySample{},
+	&ServiceCheck{},
+	&Snapshot{},
+	&ContainerBaseline{},
+	&KioskToken{},
+	&FeatureFlag{},
+	&FeatureFlagOverride{},
+	&IntegrationConfig{},
+	&ProbeAgent{},
+	&Tag{},
+	&AuditLog{},
+	&RemediationHook{},
+	&OOMKill{},
+	&AppSetting{},
+	&PasswordHistory{},
+	&DevicePing{},
+	&DeviceAttachment{},
+	&Subnet{},
+	&IPReservation{},
+	&DNSRecord{},
+	&ServiceScreenshot{},
+	&Note{},
+	&PowerSequence{},
+	&DeviceActivity{},
+	&ContainerUsage{},
+	&MetricsHistory{},
+	&DiskHistory{},
+	&AlertRule{},
+	&Alert{},
+	&DeployApp{},
+	&DeployBuild{},
+	&NotificationChannel{},
+	&InboundHook{},
+	&WidgetSource{},
+	&EmailPreference{},
+	&IngestKey{},
+	&Sensor{},
+	&SensorReading{},
+	&Room{},
+	&Rack{},
+	&RackPlacement{},
+	&TopologyLink{},
+	&HealthScoreSample{},
+	&DockerHost{},
+	&ImageUpdate{},
+	&UserSettings{},
+}
+
+type User struct {
+	ID                uint   `gorm:"primaryKey"`
+	Email             string `gorm:"size:255;uniqueIndex;not null"`
+	Username          string `gorm:"size:100;uniqueIndex;not null"`
+	Password          string `gorm:"size:255;not null"`
+	Name              string `gorm:"size:255"`
+	Avatar            string `gorm:"size:500"`
+	Role              string `gorm:"size:50;default:user"`
+	IsActive          bool   `gorm:"default:true"`
+	LastLogin         *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	PasswordChangedAt *time.Time
+	FailedLogins      int `gorm:"default:0"`
+	LockedUntil       *time.Time
+}
+
+type Session struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	User         User   `gorm:"foreignKey:UserID"`
+	Token        string `gorm:"size:500;uniqueIndex;not null"`
+	RefreshToken string `gorm:"size:500;index"`
+	UserAgent    string `gorm:"size:500"`
+	IPAddress    string `gorm:"size:50"`
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+type Device struct {
+	ID             uint   `gorm:"primaryKey"`
+	UserID         uint   `gorm:"not null;index"`
+	Name           string `gorm:"size:255;not null"`
+	IP             string `gorm:"size:50;not null"`
+	MAC            string `gorm:"size:20"`
+	Type           string `gorm:"size:50"`
+	Brand          string `gorm:"size:100"`
+	Model          string `gorm:"size:100"`
+	Icon           string `gorm:"size:100"`
+	Location       string `gorm:"size:255"`
+	Description    string `gorm:"size:500"`
+	IsOnline       bool   `gorm:"default:false"`
+	LastSeen       *time.Time
+	IsActive       bool `gorm:"default:true"`
+	ArchivedAt     *time.Time
+	Tags           []Tag  `gorm:"many2many:device_tags"`
+	SSHUser        string `gorm:"size:100"`
+	SSHPassword    string `gorm:"size:255"`
+	SSHPort        int    `gorm:"default:22"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	SerialNumber   string         `gorm:"size:100"`
+	PurchaseDate   *time.Time
+	WarrantyExpiry *time.Time `gorm:"index"`
+	Price          float64
+	Notes          string `gorm:"type:text"`
+	WarrantyNotice string `gorm:"size:20"`
+	SudoPassword   string `gorm:"size:500"`
+	PowerMethod    string `gorm:"size:10"`
+	WinRMPort      int
+	WinRMHTTPS     bool   `gorm:"default:false"`
+	WinRMInsecure  bool   `gorm:"default:false"`
+	WinRMAuth      string `gorm:"size:10"`
+	IPMIHost       string `gorm:"size:255"`
+	IPMIUser       string `gorm:"size:100"`
+	IPMIPassword   string `gorm:"size:500"`
+	PowerWatts     float64
+	Criticality    string `gorm:"size:20;default:medium"`
+}
+
+type ServiceConfig struct {
+	ID                  uint   `gorm:"primaryKey"`
+	UserID              uint   `gorm:"not null;index"`
+	DeviceID            *uint  `gorm:"index"`
+	Name                string `gorm:"size:255;not null"`
+	URL                 string `gorm:"size:500;not null"`
+	Method              string `gorm:"size:10;default:GET"`
+	Port                int
+	Icon                string `gorm:"size:100"`
+	Category            string `gorm:"size:100"`
+	Description         string `gorm:"size:500"`
+	Tags                []Tag  `gorm:"many2many:service_tags"`
+	LegacyTags          string `gorm:"column:tags;size:500"`
+	CheckInterval       int    `gorm:"default:60"`
+	Timeout             int    `gorm:"default:10"`
+	ExpectedCode        int    `gorm:"default:200"`
+	IsActive            bool   `gorm:"default:true"`
+	BadgePublic         bool   `gorm:"default:false"`
+	BadgeToken          string `gorm:"size:64"`
+	Locations           string `gorm:"size:500"`
+	SkipLocal           bool   `gorm:"default:false"`
+	MinFailingLocations int    `gorm:"default:1"`
+	FailureThreshold    int    `gorm:"default:0"`
+	RecoveryThreshold   int    `gorm:"default:0"`
+	TLSSkipVerify       bool   `gorm:"default:false"`
+	TLSServerName       string `gorm:"size:255"`
+	CABundle            string `gorm:"type:text"`
+	ClientCert          string `gorm:"type:text"`
+	ClientKey           string `gorm:"type:text"`
+	ProxyURL            string `gorm:"size:500"`
+	Impact              string `gorm:"size:20;default:medium"`
+	ImpactNote          string `gorm:"size:500"`
+	Container           string `gorm:"size:255"`
+	AutoRestart         bool   `gorm:"default:false"`
+	DBPassword          string `gorm:"type:text"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
+}
+
+type Event struct {
+	ID        uint      `gorm:"primaryKey"`
+	Type      string    `gorm:"size:100;index"`
+	Severity  string    `gorm:"size:20;index"`
+	Source    string    `gorm:"size:100;index"`
+	Title     string    `gorm:"size:255"`
+	Message   string    `gorm:"size:1000"`
+	Details   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+type ImageScan struct {
+	ID        uint   `gorm:"primaryKey"`
+	Image     string `gorm:"size:255;uniqueIndex;not null"`
+	Critical  int
+	High      int
+	Medium    int
+	Low       int
+	Unknown   int
+	Findings  string `gorm:"type:text"`
+	Error     string `gorm:"size:1000"`
+	ScannedAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type LatencySample struct {
+	ID         uint   `gorm:"primaryKey"`
+	Target     string `gorm:"size:100;index:idx_latency_target_time"`
+	Host       string `gorm:"size:255"`
+	Latency    float64
+	MinLatency float64
+	MaxLatency float64
+	PacketLoss float64
+	CreatedAt  time.Time `gorm:"index:idx_latency_target_time"`
+}
+
+type ServiceCheck struct {
+	ID           uint   `gorm:"primaryKey"`
+	ServiceID    uint   `gorm:"not null;index:idx_service_checks_service_time"`
+	Status       string `gorm:"size:20"`
+	StatusCode   int
+	ResponseTime int64
+	DNSLookup    int64
+	TCPConnect   int64
+	TLSHandshake int64
+	TTFB         int64
+	Error        string    `gorm:"size:500"`
+	Location     string    `gorm:"size:100;default:'';index"`
+	CheckedAt    time.Time `gorm:"index:idx_service_checks_service_time"`
+}
+
+type Snapshot struct {
+	ID         uint `gorm:"primaryKey"`
+	Devices    int
+	Services   int
+	Containers int
+	Data       string    `gorm:"size:16777215"`
+	TakenAt    time.Time `gorm:"index"`
+}
+
+type ContainerBaseline struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"size:255;uniqueIndex;not null"`
+	ContainerID string `gorm:"size:64"`
+	ConfigHash  string `gorm:"size:64"`
+	Config      string `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type KioskToken struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:255;not null"`
+	TokenHash  string `gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string `gorm:"size:12"`
+	Enabled    bool   `gorm:"default:true"`
+	CreatedBy  uint
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type FeatureFlag struct {
+	ID          uint   `gorm:"primaryKey"`
+	Key         string `gorm:"column:flag_key;size:100;uniqueIndex;not null"`
+	Description string `gorm:"size:500"`
+	Enabled     bool   `gorm:"default:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type FeatureFlagOverride struct {
+	ID        uint   `gorm:"primaryKey"`
+	FlagKey   string `gorm:"size:100;uniqueIndex:idx_flag_override_user;not null"`
+	UserID    uint   `gorm:"uniqueIndex:idx_flag_override_user;not null"`
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type IntegrationConfig struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;uniqueIndex;not null"`
+	Enabled   bool   `gorm:"default:false"`
+	Config    string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ProbeAgent struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:255;not null"`
+	Location   string `gorm:"size:100;not null;index"`
+	TokenHash  string `gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string `gorm:"size:12"`
+	Enabled    bool   `gorm:"default:true"`
+	Version    string `gorm:"size:50"`
+	LastSeenAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Tag struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string `gorm:"size:100;not null;uniqueIndex:idx_tags_user_name"`
+	Color     string `gorm:"size:20"`
+	CreatedAt time.Time
+}
+
+type AuditLog struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"index"`
+	Actor      string `gorm:"size:255"`
+	Action     string `gorm:"size:100;index"`
+	TargetType string `gorm:"size:50;index"`
+	TargetID   string `gorm:"size:255"`
+	Success    bool
+	Details    string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+type RemediationHook struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	Name        string `gorm:"size:255;not null"`
+	Trigger     string `gorm:"column:trigger_type;size:50;not null;index"`
+	TargetID    uint   `gorm:"not null;index"`
+	Action      string `gorm:"size:50;not null"`
+	Param       string `gorm:"size:1000"`
+	Cooldown    int    `gorm:"default:15"`
+	Enabled     bool   `gorm:"default:true"`
+	LastRunAt   *time.Time
+	LastSuccess bool
+	LastResult  string `gorm:"size:1000"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type OOMKill struct {
+	ID          uint   `gorm:"primaryKey"`
+	Scope       string `gorm:"size:20;index"`
+	Process     string `gorm:"size:255"`
+	PID         int
+	Container   string    `gorm:"size:255;index"`
+	ContainerID string    `gorm:"size:64;index"`
+	Cgroup      string    `gorm:"size:500"`
+	Message     string    `gorm:"size:1000"`
+	KilledAt    time.Time `gorm:"index"`
+}
+
+type AppSetting struct {
+	Key       string `gorm:"column:setting_key;size:100;primaryKey"`
+	Value     string `gorm:"type:text"`
+	UpdatedAt time.Time
+}
+
+type PasswordHistory struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Hash      string `gorm:"size:255;not null"`
+	CreatedAt time.Time
+}
+
+type DevicePing struct {
+	ID        uint `gorm:"primaryKey"`
+	DeviceID  uint `gorm:"not null;index:idx_device_ping_time"`
+	Online    bool
+	CheckedAt time.Time `gorm:"index:idx_device_ping_time"`
+}
+
+type DeviceAttachment struct {
+	ID          uint   `gorm:"primaryKey"`
+	DeviceID    uint   `gorm:"not null;index"`
+	UserID      uint   `gorm:"not null;index"`
+	Filename    string `gorm:"size:255;not null"`
+	ContentType string `gorm:"size:100"`
+	Size        int64
+	Path        string `gorm:"size:500;not null"`
+	CreatedAt   time.Time
+}
+
+type Subnet struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	Name         string `gorm:"size:100;not null"`
+	CIDR         string `gorm:"size:50;not null"`
+	Gateway      string `gorm:"size:50"`
+	VLAN         int
+	Description  string          `gorm:"size:500"`
+	Reservations []IPReservation `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type IPReservation struct {
+	ID          uint   `gorm:"primaryKey"`
+	SubnetID    uint   `gorm:"not null;index"`
+	Start       string `gorm:"size:50;not null"`
+	End         string `gorm:"size:50;not null"`
+	Description string `gorm:"size:255"`
+}
+
+type DNSRecord struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Name      string `gorm:"size:255;not null"`
+	Type      string `gorm:"size:10;default:A"`
+	Expected  string `gorm:"size:500"`
+	ServiceID *uint  `gorm:"index"`
+	Status    string `gorm:"size:20;default:unknown"`
+	RawResult string `gorm:"column:results;type:text"`
+	LastCheck *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ServiceScreenshot struct {
+	ID         uint   `gorm:"primaryKey"`
+	ServiceID  uint   `gorm:"uniqueIndex;not null"`
+	Path       string `gorm:"size:500"`
+	CapturedAt *time.Time
+	Error      string `gorm:"size:500"`
+	UpdatedAt  time.Time
+}
+
+type Note struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	ServiceID  *uint     `gorm:"index"`
+	DeviceID   *uint     `gorm:"index"`
+	Body       string    `gorm:"type:text;not null"`
+	OccurredAt time.Time `gorm:"index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type PowerSequence struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        uint   `gorm:"not null;index"`
+	Name          string `gorm:"size:255;not null"`
+	Description   string `gorm:"size:500"`
+	RawSteps      string `gorm:"column:steps;type:text"`
+	LastDirection string `gorm:"size:20"`
+	LastStatus    string `gorm:"size:20"`
+	LastRunAt     *time.Time
+	RawLastLog    string `gorm:"column:last_log;type:text"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type DeviceActivity struct {
+	ID           uint `gorm:"primaryKey"`
+	DeviceID     uint `gorm:"not null;index:idx_device_activity_time"`
+	Online       bool
+	Connections  int
+	NetBytes     uint64
+	ContainerCPU float64
+	SampledAt    time.Time `gorm:"index:idx_device_activity_time"`
+}
+
+type ContainerUsage struct {
+	ID          uint   `gorm:"primaryKey"`
+	Container   string `gorm:"size:255;not null;index:idx_container_usage_time"`
+	CPUPercent  float64
+	MemoryUsage int64
+	SampledAt   time.Time `gorm:"index:idx_container_usage_time"`
+}
+
+type MetricsHistory struct {
+	ID          uint      `gorm:"primaryKey"`
+	Timestamp   time.Time `gorm:"column:sampled_at;index"`
+	CPUUsage    float64
+	MemoryUsage float64
+	DiskUsage   float64
+	NetworkIn   uint64
+	NetworkOut  uint64
+}
+
+type DiskHistory struct {
+	ID          uint      `gorm:"primaryKey"`
+	Timestamp   time.Time `gorm:"column:sampled_at;index:idx_disk_history_mount_time"`
+	MountPoint  string    `gorm:"size:255;index:idx_disk_history_mount_time"`
+	Used        uint64
+	Free        uint64
+	UsedPercent float64
+}
+
+type AlertRule struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	Name        string `gorm:"size:255;not null"`
+	Target      string `gorm:"size:20;not null;index"`
+	TargetID    uint
+	TargetName  string `gorm:"size:255"`
+	Metric      string `gorm:"size:50;not null"`
+	Condition   string `gorm:"column:comparison;size:20;not null"`
+	Threshold   float64
+	Duration    int
+	Severity    string `gorm:"size:20;default:'warning'"`
+	Enabled     bool   `gorm:"default:true"`
+	EmailNotify bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Alert struct {
+	ID             uint   `gorm:"primaryKey"`
+	RuleID         uint   `gorm:"not null;index"`
+	UserID         uint   `gorm:"not null;index"`
+	Name           string `gorm:"size:255"`
+	Target         string `gorm:"size:20"`
+	TargetID       uint
+	TargetName     string `gorm:"size:255"`
+	Metric         string `gorm:"size:50"`
+	Severity       string `gorm:"size:20;index"`
+	State          string `gorm:"size:20;index"`
+	Value          float64
+	Threshold      float64
+	Message        string    `gorm:"size:1000"`
+	StartedAt      time.Time `gorm:"index"`
+	AcknowledgedAt *time.Time
+	AcknowledgedBy string `gorm:"size:255"`
+	ResolvedAt     *time.Time
+}
+
+type DeployApp struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        uint   `gorm:"not null;index"`
+	Name          string `gorm:"size:255;not null"`
+	RepoURL       string `gorm:"size:500;not null"`
+	Branch        string `gorm:"size:255"`
+	Dockerfile    string `gorm:"size:255"`
+	ContextDir    string `gorm:"size:255"`
+	Image         string `gorm:"size:255"`
+	Container     string `gorm:"size:255;not null"`
+	RawPorts      string `gorm:"column:ports;type:text"`
+	RawEnv        string `gorm:"column:env;type:text"`
+	WebhookSecret string `gorm:"size:500"`
+	LastBuildID   *uint
+	LastStatus    string `gorm:"size:20"`
+	LastBuildAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type DeployBuild struct {
+	ID         uint   `gorm:"primaryKey"`
+	AppID      uint   `gorm:"not null;index"`
+	Trigger    string `gorm:"column:trigger_type;size:20"`
+	Status     string `gorm:"size:20;index"`
+	Commit     string `gorm:"size:64"`
+	ImageID    string `gorm:"size:100"`
+	Error      string `gorm:"size:1000"`
+	Log        string `gorm:"type:text"`
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+type NotificationChannel struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	Name         string `gorm:"size:255;not null"`
+	Type         string `gorm:"size:20;not null"`
+	Enabled      bool   `gorm:"default:true"`
+	MinSeverity  string `gorm:"size:20;default:'info'"`
+	SendResolved bool   `gorm:"default:true"`
+	RawSettings  string `gorm:"column:settings;type:text"`
+	Template     string `gorm:"type:text"`
+	LastSentAt   *time.Time
+	LastError    string `gorm:"size:1000"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type InboundHook struct {
+	ID               uint   `gorm:"primaryKey"`
+	UserID           uint   `gorm:"not null;index"`
+	Name             string `gorm:"size:255;not null"`
+	Preset           string `gorm:"size:20;not null"`
+	Enabled          bool   `gorm:"default:true"`
+	CreateAlerts     bool
+	Secret           string `gorm:"size:500"`
+	ItemsPath        string `gorm:"size:255"`
+	TitleTemplate    string `gorm:"type:text"`
+	MessageTemplate  string `gorm:"type:text"`
+	SeverityTemplate string `gorm:"type:text"`
+	StatusTemplate   string `gorm:"type:text"`
+	KeyTemplate      string `gorm:"type:text"`
+	LastReceivedAt   *time.Time
+	LastError        string `gorm:"size:1000"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type WidgetSource struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        uint   `gorm:"not null;index"`
+	Name          string `gorm:"size:255;not null"`
+	URL           string `gorm:"size:1000;not null"`
+	Method        string `gorm:"size:10"`
+	Body          string `gorm:"type:text"`
+	AuthType      string `gorm:"size:20"`
+	AuthHeader    string `gorm:"size:255"`
+	Username      string `gorm:"size:255"`
+	Secret        string `gorm:"size:1000"`
+	SkipTLSVerify bool
+	CacheSeconds  int
+	RawExtract    string `gorm:"column:extract;type:text"`
+	LastFetchedAt *time.Time
+	LastError     string `gorm:"size:1000"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type EmailPreference struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;uniqueIndex"`
+	Address      string `gorm:"size:255"`
+	Digest       string `gorm:"size:20;default:'off'"`
+	DigestHour   int
+	LastDigestAt *time.Time
+	UpdatedAt    time.Time
+}
+
+type IngestKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"size:255;not null"`
+	KeyHash    string `gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string `gorm:"size:12"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type Sensor struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;uniqueIndex:idx_sensor_key"`
+	Device     string `gorm:"size:100;not null;uniqueIndex:idx_sensor_key"`
+	Metric     string `gorm:"size:100;not null;uniqueIndex:idx_sensor_key"`
+	Name       string `gorm:"size:255"`
+	Unit       string `gorm:"size:20"`
+	Value      float64
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+}
+
+type SensorReading struct {
+	ID        uint `gorm:"primaryKey"`
+	SensorID  uint `gorm:"not null;index:idx_sensor_reading_time"`
+	Value     float64
+	Timestamp time.Time `gorm:"column:sampled_at;index:idx_sensor_reading_time"`
+}
+
+type Room struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	Name        string `gorm:"size:100;not null"`
+	Description string `gorm:"size:500"`
+	Width       int    `gorm:"default:10"`
+	Depth       int    `gorm:"default:10"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Rack struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	RoomID      *uint  `gorm:"index"`
+	Name        string `gorm:"size:100;not null"`
+	Description string `gorm:"size:500"`
+	Units       int    `gorm:"default:42"`
+	PosX        int
+	PosY        int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type RackPlacement struct {
+	ID        uint   `gorm:"primaryKey"`
+	RackID    uint   `gorm:"not null;index"`
+	DeviceID  uint   `gorm:"not null;uniqueIndex"`
+	Position  int    `gorm:"not null"`
+	Height    int    `gorm:"default:1"`
+	Face      string `gorm:"size:10;default:'front'"`
+	FullDepth bool   `gorm:"default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type TopologyLink struct {
+	ID             uint   `gorm:"primaryKey"`
+	UserID         uint   `gorm:"not null;index"`
+	Source         string `gorm:"size:10;not null;default:'manual'"`
+	DeviceID       uint   `gorm:"not null;index"`
+	Port           string `gorm:"size:100"`
+	TargetDeviceID *uint  `gorm:"index"`
+	TargetPort     string `gorm:"size:100"`
+	TargetName     string `gorm:"size:255"`
+	TargetMAC      string `gorm:"size:20"`
+	Label          string `gorm:"size:100"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type HealthScoreSample struct {
+	ID        uint      `gorm:"primaryKey"`
+	Timestamp time.Time `gorm:"column:sampled_at;index"`
+	Score     float64
+	Services  float64
+	Devices   float64
+	Disk      float64
+	Updates   float64
+}
+
+type DockerHost struct {
+	ID            uint   `gorm:"primaryKey"`
+	Name          string `gorm:"size:100;not null;uniqueIndex"`
+	Endpoint      string `gorm:"size:255;not null"`
+	TLSCACert     string `gorm:"type:text"`
+	TLSCert       string `gorm:"type:text"`
+	TLSKey        string `gorm:"type:text"`
+	TLSSkipVerify bool   `gorm:"default:false"`
+	SSHPassword   string `gorm:"size:500"`
+	SSHKey        string `gorm:"type:text"`
+	SocketPath    string `gorm:"size:255"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type ImageUpdate struct {
+	ID              uint   `gorm:"primaryKey"`
+	Image           string `gorm:"size:255;uniqueIndex;not null"`
+	LocalDigest     string `gorm:"size:100"`
+	RemoteDigest    string `gorm:"size:100"`
+	UpdateAvailable bool
+	Error           string `gorm:"size:1000"`
+	CheckedAt       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type UserSettings struct {
+	UserID                     uint   `gorm:"primaryKey;autoIncrement:false"`
+	RawNotificationPreferences string `gorm:"column:notification_preferences;type:text"`
+	UpdatedAt                  time.Time
+}
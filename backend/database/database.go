@@ -2,8 +2,10 @@ package database
 
 import (
 	"log"
+	"strings"
 
 	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database/baseline"
 	"github.com/homelab/backend/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -44,22 +46,60 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 	return DB, nil
 }
 
-// Migrate runs database migrations
+// Migrate brings the schema up to date by applying every pending migration
 func Migrate() error {
 	log.Println("Running database migrations...")
 
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Session{},
-		&models.Device{},
-		&models.ServiceConfig{},
-	)
-
+	applied, err := MigrateUp(0)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Database migrations completed")
+	log.Printf("Database migrations completed (%d applied, schema version %d)", applied, LatestVersion())
+	return nil
+}
+
+// migrateLegacyTags moves tags from the old ServiceConfig string column
+// into the tags and service_tags tables
+func migrateLegacyTags(db *gorm.DB) error {
+	var services []baseline.ServiceConfig
+	if err := db.Where("tags IS NOT NULL AND tags <> ?", "").Find(&services).Error; err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	log.Printf("Migrating tags of %d services...", len(services))
+	for _, svc := range services {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			tags := make([]baseline.Tag, 0)
+			seen := make(map[string]bool)
+			for _, name := range models.ParseTagList(svc.LegacyTags) {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if name == "" || len(name) > 100 || seen[name] {
+					continue
+				}
+				seen[name] = true
+
+				tag := baseline.Tag{UserID: svc.UserID, Name: name}
+				if err := tx.Where("user_id = ? AND name = ?", svc.UserID, name).FirstOrCreate(&tag).Error; err != nil {
+					return err
+				}
+				tags = append(tags, tag)
+			}
+
+			if len(tags) > 0 {
+				if err := tx.Model(&svc).Association("Tags").Append(tags); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&svc).UpdateColumn("tags", "").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
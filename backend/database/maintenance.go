@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// VacuumResult reports what Vacuum removed and the database size around it
+type VacuumResult struct {
+	ExpiredSessions int64
+	SizeBefore      int64 // bytes, 0 when the database does not report it
+	SizeAfter       int64
+}
+
+// Vacuum deletes expired sessions and has the database reclaim the space of
+// deleted rows and refresh its planner statistics: VACUUM ANALYZE on
+// PostgreSQL, OPTIMIZE TABLE on MySQL
+func Vacuum() (*VacuumResult, error) {
+	result := &VacuumResult{SizeBefore: databaseSize()}
+
+	deleted := DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+	if deleted.Error != nil {
+		return nil, deleted.Error
+	}
+	result.ExpiredSessions = deleted.RowsAffected
+
+	switch DB.Dialector.Name() {
+	case "postgres":
+		log.Println("  → VACUUM ANALYZE")
+		if err := DB.Exec("VACUUM ANALYZE").Error; err != nil {
+			return nil, err
+		}
+	case "mysql":
+		tables, err := DB.Migrator().GetTables()
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			log.Printf("  → OPTIMIZE TABLE %s", table)
+			if err := DB.Exec(fmt.Sprintf("OPTIMIZE TABLE `%s`", table)).Error; err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("vacuum is not supported on %s", DB.Dialector.Name())
+	}
+
+	result.SizeAfter = databaseSize()
+	return result, nil
+}
+
+// databaseSize returns the size of the current database in bytes, or 0
+func databaseSize() int64 {
+	var size int64
+	switch DB.Dialector.Name() {
+	case "postgres":
+		DB.Raw("SELECT pg_database_size(current_database())").Scan(&size)
+	case "mysql":
+		DB.Raw("SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.tables WHERE table_schema = DATABASE()").Scan(&size)
+	}
+	return size
+}
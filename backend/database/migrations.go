@@ -0,0 +1,600 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/homelab/backend/database/baseline"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change. Migrations are applied in version
+// order and Down must undo exactly what Up did.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName keeps the table name independent of the struct name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState is a migration with when it was applied, nil if pending
+type MigrationState struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool // applied by a newer build
+}
+
+// migrations is the ordered list of schema changes. Never edit or renumber
+// a released migration; add a new one instead. Migrations creating tables
+// declare the model as it was then, so later model changes can't leak into
+// them.
+var migrations = []Migration{
+	{
+		// The schema as AutoMigrate created it before versioned migrations,
+		// frozen in package baseline. AutoMigrate only adds what is missing,
+		// so existing databases adopt it without changes.
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(baseline.Models...); err != nil {
+				return err
+			}
+			return migrateLegacyTags(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := []interface{}{"service_tags", "device_tags"}
+			for i := len(baseline.Models) - 1; i >= 0; i-- {
+				tables = append(tables, baseline.Models[i])
+			}
+			return tx.Migrator().DropTable(tables...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "device_power_commands",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &models.Device{}, "PowerOS", "ShutdownCommand", "RebootCommand")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &models.Device{}, "PowerOS", "ShutdownCommand", "RebootCommand")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "device_ssh_keys",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &models.Device{}, "SSHKey", "SSHKeyPassphrase", "SSHKeyPath"); err != nil {
+				return err
+			}
+			// Encrypted passwords outgrow the old 255 characters
+			return tx.Migrator().AlterColumn(&models.Device{}, "SSHPassword")
+		},
+		Down: func(tx *gorm.DB) error {
+			// ssh_password stays wide: encrypted values would not fit back
+			return dropColumns(tx, &models.Device{}, "SSHKey", "SSHKeyPassphrase", "SSHKeyPath")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "device_status_history",
+		Up: func(tx *gorm.DB) error {
+			type DeviceStatusChange struct {
+				ID        uint `gorm:"primaryKey"`
+				DeviceID  uint `gorm:"not null;index:idx_device_status_time"`
+				Online    bool
+				ChangedAt time.Time `gorm:"index:idx_device_status_time"`
+			}
+			// The model named its table through TableName
+			return tx.Table("device_status_history").AutoMigrate(&DeviceStatusChange{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("device_status_history")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "maintenance_runs",
+		Up: func(tx *gorm.DB) error {
+			type MaintenanceRun struct {
+				ID              uint      `gorm:"primaryKey"`
+				Trigger         string    `gorm:"size:20"`
+				StartedAt       time.Time `gorm:"index"`
+				FinishedAt      *time.Time
+				SoftDeleted     int64
+				ExpiredSessions int64
+				CheckResults    int64
+				SizeBefore      int64
+				SizeAfter       int64
+				Error           string `gorm:"size:1000"`
+			}
+			return tx.AutoMigrate(&MaintenanceRun{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("maintenance_runs")
+		},
+	},
+	{
+		Version: 6,
+		Name:    "history_search_indexes",
+		Up:      createHistorySearchIndexes,
+		Down:    dropHistorySearchIndexes,
+	},
+	{
+		Version: 7,
+		Name:    "snmp_configs",
+		Up: func(tx *gorm.DB) error {
+			type SNMPConfig struct {
+				ID           uint   `gorm:"primaryKey"`
+				DeviceID     uint   `gorm:"not null;uniqueIndex"`
+				Enabled      bool   `gorm:"default:true"`
+				Version      string `gorm:"size:3;not null"`
+				Port         int
+				Community    string `gorm:"size:500"`
+				User         string `gorm:"size:100"`
+				AuthProtocol string `gorm:"size:3"`
+				AuthPassword string `gorm:"size:500"`
+				PrivProtocol string `gorm:"size:3"`
+				PrivPassword string `gorm:"size:500"`
+				CreatedAt    time.Time
+				UpdatedAt    time.Time
+			}
+			return tx.AutoMigrate(&SNMPConfig{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("snmp_configs")
+		},
+	},
+	{
+		// Metrics history of devices collected over SSH
+		Version: 8,
+		Name:    "remote_metrics",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &models.Device{}, "CollectMetrics"); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &models.MetricsHistory{}, "DeviceID"); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &models.DiskHistory{}, "DeviceID"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&models.MetricsHistory{}, "idx_metrics_history_device_time") {
+				if err := tx.Migrator().CreateIndex(&models.MetricsHistory{}, "idx_metrics_history_device_time"); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex(&models.DiskHistory{}, "DeviceID") {
+				return tx.Migrator().CreateIndex(&models.DiskHistory{}, "DeviceID")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// Remote samples would read as the backend's own once the column
+			// is gone. Dropping the columns drops their indexes.
+			for _, model := range []interface{}{&models.MetricsHistory{}, &models.DiskHistory{}} {
+				if tx.Migrator().HasColumn(model, "DeviceID") {
+					if err := tx.Where("device_id <> 0").Delete(model).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if err := dropColumns(tx, &models.DiskHistory{}, "DeviceID"); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &models.MetricsHistory{}, "DeviceID"); err != nil {
+				return err
+			}
+			return dropColumns(tx, &models.Device{}, "CollectMetrics")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "metrics_agents",
+		Up: func(tx *gorm.DB) error {
+			type MetricsAgent struct {
+				ID         uint   `gorm:"primaryKey"`
+				DeviceID   uint   `gorm:"not null;uniqueIndex"`
+				TokenHash  string `gorm:"size:64;uniqueIndex;not null"`
+				Prefix     string `gorm:"size:12"`
+				Hostname   string `gorm:"size:255"`
+				Platform   string `gorm:"size:100"`
+				Version    string `gorm:"size:50"`
+				LastSeenAt *time.Time
+				CreatedAt  time.Time
+				UpdatedAt  time.Time
+			}
+			return tx.AutoMigrate(&MetricsAgent{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("metrics_agents")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "latency_sample_owner",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &models.LatencySample{}, "UserID"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&models.LatencySample{}, "UserID") {
+				return tx.Migrator().CreateIndex(&models.LatencySample{}, "UserID")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// Samples of a user's targets would read as everyone's once the
+			// column is gone
+			if tx.Migrator().HasColumn(&models.LatencySample{}, "UserID") {
+				if err := tx.Where("user_id <> 0").Delete(&models.LatencySample{}).Error; err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &models.LatencySample{}, "UserID")
+		},
+	},
+	{
+		Version: 11,
+		Name:    "alert_rule_tags",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &models.AlertRule{}, "TagID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.AlertRule{}, "TagID") {
+				if err := tx.Where("target = ?", models.AlertTargetTag).Delete(&models.AlertRule{}).Error; err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &models.AlertRule{}, "TagID")
+		},
+	},
+	{
+		Version: 12,
+		Name:    "remediation_alert_rules",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &models.RemediationHook{}, "RuleID"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&models.RemediationHook{}, "RuleID") {
+				return tx.Migrator().CreateIndex(&models.RemediationHook{}, "RuleID")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.RemediationHook{}, "RuleID") {
+				if err := tx.Where("trigger_type = ?", models.TriggerAlert).Delete(&models.RemediationHook{}).Error; err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &models.RemediationHook{}, "RuleID")
+		},
+	},
+	{
+		Version: 13,
+		Name:    "device_ssh_host_keys",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &models.Device{}, "SSHHostKey", "SSHHostKeyFingerprint")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &models.Device{}, "SSHHostKey", "SSHHostKeyFingerprint")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "docker_host_ssh_host_keys",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &models.DockerHost{}, "SSHHostKey", "SSHHostKeyFingerprint")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &models.DockerHost{}, "SSHHostKey", "SSHHostKeyFingerprint")
+		},
+	},
+	{
+		Version: 15,
+		Name:    "event_owner",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &models.Event{}, "UserID"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&models.Event{}, "UserID") {
+				return tx.Migrator().CreateIndex(&models.Event{}, "UserID")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// A user's events would read as lab-wide once the column is gone
+			if tx.Migrator().HasColumn(&models.Event{}, "UserID") {
+				if err := tx.Where("user_id <> 0").Delete(&models.Event{}).Error; err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &models.Event{}, "UserID")
+		},
+	},
+}
+
+// addColumns adds a model's fields as columns. Databases that AutoMigrate
+// created before versioned migrations may already have them, so columns that
+// exist are skipped.
+func addColumns(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropColumns drops the columns of a model's fields that exist
+func dropColumns(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if !tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().DropColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrSchemaTooNew is returned when the database has migrations this build
+// does not know, i.e. a newer build migrated it
+var ErrSchemaTooNew = errors.New("database schema is newer than this build")
+
+// migrationLockName names the database lock held while migrating
+const migrationLockName = "homelab_schema_migrations"
+
+// migrationLockTimeout bounds the wait for another instance's migrations
+const migrationLockTimeout = 5 * time.Minute
+
+// LatestVersion returns the version of the newest migration in this build
+func LatestVersion() uint {
+	return migrations[len(migrations)-1].Version
+}
+
+// withMigrationLock runs fn holding a database-wide lock, so instances
+// started together do not apply the same migrations at once. The lock
+// belongs to a session, so one connection is held for the duration.
+func withMigrationLock(fn func() error) error {
+	return DB.Connection(func(conn *gorm.DB) error {
+		switch DB.Dialector.Name() {
+		case "postgres":
+			ctx, cancel := context.WithTimeout(context.Background(), migrationLockTimeout)
+			defer cancel()
+			if err := conn.WithContext(ctx).Exec("SELECT pg_advisory_lock(hashtext(?))", migrationLockName).Error; err != nil {
+				return fmt.Errorf("waiting for the migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", migrationLockName)
+		case "mysql":
+			var locked sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&locked).Error; err != nil {
+				return fmt.Errorf("waiting for the migration lock: %w", err)
+			}
+			if locked.Int64 != 1 {
+				return fmt.Errorf("timed out waiting for the migration lock")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+		}
+		return fn()
+	})
+}
+
+// appliedMigrations returns the applied migrations by version, creating the
+// schema_migrations table on first use
+func appliedMigrations() (map[uint]SchemaMigration, error) {
+	if err := DB.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigration
+	if err := DB.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// checkSchemaVersion refuses a database with migrations newer than this
+// build's, whose schema the code would misread
+func checkSchemaVersion(applied map[uint]SchemaMigration) error {
+	latest := LatestVersion()
+	for version, r := range applied {
+		if version > latest {
+			return fmt.Errorf("%w: it has migration %d %s but this build only knows up to %d, upgrade the backend",
+				ErrSchemaTooNew, version, r.Name, latest)
+		}
+	}
+	return nil
+}
+
+// lockedMigrations runs fn under the migration lock with the applied
+// migrations, after checking the schema is not newer than the build
+func lockedMigrations(fn func(applied map[uint]SchemaMigration) error) error {
+	return withMigrationLock(func() error {
+		applied, err := appliedMigrations()
+		if err != nil {
+			return err
+		}
+		if err := checkSchemaVersion(applied); err != nil {
+			return err
+		}
+		return fn(applied)
+	})
+}
+
+// applyMigration runs a migration's Up and records it in one transaction
+func applyMigration(m Migration) error {
+	log.Printf("  → Applying migration %d %s", m.Version, m.Name)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := m.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s failed: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+// revertMigration runs a migration's Down and forgets it in one transaction
+func revertMigration(m Migration) error {
+	log.Printf("  → Reverting migration %d %s", m.Version, m.Name)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := m.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, m.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("reverting migration %d %s failed: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+// MigrateUp applies up to steps pending migrations in order, all of them
+// when steps is 0, and returns how many it applied
+func MigrateUp(steps int) (int, error) {
+	count := 0
+	err := lockedMigrations(func(applied map[uint]SchemaMigration) error {
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if steps > 0 && count == steps {
+				break
+			}
+			if err := applyMigration(m); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown reverts the latest steps applied migrations, newest first, and
+// returns how many it reverted
+func MigrateDown(steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be at least 1")
+	}
+
+	count := 0
+	err := lockedMigrations(func(applied map[uint]SchemaMigration) error {
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if err := revertMigration(m); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateTo applies or reverts migrations until exactly those up to version
+// are applied; version 0 reverts everything. Returns how many it applied and
+// reverted.
+func MigrateTo(version uint) (int, int, error) {
+	if version > LatestVersion() {
+		return 0, 0, fmt.Errorf("unknown migration version %d, the latest is %d", version, LatestVersion())
+	}
+
+	applied, reverted := 0, 0
+	err := lockedMigrations(func(done map[uint]SchemaMigration) error {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok || m.Version <= version {
+				continue
+			}
+			if err := revertMigration(m); err != nil {
+				return err
+			}
+			reverted++
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok || m.Version > version {
+				continue
+			}
+			if err := applyMigration(m); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, reverted, err
+}
+
+// SchemaVersion returns the newest applied migration version, 0 for an
+// empty database
+func SchemaVersion() (uint, error) {
+	applied, err := appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+	var version uint
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// MigrationStatus lists every migration with when it was applied, followed
+// by applied migrations this build does not know
+func MigrationStatus() ([]MigrationState, error) {
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if r, ok := applied[m.Version]; ok {
+			appliedAt := r.AppliedAt
+			state.AppliedAt = &appliedAt
+			delete(applied, m.Version)
+		}
+		states = append(states, state)
+	}
+
+	unknown := make([]MigrationState, 0, len(applied))
+	for _, r := range applied {
+		appliedAt := r.AppliedAt
+		unknown = append(unknown, MigrationState{Version: r.Version, Name: r.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(states, unknown...), nil
+}
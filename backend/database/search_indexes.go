@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// historySearchColumns are the columns of each history kind's full-text
+// index. Searches must repeat the indexed expressions for PostgreSQL to use
+// the index.
+var historySearchColumns = map[string][]string{
+	models.HistoryAudit: {"action", "actor", "target_type", "target_id", "details"},
+	models.HistoryEvent: {"type", "source", "title", "message", "details"},
+	models.HistoryNote:  {"body"},
+}
+
+// HistorySearchVector returns the PostgreSQL tsvector expression indexed for
+// a history kind
+func HistorySearchVector(kind string) string {
+	parts := make([]string, 0, len(historySearchColumns[kind]))
+	for _, column := range historySearchColumns[kind] {
+		parts = append(parts, "coalesce("+column+", '')")
+	}
+	return "to_tsvector('simple', " + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+// HistorySearchColumns returns the columns of a history kind's MySQL
+// FULLTEXT index, comma separated
+func HistorySearchColumns(kind string) string {
+	return strings.Join(historySearchColumns[kind], ", ")
+}
+
+// HistorySearchKind reports whether kind is a searchable history kind
+func HistorySearchKind(kind string) bool {
+	_, ok := historySearchColumns[kind]
+	return ok
+}
+
+// historySearchIndexes are the full-text indexes of the history kinds
+var historySearchIndexes = []struct {
+	kind, table, name string
+}{
+	{models.HistoryAudit, "audit_logs", "idx_audit_logs_search"},
+	{models.HistoryEvent, "events", "idx_events_search"},
+	{models.HistoryNote, "notes", "idx_notes_search"},
+}
+
+// createHistorySearchIndexes adds the full-text indexes: GIN indexes over
+// tsvectors on PostgreSQL, FULLTEXT indexes on MySQL
+func createHistorySearchIndexes(tx *gorm.DB) error {
+	for _, idx := range historySearchIndexes {
+		if tx.Migrator().HasIndex(idx.table, idx.name) {
+			continue
+		}
+		stmt := fmt.Sprintf("CREATE INDEX %s ON %s USING GIN (%s)", idx.name, idx.table, HistorySearchVector(idx.kind))
+		if tx.Dialector.Name() == "mysql" {
+			stmt = fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s)", idx.table, idx.name, HistorySearchColumns(idx.kind))
+		}
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropHistorySearchIndexes removes the full-text indexes
+func dropHistorySearchIndexes(tx *gorm.DB) error {
+	for _, idx := range historySearchIndexes {
+		if !tx.Migrator().HasIndex(idx.table, idx.name) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex(idx.table, idx.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
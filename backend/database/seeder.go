@@ -440,17 +440,17 @@ func SeedDemoServices() error {
 	return nil
 }
 
-// ResetDatabase drops all tables and recreates them
+// ResetDatabase reverts every migration, dropping all tables, and recreates
+// and seeds them
 func ResetDatabase() error {
 	log.Println("Resetting database...")
 
-	// Drop tables
-	if err := DB.Migrator().DropTable(
-		&models.Session{},
-		&models.ServiceConfig{},
-		&models.Device{},
-		&models.User{},
-	); err != nil {
+	// Record the baseline first on databases created before versioned
+	// migrations, so reverting it drops their tables
+	if _, err := MigrateUp(0); err != nil {
+		return err
+	}
+	if _, err := MigrateDown(len(migrations)); err != nil {
 		return err
 	}
 
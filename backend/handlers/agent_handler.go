@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// AgentHandler handles push agent enrollment and ingestion endpoints
+type AgentHandler struct {
+	service *services.AgentService
+}
+
+// NewAgentHandler creates a new AgentHandler
+func NewAgentHandler(service *services.AgentService) *AgentHandler {
+	return &AgentHandler{service: service}
+}
+
+// GetHosts lists the caller's devices with an enrolled agent and their
+// latest reports
+// GET /api/agents
+func (h *AgentHandler) GetHosts(c *gin.Context) {
+	hosts, err := h.service.ListHosts(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list agents", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hosts)
+}
+
+// Enroll issues a device's agent token, only shown in this response.
+// Enrolling a device again replaces its token.
+// POST /api/devices/:id/agent
+func (h *AgentHandler) Enroll(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	agent, err := h.service.Enroll(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to enroll agent", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, agent)
+}
+
+// Remove revokes a device's agent token
+// DELETE /api/devices/:id/agent
+func (h *AgentHandler) Remove(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	if err := h.service.Remove(uint(id), middleware.GetUserID(c)); err != nil {
+		if err.Error() == "device not found" || err.Error() == "agent not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove agent", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Agent removed"})
+}
+
+// IngestMetrics takes in a report from the calling agent
+// POST /api/ingest/metrics
+func (h *AgentHandler) IngestMetrics(c *gin.Context) {
+	agent := c.MustGet("metricsAgent").(*models.MetricsAgent)
+
+	var report models.AgentReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Ingest(agent, report); err != nil {
+		if errors.Is(err, services.ErrInvalidAgentReport) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store metrics", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stored": true})
+}
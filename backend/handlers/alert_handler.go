@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// AlertHandler handles alert rule and alert endpoints
+type AlertHandler struct {
+	service *services.AlertService
+}
+
+// NewAlertHandler creates a new AlertHandler
+func NewAlertHandler(service *services.AlertService) *AlertHandler {
+	return &AlertHandler{service: service}
+}
+
+// GetAlerts returns the user's alerts, newest first
+// Supports ?state=active|firing|acknowledged|resolved&limit=100
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	alerts, err := h.service.ListAlerts(middleware.GetUserID(c), c.Query("state"), limit)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// AcknowledgeAlert marks a firing alert as seen
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid alert ID")
+		return
+	}
+
+	alert, err := h.service.Acknowledge(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "alert not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetRules returns the user's alert rules
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	rules, err := h.service.ListRules(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule returns a single alert rule
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid rule ID")
+		return
+	}
+
+	rule, err := h.service.GetRule(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule adds an alert rule
+// POST /api/alerts/rules {"name": "Disk almost full", "target": "metric", "metric": "disk",
+// "condition": "above", "threshold": 90, "duration": 300, "severity": "critical"}
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	rule, err := h.service.CreateRule(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule replaces an alert rule's settings
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid rule ID")
+		return
+	}
+
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	rule, err := h.service.UpdateRule(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "alert rule not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule removes an alert rule
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid rule ID")
+		return
+	}
+
+	if err := h.service.DeleteRule(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "alert rule deleted"})
+}
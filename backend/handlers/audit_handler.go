@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// AuditHandler handles audit log endpoints
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAuditLog returns recent audit entries (admin)
+// Supports ?limit=100&action=remediation&targetType=service
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		limit = 100
+	}
+
+	entries, err := h.service.List(limit, c.Query("action"), c.Query("targetType"))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
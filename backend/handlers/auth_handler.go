@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/middleware"
 	"github.com/homelab/backend/models"
 	"github.com/homelab/backend/services"
@@ -23,10 +25,7 @@ func NewAuthHandler(service *services.AuthService) *AuthHandler {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
 		return
 	}
 
@@ -35,9 +34,31 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	authResponse, err := h.service.Login(req, userAgent, ipAddress)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-		})
+		code := apierror.CodeInvalidCredentials
+		switch {
+		case errors.Is(err, services.ErrAccountLocked):
+			code = apierror.CodeAccountLocked
+		case errors.Is(err, services.ErrAccountDisabled):
+			code = apierror.CodeAccountDisabled
+		}
+		apierror.Respond(c, http.StatusUnauthorized, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, authResponse)
+}
+
+// Refresh exchanges a refresh token for a new access token
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	authResponse, err := h.service.Refresh(req.RefreshToken, c.GetHeader("User-Agent"), c.ClientIP())
+	if err != nil {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, err.Error())
 		return
 	}
 
@@ -48,16 +69,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 func (h *AuthHandler) Logout(c *gin.Context) {
 	token, exists := c.Get("token")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No token found",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "No token found")
 		return
 	}
 
 	if err := h.service.Logout(token.(string)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to logout",
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to logout")
 		return
 	}
 
@@ -70,17 +87,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found",
-		})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not found")
 		return
 	}
 
 	user, err := h.service.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
 		return
 	}
 
@@ -91,26 +104,19 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found",
-		})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not found")
 		return
 	}
 
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
 		return
 	}
 
 	user, err := h.service.UpdateProfile(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update profile",
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update profile")
 		return
 	}
 
@@ -121,25 +127,22 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found",
-		})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not found")
 		return
 	}
 
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
 		return
 	}
 
 	if err := h.service.ChangePassword(userID, req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		code := apierror.CodeBadRequest
+		if errors.Is(err, services.ErrPasswordPolicy) {
+			code = apierror.CodePasswordPolicy
+		}
+		apierror.Respond(c, http.StatusBadRequest, code, err.Error())
 		return
 	}
 
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// BadgeHandler serves embeddable SVG status badges
+type BadgeHandler struct {
+	badgeService *services.BadgeService
+}
+
+// NewBadgeHandler creates a new BadgeHandler
+func NewBadgeHandler(badgeService *services.BadgeService) *BadgeHandler {
+	return &BadgeHandler{badgeService: badgeService}
+}
+
+// badgeWindows are the supported ?window= values for uptime badges
+var badgeWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// parseBadgeID parses ":id.svg" from the route
+func parseBadgeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(strings.TrimSuffix(c.Param("file"), ".svg"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeBadge(c *gin.Context, svg string) {
+	// Short cache so README embeds stay reasonably current
+	c.Header("Cache-Control", "max-age=60, s-maxage=60")
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(svg))
+}
+
+// GetServiceBadge returns the current status badge of a service
+// GET /api/badges/service/:id.svg?token=&label=
+func (h *BadgeHandler) GetServiceBadge(c *gin.Context) {
+	id, ok := parseBadgeID(c)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	svg, err := h.badgeService.StatusBadge(id, c.Query("token"), c.Query("label"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+
+	writeBadge(c, svg)
+}
+
+// GetUptimeBadge returns the uptime percentage badge of a service
+// GET /api/badges/uptime/:id.svg?token=&label=&window=24h|7d|30d
+func (h *BadgeHandler) GetUptimeBadge(c *gin.Context) {
+	id, ok := parseBadgeID(c)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	window, ok := badgeWindows[c.DefaultQuery("window", "30d")]
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "window must be one of 24h, 7d, 30d")
+		return
+	}
+
+	svg, err := h.badgeService.UptimeBadge(id, c.Query("token"), c.Query("label"), window)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+
+	writeBadge(c, svg)
+}
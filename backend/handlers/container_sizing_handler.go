@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// ContainerSizingHandler handles the container right-sizing report
+type ContainerSizingHandler struct {
+	service *services.ContainerSizingService
+}
+
+// NewContainerSizingHandler creates a new ContainerSizingHandler
+func NewContainerSizingHandler(service *services.ContainerSizingService) *ContainerSizingHandler {
+	return &ContainerSizingHandler{service: service}
+}
+
+// GetSizing compares container limits with observed usage
+// GET /api/containers/sizing?days=7
+func (h *ContainerSizingHandler) GetSizing(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+
+	report, err := h.service.Report(days)
+	if err != nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// DeployHandler handles git build-and-deploy endpoints
+type DeployHandler struct {
+	service *services.DeployService
+}
+
+// NewDeployHandler creates a new DeployHandler
+func NewDeployHandler(service *services.DeployService) *DeployHandler {
+	return &DeployHandler{service: service}
+}
+
+// deployMessage is one message on the build log WebSocket
+type deployMessage struct {
+	Type   string `json:"type"` // log, done
+	Line   string `json:"line,omitempty"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GetApps returns the user's deploy apps
+func (h *DeployHandler) GetApps(c *gin.Context) {
+	apps, err := h.service.ListApps(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, apps)
+}
+
+// GetApp returns a single deploy app
+func (h *DeployHandler) GetApp(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	app, err := h.service.GetApp(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+// CreateApp registers a deploy app. The webhook secret is only returned here
+// and when rotated.
+// POST /api/deploy/apps {"name": "blog", "repoUrl": "https://github.com/me/blog.git",
+// "branch": "main", "container": "blog", "ports": ["8080:80"]}
+func (h *DeployHandler) CreateApp(c *gin.Context) {
+	var req models.DeployAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	app, secret, err := h.service.CreateApp(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"app": app, "webhookSecret": secret})
+}
+
+// UpdateApp replaces a deploy app's settings
+func (h *DeployHandler) UpdateApp(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	var req models.DeployAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	app, err := h.service.UpdateApp(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "deploy app not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+// RotateWebhookSecret replaces a deploy app's webhook secret
+func (h *DeployHandler) RotateWebhookSecret(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	secret, err := h.service.RotateWebhookSecret(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "deploy app not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhookSecret": secret})
+}
+
+// DeleteApp removes a deploy app and its builds
+func (h *DeployHandler) DeleteApp(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	if err := h.service.DeleteApp(uint(id), middleware.GetUserID(c)); err != nil {
+		if errors.Is(err, services.ErrBuildRunning) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deploy app deleted"})
+}
+
+// Build starts a build and rollout of a deploy app
+func (h *DeployHandler) Build(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	build, err := h.service.Build(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		respondBuildError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, build)
+}
+
+// GetBuilds returns a deploy app's recent builds
+func (h *DeployHandler) GetBuilds(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	builds, err := h.service.ListBuilds(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, builds)
+}
+
+// GetBuild returns a build with its log
+func (h *DeployHandler) GetBuild(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid build ID")
+		return
+	}
+
+	build, err := h.service.GetBuild(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, build)
+}
+
+// Webhook starts a build from a git push webhook (public, authenticated by
+// the app's webhook secret)
+// POST /api/deploy/hooks/:id?token=<secret>, or signed with the secret by
+// GitHub (X-Hub-Signature-256) or Gitea (X-Gitea-Signature), or with GitLab's
+// X-Gitlab-Token header
+func (h *DeployHandler) Webhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid app ID")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "failed to read request body")
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("X-Gitlab-Token")
+	}
+	signature := c.GetHeader("X-Hub-Signature-256")
+	if signature == "" {
+		signature = c.GetHeader("X-Gitea-Signature")
+	}
+
+	build, err := h.service.Webhook(uint(id), token, signature, body)
+	if err != nil {
+		respondBuildError(c, err)
+		return
+	}
+	if build == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "push ignored: not the deploy branch"})
+		return
+	}
+	c.JSON(http.StatusAccepted, build)
+}
+
+// respondBuildError maps errors from starting a build to API errors
+func respondBuildError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebhookUnauthorized):
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+	case errors.Is(err, services.ErrBuildRunning):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case err.Error() == "deploy app not found":
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case err.Error() == "docker not connected":
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+	}
+}
+
+// StreamBuildLog streams a build's log over a WebSocket, then sends a done
+// message with the outcome. Finished builds send their stored log.
+func (h *DeployHandler) StreamBuildLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid build ID")
+		return
+	}
+	userID := middleware.GetUserID(c)
+	if _, err := h.service.GetBuild(uint(id), userID); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+
+	backlog, lines, stop, running := h.service.Follow(uint(id))
+	if running {
+		defer stop()
+		// Stop following when the client goes away
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					stop()
+					return
+				}
+			}
+		}()
+
+		if backlog != "" && conn.WriteJSON(deployMessage{Type: "log", Line: backlog}) != nil {
+			return
+		}
+		for line := range lines {
+			if conn.WriteJSON(deployMessage{Type: "log", Line: line}) != nil {
+				return
+			}
+		}
+	}
+
+	build, err := h.service.GetBuild(uint(id), userID)
+	if err != nil {
+		return
+	}
+	if build.Status == models.BuildRunning {
+		// Dropped for falling behind the build's output
+		CloseWebSocket(conn, websocket.CloseTryAgainLater, apierror.CodeUnavailable, "log stream fell behind", c.GetString("wsTopic"))
+		return
+	}
+	if !running && build.Log != "" {
+		conn.WriteJSON(deployMessage{Type: "log", Line: build.Log})
+	}
+	conn.WriteJSON(deployMessage{Type: "done", Status: build.Status, Error: build.Error})
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
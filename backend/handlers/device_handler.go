@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/middleware"
 	"github.com/homelab/backend/models"
 	"github.com/homelab/backend/services"
@@ -24,6 +31,7 @@ func NewDeviceHandler(deviceService *services.DeviceService) *DeviceHandler {
 
 // GetDevices returns all devices for the current user
 // Use ?refresh=true to ping all devices and get live status (slower)
+// Use ?tag=name to list only tagged devices (repeat or comma separate to match any)
 func (h *DeviceHandler) GetDevices(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	refresh := c.Query("refresh") == "true"
@@ -33,75 +41,150 @@ func (h *DeviceHandler) GetDevices(c *gin.Context) {
 
 	if refresh {
 		// Ping all devices in parallel (slower but live status)
-		devices, err = h.deviceService.GetDevicesWithPing(userID)
+		devices, err = h.deviceService.GetDevicesWithPing(userID, c.QueryArray("tag"))
 	} else {
 		// Fast - just return from database with last known status
-		devices, err = h.deviceService.GetDevices(userID)
+		devices, err = h.deviceService.GetDevices(userID, c.QueryArray("tag"))
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, devices)
 }
 
-// GetDevice returns a single device
+// GetDevice returns a single device with the services it hosts
 func (h *DeviceHandler) GetDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
-	device, err := h.deviceService.GetDevice(uint(id), userID)
+	device, err := h.deviceService.GetDeviceDetail(uint(id), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, device)
 }
 
+// GetStaleDevices lists devices not seen for more than ?days= days (default STALE_DEVICE_DAYS)
+func (h *DeviceHandler) GetStaleDevices(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+	devices, err := h.deviceService.StaleDevices(middleware.GetUserID(c), days)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get stale devices", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// GetAvailability returns a device's uptime for 24h/7d/30d and a daily calendar
+// GET /api/devices/:id/availability?days=90
+func (h *DeviceHandler) GetAvailability(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	availability, err := h.deviceService.GetAvailability(uint(id), userID, days)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get availability", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// GetStatusHistory returns when a device went online and offline, newest first
+// GET /api/devices/:id/history?days=7
+func (h *DeviceHandler) GetStatusHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+
+	history, err := h.deviceService.GetStatusHistory(uint(id), userID, days)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get status history", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 // CreateDevice creates a new device
 func (h *DeviceHandler) CreateDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var req models.CreateDeviceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.SSHKeyPath != "" && !serverKeyAllowed(c) {
 		return
 	}
 
 	device, err := h.deviceService.CreateDevice(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondDeviceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, device)
 }
 
+// serverKeyAllowed responds 403 unless the caller is an admin. Key files in
+// SSH_KEY_DIR belong to the server, so only admins may point a device at one.
+func serverKeyAllowed(c *gin.Context) bool {
+	if middleware.GetUserRole(c) != "admin" {
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeForbidden, "only admins can use server-side SSH keys")
+		return false
+	}
+	return true
+}
+
 // UpdateDevice updates a device
 func (h *DeviceHandler) UpdateDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
 	var req models.UpdateDeviceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.SSHKeyPath != nil && *req.SSHKeyPath != "" && !serverKeyAllowed(c) {
 		return
 	}
 
 	device, err := h.deviceService.UpdateDevice(uint(id), userID, req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondDeviceError(c, err)
 		return
 	}
 
@@ -113,12 +196,12 @@ func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
 	if err := h.deviceService.DeleteDevice(uint(id), userID); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
 		return
 	}
 
@@ -130,13 +213,13 @@ func (h *DeviceHandler) PingDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
 	isOnline, err := h.deviceService.PingDevice(uint(id), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
 		return
 	}
 
@@ -148,18 +231,40 @@ func (h *DeviceHandler) WakeDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
 	if err := h.deviceService.WakeDevice(uint(id), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Wake-on-LAN packet sent"})
 }
 
+// ResetSSHHostKey forgets the device's pinned SSH host key, after the host
+// was reinstalled or its key rotated. The next connection pins the new key.
+func (h *DeviceHandler) ResetSSHHostKey(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	device, err := h.deviceService.ResetSSHHostKey(uint(id), userID)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, device)
+}
+
 // GetDeviceTypes returns available device types
 func (h *DeviceHandler) GetDeviceTypes(c *gin.Context) {
 	types := []map[string]string{
@@ -180,14 +285,139 @@ func (h *DeviceHandler) ShutdownDevice(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid device ID"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
 		return
 	}
 
 	if err := h.deviceService.ShutdownDevice(uint(id), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPowerError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Shutdown command sent"})
 }
+
+// RebootDevice sends a reboot command to the device
+func (h *DeviceHandler) RebootDevice(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	if err := h.deviceService.RebootDevice(uint(id), userID); err != nil {
+		respondPowerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reboot command sent"})
+}
+
+// DeviceTerminal proxies an interactive SSH shell on the device, logged in
+// with its stored credentials, over a WebSocket using the terminal protocol
+// GET /ws/devices/:id/terminal?cols=&rows=
+func (h *DeviceHandler) DeviceTerminal(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+	cols, _ := strconv.ParseUint(c.Query("cols"), 10, 16)
+	rows, _ := strconv.ParseUint(c.Query("rows"), 10, 16)
+
+	shell, err := h.deviceService.OpenShell(uint(id), userID, uint(cols), uint(rows))
+	if err != nil {
+		if err.Error() == "device has no SSH credentials" {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		respondPowerError(c, err)
+		return
+	}
+	defer shell.Close()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+	tc := &terminalConn{conn: conn}
+
+	log.Printf("Device terminal started: device %d by user %d", id, userID)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		readOutput(tc, shell, "output")
+	}()
+
+	// Close idle or overlong sessions, as for the host terminal
+	limits := newTerminalLimits()
+	stop := make(chan struct{})
+	defer close(stop)
+	go limits.watch(tc, stop)
+
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var msg TerminalMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+
+			switch msg.Type {
+			case "input", "command":
+				if msg.Data == "" {
+					continue
+				}
+				limits.Touch()
+				if _, err := shell.Write([]byte(msg.Data)); err != nil {
+					tc.send("error", fmt.Sprintf("\r\nWrite error: %v", err))
+					return
+				}
+			case "resize":
+				if err := shell.Resize(msg.Cols, msg.Rows); err != nil {
+					tc.send("error", fmt.Sprintf("\r\nResize failed: %v\r\n", err))
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		if code, ok := shell.ExitCode(); ok {
+			tc.send("output", fmt.Sprintf("\r\n[process exited with code %d]\r\n", code))
+		}
+		tc.mu.Lock()
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		tc.mu.Unlock()
+	case <-disconnected:
+	}
+	log.Printf("Device terminal ended: device %d", id)
+}
+
+// respondPowerError tells an unreachable host apart from a rejected login or sudo
+func respondPowerError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "device not found":
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrHostUnreachable):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeHostUnreachable, err.Error())
+	case errors.Is(err, services.ErrPermissionDenied):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodePermissionDenied, err.Error())
+	case errors.Is(err, services.ErrHostKeyMismatch):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeHostKeyMismatch, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// DiscoveryHandler handles network discovery endpoints
+type DiscoveryHandler struct {
+	service *services.DiscoveryService
+}
+
+// NewDiscoveryHandler creates a new DiscoveryHandler
+func NewDiscoveryHandler(service *services.DiscoveryService) *DiscoveryHandler {
+	return &DiscoveryHandler{service: service}
+}
+
+// ScanNetwork sweeps a subnet of at most a /22 and returns the hosts found
+// POST /api/network/scan
+func (h *DiscoveryHandler) ScanNetwork(c *gin.Context) {
+	var req models.NetworkScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.Scan(middleware.GetUserID(c), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScan) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Network scan failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportHosts creates devices for discovered hosts, skipping known ones
+// POST /api/network/scan/import
+func (h *DiscoveryHandler) ImportHosts(c *gin.Context) {
+	var req models.ImportHostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.ImportHosts(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Import failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// DNSHandler handles the DNS record inventory endpoints
+type DNSHandler struct {
+	service *services.DNSService
+}
+
+// NewDNSHandler creates a new DNSHandler
+func NewDNSHandler(service *services.DNSService) *DNSHandler {
+	return &DNSHandler{service: service}
+}
+
+// parseDNSRecordID parses the :id route parameter
+func parseDNSRecordID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid DNS record ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetRecords returns the user's DNS records with their last check results
+func (h *DNSHandler) GetRecords(c *gin.Context) {
+	records, err := h.service.List(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// CreateRecord adds an expected DNS record
+// POST /api/dns/records {"name": "jellyfin.home.lan", "type": "A", "expected": "192.168.1.20"}
+func (h *DNSHandler) CreateRecord(c *gin.Context) {
+	var req models.DNSRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	record, err := h.service.Create(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, record)
+}
+
+// UpdateRecord changes an expected DNS record
+func (h *DNSHandler) UpdateRecord(c *gin.Context) {
+	id, ok := parseDNSRecordID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DNSRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	record, err := h.service.Update(id, middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "DNS record not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteRecord removes a DNS record
+func (h *DNSHandler) DeleteRecord(c *gin.Context) {
+	id, ok := parseDNSRecordID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "DNS record deleted"})
+}
+
+// CheckRecord resolves a record against the resolvers now
+func (h *DNSHandler) CheckRecord(c *gin.Context) {
+	id, ok := parseDNSRecordID(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.service.Check(id, middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
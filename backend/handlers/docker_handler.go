@@ -1,37 +1,90 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
 	"github.com/homelab/backend/services"
 )
 
-// DockerHandler handles Docker container endpoints
+// DockerHandler handles Docker container endpoints. Every endpoint takes
+// ?host= with a Docker host ID to act on a remote daemon instead of the
+// local one.
 type DockerHandler struct {
-	service *services.DockerService
+	hosts        *services.DockerHostService
+	scanService  *services.ScanService
+	imageUpdates *services.ImageUpdateService
 }
 
 // NewDockerHandler creates a new DockerHandler
-func NewDockerHandler(service *services.DockerService) *DockerHandler {
-	return &DockerHandler{service: service}
+func NewDockerHandler(hosts *services.DockerHostService, scanService *services.ScanService, imageUpdates *services.ImageUpdateService) *DockerHandler {
+	return &DockerHandler{hosts: hosts, scanService: scanService, imageUpdates: imageUpdates}
+}
+
+// docker returns the daemon selected by ?host=, responding when it is
+// unknown or can't be reached
+func (h *DockerHandler) docker(c *gin.Context) (*services.DockerService, bool) {
+	svc, err := h.hosts.Resolve(c.Query("host"))
+	if err != nil {
+		switch {
+		case err.Error() == "docker host not found":
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		case strings.HasPrefix(err.Error(), "invalid "):
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		case errors.Is(err, services.ErrHostKeyMismatch):
+			apierror.Respond(c, http.StatusBadGateway, apierror.CodeHostKeyMismatch, err.Error())
+		default:
+			apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+		}
+		return nil, false
+	}
+	return svc, true
 }
 
 // GetContainers returns all containers
 func (h *DockerHandler) GetContainers(c *gin.Context) {
-	containers := h.service.GetContainers()
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	containers := svc.GetContainers()
+
+	// Flag containers whose image has known vulnerabilities or a newer
+	// version; scans and update checks only cover local images
+	if svc == h.hosts.Local() {
+		summaries := h.scanService.GetSummaries()
+		updates := h.imageUpdates.UpdatesAvailable()
+		for i := range containers {
+			containers[i].Vulnerabilities = summaries[containers[i].Image]
+			containers[i].UpdateAvailable = updates[containers[i].Image]
+		}
+	}
+
 	c.JSON(http.StatusOK, containers)
 }
 
 // GetContainer returns a specific container
 func (h *DockerHandler) GetContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
 	id := c.Param("id")
-	container, err := h.service.GetContainer(id)
+	container, err := svc.GetContainer(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Container not found",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Container not found", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, container)
@@ -39,12 +92,13 @@ func (h *DockerHandler) GetContainer(c *gin.Context) {
 
 // StartContainer starts a container
 func (h *DockerHandler) StartContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
 	id := c.Param("id")
-	if err := h.service.StartContainer(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to start container",
-			"details": err.Error(),
-		})
+	if err := svc.StartContainer(id); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start container", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -55,12 +109,13 @@ func (h *DockerHandler) StartContainer(c *gin.Context) {
 
 // StopContainer stops a container
 func (h *DockerHandler) StopContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
 	id := c.Param("id")
-	if err := h.service.StopContainer(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to stop container",
-			"details": err.Error(),
-		})
+	if err := svc.StopContainer(id); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to stop container", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -71,12 +126,13 @@ func (h *DockerHandler) StopContainer(c *gin.Context) {
 
 // RestartContainer restarts a container
 func (h *DockerHandler) RestartContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
 	id := c.Param("id")
-	if err := h.service.RestartContainer(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to restart container",
-			"details": err.Error(),
-		})
+	if err := svc.RestartContainer(id); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to restart container", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -84,3 +140,532 @@ func (h *DockerHandler) RestartContainer(c *gin.Context) {
 		"id":      id,
 	})
 }
+
+// PauseContainer freezes a running container
+// POST /api/containers/:id/pause
+func (h *DockerHandler) PauseContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := svc.PauseContainer(id); err != nil {
+		respondDockerObjectError(c, "Failed to pause container", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Container paused successfully",
+		"id":      id,
+	})
+}
+
+// UnpauseContainer resumes a paused container
+// POST /api/containers/:id/unpause
+func (h *DockerHandler) UnpauseContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := svc.UnpauseContainer(id); err != nil {
+		respondDockerObjectError(c, "Failed to unpause container", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Container unpaused successfully",
+		"id":      id,
+	})
+}
+
+// KillContainer sends a signal to a running container, SIGKILL by default
+// POST /api/containers/:id/kill?signal=SIGTERM
+func (h *DockerHandler) KillContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := svc.KillContainer(id, c.Query("signal")); err != nil {
+		respondDockerObjectError(c, "Failed to kill container", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Container killed successfully",
+		"id":      id,
+	})
+}
+
+// RemoveContainer deletes a container; running containers need force=true
+// DELETE /api/containers/:id?force=true&volumes=true
+func (h *DockerHandler) RemoveContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := svc.RemoveContainer(id, c.Query("force") == "true", c.Query("volumes") == "true"); err != nil {
+		respondDockerObjectError(c, "Failed to remove container", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "container removed"})
+}
+
+// GetImageUpdates returns the latest registry check of each image running
+// containers use
+// GET /api/containers/updates
+func (h *DockerHandler) GetImageUpdates(c *gin.Context) {
+	updates, err := h.imageUpdates.GetUpdates()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, updates)
+}
+
+// CheckImageUpdates checks running containers' images against their
+// registries in the background
+// POST /api/containers/updates/check
+func (h *DockerHandler) CheckImageUpdates(c *gin.Context) {
+	go h.imageUpdates.CheckImages()
+	c.JSON(http.StatusAccepted, gin.H{"message": "Update check started"})
+}
+
+// UpdateContainer pulls the container's image tag and recreates it from the
+// new image with the same configuration
+// POST /api/containers/:id/update
+func (h *DockerHandler) UpdateContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	container, err := svc.UpdateContainer(id)
+	if err != nil {
+		respondDockerObjectError(c, "Failed to update container", err)
+		return
+	}
+
+	log.Printf("Container %s updated to a new %s image by user %d", container.Name, container.Image, middleware.GetUserID(c))
+	if svc == h.hosts.Local() {
+		go h.imageUpdates.CheckImage(container.Image)
+	}
+	c.JSON(http.StatusOK, container)
+}
+
+// logOptions reads the tail, since and timestamps query parameters
+func logOptions(c *gin.Context) services.ContainerLogOptions {
+	return services.ContainerLogOptions{
+		Tail:       c.Query("tail"),
+		Since:      c.Query("since"),
+		Timestamps: c.Query("timestamps") == "true",
+	}
+}
+
+// GetContainerLogs returns the tail of a container's stdout and stderr
+// GET /api/containers/:id/logs?tail=200&since=15m&timestamps=true
+func (h *DockerHandler) GetContainerLogs(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	logs, err := svc.GetContainerLogs(c.Param("id"), logOptions(c))
+	if err != nil {
+		respondContainerError(c, "Failed to get container logs", err)
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
+
+// containerLogMessage is sent over the container logs WebSocket
+type containerLogMessage struct {
+	Type string `json:"type"` // log, end
+	*models.ContainerLogLine
+}
+
+// StreamContainerLogs follows a container's output over a WebSocket, sending
+// the requested tail first. An end message is sent when the container stops.
+// GET /ws/containers/:id/logs?tail=100&since=15m&timestamps=true
+func (h *DockerHandler) StreamContainerLogs(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	opts := logOptions(c)
+	if _, err := svc.GetContainer(id); err != nil {
+		respondContainerError(c, "Failed to get container", err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	// Stop following when the client goes away
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = svc.FollowContainerLogs(ctx, id, opts, func(line models.ContainerLogLine) error {
+		return conn.WriteJSON(containerLogMessage{Type: "log", ContainerLogLine: &line})
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		CloseWebSocket(conn, websocket.CloseInternalServerErr, apierror.CodeUpstream, err.Error(), c.GetString("wsTopic"))
+		return
+	}
+	conn.WriteJSON(containerLogMessage{Type: "end"})
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// StreamContainerEvents pushes container start, stop, die and health_status
+// events over a WebSocket so the containers page can update without polling.
+// The socket closes with an upstream error when the daemon's stream drops.
+// GET /ws/docker?host=2
+func (h *DockerHandler) StreamContainerEvents(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	// Stop watching when the client goes away
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = svc.WatchContainerEvents(ctx, func(event models.ContainerEvent) error {
+		return conn.WriteJSON(event)
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		CloseWebSocket(conn, websocket.CloseInternalServerErr, apierror.CodeUpstream, err.Error(), c.GetString("wsTopic"))
+	}
+}
+
+// ExecContainer opens an interactive shell inside a running container over a
+// WebSocket, speaking the terminal's message protocol plus resize messages
+// GET /ws/containers/:id/exec?shell=/bin/ash&cols=120&rows=40
+func (h *DockerHandler) ExecContainer(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	cols, _ := strconv.ParseUint(c.Query("cols"), 10, 16)
+	rows, _ := strconv.ParseUint(c.Query("rows"), 10, 16)
+
+	exec, err := svc.ExecShell(id, c.Query("shell"), uint(cols), uint(rows))
+	if err != nil {
+		if err.Error() == "container is not running" {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+			return
+		}
+		respondContainerError(c, "Failed to start shell", err)
+		return
+	}
+	defer exec.Close()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+	tc := &terminalConn{conn: conn}
+
+	log.Printf("Container exec started: %s in %s by user %d", exec.ID, id, middleware.GetUserID(c))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		readOutput(tc, exec, "output")
+	}()
+
+	// Close idle or overlong sessions, as for the host terminal
+	limits := newTerminalLimits()
+	stop := make(chan struct{})
+	defer close(stop)
+	go limits.watch(tc, stop)
+
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var msg TerminalMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+
+			switch msg.Type {
+			case "input", "command":
+				if msg.Data == "" {
+					continue
+				}
+				limits.Touch()
+				if _, err := exec.Write([]byte(msg.Data)); err != nil {
+					tc.send("error", fmt.Sprintf("\r\nWrite error: %v", err))
+					return
+				}
+			case "resize":
+				if err := exec.Resize(msg.Cols, msg.Rows); err != nil {
+					tc.send("error", fmt.Sprintf("\r\nResize failed: %v\r\n", err))
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		if code, ok := exec.ExitCode(); ok {
+			tc.send("output", fmt.Sprintf("\r\n[process exited with code %d]\r\n", code))
+		}
+		tc.mu.Lock()
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		tc.mu.Unlock()
+	case <-disconnected:
+	}
+	log.Printf("Container exec ended: %s", exec.ID)
+}
+
+// respondContainerError maps a missing container to 404, bad options to 400
+// and an unavailable daemon to 503
+func respondContainerError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "container not found"):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Container not found", err.Error())
+	case strings.HasPrefix(err.Error(), "invalid "):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+	case err.Error() == "docker not connected":
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, message, err.Error())
+	}
+}
+
+// GetImageLayers returns an image's layers with their sizes and build commands
+// GET /api/images/:id/layers (image ID or name:tag)
+func (h *DockerHandler) GetImageLayers(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	image, err := svc.GetImageLayers(c.Param("id"))
+	if err != nil {
+		respondImageError(c, "Failed to get image layers", err)
+		return
+	}
+	c.JSON(http.StatusOK, image)
+}
+
+// GetImageUsage attributes image, container and volume disk usage to compose projects
+// GET /api/images/usage
+func (h *DockerHandler) GetImageUsage(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	report, err := svc.GetDiskUsage()
+	if err != nil {
+		respondImageError(c, "Failed to get disk usage", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// respondImageError maps a missing image to 404 and an unavailable daemon to 503
+func respondImageError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "image not found"):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case err.Error() == "docker not connected":
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, message, err.Error())
+	}
+}
+
+// GetVolumes returns all volumes with their sizes and the containers mounting them
+// GET /api/docker/volumes
+func (h *DockerHandler) GetVolumes(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	volumes, err := svc.ListVolumes()
+	if err != nil {
+		respondDockerObjectError(c, "Failed to list volumes", err)
+		return
+	}
+	c.JSON(http.StatusOK, volumes)
+}
+
+// GetVolume returns a volume with the containers mounting it
+// GET /api/docker/volumes/:name
+func (h *DockerHandler) GetVolume(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	volume, err := svc.GetVolume(c.Param("name"))
+	if err != nil {
+		respondDockerObjectError(c, "Failed to get volume", err)
+		return
+	}
+	c.JSON(http.StatusOK, volume)
+}
+
+// CreateVolume creates a volume
+// POST /api/docker/volumes {"name": "media", "driver": "local", "options": {...}, "labels": {...}}
+func (h *DockerHandler) CreateVolume(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	var req models.VolumeCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	volume, err := svc.CreateVolume(req)
+	if err != nil {
+		respondDockerObjectError(c, "Failed to create volume", err)
+		return
+	}
+	c.JSON(http.StatusCreated, volume)
+}
+
+// RemoveVolume removes a volume; ?force=true removes it even if a container uses it
+// DELETE /api/docker/volumes/:name
+func (h *DockerHandler) RemoveVolume(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	if err := svc.RemoveVolume(c.Param("name"), c.Query("force") == "true"); err != nil {
+		respondDockerObjectError(c, "Failed to remove volume", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "volume removed"})
+}
+
+// GetNetworks returns all networks with their subnets and attached containers
+// GET /api/docker/networks
+func (h *DockerHandler) GetNetworks(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	networks, err := svc.ListNetworks()
+	if err != nil {
+		respondDockerObjectError(c, "Failed to list networks", err)
+		return
+	}
+	c.JSON(http.StatusOK, networks)
+}
+
+// GetNetwork returns a network with the containers attached to it
+// GET /api/docker/networks/:id (network ID or name)
+func (h *DockerHandler) GetNetwork(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	network, err := svc.GetNetwork(c.Param("id"))
+	if err != nil {
+		respondDockerObjectError(c, "Failed to get network", err)
+		return
+	}
+	c.JSON(http.StatusOK, network)
+}
+
+// CreateNetwork creates a network
+// POST /api/docker/networks {"name": "proxy", "driver": "bridge", "subnet": "172.30.0.0/24"}
+func (h *DockerHandler) CreateNetwork(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	var req models.NetworkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	network, err := svc.CreateNetwork(req)
+	if err != nil {
+		respondDockerObjectError(c, "Failed to create network", err)
+		return
+	}
+	c.JSON(http.StatusCreated, network)
+}
+
+// RemoveNetwork removes a network with no containers attached
+// DELETE /api/docker/networks/:id
+func (h *DockerHandler) RemoveNetwork(c *gin.Context) {
+	svc, ok := h.docker(c)
+	if !ok {
+		return
+	}
+	if err := svc.RemoveNetwork(c.Param("id")); err != nil {
+		respondDockerObjectError(c, "Failed to remove network", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "network removed"})
+}
+
+// respondDockerObjectError maps a missing volume or network to 404, one in
+// use or already existing to 409, bad requests to 400 and an unavailable
+// daemon to 503
+func respondDockerObjectError(c *gin.Context, message string, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, " not found: "):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, msg)
+	case strings.Contains(msg, " is in use: "), strings.Contains(msg, " already exists: "),
+		strings.HasPrefix(msg, "container is "):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, msg)
+	case strings.HasPrefix(msg, "invalid "), strings.HasPrefix(msg, "cannot "):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, msg)
+	case msg == "docker not connected":
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, msg)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, message, msg)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// DockerHostHandler handles remote Docker host endpoints
+type DockerHostHandler struct {
+	service *services.DockerHostService
+}
+
+// NewDockerHostHandler creates a new DockerHostHandler
+func NewDockerHostHandler(service *services.DockerHostService) *DockerHostHandler {
+	return &DockerHostHandler{service: service}
+}
+
+// GetHosts returns the local daemon and the remote hosts with whether they answer
+// GET /api/docker/hosts
+func (h *DockerHostHandler) GetHosts(c *gin.Context) {
+	hosts, err := h.service.List()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hosts)
+}
+
+// GetHost returns a remote host with its status
+func (h *DockerHostHandler) GetHost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid docker host ID")
+		return
+	}
+
+	host, err := h.service.Get(uint(id))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, host)
+}
+
+// CreateHost adds a remote Docker host
+// POST /api/docker/hosts {"name": "nas", "endpoint": "ssh://docker@192.168.1.20", "sshKey": "-----BEGIN ..."}
+func (h *DockerHostHandler) CreateHost(c *gin.Context) {
+	var req models.DockerHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	host, err := h.service.Create(req)
+	if err != nil {
+		respondDockerHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, host)
+}
+
+// UpdateHost changes a remote Docker host; empty secrets are kept
+func (h *DockerHostHandler) UpdateHost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid docker host ID")
+		return
+	}
+
+	var req models.DockerHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	host, err := h.service.Update(uint(id), req)
+	if err != nil {
+		respondDockerHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, host)
+}
+
+// DeleteHost removes a remote Docker host
+func (h *DockerHostHandler) DeleteHost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid docker host ID")
+		return
+	}
+
+	if err := h.service.Delete(uint(id)); err != nil {
+		respondDockerHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "docker host deleted"})
+}
+
+// ResetSSHHostKey forgets the host's pinned SSH host key, after the host was
+// reinstalled or its key rotated, and reconnects to pin the new one
+// DELETE /api/docker/hosts/:id/ssh-host-key
+func (h *DockerHostHandler) ResetSSHHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid docker host ID")
+		return
+	}
+
+	host, err := h.service.ResetSSHHostKey(uint(id))
+	if err != nil {
+		respondDockerHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, host)
+}
+
+// respondDockerHostError maps a missing host to 404, a duplicate name to
+// 409 and invalid settings to 400
+func respondDockerHostError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "docker host not found":
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case strings.HasSuffix(err.Error(), "already exists"):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case strings.HasPrefix(err.Error(), "invalid "):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save docker host", err.Error())
+	}
+}
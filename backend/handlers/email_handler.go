@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// EmailHandler handles email notification settings
+type EmailHandler struct {
+	service *services.EmailService
+}
+
+// NewEmailHandler creates a new EmailHandler
+func NewEmailHandler(service *services.EmailService) *EmailHandler {
+	return &EmailHandler{service: service}
+}
+
+// GetPreferences returns the user's email settings and whether the server can send email
+func (h *EmailHandler) GetPreferences(c *gin.Context) {
+	status, err := h.service.GetPreferences(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// UpdatePreferences saves the user's email settings
+// PUT /api/notifications/email {"address": "", "digest": "daily", "digestHour": 8}
+func (h *EmailHandler) UpdatePreferences(c *gin.Context) {
+	var req models.EmailPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	status, err := h.service.UpdatePreferences(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SendTest emails the user to check the SMTP settings
+func (h *EmailHandler) SendTest(c *gin.Context) {
+	if !h.service.Configured() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Email is not configured", "set SMTP_HOST on the server")
+		return
+	}
+	if err := h.service.SendTest(middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Email failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test email sent"})
+}
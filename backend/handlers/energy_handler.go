@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// EnergyHandler handles energy-saving suggestion endpoints
+type EnergyHandler struct {
+	service *services.EnergyService
+}
+
+// NewEnergyHandler creates a new EnergyHandler
+func NewEnergyHandler(service *services.EnergyService) *EnergyHandler {
+	return &EnergyHandler{service: service}
+}
+
+// GetSuggestions returns idle windows per device with estimated savings
+// GET /api/energy/suggestions?days=14&minHours=2
+func (h *EnergyHandler) GetSuggestions(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+	minHours, _ := strconv.Atoi(c.Query("minHours"))
+
+	report, err := h.service.Suggestions(middleware.GetUserID(c), days, minHours)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// EventHandler handles event endpoints
+type EventHandler struct {
+	service *services.EventService
+}
+
+// NewEventHandler creates a new EventHandler
+func NewEventHandler(service *services.EventService) *EventHandler {
+	return &EventHandler{service: service}
+}
+
+// GetEvents returns recent events
+// Supports ?limit=100&severity=critical&source=firewall
+func (h *EventHandler) GetEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		limit = 100
+	}
+
+	events, err := h.service.List(middleware.GetUserID(c), limit, c.Query("severity"), c.Query("source"))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// FirewallHandler handles read-only firewall endpoints
+type FirewallHandler struct {
+	service *services.FirewallService
+}
+
+// NewFirewallHandler creates a new FirewallHandler
+func NewFirewallHandler(service *services.FirewallService) *FirewallHandler {
+	return &FirewallHandler{service: service}
+}
+
+// requireConfigured aborts with 503 when no firewall provider is available
+func (h *FirewallHandler) requireConfigured(c *gin.Context) bool {
+	if !h.service.IsConfigured() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Firewall integration not configured")
+		return false
+	}
+	return true
+}
+
+// GetStatus returns the firewall status
+func (h *FirewallHandler) GetStatus(c *gin.Context) {
+	if !h.requireConfigured(c) {
+		return
+	}
+
+	status, err := h.service.GetStatus()
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Failed to get firewall status", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetRules returns the firewall rules
+func (h *FirewallHandler) GetRules(c *gin.Context) {
+	if !h.requireConfigured(c) {
+		return
+	}
+
+	rules, err := h.service.GetRules()
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Failed to get firewall rules", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetBlocks returns recently blocked packets
+func (h *FirewallHandler) GetBlocks(c *gin.Context) {
+	if !h.requireConfigured(c) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	blocks, err := h.service.GetRecentBlocks(limit)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Failed to get firewall blocks", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, blocks)
+}
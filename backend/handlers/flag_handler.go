@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// FlagHandler handles feature flag endpoints
+type FlagHandler struct {
+	service *services.FlagService
+}
+
+// NewFlagHandler creates a new FlagHandler
+func NewFlagHandler(service *services.FlagService) *FlagHandler {
+	return &FlagHandler{service: service}
+}
+
+// GetFlags returns every flag evaluated for the current user
+func (h *FlagHandler) GetFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Evaluate(middleware.GetUserID(c)))
+}
+
+// ListFlags returns flag definitions with their per-user overrides (admin)
+func (h *FlagHandler) ListFlags(c *gin.Context) {
+	flags, err := h.service.List()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// UpdateFlag sets a flag's instance-wide value, creating it if needed (admin)
+func (h *FlagHandler) UpdateFlag(c *gin.Context) {
+	var req models.UpdateFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	flag, err := h.service.Update(c.Param("key"), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update flag", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag removes a flag (admin)
+func (h *FlagHandler) DeleteFlag(c *gin.Context) {
+	if err := h.service.Delete(c.Param("key")); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "flag deleted"})
+}
+
+// SetOverride enables or disables a flag for one user (admin)
+func (h *FlagHandler) SetOverride(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid user ID")
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.service.SetOverride(c.Param("key"), uint(userID), req.Enabled); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "override saved"})
+}
+
+// ClearOverride removes a user's override (admin)
+func (h *FlagHandler) ClearOverride(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.ClearOverride(c.Param("key"), uint(userID)); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "override removed"})
+}
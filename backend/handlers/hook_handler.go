@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// HookHandler handles inbound webhook endpoints
+type HookHandler struct {
+	service *services.HookService
+}
+
+// NewHookHandler creates a new HookHandler
+func NewHookHandler(service *services.HookService) *HookHandler {
+	return &HookHandler{service: service}
+}
+
+// GetPresets returns the built-in mappings for common senders
+func (h *HookHandler) GetPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Presets())
+}
+
+// GetHooks returns the user's inbound hooks
+func (h *HookHandler) GetHooks(c *gin.Context) {
+	hooks, err := h.service.ListHooks(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// GetHook returns a single inbound hook
+func (h *HookHandler) GetHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	hook, err := h.service.GetHook(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// CreateHook adds an inbound hook. The secret is only returned here and
+// when rotated.
+// POST /api/hooks {"name": "Grafana", "preset": "grafana", "createAlerts": true}
+func (h *HookHandler) CreateHook(c *gin.Context) {
+	var req models.InboundHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	hook, secret, err := h.service.CreateHook(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"hook": hook, "secret": secret})
+}
+
+// UpdateHook replaces an inbound hook's mapping
+func (h *HookHandler) UpdateHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	var req models.InboundHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	hook, err := h.service.UpdateHook(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "hook not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// RotateSecret replaces an inbound hook's secret
+func (h *HookHandler) RotateSecret(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	secret, err := h.service.RotateSecret(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "hook not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"secret": secret})
+}
+
+// DeleteHook removes an inbound hook
+func (h *HookHandler) DeleteHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	if err := h.service.DeleteHook(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "hook deleted"})
+}
+
+// Receive accepts a delivery from an external system (public, authenticated
+// by the hook's secret)
+// POST /api/hooks/:id?token=<secret>, or with X-Hook-Token, Authorization:
+// Bearer <secret>, or GitHub's X-Hub-Signature-256
+func (h *HookHandler) Receive(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "failed to read request body")
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("X-Hook-Token")
+	}
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+
+	result, err := h.service.Receive(uint(id), token, c.GetHeader("X-Hub-Signature-256"), c.Request.Header, c.Request.URL.Query(), body)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookUnauthorized) {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusUnprocessableEntity, apierror.CodeBadRequest, "Payload could not be mapped", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// IntegrationHandler handles integration registry endpoints
+type IntegrationHandler struct {
+	service *services.IntegrationService
+}
+
+// NewIntegrationHandler creates a new IntegrationHandler
+func NewIntegrationHandler(service *services.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{service: service}
+}
+
+// GetIntegrations lists registered integrations with their config schemas
+func (h *IntegrationHandler) GetIntegrations(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.List())
+}
+
+// GetIntegration returns a single integration
+func (h *IntegrationHandler) GetIntegration(c *gin.Context) {
+	info, err := h.service.Get(c.Param("name"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// UpdateIntegration enables/disables an integration or changes its settings
+func (h *IntegrationHandler) UpdateIntegration(c *gin.Context) {
+	var req models.UpdateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	info, err := h.service.Update(c.Param("name"), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Failed to update integration", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// Collect runs a collector integration and returns its data
+func (h *IntegrationHandler) Collect(c *gin.Context) {
+	data, err := h.service.Collect(c.Param("name"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Collection failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// Execute runs an action on an actor integration
+func (h *IntegrationHandler) Execute(c *gin.Context) {
+	params := make(map[string]string)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	result, err := h.service.Execute(c.Param("name"), c.Param("action"), params)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Action failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// TestNotify sends a test notification through a notifier integration
+func (h *IntegrationHandler) TestNotify(c *gin.Context) {
+	if err := h.service.TestNotify(c.Param("name")); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Notification failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
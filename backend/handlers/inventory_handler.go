@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// InventoryHandler handles device attachment endpoints
+type InventoryHandler struct {
+	service *services.InventoryService
+}
+
+// NewInventoryHandler creates a new InventoryHandler
+func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+// parseAttachmentIDs reads the device and attachment IDs from the path
+func parseAttachmentIDs(c *gin.Context) (uint, uint, bool) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return 0, 0, false
+	}
+	if c.Param("attachmentId") == "" {
+		return uint(deviceID), 0, true
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid attachment ID")
+		return 0, 0, false
+	}
+	return uint(deviceID), uint(attachmentID), true
+}
+
+// GetAttachments lists a device's attachments
+func (h *InventoryHandler) GetAttachments(c *gin.Context) {
+	deviceID, _, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.service.ListAttachments(deviceID, middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment stores an invoice, manual or photo for a device
+// POST /api/devices/:id/attachments (multipart form, "file" field)
+func (h *InventoryHandler) UploadAttachment(c *gin.Context) {
+	deviceID, _, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+
+	data, err := readUpload(c, "file", config.AppConfig.MaxUploadSize, services.AttachmentTypes...)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid attachment", err.Error())
+		return
+	}
+	if data == nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "No file uploaded")
+		return
+	}
+	file, _ := c.FormFile("file")
+
+	attachment, err := h.service.AddAttachment(deviceID, middleware.GetUserID(c), file.Filename, http.DetectContentType(data), data)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store attachment", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment returns an attachment's file
+func (h *InventoryHandler) DownloadAttachment(c *gin.Context) {
+	deviceID, attachmentID, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+
+	attachment, path, err := h.service.GetAttachment(attachmentID, deviceID, middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.Header("Content-Type", attachment.ContentType)
+	c.FileAttachment(path, attachment.Filename)
+}
+
+// DeleteAttachment removes an attachment
+func (h *InventoryHandler) DeleteAttachment(c *gin.Context) {
+	deviceID, attachmentID, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAttachment(attachmentID, deviceID, middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "attachment deleted"})
+}
+
+// respondDeviceError maps device lookup, inventory and power settings validation errors
+func respondDeviceError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "device not found":
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidDate), errors.Is(err, services.ErrInvalidPowerSettings),
+		errors.Is(err, services.ErrInvalidSSHCredentials):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+	}
+}
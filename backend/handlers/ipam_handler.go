@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// IPAMHandler handles the IP address plan endpoints
+type IPAMHandler struct {
+	service *services.IPAMService
+}
+
+// NewIPAMHandler creates a new IPAMHandler
+func NewIPAMHandler(service *services.IPAMService) *IPAMHandler {
+	return &IPAMHandler{service: service}
+}
+
+// parseSubnetID parses the :id route parameter
+func parseSubnetID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid subnet ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetSubnets returns the user's subnets
+func (h *IPAMHandler) GetSubnets(c *gin.Context) {
+	subnets, err := h.service.ListSubnets(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, subnets)
+}
+
+// CreateSubnet adds a subnet
+func (h *IPAMHandler) CreateSubnet(c *gin.Context) {
+	var req models.SubnetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	subnet, err := h.service.CreateSubnet(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, subnet)
+}
+
+// UpdateSubnet changes a subnet and its reserved ranges
+func (h *IPAMHandler) UpdateSubnet(c *gin.Context) {
+	id, ok := parseSubnetID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SubnetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	subnet, err := h.service.UpdateSubnet(id, middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "subnet not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, subnet)
+}
+
+// DeleteSubnet removes a subnet
+func (h *IPAMHandler) DeleteSubnet(c *gin.Context) {
+	id, ok := parseSubnetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubnet(id, middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "subnet deleted"})
+}
+
+// GetSubnetUsage returns the taken addresses and free count of a subnet
+func (h *IPAMHandler) GetSubnetUsage(c *gin.Context) {
+	id, ok := parseSubnetID(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.service.GetSubnetUsage(id, middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetNextFreeIP suggests an address for a new device
+// GET /api/ipam/subnets/:id/next-ip
+func (h *IPAMHandler) GetNextFreeIP(c *gin.Context) {
+	id, ok := parseSubnetID(c)
+	if !ok {
+		return
+	}
+
+	ip, err := h.service.NextFreeIP(id, middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "subnet not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subnetId": id, "ip": ip})
+}
+
+// GetConflicts returns duplicate, reserved and unexpected address usage
+func (h *IPAMHandler) GetConflicts(c *gin.Context) {
+	conflicts, err := h.service.Conflicts(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, conflicts)
+}
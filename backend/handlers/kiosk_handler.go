@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// KioskHandler manages read-only kiosk display tokens
+type KioskHandler struct {
+	service *services.KioskService
+}
+
+// NewKioskHandler creates a new KioskHandler
+func NewKioskHandler(service *services.KioskService) *KioskHandler {
+	return &KioskHandler{service: service}
+}
+
+// GetTokens lists kiosk tokens (without their values)
+func (h *KioskHandler) GetTokens(c *gin.Context) {
+	tokens, err := h.service.List()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken creates a kiosk token; the value is only shown in this response
+func (h *KioskHandler) CreateToken(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	token, err := h.service.Create(req.Name, middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create kiosk token", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// UpdateToken renames or enables/disables a kiosk token
+func (h *KioskHandler) UpdateToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid token ID")
+		return
+	}
+
+	var req struct {
+		Name    *string `json:"name"`
+		Enabled *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	token, err := h.service.Update(uint(id), req.Name, req.Enabled)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// RotateToken issues a new value for a kiosk token
+func (h *KioskHandler) RotateToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid token ID")
+		return
+	}
+
+	token, err := h.service.Rotate(uint(id))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// DeleteToken revokes a kiosk token
+func (h *KioskHandler) DeleteToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid token ID")
+		return
+	}
+
+	if err := h.service.Delete(uint(id)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "kiosk token deleted"})
+}
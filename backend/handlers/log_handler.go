@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// LogHandler handles journald/syslog error rate endpoints
+type LogHandler struct {
+	service *services.LogService
+}
+
+// NewLogHandler creates a new LogHandler
+func NewLogHandler(service *services.LogService) *LogHandler {
+	return &LogHandler{service: service}
+}
+
+// requireConfigured aborts with 503 when no log source is available
+func (h *LogHandler) requireConfigured(c *gin.Context) bool {
+	if !h.service.IsConfigured() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Log monitoring not configured")
+		return false
+	}
+	return true
+}
+
+// GetUnitRates returns error and warning rates per unit
+func (h *LogHandler) GetUnitRates(c *gin.Context) {
+	if !h.requireConfigured(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source": h.service.Source(),
+		"units":  h.service.Rates(),
+	})
+}
+
+// GetRecentErrors returns a unit's latest errors and warnings
+// Supports ?limit=50&priority=error|warning
+func (h *LogHandler) GetRecentErrors(c *gin.Context) {
+	if !h.requireConfigured(c) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	c.JSON(http.StatusOK, h.service.Recent(c.Param("unit"), c.Query("priority"), limit))
+}
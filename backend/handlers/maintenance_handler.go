@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// MaintenanceHandler handles database maintenance endpoints
+type MaintenanceHandler struct {
+	service *services.MaintenanceService
+}
+
+// NewMaintenanceHandler creates a new MaintenanceHandler
+func NewMaintenanceHandler(service *services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{service: service}
+}
+
+// GetStatus returns the maintenance schedule and recent runs
+// GET /api/maintenance
+func (h *MaintenanceHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get maintenance status", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// RunMaintenance runs database maintenance now and returns the run. A run
+// with failed steps is still returned, with its error set.
+// POST /api/maintenance/run
+func (h *MaintenanceHandler) RunMaintenance(c *gin.Context) {
+	run, err := h.service.Run(models.MaintenanceManual)
+	if errors.Is(err, services.ErrMaintenanceRunning) {
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+		return
+	}
+	if run == nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to run maintenance", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
@@ -1,31 +1,39 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
 	"github.com/homelab/backend/services"
 )
 
 // MetricsHandler handles system metrics endpoints
 type MetricsHandler struct {
 	service *services.MetricsService
+	pi      *services.PiService
+	traffic *services.TrafficService
 }
 
 // NewMetricsHandler creates a new MetricsHandler
-func NewMetricsHandler(service *services.MetricsService) *MetricsHandler {
-	return &MetricsHandler{service: service}
+func NewMetricsHandler(service *services.MetricsService, pi *services.PiService, traffic *services.TrafficService) *MetricsHandler {
+	return &MetricsHandler{service: service, pi: pi, traffic: traffic}
 }
 
 // GetSystemMetrics returns all system metrics
 func (h *MetricsHandler) GetSystemMetrics(c *gin.Context) {
 	metrics, err := h.service.GetSystemMetrics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get system metrics",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get system metrics", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, metrics)
@@ -35,10 +43,7 @@ func (h *MetricsHandler) GetSystemMetrics(c *gin.Context) {
 func (h *MetricsHandler) GetCPUMetrics(c *gin.Context) {
 	metrics, err := h.service.GetCPUMetrics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get CPU metrics",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get CPU metrics", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, metrics)
@@ -48,10 +53,7 @@ func (h *MetricsHandler) GetCPUMetrics(c *gin.Context) {
 func (h *MetricsHandler) GetMemoryMetrics(c *gin.Context) {
 	metrics, err := h.service.GetMemoryMetrics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get memory metrics",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get memory metrics", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, metrics)
@@ -61,36 +63,419 @@ func (h *MetricsHandler) GetMemoryMetrics(c *gin.Context) {
 func (h *MetricsHandler) GetDiskMetrics(c *gin.Context) {
 	metrics, err := h.service.GetDiskMetrics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get disk metrics",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get disk metrics", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, metrics)
 }
 
-// GetNetworkMetrics returns network-specific metrics
+// GetNetworkMetrics returns network-specific metrics of the interfaces the
+// interface settings show; ?all=true lists every interface, marking the
+// hidden ones
+// GET /api/metrics/network
 func (h *MetricsHandler) GetNetworkMetrics(c *gin.Context) {
-	metrics, err := h.service.GetNetworkMetrics()
+	var metrics []models.NetworkMetrics
+	var err error
+	if c.Query("all") == "true" {
+		metrics, err = h.service.ListNetworkInterfaces()
+	} else {
+		metrics, err = h.service.GetNetworkMetrics()
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get network metrics",
-			"details": err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get network metrics", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, metrics)
 }
 
-// GetMetricsHistory returns historical metrics data
+// GetNetworkTop splits the host's throughput between containers and the
+// host and lists the ?limit=10 busiest containers
+// GET /api/metrics/network/top
+func (h *MetricsHandler) GetNetworkTop(c *gin.Context) {
+	limit := services.DefaultTopContainers
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	traffic, err := h.traffic.GetTopContainers(limit)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to measure network traffic", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, traffic)
+}
+
+// StreamMetrics pushes the system metrics every 2 seconds over a
+// WebSocket, less often while the client can't keep up
+// GET /ws/metrics
+func (h *MetricsHandler) StreamMetrics(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("WebSocket upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+
+	newWSStream(conn, 2*time.Second).run(func() (interface{}, bool) {
+		metrics, err := h.service.GetSystemMetrics()
+		if err != nil {
+			log.Println("Error getting metrics:", err)
+			return nil, false
+		}
+		return metrics, true
+	})
+}
+
+// GetMetricsHistory returns historical metrics data: the latest ?limit=50
+// samples, or with ?from=RFC3339&to=RFC3339&bucket=seconds the samples in
+// that range averaged into buckets, at most ?limit points. Limits above
+// MaxMetricsHistoryPoints are clamped.
 func (h *MetricsHandler) GetMetricsHistory(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "50")
-	limit, err := strconv.Atoi(limitStr)
+	query, ok := parseHistoryQuery(c)
+	if !ok {
+		return
+	}
+
+	var history []models.MetricsHistory
+	var err error
+	if query.from.IsZero() {
+		history, err = h.service.GetMetricsHistory(0, query.limit)
+	} else {
+		history, err = h.service.GetMetricsHistoryRange(0, query.from, query.to, query.bucket, query.limit)
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// historyQuery is a parsed metrics history request; a zero from asks for
+// the latest limit samples
+type historyQuery struct {
+	from, to time.Time
+	bucket   time.Duration
+	limit    int
+}
+
+// parseHistoryQuery parses ?limit, ?from, ?to and ?bucket. It responds 400
+// and returns false when they are invalid.
+func parseHistoryQuery(c *gin.Context) (historyQuery, bool) {
+	var query historyQuery
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid limit")
+			return query, false
+		}
+		query.limit = n
+	}
+
+	if c.Query("from") == "" && c.Query("to") == "" {
+		if query.limit == 0 {
+			query.limit = 50
+		}
+		return query, true
+	}
+
+	from, to, ok := historyRange(c, time.Hour)
+	if !ok {
+		return query, false
+	}
+	query.from, query.to = from, to
+
+	if v := c.Query("bucket"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid bucket")
+			return query, false
+		}
+		query.bucket = time.Duration(seconds) * time.Second
+	}
+	return query, true
+}
+
+// respondDeviceMetricsError maps errors of metrics read over SSH to responses
+func respondDeviceMetricsError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "device not found":
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrRemoteMetricsDisabled):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotConfigured, err.Error())
+	case errors.Is(err, services.ErrAgentNotReported):
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, err.Error())
+	case errors.Is(err, services.ErrHostUnreachable):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeHostUnreachable, err.Error())
+	case errors.Is(err, services.ErrPermissionDenied):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodePermissionDenied, err.Error())
+	case errors.Is(err, services.ErrHostKeyMismatch):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeHostKeyMismatch, err.Error())
+	case errors.Is(err, services.ErrRemoteMetricsUnreadable), errors.Is(err, services.ErrInvalidSSHCredentials):
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get device metrics", err.Error())
+	}
+}
+
+// GetDeviceMetrics returns a device's CPU, memory, disk and network metrics
+// read over SSH or pushed by its agent, shaped like GET /api/metrics.
+// ?refresh=true reads them over SSH now instead of returning the last
+// collection, unless the agent is reporting.
+// GET /api/devices/:id/metrics
+func (h *MetricsHandler) GetDeviceMetrics(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	metrics, err := h.service.GetDeviceMetrics(uint(id), middleware.GetUserID(c), c.Query("refresh") == "true")
+	if err != nil {
+		respondDeviceMetricsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GetDeviceMetricsHistory returns a device's stored metrics samples, with
+// the query parameters of GET /api/metrics/history
+// GET /api/devices/:id/metrics/history
+func (h *MetricsHandler) GetDeviceMetricsHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		limit = 50
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+	query, ok := parseHistoryQuery(c)
+	if !ok {
+		return
 	}
 
-	history := h.service.GetMetricsHistory(limit)
+	history, err := h.service.GetDeviceMetricsHistory(uint(id), middleware.GetUserID(c), query.from, query.to, query.bucket, query.limit)
+	if err != nil {
+		respondDeviceMetricsError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, history)
 }
+
+// historyRange parses ?from=RFC3339&to=RFC3339, defaulting to the last
+// span. It responds 400 and returns false when they are invalid.
+func historyRange(c *gin.Context, span time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now()
+	from := to.Add(-span)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid from, expected RFC3339")
+			return from, to, false
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid to, expected RFC3339")
+			return from, to, false
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "from must be before to")
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// metricsExportFlushEvery is how many NDJSON lines are written between flushes
+const metricsExportFlushEvery = 500
+
+// ExportMetricsHistory streams the raw history samples between ?from and ?to
+// (default the last 24 hours, at most 90 days) as NDJSON, one sample per
+// line, without building the export in memory
+// GET /api/metrics/history/export?from=RFC3339&to=RFC3339
+func (h *MetricsHandler) ExportMetricsHistory(c *gin.Context) {
+	from, to, ok := historyRange(c, 24*time.Hour)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > services.MaxMetricsExportRange {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "export at most 90 days at a time")
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="metrics-%s.ndjson"`, from.UTC().Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	lines := 0
+	err := h.service.StreamMetricsHistory(0, from, to, func(sample models.MetricsHistory) error {
+		if err := encoder.Encode(sample); err != nil {
+			return err
+		}
+		if lines++; lines%metricsExportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// The status is already sent; the client sees a truncated export
+		log.Printf("Metrics history export failed after %d samples: %v", lines, err)
+	}
+	c.Writer.Flush()
+}
+
+// QueryMetrics evaluates a metrics query expression such as
+// avg(cpu.usage, 1h) or max(disk["/srv"].used_percent, 24h); with
+// ?step=seconds it is also evaluated over each step of its window
+// GET /api/metrics/query?q=
+func (h *MetricsHandler) QueryMetrics(c *gin.Context) {
+	expr := c.Query("q")
+	if expr == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "q is required")
+		return
+	}
+	if err := services.ValidateMetricsQuery(expr); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+
+	var step time.Duration
+	if v := c.Query("step"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid step")
+			return
+		}
+		step = time.Duration(seconds) * time.Second
+	}
+
+	result, err := h.service.QueryMetrics(middleware.GetUserID(c), expr, step)
+	if err != nil {
+		if strings.Contains(err.Error(), " not found: ") {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to query metrics", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetQuerySeries lists the series and functions metrics queries can use
+// GET /api/metrics/query/series
+func (h *MetricsHandler) GetQuerySeries(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"series":    services.MetricsQuerySeries(),
+		"functions": services.MetricsQueryFunctions(),
+	})
+}
+
+// GetConnections returns active network connections
+// Supports ?protocol=tcp|udp&state=LISTEN&pid=123&port=443&process=nginx&remote=true
+func (h *MetricsHandler) GetConnections(c *gin.Context) {
+	filter := models.ConnectionFilter{
+		Protocol:   c.Query("protocol"),
+		State:      c.Query("state"),
+		Process:    c.Query("process"),
+		RemoteOnly: c.Query("remote") == "true",
+	}
+
+	if pidStr := c.Query("pid"); pidStr != "" {
+		pid, err := strconv.ParseInt(pidStr, 10, 32)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid pid")
+			return
+		}
+		filter.PID = int32(pid)
+	}
+
+	if portStr := c.Query("port"); portStr != "" {
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid port")
+			return
+		}
+		filter.Port = uint32(port)
+	}
+
+	connections, err := h.service.GetConnections(filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get connections", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, connections)
+}
+
+// GetWindowsMetrics returns SCM, pending reboot and event log health on Windows hosts
+// Supports ?hours=24 for the event log window
+func (h *MetricsHandler) GetWindowsMetrics(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 || hours > 24*30 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "hours must be between 1 and 720")
+		return
+	}
+
+	metrics, err := h.service.GetWindowsMetrics(hours)
+	if err != nil {
+		respondHostError(c, "Failed to get Windows metrics", err)
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GetWindowsServices lists Windows services
+// Supports ?state=Running&startMode=Auto&name=sql
+func (h *MetricsHandler) GetWindowsServices(c *gin.Context) {
+	list, err := h.service.GetWindowsServices(models.WindowsServiceFilter{
+		State:     c.Query("state"),
+		StartMode: c.Query("startMode"),
+		Name:      c.Query("name"),
+	})
+	if err != nil {
+		respondHostError(c, "Failed to get Windows services", err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetMacOSMetrics returns thermal pressure and battery state on macOS hosts
+func (h *MetricsHandler) GetMacOSMetrics(c *gin.Context) {
+	metrics, err := h.service.GetMacOSMetrics()
+	if err != nil {
+		respondHostError(c, "Failed to get macOS metrics", err)
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// respondHostError maps collectors unsupported on this OS to 501 and other failures to 500
+func respondHostError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrNotWindows) || errors.Is(err, services.ErrNotMacOS) {
+		status = http.StatusNotImplemented
+	}
+	apierror.Respond(c, status, apierror.CodeForStatus(status), message, err.Error())
+}
+
+// GetPiMetrics returns Raspberry Pi throttling flags, core voltage and SoC temperature
+func (h *MetricsHandler) GetPiMetrics(c *gin.Context) {
+	if h.pi == nil || !h.pi.IsAvailable() {
+		apierror.Respond(c, http.StatusNotImplemented, apierror.CodeNotSupported, "Raspberry Pi metrics are only available on hosts with vcgencmd")
+		return
+	}
+
+	metrics, err := h.pi.GetMetrics()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get Raspberry Pi metrics", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
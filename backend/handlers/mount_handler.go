@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// MountHandler handles mount health endpoints
+type MountHandler struct {
+	service *services.MountService
+}
+
+// NewMountHandler creates a new MountHandler
+func NewMountHandler(service *services.MountService) *MountHandler {
+	return &MountHandler{service: service}
+}
+
+// GetMounts returns the health of network and configured mounts
+func (h *MountHandler) GetMounts(c *gin.Context) {
+	mounts, err := h.service.Check()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check mounts", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, mounts)
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/services"
 )
 
@@ -15,20 +18,72 @@ func NewNetworkHandler(service *services.NetworkService) *NetworkHandler {
 	return &NetworkHandler{service: service}
 }
 
+// GetPing pings all configured targets concurrently. The top-level latency and
+// status reflect the primary (first) target for backwards compatibility.
 func (h *NetworkHandler) GetPing(c *gin.Context) {
-	latency, err := h.service.Ping()
-	if err != nil {
-		c.JSON(http.StatusOK, gin.H{"latency": -1, "error": err.Error(), "status": "offline"})
+	results := h.service.PingTargets()
+	primary := results[0]
+	if primary.Received == 0 {
+		c.JSON(http.StatusOK, gin.H{"latency": -1, "error": primary.Error, "status": "offline", "targets": results, "method": services.ICMPMode()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"latency": latency, "status": "online"})
+	c.JSON(http.StatusOK, gin.H{"latency": primary.Latency, "status": "online", "targets": results, "method": services.ICMPMode()})
 }
 
 func (h *NetworkHandler) GetSpeedTest(c *gin.Context) {
 	speed, err := h.service.TestDownloadSpeed()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Speedtest failed", "details": err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Speedtest failed", err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"downloadMbps": speed})
 }
+
+// GetLatencyHistory returns bucketed latency percentiles from the background prober
+// Supports ?target=name&from=RFC3339&to=RFC3339&bucket=300 (seconds)
+func (h *NetworkHandler) GetLatencyHistory(c *gin.Context) {
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid from, expected RFC3339")
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid to, expected RFC3339")
+			return
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "from must be before to")
+		return
+	}
+
+	// Default to ~120 buckets across the range, never below one minute
+	bucket := to.Sub(from) / 120
+	if v := c.Query("bucket"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid bucket")
+			return
+		}
+		bucket = time.Duration(seconds) * time.Second
+	}
+	if bucket < time.Minute {
+		bucket = time.Minute
+	}
+
+	history, err := h.service.GetLatencyHistory(c.Query("target"), from, to, bucket)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
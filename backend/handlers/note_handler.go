@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// NoteHandler handles the changelog notes endpoints
+type NoteHandler struct {
+	service *services.NoteService
+}
+
+// NewNoteHandler creates a new NoteHandler
+func NewNoteHandler(service *services.NoteService) *NoteHandler {
+	return &NoteHandler{service: service}
+}
+
+// parseNoteFilter reads ?serviceId=&deviceId=&from=RFC3339&to=RFC3339&limit=
+func parseNoteFilter(c *gin.Context) (models.NoteFilter, bool) {
+	var filter models.NoteFilter
+	for _, param := range []struct {
+		name string
+		dest **uint
+	}{{"serviceId", &filter.ServiceID}, {"deviceId", &filter.DeviceID}} {
+		if v := c.Query(param.name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid "+param.name)
+				return filter, false
+			}
+			value := uint(id)
+			*param.dest = &value
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := c.Query(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid "+param.name+", expected RFC3339")
+				return filter, false
+			}
+			*param.dest = &t
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		filter.Limit, _ = strconv.Atoi(v)
+	}
+	return filter, true
+}
+
+// GetNotes returns the notes feed, newest first
+// Supports ?serviceId=&deviceId=&from=RFC3339&to=RFC3339&limit=100
+func (h *NoteHandler) GetNotes(c *gin.Context) {
+	filter, ok := parseNoteFilter(c)
+	if !ok {
+		return
+	}
+
+	notes, err := h.service.List(middleware.GetUserID(c), filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
+// GetAnnotations returns notes in a time range as graph annotations
+// Supports the same filters as GetNotes
+func (h *NoteHandler) GetAnnotations(c *gin.Context) {
+	filter, ok := parseNoteFilter(c)
+	if !ok {
+		return
+	}
+
+	annotations, err := h.service.Annotations(middleware.GetUserID(c), filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, annotations)
+}
+
+// CreateNote adds a note to a service or device
+// POST /api/notes {"serviceId": 3, "body": "upgraded Immich to 1.99"}
+func (h *NoteHandler) CreateNote(c *gin.Context) {
+	var req models.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	note, err := h.service.Create(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// UpdateNote edits a note
+func (h *NoteHandler) UpdateNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid note ID")
+		return
+	}
+
+	var req models.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	note, err := h.service.Update(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "note not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteNote removes a note
+func (h *NoteHandler) DeleteNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid note ID")
+		return
+	}
+
+	if err := h.service.Delete(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "note deleted"})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// NotificationHandler handles notification channel endpoints
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetChannelTypes returns the supported channel types, their settings and
+// default templates
+func (h *NotificationHandler) GetChannelTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ChannelTypes())
+}
+
+// GetChannels returns the user's notification channels
+func (h *NotificationHandler) GetChannels(c *gin.Context) {
+	channels, err := h.service.ListChannels(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+// GetChannel returns a single notification channel
+func (h *NotificationHandler) GetChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid channel ID")
+		return
+	}
+
+	channel, err := h.service.GetChannel(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, channel)
+}
+
+// CreateChannel adds a notification channel
+// POST /api/notifications {"name": "Phone", "type": "telegram", "minSeverity": "warning",
+// "settings": {"botToken": "123:abc", "chatId": "42"}}
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	channel, err := h.service.CreateChannel(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateChannel replaces a notification channel's settings. Masked secrets
+// keep their stored value.
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid channel ID")
+		return
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "notification channel not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel removes a notification channel
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid channel ID")
+		return
+	}
+
+	if err := h.service.DeleteChannel(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notification channel deleted"})
+}
+
+// TestChannel sends a test message through a notification channel
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid channel ID")
+		return
+	}
+
+	if err := h.service.TestChannel(uint(id), middleware.GetUserID(c)); err != nil {
+		if err.Error() == "notification channel not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Notification failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
+
+// GetCategories returns the notification categories channels can receive
+func (h *NotificationHandler) GetCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Categories())
+}
+
+// GetPreferences returns which categories and severities each of the
+// user's channels receives
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	settings, err := h.service.GetPreferences(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdatePreferences replaces the user's notification preferences
+// PUT /api/notifications/preferences {"preferences": [{"channelId": 1, "categories": ["alerts", "docker"], "minSeverity": "warning"}]}
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req models.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	settings, err := h.service.UpdatePreferences(middleware.GetUserID(c), req.Preferences)
+	if err != nil {
+		if err.Error() == "notification channel not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// OOMHandler handles OOM kill endpoints
+type OOMHandler struct {
+	service *services.OOMService
+}
+
+// NewOOMHandler creates a new OOMHandler
+func NewOOMHandler(service *services.OOMService) *OOMHandler {
+	return &OOMHandler{service: service}
+}
+
+// GetKills returns recent OOM kills
+// Supports ?limit=100&container=name
+func (h *OOMHandler) GetKills(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		limit = 100
+	}
+
+	kills, err := h.service.List(limit, c.Query("container"))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, kills)
+}
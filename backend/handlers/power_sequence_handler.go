@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// PowerSequenceHandler handles lab shutdown and start sequence endpoints
+type PowerSequenceHandler struct {
+	service *services.PowerSequenceService
+}
+
+// NewPowerSequenceHandler creates a new PowerSequenceHandler
+func NewPowerSequenceHandler(service *services.PowerSequenceService) *PowerSequenceHandler {
+	return &PowerSequenceHandler{service: service}
+}
+
+// GetSequences returns the user's power sequences
+func (h *PowerSequenceHandler) GetSequences(c *gin.Context) {
+	sequences, err := h.service.List(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, sequences)
+}
+
+// GetSequence returns a power sequence with the log of its last run
+func (h *PowerSequenceHandler) GetSequence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sequence ID")
+		return
+	}
+
+	seq, err := h.service.Get(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, seq)
+}
+
+// CreateSequence adds a power sequence
+func (h *PowerSequenceHandler) CreateSequence(c *gin.Context) {
+	var req models.PowerSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	seq, err := h.service.Create(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, seq)
+}
+
+// UpdateSequence replaces a power sequence's steps
+func (h *PowerSequenceHandler) UpdateSequence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sequence ID")
+		return
+	}
+
+	var req models.PowerSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	seq, err := h.service.Update(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "power sequence not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, seq)
+}
+
+// DeleteSequence removes a power sequence
+func (h *PowerSequenceHandler) DeleteSequence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sequence ID")
+		return
+	}
+
+	if err := h.service.Delete(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "power sequence deleted"})
+}
+
+// ShutdownLab runs a sequence's steps in order
+func (h *PowerSequenceHandler) ShutdownLab(c *gin.Context) {
+	h.run(c, models.PowerDirectionShutdown)
+}
+
+// StartLab runs a sequence's steps in reverse, waking devices
+func (h *PowerSequenceHandler) StartLab(c *gin.Context) {
+	h.run(c, models.PowerDirectionStart)
+}
+
+// run starts a sequence in the background; poll GetSequence for progress
+func (h *PowerSequenceHandler) run(c *gin.Context, direction string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sequence ID")
+		return
+	}
+
+	seq, err := h.service.Run(uint(id), middleware.GetUserID(c), direction)
+	if err != nil {
+		switch err.Error() {
+		case "power sequence not found":
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		case "power sequence already running":
+			apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+		default:
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, seq)
+}
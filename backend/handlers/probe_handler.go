@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// ProbeHandler handles remote probe agent endpoints
+type ProbeHandler struct {
+	service *services.ProbeService
+}
+
+// NewProbeHandler creates a new ProbeHandler
+func NewProbeHandler(service *services.ProbeService) *ProbeHandler {
+	return &ProbeHandler{service: service}
+}
+
+// GetAgents lists registered probe agents
+func (h *ProbeHandler) GetAgents(c *gin.Context) {
+	agents, err := h.service.ListAgents()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+// CreateAgent registers a probe agent; the token is only shown in this response
+func (h *ProbeHandler) CreateAgent(c *gin.Context) {
+	var req models.CreateProbeAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	agent, err := h.service.CreateAgent(req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Failed to create probe agent", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, agent)
+}
+
+// UpdateAgent renames or enables/disables a probe agent
+func (h *ProbeHandler) UpdateAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid agent ID")
+		return
+	}
+
+	var req struct {
+		Name    *string `json:"name"`
+		Enabled *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	agent, err := h.service.UpdateAgent(uint(id), req.Name, req.Enabled)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+// RotateAgent issues a new token for a probe agent
+func (h *ProbeHandler) RotateAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid agent ID")
+		return
+	}
+
+	agent, err := h.service.RotateAgent(uint(id))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+// DeleteAgent removes a probe agent
+func (h *ProbeHandler) DeleteAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid agent ID")
+		return
+	}
+
+	if err := h.service.DeleteAgent(uint(id)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "probe agent deleted"})
+}
+
+// GetServiceLocations returns the latest result per location and the consensus status
+func (h *ProbeHandler) GetServiceLocations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	statuses, err := h.service.LocationStatuses(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+// SetServiceLocations selects which locations check a service
+// PUT /api/services/:id/locations {"locations": ["local", "vps-fra"], "minFailingLocations": 2}
+func (h *ProbeHandler) SetServiceLocations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	var req struct {
+		Locations           []string `json:"locations" binding:"required"`
+		MinFailingLocations int      `json:"minFailingLocations"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	service, err := h.service.SetLocations(uint(id), middleware.GetUserID(c), req.Locations, req.MinFailingLocations)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, service)
+}
+
+// GetAssignments returns the checks the calling agent should run
+func (h *ProbeHandler) GetAssignments(c *gin.Context) {
+	agent := c.MustGet("probeAgent").(*models.ProbeAgent)
+
+	checks, err := h.service.Assignments(agent)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"location": agent.Location, "checks": checks})
+}
+
+// ReportResults stores check results from the calling agent
+func (h *ProbeHandler) ReportResults(c *gin.Context) {
+	agent := c.MustGet("probeAgent").(*models.ProbeAgent)
+
+	var report models.ProbeReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	stored, err := h.service.Report(agent, report)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store results", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stored": stored})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/services"
+)
+
+// PrometheusHandler serves metrics to Prometheus scrapers
+type PrometheusHandler struct {
+	service *services.PrometheusService
+}
+
+// NewPrometheusHandler creates a new PrometheusHandler
+func NewPrometheusHandler(service *services.PrometheusService) *PrometheusHandler {
+	return &PrometheusHandler{service: service}
+}
+
+// GetMetrics serves system, container, service check and device metrics in
+// the Prometheus text format to scrapers sending PROMETHEUS_TOKEN as a
+// bearer token
+// GET /metrics
+func (h *PrometheusHandler) GetMetrics(c *gin.Context) {
+	token := config.AppConfig.PrometheusToken
+	if token == "" {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotConfigured, "Set PROMETHEUS_TOKEN to enable the Prometheus endpoint")
+		return
+	}
+	given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid Prometheus token")
+		return
+	}
+
+	c.Header("Content-Type", services.PrometheusContentType)
+	c.Status(http.StatusOK)
+	if err := h.service.Write(c.Writer); err != nil {
+		log.Printf("Failed to write Prometheus metrics: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// QRHandler serves QR codes for device and service labels
+type QRHandler struct {
+	qrService *services.QRService
+}
+
+// NewQRHandler creates a new QRHandler
+func NewQRHandler(qrService *services.QRService) *QRHandler {
+	return &QRHandler{qrService: qrService}
+}
+
+// writeQR renders link in the requested ?format= and ?size=
+func writeQR(c *gin.Context, link string) {
+	size := services.DefaultQRSize
+	if raw := c.Query("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid size")
+			return
+		}
+		size = parsed
+	}
+
+	data, contentType, err := services.RenderQR(link, c.Query("format"), size)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.Header("X-QR-Link", link)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetDeviceQR returns a QR code linking to the device's dashboard page
+// GET /api/devices/:id/qr?format=png|svg&size=256
+func (h *QRHandler) GetDeviceQR(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	link, err := h.qrService.DeviceLink(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	writeQR(c, link)
+}
+
+// GetServiceQR returns a QR code linking to the service URL, or to its
+// dashboard page with ?target=dashboard
+// GET /api/services/:id/qr?format=png|svg&size=256&target=url|dashboard
+func (h *QRHandler) GetServiceQR(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	link, err := h.qrService.ServiceLink(uint(id), middleware.GetUserID(c), c.Query("target") == "dashboard")
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	writeQR(c, link)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// RackHandler handles room, rack and device placement endpoints
+type RackHandler struct {
+	service *services.RackService
+}
+
+// NewRackHandler creates a new RackHandler
+func NewRackHandler(service *services.RackService) *RackHandler {
+	return &RackHandler{service: service}
+}
+
+// GetRooms returns the user's rooms
+func (h *RackHandler) GetRooms(c *gin.Context) {
+	rooms, err := h.service.ListRooms(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rooms)
+}
+
+// CreateRoom adds a room
+func (h *RackHandler) CreateRoom(c *gin.Context) {
+	var req models.RoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	room, err := h.service.CreateRoom(middleware.GetUserID(c), req)
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, room)
+}
+
+// UpdateRoom renames or resizes a room
+func (h *RackHandler) UpdateRoom(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.RoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	room, err := h.service.UpdateRoom(id, middleware.GetUserID(c), req)
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// DeleteRoom removes a room, keeping its racks
+func (h *RackHandler) DeleteRoom(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "room")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRoom(id, middleware.GetUserID(c)); err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "room deleted"})
+}
+
+// GetFloorPlan returns a room with its racks for the floor plan view
+// GET /api/rooms/:id/plan
+func (h *RackHandler) GetFloorPlan(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "room")
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetFloorPlan(id, middleware.GetUserID(c))
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// GetRacks returns the user's racks with how full they are
+func (h *RackHandler) GetRacks(c *gin.Context) {
+	racks, err := h.service.ListRacks(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, racks)
+}
+
+// GetRack returns a rack's layout: its devices and per-unit elevation
+// GET /api/racks/:id
+func (h *RackHandler) GetRack(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+
+	layout, err := h.service.GetLayout(id, middleware.GetUserID(c))
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, layout)
+}
+
+// CreateRack adds a rack
+// POST /api/racks {"name": "Main", "units": 42, "roomId": 1, "posX": 2, "posY": 0}
+func (h *RackHandler) CreateRack(c *gin.Context) {
+	var req models.RackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	layout, err := h.service.CreateRack(middleware.GetUserID(c), req)
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, layout)
+}
+
+// UpdateRack renames, resizes or moves a rack
+func (h *RackHandler) UpdateRack(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+
+	var req models.RackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	layout, err := h.service.UpdateRack(id, middleware.GetUserID(c), req)
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, layout)
+}
+
+// DeleteRack removes a rack and unmounts its devices
+func (h *RackHandler) DeleteRack(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRack(id, middleware.GetUserID(c)); err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "rack deleted"})
+}
+
+// PlaceDevice mounts a device in a rack and returns the updated layout
+// POST /api/racks/:id/devices {"deviceId": 3, "position": 10, "height": 2, "face": "front"}
+func (h *RackHandler) PlaceDevice(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+
+	var req models.RackPlacementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	layout, err := h.service.PlaceDevice(id, middleware.GetUserID(c), req)
+	if err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, layout)
+}
+
+// RemoveDevice unmounts a device from a rack
+// DELETE /api/racks/:id/devices/:deviceId
+func (h *RackHandler) RemoveDevice(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+	deviceID, ok := parseRackParam(c, "deviceId", "device")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveDevice(id, deviceID, middleware.GetUserID(c)); err != nil {
+		respondRackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "device removed from rack"})
+}
+
+// ExportElevation downloads a rack's elevation as a drawing or spreadsheet
+// GET /api/racks/:id/elevation?format=svg|csv
+func (h *RackHandler) ExportElevation(c *gin.Context) {
+	id, ok := parseRackParam(c, "id", "rack")
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "svg")
+	data, contentType, err := h.service.ExportElevation(id, middleware.GetUserID(c), format)
+	if err != nil {
+		if errors.Is(err, services.ErrElevationFormat) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		respondRackError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="rack-%d-elevation.%s"`, id, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// parseRackParam parses a numeric path parameter, responding on failure
+func parseRackParam(c *gin.Context, param, what string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid "+what+" ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondRackError maps a missing room, rack or device to 404 and anything
+// else the service rejects to 400
+func respondRackError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+}
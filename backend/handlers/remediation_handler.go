@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// RemediationHandler handles remediation hook endpoints
+type RemediationHandler struct {
+	service *services.RemediationService
+}
+
+// NewRemediationHandler creates a new RemediationHandler
+func NewRemediationHandler(service *services.RemediationService) *RemediationHandler {
+	return &RemediationHandler{service: service}
+}
+
+// GetHooks returns the user's remediation hooks
+func (h *RemediationHandler) GetHooks(c *gin.Context) {
+	hooks, err := h.service.List(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateHook adds a remediation hook
+func (h *RemediationHandler) CreateHook(c *gin.Context) {
+	var req models.RemediationHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	hook, err := h.service.Create(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateHook replaces a remediation hook's settings
+func (h *RemediationHandler) UpdateHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	var req models.RemediationHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	hook, err := h.service.Update(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "remediation hook not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteHook removes a remediation hook
+func (h *RemediationHandler) DeleteHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	if err := h.service.Delete(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "remediation hook deleted"})
+}
+
+// RunHook executes a remediation hook immediately, ignoring its cooldown
+func (h *RemediationHandler) RunHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid hook ID")
+		return
+	}
+
+	hook, err := h.service.Run(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// ReportHandler handles incident and downtime report endpoints
+type ReportHandler struct {
+	service *services.ReportService
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(service *services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetIncidents lists failure periods of the user's services
+// Supports ?days=7&tag=media
+func (h *ReportHandler) GetIncidents(c *gin.Context) {
+	from, to := reportWindow(c)
+
+	incidents, err := h.service.Incidents(middleware.GetUserID(c), c.QueryArray("tag"), from, to)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+// GetDowntime ranks the user's services by impact-weighted downtime
+// Supports ?days=7&tag=media
+func (h *ReportHandler) GetDowntime(c *gin.Context) {
+	from, to := reportWindow(c)
+
+	report, err := h.service.Downtime(middleware.GetUserID(c), c.QueryArray("tag"), from, to)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// reportWindow returns the report range from ?days=, defaulting to a week
+func reportWindow(c *gin.Context) (time.Time, time.Time) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 || days > 365 {
+		days = 7
+	}
+	to := time.Now()
+	return to.AddDate(0, 0, -days), to
+}
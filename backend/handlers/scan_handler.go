@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// ScanHandler handles container image vulnerability scan endpoints
+type ScanHandler struct {
+	service *services.ScanService
+}
+
+// NewScanHandler creates a new ScanHandler
+func NewScanHandler(service *services.ScanService) *ScanHandler {
+	return &ScanHandler{service: service}
+}
+
+// GetScans returns the latest scan summary for every image
+func (h *ScanHandler) GetScans(c *gin.Context) {
+	scans, err := h.service.GetScans()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, scans)
+}
+
+// GetScan returns a single scan including its CVE list
+func (h *ScanHandler) GetScan(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid scan ID")
+		return
+	}
+
+	scan, err := h.service.GetScan(uint(id))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, scan)
+}
+
+// RunScan triggers a scan of all running images in the background
+func (h *ScanHandler) RunScan(c *gin.Context) {
+	if !h.service.IsEnabled() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Trivy scanning is not enabled")
+		return
+	}
+
+	go h.service.ScanRunningImages()
+	c.JSON(http.StatusAccepted, gin.H{"message": "Scan started"})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// ScreenshotHandler serves service thumbnails
+type ScreenshotHandler struct {
+	service *services.ScreenshotService
+}
+
+// NewScreenshotHandler creates a new ScreenshotHandler
+func NewScreenshotHandler(service *services.ScreenshotService) *ScreenshotHandler {
+	return &ScreenshotHandler{service: service}
+}
+
+// GetScreenshot returns the cached thumbnail of a service as a JPEG
+func (h *ScreenshotHandler) GetScreenshot(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	shot, err := h.service.Get(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.File(shot.Path)
+}
+
+// CaptureScreenshot takes a new thumbnail of a service now
+func (h *ScreenshotHandler) CaptureScreenshot(c *gin.Context) {
+	if !h.service.IsEnabled() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Screenshots are not enabled")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid service ID")
+		return
+	}
+
+	shot, err := h.service.Capture(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "service not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		if shot == nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Failed to capture screenshot", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, shot)
+}
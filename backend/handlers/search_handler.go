@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SearchHandler handles search endpoints
+type SearchHandler struct {
+	service *services.SearchService
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(service *services.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// SearchHistory searches audit entries, events and notes, newest first.
+// Audit entries are only searched for admins.
+// GET /api/search/history?q=restart&type=audit,event&from=RFC3339&to=RFC3339&userId=1&limit=50
+func (h *SearchHandler) SearchHistory(c *gin.Context) {
+	filter := models.HistoryFilter{Query: c.Query("q")}
+	if v := c.Query("type"); v != "" {
+		filter.Kinds = strings.Split(v, ",")
+	}
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid "+param.name+", expected RFC3339")
+			return
+		}
+		*param.dst = &t
+	}
+	if v := c.Query("userId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid userId")
+			return
+		}
+		userID := uint(id)
+		filter.UserID = &userID
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	admin := middleware.GetUserRole(c) == "admin"
+	entries, err := h.service.SearchHistory(middleware.GetUserID(c), admin, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearch) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Search failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SecurityHandler handles fail2ban/CrowdSec endpoints
+type SecurityHandler struct {
+	service *services.SecurityService
+}
+
+// NewSecurityHandler creates a new SecurityHandler
+func NewSecurityHandler(service *services.SecurityService) *SecurityHandler {
+	return &SecurityHandler{service: service}
+}
+
+// GetBans returns banned IPs and per-jail counters
+func (h *SecurityHandler) GetBans(c *gin.Context) {
+	if !h.service.IsConfigured() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Ban provider not configured")
+		return
+	}
+
+	report, err := h.service.GetBans()
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Failed to get bans", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Unban lifts a ban for an IP
+func (h *SecurityHandler) Unban(c *gin.Context) {
+	if !h.service.IsConfigured() {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeNotConfigured, "Ban provider not configured")
+		return
+	}
+
+	var req models.UnbanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.service.Unban(req, c.GetString("username")); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to unban IP", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "IP unbanned", "ip": req.IP})
+}
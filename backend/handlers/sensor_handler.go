@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SensorHandler handles sensor ingestion and sensor endpoints
+type SensorHandler struct {
+	service *services.SensorService
+}
+
+// NewSensorHandler creates a new SensorHandler
+func NewSensorHandler(service *services.SensorService) *SensorHandler {
+	return &SensorHandler{service: service}
+}
+
+// Ingest stores readings pushed by a sensor, authenticated by an ingest key
+// in X-API-Key, a Bearer token or ?key=
+// POST /api/ingest {"device": "living-room", "values": {"temperature": 21.5, "humidity": 40}}
+// POST /api/ingest?key=...&device=front-door&metric=door&value=open
+func (h *SensorHandler) Ingest(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "failed to read request body")
+		return
+	}
+
+	key := c.GetHeader("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	if key == "" {
+		key = c.Query("key")
+	}
+
+	result, err := h.service.Ingest(key, body, c.Request.URL.Query())
+	if err != nil {
+		if errors.Is(err, services.ErrIngestUnauthorized) {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetKeys returns the user's ingest keys (without their values)
+func (h *SensorHandler) GetKeys(c *gin.Context) {
+	keys, err := h.service.ListKeys(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateKey creates an ingest key; the value is only shown in this response
+func (h *SensorHandler) CreateKey(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	key, err := h.service.CreateKey(middleware.GetUserID(c), req.Name)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create ingest key", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// DeleteKey removes an ingest key
+func (h *SensorHandler) DeleteKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid key ID")
+		return
+	}
+
+	if err := h.service.DeleteKey(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ingest key deleted"})
+}
+
+// GetSensors returns the user's sensors with their latest values
+func (h *SensorHandler) GetSensors(c *gin.Context) {
+	sensors, err := h.service.ListSensors(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, sensors)
+}
+
+// UpdateSensor renames a sensor or sets its unit
+func (h *SensorHandler) UpdateSensor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sensor ID")
+		return
+	}
+
+	var req models.SensorUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	sensor, err := h.service.UpdateSensor(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, sensor)
+}
+
+// DeleteSensor removes a sensor and its history
+func (h *SensorHandler) DeleteSensor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sensor ID")
+		return
+	}
+
+	if err := h.service.DeleteSensor(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "sensor deleted"})
+}
+
+// GetHistory returns a sensor's readings, like the metrics history:
+// ?from=RFC3339&to=RFC3339 (default the last 24 hours) averaged into
+// ?bucket=seconds
+func (h *SensorHandler) GetHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid sensor ID")
+		return
+	}
+
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid from, expected RFC3339")
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid to, expected RFC3339")
+			return
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "from must be before to")
+		return
+	}
+
+	var bucket time.Duration
+	if v := c.Query("bucket"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid bucket")
+			return
+		}
+		bucket = time.Duration(seconds) * time.Second
+	}
+
+	history, err := h.service.GetHistory(uint(id), middleware.GetUserID(c), from, to, bucket)
+	if err != nil {
+		if err.Error() == "sensor not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
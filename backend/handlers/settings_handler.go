@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SettingsHandler handles instance-wide settings endpoints
+type SettingsHandler struct {
+	service *services.SettingsService
+}
+
+// NewSettingsHandler creates a new SettingsHandler
+func NewSettingsHandler(service *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{service: service}
+}
+
+// GetPasswordPolicy returns the password and lockout policy
+func (h *SettingsHandler) GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.PasswordPolicy())
+}
+
+// UpdatePasswordPolicy replaces the password and lockout policy (admin)
+func (h *SettingsHandler) UpdatePasswordPolicy(c *gin.Context) {
+	var req models.PasswordPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	policy, err := h.service.UpdatePasswordPolicy(req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Failed to update password policy", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetNetworkInterfaces returns which interfaces network metrics show and
+// their friendly names
+// GET /api/settings/network-interfaces
+func (h *SettingsHandler) GetNetworkInterfaces(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.NetworkInterfaces())
+}
+
+// UpdateNetworkInterfaces replaces the interface selection and names (admin)
+// PUT /api/settings/network-interfaces
+func (h *SettingsHandler) UpdateNetworkInterfaces(c *gin.Context) {
+	var req models.NetworkInterfaceSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	settings, err := h.service.UpdateNetworkInterfaces(req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "Failed to update network interfaces", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
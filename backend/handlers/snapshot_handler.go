@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SnapshotHandler handles homelab state snapshot endpoints
+type SnapshotHandler struct {
+	service *services.SnapshotService
+}
+
+// NewSnapshotHandler creates a new SnapshotHandler
+func NewSnapshotHandler(service *services.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{service: service}
+}
+
+// GetSnapshots returns snapshot summaries, newest first
+// Supports ?limit=30
+func (h *SnapshotHandler) GetSnapshots(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 {
+		limit = 30
+	}
+
+	snapshots, err := h.service.GetSnapshots(limit)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// GetSnapshot returns a single snapshot with its full data
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid snapshot ID")
+		return
+	}
+
+	snapshot, err := h.service.GetSnapshot(uint(id))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// TakeSnapshot captures a snapshot now
+func (h *SnapshotHandler) TakeSnapshot(c *gin.Context) {
+	snapshot, err := h.service.TakeSnapshot()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to take snapshot", err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// DiffSnapshots lists changes between two snapshots
+// GET /api/snapshots/diff?from=<id|date>&to=<id|date>
+// Dates (2006-01-02 or RFC3339) select the latest snapshot at or before that time;
+// "to" defaults to the most recent snapshot.
+func (h *SnapshotHandler) DiffSnapshots(c *gin.Context) {
+	if c.Query("from") == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "from is required")
+		return
+	}
+
+	from, err := h.resolve(c.Query("from"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+
+	to, err := h.resolve(c.DefaultQuery("to", time.Now().Format(time.RFC3339)))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, h.service.Diff(from, to))
+}
+
+// resolve finds a snapshot by ID or by date
+func (h *SnapshotHandler) resolve(value string) (*models.SnapshotDetail, error) {
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return h.service.GetSnapshot(uint(id))
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return h.service.FindSnapshot(t)
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	// A bare date means the end of that day
+	return h.service.FindSnapshot(t.Add(24*time.Hour - time.Second))
+}
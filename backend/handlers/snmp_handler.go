@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// SNMPHandler handles device SNMP endpoints
+type SNMPHandler struct {
+	service *services.SNMPService
+}
+
+// NewSNMPHandler creates a new SNMPHandler
+func NewSNMPHandler(service *services.SNMPService) *SNMPHandler {
+	return &SNMPHandler{service: service}
+}
+
+// respondSNMPError maps SNMP service errors to responses
+func respondSNMPError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "device not found", errors.Is(err, services.ErrSNMPNotConfigured):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidSNMPConfig):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, message, err.Error())
+	}
+}
+
+// GetStatus returns the latest SNMP poll of a device: uptime, interface
+// counters and rates, and CPU, memory and disks where the agent has them
+// GET /api/devices/:id/snmp?refresh=true
+func (h *SNMPHandler) GetStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	status, err := h.service.GetStatus(uint(id), middleware.GetUserID(c), c.Query("refresh") == "true")
+	if err != nil {
+		respondSNMPError(c, err, "Failed to get SNMP status")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetConfig returns a device's SNMP settings without the secrets
+// GET /api/devices/:id/snmp/config
+func (h *SNMPHandler) GetConfig(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	cfg, err := h.service.GetConfig(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		respondSNMPError(c, err, "Failed to get SNMP settings")
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// SaveConfig sets a device's SNMP settings
+// PUT /api/devices/:id/snmp/config
+func (h *SNMPHandler) SaveConfig(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+	var req models.SNMPConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	cfg, err := h.service.SaveConfig(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		respondSNMPError(c, err, "Failed to save SNMP settings")
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// DeleteConfig removes a device's SNMP settings, which stops its polling
+// DELETE /api/devices/:id/snmp/config
+func (h *SNMPHandler) DeleteConfig(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	if err := h.service.DeleteConfig(uint(id), middleware.GetUserID(c)); err != nil {
+		respondSNMPError(c, err, "Failed to delete SNMP settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "SNMP settings deleted"})
+}
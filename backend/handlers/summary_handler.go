@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/services"
+)
+
+// SummaryHandler serves the homelab overview, over REST and WebSocket
+type SummaryHandler struct {
+	service *services.SummaryService
+	health  *services.HealthService
+}
+
+// NewSummaryHandler creates a new SummaryHandler
+func NewSummaryHandler(service *services.SummaryService, health *services.HealthService) *SummaryHandler {
+	return &SummaryHandler{service: service, health: health}
+}
+
+// GetSummary returns the current overview of the user's homelab
+func (h *SummaryHandler) GetSummary(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetSummary(middleware.GetUserID(c)))
+}
+
+// GetScore returns the lab health score with the factors behind it and its
+// history over the last ?hours=24 (at most 30 days)
+// GET /api/summary/score
+func (h *SummaryHandler) GetScore(c *gin.Context) {
+	hours := 24
+	if v := c.Query("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 720 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "hours must be between 1 and 720")
+			return
+		}
+		hours = n
+	}
+
+	report, err := h.health.GetReport(hours)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get health score", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// StreamSummary pushes the overview every 5 seconds over a WebSocket. Kiosk
+// tokens have no user and get the whole lab.
+func (h *SummaryHandler) StreamSummary(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+
+	newWSStream(conn, 5*time.Second).run(func() (interface{}, bool) {
+		return h.service.GetSummary(userID), true
+	})
+}
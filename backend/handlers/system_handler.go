@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/services"
+)
+
+// SystemHandler handles backend system information endpoints
+type SystemHandler struct {
+	updateService *services.UpdateService
+}
+
+// NewSystemHandler creates a new SystemHandler
+func NewSystemHandler(updateService *services.UpdateService) *SystemHandler {
+	return &SystemHandler{updateService: updateService}
+}
+
+// GetVersion returns the running version and update availability
+func (h *SystemHandler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, h.updateService.GetVersionInfo())
+}
@@ -8,12 +8,12 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"runtime"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/middleware"
 )
 
@@ -27,8 +27,10 @@ var upgrader = websocket.Upgrader{
 
 // TerminalMessage represents a message between client and server
 type TerminalMessage struct {
-	Type string `json:"type"` // "input", "output", "error"
+	Type string `json:"type"` // "input", "output", "error", "resize"
 	Data string `json:"data"`
+	Cols uint   `json:"cols,omitempty"` // resize only
+	Rows uint   `json:"rows,omitempty"`
 }
 
 // TerminalHandler handles terminal WebSocket connections
@@ -46,7 +48,7 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 	// Authenticate (handled by middleware usually, but verify here)
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "unauthorized")
 		return
 	}
 
@@ -56,24 +58,14 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	defer GuardWebSocket(c, conn)()
+	tc := &terminalConn{conn: conn}
 
 	sessionID := fmt.Sprintf("term-%d-%d", userID, time.Now().UnixNano())
 	log.Printf("Terminal session started: %s", sessionID)
 
 	// Determine shell
-	shell := "bash"
-	args := []string{}
-	if runtime.GOOS == "windows" {
-		shell = "powershell"
-		// remove "-Command -" to allow interactive mode (prompts)
-		args = []string{"-NoLogo", "-NoExit"}
-	} else {
-		// Verify bash exists, fallback to sh
-		if _, err := exec.LookPath("bash"); err != nil {
-			shell = "sh"
-		}
-		args = []string{"-i"} // Force interactive for bash
-	}
+	shell, args := terminalShell()
 
 	// Prepare command with persistent pipes
 	cmd := exec.Command(shell, args...)
@@ -87,23 +79,23 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 	// Create pipes
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		h.sendError(conn, fmt.Sprintf("Failed to create stdin: %v", err))
+		tc.send("error", fmt.Sprintf("Failed to create stdin: %v", err))
 		return
 	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		h.sendError(conn, fmt.Sprintf("Failed to create stdout: %v", err))
+		tc.send("error", fmt.Sprintf("Failed to create stdout: %v", err))
 		return
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		h.sendError(conn, fmt.Sprintf("Failed to create stderr: %v", err))
+		tc.send("error", fmt.Sprintf("Failed to create stderr: %v", err))
 		return
 	}
 
 	// Start shell
 	if err := cmd.Start(); err != nil {
-		h.sendError(conn, fmt.Sprintf("Failed to start shell: %v", err))
+		tc.send("error", fmt.Sprintf("Failed to start shell: %v", err))
 		return
 	}
 
@@ -116,21 +108,29 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 
 		// Create a multi-reader for stdout and stderr (basic handling)
 		// Better to run separate goroutines, but simple for now
-		readOutput(conn, stdout, "output")
+		readOutput(tc, stdout, "output")
 	}()
 
 	go func() {
-		readOutput(conn, stderr, "error")
+		readOutput(tc, stderr, "error")
 	}()
 
-	h.sendOutput(conn, fmt.Sprintf("Connected to persistent %s session in %s\r\n\r\n", shell, cmd.Dir))
+	tc.send("output", fmt.Sprintf("Connected to persistent %s session in %s\r\n\r\n", shell, cmd.Dir))
 
 	// Inject custom prompt is NOT usually needed in interactive mode,
 	// but if it is missing, we can try injecting a newline to trigger it.
 	// For now, let's rely on standard interactive mode.
 
+	// Close idle or overlong sessions
+	limits := newTerminalLimits()
+	stop := make(chan struct{})
+	defer close(stop)
+	go limits.watch(tc, stop)
+
 	// Handle input from WebSocket
+	disconnected := make(chan struct{})
 	go func() {
+		defer close(disconnected)
 		for {
 			_, message, err := conn.ReadMessage()
 			if err != nil {
@@ -143,10 +143,11 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 			}
 
 			if (msg.Type == "input" || msg.Type == "command") && msg.Data != "" {
+				limits.Touch()
 				// Write to shell stdin
 				_, err := stdin.Write([]byte(msg.Data))
 				if err != nil {
-					h.sendError(conn, fmt.Sprintf("\r\nWrite error: %v", err))
+					tc.send("error", fmt.Sprintf("\r\nWrite error: %v", err))
 					return
 				}
 			}
@@ -154,35 +155,23 @@ func (h *TerminalHandler) HandleTerminalWS(c *gin.Context) {
 	}()
 
 	// Wait for process to exit or input loop to break (connection closed)
-	<-done
+	select {
+	case <-done:
+	case <-disconnected:
+	}
 	cmd.Process.Kill()
 	log.Printf("Terminal session ended: %s", sessionID)
 }
 
-func readOutput(conn *websocket.Conn, r io.Reader, msgType string) {
+func readOutput(tc *terminalConn, r io.Reader, msgType string) {
 	buf := make([]byte, 1024)
 	for {
 		n, err := r.Read(buf)
 		if n > 0 {
-			data := string(buf[:n])
-			msg := TerminalMessage{Type: msgType, Data: data}
-			msgBytes, _ := json.Marshal(msg)
-			conn.WriteMessage(websocket.TextMessage, msgBytes)
+			tc.send(msgType, string(buf[:n]))
 		}
 		if err != nil {
 			return
 		}
 	}
 }
-
-func (h *TerminalHandler) sendOutput(conn *websocket.Conn, data string) {
-	msg := TerminalMessage{Type: "output", Data: data}
-	msgBytes, _ := json.Marshal(msg)
-	conn.WriteMessage(websocket.TextMessage, msgBytes)
-}
-
-func (h *TerminalHandler) sendError(conn *websocket.Conn, data string) {
-	msg := TerminalMessage{Type: "error", Data: data}
-	msgBytes, _ := json.Marshal(msg)
-	conn.WriteMessage(websocket.TextMessage, msgBytes)
-}
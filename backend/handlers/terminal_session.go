@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/middleware"
+)
+
+// WSCloseSessionTimeout is the close code sent when a terminal session hits
+// its idle or maximum duration
+const WSCloseSessionTimeout = 4008
+
+// terminalWarning is how long before a limit the user is warned
+const terminalWarning = time.Minute
+
+// terminalConn serializes writes to a terminal WebSocket, which the output
+// readers and the session limits share
+type terminalConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// send writes a TerminalMessage
+func (t *terminalConn) send(msgType, data string) error {
+	msgBytes, _ := json.Marshal(TerminalMessage{Type: msgType, Data: data})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn.WriteMessage(websocket.TextMessage, msgBytes)
+}
+
+// terminalLimits closes a terminal after a period without input or once it
+// has run for the maximum duration, warning the user a minute before
+type terminalLimits struct {
+	idle      time.Duration
+	max       time.Duration
+	started   time.Time
+	lastInput atomic.Int64 // unix nanoseconds
+}
+
+// newTerminalLimits reads the configured limits
+func newTerminalLimits() *terminalLimits {
+	cfg := config.AppConfig
+	l := &terminalLimits{
+		idle:    time.Duration(cfg.TerminalIdleTimeout) * time.Minute,
+		max:     time.Duration(cfg.TerminalMaxDuration) * time.Minute,
+		started: time.Now(),
+	}
+	l.Touch()
+	return l
+}
+
+// Touch records user input, resetting the idle timer
+func (l *terminalLimits) Touch() {
+	l.lastInput.Store(time.Now().UnixNano())
+}
+
+// deadline returns the next limit to hit and why; zero when there is none
+func (l *terminalLimits) deadline() (time.Time, string) {
+	var deadline time.Time
+	var reason string
+	if l.idle > 0 {
+		deadline = time.Unix(0, l.lastInput.Load()).Add(l.idle)
+		reason = "idle"
+	}
+	if l.max > 0 {
+		if end := l.started.Add(l.max); deadline.IsZero() || end.Before(deadline) {
+			deadline, reason = end, "max"
+		}
+	}
+	return deadline, reason
+}
+
+// watch enforces the limits until stop is closed
+func (l *terminalLimits) watch(tc *terminalConn, stop <-chan struct{}) {
+	if l.idle <= 0 && l.max <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	var warned time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		deadline, reason := l.deadline()
+		remaining := time.Until(deadline)
+		switch {
+		case remaining <= 0:
+			message := "terminal closed after inactivity"
+			if reason == "max" {
+				message = "terminal reached its maximum session duration"
+			}
+			tc.send("error", "\r\n["+message+"]\r\n")
+			CloseWebSocket(tc.conn, WSCloseSessionTimeout, apierror.CodeSessionTimeout, message, middleware.TopicTerminal)
+			return
+		case remaining <= terminalWarning && !warned.Equal(deadline):
+			warned = deadline
+			seconds := int(remaining.Round(time.Second).Seconds())
+			if reason == "idle" {
+				tc.send("error", fmt.Sprintf("\r\n[Session idle, closing in %ds. Type anything to stay connected]\r\n", seconds))
+			} else {
+				tc.send("error", fmt.Sprintf("\r\n[Session reaches its maximum duration in %ds]\r\n", seconds))
+			}
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build darwin
+
+package handlers
+
+import (
+	"os"
+	"os/exec"
+)
+
+// terminalShell returns the shell and arguments for a terminal session.
+// macOS defaults to zsh and ships an outdated bash, so the user's login
+// shell is preferred, then zsh.
+func terminalShell() (string, []string) {
+	candidates := []string{os.Getenv("SHELL"), "zsh", "bash", "sh"}
+	for _, shell := range candidates {
+		if shell == "" {
+			continue
+		}
+		if path, err := exec.LookPath(shell); err == nil {
+			return path, []string{"-i"}
+		}
+	}
+	return "/bin/zsh", []string{"-i"}
+}
@@ -0,0 +1,23 @@
+//go:build !darwin
+
+package handlers
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// terminalShell returns the shell and arguments for a terminal session
+func terminalShell() (string, []string) {
+	if runtime.GOOS == "windows" {
+		// remove "-Command -" to allow interactive mode (prompts)
+		return "powershell", []string{"-NoLogo", "-NoExit"}
+	}
+
+	// Verify bash exists, fallback to sh
+	shell := "bash"
+	if _, err := exec.LookPath("bash"); err != nil {
+		shell = "sh"
+	}
+	return shell, []string{"-i"} // Force interactive for bash
+}
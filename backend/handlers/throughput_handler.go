@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// ThroughputHandler handles LAN throughput test endpoints
+type ThroughputHandler struct {
+	service       *services.ThroughputService
+	deviceService *services.DeviceService
+}
+
+// NewThroughputHandler creates a new ThroughputHandler
+func NewThroughputHandler(service *services.ThroughputService, deviceService *services.DeviceService) *ThroughputHandler {
+	return &ThroughputHandler{service: service, deviceService: deviceService}
+}
+
+// RunTest runs a throughput test against a host or registered device
+func (h *ThroughputHandler) RunTest(c *gin.Context) {
+	var req models.ThroughputRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	host := req.Host
+	if req.DeviceID != 0 {
+		device, err := h.deviceService.GetDevice(req.DeviceID, middleware.GetUserID(c))
+		if err != nil {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		host = device.IP
+	}
+	if host == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "host or deviceId is required")
+		return
+	}
+
+	results, err := h.service.RunTest(host, req.Port, req.Direction, req.Duration)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Throughput test failed", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// TopologyHandler handles the network map and link endpoints
+type TopologyHandler struct {
+	service *services.TopologyService
+}
+
+// NewTopologyHandler creates a new TopologyHandler
+func NewTopologyHandler(service *services.TopologyService) *TopologyHandler {
+	return &TopologyHandler{service: service}
+}
+
+// GetTopology returns the network map as nodes and edges with their status;
+// ?containers=false leaves out Docker networks and containers
+// GET /api/topology
+func (h *TopologyHandler) GetTopology(c *gin.Context) {
+	topology, err := h.service.GetTopology(middleware.GetUserID(c), c.Query("containers") != "false")
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build topology", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, topology)
+}
+
+// GetLinks returns the user's manual and LLDP links
+func (h *TopologyHandler) GetLinks(c *gin.Context) {
+	links, err := h.service.ListLinks(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// CreateLink adds a manual link between two devices
+// POST /api/topology/links {"deviceId": 1, "port": "ge-0/0/1", "targetDeviceId": 4, "targetPort": "eth0"}
+func (h *TopologyHandler) CreateLink(c *gin.Context) {
+	var req models.TopologyLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	link, err := h.service.CreateLink(middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// DeleteLink removes a link
+func (h *TopologyHandler) DeleteLink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid link ID")
+		return
+	}
+
+	if err := h.service.DeleteLink(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "link deleted"})
+}
+
+// ReportLLDP replaces a switch's LLDP neighbors, e.g. pushed from lldpctl
+// by a cron job. Links to neighbors it no longer reports are dropped.
+// PUT /api/topology/devices/:id/lldp {"neighbors": [{"localPort": "eth1", "chassisId": "aa:bb:..", "systemName": "nas", "portId": "enp3s0"}]}
+func (h *TopologyHandler) ReportLLDP(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid device ID")
+		return
+	}
+
+	var report models.LLDPReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	links, err := h.service.ReportLLDP(uint(id), middleware.GetUserID(c), report)
+	if err != nil {
+		if err.Error() == "device not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store LLDP neighbors", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
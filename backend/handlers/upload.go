@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readUpload returns the contents of an uploaded file, or nil if the field is absent.
+// The file must be at most maxSize bytes and, when allowed is not empty, its sniffed
+// content type must be one of the allowed media types.
+func readUpload(c *gin.Context, field string, maxSize int64, allowed ...string) ([]byte, error) {
+	file, err := c.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Size > maxSize {
+		return nil, fmt.Errorf("file is too large (max %d bytes)", maxSize)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is too large (max %d bytes)", maxSize)
+	}
+
+	if len(allowed) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		ok := false
+		for _, t := range allowed {
+			if mediaType == t {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unsupported file type %s", mediaType)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// WidgetHandler handles dashboard widget data source endpoints
+type WidgetHandler struct {
+	service *services.WidgetService
+}
+
+// NewWidgetHandler creates a new WidgetHandler
+func NewWidgetHandler(service *services.WidgetService) *WidgetHandler {
+	return &WidgetHandler{service: service}
+}
+
+// GetSources returns the user's widget sources
+func (h *WidgetHandler) GetSources(c *gin.Context) {
+	sources, err := h.service.ListSources(middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, sources)
+}
+
+// GetSource returns a single widget source
+func (h *WidgetHandler) GetSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid source ID")
+		return
+	}
+
+	source, err := h.service.GetSource(uint(id), middleware.GetUserID(c))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// CreateSource adds a widget source
+// POST /api/widgets/sources {"name": "qBittorrent", "url": "http://qbit:8080/api/v2/torrents/info",
+// "authType": "header", "authHeader": "Cookie", "secret": "SID=...", "cacheSeconds": 15,
+// "extract": {"downloading": "$[?state==\"downloading\"] | length"}}
+func (h *WidgetHandler) CreateSource(c *gin.Context) {
+	var req models.WidgetSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	source, err := h.service.CreateSource(middleware.GetUserID(c), req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, source)
+}
+
+// UpdateSource replaces a widget source's settings. An empty secret keeps
+// the stored one.
+func (h *WidgetHandler) UpdateSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid source ID")
+		return
+	}
+
+	var req models.WidgetSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	source, err := h.service.UpdateSource(uint(id), middleware.GetUserID(c), req)
+	if err != nil {
+		if err.Error() == "widget source not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteSource removes a widget source
+func (h *WidgetHandler) DeleteSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid source ID")
+		return
+	}
+
+	if err := h.service.DeleteSource(uint(id), middleware.GetUserID(c)); err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "widget source deleted"})
+}
+
+// GetData returns a source's extracted data, cached for its cache time
+// Supports ?refresh=true to bypass the cache
+func (h *WidgetHandler) GetData(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeBadRequest, "invalid source ID")
+		return
+	}
+
+	data, err := h.service.GetData(uint(id), middleware.GetUserID(c), c.Query("refresh") == "true")
+	if err != nil {
+		if err.Error() == "widget source not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Widget source failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// Preview fetches an unsaved source to try out its extraction paths
+// Supports ?sourceId= to reuse a saved source's secret
+func (h *WidgetHandler) Preview(c *gin.Context) {
+	var req models.WidgetSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	sourceID, _ := strconv.ParseUint(c.Query("sourceId"), 10, 32)
+
+	data, err := h.service.Preview(middleware.GetUserID(c), uint(sourceID), req)
+	if err != nil {
+		if err.Error() == "widget source not found" {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeUpstream, "Widget source failed", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
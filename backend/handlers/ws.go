@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/middleware"
+)
+
+// WSCloseTokenExpired is the application close code sent when the token
+// a WebSocket was opened with expires
+const WSCloseTokenExpired = 4001
+
+// wsCloseReason is the JSON close frame reason, matching the API error body
+type wsCloseReason struct {
+	Code  apierror.Code `json:"code"`
+	Error string        `json:"error"`
+	Topic string        `json:"topic,omitempty"`
+}
+
+// CloseWebSocket sends a close frame with a structured reason and closes the
+// connection. It is safe to call while another goroutine writes.
+func CloseWebSocket(conn *websocket.Conn, closeCode int, code apierror.Code, message, topic string) {
+	reason, _ := json.Marshal(wsCloseReason{Code: code, Error: message, Topic: topic})
+	// Control frame payloads are limited to 125 bytes including the 2-byte code
+	if len(reason) > 123 {
+		reason, _ = json.Marshal(wsCloseReason{Code: code, Error: "closed"})
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, string(reason)), time.Now().Add(time.Second))
+	conn.Close()
+}
+
+// GuardWebSocket closes conn when the caller's token expires. Call the
+// returned function when the connection ends.
+func GuardWebSocket(c *gin.Context, conn *websocket.Conn) func() {
+	expiresAt := middleware.GetTokenExpiry(c)
+	if expiresAt.IsZero() {
+		return func() {}
+	}
+
+	topic := c.GetString("wsTopic")
+	timer := time.AfterFunc(time.Until(expiresAt), func() {
+		CloseWebSocket(conn, WSCloseTokenExpired, apierror.CodeTokenInvalid, "token expired", topic)
+	})
+	return func() { timer.Stop() }
+}
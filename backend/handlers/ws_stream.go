@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsSendBuffer is how many frames wait for a slow client before the
+	// oldest is dropped
+	wsSendBuffer = 4
+	// wsWriteTimeout bounds one frame write; a client that takes longer is gone
+	wsWriteTimeout = 10 * time.Second
+	// wsPingInterval is how often the client is pinged; it must answer
+	// within wsPongWait
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+	// wsMaxSlowdown bounds how far a slow client's update interval grows
+	wsMaxSlowdown = 8
+	// wsRecoverTicks is how many ticks a client must keep up before its
+	// interval shrinks again
+	wsRecoverTicks = 3
+)
+
+// wsStream sends periodic updates to a WebSocket client without letting a
+// slow client hold up the producer. Frames wait in a small buffer that drops
+// the oldest when full, a writer goroutine drains it and pings the client,
+// and the update interval backs off while frames pile up.
+type wsStream struct {
+	conn *websocket.Conn
+	send chan []byte
+	done chan struct{}
+
+	base     time.Duration
+	interval time.Duration
+	clean    int // ticks in a row the client kept up
+}
+
+// newWSStream starts the writer and the reader, which only answers pongs
+// and notices when the client goes away
+func newWSStream(conn *websocket.Conn, interval time.Duration) *wsStream {
+	s := &wsStream{
+		conn:     conn,
+		send:     make(chan []byte, wsSendBuffer),
+		done:     make(chan struct{}),
+		base:     interval,
+		interval: interval,
+	}
+	go s.writeLoop()
+	go s.readLoop()
+	return s
+}
+
+// run calls produce every interval and queues its result until the client
+// leaves. produce returns false to skip a tick.
+func (s *wsStream) run(produce func() (interface{}, bool)) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// A frame still waiting from the last tick means the client is slow
+		if previous := s.interval; s.adjust() != previous {
+			ticker.Reset(s.interval)
+		}
+		if v, ok := produce(); ok {
+			s.queue(v)
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queue adds a frame, dropping the oldest waiting one when the buffer is full
+func (s *wsStream) queue(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("WebSocket encode error: %v", err)
+		return
+	}
+	for {
+		select {
+		case s.send <- data:
+			return
+		default:
+		}
+		select {
+		case <-s.send:
+		default:
+		}
+	}
+}
+
+// adjust doubles the interval while frames are waiting and halves it back
+// toward the base once the client has kept up for wsRecoverTicks ticks
+func (s *wsStream) adjust() time.Duration {
+	if len(s.send) > 0 {
+		s.clean = 0
+		s.interval = min(s.interval*2, s.base*wsMaxSlowdown)
+		return s.interval
+	}
+	s.clean++
+	if s.clean >= wsRecoverTicks && s.interval > s.base {
+		s.clean = 0
+		s.interval = max(s.interval/2, s.base)
+	}
+	return s.interval
+}
+
+// writeLoop writes queued frames and pings until a write fails
+func (s *wsStream) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case data := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// readLoop discards client messages, extending the read deadline on every
+// pong, and ends the stream when the connection fails
+func (s *wsStream) readLoop() {
+	defer close(s.done)
+
+	s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := s.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Package builtin adapts the backend's own subsystems (firewall, fail2ban/CrowdSec,
+// Trivy), media servers (Jellyfin, Plex), torrent clients (qBittorrent,
+// Transmission) and generic outputs (webhook) to the integration interfaces.
+package builtin
+
+import (
+	"github.com/homelab/backend/integrations"
+	"github.com/homelab/backend/services"
+)
+
+// RegisterAll registers the built-in integrations into the default registry
+func RegisterAll(firewall *services.FirewallService, security *services.SecurityService, scans *services.ScanService, events *services.EventService) {
+	integrations.Register(&firewallIntegration{service: firewall})
+	integrations.Register(&securityIntegration{service: security})
+	integrations.Register(&trivyIntegration{service: scans})
+	integrations.Register(&webhookIntegration{})
+	integrations.Register(newJellyfinIntegration(events))
+	integrations.Register(newPlexIntegration(events))
+	integrations.Register(newQBittorrentIntegration())
+	integrations.Register(newTransmissionIntegration())
+}
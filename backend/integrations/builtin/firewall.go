@@ -0,0 +1,38 @@
+package builtin
+
+import (
+	"context"
+
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// firewallIntegration exposes firewall status and rules as a collector.
+// The provider itself is configured through FIREWALL_* environment variables.
+type firewallIntegration struct {
+	service *services.FirewallService
+}
+
+func (f *firewallIntegration) Name() string { return "firewall" }
+
+func (f *firewallIntegration) Description() string {
+	return "Firewall status and rules (ufw, nftables, OPNsense, pfSense)"
+}
+
+func (f *firewallIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{}
+}
+
+func (f *firewallIntegration) Configure(config map[string]string) error { return nil }
+
+func (f *firewallIntegration) Collect(ctx context.Context) (interface{}, error) {
+	status, err := f.service.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := f.service.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": status, "rules": rules}, nil
+}
@@ -0,0 +1,394 @@
+package builtin
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// maxMediaResponse is the largest session list read from a media server
+const maxMediaResponse = 5 << 20
+
+// mediaIntegration collects active streams from a media server and, while
+// enabled, polls it to record an event when a hardware transcode starts
+type mediaIntegration struct {
+	server      string // jellyfin or plex
+	description string
+	tokenField  models.IntegrationField
+	fetch       func(ctx context.Context, m *mediaIntegration) ([]models.MediaSession, error)
+	events      *services.EventService
+
+	mu     sync.RWMutex
+	url    string
+	token  string
+	client *http.Client
+	stop   chan struct{}
+}
+
+func newJellyfinIntegration(events *services.EventService) *mediaIntegration {
+	return &mediaIntegration{
+		server:      "jellyfin",
+		description: "Active Jellyfin streams, transcodes and bandwidth",
+		tokenField: models.IntegrationField{Key: "token", Label: "API key", Type: models.FieldSecret, Required: true,
+			Description: "Created under Dashboard > API Keys"},
+		fetch:  fetchJellyfinSessions,
+		events: events,
+	}
+}
+
+func newPlexIntegration(events *services.EventService) *mediaIntegration {
+	return &mediaIntegration{
+		server:      "plex",
+		description: "Active Plex streams, transcodes and bandwidth",
+		tokenField: models.IntegrationField{Key: "token", Label: "X-Plex-Token", Type: models.FieldSecret, Required: true,
+			Description: "The server owner's token, see Plex's \"Finding an authentication token\" article"},
+		fetch:  fetchPlexSessions,
+		events: events,
+	}
+}
+
+func (m *mediaIntegration) Name() string { return m.server }
+
+func (m *mediaIntegration) Description() string { return m.description }
+
+func (m *mediaIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{
+		{Key: "url", Label: "Server URL", Type: models.FieldURL, Required: true},
+		m.tokenField,
+		{Key: "skipTlsVerify", Label: "Skip TLS verification", Type: models.FieldBool, Default: "false"},
+		{Key: "pollSeconds", Label: "Poll interval (seconds)", Type: models.FieldNumber, Default: "30",
+			Description: "How often streams are checked for new GPU transcodes; 0 disables the events"},
+	}
+}
+
+func (m *mediaIntegration) Configure(config map[string]string) error {
+	u, err := url.Parse(config["url"])
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	skipVerify, _ := strconv.ParseBool(config["skipTlsVerify"])
+	poll, _ := strconv.Atoi(config["pollSeconds"])
+	if poll != 0 && poll < 10 {
+		return fmt.Errorf("pollSeconds must be 0 or at least 10")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify}
+
+	m.Stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.url = strings.TrimRight(config["url"], "/")
+	m.token = config["token"]
+	m.client = &http.Client{Timeout: 15 * time.Second, Transport: transport}
+	if poll > 0 {
+		m.stop = make(chan struct{})
+		go m.watch(m.stop, time.Duration(poll)*time.Second)
+	}
+	return nil
+}
+
+// Stop ends the transcode watcher
+func (m *mediaIntegration) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+func (m *mediaIntegration) Collect(ctx context.Context) (interface{}, error) {
+	sessions, err := m.fetch(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	result := models.MediaSessions{Server: m.server, Sessions: sessions, Streams: len(sessions), FetchedAt: time.Now()}
+	for _, session := range sessions {
+		if session.Decision == models.PlayTranscode {
+			result.Transcodes++
+			if session.HWAcceleration != "" {
+				result.HWTranscodes++
+			}
+		} else {
+			result.DirectPlays++
+		}
+		result.BandwidthKbps += session.BandwidthKbps
+	}
+	return result, nil
+}
+
+// watch polls the server and records an event for each stream that starts
+// transcoding on the GPU. Streams already transcoding on the first poll are
+// not reported.
+func (m *mediaIntegration) watch(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var seen map[string]bool
+	failing := false
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		sessions, err := m.fetch(ctx, m)
+		cancel()
+
+		switch {
+		case err != nil:
+			if !failing {
+				log.Printf("Failed to poll %s sessions: %v", m.server, err)
+			}
+			failing = true
+		default:
+			failing = false
+			current := make(map[string]bool)
+			for _, session := range sessions {
+				if session.Decision != models.PlayTranscode || session.HWAcceleration == "" {
+					continue
+				}
+				current[session.ID] = true
+				if seen != nil && !seen[session.ID] {
+					m.events.Record("transcode_started", models.SeverityInfo, m.server, "GPU transcode started",
+						fmt.Sprintf("%s is transcoding %s for %s with %s", session.User, session.Title, session.Device, session.HWAcceleration),
+						session)
+				}
+			}
+			seen = current
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// get requests a path on the server and decodes the JSON response
+func (m *mediaIntegration) get(ctx context.Context, path string, header string, out interface{}) error {
+	m.mu.RLock()
+	base, token, client := m.url, m.token, m.client
+	m.mu.RUnlock()
+	if base == "" {
+		return fmt.Errorf("%s is not configured", m.server)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+	req.Header.Set(header, token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s rejected the token", m.server)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d", m.server, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxMediaResponse)).Decode(out)
+}
+
+// episodeTitle formats "Series - S01E02 - Episode", or the plain title
+func episodeTitle(series string, season, episode int, title string) string {
+	if series == "" {
+		return title
+	}
+	return fmt.Sprintf("%s - S%02dE%02d - %s", series, season, episode, title)
+}
+
+// jellyfinSession is the part of a Jellyfin /Sessions entry used here
+type jellyfinSession struct {
+	ID             string `json:"Id"`
+	UserName       string `json:"UserName"`
+	Client         string `json:"Client"`
+	DeviceName     string `json:"DeviceName"`
+	NowPlayingItem *struct {
+		Name              string `json:"Name"`
+		SeriesName        string `json:"SeriesName"`
+		Type              string `json:"Type"`
+		ParentIndexNumber int    `json:"ParentIndexNumber"`
+		IndexNumber       int    `json:"IndexNumber"`
+		RunTimeTicks      int64  `json:"RunTimeTicks"`
+		MediaStreams      []struct {
+			BitRate int64 `json:"BitRate"`
+		} `json:"MediaStreams"`
+	} `json:"NowPlayingItem"`
+	PlayState struct {
+		PositionTicks int64  `json:"PositionTicks"`
+		IsPaused      bool   `json:"IsPaused"`
+		PlayMethod    string `json:"PlayMethod"` // DirectPlay, DirectStream, Transcode
+	} `json:"PlayState"`
+	TranscodingInfo *struct {
+		VideoCodec               string   `json:"VideoCodec"`
+		IsVideoDirect            bool     `json:"IsVideoDirect"`
+		Bitrate                  int64    `json:"Bitrate"`
+		HardwareAccelerationType string   `json:"HardwareAccelerationType"`
+		TranscodeReasons         []string `json:"TranscodeReasons"`
+	} `json:"TranscodingInfo"`
+}
+
+func fetchJellyfinSessions(ctx context.Context, m *mediaIntegration) ([]models.MediaSession, error) {
+	var raw []jellyfinSession
+	if err := m.get(ctx, "/Sessions?activeWithinSeconds=960", "X-Emby-Token", &raw); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.MediaSession, 0)
+	for _, s := range raw {
+		item := s.NowPlayingItem
+		if item == nil {
+			continue
+		}
+		session := models.MediaSession{
+			ID:       s.ID,
+			User:     s.UserName,
+			Title:    episodeTitle(item.SeriesName, item.ParentIndexNumber, item.IndexNumber, item.Name),
+			Type:     strings.ToLower(item.Type),
+			Client:   s.Client,
+			Device:   s.DeviceName,
+			State:    "playing",
+			Decision: models.PlayDirect,
+		}
+		if s.PlayState.IsPaused {
+			session.State = "paused"
+		}
+		if item.RunTimeTicks > 0 {
+			session.Progress = float64(s.PlayState.PositionTicks) / float64(item.RunTimeTicks) * 100
+		}
+		for _, stream := range item.MediaStreams {
+			session.BandwidthKbps += stream.BitRate / 1000
+		}
+
+		switch s.PlayState.PlayMethod {
+		case "DirectStream":
+			session.Decision = models.PlayStream
+		case "Transcode":
+			session.Decision = models.PlayTranscode
+		}
+		if t := s.TranscodingInfo; t != nil && session.Decision == models.PlayTranscode {
+			session.VideoCodec = t.VideoCodec
+			session.TranscodeReason = strings.Join(t.TranscodeReasons, ", ")
+			if t.Bitrate > 0 {
+				session.BandwidthKbps = t.Bitrate / 1000
+			}
+			if !t.IsVideoDirect && t.HardwareAccelerationType != "" && t.HardwareAccelerationType != "none" {
+				session.HWAcceleration = t.HardwareAccelerationType
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// plexSession is the part of a Plex /status/sessions entry used here
+type plexSession struct {
+	SessionKey       string `json:"sessionKey"`
+	Title            string `json:"title"`
+	GrandparentTitle string `json:"grandparentTitle"`
+	ParentIndex      int    `json:"parentIndex"`
+	Index            int    `json:"index"`
+	Type             string `json:"type"`
+	Duration         int64  `json:"duration"`
+	ViewOffset       int64  `json:"viewOffset"`
+	User             struct {
+		Title string `json:"title"`
+	} `json:"User"`
+	Player struct {
+		Title   string `json:"title"`
+		Product string `json:"product"`
+		State   string `json:"state"`
+		Local   bool   `json:"local"`
+	} `json:"Player"`
+	Session struct {
+		ID        string `json:"id"`
+		Bandwidth int64  `json:"bandwidth"` // kbps
+	} `json:"Session"`
+	Media []struct {
+		Bitrate int64 `json:"bitrate"` // kbps
+	} `json:"Media"`
+	TranscodeSession *struct {
+		VideoDecision       string `json:"videoDecision"` // transcode, copy
+		AudioDecision       string `json:"audioDecision"`
+		VideoCodec          string `json:"videoCodec"`
+		TranscodeHwEncoding string `json:"transcodeHwEncoding"`
+		TranscodeHwDecoding string `json:"transcodeHwDecoding"`
+	} `json:"TranscodeSession"`
+}
+
+func fetchPlexSessions(ctx context.Context, m *mediaIntegration) ([]models.MediaSession, error) {
+	var raw struct {
+		MediaContainer struct {
+			Metadata []plexSession `json:"Metadata"`
+		} `json:"MediaContainer"`
+	}
+	if err := m.get(ctx, "/status/sessions", "X-Plex-Token", &raw); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.MediaSession, 0, len(raw.MediaContainer.Metadata))
+	for _, s := range raw.MediaContainer.Metadata {
+		local := s.Player.Local
+		title := s.Title
+		if s.Type == "episode" {
+			title = episodeTitle(s.GrandparentTitle, s.ParentIndex, s.Index, s.Title)
+		}
+		session := models.MediaSession{
+			ID:            s.Session.ID,
+			User:          s.User.Title,
+			Title:         title,
+			Type:          s.Type,
+			Client:        s.Player.Product,
+			Device:        s.Player.Title,
+			State:         s.Player.State,
+			Decision:      models.PlayDirect,
+			BandwidthKbps: s.Session.Bandwidth,
+			Local:         &local,
+		}
+		if session.ID == "" {
+			session.ID = s.SessionKey
+		}
+		if s.Duration > 0 {
+			session.Progress = float64(s.ViewOffset) / float64(s.Duration) * 100
+		}
+		if session.BandwidthKbps == 0 && len(s.Media) > 0 {
+			session.BandwidthKbps = s.Media[0].Bitrate
+		}
+
+		if t := s.TranscodeSession; t != nil {
+			session.Decision = models.PlayStream
+			// Music has no video decision
+			if t.VideoDecision == "transcode" || (t.VideoDecision == "" && t.AudioDecision == "transcode") {
+				session.Decision = models.PlayTranscode
+				session.VideoCodec = t.VideoCodec
+			}
+			if t.VideoDecision == "transcode" {
+				session.HWAcceleration = t.TranscodeHwEncoding
+				if session.HWAcceleration == "" {
+					session.HWAcceleration = t.TranscodeHwDecoding
+				}
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
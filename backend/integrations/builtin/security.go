@@ -0,0 +1,58 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// securityIntegration exposes fail2ban/CrowdSec bans as a collector and unban as an action.
+// The provider itself is configured through BAN_PROVIDER and CROWDSEC_* environment variables.
+type securityIntegration struct {
+	service *services.SecurityService
+}
+
+func (s *securityIntegration) Name() string { return "bans" }
+
+func (s *securityIntegration) Description() string {
+	return "Banned IPs from fail2ban or CrowdSec"
+}
+
+func (s *securityIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{}
+}
+
+func (s *securityIntegration) Configure(config map[string]string) error { return nil }
+
+func (s *securityIntegration) Collect(ctx context.Context) (interface{}, error) {
+	return s.service.GetBans()
+}
+
+func (s *securityIntegration) Actions() []models.IntegrationAction {
+	return []models.IntegrationAction{{
+		Name:        "unban",
+		Description: "Remove a ban for an IP address",
+		Params: []models.IntegrationField{
+			{Key: "ip", Label: "IP address", Type: models.FieldString, Required: true},
+			{Key: "jail", Label: "Jail (fail2ban only)", Type: models.FieldString},
+		},
+	}}
+}
+
+func (s *securityIntegration) Execute(ctx context.Context, action string, params map[string]string) (interface{}, error) {
+	if action != "unban" {
+		return nil, fmt.Errorf("unknown action: %s", action)
+	}
+	if net.ParseIP(params["ip"]) == nil {
+		return nil, fmt.Errorf("invalid IP address")
+	}
+
+	req := models.UnbanRequest{IP: params["ip"], Jail: params["jail"]}
+	if err := s.service.Unban(req, "integration:bans"); err != nil {
+		return nil, err
+	}
+	return map[string]string{"message": "IP unbanned"}, nil
+}
@@ -0,0 +1,542 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// maxTorrentResponse is the largest response read from a torrent client
+const maxTorrentResponse = 20 << 20
+
+// torrentClient is the API of one kind of download client
+type torrentClient interface {
+	// torrents returns every torrent and the global transfer state
+	torrents(ctx context.Context) (*models.TorrentStatus, []models.Torrent, error)
+	setPaused(ctx context.Context, paused bool) error
+	// setLimits sets the global speed limits in bytes/s, 0 for unlimited
+	setLimits(ctx context.Context, download, upload int64) error
+}
+
+// torrentIntegration shows a download client's active torrents and speeds,
+// and pauses, resumes or throttles it
+type torrentIntegration struct {
+	name        string
+	description string
+	newClient   func(base, username, password string, httpClient *http.Client) torrentClient
+
+	mu     sync.RWMutex
+	client torrentClient
+}
+
+func newQBittorrentIntegration() *torrentIntegration {
+	return &torrentIntegration{
+		name:        "qbittorrent",
+		description: "qBittorrent downloads, speeds and throttling",
+		newClient: func(base, username, password string, httpClient *http.Client) torrentClient {
+			return &qbittorrentClient{base: base, username: username, password: password, http: httpClient}
+		},
+	}
+}
+
+func newTransmissionIntegration() *torrentIntegration {
+	return &torrentIntegration{
+		name:        "transmission",
+		description: "Transmission downloads, speeds and throttling",
+		newClient: func(base, username, password string, httpClient *http.Client) torrentClient {
+			return &transmissionClient{base: base, username: username, password: password, http: httpClient}
+		},
+	}
+}
+
+func (t *torrentIntegration) Name() string { return t.name }
+
+func (t *torrentIntegration) Description() string { return t.description }
+
+func (t *torrentIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{
+		{Key: "url", Label: "Web UI URL", Type: models.FieldURL, Required: true},
+		{Key: "username", Label: "Username", Type: models.FieldString},
+		{Key: "password", Label: "Password", Type: models.FieldSecret},
+		{Key: "skipTlsVerify", Label: "Skip TLS verification", Type: models.FieldBool, Default: "false"},
+	}
+}
+
+func (t *torrentIntegration) Configure(config map[string]string) error {
+	u, err := url.Parse(config["url"])
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	skipVerify, _ := strconv.ParseBool(config["skipTlsVerify"])
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify}
+	jar, _ := cookiejar.New(nil)
+	httpClient := &http.Client{Timeout: 15 * time.Second, Transport: transport, Jar: jar}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.client = t.newClient(strings.TrimRight(config["url"], "/"), config["username"], config["password"], httpClient)
+	return nil
+}
+
+// current returns the configured client
+func (t *torrentIntegration) current() (torrentClient, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.client == nil {
+		return nil, fmt.Errorf("%s is not configured", t.name)
+	}
+	return t.client, nil
+}
+
+func (t *torrentIntegration) Collect(ctx context.Context) (interface{}, error) {
+	client, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	status, torrents, err := client.torrents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Client = t.name
+	status.Total = len(torrents)
+	status.Torrents = make([]models.Torrent, 0)
+	status.FetchedAt = time.Now()
+	for _, torrent := range torrents {
+		active := torrent.DownloadSpeed > 0 || torrent.UploadSpeed > 0
+		switch torrent.State {
+		case models.TorrentDownloading, models.TorrentStalled:
+			status.Downloading++
+			active = true
+		case models.TorrentSeeding:
+			status.Seeding++
+		case models.TorrentPaused, models.TorrentCompleted:
+			status.Paused++
+		case models.TorrentError:
+			status.Errored++
+			active = true
+		case models.TorrentQueued, models.TorrentChecking:
+			active = true
+		}
+		if active {
+			status.Torrents = append(status.Torrents, torrent)
+		}
+	}
+
+	// Fastest downloads first
+	sort.SliceStable(status.Torrents, func(i, j int) bool {
+		return status.Torrents[i].DownloadSpeed > status.Torrents[j].DownloadSpeed
+	})
+	return status, nil
+}
+
+func (t *torrentIntegration) Actions() []models.IntegrationAction {
+	return []models.IntegrationAction{
+		{Name: "pause_all", Description: "Pause every torrent", Params: []models.IntegrationField{}},
+		{Name: "resume_all", Description: "Resume every torrent", Params: []models.IntegrationField{}},
+		{Name: "throttle", Description: "Set the global speed limits", Params: []models.IntegrationField{
+			{Key: "download", Label: "Download limit (KiB/s)", Type: models.FieldNumber, Required: true, Description: "0 for unlimited"},
+			{Key: "upload", Label: "Upload limit (KiB/s)", Type: models.FieldNumber, Required: true, Description: "0 for unlimited"},
+		}},
+	}
+}
+
+func (t *torrentIntegration) Execute(ctx context.Context, action string, params map[string]string) (interface{}, error) {
+	client, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "pause_all", "resume_all":
+		if err := client.setPaused(ctx, action == "pause_all"); err != nil {
+			return nil, err
+		}
+		if action == "pause_all" {
+			return map[string]string{"message": "All torrents paused"}, nil
+		}
+		return map[string]string{"message": "All torrents resumed"}, nil
+
+	case "throttle":
+		download, _ := strconv.ParseFloat(params["download"], 64)
+		upload, _ := strconv.ParseFloat(params["upload"], 64)
+		if download < 0 || upload < 0 {
+			return nil, fmt.Errorf("limits must not be negative")
+		}
+		if err := client.setLimits(ctx, int64(download*1024), int64(upload*1024)); err != nil {
+			return nil, err
+		}
+		return map[string]string{"message": "Speed limits updated"}, nil
+	}
+	return nil, fmt.Errorf("unknown action: %s", action)
+}
+
+// qbittorrentClient talks to the qBittorrent Web API v2, logging in with a
+// session cookie when credentials are set
+type qbittorrentClient struct {
+	base, username, password string
+	http                     *http.Client
+}
+
+// call posts or gets an API path, logging in again once if the session expired
+func (q *qbittorrentClient) call(ctx context.Context, method, path string, form url.Values) (int, []byte, error) {
+	status, body, err := q.send(ctx, method, path, form)
+	if err == nil && status == http.StatusForbidden && q.username != "" {
+		if err := q.login(ctx); err != nil {
+			return 0, nil, err
+		}
+		status, body, err = q.send(ctx, method, path, form)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if status == http.StatusForbidden {
+		return 0, nil, fmt.Errorf("qbittorrent rejected the request; check the username and password")
+	}
+	return status, body, nil
+}
+
+func (q *qbittorrentClient) send(ctx context.Context, method, path string, form url.Values) (int, []byte, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, q.base+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	// qBittorrent's CSRF check compares the Referer with its own host
+	req.Header.Set("Referer", q.base)
+	req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := q.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentResponse))
+	return resp.StatusCode, data, err
+}
+
+func (q *qbittorrentClient) login(ctx context.Context) error {
+	status, body, err := q.send(ctx, "POST", "/api/v2/auth/login", url.Values{"username": {q.username}, "password": {q.password}})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "Ok." {
+		return fmt.Errorf("qbittorrent login failed")
+	}
+	return nil
+}
+
+// get fetches an API path and decodes its JSON
+func (q *qbittorrentClient) get(ctx context.Context, path string, out interface{}) error {
+	status, body, err := q.call(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("qbittorrent returned %d", status)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// qbittorrentStates maps qBittorrent's torrent states
+var qbittorrentStates = map[string]string{
+	"downloading": models.TorrentDownloading, "metaDL": models.TorrentDownloading, "forcedDL": models.TorrentDownloading,
+	"forcedMetaDL": models.TorrentDownloading, "stalledDL": models.TorrentStalled,
+	"uploading": models.TorrentSeeding, "forcedUP": models.TorrentSeeding, "stalledUP": models.TorrentSeeding,
+	"pausedDL": models.TorrentPaused, "stoppedDL": models.TorrentPaused,
+	"pausedUP": models.TorrentCompleted, "stoppedUP": models.TorrentCompleted,
+	"queuedDL": models.TorrentQueued, "queuedUP": models.TorrentQueued,
+	"checkingDL": models.TorrentChecking, "checkingUP": models.TorrentChecking, "checkingResumeData": models.TorrentChecking,
+	"moving": models.TorrentChecking, "allocating": models.TorrentChecking,
+	"error": models.TorrentError, "missingFiles": models.TorrentError,
+}
+
+func (q *qbittorrentClient) torrents(ctx context.Context) (*models.TorrentStatus, []models.Torrent, error) {
+	var transfer struct {
+		DownloadSpeed int64 `json:"dl_info_speed"`
+		UploadSpeed   int64 `json:"up_info_speed"`
+		DownloadLimit int64 `json:"dl_rate_limit"`
+		UploadLimit   int64 `json:"up_rate_limit"`
+	}
+	if err := q.get(ctx, "/api/v2/transfer/info", &transfer); err != nil {
+		return nil, nil, err
+	}
+	var raw []struct {
+		Hash     string  `json:"hash"`
+		Name     string  `json:"name"`
+		State    string  `json:"state"`
+		Progress float64 `json:"progress"` // 0 to 1
+		Size     int64   `json:"size"`
+		DLSpeed  int64   `json:"dlspeed"`
+		UPSpeed  int64   `json:"upspeed"`
+		ETA      int64   `json:"eta"`
+		Ratio    float64 `json:"ratio"`
+	}
+	if err := q.get(ctx, "/api/v2/torrents/info", &raw); err != nil {
+		return nil, nil, err
+	}
+
+	torrents := make([]models.Torrent, 0, len(raw))
+	for _, r := range raw {
+		state, ok := qbittorrentStates[r.State]
+		if !ok {
+			state = models.TorrentQueued
+		}
+		eta := r.ETA
+		// qBittorrent reports 8640000 (100 days) for "infinite"
+		if eta >= 8640000 {
+			eta = -1
+		}
+		torrent := models.Torrent{
+			ID: r.Hash, Name: r.Name, State: state, Progress: r.Progress * 100, Size: r.Size,
+			DownloadSpeed: r.DLSpeed, UploadSpeed: r.UPSpeed, ETA: eta, Ratio: r.Ratio,
+		}
+		if state == models.TorrentError {
+			torrent.Error = r.State
+		}
+		torrents = append(torrents, torrent)
+	}
+
+	status := &models.TorrentStatus{
+		DownloadSpeed: transfer.DownloadSpeed,
+		UploadSpeed:   transfer.UploadSpeed,
+		DownloadLimit: transfer.DownloadLimit,
+		UploadLimit:   transfer.UploadLimit,
+	}
+	return status, torrents, nil
+}
+
+func (q *qbittorrentClient) setPaused(ctx context.Context, paused bool) error {
+	// qBittorrent 5 renamed pause/resume to stop/start
+	paths := []string{"/api/v2/torrents/resume", "/api/v2/torrents/start"}
+	if paused {
+		paths = []string{"/api/v2/torrents/pause", "/api/v2/torrents/stop"}
+	}
+	for _, path := range paths {
+		status, _, err := q.call(ctx, "POST", path, url.Values{"hashes": {"all"}})
+		if err != nil {
+			return err
+		}
+		if status == http.StatusNotFound {
+			continue
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("qbittorrent returned %d", status)
+		}
+		return nil
+	}
+	return fmt.Errorf("qbittorrent does not support pausing torrents")
+}
+
+func (q *qbittorrentClient) setLimits(ctx context.Context, download, upload int64) error {
+	for path, limit := range map[string]int64{
+		"/api/v2/transfer/setDownloadLimit": download,
+		"/api/v2/transfer/setUploadLimit":   upload,
+	} {
+		status, _, err := q.call(ctx, "POST", path, url.Values{"limit": {strconv.FormatInt(limit, 10)}})
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("qbittorrent returned %d", status)
+		}
+	}
+	return nil
+}
+
+// transmissionClient talks to Transmission's JSON-RPC API, which requires the
+// session ID it hands out in a 409 response
+type transmissionClient struct {
+	base, username, password string
+	http                     *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// rpc calls a method, fetching a new session ID once if the old one expired
+func (t *transmissionClient) rpc(ctx context.Context, method string, arguments interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"method": method, "arguments": arguments})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", t.base+"/transmission/rpc", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+		t.mu.Lock()
+		req.Header.Set("X-Transmission-Session-Id", t.sessionID)
+		t.mu.Unlock()
+		if t.username != "" {
+			req.SetBasicAuth(t.username, t.password)
+		}
+
+		resp, err := t.http.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentResponse))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusConflict:
+			t.mu.Lock()
+			t.sessionID = resp.Header.Get("X-Transmission-Session-Id")
+			t.mu.Unlock()
+			continue
+		case http.StatusUnauthorized:
+			return fmt.Errorf("transmission rejected the username or password")
+		case http.StatusOK:
+		default:
+			return fmt.Errorf("transmission returned %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Result    string          `json:"result"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return err
+		}
+		if result.Result != "success" {
+			return fmt.Errorf("transmission: %s", result.Result)
+		}
+		if out != nil {
+			return json.Unmarshal(result.Arguments, out)
+		}
+		return nil
+	}
+	return fmt.Errorf("transmission did not accept the session ID")
+}
+
+// transmissionStates maps Transmission's numeric torrent status
+var transmissionStates = map[int]string{
+	0: models.TorrentPaused,
+	1: models.TorrentChecking, 2: models.TorrentChecking,
+	3: models.TorrentQueued, 5: models.TorrentQueued,
+	4: models.TorrentDownloading,
+	6: models.TorrentSeeding,
+}
+
+// transmissionKB is Transmission's speed unit in bytes
+const transmissionKB = 1000
+
+func (t *transmissionClient) torrents(ctx context.Context) (*models.TorrentStatus, []models.Torrent, error) {
+	var session struct {
+		DownloadLimit        int64 `json:"speed-limit-down"` // kB/s
+		DownloadLimitEnabled bool  `json:"speed-limit-down-enabled"`
+		UploadLimit          int64 `json:"speed-limit-up"`
+		UploadLimitEnabled   bool  `json:"speed-limit-up-enabled"`
+	}
+	if err := t.rpc(ctx, "session-get", map[string]interface{}{}, &session); err != nil {
+		return nil, nil, err
+	}
+	var stats struct {
+		DownloadSpeed int64 `json:"downloadSpeed"`
+		UploadSpeed   int64 `json:"uploadSpeed"`
+	}
+	if err := t.rpc(ctx, "session-stats", map[string]interface{}{}, &stats); err != nil {
+		return nil, nil, err
+	}
+	var raw struct {
+		Torrents []struct {
+			HashString   string  `json:"hashString"`
+			Name         string  `json:"name"`
+			Status       int     `json:"status"`
+			PercentDone  float64 `json:"percentDone"`
+			TotalSize    int64   `json:"totalSize"`
+			RateDownload int64   `json:"rateDownload"`
+			RateUpload   int64   `json:"rateUpload"`
+			ETA          int64   `json:"eta"`
+			UploadRatio  float64 `json:"uploadRatio"`
+			Error        int     `json:"error"`
+			ErrorString  string  `json:"errorString"`
+		} `json:"torrents"`
+	}
+	fields := []string{"hashString", "name", "status", "percentDone", "totalSize", "rateDownload", "rateUpload", "eta", "uploadRatio", "error", "errorString"}
+	if err := t.rpc(ctx, "torrent-get", map[string]interface{}{"fields": fields}, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	torrents := make([]models.Torrent, 0, len(raw.Torrents))
+	for _, r := range raw.Torrents {
+		state := transmissionStates[r.Status]
+		switch {
+		case r.Error != 0:
+			state = models.TorrentError
+		case state == models.TorrentPaused && r.PercentDone >= 1:
+			state = models.TorrentCompleted
+		case state == models.TorrentDownloading && r.RateDownload == 0:
+			state = models.TorrentStalled
+		}
+		eta := r.ETA
+		if eta < 0 {
+			eta = -1
+		}
+		torrents = append(torrents, models.Torrent{
+			ID: r.HashString, Name: r.Name, State: state, Progress: r.PercentDone * 100, Size: r.TotalSize,
+			DownloadSpeed: r.RateDownload, UploadSpeed: r.RateUpload, ETA: eta, Ratio: r.UploadRatio, Error: r.ErrorString,
+		})
+	}
+
+	status := &models.TorrentStatus{DownloadSpeed: stats.DownloadSpeed, UploadSpeed: stats.UploadSpeed}
+	if session.DownloadLimitEnabled {
+		status.DownloadLimit = session.DownloadLimit * transmissionKB
+	}
+	if session.UploadLimitEnabled {
+		status.UploadLimit = session.UploadLimit * transmissionKB
+	}
+	return status, torrents, nil
+}
+
+func (t *transmissionClient) setPaused(ctx context.Context, paused bool) error {
+	// Without ids the call applies to every torrent
+	method := "torrent-start"
+	if paused {
+		method = "torrent-stop"
+	}
+	return t.rpc(ctx, method, map[string]interface{}{}, nil)
+}
+
+func (t *transmissionClient) setLimits(ctx context.Context, download, upload int64) error {
+	return t.rpc(ctx, "session-set", map[string]interface{}{
+		"speed-limit-down":         toTransmissionKB(download),
+		"speed-limit-down-enabled": download > 0,
+		"speed-limit-up":           toTransmissionKB(upload),
+		"speed-limit-up-enabled":   upload > 0,
+	}, nil)
+}
+
+// toTransmissionKB converts bytes/s to kB/s, rounding a set limit up so it
+// never becomes 0, which Transmission treats as stopped
+func toTransmissionKB(limit int64) int64 {
+	return (limit + transmissionKB - 1) / transmissionKB
+}
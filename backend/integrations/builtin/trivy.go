@@ -0,0 +1,51 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/services"
+)
+
+// trivyIntegration exposes image scan results as a collector and scanning as an action.
+// Trivy itself is configured through TRIVY_* environment variables.
+type trivyIntegration struct {
+	service *services.ScanService
+}
+
+func (t *trivyIntegration) Name() string { return "trivy" }
+
+func (t *trivyIntegration) Description() string {
+	return "Container image vulnerability scans with Trivy"
+}
+
+func (t *trivyIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{}
+}
+
+func (t *trivyIntegration) Configure(config map[string]string) error { return nil }
+
+func (t *trivyIntegration) Collect(ctx context.Context) (interface{}, error) {
+	return t.service.GetScans()
+}
+
+func (t *trivyIntegration) Actions() []models.IntegrationAction {
+	return []models.IntegrationAction{{
+		Name:        "scan",
+		Description: "Scan all running images now",
+		Params:      []models.IntegrationField{},
+	}}
+}
+
+func (t *trivyIntegration) Execute(ctx context.Context, action string, params map[string]string) (interface{}, error) {
+	if action != "scan" {
+		return nil, fmt.Errorf("unknown action: %s", action)
+	}
+	if !t.service.IsEnabled() {
+		return nil, fmt.Errorf("Trivy scanning is not enabled")
+	}
+
+	go t.service.ScanRunningImages()
+	return map[string]string{"message": "Scan started"}, nil
+}
@@ -0,0 +1,86 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// webhookIntegration posts notifications as JSON to a URL, optionally signed
+// with an HMAC-SHA256 of the body in the X-Homelab-Signature header
+type webhookIntegration struct {
+	mu     sync.RWMutex
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (w *webhookIntegration) Name() string { return "webhook" }
+
+func (w *webhookIntegration) Description() string {
+	return "Send notifications as JSON to an HTTP endpoint"
+}
+
+func (w *webhookIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{
+		{Key: "url", Label: "Webhook URL", Type: models.FieldURL, Required: true},
+		{Key: "secret", Label: "Signing secret", Type: models.FieldSecret,
+			Description: "When set, requests carry X-Homelab-Signature: sha256=<hmac of body>"},
+	}
+}
+
+func (w *webhookIntegration) Configure(config map[string]string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.url = config["url"]
+	w.secret = config["secret"]
+	w.client = &http.Client{Timeout: 10 * time.Second}
+	return nil
+}
+
+func (w *webhookIntegration) Notify(ctx context.Context, n models.Notification) error {
+	w.mu.RLock()
+	url, secret, client := w.url, w.secret, w.client
+	w.mu.RUnlock()
+
+	if url == "" {
+		return fmt.Errorf("webhook URL is not configured")
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Homelab-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
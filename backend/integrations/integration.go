@@ -0,0 +1,154 @@
+// Package integrations defines the interfaces third-party integrations
+// implement and the registry they are added to at startup.
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/homelab/backend/models"
+)
+
+// Integration is implemented by every integration
+type Integration interface {
+	// Name is the unique identifier used in /api/integrations/:name
+	Name() string
+	Description() string
+	// ConfigSchema lists the settings the integration accepts
+	ConfigSchema() []models.IntegrationField
+	// Configure applies validated settings; called on startup and on every change
+	Configure(config map[string]string) error
+}
+
+// Collector gathers data from an external system
+type Collector interface {
+	Integration
+	Collect(ctx context.Context) (interface{}, error)
+}
+
+// Actor performs actions on an external system
+type Actor interface {
+	Integration
+	Actions() []models.IntegrationAction
+	Execute(ctx context.Context, action string, params map[string]string) (interface{}, error)
+}
+
+// Notifier delivers notifications to an external system
+type Notifier interface {
+	Integration
+	Notify(ctx context.Context, n models.Notification) error
+}
+
+// Stopper is implemented by integrations that run in the background once
+// configured; Stop is called when the integration is disabled
+type Stopper interface {
+	Integration
+	Stop()
+}
+
+// Registry holds registered integrations by name
+type Registry struct {
+	mu    sync.RWMutex
+	items map[string]Integration
+}
+
+// Default is the registry integrations register into at startup
+var Default = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{items: make(map[string]Integration)}
+}
+
+// Register adds an integration to the default registry
+func Register(i Integration) {
+	Default.Register(i)
+}
+
+// Register adds an integration; registering a duplicate name is a programming error
+func (r *Registry) Register(i Integration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.items[i.Name()]; exists {
+		panic(fmt.Sprintf("integration %q registered twice", i.Name()))
+	}
+	r.items[i.Name()] = i
+}
+
+// Get returns an integration by name
+func (r *Registry) Get(name string) (Integration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, ok := r.items[name]
+	return i, ok
+}
+
+// All returns every registered integration, sorted by name
+func (r *Registry) All() []Integration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]Integration, 0, len(r.items))
+	for _, i := range r.items {
+		result = append(result, i)
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].Name() < result[b].Name() })
+	return result
+}
+
+// Kinds returns which capabilities an integration implements
+func Kinds(i Integration) []string {
+	kinds := make([]string, 0, 3)
+	if _, ok := i.(Collector); ok {
+		kinds = append(kinds, "collector")
+	}
+	if _, ok := i.(Actor); ok {
+		kinds = append(kinds, "actor")
+	}
+	if _, ok := i.(Notifier); ok {
+		kinds = append(kinds, "notifier")
+	}
+	return kinds
+}
+
+// ApplyDefaults returns config with schema defaults filled in for missing keys
+func ApplyDefaults(schema []models.IntegrationField, config map[string]string) map[string]string {
+	result := make(map[string]string, len(schema))
+	for _, field := range schema {
+		if value, ok := config[field.Key]; ok && value != "" {
+			result[field.Key] = value
+		} else if field.Default != "" {
+			result[field.Key] = field.Default
+		}
+	}
+	return result
+}
+
+// ValidateConfig checks required fields and value types against the schema
+func ValidateConfig(schema []models.IntegrationField, config map[string]string) error {
+	for _, field := range schema {
+		value := config[field.Key]
+		if value == "" {
+			if field.Required {
+				return fmt.Errorf("%s is required", field.Key)
+			}
+			continue
+		}
+
+		switch field.Type {
+		case models.FieldNumber:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("%s must be a number", field.Key)
+			}
+		case models.FieldBool:
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("%s must be true or false", field.Key)
+			}
+		}
+	}
+	return nil
+}
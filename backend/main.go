@@ -7,20 +7,16 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/config"
 	"github.com/homelab/backend/database"
 	"github.com/homelab/backend/handlers"
+	"github.com/homelab/backend/integrations"
+	"github.com/homelab/backend/integrations/builtin"
 	"github.com/homelab/backend/middleware"
 	"github.com/homelab/backend/services"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
 func main() {
 	// Load configuration
 	cfg := config.Load()
@@ -39,6 +35,11 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	// Encrypt credentials stored before they were encrypted at rest
+	if err := services.EncryptStoredSecrets(database.GetDB()); err != nil {
+		log.Println("Warning: Failed to encrypt stored secrets:", err)
+	}
+
 	// Seed database if empty
 	if err := database.SeedIfEmpty(); err != nil {
 		log.Println("Warning: Failed to seed database:", err)
@@ -51,28 +52,142 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.FrontendURL, "http://localhost:3000", "http://127.0.0.1:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.SecurityHeaders(cfg.HSTSMaxAge))
+	r.Use(middleware.MaxBodySize(cfg.MaxBodySize, map[string]int64{
+		// PEM uploads and device attachments
+		"/api/services/:id/tls":        cfg.MaxUploadSize,
+		"/api/devices/:id/attachments": cfg.MaxUploadSize,
+	}))
 
 	// Initialize services
-	authService := services.NewAuthService()
-	metricsService := services.NewMetricsService()
+	cache := services.NewCache()
+	settingsService := services.NewSettingsService()
+	authService := services.NewAuthService(cache, settingsService)
+	metricsService := services.NewMetricsService(settingsService)
 	dockerService := services.NewDockerService()
+	dockerHostService := services.NewDockerHostService(dockerService)
 	deviceService := services.NewDeviceService()
-	serviceConfigService := services.NewServiceConfigService()
+	eventService := services.NewEventService()
+	serviceConfigService := services.NewServiceConfigService(deviceService, dockerService, eventService, cache)
 	networkService := services.NewNetworkService()
+	firewallService := services.NewFirewallService(eventService)
+	securityService := services.NewSecurityService(eventService)
+	scanService := services.NewScanService(dockerService, eventService)
+	imageUpdateService := services.NewImageUpdateService(dockerService, eventService)
+	updateService := services.NewUpdateService(eventService)
+	throughputService := services.NewThroughputService()
+	badgeService := services.NewBadgeService()
+	qrService := services.NewQRService()
+	ipamService := services.NewIPAMService()
+	discoveryService := services.NewDiscoveryService(deviceService)
+	snmpService := services.NewSNMPService(deviceService)
+	screenshotService := services.NewScreenshotService()
+	noteService := services.NewNoteService()
+	snapshotService := services.NewSnapshotService(deviceService, serviceConfigService, dockerService)
+	maintenanceService := services.NewMaintenanceService(eventService)
+	services.NewDriftService(dockerService, eventService)
+	kioskService := services.NewKioskService()
+	summaryService := services.NewSummaryService(metricsService, dockerService, cache)
+	healthService := services.NewHealthService(metricsService, updateService, cache)
+	flagService := services.NewFlagService()
+	builtin.RegisterAll(firewallService, securityService, scanService, eventService)
+	integrationService := services.NewIntegrationService(integrations.Default)
+	probeService := services.NewProbeService()
+	reportService := services.NewReportService(eventService, deviceService)
+	piService := services.NewPiService(eventService)
+	oomService := services.NewOOMService(dockerService, eventService)
+	containerSizingService := services.NewContainerSizingService(dockerService)
+	logService := services.NewLogService(eventService)
+	mountService := services.NewMountService(eventService)
+	inventoryService := services.NewInventoryService(eventService)
+	dnsService := services.NewDNSService(eventService)
+	energyService := services.NewEnergyService(dockerService)
+	tagService := services.NewTagService(serviceConfigService, deviceService)
+	auditService := services.NewAuditService()
+	searchService := services.NewSearchService()
+	powerSequenceService := services.NewPowerSequenceService(deviceService, dockerService, auditService, eventService)
+	alertService := services.NewAlertService(metricsService, dockerService, serviceConfigService, auditService, eventService)
+	remediationService := services.NewRemediationService(serviceConfigService, deviceService, dockerService, alertService, auditService, eventService)
+	deployService := services.NewDeployService(dockerService, auditService, eventService)
+	notificationService := services.NewNotificationService(alertService, eventService)
+	hookService := services.NewHookService(alertService, eventService)
+	widgetService := services.NewWidgetService()
+	emailService := services.NewEmailService(alertService, reportService)
+	sensorService := services.NewSensorService()
+	rackService := services.NewRackService()
+	topologyService := services.NewTopologyService(dockerService)
+	trafficService := services.NewTrafficService(metricsService, dockerService)
+	agentService := services.NewAgentService(deviceService, metricsService)
+	prometheusService := services.NewPrometheusService(metricsService, dockerService, serviceConfigService)
+	services.NewMetricsSinkService(metricsService, dockerService)
+
+	// Start background service checks once every status listener is registered
+	serviceConfigService.StartScheduler()
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	metricsHandler := handlers.NewMetricsHandler(metricsService)
-	dockerHandler := handlers.NewDockerHandler(dockerService)
+	metricsHandler := handlers.NewMetricsHandler(metricsService, piService, trafficService)
+	dockerHandler := handlers.NewDockerHandler(dockerHostService, scanService, imageUpdateService)
+	dockerHostHandler := handlers.NewDockerHostHandler(dockerHostService)
 	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	snmpHandler := handlers.NewSNMPHandler(snmpService)
+	agentHandler := handlers.NewAgentHandler(agentService)
+	prometheusHandler := handlers.NewPrometheusHandler(prometheusService)
 	serviceHandler := handlers.NewServiceHandler(serviceConfigService)
 	networkHandler := handlers.NewNetworkHandler(networkService)
 	terminalHandler := handlers.NewTerminalHandler()
+	eventHandler := handlers.NewEventHandler(eventService)
+	firewallHandler := handlers.NewFirewallHandler(firewallService)
+	securityHandler := handlers.NewSecurityHandler(securityService)
+	scanHandler := handlers.NewScanHandler(scanService)
+	systemHandler := handlers.NewSystemHandler(updateService)
+	throughputHandler := handlers.NewThroughputHandler(throughputService, deviceService)
+	badgeHandler := handlers.NewBadgeHandler(badgeService)
+	qrHandler := handlers.NewQRHandler(qrService)
+	ipamHandler := handlers.NewIPAMHandler(ipamService)
+	discoveryHandler := handlers.NewDiscoveryHandler(discoveryService)
+	dnsHandler := handlers.NewDNSHandler(dnsService)
+	energyHandler := handlers.NewEnergyHandler(energyService)
+	screenshotHandler := handlers.NewScreenshotHandler(screenshotService)
+	noteHandler := handlers.NewNoteHandler(noteService)
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
+	kioskHandler := handlers.NewKioskHandler(kioskService)
+	summaryHandler := handlers.NewSummaryHandler(summaryService, healthService)
+	flagHandler := handlers.NewFlagHandler(flagService)
+	integrationHandler := handlers.NewIntegrationHandler(integrationService)
+	probeHandler := handlers.NewProbeHandler(probeService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	remediationHandler := handlers.NewRemediationHandler(remediationService)
+	powerSequenceHandler := handlers.NewPowerSequenceHandler(powerSequenceService)
+	alertHandler := handlers.NewAlertHandler(alertService)
+	deployHandler := handlers.NewDeployHandler(deployService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	hookHandler := handlers.NewHookHandler(hookService)
+	widgetHandler := handlers.NewWidgetHandler(widgetService)
+	emailHandler := handlers.NewEmailHandler(emailService)
+	sensorHandler := handlers.NewSensorHandler(sensorService)
+	rackHandler := handlers.NewRackHandler(rackService)
+	topologyHandler := handlers.NewTopologyHandler(topologyService)
+	oomHandler := handlers.NewOOMHandler(oomService)
+	containerSizingHandler := handlers.NewContainerSizingHandler(containerSizingService)
+	logHandler := handlers.NewLogHandler(logService)
+	mountHandler := handlers.NewMountHandler(mountService)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+
+	r.NoRoute(func(c *gin.Context) {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Route not found")
+	})
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -82,6 +197,9 @@ func main() {
 		})
 	})
 
+	// Prometheus exposition (bearer PROMETHEUS_TOKEN)
+	r.GET("/metrics", prometheusHandler.GetMetrics)
+
 	// API routes
 	api := r.Group("/api")
 	{
@@ -90,6 +208,7 @@ func main() {
 		{
 
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
 		}
 
 		// Protected auth routes
@@ -110,28 +229,136 @@ func main() {
 		api.GET("/metrics/disk", metricsHandler.GetDiskMetrics)
 		api.GET("/metrics/network", metricsHandler.GetNetworkMetrics)
 		api.GET("/metrics/history", metricsHandler.GetMetricsHistory)
+		api.GET("/metrics/pi", metricsHandler.GetPiMetrics)
+
+		// Status badges (public, or ?token= for private services)
+		api.GET("/badges/service/:file", badgeHandler.GetServiceBadge)
+		api.GET("/badges/uptime/:file", badgeHandler.GetUptimeBadge)
+
+		// Metrics pushed by device agents (authenticated by agent token)
+		api.POST("/ingest/metrics", middleware.MetricsAgentMiddleware(agentService), agentHandler.IngestMetrics)
+
+		// Remote probe agents (authenticated by agent token)
+		probeAPI := api.Group("/probe")
+		probeAPI.Use(middleware.FeatureMiddleware(flagService, services.FlagProbeAgents), middleware.ProbeAgentMiddleware(probeService))
+		{
+			probeAPI.GET("/checks", probeHandler.GetAssignments)
+			probeAPI.POST("/results", probeHandler.ReportResults)
+		}
+
+		// Deploy webhooks from git hosts (authenticated by the app's webhook secret)
+		api.POST("/deploy/hooks/:id", deployHandler.Webhook)
+
+		// Inbound webhooks from external systems (authenticated by the hook's secret)
+		api.POST("/hooks/:id", hookHandler.Receive)
+
+		// Sensor readings from ESPHome, Tasmota and the like (authenticated by an ingest key)
+		api.POST("/ingest", sensorHandler.Ingest)
 
 		// Protected routes - require authentication
 		protected := api.Group("")
 		protected.Use(middleware.AuthMiddleware(authService))
 		{
+			// Metrics query expressions over stored series (sensors and services are per user)
+			protected.GET("/metrics/history/export", metricsHandler.ExportMetricsHistory)
+			protected.GET("/metrics/query", metricsHandler.QueryMetrics)
+			protected.GET("/metrics/query/series", metricsHandler.GetQuerySeries)
+
+			// Network connections (exposes process info, so protected)
+			protected.GET("/metrics/connections", metricsHandler.GetConnections)
+
+			// Throughput split between containers and the host (names containers, so protected)
+			protected.GET("/metrics/network/top", metricsHandler.GetNetworkTop)
+
+			// Windows hosts: SCM services, pending reboot, event log errors
+			protected.GET("/metrics/windows", metricsHandler.GetWindowsMetrics)
+			protected.GET("/metrics/windows/services", metricsHandler.GetWindowsServices)
+
+			// macOS hosts: thermal pressure, battery
+			protected.GET("/metrics/macos", metricsHandler.GetMacOSMetrics)
+
+			// OOM kills (host processes and containers)
+			protected.GET("/metrics/oom", oomHandler.GetKills)
+			protected.GET("/metrics/mounts", mountHandler.GetMounts)
+
+			// Journald/syslog error rates (log lines may contain secrets, so admin only)
+			protected.GET("/logs/units", middleware.AdminMiddleware(), logHandler.GetUnitRates)
+			protected.GET("/logs/units/:unit/recent", middleware.AdminMiddleware(), logHandler.GetRecentErrors)
+
 			// Docker containers
 			protected.GET("/containers", dockerHandler.GetContainers)
+			protected.GET("/containers/sizing", containerSizingHandler.GetSizing)
+			protected.GET("/containers/updates", dockerHandler.GetImageUpdates)
+			protected.POST("/containers/updates/check", middleware.AdminMiddleware(), dockerHandler.CheckImageUpdates)
 			protected.GET("/containers/:id", dockerHandler.GetContainer)
 			protected.POST("/containers/:id/start", dockerHandler.StartContainer)
 			protected.POST("/containers/:id/stop", dockerHandler.StopContainer)
 			protected.POST("/containers/:id/restart", dockerHandler.RestartContainer)
+			protected.POST("/containers/:id/pause", dockerHandler.PauseContainer)
+			protected.POST("/containers/:id/unpause", dockerHandler.UnpauseContainer)
+			protected.POST("/containers/:id/kill", dockerHandler.KillContainer)
+			protected.DELETE("/containers/:id", middleware.AdminMiddleware(), dockerHandler.RemoveContainer)
+			protected.POST("/containers/:id/update", middleware.AdminMiddleware(), dockerHandler.UpdateContainer)
+			// Container output may contain secrets, so admin only
+			protected.GET("/containers/:id/logs", middleware.AdminMiddleware(), dockerHandler.GetContainerLogs)
+
+			// Docker images: layer breakdown and disk usage per compose project
+			protected.GET("/images/usage", dockerHandler.GetImageUsage)
+			protected.GET("/images/:id/layers", dockerHandler.GetImageLayers)
+
+			// Remote Docker daemons, selected on the Docker endpoints with ?host=<id>
+			protected.GET("/docker/hosts", dockerHostHandler.GetHosts)
+			protected.GET("/docker/hosts/:id", dockerHostHandler.GetHost)
+			protected.POST("/docker/hosts", middleware.AdminMiddleware(), dockerHostHandler.CreateHost)
+			protected.PUT("/docker/hosts/:id", middleware.AdminMiddleware(), dockerHostHandler.UpdateHost)
+			protected.DELETE("/docker/hosts/:id", middleware.AdminMiddleware(), dockerHostHandler.DeleteHost)
+			protected.DELETE("/docker/hosts/:id/ssh-host-key", middleware.AdminMiddleware(), dockerHostHandler.ResetSSHHostKey)
+
+			// Docker volumes and networks (creating and removing them is admin only)
+			protected.GET("/docker/volumes", dockerHandler.GetVolumes)
+			protected.GET("/docker/volumes/:name", dockerHandler.GetVolume)
+			protected.POST("/docker/volumes", middleware.AdminMiddleware(), dockerHandler.CreateVolume)
+			protected.DELETE("/docker/volumes/:name", middleware.AdminMiddleware(), dockerHandler.RemoveVolume)
+			protected.GET("/docker/networks", dockerHandler.GetNetworks)
+			protected.GET("/docker/networks/:id", dockerHandler.GetNetwork)
+			protected.POST("/docker/networks", middleware.AdminMiddleware(), dockerHandler.CreateNetwork)
+			protected.DELETE("/docker/networks/:id", middleware.AdminMiddleware(), dockerHandler.RemoveNetwork)
+
+			// Image vulnerability scans (Trivy)
+			protected.GET("/scans", scanHandler.GetScans)
+			protected.GET("/scans/:id", scanHandler.GetScan)
+			protected.POST("/scans/run", middleware.AdminMiddleware(), scanHandler.RunScan)
 
 			// Devices
 			protected.GET("/devices", deviceHandler.GetDevices)
 			protected.GET("/devices/types", deviceHandler.GetDeviceTypes)
+			protected.GET("/devices/stale", deviceHandler.GetStaleDevices)
 			protected.GET("/devices/:id", deviceHandler.GetDevice)
 			protected.POST("/devices", deviceHandler.CreateDevice)
 			protected.PUT("/devices/:id", deviceHandler.UpdateDevice)
 			protected.DELETE("/devices/:id", deviceHandler.DeleteDevice)
 			protected.GET("/devices/:id/ping", deviceHandler.PingDevice)
+			protected.GET("/devices/:id/availability", deviceHandler.GetAvailability)
+			protected.GET("/devices/:id/history", deviceHandler.GetStatusHistory)
+			protected.GET("/devices/:id/qr", qrHandler.GetDeviceQR)
+			protected.GET("/devices/:id/attachments", inventoryHandler.GetAttachments)
+			protected.POST("/devices/:id/attachments", inventoryHandler.UploadAttachment)
+			protected.GET("/devices/:id/attachments/:attachmentId", inventoryHandler.DownloadAttachment)
+			protected.DELETE("/devices/:id/attachments/:attachmentId", inventoryHandler.DeleteAttachment)
 			protected.POST("/devices/:id/wake", deviceHandler.WakeDevice)
 			protected.POST("/devices/:id/shutdown", deviceHandler.ShutdownDevice)
+			protected.POST("/devices/:id/reboot", deviceHandler.RebootDevice)
+			protected.DELETE("/devices/:id/ssh-host-key", middleware.AdminMiddleware(), deviceHandler.ResetSSHHostKey)
+			protected.PUT("/devices/:id/tags", tagHandler.SetDeviceTags)
+			protected.GET("/devices/:id/metrics", metricsHandler.GetDeviceMetrics)
+			protected.GET("/devices/:id/metrics/history", metricsHandler.GetDeviceMetricsHistory)
+			protected.POST("/devices/:id/agent", agentHandler.Enroll)
+			protected.DELETE("/devices/:id/agent", agentHandler.Remove)
+			protected.GET("/agents", agentHandler.GetHosts)
+			protected.GET("/devices/:id/snmp", snmpHandler.GetStatus)
+			protected.GET("/devices/:id/snmp/config", snmpHandler.GetConfig)
+			protected.PUT("/devices/:id/snmp/config", snmpHandler.SaveConfig)
+			protected.DELETE("/devices/:id/snmp/config", snmpHandler.DeleteConfig)
 
 			// Services
 			protected.GET("/services", serviceHandler.GetServices)
@@ -141,51 +368,281 @@ func main() {
 			protected.PUT("/services/:id", serviceHandler.UpdateService)
 			protected.DELETE("/services/:id", serviceHandler.DeleteService)
 			protected.GET("/services/:id/health", serviceHandler.CheckServiceHealth)
+			protected.GET("/services/:id/history", serviceHandler.GetHistory)
+			protected.PUT("/services/:id/badge", serviceHandler.UpdateBadgeSettings)
+			protected.GET("/services/:id/qr", qrHandler.GetServiceQR)
+			protected.GET("/services/:id/screenshot", screenshotHandler.GetScreenshot)
+			protected.POST("/services/:id/screenshot", screenshotHandler.CaptureScreenshot)
+			protected.PUT("/services/:id/tls", serviceHandler.UpdateTLSSettings)
+			protected.PUT("/services/:id/proxy", serviceHandler.UpdateProxySettings)
+			protected.PUT("/services/:id/database", serviceHandler.UpdateDatabaseCredentials)
+			protected.PUT("/services/:id/impact", serviceHandler.UpdateImpact)
+			protected.PUT("/services/:id/thresholds", serviceHandler.UpdateCheckThresholds)
+			protected.PUT("/services/:id/device", serviceHandler.SetDevice)
+			protected.PUT("/services/:id/container", serviceHandler.SetContainer)
+			protected.POST("/services/:id/container/restart", serviceHandler.RestartContainer)
+			protected.PUT("/services/:id/tags", tagHandler.SetServiceTags)
+
+			// IP address plan
+			protected.GET("/ipam/subnets", ipamHandler.GetSubnets)
+			protected.POST("/ipam/subnets", ipamHandler.CreateSubnet)
+			protected.PUT("/ipam/subnets/:id", ipamHandler.UpdateSubnet)
+			protected.DELETE("/ipam/subnets/:id", ipamHandler.DeleteSubnet)
+			protected.GET("/ipam/subnets/:id/usage", ipamHandler.GetSubnetUsage)
+			protected.GET("/ipam/subnets/:id/next-ip", ipamHandler.GetNextFreeIP)
+			protected.GET("/ipam/conflicts", ipamHandler.GetConflicts)
+
+			// Changelog notes
+			protected.GET("/notes", noteHandler.GetNotes)
+			protected.GET("/notes/annotations", noteHandler.GetAnnotations)
+			protected.POST("/notes", noteHandler.CreateNote)
+			protected.PUT("/notes/:id", noteHandler.UpdateNote)
+			protected.DELETE("/notes/:id", noteHandler.DeleteNote)
+
+			// DNS record inventory
+			protected.GET("/dns/records", dnsHandler.GetRecords)
+			protected.POST("/dns/records", dnsHandler.CreateRecord)
+			protected.PUT("/dns/records/:id", dnsHandler.UpdateRecord)
+			protected.DELETE("/dns/records/:id", dnsHandler.DeleteRecord)
+			protected.POST("/dns/records/:id/check", dnsHandler.CheckRecord)
+
+			// Energy-saving suggestions
+			protected.GET("/energy/suggestions", energyHandler.GetSuggestions)
+
+			// Tags
+			protected.GET("/tags", tagHandler.GetTags)
+			protected.POST("/tags", tagHandler.CreateTag)
+			protected.PUT("/tags/:id", tagHandler.UpdateTag)
+			protected.DELETE("/tags/:id", tagHandler.DeleteTag)
+			protected.POST("/tags/:id/actions/:action", tagHandler.RunAction)
+
+			// Incident and downtime reports
+			protected.GET("/reports/incidents", reportHandler.GetIncidents)
+			protected.GET("/reports/downtime", reportHandler.GetDowntime)
+
+			// Remediation hooks and audit log
+			protected.GET("/remediations", middleware.AdminMiddleware(), remediationHandler.GetHooks)
+			protected.POST("/remediations", middleware.AdminMiddleware(), remediationHandler.CreateHook)
+			protected.PUT("/remediations/:id", middleware.AdminMiddleware(), remediationHandler.UpdateHook)
+			protected.DELETE("/remediations/:id", middleware.AdminMiddleware(), remediationHandler.DeleteHook)
+			protected.POST("/remediations/:id/run", middleware.AdminMiddleware(), remediationHandler.RunHook)
+			protected.GET("/audit", middleware.AdminMiddleware(), auditHandler.GetAuditLog)
+			// Full-text search over audit entries (admin), events and own notes
+			protected.GET("/search/history", searchHandler.SearchHistory)
+
+			// Lab power sequences (ordered shutdown, reverse start)
+			protected.GET("/power-sequences", middleware.AdminMiddleware(), powerSequenceHandler.GetSequences)
+			protected.POST("/power-sequences", middleware.AdminMiddleware(), powerSequenceHandler.CreateSequence)
+			protected.GET("/power-sequences/:id", middleware.AdminMiddleware(), powerSequenceHandler.GetSequence)
+			protected.PUT("/power-sequences/:id", middleware.AdminMiddleware(), powerSequenceHandler.UpdateSequence)
+			protected.DELETE("/power-sequences/:id", middleware.AdminMiddleware(), powerSequenceHandler.DeleteSequence)
+			protected.POST("/power-sequences/:id/shutdown", middleware.AdminMiddleware(), powerSequenceHandler.ShutdownLab)
+			protected.POST("/power-sequences/:id/start", middleware.AdminMiddleware(), powerSequenceHandler.StartLab)
+
+			// Alerting (rules and the alerts they raise)
+			protected.GET("/alerts", alertHandler.GetAlerts)
+			protected.POST("/alerts/:id/acknowledge", alertHandler.AcknowledgeAlert)
+			protected.GET("/alerts/rules", alertHandler.GetRules)
+			protected.POST("/alerts/rules", alertHandler.CreateRule)
+			protected.GET("/alerts/rules/:id", alertHandler.GetRule)
+			protected.PUT("/alerts/rules/:id", alertHandler.UpdateRule)
+			protected.DELETE("/alerts/rules/:id", alertHandler.DeleteRule)
+
+			// Notification channels alerts are delivered to
+			protected.GET("/notifications", notificationHandler.GetChannels)
+			protected.POST("/notifications", notificationHandler.CreateChannel)
+			protected.GET("/notifications/types", notificationHandler.GetChannelTypes)
+			protected.GET("/notifications/categories", notificationHandler.GetCategories)
+			protected.GET("/notifications/preferences", notificationHandler.GetPreferences)
+			protected.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+			protected.GET("/notifications/:id", notificationHandler.GetChannel)
+			protected.PUT("/notifications/:id", notificationHandler.UpdateChannel)
+			protected.DELETE("/notifications/:id", notificationHandler.DeleteChannel)
+			protected.POST("/notifications/:id/test", notificationHandler.TestChannel)
+			protected.GET("/notifications/email", emailHandler.GetPreferences)
+			protected.PUT("/notifications/email", emailHandler.UpdatePreferences)
+			protected.POST("/notifications/email/test", emailHandler.SendTest)
+
+			// Inbound webhook hub (deliveries are received at POST /api/hooks/:id)
+			protected.GET("/hooks", hookHandler.GetHooks)
+			protected.POST("/hooks", hookHandler.CreateHook)
+			protected.GET("/hooks/presets", hookHandler.GetPresets)
+			protected.GET("/hooks/:id", hookHandler.GetHook)
+			protected.PUT("/hooks/:id", hookHandler.UpdateHook)
+			protected.DELETE("/hooks/:id", hookHandler.DeleteHook)
+			protected.POST("/hooks/:id/rotate", hookHandler.RotateSecret)
+
+			// Dashboard widget data from third-party JSON APIs
+			protected.GET("/widgets/sources", widgetHandler.GetSources)
+			protected.POST("/widgets/sources", widgetHandler.CreateSource)
+			protected.POST("/widgets/sources/preview", widgetHandler.Preview)
+			protected.GET("/widgets/sources/:id", widgetHandler.GetSource)
+			protected.PUT("/widgets/sources/:id", widgetHandler.UpdateSource)
+			protected.DELETE("/widgets/sources/:id", widgetHandler.DeleteSource)
+			protected.GET("/widgets/sources/:id/data", widgetHandler.GetData)
+
+			// Home sensors (readings are pushed to POST /api/ingest)
+			protected.GET("/sensors", sensorHandler.GetSensors)
+			protected.GET("/sensors/keys", sensorHandler.GetKeys)
+			protected.POST("/sensors/keys", sensorHandler.CreateKey)
+			protected.DELETE("/sensors/keys/:id", sensorHandler.DeleteKey)
+			protected.PUT("/sensors/:id", sensorHandler.UpdateSensor)
+			protected.DELETE("/sensors/:id", sensorHandler.DeleteSensor)
+			protected.GET("/sensors/:id/history", sensorHandler.GetHistory)
+
+			// Rooms, racks and device placement for the rack diagram
+			protected.GET("/rooms", rackHandler.GetRooms)
+			protected.POST("/rooms", rackHandler.CreateRoom)
+			protected.PUT("/rooms/:id", rackHandler.UpdateRoom)
+			protected.DELETE("/rooms/:id", rackHandler.DeleteRoom)
+			protected.GET("/rooms/:id/plan", rackHandler.GetFloorPlan)
+			protected.GET("/racks", rackHandler.GetRacks)
+			protected.POST("/racks", rackHandler.CreateRack)
+			protected.GET("/racks/:id", rackHandler.GetRack)
+			protected.PUT("/racks/:id", rackHandler.UpdateRack)
+			protected.DELETE("/racks/:id", rackHandler.DeleteRack)
+			protected.POST("/racks/:id/devices", rackHandler.PlaceDevice)
+			protected.DELETE("/racks/:id/devices/:deviceId", rackHandler.RemoveDevice)
+			protected.GET("/racks/:id/elevation", rackHandler.ExportElevation)
+
+			// Network map: devices, subnets, physical links and Docker networks
+			protected.GET("/topology", topologyHandler.GetTopology)
+			protected.GET("/topology/links", topologyHandler.GetLinks)
+			protected.POST("/topology/links", topologyHandler.CreateLink)
+			protected.DELETE("/topology/links/:id", topologyHandler.DeleteLink)
+			protected.PUT("/topology/devices/:id/lldp", topologyHandler.ReportLLDP)
+
+			// Build-and-deploy from git (builds run arbitrary Dockerfiles, so admin only)
+			protected.GET("/deploy/apps", middleware.AdminMiddleware(), deployHandler.GetApps)
+			protected.POST("/deploy/apps", middleware.AdminMiddleware(), deployHandler.CreateApp)
+			protected.GET("/deploy/apps/:id", middleware.AdminMiddleware(), deployHandler.GetApp)
+			protected.PUT("/deploy/apps/:id", middleware.AdminMiddleware(), deployHandler.UpdateApp)
+			protected.DELETE("/deploy/apps/:id", middleware.AdminMiddleware(), deployHandler.DeleteApp)
+			protected.POST("/deploy/apps/:id/rotate", middleware.AdminMiddleware(), deployHandler.RotateWebhookSecret)
+			protected.POST("/deploy/apps/:id/build", middleware.AdminMiddleware(), deployHandler.Build)
+			protected.GET("/deploy/apps/:id/builds", middleware.AdminMiddleware(), deployHandler.GetBuilds)
+			protected.GET("/deploy/builds/:id", middleware.AdminMiddleware(), deployHandler.GetBuild)
+
+			// Instance settings
+			protected.GET("/settings/password-policy", settingsHandler.GetPasswordPolicy)
+			protected.PUT("/settings/password-policy", middleware.AdminMiddleware(), settingsHandler.UpdatePasswordPolicy)
+			protected.GET("/settings/network-interfaces", settingsHandler.GetNetworkInterfaces)
+			protected.PUT("/settings/network-interfaces", middleware.AdminMiddleware(), settingsHandler.UpdateNetworkInterfaces)
 
 			// Network Tools
 			protected.GET("/network/ping", networkHandler.GetPing)
 			protected.GET("/network/speedtest", networkHandler.GetSpeedTest)
+			protected.GET("/network/latency/history", networkHandler.GetLatencyHistory)
+			protected.POST("/network/throughput", throughputHandler.RunTest)
+			// Subnet discovery probes every address, so scanning is admin only
+			protected.POST("/network/scan", middleware.AdminMiddleware(), discoveryHandler.ScanNetwork)
+			protected.POST("/network/scan/import", discoveryHandler.ImportHosts)
+
+			// Events
+			protected.GET("/events", eventHandler.GetEvents)
+
+			// Summary
+			protected.GET("/summary", summaryHandler.GetSummary)
+			protected.GET("/summary/score", summaryHandler.GetScore)
+
+			// Feature flags
+			protected.GET("/flags", flagHandler.GetFlags)
+			protected.GET("/flags/admin", middleware.AdminMiddleware(), flagHandler.ListFlags)
+			protected.PUT("/flags/:key", middleware.AdminMiddleware(), flagHandler.UpdateFlag)
+			protected.DELETE("/flags/:key", middleware.AdminMiddleware(), flagHandler.DeleteFlag)
+			protected.PUT("/flags/:key/users/:userId", middleware.AdminMiddleware(), flagHandler.SetOverride)
+			protected.DELETE("/flags/:key/users/:userId", middleware.AdminMiddleware(), flagHandler.ClearOverride)
+
+			// Integrations (behind the "integrations" feature flag)
+			integrationRoutes := protected.Group("/integrations")
+			integrationRoutes.Use(middleware.FeatureMiddleware(flagService, services.FlagIntegrations))
+			{
+				integrationRoutes.GET("", integrationHandler.GetIntegrations)
+				integrationRoutes.GET("/:name", integrationHandler.GetIntegration)
+				integrationRoutes.PUT("/:name", middleware.AdminMiddleware(), integrationHandler.UpdateIntegration)
+				integrationRoutes.GET("/:name/collect", integrationHandler.Collect)
+				integrationRoutes.POST("/:name/actions/:action", middleware.AdminMiddleware(), integrationHandler.Execute)
+				integrationRoutes.POST("/:name/test", middleware.AdminMiddleware(), integrationHandler.TestNotify)
+			}
+
+			// Remote probe agents (behind the "probe_agents" feature flag)
+			probeRoutes := protected.Group("")
+			probeRoutes.Use(middleware.FeatureMiddleware(flagService, services.FlagProbeAgents))
+			{
+				probeRoutes.GET("/probes/agents", middleware.AdminMiddleware(), probeHandler.GetAgents)
+				probeRoutes.POST("/probes/agents", middleware.AdminMiddleware(), probeHandler.CreateAgent)
+				probeRoutes.PUT("/probes/agents/:id", middleware.AdminMiddleware(), probeHandler.UpdateAgent)
+				probeRoutes.POST("/probes/agents/:id/rotate", middleware.AdminMiddleware(), probeHandler.RotateAgent)
+				probeRoutes.DELETE("/probes/agents/:id", middleware.AdminMiddleware(), probeHandler.DeleteAgent)
+				probeRoutes.GET("/services/:id/locations", probeHandler.GetServiceLocations)
+				probeRoutes.PUT("/services/:id/locations", probeHandler.SetServiceLocations)
+			}
+
+			// Kiosk display tokens
+			protected.GET("/kiosk/tokens", middleware.AdminMiddleware(), kioskHandler.GetTokens)
+			protected.POST("/kiosk/tokens", middleware.AdminMiddleware(), kioskHandler.CreateToken)
+			protected.PUT("/kiosk/tokens/:id", middleware.AdminMiddleware(), kioskHandler.UpdateToken)
+			protected.POST("/kiosk/tokens/:id/rotate", middleware.AdminMiddleware(), kioskHandler.RotateToken)
+			protected.DELETE("/kiosk/tokens/:id", middleware.AdminMiddleware(), kioskHandler.DeleteToken)
+
+			// System
+			protected.GET("/system/version", systemHandler.GetVersion)
+
+			// Homelab state snapshots (all users' devices and services)
+			protected.GET("/snapshots", middleware.AdminMiddleware(), snapshotHandler.GetSnapshots)
+			protected.GET("/snapshots/diff", middleware.AdminMiddleware(), snapshotHandler.DiffSnapshots)
+			protected.GET("/snapshots/:id", middleware.AdminMiddleware(), snapshotHandler.GetSnapshot)
+			protected.POST("/snapshots", middleware.AdminMiddleware(), snapshotHandler.TakeSnapshot)
+
+			// Database maintenance (purges deleted rows across all users)
+			protected.GET("/maintenance", middleware.AdminMiddleware(), maintenanceHandler.GetStatus)
+			protected.POST("/maintenance/run", middleware.AdminMiddleware(), maintenanceHandler.RunMaintenance)
+
+			// Firewall (read-only)
+			protected.GET("/firewall/status", firewallHandler.GetStatus)
+			protected.GET("/firewall/rules", firewallHandler.GetRules)
+			protected.GET("/firewall/blocks", firewallHandler.GetBlocks)
+
+			// Intrusion prevention (fail2ban / CrowdSec)
+			protected.GET("/security/bans", securityHandler.GetBans)
+			protected.POST("/security/bans/unban", middleware.AdminMiddleware(), securityHandler.Unban)
 		}
 	}
 
+	// WebSockets check the topic's roles before upgrading and close with
+	// code 4001 when the token expires mid-connection
+
 	// WebSocket for real-time metrics (with optional auth)
-	r.GET("/ws/metrics", middleware.OptionalAuthMiddleware(authService), func(c *gin.Context) {
-		handleWebSocket(c, metricsService)
-	})
+	r.GET("/ws/metrics", middleware.OptionalAuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicMetrics), metricsHandler.StreamMetrics)
 
-	// WebSocket for terminal (requires auth)
-	r.GET("/ws/terminal", middleware.AuthMiddleware(authService), terminalHandler.HandleTerminalWS)
+	// WebSocket for the homelab summary (user or kiosk token)
+	r.GET("/ws/summary", middleware.KioskOrAuthMiddleware(authService, kioskService), middleware.TopicMiddleware(middleware.TopicSummary), summaryHandler.StreamSummary)
 
-	log.Printf("Homelab Backend starting on :%s", cfg.Port)
-	log.Printf("Frontend URL: %s", cfg.FrontendURL)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
-	}
-}
+	// WebSocket for terminal (admin only)
+	r.GET("/ws/terminal", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicTerminal), terminalHandler.HandleTerminalWS)
 
-func handleWebSocket(c *gin.Context, metricsService *services.MetricsService) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		log.Println("WebSocket upgrade error:", err)
-		return
+	// WebSocket for deploy build logs (admin only)
+	r.GET("/ws/deploy/builds/:id", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicDeploy), deployHandler.StreamBuildLog)
+
+	// WebSocket shell inside a container, on the admin-only terminal topic
+	r.GET("/ws/containers/:id/exec", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicTerminal), dockerHandler.ExecContainer)
+
+	// WebSocket SSH shell on a device with its stored credentials, on the admin-only terminal topic
+	r.GET("/ws/devices/:id/terminal", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicTerminal), deviceHandler.DeviceTerminal)
+
+	// WebSocket for following container logs (admin only)
+	r.GET("/ws/containers/:id/logs", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicLogs), dockerHandler.StreamContainerLogs)
+
+	// WebSocket for container lifecycle events (?host= picks the Docker host)
+	r.GET("/ws/docker", middleware.AuthMiddleware(authService), middleware.TopicMiddleware(middleware.TopicDocker), dockerHandler.StreamContainerEvents)
+
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
 	}
-	defer conn.Close()
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			metrics, err := metricsService.GetSystemMetrics()
-			if err != nil {
-				log.Println("Error getting metrics:", err)
-				continue
-			}
-			if err := conn.WriteJSON(metrics); err != nil {
-				log.Println("WebSocket write error:", err)
-				return
-			}
-		}
+	log.Printf("Homelab Backend %s starting on :%s (%s)", config.Version, cfg.Port, scheme)
+	log.Printf("Frontend URL: %s", cfg.FrontendURL)
+	if err := runServer(r, cfg); err != nil {
+		log.Fatal("Failed to start server:", err)
 	}
 }
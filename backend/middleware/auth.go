@@ -3,8 +3,10 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
 	"github.com/homelab/backend/services"
 )
 
@@ -28,20 +30,19 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		}
 
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header or token query param required",
-			})
-			c.Abort()
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header or token query param required")
 			return
 		}
 
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Invalid or expired token",
-				"details": err.Error(),
-			})
-			c.Abort()
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Invalid or expired token", err.Error())
+			return
+		}
+
+		// An expired password only allows the auth endpoints, to change it
+		if claims.PasswordExpired && !strings.HasPrefix(c.FullPath(), "/api/auth/") {
+			apierror.Respond(c, http.StatusForbidden, apierror.CodePasswordExpired, "Password expired, change it to continue")
 			return
 		}
 
@@ -51,6 +52,9 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
 		c.Set("token", token)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
@@ -61,10 +65,7 @@ func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Admin access required",
-			})
-			c.Abort()
+			apierror.Respond(c, http.StatusForbidden, apierror.CodeAdminRequired, "Admin access required")
 			return
 		}
 		c.Next()
@@ -100,8 +101,84 @@ func OptionalAuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			c.Set("username", claims.Username)
 			c.Set("role", claims.Role)
 			c.Set("token", token)
+			if claims.ExpiresAt != nil {
+				c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// KioskOrAuthMiddleware accepts either a user JWT or a kiosk token.
+// Only use it on read-only display streams: kiosk tokens get the "kiosk" role.
+func KioskOrAuthMiddleware(authService *services.AuthService, kioskService *services.KioskService) gin.HandlerFunc {
+	userAuth := AuthMiddleware(authService)
+
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) == 2 && parts[0] == "Bearer" {
+				token = parts[1]
+			}
+		}
+
+		if !services.IsKioskToken(token) {
+			userAuth(c)
+			return
+		}
+
+		kiosk, err := kioskService.Validate(token)
+		if err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid kiosk token", err.Error())
+			return
+		}
+
+		c.Set("role", "kiosk")
+		c.Set("kioskID", kiosk.ID)
+		c.Set("username", "kiosk:"+kiosk.Name)
+
+		c.Next()
+	}
+}
+
+// ProbeAgentMiddleware authenticates remote probe agents by their Bearer token
+func ProbeAgentMiddleware(probeService *services.ProbeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := ""
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+
+		agent, err := probeService.Authenticate(token)
+		if err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid probe token", err.Error())
+			return
+		}
+
+		c.Set("probeAgent", agent)
+		c.Next()
+	}
+}
+
+// MetricsAgentMiddleware authenticates push agents by their Bearer token
+func MetricsAgentMiddleware(agentService *services.AgentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := ""
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
 		}
 
+		agent, err := agentService.Authenticate(token)
+		if err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid agent token", err.Error())
+			return
+		}
+
+		c.Set("metricsAgent", agent)
 		c.Next()
 	}
 }
@@ -121,3 +198,12 @@ func GetUserRole(c *gin.Context) string {
 	}
 	return ""
 }
+
+// GetTokenExpiry returns when the caller's token expires; zero for kiosk
+// tokens and anonymous clients
+func GetTokenExpiry(c *gin.Context) time.Time {
+	if expiresAt, exists := c.Get("tokenExpiresAt"); exists {
+		return expiresAt.(time.Time)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+)
+
+// MaxBodySize rejects request bodies larger than limit bytes. routes raises the
+// limit for specific routes, keyed by their registered path
+// (e.g. "/api/services/:id/tls"), since a later middleware cannot widen it.
+func MaxBodySize(limit int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := limit
+		if routeLimit, ok := routes[c.FullPath()]; ok {
+			allowed = routeLimit
+		}
+
+		if c.Request.ContentLength > allowed {
+			apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.CodeTooLarge, "Request body too large",
+				fmt.Sprintf("limit is %d bytes", allowed))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, allowed)
+		c.Next()
+	}
+}
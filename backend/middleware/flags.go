@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+	"github.com/homelab/backend/services"
+)
+
+// FeatureMiddleware rejects requests when the feature flag is off for the user
+func FeatureMiddleware(flagService *services.FlagService, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !flagService.IsEnabled(key, GetUserID(c)) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeFeatureDisabled, "Feature not enabled", key)
+			return
+		}
+		c.Next()
+	}
+}
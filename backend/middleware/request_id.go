@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+)
+
+// requestIDPattern accepts IDs from a reverse proxy without letting clients inject arbitrary text
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, reusing a valid incoming X-Request-ID,
+// and echoes it in the response header and in error bodies
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+
+		c.Set(apierror.RequestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homelab/backend/apierror"
+)
+
+// WebSocket topics
+const (
+	TopicMetrics  = "metrics"
+	TopicSummary  = "summary"
+	TopicTerminal = "terminal"
+	TopicDeploy   = "deploy"
+	TopicLogs     = "container_logs"
+	TopicDocker   = "docker"
+)
+
+// anonymousRole stands for a client without a token
+const anonymousRole = ""
+
+// topicRoles lists the roles allowed to subscribe to each WebSocket topic.
+// Topics missing from the map are closed to everyone.
+var topicRoles = map[string][]string{
+	TopicMetrics:  {anonymousRole, "user", "admin", "kiosk"},
+	TopicSummary:  {"user", "admin", "kiosk"},
+	TopicTerminal: {"admin"},
+	TopicDeploy:   {"admin"},
+	TopicLogs:     {"admin"},
+	TopicDocker:   {"user", "admin"},
+}
+
+// TopicAllowed reports whether a role may subscribe to a topic
+func TopicAllowed(topic, role string) bool {
+	for _, allowed := range topicRoles[topic] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicMiddleware checks the caller's role against the topic before the
+// WebSocket upgrade. It runs after the auth middleware that sets the role.
+func TopicMiddleware(topic string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := GetUserRole(c)
+		if !TopicAllowed(topic, role) {
+			if role == anonymousRole {
+				apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, fmt.Sprintf("The %s topic requires a token", topic))
+				return
+			}
+			apierror.Respond(c, http.StatusForbidden, apierror.CodeForbidden, fmt.Sprintf("Role %q may not subscribe to the %s topic", role, topic))
+			return
+		}
+		c.Set("wsTopic", topic)
+		c.Next()
+	}
+}
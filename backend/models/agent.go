@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// MetricsAgent is the push agent (cmd/agent) enrolled for a device. It
+// reports the machine's metrics with its token instead of the backend
+// reading them over SSH.
+type MetricsAgent struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	DeviceID   uint       `json:"deviceId" gorm:"not null;uniqueIndex"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:12"`
+	Hostname   string     `json:"hostname" gorm:"size:255"` // as reported by the agent
+	Platform   string     `json:"platform" gorm:"size:100"`
+	Version    string     `json:"version" gorm:"size:50"`
+	LastSeenAt *time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// MetricsAgentResponse includes the plaintext enrollment token, returned
+// only when the agent is enrolled
+type MetricsAgentResponse struct {
+	MetricsAgent
+	Token string `json:"token"`
+}
+
+// AgentReport is the payload an agent posts to /api/ingest/metrics
+type AgentReport struct {
+	Version  string        `json:"version"`
+	Hostname string        `json:"hostname"`
+	Platform string        `json:"platform"`
+	Metrics  SystemMetrics `json:"metrics"`
+}
+
+// AgentHost is a device with an enrolled agent and its latest report.
+// Online is false once the agent has not reported for a while.
+type AgentHost struct {
+	DeviceID   uint           `json:"deviceId"`
+	DeviceName string         `json:"deviceName"`
+	Agent      MetricsAgent   `json:"agent"`
+	Online     bool           `json:"online"`
+	Metrics    *SystemMetrics `json:"metrics,omitempty"`
+}
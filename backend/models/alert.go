@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Alert rule targets
+const (
+	AlertTargetMetric    = "metric"    // this host's metrics
+	AlertTargetService   = "service"   // a monitored service, by TargetID
+	AlertTargetDevice    = "device"    // a device, by TargetID
+	AlertTargetContainer = "container" // a container, by TargetName
+	AlertTargetExternal  = "external"  // raised by an inbound hook (TargetID), keyed by TargetName
+	AlertTargetSensor    = "sensor"    // an ingested sensor, by TargetID
+	AlertTargetQuery     = "query"     // a metrics query expression in TargetName
+	AlertTargetTag       = "tag"       // the services or devices carrying a tag, by TagID
+)
+
+// Alert rule metrics. Status metrics (down, offline) are 1 while the target
+// is down and 0 otherwise; for tag targets they count the members down.
+const (
+	AlertMetricCPU          = "cpu"           // percent; metric and container targets
+	AlertMetricMemory       = "memory"        // percent; metric and container targets
+	AlertMetricDisk         = "disk"          // percent, fullest filesystem or TargetName mount point
+	AlertMetricLoad         = "load"          // 1-minute load average
+	AlertMetricTemperature  = "temperature"   // CPU °C
+	AlertMetricDown         = "down"          // service or container
+	AlertMetricResponseTime = "response_time" // service check in ms
+	AlertMetricOffline      = "offline"       // device
+	AlertMetricValue        = "value"         // sensor's latest reading or the query's result
+)
+
+// Alert rule conditions
+const (
+	ConditionAbove = "above"
+	ConditionBelow = "below"
+	ConditionEqual = "equal"
+)
+
+// Alert states. Acknowledged alerts are still active until they resolve.
+const (
+	AlertFiring       = "firing"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// AlertRule raises an alert when a target's metric meets the condition for
+// at least Duration seconds, and resolves it once the condition clears
+type AlertRule struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Target      string    `json:"target" gorm:"size:20;not null;index"` // metric, service, device, container, sensor, query, tag
+	TargetID    uint      `json:"targetId"`                             // service, device or sensor ID
+	TargetName  string    `json:"targetName" gorm:"size:255"`           // container name, disk mount point or query expression
+	TagID       uint      `json:"tagId"`                                // tag whose services or devices a tag rule watches
+	Metric      string    `json:"metric" gorm:"size:50;not null"`
+	Condition   string    `json:"condition" gorm:"column:comparison;size:20;not null"` // above, below, equal
+	Threshold   float64   `json:"threshold"`
+	Duration    int       `json:"duration"`                                  // seconds the condition must hold
+	Severity    string    `json:"severity" gorm:"size:20;default:'warning'"` // info, warning, critical
+	Enabled     bool      `json:"enabled" gorm:"default:true"`
+	EmailNotify bool      `json:"emailNotify"` // email the owner when the alert fires and resolves
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// AlertRuleRequest for creating or updating an alert rule
+type AlertRuleRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Target      string  `json:"target" binding:"required"`
+	TargetID    uint    `json:"targetId"`
+	TargetName  string  `json:"targetName"`
+	TagID       uint    `json:"tagId"`
+	Metric      string  `json:"metric" binding:"required"`
+	Condition   string  `json:"condition"` // ignored for down and offline, except on tag targets
+	Threshold   float64 `json:"threshold"`
+	Duration    int     `json:"duration"`
+	Severity    string  `json:"severity"`
+	Enabled     *bool   `json:"enabled"`
+	EmailNotify bool    `json:"emailNotify"`
+}
+
+// Alert is one occurrence of a rule's condition, from firing until it resolves
+type Alert struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	RuleID         uint       `json:"ruleId" gorm:"not null;index"`
+	UserID         uint       `json:"userId" gorm:"not null;index"`
+	Name           string     `json:"name" gorm:"size:255"` // rule name when the alert fired
+	Target         string     `json:"target" gorm:"size:20"`
+	TargetID       uint       `json:"targetId"`                   // the rule's target ID, or tag ID for tag rules
+	TargetName     string     `json:"targetName" gorm:"size:255"` // resolved name of the target
+	Metric         string     `json:"metric" gorm:"size:50"`
+	Severity       string     `json:"severity" gorm:"size:20;index"`
+	State          string     `json:"state" gorm:"size:20;index"` // firing, acknowledged, resolved
+	Value          float64    `json:"value"`                      // last observed value
+	Threshold      float64    `json:"threshold"`
+	Message        string     `json:"message" gorm:"size:1000"`
+	StartedAt      time.Time  `json:"startedAt" gorm:"index"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt"`
+	AcknowledgedBy string     `json:"acknowledgedBy,omitempty" gorm:"size:255"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// AuditLog records an action taken by a user or by the system on its own
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"index"`          // 0 for automatic actions
+	Actor      string    `json:"actor" gorm:"size:255"`        // user email or "system"
+	Action     string    `json:"action" gorm:"size:100;index"` // remediation.restart_container, ...
+	TargetType string    `json:"targetType" gorm:"size:50;index"`
+	TargetID   string    `json:"targetId" gorm:"size:255"`
+	Success    bool      `json:"success"`
+	Details    string    `json:"details,omitempty" gorm:"type:text"` // JSON payload
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+}
+
+// ActorSystem is the actor for actions taken automatically
+const ActorSystem = "system"
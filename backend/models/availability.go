@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// DevicePing is a stored reachability result for a device
+type DevicePing struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DeviceID  uint      `json:"deviceId" gorm:"not null;index:idx_device_ping_time"`
+	Online    bool      `json:"online"`
+	CheckedAt time.Time `json:"checkedAt" gorm:"index:idx_device_ping_time"`
+}
+
+// DeviceStatusChange records a device going online or offline
+type DeviceStatusChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DeviceID  uint      `json:"deviceId" gorm:"not null;index:idx_device_status_time"`
+	Online    bool      `json:"online"`
+	ChangedAt time.Time `json:"changedAt" gorm:"index:idx_device_status_time"`
+	// Duration is how long the device stayed in this state, up to now for
+	// the latest change, in seconds
+	Duration int64 `json:"duration" gorm:"-"`
+}
+
+// TableName keeps the history table name independent of the struct name
+func (DeviceStatusChange) TableName() string {
+	return "device_status_history"
+}
+
+// AvailabilityWindow summarizes a device's availability over a trailing window
+type AvailabilityWindow struct {
+	Window string `json:"window"` // 24h, 7d or 30d
+	// Availability is the percentage of observed time the device was online, nil without data
+	Availability *float64 `json:"availability"`
+	Downtime     int64    `json:"downtime"` // seconds
+	Outages      int      `json:"outages"`
+	// Coverage is the percentage of the window covered by ping history
+	Coverage float64 `json:"coverage"`
+}
+
+// AvailabilityDay is one cell of the availability calendar heatmap
+type AvailabilityDay struct {
+	Date         string   `json:"date"` // YYYY-MM-DD, server local time
+	Availability *float64 `json:"availability"`
+	Downtime     int64    `json:"downtime"` // seconds
+	Outages      int      `json:"outages"`
+}
+
+// DeviceAvailability is a device's uptime summary and daily calendar
+type DeviceAvailability struct {
+	DeviceID uint                 `json:"deviceId"`
+	Windows  []AvailabilityWindow `json:"windows"`
+	Calendar []AvailabilityDay    `json:"calendar"`
+	// Annotations are the device's notes in the calendar range
+	Annotations []Annotation `json:"annotations"`
+}
+
+// StaleDevice is a device that has not been seen for longer than the stale threshold
+type StaleDevice struct {
+	ID       uint       `json:"id"`
+	UserID   uint       `json:"userId"`
+	Name     string     `json:"name"`
+	IP       string     `json:"ip"`
+	Type     string     `json:"type"`
+	LastSeen *time.Time `json:"lastSeen"`
+	// DaysUnseen counts from the last sighting, or from creation for devices never seen
+	DaysUnseen int  `json:"daysUnseen"`
+	NeverSeen  bool `json:"neverSeen"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,13 +23,99 @@ type Device struct {
 	IsOnline    bool       `json:"isOnline" gorm:"default:false"`
 	LastSeen    *time.Time `json:"lastSeen"`
 	IsActive    bool       `json:"isActive" gorm:"default:true"`
-	// SSH fields for remote shutdown
-	SSHUser     string         `json:"sshUser" gorm:"size:100"`
-	SSHPassword string         `json:"sshPassword,omitempty" gorm:"size:255"`
-	SSHPort     int            `json:"sshPort" gorm:"default:22"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty"` // set when deactivated as stale
+	Tags        []Tag      `json:"tags" gorm:"many2many:device_tags"`
+	// SSH login for power actions and the terminal: a password, a private
+	// key, or both. Secrets are encrypted at rest and never returned.
+	SSHUser          string         `json:"sshUser" gorm:"size:100"`
+	SSHPassword      string         `json:"-" gorm:"size:500"`          // encrypted
+	SSHKey           string         `json:"-" gorm:"type:text"`         // PEM private key, encrypted
+	SSHKeyPassphrase string         `json:"-" gorm:"size:500"`          // encrypted
+	SSHKeyPath       string         `json:"sshKeyPath" gorm:"size:255"` // key file in SSH_KEY_DIR instead of a stored key
+	HasSSHPassword   bool           `json:"hasSshPassword" gorm:"-"`    // whether a password is stored
+	HasSSHKey        bool           `json:"hasSshKey" gorm:"-"`         // whether a stored or server-side key is set
+	SSHPort          int            `json:"sshPort" gorm:"default:22"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Asset management
+	SerialNumber   string     `json:"serialNumber" gorm:"size:100"`
+	PurchaseDate   *time.Time `json:"purchaseDate"`
+	WarrantyExpiry *time.Time `json:"warrantyExpiry" gorm:"index"`
+	Price          float64    `json:"price"`
+	Notes          string     `json:"notes" gorm:"type:text"`
+	// WarrantyNotice is the last warranty reminder sent: "", "expiring" or "expired"
+	WarrantyNotice string `json:"-" gorm:"size:20"`
+
+	// SudoPassword is given to sudo for power actions, encrypted at rest;
+	// empty falls back to the SSH password
+	SudoPassword string `json:"-" gorm:"size:500"`
+
+	// Power actions. PowerMethod is "", "ssh" or "winrm"; empty uses WinRM
+	// when only the WinRM port answers. WinRM logs in with SSHUser/SSHPassword
+	PowerMethod   string `json:"powerMethod" gorm:"size:10"`
+	WinRMPort     int    `json:"winrmPort"` // 0 uses 5985, or 5986 over HTTPS
+	WinRMHTTPS    bool   `json:"winrmHttps" gorm:"default:false"`
+	WinRMInsecure bool   `json:"winrmInsecure" gorm:"default:false"` // skip certificate verification
+	WinRMAuth     string `json:"winrmAuth" gorm:"size:10"`           // ntlm (default) or basic
+
+	// PowerOS picks the default SSH power commands: "" or linux (also macOS)
+	// runs shutdown through sudo, windows runs Windows' shutdown directly.
+	// ShutdownCommand and RebootCommand replace the defaults.
+	PowerOS         string `json:"powerOs" gorm:"size:10"`
+	ShutdownCommand string `json:"shutdownCommand" gorm:"size:255"`
+	RebootCommand   string `json:"rebootCommand" gorm:"size:255"`
+
+	// IPMI BMC used to power the device on; the password is encrypted at rest
+	IPMIHost     string `json:"ipmiHost" gorm:"size:255"`
+	IPMIUser     string `json:"ipmiUser" gorm:"size:100"`
+	IPMIPassword string `json:"-" gorm:"size:500"`
+
+	// PowerWatts is the average draw when on; 0 uses a default for the type
+	PowerWatts float64 `json:"powerWatts"`
+
+	// Criticality weighs the device in the health score: low, medium, high, critical
+	Criticality string `json:"criticality" gorm:"size:20;default:medium"`
+
+	// CollectMetrics reads CPU, memory, disk and network usage over SSH, for
+	// Linux hosts with SSH credentials
+	CollectMetrics bool `json:"collectMetrics" gorm:"default:false"`
+
+	// SSHHostKey is the host key pinned on the first SSH connection, in
+	// authorized_keys format; later connections must present the same key
+	SSHHostKey            string `json:"-" gorm:"size:1000"`
+	SSHHostKeyFingerprint string `json:"sshHostKeyFingerprint" gorm:"size:100"`
+}
+
+// AfterFind reports which SSH secrets are set without exposing them
+func (d *Device) AfterFind(tx *gorm.DB) error {
+	d.HasSSHPassword = d.SSHPassword != ""
+	d.HasSSHKey = d.SSHKey != "" || d.SSHKeyPath != ""
+	return nil
+}
+
+// AfterSave keeps the SSH secret flags current on created and updated devices
+func (d *Device) AfterSave(tx *gorm.DB) error {
+	return d.AfterFind(tx)
+}
+
+// DeviceDetail is a device with the services it hosts
+type DeviceDetail struct {
+	Device
+	Services     []HostedService     `json:"services"`
+	Availability *DeviceAvailability `json:"availability,omitempty"`
+}
+
+// HostedService is a service running on a device, with its last known status
+type HostedService struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	URL       string     `json:"url"`
+	Icon      string     `json:"icon"`
+	IsActive  bool       `json:"isActive"`
+	Status    string     `json:"status"` // online, offline, error, host_down, unknown
+	LastCheck *time.Time `json:"lastCheck,omitempty"`
 }
 
 // DeviceType constants
@@ -36,24 +123,43 @@ var DeviceTypes = []string{"pc", "server", "phone", "cctv", "router", "tablet",
 
 // ServiceConfig represents a saved service configuration in the database
 type ServiceConfig struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	UserID        uint           `json:"userId" gorm:"not null;index"`
-	DeviceID      *uint          `json:"deviceId" gorm:"index"`
-	Name          string         `json:"name" gorm:"size:255;not null"`
-	URL           string         `json:"url" gorm:"size:500;not null"`
-	Method        string         `json:"method" gorm:"size:10;default:GET"` // GET, POST, TCP, PING
-	Port          int            `json:"port"`
-	Icon          string         `json:"icon" gorm:"size:100"`
-	Category      string         `json:"category" gorm:"size:100"` // media, network, storage, security, productivity
-	Description   string         `json:"description" gorm:"size:500"`
-	Tags          string         `json:"tags" gorm:"size:500"`            // JSON array stored as string
-	CheckInterval int            `json:"checkInterval" gorm:"default:60"` // in seconds
-	Timeout       int            `json:"timeout" gorm:"default:10"`       // in seconds
-	ExpectedCode  int            `json:"expectedCode" gorm:"default:200"`
-	IsActive      bool           `json:"isActive" gorm:"default:true"`
-	CreatedAt     time.Time      `json:"createdAt"`
-	UpdatedAt     time.Time      `json:"updatedAt"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	UserID              uint           `json:"userId" gorm:"not null;index"`
+	DeviceID            *uint          `json:"deviceId" gorm:"index"`
+	Name                string         `json:"name" gorm:"size:255;not null"`
+	URL                 string         `json:"url" gorm:"size:500;not null"`
+	Method              string         `json:"method" gorm:"size:10;default:GET"` // GET, POST, TCP, PING, POSTGRES, MYSQL, REDIS
+	Port                int            `json:"port"`
+	Icon                string         `json:"icon" gorm:"size:100"`
+	Category            string         `json:"category" gorm:"size:100"` // media, network, storage, security, productivity
+	Description         string         `json:"description" gorm:"size:500"`
+	Tags                []Tag          `json:"tags" gorm:"many2many:service_tags"`
+	LegacyTags          string         `json:"-" gorm:"column:tags;size:500"`   // pre-normalization JSON array, migrated to Tags
+	CheckInterval       int            `json:"checkInterval" gorm:"default:60"` // in seconds
+	Timeout             int            `json:"timeout" gorm:"default:10"`       // in seconds
+	ExpectedCode        int            `json:"expectedCode" gorm:"default:200"`
+	IsActive            bool           `json:"isActive" gorm:"default:true"`
+	BadgePublic         bool           `json:"badgePublic" gorm:"default:false"` // badge viewable without token
+	BadgeToken          string         `json:"badgeToken,omitempty" gorm:"size:64"`
+	Locations           string         `json:"locations" gorm:"size:500"`            // comma separated probe agent locations
+	SkipLocal           bool           `json:"skipLocal" gorm:"default:false"`       // exclude the backend's own checks from consensus
+	MinFailingLocations int            `json:"minFailingLocations" gorm:"default:1"` // M-of-N failures before marking down
+	FailureThreshold    int            `json:"failureThreshold" gorm:"default:0"`    // consecutive failures before down, 0 uses CHECK_FAILURE_THRESHOLD
+	RecoveryThreshold   int            `json:"recoveryThreshold" gorm:"default:0"`   // consecutive successes before up, 0 uses CHECK_RECOVERY_THRESHOLD
+	TLSSkipVerify       bool           `json:"tlsSkipVerify" gorm:"default:false"`
+	TLSServerName       string         `json:"tlsServerName" gorm:"size:255"`         // SNI override
+	CABundle            string         `json:"caBundle,omitempty" gorm:"type:text"`   // PEM certificates to trust
+	ClientCert          string         `json:"clientCert,omitempty" gorm:"type:text"` // PEM client certificate for mTLS
+	ClientKey           string         `json:"-" gorm:"type:text"`                    // PEM private key, encrypted
+	ProxyURL            string         `json:"proxyUrl" gorm:"size:500"`              // check proxy, "direct" bypasses the global proxy
+	Impact              string         `json:"impact" gorm:"size:20;default:medium"`  // low, medium, high, critical
+	ImpactNote          string         `json:"impactNote" gorm:"size:500"`            // who is affected when it is down
+	Container           string         `json:"container" gorm:"size:255"`             // container name or "label:key=value"
+	AutoRestart         bool           `json:"autoRestart" gorm:"default:false"`      // a remediation hook restarts the container when the service goes down
+	DBPassword          string         `json:"-" gorm:"type:text"`                    // password for database checks, encrypted
+	CreatedAt           time.Time      `json:"createdAt"`
+	UpdatedAt           time.Time      `json:"updatedAt"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // CreateDeviceRequest for creating a new device
@@ -67,10 +173,43 @@ type CreateDeviceRequest struct {
 	Icon        string `json:"icon"`
 	Location    string `json:"location"`
 	Description string `json:"description"`
-	// SSH fields for remote shutdown
-	SSHUser     string `json:"sshUser"`
-	SSHPassword string `json:"sshPassword"`
-	SSHPort     int    `json:"sshPort"`
+	// SSH login for power actions and the terminal: a password, a PEM
+	// private key (with its passphrase if encrypted), or a key file name in
+	// SSH_KEY_DIR (admins only)
+	SSHUser          string `json:"sshUser"`
+	SSHPassword      string `json:"sshPassword"`
+	SSHKey           string `json:"sshKey"`
+	SSHKeyPassphrase string `json:"sshKeyPassphrase"`
+	SSHKeyPath       string `json:"sshKeyPath"`
+	SSHPort          int    `json:"sshPort"`
+	// SudoPassword when it differs from the SSH password
+	SudoPassword string `json:"sudoPassword"`
+	// Power actions over SSH or WinRM
+	PowerMethod   string `json:"powerMethod"`
+	WinRMPort     int    `json:"winrmPort"`
+	WinRMHTTPS    bool   `json:"winrmHttps"`
+	WinRMInsecure bool   `json:"winrmInsecure"`
+	WinRMAuth     string `json:"winrmAuth"`
+	// Target OS and custom commands for shutdown and reboot
+	PowerOS         string `json:"powerOs"`
+	ShutdownCommand string `json:"shutdownCommand"`
+	RebootCommand   string `json:"rebootCommand"`
+	// IPMI BMC for powering on
+	IPMIHost     string `json:"ipmiHost"`
+	IPMIUser     string `json:"ipmiUser"`
+	IPMIPassword string `json:"ipmiPassword"`
+	// Average power draw in watts, for energy estimates
+	PowerWatts float64 `json:"powerWatts"`
+	// Criticality for the health score; default medium
+	Criticality string `json:"criticality"`
+	// Collect metrics over SSH; needs SSH credentials
+	CollectMetrics bool `json:"collectMetrics"`
+	// Asset management; dates are YYYY-MM-DD
+	SerialNumber   string  `json:"serialNumber"`
+	PurchaseDate   string  `json:"purchaseDate"`
+	WarrantyExpiry string  `json:"warrantyExpiry"`
+	Price          float64 `json:"price"`
+	Notes          string  `json:"notes"`
 }
 
 // UpdateDeviceRequest for updating a device
@@ -85,8 +224,82 @@ type UpdateDeviceRequest struct {
 	Location    *string `json:"location"`
 	Description *string `json:"description"`
 	IsActive    *bool   `json:"isActive"`
-	// SSH fields for remote shutdown
-	SSHUser     *string `json:"sshUser"`
-	SSHPassword *string `json:"sshPassword"`
-	SSHPort     *int    `json:"sshPort"`
+	// SSH login. An empty password or key keeps the stored one; the Clear
+	// flags remove them. An empty key path clears it.
+	SSHUser          *string `json:"sshUser"`
+	SSHPassword      *string `json:"sshPassword"`
+	SSHKey           *string `json:"sshKey"`
+	SSHKeyPassphrase *string `json:"sshKeyPassphrase"`
+	SSHKeyPath       *string `json:"sshKeyPath"`
+	ClearSSHPassword bool    `json:"clearSshPassword"`
+	ClearSSHKey      bool    `json:"clearSshKey"`
+	SSHPort          *int    `json:"sshPort"`
+	// SudoPassword when it differs from the SSH password, empty clears it
+	SudoPassword *string `json:"sudoPassword"`
+	// Power actions over SSH or WinRM
+	PowerMethod   *string `json:"powerMethod"`
+	WinRMPort     *int    `json:"winrmPort"`
+	WinRMHTTPS    *bool   `json:"winrmHttps"`
+	WinRMInsecure *bool   `json:"winrmInsecure"`
+	WinRMAuth     *string `json:"winrmAuth"`
+	// Target OS and custom commands for shutdown and reboot, empty uses the defaults
+	PowerOS         *string `json:"powerOs"`
+	ShutdownCommand *string `json:"shutdownCommand"`
+	RebootCommand   *string `json:"rebootCommand"`
+	// IPMI BMC for powering on; an empty password clears it
+	IPMIHost     *string `json:"ipmiHost"`
+	IPMIUser     *string `json:"ipmiUser"`
+	IPMIPassword *string `json:"ipmiPassword"`
+	// Average power draw in watts, for energy estimates
+	PowerWatts *float64 `json:"powerWatts"`
+	// Criticality for the health score: low, medium, high, critical
+	Criticality *string `json:"criticality"`
+	// Collect metrics over SSH; needs SSH credentials
+	CollectMetrics *bool `json:"collectMetrics"`
+	// Asset management; dates are YYYY-MM-DD, empty clears them
+	SerialNumber   *string  `json:"serialNumber"`
+	PurchaseDate   *string  `json:"purchaseDate"`
+	WarrantyExpiry *string  `json:"warrantyExpiry"`
+	Price          *float64 `json:"price"`
+	Notes          *string  `json:"notes"`
+}
+
+// DeviceAttachment is a file such as an invoice or manual stored for a device
+type DeviceAttachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeviceID    uint      `json:"deviceId" gorm:"not null;index"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	Filename    string    `json:"filename" gorm:"size:255;not null"`
+	ContentType string    `json:"contentType" gorm:"size:100"`
+	Size        int64     `json:"size"`
+	Path        string    `json:"-" gorm:"size:500;not null"` // relative to ATTACHMENT_DIR
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// UpdateServiceRequest for updating a service's check. Device, container,
+// TLS, proxy, database credentials, impact, thresholds, locations and the
+// badge have their own endpoints.
+type UpdateServiceRequest struct {
+	Name          *string `json:"name"`
+	URL           *string `json:"url"`
+	Method        *string `json:"method"`
+	Port          *int    `json:"port"`
+	Icon          *string `json:"icon"`
+	Category      *string `json:"category"`
+	Description   *string `json:"description"`
+	CheckInterval *int    `json:"checkInterval"`
+	Timeout       *int    `json:"timeout"`
+	ExpectedCode  *int    `json:"expectedCode"`
+	IsActive      *bool   `json:"isActive"`
+	// Tag names or {"name": ...} objects, replacing the service's tags
+	Tags json.RawMessage `json:"tags"`
+}
+
+// UpdateServiceTLSRequest for changing the TLS options of a service check
+type UpdateServiceTLSRequest struct {
+	TLSSkipVerify *bool   `json:"tlsSkipVerify" form:"tlsSkipVerify"`
+	TLSServerName *string `json:"tlsServerName" form:"tlsServerName"`
+	CABundle      *string `json:"caBundle"`
+	ClientCert    *string `json:"clientCert"`
+	ClientKey     *string `json:"clientKey"`
 }
@@ -18,6 +18,20 @@ type Container struct {
 	Mounts      []ContainerMount  `json:"mounts"`
 	Stats       ContainerStats    `json:"stats,omitempty"`
 	Health      string            `json:"health,omitempty"`
+
+	Vulnerabilities *VulnerabilitySummary `json:"vulnerabilities,omitempty"`
+	// UpdateAvailable is set when the registry has a newer image for the tag
+	UpdateAvailable bool `json:"updateAvailable,omitempty"`
+}
+
+// ServiceContainer is the state of the container backing a service
+type ServiceContainer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
+	Error  string `json:"error,omitempty"` // set when the container can't be found
 }
 
 // ContainerPort represents a port mapping
@@ -55,3 +69,78 @@ type ContainerStats struct {
 type ContainerAction struct {
 	Action string `json:"action"` // start, stop, restart, pause, unpause, remove
 }
+
+// ContainerUsage is a periodic CPU and memory sample of a running container,
+// kept by name so history survives container re-creation
+type ContainerUsage struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Container   string    `json:"container" gorm:"size:255;not null;index:idx_container_usage_time"`
+	CPUPercent  float64   `json:"cpuPercent"`  // 100 = one core
+	MemoryUsage int64     `json:"memoryUsage"` // bytes, page cache excluded
+	SampledAt   time.Time `json:"sampledAt" gorm:"index:idx_container_usage_time"`
+}
+
+// ContainerResources are the CPU and memory limits set on a container; zero means unset
+type ContainerResources struct {
+	CPULimit          float64 `json:"cpuLimit"`          // cores (cpus)
+	MemoryLimit       int64   `json:"memoryLimit"`       // bytes (mem_limit)
+	MemoryReservation int64   `json:"memoryReservation"` // bytes (mem_reservation)
+}
+
+// Container sizing verdicts
+const (
+	SizingOK              = "ok"
+	SizingOverProvisioned = "over_provisioned"
+	SizingAtRisk          = "at_risk"
+	SizingUnbounded       = "unbounded" // no limit set
+	SizingNoData          = "no_data"
+)
+
+// ContainerSizing compares a container's limits with its observed usage
+type ContainerSizing struct {
+	Container string             `json:"container"`
+	Image     string             `json:"image"`
+	State     string             `json:"state"`
+	Resources ContainerResources `json:"resources"`
+	Samples   int                `json:"samples"`
+
+	CPUP95    float64 `json:"cpuP95"` // cores
+	CPUMax    float64 `json:"cpuMax"`
+	MemoryP95 int64   `json:"memoryP95"` // bytes
+	MemoryMax int64   `json:"memoryMax"`
+
+	CPUStatus    string `json:"cpuStatus"`
+	MemoryStatus string `json:"memoryStatus"`
+	Status       string `json:"status"` // worst of the two
+
+	// Suggested compose values, zero when there is not enough data
+	SuggestedCPUs   float64  `json:"suggestedCpus"`
+	SuggestedMemory int64    `json:"suggestedMemory"` // bytes
+	Notes           []string `json:"notes"`
+}
+
+// ContainerLogLine is one line of a container's output
+type ContainerLogLine struct {
+	Stream    string     `json:"stream"`              // stdout or stderr
+	Timestamp *time.Time `json:"timestamp,omitempty"` // set when timestamps were requested
+	Line      string     `json:"line"`
+}
+
+// ContainerLogs is the tail of a container's output
+type ContainerLogs struct {
+	ContainerID string             `json:"containerId"`
+	Lines       []ContainerLogLine `json:"lines"`
+	Truncated   bool               `json:"truncated"` // output exceeded the size cap
+}
+
+// ContainerEvent is a container lifecycle change reported by the Docker daemon
+type ContainerEvent struct {
+	Action      string    `json:"action"` // start, stop, die, health_status
+	ContainerID string    `json:"containerId"`
+	Name        string    `json:"name"`
+	Image       string    `json:"image"`
+	State       string    `json:"state,omitempty"`    // running or exited, after start, stop and die
+	Health      string    `json:"health,omitempty"`   // healthy, unhealthy or starting, after health_status
+	ExitCode    *int      `json:"exitCode,omitempty"` // after die
+	Time        time.Time `json:"time"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// DatabaseSize is the size of one database (or Redis keyspace)
+type DatabaseSize struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes,omitempty"`
+	Keys  int64  `json:"keys,omitempty"` // Redis only
+}
+
+// DatabaseHealth is the result of a native PostgreSQL, MySQL or Redis check
+type DatabaseHealth struct {
+	Engine         string         `json:"engine"` // postgres, mysql, redis
+	Version        string         `json:"version,omitempty"`
+	Role           string         `json:"role,omitempty"`           // primary, replica
+	ConnectMs      int64          `json:"connectMs"`                // time to connect and authenticate
+	QueryMs        int64          `json:"queryMs"`                  // time for SELECT 1 / PING
+	ReplicationLag *float64       `json:"replicationLag,omitempty"` // seconds behind the primary
+	Replication    string         `json:"replication,omitempty"`    // replica link state, when reported
+	Sizes          []DatabaseSize `json:"sizes,omitempty"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Deploy build statuses
+const (
+	BuildRunning   = "running"
+	BuildSucceeded = "succeeded"
+	BuildFailed    = "failed"
+)
+
+// Deploy build triggers
+const (
+	BuildTriggerManual  = "manual"
+	BuildTriggerWebhook = "webhook"
+)
+
+// DeployApp is a git repository with a Dockerfile that is built into an
+// image and rolled out as a single container
+type DeployApp struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserID     uint   `json:"userId" gorm:"not null;index"`
+	Name       string `json:"name" gorm:"size:255;not null"`
+	RepoURL    string `json:"repoUrl" gorm:"size:500;not null"` // https or ssh URL; credentials are never logged
+	Branch     string `json:"branch" gorm:"size:255"`           // default main
+	Dockerfile string `json:"dockerfile" gorm:"size:255"`       // relative to ContextDir, default Dockerfile
+	ContextDir string `json:"contextDir" gorm:"size:255"`       // build context inside the repository, default the root
+	Image      string `json:"image" gorm:"size:255"`            // image tag built, default homelab-deploy/<name>:latest
+	Container  string `json:"container" gorm:"size:255;not null"`
+
+	// Used when the container is first created, and replace the running
+	// container's settings when set
+	Ports    []string `json:"ports" gorm:"-"` // "8080:80", "53:53/udp"
+	RawPorts string   `json:"-" gorm:"column:ports;type:text"`
+	Env      []string `json:"env" gorm:"-"` // "KEY=value"
+	RawEnv   string   `json:"-" gorm:"column:env;type:text"`
+
+	// WebhookSecret authenticates build webhooks, as ?token= or as the
+	// GitHub/Gitea HMAC signature secret. Stored encrypted.
+	WebhookSecret string `json:"-" gorm:"size:500"`
+
+	LastBuildID *uint      `json:"lastBuildId"`
+	LastStatus  string     `json:"lastStatus" gorm:"size:20"`
+	LastBuildAt *time.Time `json:"lastBuildAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// DeployAppRequest for creating or updating a deploy app
+type DeployAppRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	RepoURL    string   `json:"repoUrl" binding:"required"`
+	Branch     string   `json:"branch"`
+	Dockerfile string   `json:"dockerfile"`
+	ContextDir string   `json:"contextDir"`
+	Image      string   `json:"image"`
+	Container  string   `json:"container" binding:"required"`
+	Ports      []string `json:"ports"`
+	Env        []string `json:"env"`
+}
+
+// DeployBuild is one build and rollout of a deploy app
+type DeployBuild struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	AppID      uint       `json:"appId" gorm:"not null;index"`
+	Trigger    string     `json:"trigger" gorm:"column:trigger_type;size:20"` // manual, webhook
+	Status     string     `json:"status" gorm:"size:20;index"`
+	Commit     string     `json:"commit" gorm:"size:64"`
+	ImageID    string     `json:"imageId" gorm:"size:100"`
+	Error      string     `json:"error,omitempty" gorm:"size:1000"`
+	Log        string     `json:"log,omitempty" gorm:"type:text"` // last 60 KB
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
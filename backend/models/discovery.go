@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// NetworkScanRequest asks for a sweep of an IPv4 subnet
+type NetworkScanRequest struct {
+	CIDR string `json:"cidr" binding:"required"`
+	// Ports to probe; empty uses the default list of common homelab ports
+	Ports []int `json:"ports"`
+}
+
+// DiscoveredHost is a host that answered a network scan
+type DiscoveredHost struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`    // from the ARP cache, empty off-link
+	Vendor    string `json:"vendor"` // from the MAC's OUI when known
+	Hostname  string `json:"hostname"`
+	OpenPorts []int  `json:"openPorts"`
+	// Type is a guess from the open ports, used as the default on import
+	Type string `json:"type"`
+	// DeviceID is the caller's existing device with this IP or MAC
+	DeviceID *uint `json:"deviceId,omitempty"`
+}
+
+// NetworkScanResult is the outcome of a network scan
+type NetworkScanResult struct {
+	CIDR      string           `json:"cidr"`
+	Scanned   int              `json:"scanned"` // addresses probed
+	Hosts     []DiscoveredHost `json:"hosts"`
+	StartedAt time.Time        `json:"startedAt"`
+	Duration  int64            `json:"duration"` // milliseconds
+}
+
+// ImportHost is a discovered host to create as a device. Name defaults to
+// the IP and Type to "other".
+type ImportHost struct {
+	IP   string `json:"ip" binding:"required"`
+	MAC  string `json:"mac"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ImportHostsRequest bulk-imports discovered hosts as devices
+type ImportHostsRequest struct {
+	Hosts []ImportHost `json:"hosts" binding:"required,min=1,dive"`
+}
+
+// ImportHostsResult lists the devices created and the hosts skipped
+type ImportHostsResult struct {
+	Created []Device      `json:"created"`
+	Skipped []SkippedHost `json:"skipped"`
+}
+
+// SkippedHost is a host an import did not create, with the reason
+type SkippedHost struct {
+	IP     string `json:"ip"`
+	Reason string `json:"reason"`
+}
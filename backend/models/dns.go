@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// DNSRecord is an expected DNS answer that is checked against the configured resolvers
+type DNSRecord struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"userId" gorm:"not null;index"`
+	Name   string `json:"name" gorm:"size:255;not null"`
+	Type   string `json:"type" gorm:"size:10;default:A"` // A, AAAA, CNAME
+	// Expected is a comma-separated list of addresses, or the CNAME target.
+	// When empty the address of the linked service's device is expected.
+	Expected  string `json:"expected" gorm:"size:500"`
+	ServiceID *uint  `json:"serviceId" gorm:"index"`
+
+	Status    string              `json:"status" gorm:"size:20;default:unknown"` // ok, mismatch, error, unknown
+	Results   []DNSResolverAnswer `json:"results" gorm:"-"`
+	RawResult string              `json:"-" gorm:"column:results;type:text"` // JSON []DNSResolverAnswer
+	LastCheck *time.Time          `json:"lastCheck"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// DNSRecordRequest for creating or updating a DNS record
+type DNSRecordRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type"`
+	Expected  string `json:"expected"`
+	ServiceID *uint  `json:"serviceId"`
+}
+
+// DNSResolverAnswer is what one resolver returned for a record
+type DNSResolverAnswer struct {
+	Resolver string   `json:"resolver"`
+	Answers  []string `json:"answers"`
+	Match    bool     `json:"match"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// DNS record check statuses
+const (
+	DNSStatusOK       = "ok"
+	DNSStatusMismatch = "mismatch"
+	DNSStatusError    = "error"
+	DNSStatusUnknown  = "unknown"
+)
+
+// DNSRecordTypes are the record types that can be checked
+var DNSRecordTypes = []string{"A", "AAAA", "CNAME"}
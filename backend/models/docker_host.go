@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// DockerHost is a remote Docker daemon managed alongside the local one.
+// Endpoint is tcp://host:2376 (optionally with TLS client certificates) or
+// ssh://user@host[:port], which tunnels to the daemon's socket over SSH.
+type DockerHost struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Endpoint      string    `json:"endpoint" gorm:"size:255;not null"`
+	TLSCACert     string    `json:"tlsCaCert,omitempty" gorm:"type:text"` // PEM, verifies the daemon
+	TLSCert       string    `json:"tlsCert,omitempty" gorm:"type:text"`   // PEM client certificate
+	TLSKey        string    `json:"-" gorm:"type:text"`                   // PEM client key, encrypted
+	TLSSkipVerify bool      `json:"tlsSkipVerify" gorm:"default:false"`
+	SSHPassword   string    `json:"-" gorm:"size:500"`          // encrypted
+	SSHKey        string    `json:"-" gorm:"type:text"`         // PEM private key, encrypted
+	SocketPath    string    `json:"socketPath" gorm:"size:255"` // over SSH, default /var/run/docker.sock
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// SSHHostKey is the host key pinned on the first SSH connection, in
+	// authorized_keys format; later connections must present the same key
+	SSHHostKey            string `json:"-" gorm:"size:1000"`
+	SSHHostKeyFingerprint string `json:"sshHostKeyFingerprint,omitempty" gorm:"size:100"`
+}
+
+// DockerHostRequest for creating or updating a Docker host. Secrets left
+// empty on update keep their current value.
+type DockerHostRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Endpoint      string `json:"endpoint" binding:"required"`
+	TLSCACert     string `json:"tlsCaCert"`
+	TLSCert       string `json:"tlsCert"`
+	TLSKey        string `json:"tlsKey"`
+	TLSSkipVerify bool   `json:"tlsSkipVerify"`
+	SSHPassword   string `json:"sshPassword"`
+	SSHKey        string `json:"sshKey"`
+	SocketPath    string `json:"socketPath"`
+}
+
+// DockerHostStatus is a Docker host with whether its daemon answers
+type DockerHostStatus struct {
+	DockerHost
+	Local      bool   `json:"local"` // the daemon the backend runs next to
+	Connected  bool   `json:"connected"`
+	Version    string `json:"version,omitempty"`
+	Containers int    `json:"containers"`
+	Running    int    `json:"running"`
+	Error      string `json:"error,omitempty"`
+}
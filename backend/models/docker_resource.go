@@ -0,0 +1,83 @@
+package models
+
+// DockerVolume is a Docker volume with the containers mounting it
+type DockerVolume struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Mountpoint string            `json:"mountpoint"` // path on the host
+	Scope      string            `json:"scope"`      // local, global
+	Created    string            `json:"created"`
+	Project    string            `json:"project,omitempty"` // compose project
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+	Size       int64             `json:"size"` // bytes, -1 when the driver doesn't report it
+	Containers []VolumeMount     `json:"containers"`
+}
+
+// VolumeMount is a container's mount of a volume
+type VolumeMount struct {
+	ContainerID   string `json:"containerId"`
+	ContainerName string `json:"containerName"`
+	State         string `json:"state"`
+	Destination   string `json:"destination"` // path inside the container
+	ReadOnly      bool   `json:"readOnly"`
+}
+
+// VolumeCreateRequest represents the request body for creating a volume
+type VolumeCreateRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Driver  string            `json:"driver"` // default local
+	Options map[string]string `json:"options"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// DockerNetwork is a Docker network with its address ranges and the
+// containers attached to it
+type DockerNetwork struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Scope      string            `json:"scope"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	IPv6       bool              `json:"ipv6"`
+	BuiltIn    bool              `json:"builtIn"` // bridge, host and none can't be removed
+	Created    string            `json:"created"`
+	Project    string            `json:"project,omitempty"` // compose project
+	Subnets    []NetworkSubnet   `json:"subnets"`
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+	Containers []NetworkEndpoint `json:"containers"`
+}
+
+// NetworkSubnet is one address range of a Docker network
+type NetworkSubnet struct {
+	Subnet  string `json:"subnet"`
+	Gateway string `json:"gateway,omitempty"`
+	IPRange string `json:"ipRange,omitempty"`
+}
+
+// NetworkEndpoint is a container's attachment to a network
+type NetworkEndpoint struct {
+	ContainerID   string   `json:"containerId"`
+	ContainerName string   `json:"containerName"`
+	State         string   `json:"state"`
+	IPv4Address   string   `json:"ipv4Address,omitempty"`
+	IPv6Address   string   `json:"ipv6Address,omitempty"`
+	MacAddress    string   `json:"macAddress,omitempty"`
+	Aliases       []string `json:"aliases,omitempty"`
+}
+
+// NetworkCreateRequest represents the request body for creating a network
+type NetworkCreateRequest struct {
+	Name       string            `json:"name" binding:"required"`
+	Driver     string            `json:"driver"`  // default bridge
+	Subnet     string            `json:"subnet"`  // CIDR, optional
+	Gateway    string            `json:"gateway"` // optional, inside the subnet
+	IPRange    string            `json:"ipRange"` // optional, inside the subnet
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	IPv6       bool              `json:"ipv6"`
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+}
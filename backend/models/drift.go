@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ContainerBaseline stores the last known creation config of a container, keyed by name
+type ContainerBaseline struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"size:255;uniqueIndex;not null"`
+	ContainerID string    `json:"containerId" gorm:"size:64"`
+	ConfigHash  string    `json:"configHash" gorm:"size:64"`
+	Config      string    `json:"-" gorm:"type:text"` // JSON ContainerSpec
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ContainerSpec is the drift-relevant part of a container's configuration.
+// Env values are stored as hashes so secrets never reach the database or events.
+type ContainerSpec struct {
+	Image       string            `json:"image"`
+	Command     string            `json:"command"`
+	Env         map[string]string `json:"env"`
+	Ports       []string          `json:"ports"`
+	Mounts      []string          `json:"mounts"`
+	NetworkMode string            `json:"networkMode"`
+	Privileged  bool              `json:"privileged"`
+	CapAdd      []string          `json:"capAdd"`
+}
+
+// ConfigDrift is a single field-level difference between two container specs
+type ConfigDrift struct {
+	Field  string `json:"field"`
+	Key    string `json:"key,omitempty"`
+	Change string `json:"change"` // added, removed, changed
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Email digest modes
+const (
+	DigestOff   = "off"
+	DigestDaily = "daily"
+)
+
+// EmailPreference holds a user's email notification settings. Alert emails
+// are opted into per rule with AlertRule.EmailNotify.
+type EmailPreference struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"userId" gorm:"not null;uniqueIndex"`
+	Address      string     `json:"address" gorm:"size:255"` // empty sends to the account email
+	Digest       string     `json:"digest" gorm:"size:20;default:'off'"`
+	DigestHour   int        `json:"digestHour"` // server local hour the daily digest is sent, 0-23
+	LastDigestAt *time.Time `json:"lastDigestAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// EmailPreferenceRequest for updating email notification settings
+type EmailPreferenceRequest struct {
+	Address    string `json:"address"`
+	Digest     string `json:"digest"`
+	DigestHour int    `json:"digestHour"`
+}
+
+// EmailStatus is a user's email settings with whether the server can send email
+type EmailStatus struct {
+	EmailPreference
+	Configured bool   `json:"configured"` // SMTP is set up on the server
+	SendsTo    string `json:"sendsTo"`    // address emails go to
+}
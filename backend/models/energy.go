@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// DeviceActivity is a periodic sample of how busy a device is
+type DeviceActivity struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	DeviceID     uint      `json:"deviceId" gorm:"not null;index:idx_device_activity_time"`
+	Online       bool      `json:"online"`
+	Connections  int       `json:"connections"`  // TCP connections between this host and the device
+	NetBytes     uint64    `json:"netBytes"`     // bytes moved since the last sample, only for this host
+	ContainerCPU float64   `json:"containerCpu"` // CPU % of containers behind the device's services
+	SampledAt    time.Time `json:"sampledAt" gorm:"index:idx_device_activity_time"`
+}
+
+// IdleWindow is a weekly stretch of hours a device is on but unused
+type IdleWindow struct {
+	Start      string  `json:"start"` // e.g. "Mon 01:00"
+	End        string  `json:"end"`
+	StartDay   int     `json:"startDay"` // 0 = Sunday
+	StartHour  int     `json:"startHour"`
+	Hours      int     `json:"hours"`
+	Confidence float64 `json:"confidence"` // share of samples in the window that were idle
+}
+
+// EnergySuggestion proposes shutdown windows for one device
+type EnergySuggestion struct {
+	DeviceID     uint         `json:"deviceId"`
+	DeviceName   string       `json:"deviceName"`
+	Type         string       `json:"type"`
+	Watts        float64      `json:"watts"`
+	Samples      int          `json:"samples"`
+	Windows      []IdleWindow `json:"windows"`
+	HoursPerWeek int          `json:"hoursPerWeek"`
+	KWhPerMonth  float64      `json:"kwhPerMonth"`
+	CostPerMonth float64      `json:"costPerMonth"`
+}
+
+// EnergyReport is the set of energy-saving suggestions for a user's devices
+type EnergyReport struct {
+	Days              int                `json:"days"`
+	PricePerKWh       float64            `json:"pricePerKwh"`
+	Currency          string             `json:"currency"`
+	Suggestions       []EnergySuggestion `json:"suggestions"`
+	TotalKWhPerMonth  float64            `json:"totalKwhPerMonth"`
+	TotalCostPerMonth float64            `json:"totalCostPerMonth"`
+}
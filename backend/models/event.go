@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Event represents a notable occurrence recorded by a background monitor
+type Event struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"size:100;index"`    // firewall_disabled, update_available, ...
+	Severity  string    `json:"severity" gorm:"size:20;index"` // info, warning, critical
+	Source    string    `json:"source" gorm:"size:100;index"`  // firewall, docker, system, ...
+	Title     string    `json:"title" gorm:"size:255"`
+	Message   string    `json:"message" gorm:"size:1000"`
+	Details   string    `json:"details,omitempty" gorm:"type:text"` // JSON payload
+	UserID    uint      `json:"userId,omitempty" gorm:"index"`      // owner of the subject, 0 for lab-wide events
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// Event severity levels
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// FirewallStatus represents the current state of the host or network firewall
+type FirewallStatus struct {
+	Provider        string    `json:"provider"` // ufw, nftables, opnsense, pfsense
+	Enabled         bool      `json:"enabled"`
+	DefaultIncoming string    `json:"defaultIncoming,omitempty"`
+	DefaultOutgoing string    `json:"defaultOutgoing,omitempty"`
+	RuleCount       int       `json:"ruleCount"`
+	CheckedAt       time.Time `json:"checkedAt"`
+}
+
+// FirewallRule represents a single firewall rule in a provider-neutral form
+type FirewallRule struct {
+	ID          string `json:"id,omitempty"`
+	Chain       string `json:"chain,omitempty"` // chain, interface or direction
+	Action      string `json:"action"`          // allow, deny, reject, ...
+	Protocol    string `json:"protocol,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Port        string `json:"port,omitempty"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// FirewallBlock represents a recently blocked packet from the firewall log
+type FirewallBlock struct {
+	Timestamp       string `json:"timestamp"`
+	Interface       string `json:"interface,omitempty"`
+	Protocol        string `json:"protocol,omitempty"`
+	Source          string `json:"source"`
+	SourcePort      string `json:"sourcePort,omitempty"`
+	Destination     string `json:"destination"`
+	DestinationPort string `json:"destinationPort,omitempty"`
+}
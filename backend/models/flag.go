@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// FeatureFlag toggles an experimental subsystem for the whole instance
+type FeatureFlag struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Key         string    `json:"key" gorm:"column:flag_key;size:100;uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Enabled     bool      `json:"enabled" gorm:"default:false"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Overrides []FeatureFlagOverride `json:"overrides,omitempty" gorm:"-"`
+}
+
+// FeatureFlagOverride enables or disables a flag for a single user,
+// taking precedence over the instance-wide value
+type FeatureFlagOverride struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	FlagKey   string    `json:"flagKey" gorm:"size:100;uniqueIndex:idx_flag_override_user;not null"`
+	UserID    uint      `json:"userId" gorm:"uniqueIndex:idx_flag_override_user;not null"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UpdateFlagRequest for changing a flag's instance-wide value
+type UpdateFlagRequest struct {
+	Enabled     *bool   `json:"enabled"`
+	Description *string `json:"description"`
+}
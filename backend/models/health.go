@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Health score factors
+const (
+	FactorServices = "services"
+	FactorDevices  = "devices"
+	FactorDisk     = "disk"
+	FactorUpdates  = "updates"
+)
+
+// HealthPenalty is one thing pulling a factor's score down
+type HealthPenalty struct {
+	Name    string  `json:"name"`
+	Reason  string  `json:"reason"`
+	Penalty float64 `json:"penalty"` // points taken off the factor's score
+}
+
+// HealthFactor is one weighted part of the health score
+type HealthFactor struct {
+	Name      string          `json:"name"`
+	Weight    float64         `json:"weight"` // share of the overall score, the weights add up to 1
+	Score     float64         `json:"score"`  // 0-100
+	Penalties []HealthPenalty `json:"penalties"`
+}
+
+// HealthScore is the overall lab health, 0-100, with the factors it is made of
+type HealthScore struct {
+	Score     float64        `json:"score"`
+	Grade     string         `json:"grade"` // healthy, degraded, critical
+	Factors   []HealthFactor `json:"factors"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// HealthScoreSample is a recorded health score with its factor scores
+type HealthScoreSample struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	Timestamp time.Time `json:"timestamp" gorm:"column:sampled_at;index"`
+	Score     float64   `json:"score"`
+	Services  float64   `json:"services"`
+	Devices   float64   `json:"devices"`
+	Disk      float64   `json:"disk"`
+	Updates   float64   `json:"updates"`
+}
+
+// HealthScoreReport is the current health score and its recent history
+type HealthScoreReport struct {
+	HealthScore
+	History []HealthScoreSample `json:"history"` // oldest first
+}
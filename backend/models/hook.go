@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Inbound hook presets. A preset supplies the mapping templates a hook leaves empty.
+const (
+	HookPresetCustom      = "custom"
+	HookPresetGrafana     = "grafana"
+	HookPresetUptimeRobot = "uptimerobot"
+	HookPresetGitHub      = "github"
+)
+
+// InboundHook receives webhooks from an external system at /api/hooks/:id
+// and maps them into events, or into alerts when CreateAlerts is set
+type InboundHook struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"userId" gorm:"not null;index"`
+	Name         string `json:"name" gorm:"size:255;not null"`
+	Preset       string `json:"preset" gorm:"size:20;not null"` // custom, grafana, uptimerobot, github
+	Enabled      bool   `json:"enabled" gorm:"default:true"`
+	CreateAlerts bool   `json:"createAlerts"` // raise and resolve alerts instead of recording events
+
+	// Secret authenticates deliveries as ?token=, X-Hook-Token, a Bearer
+	// token or a GitHub HMAC signature. Stored encrypted.
+	Secret string `json:"-" gorm:"size:500"`
+
+	// Mapping templates are Go text/templates rendered with HookPayload.
+	// Empty templates use the preset's.
+	ItemsPath        string `json:"itemsPath" gorm:"size:255"` // dotted path to an array mapped one item at a time, e.g. "alerts"
+	TitleTemplate    string `json:"titleTemplate" gorm:"type:text"`
+	MessageTemplate  string `json:"messageTemplate" gorm:"type:text"`
+	SeverityTemplate string `json:"severityTemplate" gorm:"type:text"` // info, warning, critical
+	StatusTemplate   string `json:"statusTemplate" gorm:"type:text"`   // firing or resolved; anything else is a plain event
+	KeyTemplate      string `json:"keyTemplate" gorm:"type:text"`      // identifies an alert across firing and resolved
+
+	LastReceivedAt *time.Time `json:"lastReceivedAt"`
+	LastError      string     `json:"lastError,omitempty" gorm:"size:1000"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// InboundHookRequest for creating or updating an inbound hook
+type InboundHookRequest struct {
+	Name             string `json:"name" binding:"required"`
+	Preset           string `json:"preset"`
+	Enabled          *bool  `json:"enabled"`
+	CreateAlerts     bool   `json:"createAlerts"`
+	ItemsPath        string `json:"itemsPath"`
+	TitleTemplate    string `json:"titleTemplate"`
+	MessageTemplate  string `json:"messageTemplate"`
+	SeverityTemplate string `json:"severityTemplate"`
+	StatusTemplate   string `json:"statusTemplate"`
+	KeyTemplate      string `json:"keyTemplate"`
+}
+
+// HookPreset is a built-in set of mapping templates
+type HookPreset struct {
+	Preset           string `json:"preset"`
+	Description      string `json:"description"`
+	ItemsPath        string `json:"itemsPath,omitempty"`
+	TitleTemplate    string `json:"titleTemplate"`
+	MessageTemplate  string `json:"messageTemplate"`
+	SeverityTemplate string `json:"severityTemplate"`
+	StatusTemplate   string `json:"statusTemplate,omitempty"`
+	KeyTemplate      string `json:"keyTemplate,omitempty"`
+}
+
+// HookPayload is the data mapping templates are rendered with
+type HookPayload struct {
+	Payload interface{}       `json:"payload"` // decoded JSON body, or form and query values
+	Item    interface{}       `json:"item"`    // current element of ItemsPath, otherwise the payload
+	Headers map[string]string `json:"headers"` // lower-case names
+}
+
+// HookResult reports what a delivery was mapped to
+type HookResult struct {
+	Events   int `json:"events"`
+	Fired    int `json:"fired"`
+	Resolved int `json:"resolved"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// ImageLayers is an image with the layers it was built from
+type ImageLayers struct {
+	ID           string       `json:"id"`
+	Tags         []string     `json:"tags"`
+	Size         int64        `json:"size"` // bytes, all layers
+	Architecture string       `json:"architecture"`
+	OS           string       `json:"os"`
+	Created      string       `json:"created"`
+	Layers       []ImageLayer `json:"layers"` // oldest first
+}
+
+// ImageLayer is one step of an image's build history. Steps that only
+// change metadata (ENV, CMD, ...) have a size of zero.
+type ImageLayer struct {
+	ID        string    `json:"id,omitempty"` // empty for layers pulled from a registry
+	CreatedBy string    `json:"createdBy"`
+	Created   time.Time `json:"created"`
+	Size      int64     `json:"size"` // bytes
+	Comment   string    `json:"comment,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+}
+
+// ProjectDiskUsage is the disk space attributed to one compose project.
+// Image bytes are the layers unique to each image, split evenly between the
+// projects using it; layers shared with other images (common base images)
+// are reported separately and left out of the total.
+type ProjectDiskUsage struct {
+	Project         string   `json:"project"`
+	Containers      int      `json:"containers"`
+	Images          []string `json:"images"`
+	ImageSize       int64    `json:"imageSize"`       // bytes
+	ContainerSize   int64    `json:"containerSize"`   // bytes, writable layers
+	VolumeSize      int64    `json:"volumeSize"`      // bytes, volumes labelled with the project
+	TotalSize       int64    `json:"totalSize"`       // bytes
+	SharedLayerSize int64    `json:"sharedLayerSize"` // bytes its images share with other images
+}
+
+// DiskUsageReport breaks Docker's disk usage down per compose project
+type DiskUsageReport struct {
+	Projects []ProjectDiskUsage `json:"projects"` // largest first
+
+	StandaloneSize  int64 `json:"standaloneSize"`  // containers outside compose projects, their images and unlabelled volumes
+	UnusedImageSize int64 `json:"unusedImageSize"` // layers unique to images no container uses
+	SharedLayerSize int64 `json:"sharedLayerSize"` // layers shared by several images
+	BuildCacheSize  int64 `json:"buildCacheSize"`
+	TotalSize       int64 `json:"totalSize"`
+}
+
+// ImageUpdate is the latest registry check of an image used by a container.
+// An update is available when the digest the registry serves for the tag is
+// not one of the digests of the image the containers run.
+type ImageUpdate struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Image           string    `json:"image" gorm:"size:255;uniqueIndex;not null"` // reference the containers were created from
+	LocalDigest     string    `json:"localDigest" gorm:"size:100"`
+	RemoteDigest    string    `json:"remoteDigest" gorm:"size:100"`
+	UpdateAvailable bool      `json:"updateAvailable"`
+	Error           string    `json:"error,omitempty" gorm:"size:1000"`
+	CheckedAt       time.Time `json:"checkedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+
+	Containers []string `json:"containers" gorm:"-"` // names of the running containers using it
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// IntegrationConfig stores whether an integration is enabled and its settings
+type IntegrationConfig struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:100;uniqueIndex;not null"`
+	Enabled   bool      `json:"enabled" gorm:"default:false"`
+	Config    string    `json:"-" gorm:"type:text"` // JSON object of field key -> value
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Integration config field types
+const (
+	FieldString = "string"
+	FieldSecret = "secret" // masked in API responses
+	FieldURL    = "url"
+	FieldNumber = "number"
+	FieldBool   = "bool"
+)
+
+// IntegrationField describes one setting in an integration's config schema
+type IntegrationField struct {
+	Key         string `json:"key"`
+	Label       string `json:"label"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// IntegrationAction describes an action an integration can perform
+type IntegrationAction struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Params      []IntegrationField `json:"params"`
+}
+
+// IntegrationInfo is the API view of a registered integration
+type IntegrationInfo struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Kinds       []string            `json:"kinds"` // collector, actor, notifier
+	Schema      []IntegrationField  `json:"schema"`
+	Actions     []IntegrationAction `json:"actions,omitempty"`
+	Enabled     bool                `json:"enabled"`
+	Config      map[string]string   `json:"config"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// UpdateIntegrationRequest enables/disables an integration or changes its settings
+type UpdateIntegrationRequest struct {
+	Enabled *bool             `json:"enabled"`
+	Config  map[string]string `json:"config"`
+}
+
+// Notification is a message sent through notifier integrations
+type Notification struct {
+	Title    string      `json:"title"`
+	Message  string      `json:"message"`
+	Severity string      `json:"severity"`
+	Source   string      `json:"source"`
+	Details  interface{} `json:"details,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Subnet is a planned IPv4 network in the address plan
+type Subnet struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	UserID       uint            `json:"userId" gorm:"not null;index"`
+	Name         string          `json:"name" gorm:"size:100;not null"`
+	CIDR         string          `json:"cidr" gorm:"size:50;not null"`
+	Gateway      string          `json:"gateway" gorm:"size:50"`
+	VLAN         int             `json:"vlan"`
+	Description  string          `json:"description" gorm:"size:500"`
+	Reservations []IPReservation `json:"reservations" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
+
+// IPReservation is an inclusive address range kept out of automatic assignment,
+// e.g. a DHCP pool or addresses set aside for infrastructure
+type IPReservation struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	SubnetID    uint   `json:"subnetId" gorm:"not null;index"`
+	Start       string `json:"start" gorm:"size:50;not null"`
+	End         string `json:"end" gorm:"size:50;not null"`
+	Description string `json:"description" gorm:"size:255"`
+}
+
+// SubnetRequest for creating or updating a subnet. Reservations replace the
+// existing ones.
+type SubnetRequest struct {
+	Name         string          `json:"name" binding:"required"`
+	CIDR         string          `json:"cidr" binding:"required"`
+	Gateway      string          `json:"gateway"`
+	VLAN         int             `json:"vlan"`
+	Description  string          `json:"description"`
+	Reservations []IPReservation `json:"reservations"`
+}
+
+// IPAssignment is an address in a subnet that is taken
+type IPAssignment struct {
+	IP         string `json:"ip"`
+	DeviceID   uint   `json:"deviceId,omitempty"`
+	DeviceName string `json:"deviceName,omitempty"`
+	MAC        string `json:"mac,omitempty"`
+	Source     string `json:"source"` // device, gateway, neighbor
+	Reserved   bool   `json:"reserved"`
+}
+
+// SubnetUsage summarizes how a subnet's addresses are used
+type SubnetUsage struct {
+	Subnet      Subnet         `json:"subnet"`
+	Total       int            `json:"total"` // usable host addresses
+	Assigned    int            `json:"assigned"`
+	Reserved    int            `json:"reserved"`
+	Free        int            `json:"free"`
+	Assignments []IPAssignment `json:"assignments"`
+	NextFree    string         `json:"nextFree,omitempty"`
+}
+
+// IP conflict types
+const (
+	ConflictDuplicateIP   = "duplicate_ip"   // several devices share an address
+	ConflictReservedRange = "reserved_range" // a device sits in a reserved range
+	ConflictMACMismatch   = "mac_mismatch"   // the network sees another MAC at a device's address
+	ConflictUnknownHost   = "unknown_host"   // a host answers at an address no device claims
+)
+
+// IPConflict is a problem found in the address plan
+type IPConflict struct {
+	Type        string `json:"type"`
+	IP          string `json:"ip"`
+	SubnetID    uint   `json:"subnetId,omitempty"`
+	DeviceIDs   []uint `json:"deviceIds,omitempty"`
+	ObservedMAC string `json:"observedMac,omitempty"`
+	Message     string `json:"message"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// KioskToken is a long-lived, read-only token for wall displays.
+// Only a hash of the token is stored.
+type KioskToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"size:255;not null"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:12"` // first characters, for identification
+	Enabled    bool       `json:"enabled" gorm:"default:true"`
+	CreatedBy  uint       `json:"createdBy"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// KioskTokenResponse includes the plaintext token, returned only on create and rotate
+type KioskTokenResponse struct {
+	KioskToken
+	Token string `json:"token"`
+}
+
+// Summary is a compact overview of the homelab for dashboards and kiosks
+type Summary struct {
+	Metrics    *SystemMetrics   `json:"metrics,omitempty"`
+	Devices    SummaryCount     `json:"devices"`
+	Services   SummaryCount     `json:"services"`
+	Containers SummaryCount     `json:"containers"`
+	Events     map[string]int64 `json:"events"` // last 24h by severity
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// SummaryCount counts items that are up out of the total
+type SummaryCount struct {
+	Total int `json:"total"`
+	Up    int `json:"up"`
+}
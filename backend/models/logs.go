@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Log priorities tracked by the log monitor
+const (
+	LogPriorityError   = "error"
+	LogPriorityWarning = "warning"
+)
+
+// LogEntry is an error or warning line from journald or syslog
+type LogEntry struct {
+	Time     time.Time `json:"time"`
+	Unit     string    `json:"unit"`
+	Priority string    `json:"priority"` // error, warning
+	Message  string    `json:"message"`
+}
+
+// UnitLogRate summarizes recent errors and warnings logged by one unit
+type UnitLogRate struct {
+	Unit        string     `json:"unit"`
+	Errors5m    int        `json:"errors5m"`
+	Warnings5m  int        `json:"warnings5m"`
+	Errors1h    int        `json:"errors1h"`
+	Warnings1h  int        `json:"warnings1h"`
+	LastMessage string     `json:"lastMessage,omitempty"`
+	LastAt      *time.Time `json:"lastAt,omitempty"`
+}
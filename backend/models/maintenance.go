@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Maintenance run triggers
+const (
+	MaintenanceScheduled = "scheduled"
+	MaintenanceManual    = "manual"
+)
+
+// MaintenanceRun is a run of the database maintenance job
+type MaintenanceRun struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Trigger    string     `json:"trigger" gorm:"size:20"` // scheduled or manual
+	StartedAt  time.Time  `json:"startedAt" gorm:"index"`
+	FinishedAt *time.Time `json:"finishedAt"`
+	// Rows removed: soft-deleted rows past retention, expired sessions, and
+	// service checks and device pings past their retention
+	SoftDeleted     int64 `json:"softDeleted"`
+	ExpiredSessions int64 `json:"expiredSessions"`
+	CheckResults    int64 `json:"checkResults"`
+	// Database size in bytes around the run, 0 when it is not reported
+	SizeBefore int64  `json:"sizeBefore"`
+	SizeAfter  int64  `json:"sizeAfter"`
+	Error      string `json:"error,omitempty" gorm:"size:1000"`
+}
+
+// MaintenanceStatus is the maintenance schedule with its recent runs
+type MaintenanceStatus struct {
+	Enabled                 bool             `json:"enabled"`
+	IntervalHours           int              `json:"intervalHours"`
+	SoftDeleteRetentionDays int              `json:"softDeleteRetentionDays"`
+	Running                 bool             `json:"running"`
+	NextRun                 *time.Time       `json:"nextRun"`
+	Runs                    []MaintenanceRun `json:"runs"` // newest first
+}
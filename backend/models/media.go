@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Media session playback decisions
+const (
+	PlayDirect    = "direct_play"
+	PlayStream    = "direct_stream" // container remuxed, video copied
+	PlayTranscode = "transcode"
+)
+
+// MediaSession is one stream on a Jellyfin or Plex server
+type MediaSession struct {
+	ID              string  `json:"id"`
+	User            string  `json:"user"`
+	Title           string  `json:"title"` // "Show - S01E02 - Episode" for episodes
+	Type            string  `json:"type"`  // movie, episode, track, ...
+	Client          string  `json:"client"`
+	Device          string  `json:"device"`
+	State           string  `json:"state"` // playing, paused, buffering
+	Decision        string  `json:"decision"`
+	HWAcceleration  string  `json:"hwAcceleration,omitempty"` // e.g. vaapi, nvenc, qsv; empty for software
+	TranscodeReason string  `json:"transcodeReason,omitempty"`
+	VideoCodec      string  `json:"videoCodec,omitempty"` // codec sent to the client
+	BandwidthKbps   int64   `json:"bandwidthKbps"`
+	Progress        float64 `json:"progress"` // percent
+	Local           *bool   `json:"local,omitempty"`
+}
+
+// MediaSessions is the current activity of a media server
+type MediaSessions struct {
+	Server        string         `json:"server"` // jellyfin or plex
+	Sessions      []MediaSession `json:"sessions"`
+	Streams       int            `json:"streams"`
+	Transcodes    int            `json:"transcodes"`
+	HWTranscodes  int            `json:"hwTranscodes"`
+	DirectPlays   int            `json:"directPlays"` // direct play and direct stream
+	BandwidthKbps int64          `json:"bandwidthKbps"`
+	FetchedAt     time.Time      `json:"fetchedAt"`
+}
@@ -22,6 +22,8 @@ type CPUMetrics struct {
 	PerCoreUsage []float64 `json:"perCoreUsage"`
 	Temperature  float64   `json:"temperature,omitempty"`
 	LoadAverage  []float64 `json:"loadAverage,omitempty"`
+	PerCoreFreq  []float64 `json:"perCoreFrequency,omitempty"` // current MHz per logical core
+	StealPercent float64   `json:"stealPercent"`               // time taken by the hypervisor
 }
 
 // MemoryMetrics represents memory usage information
@@ -35,6 +37,8 @@ type MemoryMetrics struct {
 	SwapUsed    uint64  `json:"swapUsed"`
 	SwapFree    uint64  `json:"swapFree"`
 	SwapPercent float64 `json:"swapPercent"`
+	SwapIn      uint64  `json:"swapIn"`  // bytes swapped in since boot
+	SwapOut     uint64  `json:"swapOut"` // bytes swapped out since boot
 }
 
 // DiskMetrics represents disk usage information
@@ -53,6 +57,8 @@ type DiskMetrics struct {
 // NetworkMetrics represents network interface information
 type NetworkMetrics struct {
 	Interface   string `json:"interface"`
+	Name        string `json:"name,omitempty"`   // friendly name from the interface settings
+	Hidden      bool   `json:"hidden,omitempty"` // excluded by the interface settings, listed with ?all=true
 	BytesSent   uint64 `json:"bytesSent"`
 	BytesRecv   uint64 `json:"bytesRecv"`
 	PacketsSent uint64 `json:"packetsSent"`
@@ -63,12 +69,189 @@ type NetworkMetrics struct {
 	DropOut     uint64 `json:"dropOut"`
 }
 
-// MetricsHistory stores historical metrics data
+// NetworkTraffic splits the host's throughput between containers and the
+// host itself. Rates are bytes per second over the last SampleSeconds.
+// Container traffic also crosses the uplinks when it leaves the host, so
+// Host is Total minus Containers; traffic between containers never reaches
+// the uplinks and can make Containers exceed Total.
+type NetworkTraffic struct {
+	Total      TrafficRate        `json:"total"`      // interfaces shown by the interface settings
+	Containers TrafficRate        `json:"containers"` // all bridged containers
+	Host       TrafficRate        `json:"host"`       // the rest, never below zero
+	Interfaces []InterfaceTraffic `json:"interfaces"`
+	Top        []ContainerTraffic `json:"top"` // busiest containers first
+	// HostNetwork lists containers sharing the host's network stack, whose
+	// traffic can't be told apart from the host's
+	HostNetwork     []string  `json:"hostNetwork"`
+	DockerConnected bool      `json:"dockerConnected"`
+	SampleSeconds   float64   `json:"sampleSeconds"`
+	SampledAt       time.Time `json:"sampledAt"`
+}
+
+// TrafficRate is received and sent bytes per second
+type TrafficRate struct {
+	RxRate float64 `json:"rxRate"`
+	TxRate float64 `json:"txRate"`
+}
+
+// InterfaceTraffic is the rate of a host interface
+type InterfaceTraffic struct {
+	Interface string `json:"interface"`
+	Name      string `json:"name,omitempty"`
+	TrafficRate
+}
+
+// ContainerTraffic is the rate of a container, seen from inside it.
+// Share is its percentage of the combined container traffic.
+type ContainerTraffic struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	NetworkMode string  `json:"networkMode"`
+	Share       float64 `json:"share"`
+	TrafficRate
+}
+
+// MetricsHistory stores historical metrics data, sampled every 30 seconds.
+// NetworkIn/NetworkOut are the cumulative interface counters at the sample.
+// DeviceID is the device collected over SSH, 0 for the backend's own host.
 type MetricsHistory struct {
-	Timestamp   time.Time `json:"timestamp"`
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	DeviceID    uint      `json:"deviceId,omitempty" gorm:"not null;default:0;index:idx_metrics_history_device_time"`
+	Timestamp   time.Time `json:"timestamp" gorm:"column:sampled_at;index;index:idx_metrics_history_device_time"`
 	CPUUsage    float64   `json:"cpuUsage"`
 	MemoryUsage float64   `json:"memoryUsage"`
 	DiskUsage   float64   `json:"diskUsage"`
 	NetworkIn   uint64    `json:"networkIn"`
 	NetworkOut  uint64    `json:"networkOut"`
 }
+
+// DiskHistory stores the usage of each mounted filesystem at a history sample
+type DiskHistory struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	DeviceID    uint      `json:"deviceId,omitempty" gorm:"not null;default:0;index"`
+	Timestamp   time.Time `json:"timestamp" gorm:"column:sampled_at;index:idx_disk_history_mount_time"`
+	MountPoint  string    `json:"mountPoint" gorm:"size:255;index:idx_disk_history_mount_time"`
+	Used        uint64    `json:"used"`
+	Free        uint64    `json:"free"`
+	UsedPercent float64   `json:"usedPercent"`
+}
+
+// MetricsQueryPoint is one value of a query's result over time
+type MetricsQueryPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricsQueryResult is an evaluated metrics query expression such as
+// avg(cpu.usage, 1h). Value applies the function over the whole window and
+// is nil when the series has no samples in it; with a step, Points applies
+// it to each step of the window.
+type MetricsQueryResult struct {
+	Query    string              `json:"query"`
+	Function string              `json:"function"`
+	Series   string              `json:"series"`
+	Label    string              `json:"label,omitempty"`
+	Window   string              `json:"window"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Value    *float64            `json:"value"`
+	Points   []MetricsQueryPoint `json:"points,omitempty"`
+}
+
+// MetricsSeries describes a stored series the query language can reference
+type MetricsSeries struct {
+	Name        string `json:"name"`            // e.g. cpu.usage
+	Label       string `json:"label,omitempty"` // what the [..] filter selects, empty when it takes none
+	Unit        string `json:"unit,omitempty"`
+	Counter     bool   `json:"counter"` // cumulative, use rate()
+	Description string `json:"description"`
+}
+
+// ConnectionInfo represents an active network connection on the host
+type ConnectionInfo struct {
+	Protocol      string `json:"protocol"` // tcp, tcp6, udp, udp6
+	LocalAddress  string `json:"localAddress"`
+	LocalPort     uint32 `json:"localPort"`
+	RemoteAddress string `json:"remoteAddress"`
+	RemotePort    uint32 `json:"remotePort"`
+	State         string `json:"state"` // LISTEN, ESTABLISHED, TIME_WAIT, ...
+	PID           int32  `json:"pid"`
+	Process       string `json:"process,omitempty"`
+}
+
+// ConnectionFilter holds optional filters for listing connections
+type ConnectionFilter struct {
+	Protocol   string // tcp or udp (matches v4 and v6)
+	State      string
+	PID        int32
+	Port       uint32 // matches local or remote port
+	Process    string // case-insensitive substring match
+	RemoteOnly bool   // only connections with a remote peer
+}
+
+// WindowsService is a service registered with the Windows Service Control Manager
+type WindowsService struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	State       string `json:"state"`     // Running, Stopped, Paused, ...
+	StartMode   string `json:"startMode"` // Auto, Manual, Disabled
+	PID         int32  `json:"pid,omitempty"`
+}
+
+// WindowsServiceFilter holds optional filters for listing Windows services
+type WindowsServiceFilter struct {
+	State     string
+	StartMode string
+	Name      string // case-insensitive substring of name or display name
+}
+
+// PendingReboot reports whether Windows is waiting for a restart and why
+type PendingReboot struct {
+	Pending bool     `json:"pending"`
+	Reasons []string `json:"reasons"`
+}
+
+// EventLogSource is an event source with its error count
+type EventLogSource struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// EventLogSummary counts critical and error entries in a Windows event log
+type EventLogSummary struct {
+	Log        string           `json:"log"`
+	Critical   int              `json:"critical"`
+	Errors     int              `json:"errors"`
+	TopSources []EventLogSource `json:"topSources"`
+}
+
+// WindowsMetrics groups Windows-specific host health
+type WindowsMetrics struct {
+	ServicesRunning int               `json:"servicesRunning"`
+	ServicesStopped int               `json:"servicesStopped"`
+	AutoNotRunning  []WindowsService  `json:"autoNotRunning"` // automatic services that are not running
+	PendingReboot   PendingReboot     `json:"pendingReboot"`
+	EventLogHours   int               `json:"eventLogHours"`
+	EventLog        []EventLogSummary `json:"eventLog"`
+}
+
+// ThermalStatus reports macOS thermal pressure from pmset
+type ThermalStatus struct {
+	Pressure     string `json:"pressure"` // nominal, throttled
+	WarningLevel int    `json:"warningLevel"`
+	CPUSpeed     int    `json:"cpuSpeedLimit"` // percent of full speed allowed
+}
+
+// BatteryStatus reports the state of a laptop's internal battery
+type BatteryStatus struct {
+	Percent   int    `json:"percent"`
+	State     string `json:"state"`               // charging, discharging, charged, ...
+	Source    string `json:"source"`              // AC Power, Battery Power
+	Remaining string `json:"remaining,omitempty"` // h:mm, empty when unknown
+}
+
+// MacOSMetrics groups macOS-specific host health
+type MacOSMetrics struct {
+	Thermal ThermalStatus  `json:"thermal"`
+	Battery *BatteryStatus `json:"battery"` // nil on machines without a battery
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Mount health states
+const (
+	MountOK      = "ok"
+	MountMissing = "missing" // not mounted
+	MountStale   = "stale"   // stale NFS file handle
+	MountTimeout = "timeout" // statfs did not return in time
+	MountError   = "error"
+)
+
+// MountHealth is the result of checking a network or configured mount
+type MountHealth struct {
+	MountPoint  string    `json:"mountPoint"`
+	Device      string    `json:"device"`
+	Fstype      string    `json:"fstype"`
+	Configured  bool      `json:"configured"` // listed in MOUNT_CHECK_PATHS
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Total       uint64    `json:"total"`
+	Used        uint64    `json:"used"`
+	UsedPercent float64   `json:"usedPercent"`
+	ResponseMs  int64     `json:"responseMs"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
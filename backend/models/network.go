@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// PingTarget is a named host checked by the network ping tool
+type PingTarget struct {
+	Name string `json:"name"`
+	Host string `json:"host"`
+}
+
+// PingResult represents the latency and packet loss measured for a target
+type PingResult struct {
+	Name       string  `json:"name"`
+	Host       string  `json:"host"`
+	Status     string  `json:"status"`     // online, degraded, offline
+	Latency    float64 `json:"latency"`    // average in ms, -1 when unreachable
+	MinLatency float64 `json:"minLatency"` // in ms
+	MaxLatency float64 `json:"maxLatency"` // in ms
+	Sent       int     `json:"sent"`
+	Received   int     `json:"received"`
+	PacketLoss float64 `json:"packetLoss"` // percentage
+	Error      string  `json:"error,omitempty"`
+}
+
+// LatencySample is a stored probe result for a ping target
+type LatencySample struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"-" gorm:"not null;default:0;index"` // 0 for the PING_TARGETS every user reads
+	Target     string    `json:"target" gorm:"size:100;index:idx_latency_target_time"`
+	Host       string    `json:"host" gorm:"size:255"`
+	Latency    float64   `json:"latency"` // average in ms, -1 when unreachable
+	MinLatency float64   `json:"minLatency"`
+	MaxLatency float64   `json:"maxLatency"`
+	PacketLoss float64   `json:"packetLoss"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index:idx_latency_target_time"`
+}
+
+// LatencyBucket aggregates latency samples over a time bucket
+type LatencyBucket struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Samples    int       `json:"samples"`
+	Min        float64   `json:"min"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	P99        float64   `json:"p99"`
+	Max        float64   `json:"max"`
+	PacketLoss float64   `json:"packetLoss"` // average loss percentage
+}
+
+// LatencyHistory is the bucketed latency history for a single target
+type LatencyHistory struct {
+	Target  string          `json:"target"`
+	Host    string          `json:"host"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// ThroughputRequest represents the request body for a LAN throughput test
+type ThroughputRequest struct {
+	Host      string `json:"host"`
+	DeviceID  uint   `json:"deviceId"`
+	Port      int    `json:"port"`
+	Direction string `json:"direction"` // upload, download, both
+	Duration  int    `json:"duration"`  // seconds per direction
+}
+
+// ThroughputResult represents the outcome of a throughput test in one direction
+type ThroughputResult struct {
+	Host      string  `json:"host"`
+	Port      int     `json:"port"`
+	Direction string  `json:"direction"` // upload (backend -> host), download (host -> backend)
+	Bytes     int64   `json:"bytes"`
+	Seconds   float64 `json:"seconds"`
+	Mbps      float64 `json:"mbps"`
+}
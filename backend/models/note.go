@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Note is a timestamped changelog entry on a service or device, such as an
+// upgrade or a hardware swap
+type Note struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"not null;index"`
+	ServiceID  *uint     `json:"serviceId" gorm:"index"`
+	DeviceID   *uint     `json:"deviceId" gorm:"index"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	OccurredAt time.Time `json:"occurredAt" gorm:"index"` // when the change happened
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// NoteRequest for creating or updating a note. OccurredAt defaults to now.
+type NoteRequest struct {
+	ServiceID  *uint      `json:"serviceId"`
+	DeviceID   *uint      `json:"deviceId"`
+	Body       string     `json:"body" binding:"required"`
+	OccurredAt *time.Time `json:"occurredAt"`
+}
+
+// NoteFilter narrows the notes feed
+type NoteFilter struct {
+	ServiceID *uint
+	DeviceID  *uint
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+// Annotation is a note reduced to what a graph overlay needs
+type Annotation struct {
+	Time      time.Time `json:"time"`
+	Text      string    `json:"text"`
+	NoteID    uint      `json:"noteId"`
+	ServiceID *uint     `json:"serviceId,omitempty"`
+	DeviceID  *uint     `json:"deviceId,omitempty"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// Notification channel types
+const (
+	ChannelTelegram = "telegram" // Telegram bot message
+	ChannelDiscord  = "discord"  // Discord webhook message
+	ChannelWebhook  = "webhook"  // HTTP request with a templated body
+)
+
+// NotificationChannel delivers a user's alerts to an external service
+type NotificationChannel struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"userId" gorm:"not null;index"`
+	Name         string `json:"name" gorm:"size:255;not null"`
+	Type         string `json:"type" gorm:"size:20;not null"` // telegram, discord, webhook
+	Enabled      bool   `json:"enabled" gorm:"default:true"`
+	MinSeverity  string `json:"minSeverity" gorm:"size:20;default:'info'"` // lowest alert severity sent
+	SendResolved bool   `json:"sendResolved" gorm:"default:true"`
+
+	// Settings follow the type's schema; secret fields are stored encrypted
+	// and masked in responses
+	Settings    map[string]string `json:"settings" gorm:"-"`
+	RawSettings string            `json:"-" gorm:"column:settings;type:text"`
+
+	// Template is a Go text/template rendered with NotificationMessage: the
+	// message text for Telegram and Discord, the request body for webhooks.
+	// Empty uses the type's default.
+	Template string `json:"template" gorm:"type:text"`
+
+	LastSentAt *time.Time `json:"lastSentAt"`
+	LastError  string     `json:"lastError,omitempty" gorm:"size:1000"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// NotificationChannelRequest for creating or updating a notification channel
+type NotificationChannelRequest struct {
+	Name         string            `json:"name" binding:"required"`
+	Type         string            `json:"type" binding:"required"`
+	Enabled      *bool             `json:"enabled"`
+	MinSeverity  string            `json:"minSeverity"`
+	SendResolved *bool             `json:"sendResolved"`
+	Settings     map[string]string `json:"settings"`
+	Template     string            `json:"template"`
+}
+
+// NotificationChannelType describes a channel type and its settings
+type NotificationChannelType struct {
+	Type            string             `json:"type"`
+	Description     string             `json:"description"`
+	Schema          []IntegrationField `json:"schema"`
+	DefaultTemplate string             `json:"defaultTemplate"`
+}
+
+// NotificationMessage is the data channel templates are rendered with
+type NotificationMessage struct {
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Severity string    `json:"severity"`
+	State    string    `json:"state"` // firing, acknowledged, resolved, event, test
+	Source   string    `json:"source"`
+	Time     time.Time `json:"time"`
+	Alert    *Alert    `json:"alert,omitempty"`
+	Event    *Event    `json:"event,omitempty"`
+	// Alerts lists every alert of a grouped notification, Alert first
+	Alerts []Alert `json:"alerts,omitempty"`
+}
+
+// NotificationCategoryAlerts is the category of alert notifications; the
+// other categories are the sources of recorded events
+const NotificationCategoryAlerts = "alerts"
+
+// NotificationCategories are the categories users can route to channels
+var NotificationCategories = []string{
+	NotificationCategoryAlerts, "docker", "services", "system", "security", "firewall",
+	"dns", "trivy", "inventory", "logs", "hooks", "power", "remediation",
+}
+
+// NotificationPreference chooses what one of the user's channels receives.
+// Channels without a preference get alerts from their MinSeverity up.
+type NotificationPreference struct {
+	ChannelID   uint     `json:"channelId"`
+	Categories  []string `json:"categories"`  // alerts and event sources; empty mutes the channel
+	MinSeverity string   `json:"minSeverity"` // lowest severity sent, overriding the channel's
+}
+
+// UserSettings holds a user's personal settings
+type UserSettings struct {
+	UserID uint `json:"userId" gorm:"primaryKey;autoIncrement:false"`
+
+	NotificationPreferences    []NotificationPreference `json:"notificationPreferences" gorm:"-"`
+	RawNotificationPreferences string                   `json:"-" gorm:"column:notification_preferences;type:text"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NotificationPreferencesRequest replaces a user's notification preferences
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OOM kill scopes
+const (
+	OOMScopeHost      = "host"
+	OOMScopeContainer = "container"
+)
+
+// OOMKill records a process or container killed by the kernel OOM killer
+type OOMKill struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Scope       string    `json:"scope" gorm:"size:20;index"` // host, container
+	Process     string    `json:"process" gorm:"size:255"`
+	PID         int       `json:"pid"`
+	Container   string    `json:"container" gorm:"size:255;index"`
+	ContainerID string    `json:"containerId" gorm:"size:64;index"`
+	Cgroup      string    `json:"cgroup" gorm:"size:500"`
+	Message     string    `json:"message" gorm:"size:1000"`
+	KilledAt    time.Time `json:"killedAt" gorm:"index"`
+}
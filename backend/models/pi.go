@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PiMetrics reports Raspberry Pi firmware health read through vcgencmd
+type PiMetrics struct {
+	Throttled   string    `json:"throttled"` // raw get_throttled bitmask, e.g. 0x50005
+	CoreVoltage float64   `json:"coreVoltage"`
+	Temperature float64   `json:"temperature"` // SoC temperature in °C
+	Flags       PiFlags   `json:"flags"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// PiFlags decodes the get_throttled bitmask. "Now" flags are active at the
+// time of the check; "Occurred" flags are sticky since boot.
+type PiFlags struct {
+	UnderVoltage         bool `json:"underVoltage"`
+	FrequencyCapped      bool `json:"frequencyCapped"`
+	Throttled            bool `json:"throttled"`
+	SoftTempLimit        bool `json:"softTempLimit"`
+	UnderVoltageOccurred bool `json:"underVoltageOccurred"`
+	FrequencyOccurred    bool `json:"frequencyCappedOccurred"`
+	ThrottledOccurred    bool `json:"throttledOccurred"`
+	SoftTempOccurred     bool `json:"softTempLimitOccurred"`
+}
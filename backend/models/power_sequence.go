@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Power sequence step actions. The start sequence runs the steps in reverse
+// with the opposite action: containers are started and devices woken.
+const (
+	PowerStepStopContainer  = "stop_container"  // target: container name or label:key=value
+	PowerStepShutdownDevice = "shutdown_device" // target: device ID
+	PowerStepRunTask        = "run_task"        // target: shell command, startTarget: command for start
+)
+
+// Power sequence directions
+const (
+	PowerDirectionShutdown = "shutdown"
+	PowerDirectionStart    = "start"
+)
+
+// Power sequence run statuses
+const (
+	PowerRunRunning   = "running"
+	PowerRunCompleted = "completed"
+	PowerRunFailed    = "failed"
+)
+
+// PowerSequence is an ordered "shutdown lab" plan, e.g. VMs, then the NAS,
+// then switch PoE. Each step is verified before the next one starts.
+type PowerSequence struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	UserID      uint        `json:"userId" gorm:"not null;index"`
+	Name        string      `json:"name" gorm:"size:255;not null"`
+	Description string      `json:"description" gorm:"size:500"`
+	Steps       []PowerStep `json:"steps" gorm:"-"`
+	RawSteps    string      `json:"-" gorm:"column:steps;type:text"` // JSON []PowerStep
+
+	// Last run, in either direction
+	LastDirection string            `json:"lastDirection" gorm:"size:20"`
+	LastStatus    string            `json:"lastStatus" gorm:"size:20"` // running, completed, failed
+	LastRunAt     *time.Time        `json:"lastRunAt"`
+	LastLog       []PowerStepResult `json:"lastLog" gorm:"-"`
+	RawLastLog    string            `json:"-" gorm:"column:last_log;type:text"` // JSON []PowerStepResult
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PowerStep is one action of a power sequence
+type PowerStep struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // stop_container, shutdown_device, run_task
+	Target string `json:"target"`
+	// StartTarget is the command run_task runs when starting; empty skips the step
+	StartTarget string `json:"startTarget,omitempty"`
+	// StartMethod wakes a device with "wol" or "ipmi"; empty uses IPMI when
+	// the device has a BMC configured
+	StartMethod string `json:"startMethod,omitempty"`
+	Wait        int    `json:"wait"`    // seconds to wait after the step
+	Timeout     int    `json:"timeout"` // seconds to wait for verification, default 120
+}
+
+// PowerStepResult is the outcome of one step of a run
+type PowerStepResult struct {
+	Step      int       `json:"step"`
+	Name      string    `json:"name"`
+	Action    string    `json:"action"`
+	Success   bool      `json:"success"`
+	Skipped   bool      `json:"skipped,omitempty"`
+	Message   string    `json:"message"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  float64   `json:"duration"` // seconds
+}
+
+// PowerSequenceRequest for creating or updating a power sequence
+type PowerSequenceRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	Steps       []PowerStep `json:"steps" binding:"required"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// ProbeAgent is a remote agent that runs service checks from another location
+type ProbeAgent struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"size:255;not null"`
+	Location   string     `json:"location" gorm:"size:100;not null;index"` // e.g. vps-fra
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:12"`
+	Enabled    bool       `json:"enabled" gorm:"default:true"`
+	Version    string     `json:"version" gorm:"size:50"`
+	LastSeenAt *time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// ProbeAgentResponse includes the plaintext token, returned only on create and rotate
+type ProbeAgentResponse struct {
+	ProbeAgent
+	Token string `json:"token"`
+}
+
+// CreateProbeAgentRequest for registering a probe agent
+type CreateProbeAgentRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Location string `json:"location" binding:"required"`
+}
+
+// ProbeResult is a check result reported by a probe agent
+type ProbeResult struct {
+	ServiceID    uint          `json:"serviceId" binding:"required"`
+	Status       string        `json:"status" binding:"required"`
+	StatusCode   int           `json:"statusCode"`
+	ResponseTime int64         `json:"responseTime"`
+	Error        string        `json:"error"`
+	Timings      *CheckTimings `json:"timings"`
+	CheckedAt    time.Time     `json:"checkedAt"`
+}
+
+// ProbeReport is the payload a probe agent posts after a round of checks
+type ProbeReport struct {
+	Version string        `json:"version"`
+	Results []ProbeResult `json:"results"`
+}
+
+// LocationStatus is the latest check result of a service from one location
+type LocationStatus struct {
+	Location     string    `json:"location"` // "local" for the backend itself
+	Status       string    `json:"status"`
+	StatusCode   int       `json:"statusCode"`
+	ResponseTime int64     `json:"responseTime"`
+	Error        string    `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checkedAt"`
+}
+
+// ProbeCheck is a service check assigned to a probe agent
+type ProbeCheck struct {
+	ServiceID    uint   `json:"serviceId"`
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	Method       string `json:"method"`
+	Port         int    `json:"port"`
+	Timeout      int    `json:"timeout"`
+	ExpectedCode int    `json:"expectedCode"`
+
+	TLSSkipVerify bool   `json:"tlsSkipVerify,omitempty"`
+	TLSServerName string `json:"tlsServerName,omitempty"`
+	CABundle      string `json:"caBundle,omitempty"`
+	ClientCert    string `json:"clientCert,omitempty"`
+	ClientKey     string `json:"clientKey,omitempty"`
+	DBPassword    string `json:"dbPassword,omitempty"`
+}
+
+// ConsensusStatus combines the latest results from every location that checks a service
+type ConsensusStatus struct {
+	Status    string           `json:"status"` // online, degraded, offline, unknown
+	Failing   int              `json:"failing"`
+	Reporting int              `json:"reporting"` // locations with a recent result
+	Required  int              `json:"required"`  // failures needed to mark the service down
+	Locations []LocationStatus `json:"locations"`
+}
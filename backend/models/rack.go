@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Rack faces a device can be mounted on
+const (
+	RackFaceFront = "front"
+	RackFaceRear  = "rear"
+)
+
+// Room is a floor plan that racks are placed on. Positions on the plan are
+// in grid cells, Width by Depth.
+type Room struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Width       int       `json:"width" gorm:"default:10"`
+	Depth       int       `json:"depth" gorm:"default:10"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Rack is an equipment rack of Units U, optionally placed in a room
+type Rack struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	RoomID      *uint     `json:"roomId" gorm:"index"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Units       int       `json:"units" gorm:"default:42"`
+	PosX        int       `json:"posX"` // grid cell on the room's floor plan
+	PosY        int       `json:"posY"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// RackPlacement mounts a device in a rack. Position is the lowest U the
+// device occupies (1 is the bottom) and Height its size in U. A full-depth
+// device blocks the units on both faces.
+type RackPlacement struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RackID    uint      `json:"rackId" gorm:"not null;index"`
+	DeviceID  uint      `json:"deviceId" gorm:"not null;uniqueIndex"`
+	Position  int       `json:"position" gorm:"not null"`
+	Height    int       `json:"height" gorm:"default:1"`
+	Face      string    `json:"face" gorm:"size:10;default:'front'"`
+	FullDepth bool      `json:"fullDepth" gorm:"default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RoomRequest for creating or updating a room
+type RoomRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Width       int    `json:"width"` // default 10
+	Depth       int    `json:"depth"` // default 10
+}
+
+// RackRequest for creating or updating a rack
+type RackRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	RoomID      *uint  `json:"roomId"`
+	Units       int    `json:"units"` // default 42
+	PosX        int    `json:"posX"`
+	PosY        int    `json:"posY"`
+}
+
+// RackPlacementRequest places a device in a rack, moving it if it is
+// already mounted elsewhere
+type RackPlacementRequest struct {
+	DeviceID  uint   `json:"deviceId" binding:"required"`
+	Position  int    `json:"position" binding:"required"`
+	Height    int    `json:"height"` // default 1
+	Face      string `json:"face"`   // front (default) or rear
+	FullDepth bool   `json:"fullDepth"`
+}
+
+// RackDevice is a placed device with what a rack diagram shows for it
+type RackDevice struct {
+	RackPlacement
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	IP       string `json:"ip"`
+	Model    string `json:"model"`
+	IsOnline bool   `json:"isOnline"`
+	TopU     int    `json:"topU"` // highest U the device occupies
+}
+
+// RackUnit is one U of a rack elevation with the devices mounted in it
+type RackUnit struct {
+	U     int   `json:"u"`
+	Front *uint `json:"front"` // placement ID, nil when free
+	Rear  *uint `json:"rear"`
+}
+
+// RackLayout is a rack with its devices and a per-unit elevation, top down
+type RackLayout struct {
+	Rack
+	Room      *Room        `json:"room,omitempty"`
+	Devices   []RackDevice `json:"devices"` // top down
+	Elevation []RackUnit   `json:"elevation"`
+	UsedUnits int          `json:"usedUnits"` // units with any device mounted
+	FreeUnits int          `json:"freeUnits"`
+}
+
+// RackSummary is a rack as shown on a floor plan
+type RackSummary struct {
+	Rack
+	Devices   int `json:"devices"`
+	UsedUnits int `json:"usedUnits"`
+	Offline   int `json:"offline"` // placed devices currently offline
+}
+
+// FloorPlan is a room with the racks placed on it
+type FloorPlan struct {
+	Room
+	Racks []RackSummary `json:"racks"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Remediation triggers. Alert hooks run when their alert rule fires; the
+// others watch a service or device directly.
+const (
+	TriggerAlert         = "alert"
+	TriggerServiceDown   = "service_down"
+	TriggerDeviceOffline = "device_offline"
+)
+
+// Remediation actions
+const (
+	RemediationRestartContainer = "restart_container"
+	RemediationRunTask          = "run_task"
+	RemediationWakeDevice       = "wake_device"
+)
+
+// RemediationHook runs an automatic fix when its alert rule fires, or when a
+// service goes down or a device goes offline. It fires once per outage, at
+// most once per cooldown.
+type RemediationHook struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"userId" gorm:"not null;index"`
+	Name        string     `json:"name" gorm:"size:255;not null"`
+	Trigger     string     `json:"trigger" gorm:"column:trigger_type;size:50;not null;index"` // alert, service_down, device_offline
+	RuleID      uint       `json:"ruleId" gorm:"index"`                                       // alert rule ID of alert hooks
+	TargetID    uint       `json:"targetId" gorm:"not null;index"`                            // service or device ID of the other triggers
+	Action      string     `json:"action" gorm:"size:50;not null"`                            // restart_container, run_task, wake_device
+	Param       string     `json:"param" gorm:"size:1000"`                                    // container ref, command or device ID
+	Cooldown    int        `json:"cooldown" gorm:"default:15"`                                // in minutes
+	Enabled     bool       `json:"enabled" gorm:"default:true"`
+	LastRunAt   *time.Time `json:"lastRunAt"`
+	LastSuccess bool       `json:"lastSuccess"`
+	LastResult  string     `json:"lastResult" gorm:"size:1000"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// RemediationHookRequest for creating or updating a remediation hook
+type RemediationHookRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Trigger  string `json:"trigger" binding:"required"`
+	RuleID   uint   `json:"ruleId"`   // alert hooks
+	TargetID uint   `json:"targetId"` // service_down and device_offline hooks
+	Action   string `json:"action" binding:"required"`
+	Param    string `json:"param"`
+	Cooldown int    `json:"cooldown"`
+	Enabled  *bool  `json:"enabled"`
+}
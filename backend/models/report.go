@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Service impact levels, used to weight downtime in reports
+const (
+	ImpactLow      = "low"
+	ImpactMedium   = "medium"
+	ImpactHigh     = "high"
+	ImpactCritical = "critical"
+)
+
+// ImpactWeights multiplies downtime by how much a service matters
+var ImpactWeights = map[string]float64{
+	ImpactLow:      1,
+	ImpactMedium:   2,
+	ImpactHigh:     4,
+	ImpactCritical: 8,
+}
+
+// Incident is a continuous period in which a service's checks failed
+type Incident struct {
+	ServiceID   uint       `json:"serviceId"`
+	ServiceName string     `json:"serviceName"`
+	Impact      string     `json:"impact"`
+	ImpactNote  string     `json:"impactNote,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty"` // nil while ongoing
+	Duration    int64      `json:"duration"`          // in seconds
+	Weighted    float64    `json:"weighted"`          // duration in minutes times the impact weight
+	Checks      int        `json:"checks"`            // failed checks in the incident
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// ServiceDowntime aggregates a service's incidents over a report window
+type ServiceDowntime struct {
+	ServiceID   uint    `json:"serviceId"`
+	ServiceName string  `json:"serviceName"`
+	Impact      string  `json:"impact"`
+	ImpactNote  string  `json:"impactNote,omitempty"`
+	Incidents   int     `json:"incidents"`
+	Downtime    int64   `json:"downtime"` // in seconds
+	Weighted    float64 `json:"weighted"` // impact-weighted downtime minutes
+	Uptime      float64 `json:"uptime"`   // percentage of checks that succeeded
+}
+
+// DowntimeReport ranks services by impact-weighted downtime
+type DowntimeReport struct {
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Downtime int64             `json:"downtime"` // total seconds across services
+	Weighted float64           `json:"weighted"`
+	Services []ServiceDowntime `json:"services"`
+}
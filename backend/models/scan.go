@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ImageScan stores the latest vulnerability scan result for a container image
+type ImageScan struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Image     string    `json:"image" gorm:"size:255;uniqueIndex;not null"`
+	Critical  int       `json:"critical"`
+	High      int       `json:"high"`
+	Medium    int       `json:"medium"`
+	Low       int       `json:"low"`
+	Unknown   int       `json:"unknown"`
+	Findings  string    `json:"-" gorm:"type:text"` // JSON array of Vulnerability
+	Error     string    `json:"error,omitempty" gorm:"size:1000"`
+	ScannedAt time.Time `json:"scannedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Vulnerability represents a single CVE finding reported by Trivy
+type Vulnerability struct {
+	ID               string `json:"id"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion"`
+	FixedVersion     string `json:"fixedVersion,omitempty"`
+	Severity         string `json:"severity"`
+	Title            string `json:"title,omitempty"`
+}
+
+// VulnerabilitySummary is the per-image CVE count attached to containers
+type VulnerabilitySummary struct {
+	Critical  int       `json:"critical"`
+	High      int       `json:"high"`
+	Medium    int       `json:"medium"`
+	Low       int       `json:"low"`
+	ScannedAt time.Time `json:"scannedAt"`
+}
+
+// ImageScanDetail is an ImageScan with its decoded findings
+type ImageScanDetail struct {
+	ImageScan
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ServiceScreenshot records the last thumbnail captured for an HTTP service
+type ServiceScreenshot struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ServiceID  uint       `json:"serviceId" gorm:"uniqueIndex;not null"`
+	Path       string     `json:"-" gorm:"size:500"`
+	CapturedAt *time.Time `json:"capturedAt"` // last successful capture
+	Error      string     `json:"error,omitempty" gorm:"size:500"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
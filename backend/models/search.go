@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// History entry kinds searched by the history search
+const (
+	HistoryAudit = "audit"
+	HistoryEvent = "event"
+	HistoryNote  = "note"
+)
+
+// HistoryFilter narrows a history search. Query words all have to match,
+// each as a word prefix; an empty query matches everything.
+type HistoryFilter struct {
+	Query  string
+	Kinds  []string // audit, event, note; empty searches all allowed kinds
+	From   *time.Time
+	To     *time.Time
+	UserID *uint // audit entries and notes by this user; excludes events
+	Limit  int
+}
+
+// HistoryEntry is an audit entry, event or note in history search results
+type HistoryEntry struct {
+	Kind     string    `json:"kind"` // audit, event or note
+	ID       uint      `json:"id"`
+	Time     time.Time `json:"time"`
+	UserID   uint      `json:"userId"`             // 0 for events and automatic actions
+	Title    string    `json:"title"`              // audit action, event title or note's first line
+	Text     string    `json:"text"`               // audit details, event message or note body
+	Category string    `json:"category,omitempty"` // audit target type or event source
+	Severity string    `json:"severity,omitempty"` // events only
+	Actor    string    `json:"actor,omitempty"`    // audit entries only
+}
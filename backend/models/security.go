@@ -0,0 +1,34 @@
+package models
+
+// BanReport summarizes intrusion prevention state from fail2ban or CrowdSec
+type BanReport struct {
+	Provider    string        `json:"provider"` // fail2ban, crowdsec
+	TotalBanned int           `json:"totalBanned"`
+	Jails       []JailSummary `json:"jails"`
+	Bans        []BannedIP    `json:"bans"`
+}
+
+// JailSummary represents counters for a fail2ban jail or CrowdSec scenario
+type JailSummary struct {
+	Name            string `json:"name"`
+	CurrentlyFailed int    `json:"currentlyFailed"`
+	TotalFailed     int    `json:"totalFailed"`
+	CurrentlyBanned int    `json:"currentlyBanned"`
+	TotalBanned     int    `json:"totalBanned"`
+}
+
+// BannedIP represents an active ban/decision
+type BannedIP struct {
+	IP       string `json:"ip"`
+	Jail     string `json:"jail"` // fail2ban jail or CrowdSec scenario
+	Origin   string `json:"origin,omitempty"`
+	Type     string `json:"type,omitempty"` // ban, captcha
+	Duration string `json:"duration,omitempty"`
+	Country  string `json:"country,omitempty"`
+}
+
+// UnbanRequest represents the request body for lifting a ban
+type UnbanRequest struct {
+	IP   string `json:"ip" binding:"required,ip"`
+	Jail string `json:"jail"`
+}
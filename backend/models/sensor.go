@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// IngestKey authenticates sensors pushing readings to /api/ingest as the
+// user who created it. Only a hash of the key is stored.
+type IngestKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:255;not null"`
+	KeyHash    string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:12"` // first characters, for identification
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// IngestKeyResponse includes the plaintext key, returned only on create
+type IngestKeyResponse struct {
+	IngestKey
+	Key string `json:"key"`
+}
+
+// Sensor is a value reported by a device, such as a room's temperature or
+// whether a door is open. It is created by the first reading.
+type Sensor struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"not null;uniqueIndex:idx_sensor_key"`
+	Device     string    `json:"device" gorm:"size:100;not null;uniqueIndex:idx_sensor_key"`
+	Metric     string    `json:"metric" gorm:"size:100;not null;uniqueIndex:idx_sensor_key"` // e.g. temperature, AM2301.Humidity
+	Name       string    `json:"name" gorm:"size:255"`
+	Unit       string    `json:"unit" gorm:"size:20"`
+	Value      float64   `json:"value"` // latest reading; states such as open/closed are 1/0
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// SensorReading is one stored value of a sensor
+type SensorReading struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	SensorID  uint      `json:"-" gorm:"not null;index:idx_sensor_reading_time"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp" gorm:"column:sampled_at;index:idx_sensor_reading_time"`
+}
+
+// IngestReading is one value pushed to /api/ingest
+type IngestReading struct {
+	Device    string
+	Metric    string
+	Value     float64
+	Unit      string
+	Timestamp time.Time
+}
+
+// IngestResult reports what an ingest request stored
+type IngestResult struct {
+	Accepted int      `json:"accepted"`
+	Skipped  []string `json:"skipped,omitempty"` // values that were not numbers or on/off states
+}
+
+// SensorUpdateRequest renames a sensor or sets its unit
+type SensorUpdateRequest struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
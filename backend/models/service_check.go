@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// ServiceCheck stores the result of a single service health check
+type ServiceCheck struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ServiceID    uint      `json:"serviceId" gorm:"not null;index:idx_service_checks_service_time"`
+	Status       string    `json:"status" gorm:"size:20"` // online, offline, error
+	StatusCode   int       `json:"statusCode"`
+	ResponseTime int64     `json:"responseTime"` // in milliseconds
+	DNSLookup    int64     `json:"dnsLookup"`    // in milliseconds
+	TCPConnect   int64     `json:"tcpConnect"`   // in milliseconds
+	TLSHandshake int64     `json:"tlsHandshake"` // in milliseconds
+	TTFB         int64     `json:"ttfb"`         // time to first byte, in milliseconds
+	Error        string    `json:"error,omitempty" gorm:"size:500"`
+	Location     string    `json:"location,omitempty" gorm:"size:100;default:'';index"` // probe location, empty for local
+	CheckedAt    time.Time `json:"checkedAt" gorm:"index:idx_service_checks_service_time"`
+}
+
+// ServiceUptime is the share of a service's local checks that were online
+// over a trailing window
+type ServiceUptime struct {
+	Window string `json:"window"` // 24h, 7d or 30d
+	// UptimePercent is nil when the service has no checks in the window
+	UptimePercent *float64 `json:"uptimePercent"`
+	Checks        int64    `json:"checks"`
+	Failed        int64    `json:"failed"`
+}
+
+// ServiceHistory is a service's recent check results with its uptime
+type ServiceHistory struct {
+	ServiceID uint            `json:"serviceId"`
+	Uptime    []ServiceUptime `json:"uptime"`
+	Checks    []ServiceCheck  `json:"checks"` // newest first
+}
+
+// PendingStatus is a status change seen by the latest checks but not yet
+// confirmed by enough consecutive checks to be reported
+type PendingStatus struct {
+	Status   string `json:"status"`   // the status the checks report
+	Checks   int    `json:"checks"`   // consecutive checks seen so far
+	Required int    `json:"required"` // checks needed to confirm it
+}
+
+// CheckTimings is the phase breakdown of an HTTP check, in milliseconds
+type CheckTimings struct {
+	DNSLookup        int64 `json:"dnsLookup"`
+	TCPConnect       int64 `json:"tcpConnect"`
+	TLSHandshake     int64 `json:"tlsHandshake"`
+	TTFB             int64 `json:"ttfb"`
+	Total            int64 `json:"total"`
+	ConnectionReused bool  `json:"connectionReused"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AppSetting stores an instance-wide setting as JSON under a key
+type AppSetting struct {
+	Key       string    `json:"key" gorm:"column:setting_key;size:100;primaryKey"`
+	Value     string    `json:"value" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SettingPasswordPolicy is the key of the password and lockout policy
+const SettingPasswordPolicy = "password_policy"
+
+// PasswordPolicy controls password complexity, expiry, reuse and login lockout
+type PasswordPolicy struct {
+	MinLength     int  `json:"minLength"`
+	RequireUpper  bool `json:"requireUpper"`
+	RequireLower  bool `json:"requireLower"`
+	RequireDigit  bool `json:"requireDigit"`
+	RequireSymbol bool `json:"requireSymbol"`
+
+	// ExpiryDays forces a password change after this many days; 0 never expires
+	ExpiryDays int `json:"expiryDays"`
+	// HistoryCount rejects reuse of the last N passwords; 0 allows reuse
+	HistoryCount int `json:"historyCount"`
+
+	// MaxFailedLogins locks the account after this many wrong passwords in a row; 0 disables lockout
+	MaxFailedLogins int `json:"maxFailedLogins"`
+	LockoutMinutes  int `json:"lockoutMinutes"`
+}
+
+// DefaultPasswordPolicy keeps the original six character minimum and adds a lockout
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:       6,
+		MaxFailedLogins: 10,
+		LockoutMinutes:  15,
+	}
+}
+
+// SettingNetworkInterfaces is the key of the network interface selection
+const SettingNetworkInterfaces = "network_interfaces"
+
+// NetworkInterfaceSettings picks the host interfaces network metrics show
+// and names them. Include and Exclude are shell patterns such as enp* or
+// veth*: an interface is shown when it matches Include, or Include is empty,
+// and does not match Exclude.
+type NetworkInterfaceSettings struct {
+	Include []string          `json:"include"`
+	Exclude []string          `json:"exclude"`
+	Names   map[string]string `json:"names"` // friendly names by interface, e.g. eth0: WAN
+}
+
+// DefaultNetworkInterfaceSettings hides container veths and Docker bridges
+func DefaultNetworkInterfaceSettings() NetworkInterfaceSettings {
+	return NetworkInterfaceSettings{
+		Include: []string{},
+		Exclude: []string{"veth*", "docker*", "br-*"},
+		Names:   map[string]string{},
+	}
+}
+
+// PasswordHistory keeps previous password hashes to prevent reuse
+type PasswordHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;index"`
+	Hash      string    `json:"-" gorm:"size:255;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Snapshot stores a point-in-time capture of the whole homelab state
+type Snapshot struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Devices    int       `json:"devices"`
+	Services   int       `json:"services"`
+	Containers int       `json:"containers"`
+	Data       string    `json:"-" gorm:"size:16777215"` // JSON SnapshotData (mediumtext on MySQL)
+	TakenAt    time.Time `json:"takenAt" gorm:"index"`
+}
+
+// SnapshotData is the captured homelab state
+type SnapshotData struct {
+	TakenAt    time.Time           `json:"takenAt"`
+	Versions   map[string]string   `json:"versions"`
+	Devices    []SnapshotDevice    `json:"devices"`
+	Services   []SnapshotService   `json:"services"`
+	Containers []SnapshotContainer `json:"containers"`
+}
+
+// SnapshotDevice is the captured state of a device
+type SnapshotDevice struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	IP       string `json:"ip"`
+	MAC      string `json:"mac,omitempty"`
+	Type     string `json:"type"`
+	IsOnline bool   `json:"isOnline"`
+	IsActive bool   `json:"isActive"`
+}
+
+// SnapshotService is the captured state of a service
+type SnapshotService struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"statusCode,omitempty"`
+}
+
+// SnapshotContainer is the captured state of a container
+type SnapshotContainer struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	ImageID string `json:"imageId"`
+	State   string `json:"state"`
+	Ports   string `json:"ports,omitempty"`
+}
+
+// SnapshotDetail is a snapshot with its decoded data
+type SnapshotDetail struct {
+	Snapshot
+	Data SnapshotData `json:"data"`
+}
+
+// SnapshotChange describes a single difference between two snapshots
+type SnapshotChange struct {
+	Kind   string `json:"kind"`   // device, service, container, version
+	Name   string `json:"name"`   // item name
+	Change string `json:"change"` // added, removed, changed
+	Field  string `json:"field,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// SnapshotDiff is the set of changes between two snapshots
+type SnapshotDiff struct {
+	From    Snapshot         `json:"from"`
+	To      Snapshot         `json:"to"`
+	Changes []SnapshotChange `json:"changes"`
+}
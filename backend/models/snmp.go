@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SNMPConfig is how a device's SNMP agent is polled: a v2c community or a
+// v3 user. Secrets are encrypted at rest and never returned.
+type SNMPConfig struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	DeviceID     uint   `json:"deviceId" gorm:"not null;uniqueIndex"`
+	Enabled      bool   `json:"enabled" gorm:"default:true"`
+	Version      string `json:"version" gorm:"size:3;not null"` // 2c or 3
+	Port         int    `json:"port"`                           // 0 uses 161
+	Community    string `json:"-" gorm:"size:500"`              // encrypted
+	User         string `json:"user" gorm:"size:100"`
+	AuthProtocol string `json:"authProtocol" gorm:"size:3"` // "", MD5 or SHA
+	AuthPassword string `json:"-" gorm:"size:500"`          // encrypted
+	PrivProtocol string `json:"privProtocol" gorm:"size:3"` // "", DES or AES
+	PrivPassword string `json:"-" gorm:"size:500"`          // encrypted
+
+	HasCommunity    bool `json:"hasCommunity" gorm:"-"`
+	HasAuthPassword bool `json:"hasAuthPassword" gorm:"-"`
+	HasPrivPassword bool `json:"hasPrivPassword" gorm:"-"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AfterFind reports which secrets are set without exposing them
+func (c *SNMPConfig) AfterFind(tx *gorm.DB) error {
+	c.HasCommunity = c.Community != ""
+	c.HasAuthPassword = c.AuthPassword != ""
+	c.HasPrivPassword = c.PrivPassword != ""
+	return nil
+}
+
+// AfterSave keeps the secret flags current on saved configs
+func (c *SNMPConfig) AfterSave(tx *gorm.DB) error {
+	return c.AfterFind(tx)
+}
+
+// SNMPConfigRequest sets a device's SNMP settings. The form cannot show
+// stored secrets, so an empty community or password keeps the stored one.
+type SNMPConfigRequest struct {
+	Enabled      *bool  `json:"enabled"`
+	Version      string `json:"version"` // 2c (default) or 3
+	Port         int    `json:"port"`
+	Community    string `json:"community"`
+	User         string `json:"user"`
+	AuthProtocol string `json:"authProtocol"`
+	AuthPassword string `json:"authPassword"`
+	PrivProtocol string `json:"privProtocol"`
+	PrivPassword string `json:"privPassword"`
+}
+
+// SNMPStatus is the latest poll of a device's agent. CPU, memory and
+// storage are only set when the agent exposes the HOST-RESOURCES or UCD MIBs.
+type SNMPStatus struct {
+	DeviceID      uint            `json:"deviceId"`
+	SysName       string          `json:"sysName"`
+	SysDescr      string          `json:"sysDescr"`
+	SysLocation   string          `json:"sysLocation"`
+	UptimeSeconds uint64          `json:"uptimeSeconds"`
+	CPUPercent    *float64        `json:"cpuPercent,omitempty"`
+	Memory        *SNMPMemory     `json:"memory,omitempty"`
+	Storage       []SNMPStorage   `json:"storage"`
+	Interfaces    []SNMPInterface `json:"interfaces"`
+	PolledAt      time.Time       `json:"polledAt"`
+	Error         string          `json:"error,omitempty"` // why the last poll failed; the rest is from the poll before
+}
+
+// SNMPMemory is physical memory in bytes
+type SNMPMemory struct {
+	Total   uint64  `json:"total"`
+	Used    uint64  `json:"used"`
+	Percent float64 `json:"percent"`
+}
+
+// SNMPStorage is a fixed disk from hrStorageTable, in bytes
+type SNMPStorage struct {
+	Description string  `json:"description"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Percent     float64 `json:"percent"`
+}
+
+// SNMPInterface is a network interface's counters. Rates are bytes per
+// second since the previous poll, absent on the first.
+type SNMPInterface struct {
+	Index       int      `json:"index"`
+	Name        string   `json:"name"`
+	Alias       string   `json:"alias,omitempty"`
+	Status      string   `json:"status"` // up, down, testing, unknown, dormant, notPresent, lowerLayerDown
+	SpeedMbps   uint64   `json:"speedMbps"`
+	InOctets    uint64   `json:"inOctets"`
+	OutOctets   uint64   `json:"outOctets"`
+	InErrors    uint64   `json:"inErrors"`
+	OutErrors   uint64   `json:"outErrors"`
+	InRate      *float64 `json:"inRate,omitempty"`
+	OutRate     *float64 `json:"outRate,omitempty"`
+	HighCounter bool     `json:"-"` // counters are the 64-bit ifXTable ones
+}
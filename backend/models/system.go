@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// VersionInfo describes the running backend version and the latest release
+type VersionInfo struct {
+	Current         string     `json:"current"`
+	Latest          string     `json:"latest,omitempty"`
+	UpdateAvailable bool       `json:"updateAvailable"`
+	ReleaseName     string     `json:"releaseName,omitempty"`
+	Changelog       string     `json:"changelog,omitempty"`
+	ReleaseURL      string     `json:"releaseUrl,omitempty"`
+	PublishedAt     *time.Time `json:"publishedAt,omitempty"`
+	CheckedAt       *time.Time `json:"checkedAt,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Tag labels services and devices for filtering and bulk actions
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"size:100;not null;uniqueIndex:idx_tags_user_name"`
+	Color     string    `json:"color" gorm:"size:20"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TagUsage is a tag with how many services and devices carry it
+type TagUsage struct {
+	Tag
+	Services int64 `json:"services"`
+	Devices  int64 `json:"devices"`
+}
+
+// TagRequest for creating or updating a tag
+type TagRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"`
+}
+
+// SetTagsRequest replaces the tags on a service or device
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// TagActionResult reports what a tag-scoped bulk action touched
+type TagActionResult struct {
+	Action   string      `json:"action"`
+	Services int         `json:"services"`
+	Devices  int         `json:"devices"`
+	Results  interface{} `json:"results,omitempty"`
+}
+
+// ParseTagList reads tags from the legacy string format: a JSON array
+// or a comma separated list
+func ParseTagList(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal([]byte(value), &names); err == nil {
+		return names
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// Topology link sources
+const (
+	LinkSourceManual = "manual"
+	LinkSourceLLDP   = "lldp"
+)
+
+// TopologyLink is a physical connection between two devices, entered by
+// hand or learned from a switch's LLDP neighbor table. An LLDP neighbor
+// that matches no device keeps its advertised name and MAC instead.
+type TopologyLink struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"userId" gorm:"not null;index"`
+	Source         string    `json:"source" gorm:"size:10;not null;default:'manual'"`
+	DeviceID       uint      `json:"deviceId" gorm:"not null;index"`
+	Port           string    `json:"port" gorm:"size:100"`
+	TargetDeviceID *uint     `json:"targetDeviceId" gorm:"index"`
+	TargetPort     string    `json:"targetPort" gorm:"size:100"`
+	TargetName     string    `json:"targetName,omitempty" gorm:"size:255"` // unmatched LLDP neighbor
+	TargetMAC      string    `json:"targetMac,omitempty" gorm:"size:20"`
+	Label          string    `json:"label" gorm:"size:100"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"` // last reported, for LLDP links
+}
+
+// TopologyLinkRequest for creating a manual link between two devices
+type TopologyLinkRequest struct {
+	DeviceID       uint   `json:"deviceId" binding:"required"`
+	Port           string `json:"port"`
+	TargetDeviceID uint   `json:"targetDeviceId" binding:"required"`
+	TargetPort     string `json:"targetPort"`
+	Label          string `json:"label"` // e.g. 1G, uplink
+}
+
+// LLDPNeighbor is one entry of a switch's LLDP neighbor table, as shown by
+// lldpctl or the switch's LLDP-MIB
+type LLDPNeighbor struct {
+	LocalPort         string `json:"localPort"`
+	ChassisID         string `json:"chassisId"` // usually the neighbor's MAC
+	SystemName        string `json:"systemName"`
+	PortID            string `json:"portId"`
+	ManagementAddress string `json:"managementAddress"`
+}
+
+// LLDPReport replaces the LLDP neighbors known for a device
+type LLDPReport struct {
+	Neighbors []LLDPNeighbor `json:"neighbors"`
+}
+
+// Topology node types
+const (
+	NodeDevice        = "device"
+	NodeNeighbor      = "neighbor" // LLDP neighbor that matches no device
+	NodeSubnet        = "subnet"
+	NodeDockerHost    = "docker_host"
+	NodeDockerNetwork = "docker_network"
+	NodeContainer     = "container"
+)
+
+// Topology edge types
+const (
+	EdgeLink    = "link"    // manual link
+	EdgeLLDP    = "lldp"    // learned from LLDP
+	EdgeSubnet  = "subnet"  // device address in a subnet
+	EdgeNetwork = "network" // container attached to a Docker network
+)
+
+// Topology statuses
+const (
+	TopologyUp      = "up"
+	TopologyDown    = "down"
+	TopologyStale   = "stale" // LLDP link not reported recently
+	TopologyUnknown = "unknown"
+)
+
+// TopologyNode is a vertex of the network map
+type TopologyNode struct {
+	ID     string `json:"id"` // type-prefixed, e.g. device:3
+	Type   string `json:"type"`
+	Label  string `json:"label"`
+	Status string `json:"status"`
+	IP     string `json:"ip,omitempty"`
+	MAC    string `json:"mac,omitempty"`
+	Kind   string `json:"kind,omitempty"`  // device type, network driver or container state
+	RefID  string `json:"refId,omitempty"` // ID of the device, subnet, network or container
+}
+
+// TopologyEdge connects two nodes
+type TopologyEdge struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Label  string `json:"label,omitempty"` // ports, address or link label
+}
+
+// Topology is the network map as a graph
+type Topology struct {
+	Nodes       []TopologyNode `json:"nodes"`
+	Edges       []TopologyEdge `json:"edges"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Torrent states, normalised across torrent clients
+const (
+	TorrentDownloading = "downloading"
+	TorrentStalled     = "stalled" // downloading with no peers sending data
+	TorrentSeeding     = "seeding"
+	TorrentCompleted   = "completed" // finished and stopped
+	TorrentPaused      = "paused"
+	TorrentQueued      = "queued"
+	TorrentChecking    = "checking"
+	TorrentError       = "error"
+)
+
+// Torrent is one torrent in a download client
+type Torrent struct {
+	ID            string  `json:"id"` // info hash
+	Name          string  `json:"name"`
+	State         string  `json:"state"`
+	Progress      float64 `json:"progress"`      // percent
+	Size          int64   `json:"size"`          // bytes
+	DownloadSpeed int64   `json:"downloadSpeed"` // bytes/s
+	UploadSpeed   int64   `json:"uploadSpeed"`
+	ETA           int64   `json:"eta"` // seconds, -1 when unknown
+	Ratio         float64 `json:"ratio"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// TorrentStatus is the transfer state of a download client. Torrents lists
+// only the active ones; the counts cover all of them.
+type TorrentStatus struct {
+	Client        string    `json:"client"`        // qbittorrent or transmission
+	DownloadSpeed int64     `json:"downloadSpeed"` // bytes/s
+	UploadSpeed   int64     `json:"uploadSpeed"`
+	DownloadLimit int64     `json:"downloadLimit"` // bytes/s, 0 for unlimited
+	UploadLimit   int64     `json:"uploadLimit"`
+	Total         int       `json:"total"`
+	Downloading   int       `json:"downloading"` // including stalled
+	Seeding       int       `json:"seeding"`
+	Paused        int       `json:"paused"` // including completed
+	Errored       int       `json:"errored"`
+	Torrents      []Torrent `json:"torrents"`
+	FetchedAt     time.Time `json:"fetchedAt"`
+}
@@ -21,6 +21,11 @@ type User struct {
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Password policy state
+	PasswordChangedAt *time.Time `json:"passwordChangedAt"`
+	FailedLogins      int        `json:"-" gorm:"default:0"`
+	LockedUntil       *time.Time `json:"lockedUntil,omitempty"`
 }
 
 // Session represents an active user session
@@ -92,6 +97,11 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// RefreshRequest represents the token refresh request body
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
 // RegisterRequest represents the registration request body
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -106,6 +116,8 @@ type AuthResponse struct {
 	AccessToken  string       `json:"accessToken"`
 	RefreshToken string       `json:"refreshToken,omitempty"`
 	ExpiresAt    time.Time    `json:"expiresAt"`
+	// PasswordExpired means only the auth endpoints are usable until the password is changed
+	PasswordExpired bool `json:"passwordExpired,omitempty"`
 }
 
 // UpdateProfileRequest represents the profile update request
@@ -117,5 +129,5 @@ type UpdateProfileRequest struct {
 // ChangePasswordRequest represents the password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword" binding:"required"`
-	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+	NewPassword     string `json:"newPassword" binding:"required"`
 }
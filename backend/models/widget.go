@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Widget source auth types
+const (
+	WidgetAuthNone   = "none"
+	WidgetAuthBasic  = "basic"  // Username and Secret as HTTP basic auth
+	WidgetAuthBearer = "bearer" // Secret as a Bearer token
+	WidgetAuthHeader = "header" // Secret as the value of AuthHeader, e.g. X-Api-Key
+	WidgetAuthQuery  = "query"  // Secret as the AuthHeader query parameter, e.g. api_key
+)
+
+// WidgetSource is a third-party JSON API fetched by the backend on behalf of
+// dashboard widgets, so the browser never needs its credentials or CORS
+type WidgetSource struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	UserID        uint   `json:"userId" gorm:"not null;index"`
+	Name          string `json:"name" gorm:"size:255;not null"`
+	URL           string `json:"url" gorm:"size:1000;not null"`
+	Method        string `json:"method" gorm:"size:10"` // GET or POST
+	Body          string `json:"body" gorm:"type:text"` // POST body, sent as JSON
+	AuthType      string `json:"authType" gorm:"size:20"`
+	AuthHeader    string `json:"authHeader" gorm:"size:255"` // header or query parameter name
+	Username      string `json:"username" gorm:"size:255"`
+	Secret        string `json:"-" gorm:"size:1000"` // password, token or API key, stored encrypted
+	HasSecret     bool   `json:"hasSecret" gorm:"-"`
+	SkipTLSVerify bool   `json:"skipTlsVerify"`
+	CacheSeconds  int    `json:"cacheSeconds"` // default 30
+
+	// Extract maps output fields to paths into the response, e.g.
+	// {"queued": "$.torrents[*] | length", "speed": "$.server_state.dl_info_speed"}.
+	// Empty returns the whole response.
+	Extract    map[string]string `json:"extract" gorm:"-"`
+	RawExtract string            `json:"-" gorm:"column:extract;type:text"`
+
+	LastFetchedAt *time.Time `json:"lastFetchedAt"`
+	LastError     string     `json:"lastError,omitempty" gorm:"size:1000"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// WidgetSourceRequest for creating, updating or previewing a widget source.
+// On update an empty Secret keeps the stored one.
+type WidgetSourceRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	URL           string            `json:"url" binding:"required"`
+	Method        string            `json:"method"`
+	Body          string            `json:"body"`
+	AuthType      string            `json:"authType"`
+	AuthHeader    string            `json:"authHeader"`
+	Username      string            `json:"username"`
+	Secret        string            `json:"secret"`
+	SkipTLSVerify bool              `json:"skipTlsVerify"`
+	CacheSeconds  int               `json:"cacheSeconds"`
+	Extract       map[string]string `json:"extract"`
+}
+
+// WidgetData is the extracted result of fetching a widget source
+type WidgetData struct {
+	SourceID  uint        `json:"sourceId"`
+	Data      interface{} `json:"data"`
+	FetchedAt time.Time   `json:"fetchedAt"`
+	Cached    bool        `json:"cached"`
+	Errors    []string    `json:"errors,omitempty"` // extraction paths that did not match
+}
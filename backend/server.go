@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// runServer serves the API over HTTPS when TLS is configured, otherwise over plain HTTP
+func runServer(handler http.Handler, cfg *config.Config) error {
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	if !cfg.TLSEnabled() {
+		return srv.ListenAndServe()
+	}
+
+	redirect := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, httpsURL(r, cfg.Port), http.StatusPermanentRedirect)
+	}))
+
+	if cfg.TLSDomains != "" {
+		var domains []string
+		for _, domain := range strings.Split(cfg.TLSDomains, ",") {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cfg.TLSCacheDir),
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		// The HTTP listener also answers ACME HTTP-01 challenges
+		redirect = manager.HTTPHandler(redirect)
+		log.Printf("TLS certificates from Let's Encrypt for %s", strings.Join(domains, ", "))
+	}
+
+	if cfg.HTTPRedirectPort != "" {
+		go func() {
+			redirectSrv := &http.Server{
+				Addr:              ":" + cfg.HTTPRedirectPort,
+				Handler:           redirect,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			log.Printf("Redirecting HTTP on :%s to HTTPS", cfg.HTTPRedirectPort)
+			if err := redirectSrv.ListenAndServe(); err != nil {
+				log.Printf("Warning: HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+
+	return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+}
+
+// httpsURL rewrites a request URL to the HTTPS listener
+func httpsURL(r *http.Request, port string) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+	return "https://" + host + r.URL.RequestURI()
+}
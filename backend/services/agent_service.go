@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAgentNotReported is returned for a device whose agent is enrolled
+	// but has not reported yet
+	ErrAgentNotReported = errors.New("the device's agent has not reported yet")
+	// ErrInvalidAgentReport is returned for reports that can't be metrics
+	ErrInvalidAgentReport = errors.New("invalid agent report")
+)
+
+const (
+	// AgentTokenPrefix marks metrics agent tokens
+	AgentTokenPrefix = "hla_"
+	// agentOfflineAfter is how long after its last report an agent counts
+	// as offline, and SSH collection takes over for devices that have it on
+	agentOfflineAfter = 5 * time.Minute
+
+	// Bounds on the lists in a report
+	maxAgentDisks      = 64
+	maxAgentInterfaces = 256
+	maxAgentCores      = 1024
+)
+
+// AgentService enrolls push agents for devices and takes in their reports
+type AgentService struct {
+	db      *gorm.DB
+	devices *DeviceService
+	metrics *MetricsService
+}
+
+// NewAgentService creates a new AgentService
+func NewAgentService(devices *DeviceService, metrics *MetricsService) *AgentService {
+	return &AgentService{
+		db:      database.GetDB(),
+		devices: devices,
+		metrics: metrics,
+	}
+}
+
+// ListHosts returns the user's devices with an enrolled agent and their
+// latest reports
+func (s *AgentService) ListHosts(userID uint) ([]models.AgentHost, error) {
+	var rows []struct {
+		models.MetricsAgent
+		DeviceName string
+	}
+	err := s.db.Model(&models.MetricsAgent{}).
+		Select("metrics_agents.*, devices.name AS device_name").
+		Joins("JOIN devices ON devices.id = metrics_agents.device_id AND devices.deleted_at IS NULL").
+		Where("devices.user_id = ?", userID).
+		Order("devices.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]models.AgentHost, 0, len(rows))
+	for _, row := range rows {
+		host := models.AgentHost{
+			DeviceID:   row.DeviceID,
+			DeviceName: row.DeviceName,
+			Agent:      row.MetricsAgent,
+			Online:     row.LastSeenAt != nil && time.Since(*row.LastSeenAt) < agentOfflineAfter,
+		}
+		if reading, ok := s.metrics.pushedReading(row.DeviceID); ok {
+			host.Metrics = reading.metrics
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// Enroll issues a device's agent token, returned once. Enrolling again
+// replaces the token, so a lost one can be reissued.
+func (s *AgentService) Enroll(deviceID, userID uint) (*models.MetricsAgentResponse, error) {
+	if _, err := s.devices.GetDevice(deviceID, userID); err != nil {
+		return nil, err
+	}
+
+	token, hash := newToken(AgentTokenPrefix)
+	var agent models.MetricsAgent
+	if err := s.db.Where("device_id = ?", deviceID).First(&agent).Error; err != nil {
+		agent = models.MetricsAgent{DeviceID: deviceID}
+	}
+	agent.TokenHash = hash
+	agent.Prefix = token[:len(AgentTokenPrefix)+6]
+	if err := s.db.Save(&agent).Error; err != nil {
+		return nil, err
+	}
+	return &models.MetricsAgentResponse{MetricsAgent: agent, Token: token}, nil
+}
+
+// Remove revokes a device's agent. Its stored history is kept.
+func (s *AgentService) Remove(deviceID, userID uint) error {
+	if _, err := s.devices.GetDevice(deviceID, userID); err != nil {
+		return err
+	}
+	result := s.db.Where("device_id = ?", deviceID).Delete(&models.MetricsAgent{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("agent not found")
+	}
+	s.metrics.forgetPushed(deviceID)
+	return nil
+}
+
+// Authenticate returns the agent owning the token, as long as its device
+// still exists
+func (s *AgentService) Authenticate(token string) (*models.MetricsAgent, error) {
+	if !strings.HasPrefix(token, AgentTokenPrefix) {
+		return nil, fmt.Errorf("not an agent token")
+	}
+
+	var agent models.MetricsAgent
+	if err := s.db.Where("token_hash = ?", hashToken(token)).First(&agent).Error; err != nil {
+		return nil, fmt.Errorf("invalid agent token")
+	}
+	if err := s.db.First(&models.Device{}, agent.DeviceID).Error; err != nil {
+		return nil, fmt.Errorf("the agent's device was deleted")
+	}
+	return &agent, nil
+}
+
+// Ingest takes in an agent's report as its device's latest metrics,
+// storing it in the history at most every history interval
+func (s *AgentService) Ingest(agent *models.MetricsAgent, report models.AgentReport) error {
+	metrics := report.Metrics
+	if err := validateAgentMetrics(&metrics); err != nil {
+		return err
+	}
+	// The agent's clock may be off, so the report is dated on arrival
+	now := time.Now()
+	metrics.Timestamp = now
+
+	updates := map[string]interface{}{"last_seen_at": now}
+	if report.Hostname != "" {
+		updates["hostname"] = truncateRunes(report.Hostname, 255)
+	}
+	if report.Platform != "" {
+		updates["platform"] = truncateRunes(report.Platform, 100)
+	}
+	if report.Version != "" {
+		updates["version"] = truncateRunes(report.Version, 50)
+	}
+	if err := s.db.Model(agent).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	s.metrics.recordPushed(agent.DeviceID, &metrics)
+	return nil
+}
+
+// validateAgentMetrics rejects reports with impossible percentages or
+// oversized lists
+func validateAgentMetrics(m *models.SystemMetrics) error {
+	if len(m.Disk) > maxAgentDisks || len(m.Network) > maxAgentInterfaces || len(m.CPU.PerCoreUsage) > maxAgentCores {
+		return fmt.Errorf("%w: too many disks, interfaces or cores", ErrInvalidAgentReport)
+	}
+	percents := []float64{m.CPU.UsagePercent, m.Memory.UsedPercent, m.Memory.SwapPercent}
+	for _, d := range m.Disk {
+		percents = append(percents, d.UsedPercent)
+	}
+	for _, p := range percents {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return fmt.Errorf("%w: percentages must be between 0 and 100", ErrInvalidAgentReport)
+		}
+	}
+	return nil
+}
+
+// recordPushed caches a reading pushed by a device's agent and stores it in
+// the history when the last stored one is a history interval old
+func (s *MetricsService) recordPushed(deviceID uint, metrics *models.SystemMetrics) {
+	s.remoteMu.Lock()
+	previous := s.remote[deviceID]
+	reading := remoteReading{metrics: metrics, at: metrics.Timestamp, pushed: true, stored: previous.stored}
+	store := time.Since(reading.stored) >= metricsHistoryInterval
+	if store {
+		reading.stored = reading.at
+	}
+	s.remote[deviceID] = reading
+	s.remoteMu.Unlock()
+
+	if store {
+		s.storeHistory(deviceID, metrics)
+	}
+}
+
+// pushedReading returns the latest reading a device's agent pushed
+func (s *MetricsService) pushedReading(deviceID uint) (remoteReading, bool) {
+	s.remoteMu.Lock()
+	defer s.remoteMu.Unlock()
+	reading, ok := s.remote[deviceID]
+	return reading, ok && reading.pushed
+}
+
+// agentReporting reports whether a device's agent pushed a reading recently
+func (s *MetricsService) agentReporting(deviceID uint) bool {
+	reading, ok := s.pushedReading(deviceID)
+	return ok && time.Since(reading.at) < agentOfflineAfter
+}
+
+// forgetPushed drops the reading of a device whose agent was removed
+func (s *MetricsService) forgetPushed(deviceID uint) {
+	s.remoteMu.Lock()
+	defer s.remoteMu.Unlock()
+	if s.remote[deviceID].pushed {
+		delete(s.remote, deviceID)
+	}
+}
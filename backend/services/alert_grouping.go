@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// maxGroupLines is the most alerts listed in a grouped notification
+const maxGroupLines = 25
+
+// alertBatch holds a user's alerts during the grouping window, the latest
+// version of each alert in arrival order
+type alertBatch struct {
+	alerts map[uint]models.Alert
+	order  []uint
+	fired  map[uint]bool // alerts that fired within the window
+}
+
+// alertGroup is alerts sent as one notification: those sharing a device
+// they depend on and a state
+type alertGroup struct {
+	name   string // device or target the alerts are about
+	alerts []models.Alert
+}
+
+// queueAlert holds an alert back for the grouping window, which starts with
+// the first alert the user gets. An alert that fires and resolves within the
+// window is dropped, since nobody was told it fired.
+func (s *NotificationService) queueAlert(alert models.Alert) {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	batch, ok := s.batches[alert.UserID]
+	if !ok {
+		batch = &alertBatch{alerts: make(map[uint]models.Alert), fired: make(map[uint]bool)}
+		s.batches[alert.UserID] = batch
+		userID := alert.UserID
+		time.AfterFunc(s.window, func() { s.flushAlerts(userID) })
+	}
+
+	if alert.State == models.AlertResolved && batch.fired[alert.ID] {
+		delete(batch.alerts, alert.ID)
+		delete(batch.fired, alert.ID)
+		return
+	}
+	if _, queued := batch.alerts[alert.ID]; !queued {
+		batch.order = append(batch.order, alert.ID)
+	}
+	batch.alerts[alert.ID] = alert
+	if alert.State == models.AlertFiring {
+		batch.fired[alert.ID] = true
+	}
+}
+
+// flushAlerts sends the alerts a user collected during the window
+func (s *NotificationService) flushAlerts(userID uint) {
+	s.batchMu.Lock()
+	batch := s.batches[userID]
+	delete(s.batches, userID)
+	s.batchMu.Unlock()
+	if batch == nil {
+		return
+	}
+
+	alerts := make([]models.Alert, 0, len(batch.alerts))
+	for _, id := range batch.order {
+		if alert, ok := batch.alerts[id]; ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	if len(alerts) > 0 {
+		s.notifyAlerts(userID, alerts)
+	}
+}
+
+// groupAlerts splits alerts by state and by the device they depend on: a
+// device's own alerts and those of the services linked to it share a group.
+// Other alerts stay on their own. Groups keep the order of their first alert.
+func (s *NotificationService) groupAlerts(alerts []models.Alert) []alertGroup {
+	var groups []alertGroup
+	index := make(map[string]int)
+	for _, alert := range alerts {
+		root, name := s.alertRoot(alert)
+		key := alertStateGroup(alert.State) + "/" + root
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, alertGroup{name: name})
+		}
+		groups[i].alerts = append(groups[i].alerts, alert)
+	}
+
+	// The device's own alert leads its group
+	for _, group := range groups {
+		for i, alert := range group.alerts {
+			if i > 0 && alert.Target == models.AlertTargetDevice {
+				copy(group.alerts[1:i+1], group.alerts[:i])
+				group.alerts[0] = alert
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// alertStateGroup keeps resolved alerts apart from active ones
+func alertStateGroup(state string) string {
+	if state == models.AlertResolved {
+		return models.AlertResolved
+	}
+	return models.AlertFiring
+}
+
+// alertRoot returns the key and name of what an alert depends on: the
+// device for device alerts and services linked to a device, otherwise the
+// alert itself
+func (s *NotificationService) alertRoot(alert models.Alert) (string, string) {
+	switch alert.Target {
+	case models.AlertTargetDevice:
+		return fmt.Sprintf("device:%d", alert.TargetID), alert.TargetName
+	case models.AlertTargetService:
+		var svc models.ServiceConfig
+		if err := s.db.Select("id", "device_id").First(&svc, alert.TargetID).Error; err == nil && svc.DeviceID != nil {
+			var device models.Device
+			if err := s.db.Select("id", "name").First(&device, *svc.DeviceID).Error; err == nil {
+				return fmt.Sprintf("device:%d", device.ID), device.Name
+			}
+		}
+	}
+	return fmt.Sprintf("alert:%d", alert.ID), alert.TargetName
+}
+
+// groupNotification builds the message for a group of alerts. A single
+// alert keeps its own title and message; several are summarized in one
+// message listing each of them.
+func groupNotification(name string, alerts []models.Alert) models.NotificationMessage {
+	first := alerts[0]
+	prefix := "[FIRING] "
+	if first.State == models.AlertResolved {
+		prefix = "[RESOLVED] "
+	}
+	msg := models.NotificationMessage{
+		Title:    prefix + first.Name,
+		Message:  first.Message,
+		Severity: first.Severity,
+		State:    first.State,
+		Source:   "alerts",
+		Time:     time.Now(),
+		Alert:    &first,
+	}
+	if len(alerts) == 1 {
+		return msg
+	}
+
+	if name == "" {
+		name = first.Name
+	}
+	msg.Title = fmt.Sprintf("%s%s: %d alerts", prefix, name, len(alerts))
+	lines := make([]string, 0, len(alerts)+1)
+	for i, alert := range alerts {
+		if severityRank[alert.Severity] > severityRank[msg.Severity] {
+			msg.Severity = alert.Severity
+		}
+		if i < maxGroupLines {
+			lines = append(lines, fmt.Sprintf("- %s: %s", alert.Name, alert.Message))
+		}
+	}
+	if len(alerts) > maxGroupLines {
+		lines = append(lines, fmt.Sprintf("... and %d more", len(alerts)-maxGroupLines))
+	}
+	msg.Message = strings.Join(lines, "\n")
+	msg.Alerts = alerts
+	return msg
+}
@@ -0,0 +1,722 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// AlertService evaluates alert rules in the background and tracks the
+// resulting alerts from firing to resolved
+type AlertService struct {
+	db             *gorm.DB
+	metrics        *MetricsService
+	docker         *DockerService
+	serviceConfigs *ServiceConfigService
+	audit          *AuditService
+	events         *EventService
+
+	mu        sync.Mutex
+	pending   map[uint]time.Time // when each rule's condition started holding
+	listeners []func(models.Alert)
+}
+
+// alertRetention is how long resolved alerts are kept
+const alertRetention = 90 * 24 * time.Hour
+
+// alertMetrics are the metrics each target supports
+var alertMetrics = map[string][]string{
+	models.AlertTargetMetric:    {models.AlertMetricCPU, models.AlertMetricMemory, models.AlertMetricDisk, models.AlertMetricLoad, models.AlertMetricTemperature},
+	models.AlertTargetService:   {models.AlertMetricDown, models.AlertMetricResponseTime},
+	models.AlertTargetDevice:    {models.AlertMetricOffline},
+	models.AlertTargetContainer: {models.AlertMetricDown, models.AlertMetricCPU, models.AlertMetricMemory},
+	models.AlertTargetSensor:    {models.AlertMetricValue},
+	models.AlertTargetQuery:     {models.AlertMetricValue},
+	models.AlertTargetTag:       {models.AlertMetricDown, models.AlertMetricResponseTime, models.AlertMetricOffline},
+}
+
+// NewAlertService creates a new AlertService and starts the rule evaluator
+func NewAlertService(metrics *MetricsService, docker *DockerService, serviceConfigs *ServiceConfigService, audit *AuditService, events *EventService) *AlertService {
+	s := &AlertService{
+		db:             database.GetDB(),
+		metrics:        metrics,
+		docker:         docker,
+		serviceConfigs: serviceConfigs,
+		audit:          audit,
+		events:         events,
+		pending:        make(map[uint]time.Time),
+	}
+	if config.AppConfig.AlertEvalInterval > 0 {
+		go s.evaluateBackground(time.Duration(config.AppConfig.AlertEvalInterval) * time.Second)
+	}
+	return s
+}
+
+// OnAlert registers a function called when an alert fires or resolves
+func (s *AlertService) OnAlert(fn func(models.Alert)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// notify calls the alert listeners
+func (s *AlertService) notify(alert models.Alert) {
+	s.mu.Lock()
+	listeners := append([]func(models.Alert){}, s.listeners...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(alert)
+	}
+}
+
+// evaluateBackground evaluates every enabled rule on each interval
+func (s *AlertService) evaluateBackground(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for range ticker.C {
+		s.evaluate()
+
+		// Purge resolved alerts beyond retention once a day
+		if time.Since(lastCleanup) > 24*time.Hour {
+			s.db.Where("state = ? AND resolved_at < ?", models.AlertResolved, time.Now().Add(-alertRetention)).Delete(&models.Alert{})
+			lastCleanup = time.Now()
+		}
+	}
+}
+
+// evaluate checks every enabled rule once, firing alerts whose condition has
+// held for the rule's duration and resolving those whose condition cleared
+func (s *AlertService) evaluate() {
+	var rules []models.AlertRule
+	if err := s.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		log.Printf("Failed to load alert rules: %v", err)
+		return
+	}
+
+	var active []models.Alert
+	s.db.Where("state <> ? AND target <> ?", models.AlertResolved, models.AlertTargetExternal).Find(&active)
+	activeByRule := make(map[uint]models.Alert, len(active))
+	for _, alert := range active {
+		activeByRule[alert.RuleID] = alert
+	}
+
+	var host *models.SystemMetrics
+	now := time.Now()
+	enabled := make(map[uint]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule.ID] = true
+
+		value, targetName, err := s.observe(rule, &host)
+		if err != nil {
+			// No data: keep the current state rather than guessing
+			continue
+		}
+		alert, firing := activeByRule[rule.ID]
+
+		if !conditionMet(rule, value) {
+			s.mu.Lock()
+			delete(s.pending, rule.ID)
+			s.mu.Unlock()
+			if firing {
+				s.resolve(alert, value)
+			}
+			continue
+		}
+
+		if firing {
+			s.db.Model(&alert).Update("value", value)
+			continue
+		}
+
+		s.mu.Lock()
+		since, ok := s.pending[rule.ID]
+		if !ok {
+			since = now
+			s.pending[rule.ID] = now
+		}
+		s.mu.Unlock()
+		if now.Sub(since) >= time.Duration(rule.Duration)*time.Second {
+			s.fire(rule, targetName, value, since)
+		}
+	}
+
+	// Alerts of deleted or disabled rules can no longer clear on their own
+	for ruleID, alert := range activeByRule {
+		if !enabled[ruleID] {
+			s.resolve(alert, alert.Value)
+		}
+	}
+}
+
+// observe returns the current value of a rule's metric and the target's
+// name. host caches this host's metrics for the evaluation round.
+func (s *AlertService) observe(rule models.AlertRule, host **models.SystemMetrics) (float64, string, error) {
+	switch rule.Target {
+	case models.AlertTargetMetric:
+		if *host == nil {
+			metrics, err := s.metrics.GetSystemMetrics()
+			if err != nil {
+				return 0, "", err
+			}
+			*host = metrics
+		}
+		return hostMetricValue(**host, rule)
+
+	case models.AlertTargetService:
+		status, ok := s.serviceConfigs.LatestStatus(rule.TargetID)
+		if !ok || status.Status == "disabled" {
+			return 0, "", fmt.Errorf("service not checked yet")
+		}
+		if rule.Metric == models.AlertMetricResponseTime {
+			return float64(status.ResponseTime), status.Name, nil
+		}
+		return boolValue(statusDown(status.Status)), status.Name, nil
+
+	case models.AlertTargetDevice:
+		var device models.Device
+		if err := s.db.Select("id", "name", "is_online").First(&device, rule.TargetID).Error; err != nil {
+			return 0, "", fmt.Errorf("device not found")
+		}
+		return boolValue(!device.IsOnline), device.Name, nil
+
+	case models.AlertTargetContainer:
+		if !s.docker.IsConnected() {
+			return 0, "", fmt.Errorf("docker not connected")
+		}
+		c, err := s.docker.FindContainer(rule.TargetName)
+		running := err == nil && c.State == "running"
+		switch rule.Metric {
+		case models.AlertMetricDown:
+			return boolValue(!running), rule.TargetName, nil
+		case models.AlertMetricCPU, models.AlertMetricMemory:
+			if !running {
+				return 0, "", fmt.Errorf("container not running")
+			}
+			stats := s.docker.getCachedStats(c.ID)
+			if rule.Metric == models.AlertMetricCPU {
+				return stats.CPUPercent, c.Name, nil
+			}
+			return stats.MemoryPercent, c.Name, nil
+		}
+
+	case models.AlertTargetSensor:
+		var sensor models.Sensor
+		if err := s.db.First(&sensor, rule.TargetID).Error; err != nil {
+			return 0, "", fmt.Errorf("sensor not found")
+		}
+		if time.Since(sensor.LastSeenAt) > sensorStaleAfter {
+			return 0, "", fmt.Errorf("no recent reading")
+		}
+		return sensor.Value, sensor.Name, nil
+
+	case models.AlertTargetQuery:
+		result, err := s.metrics.QueryMetrics(rule.UserID, rule.TargetName, 0)
+		if err != nil {
+			return 0, "", err
+		}
+		if result.Value == nil {
+			return 0, "", fmt.Errorf("no samples in the query window")
+		}
+		return *result.Value, rule.TargetName, nil
+
+	case models.AlertTargetTag:
+		return s.observeTag(rule)
+	}
+	return 0, "", fmt.Errorf("unsupported rule")
+}
+
+// observeTag evaluates a tag rule across the user's services or devices
+// carrying the tag: how many are down or offline, or the slowest response
+// time. The target name is the tag followed by the members counted.
+func (s *AlertService) observeTag(rule models.AlertRule) (float64, string, error) {
+	var tag models.Tag
+	if err := s.db.Where("id = ? AND user_id = ?", rule.TagID, rule.UserID).First(&tag).Error; err != nil {
+		return 0, "", fmt.Errorf("tag not found")
+	}
+	scope := []string{tag.Name}
+	var members []string
+
+	if rule.Metric == models.AlertMetricOffline {
+		var devices []models.Device
+		if err := s.db.Select("id", "name", "is_online").Where("user_id = ? AND is_active = ?", rule.UserID, true).
+			Scopes(DeviceTagScope(scope)).Order("name ASC").Find(&devices).Error; err != nil {
+			return 0, "", err
+		}
+		if len(devices) == 0 {
+			return 0, "", fmt.Errorf("no devices tagged %s", tag.Name)
+		}
+		for _, device := range devices {
+			if !device.IsOnline {
+				members = append(members, device.Name)
+			}
+		}
+		return float64(len(members)), tagTargetName(tag.Name, members), nil
+	}
+
+	var ids []uint
+	if err := s.db.Model(&models.ServiceConfig{}).Where("user_id = ?", rule.UserID).
+		Scopes(ServiceTagScope(scope)).Order("name ASC").Pluck("id", &ids).Error; err != nil {
+		return 0, "", err
+	}
+	checked := 0
+	var slowest float64
+	for _, id := range ids {
+		status, ok := s.serviceConfigs.LatestStatus(id)
+		if !ok || status.Status == "disabled" {
+			continue
+		}
+		checked++
+		if rule.Metric == models.AlertMetricResponseTime {
+			if len(members) == 0 || float64(status.ResponseTime) > slowest {
+				slowest = float64(status.ResponseTime)
+				members = []string{status.Name}
+			}
+		} else if statusDown(status.Status) {
+			members = append(members, status.Name)
+		}
+	}
+	if checked == 0 {
+		return 0, "", fmt.Errorf("no checked services tagged %s", tag.Name)
+	}
+	if rule.Metric == models.AlertMetricResponseTime {
+		return slowest, tagTargetName(tag.Name, members), nil
+	}
+	return float64(len(members)), tagTargetName(tag.Name, members), nil
+}
+
+// tagTargetName names a tag rule's target, e.g. "web (nas, plex)"
+func tagTargetName(tag string, members []string) string {
+	if len(members) == 0 {
+		return tag
+	}
+	return tag + " (" + strings.Join(members, ", ") + ")"
+}
+
+// hostMetricValue picks a rule's metric out of this host's metrics
+func hostMetricValue(metrics models.SystemMetrics, rule models.AlertRule) (float64, string, error) {
+	switch rule.Metric {
+	case models.AlertMetricCPU:
+		return metrics.CPU.UsagePercent, "host", nil
+	case models.AlertMetricMemory:
+		return metrics.Memory.UsedPercent, "host", nil
+	case models.AlertMetricLoad:
+		if len(metrics.CPU.LoadAverage) == 0 {
+			return 0, "", fmt.Errorf("load average not available")
+		}
+		return metrics.CPU.LoadAverage[0], "host", nil
+	case models.AlertMetricTemperature:
+		if metrics.CPU.Temperature == 0 {
+			return 0, "", fmt.Errorf("temperature not available")
+		}
+		return metrics.CPU.Temperature, "host", nil
+	case models.AlertMetricDisk:
+		// A named mount point, otherwise the fullest filesystem
+		var fullest *models.DiskMetrics
+		for i, d := range metrics.Disk {
+			if rule.TargetName != "" {
+				if d.MountPoint == rule.TargetName {
+					return d.UsedPercent, d.MountPoint, nil
+				}
+				continue
+			}
+			if fullest == nil || d.UsedPercent > fullest.UsedPercent {
+				fullest = &metrics.Disk[i]
+			}
+		}
+		if fullest == nil {
+			return 0, "", fmt.Errorf("disk not found")
+		}
+		return fullest.UsedPercent, fullest.MountPoint, nil
+	}
+	return 0, "", fmt.Errorf("unsupported metric")
+}
+
+// boolValue is 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// conditionMet compares a value with the rule's threshold
+func conditionMet(rule models.AlertRule, value float64) bool {
+	switch rule.Condition {
+	case models.ConditionAbove:
+		return value > rule.Threshold
+	case models.ConditionBelow:
+		return value < rule.Threshold
+	case models.ConditionEqual:
+		return value == rule.Threshold
+	}
+	return false
+}
+
+// fire opens an alert for a rule whose condition has held long enough
+func (s *AlertService) fire(rule models.AlertRule, targetName string, value float64, since time.Time) {
+	targetID := rule.TargetID
+	if rule.Target == models.AlertTargetTag {
+		targetID = rule.TagID
+	}
+	alert := models.Alert{
+		RuleID:     rule.ID,
+		UserID:     rule.UserID,
+		Name:       rule.Name,
+		Target:     rule.Target,
+		TargetID:   targetID,
+		TargetName: targetName,
+		Metric:     rule.Metric,
+		Severity:   rule.Severity,
+		State:      models.AlertFiring,
+		Value:      value,
+		Threshold:  rule.Threshold,
+		Message:    alertMessage(rule, targetName, value),
+		StartedAt:  since,
+	}
+	if err := s.db.Create(&alert).Error; err != nil {
+		log.Printf("Failed to store alert for rule %d: %v", rule.ID, err)
+		return
+	}
+
+	s.events.RecordFor(alert.UserID, "alert_firing", alert.Severity, "alerts", "Alert firing: "+alert.Name, alert.Message,
+		map[string]interface{}{"alertId": alert.ID, "ruleId": rule.ID, "value": value})
+	s.notify(alert)
+}
+
+// resolve closes an active alert
+func (s *AlertService) resolve(alert models.Alert, value float64) {
+	now := time.Now()
+	if err := s.db.Model(&alert).Updates(map[string]interface{}{
+		"state":       models.AlertResolved,
+		"value":       value,
+		"resolved_at": now,
+	}).Error; err != nil {
+		log.Printf("Failed to resolve alert %d: %v", alert.ID, err)
+		return
+	}
+	alert.State = models.AlertResolved
+	alert.Value = value
+	alert.ResolvedAt = &now
+
+	s.events.RecordFor(alert.UserID, "alert_resolved", models.SeverityInfo, "alerts", "Alert resolved: "+alert.Name,
+		fmt.Sprintf("%s after %s", alert.Message, now.Sub(alert.StartedAt).Round(time.Second)),
+		map[string]interface{}{"alertId": alert.ID, "ruleId": alert.RuleID, "value": value})
+	s.notify(alert)
+}
+
+// RaiseExternal fires an alert raised by an inbound hook, or refreshes the
+// message of the one already active for the same key. It reports whether a
+// new alert fired.
+func (s *AlertService) RaiseExternal(userID, hookID uint, key, name, severity, message string) bool {
+	var alert models.Alert
+	err := s.db.Where("target = ? AND target_id = ? AND target_name = ? AND state <> ?",
+		models.AlertTargetExternal, hookID, key, models.AlertResolved).First(&alert).Error
+	if err == nil {
+		s.db.Model(&alert).Updates(map[string]interface{}{"message": message, "severity": severity})
+		return false
+	}
+
+	alert = models.Alert{
+		UserID:     userID,
+		Name:       name,
+		Target:     models.AlertTargetExternal,
+		TargetID:   hookID,
+		TargetName: key,
+		Metric:     models.AlertTargetExternal,
+		Severity:   severity,
+		State:      models.AlertFiring,
+		Value:      1,
+		Message:    message,
+		StartedAt:  time.Now(),
+	}
+	if err := s.db.Create(&alert).Error; err != nil {
+		log.Printf("Failed to store external alert from hook %d: %v", hookID, err)
+		return false
+	}
+
+	s.events.RecordFor(alert.UserID, "alert_firing", alert.Severity, "alerts", "Alert firing: "+alert.Name, alert.Message,
+		map[string]interface{}{"alertId": alert.ID, "hookId": hookID, "key": key})
+	s.notify(alert)
+	return true
+}
+
+// ResolveExternal resolves the active alert an inbound hook raised for key.
+// It reports whether there was one.
+func (s *AlertService) ResolveExternal(hookID uint, key string) bool {
+	var alert models.Alert
+	if err := s.db.Where("target = ? AND target_id = ? AND target_name = ? AND state <> ?",
+		models.AlertTargetExternal, hookID, key, models.AlertResolved).First(&alert).Error; err != nil {
+		return false
+	}
+	s.resolve(alert, 0)
+	return true
+}
+
+// alertMessage describes a rule's condition with the observed value
+func alertMessage(rule models.AlertRule, targetName string, value float64) string {
+	if rule.Target == models.AlertTargetTag && rule.Metric != models.AlertMetricResponseTime {
+		noun := "services"
+		if rule.Metric == models.AlertMetricOffline {
+			noun = "devices"
+		}
+		return fmt.Sprintf("%s %s tagged %s are %s", strconv.FormatFloat(value, 'f', -1, 64), noun, targetName, rule.Metric)
+	}
+	switch rule.Metric {
+	case models.AlertMetricDown, models.AlertMetricOffline:
+		return fmt.Sprintf("%s is %s", targetName, rule.Metric)
+	case models.AlertMetricValue:
+		return fmt.Sprintf("%s is %s (%s %s)", targetName,
+			strconv.FormatFloat(value, 'f', -1, 64), rule.Condition, strconv.FormatFloat(rule.Threshold, 'f', -1, 64))
+	}
+	return fmt.Sprintf("%s %s is %s (%s %s)", targetName, rule.Metric,
+		strconv.FormatFloat(value, 'f', -1, 64), rule.Condition, strconv.FormatFloat(rule.Threshold, 'f', -1, 64))
+}
+
+// validate checks a rule request against the user's services and devices
+func (s *AlertService) validate(userID uint, req *models.AlertRuleRequest) error {
+	metrics, ok := alertMetrics[req.Target]
+	if !ok {
+		return fmt.Errorf("unknown target %q (use metric, service, device, container, sensor, query or tag)", req.Target)
+	}
+	supported := false
+	for _, m := range metrics {
+		if m == req.Metric {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("metric %q is not supported for %s targets", req.Metric, req.Target)
+	}
+
+	switch req.Target {
+	case models.AlertTargetService:
+		var count int64
+		s.db.Model(&models.ServiceConfig{}).Where("id = ? AND user_id = ?", req.TargetID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("service not found")
+		}
+	case models.AlertTargetDevice:
+		var count int64
+		s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", req.TargetID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("device not found")
+		}
+	case models.AlertTargetContainer:
+		if req.TargetName == "" {
+			return fmt.Errorf("container targets require a container name or label:key=value")
+		}
+	case models.AlertTargetSensor:
+		var count int64
+		s.db.Model(&models.Sensor{}).Where("id = ? AND user_id = ?", req.TargetID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("sensor not found")
+		}
+	case models.AlertTargetQuery:
+		if req.TargetName == "" {
+			return fmt.Errorf("query targets require an expression such as avg(cpu.usage, 5m)")
+		}
+		if err := ValidateMetricsQuery(req.TargetName); err != nil {
+			return err
+		}
+	case models.AlertTargetTag:
+		var count int64
+		s.db.Model(&models.Tag{}).Where("id = ? AND user_id = ?", req.TagID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("tag not found")
+		}
+	}
+	if req.Target != models.AlertTargetTag {
+		req.TagID = 0
+	}
+
+	// Status metrics only make sense as "is down". Tag rules count the
+	// members down, any of them unless a condition says how many.
+	if req.Metric == models.AlertMetricDown || req.Metric == models.AlertMetricOffline {
+		if req.Target != models.AlertTargetTag {
+			req.Condition = models.ConditionEqual
+			req.Threshold = 1
+		} else if req.Condition == "" {
+			req.Condition = models.ConditionAbove
+			req.Threshold = 0
+		}
+	}
+
+	switch req.Condition {
+	case models.ConditionAbove, models.ConditionBelow, models.ConditionEqual:
+	default:
+		return fmt.Errorf("unknown condition %q (use above, below or equal)", req.Condition)
+	}
+
+	if req.Duration < 0 {
+		return fmt.Errorf("duration must not be negative")
+	}
+	switch req.Severity {
+	case "":
+		req.Severity = models.SeverityWarning
+	case models.SeverityInfo, models.SeverityWarning, models.SeverityCritical:
+	default:
+		return fmt.Errorf("unknown severity %q (use info, warning or critical)", req.Severity)
+	}
+	return nil
+}
+
+// ListRules returns the user's alert rules
+func (s *AlertService) ListRules(userID uint) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRule returns one of the user's alert rules
+func (s *AlertService) GetRule(id uint, userID uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error; err != nil {
+		return nil, fmt.Errorf("alert rule not found")
+	}
+	return &rule, nil
+}
+
+// CreateRule adds an alert rule
+func (s *AlertService) CreateRule(userID uint, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+
+	rule := models.AlertRule{
+		UserID:      userID,
+		Name:        req.Name,
+		Target:      req.Target,
+		TargetID:    req.TargetID,
+		TargetName:  req.TargetName,
+		TagID:       req.TagID,
+		Metric:      req.Metric,
+		Condition:   req.Condition,
+		Threshold:   req.Threshold,
+		Duration:    req.Duration,
+		Severity:    req.Severity,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		EmailNotify: req.EmailNotify,
+	}
+	if err := s.db.Create(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule replaces an alert rule's settings. An alert already firing for
+// the rule resolves on the next evaluation if the new condition no longer holds.
+func (s *AlertService) UpdateRule(id uint, userID uint, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := s.GetRule(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+
+	rule.Name = req.Name
+	rule.Target = req.Target
+	rule.TargetID = req.TargetID
+	rule.TargetName = req.TargetName
+	rule.TagID = req.TagID
+	rule.Metric = req.Metric
+	rule.Condition = req.Condition
+	rule.Threshold = req.Threshold
+	rule.Duration = req.Duration
+	rule.Severity = req.Severity
+	rule.EmailNotify = req.EmailNotify
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.pending, rule.ID)
+	s.mu.Unlock()
+	return rule, nil
+}
+
+// DeleteRule removes an alert rule and its remediation hooks; its active
+// alert resolves on the next evaluation
+func (s *AlertService) DeleteRule(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.AlertRule{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+	s.db.Where("trigger_type = ? AND rule_id = ?", models.TriggerAlert, id).Delete(&models.RemediationHook{})
+
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+	return result.Error
+}
+
+// ListAlerts returns the user's alerts, newest first, optionally filtered by
+// state. The state "active" matches firing and acknowledged alerts.
+func (s *AlertService) ListAlerts(userID uint, state string, limit int) ([]models.Alert, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := s.db.Where("user_id = ?", userID).Order("started_at DESC").Limit(limit)
+	switch state {
+	case "":
+	case "active":
+		query = query.Where("state <> ?", models.AlertResolved)
+	case models.AlertFiring, models.AlertAcknowledged, models.AlertResolved:
+		query = query.Where("state = ?", state)
+	default:
+		return nil, fmt.Errorf("unknown state %q", state)
+	}
+
+	alerts := make([]models.Alert, 0)
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// Acknowledge marks a firing alert as seen. It stays active until its
+// condition clears.
+func (s *AlertService) Acknowledge(id uint, userID uint) (*models.Alert, error) {
+	var alert models.Alert
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&alert).Error; err != nil {
+		return nil, fmt.Errorf("alert not found")
+	}
+	if alert.State != models.AlertFiring {
+		return nil, fmt.Errorf("alert is %s", alert.State)
+	}
+
+	actor := fmt.Sprintf("user:%d", userID)
+	now := time.Now()
+	if err := s.db.Model(&alert).Updates(map[string]interface{}{
+		"state":           models.AlertAcknowledged,
+		"acknowledged_at": now,
+		"acknowledged_by": actor,
+	}).Error; err != nil {
+		return nil, err
+	}
+	alert.State = models.AlertAcknowledged
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = actor
+
+	s.audit.Record(userID, actor, "alert.acknowledge", "alert", strconv.FormatUint(uint64(alert.ID), 10), true,
+		map[string]interface{}{"ruleId": alert.RuleID, "name": alert.Name})
+	return &alert, nil
+}
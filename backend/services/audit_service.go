@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// AuditService records and lists actions taken by users and automation
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService() *AuditService {
+	return &AuditService{
+		db: database.GetDB(),
+	}
+}
+
+// Record stores an audit entry, encoding details as JSON when provided
+func (s *AuditService) Record(userID uint, actor, action, targetType, targetID string, success bool, details interface{}) {
+	entry := models.AuditLog{
+		UserID:     userID,
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Success:    success,
+	}
+
+	if details != nil {
+		if data, err := json.Marshal(details); err == nil {
+			entry.Details = string(data)
+		}
+	}
+
+	if err := s.db.Create(&entry).Error; err != nil {
+		log.Printf("Failed to record audit entry %s: %v", action, err)
+	}
+}
+
+// List returns the most recent audit entries, optionally filtered by action prefix and target type
+func (s *AuditService) List(limit int, action, targetType string) ([]models.AuditLog, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := s.db.Order("created_at DESC").Limit(limit)
+	if action != "" {
+		query = query.Where("action LIKE ?", action+"%")
+	}
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var entries []models.AuditLog
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
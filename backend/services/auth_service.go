@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,45 +18,90 @@ type AuthService struct {
 	db        *gorm.DB
 	jwtSecret []byte
 	jwtExpiry time.Duration
+	cache     Cache
+	settings  *SettingsService
+
+	// stateless skips the session lookup; access tokens then last accessExpiry
+	// and jwtExpiry becomes the refresh token lifetime
+	stateless    bool
+	accessExpiry time.Duration
 }
 
+// Login and password errors the API reports with their own codes
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrAccountLocked      = errors.New("account is locked")
+	ErrPasswordPolicy     = errors.New("password does not meet the policy")
+)
+
+// RefreshTokenPrefix marks refresh tokens issued in stateless mode
+const RefreshTokenPrefix = "hlr_"
+
+// sessionCacheTTL is how long a validated session is trusted without hitting the database
+const sessionCacheTTL = time.Minute
+
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
 	UserID   uint   `json:"userId"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// PasswordExpired limits the token to the auth endpoints
+	PasswordExpired bool `json:"pwdExpired,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// NewAuthService creates a new AuthService
-func NewAuthService() *AuthService {
+// NewAuthService creates a new AuthService. Sessions are cached in process
+// when no shared cache is configured.
+func NewAuthService(cache Cache, settings *SettingsService) *AuthService {
 	cfg := config.AppConfig
+	if _, ok := cache.(noopCache); ok {
+		cache = newMemoryCache()
+	}
 	return &AuthService{
-		db:        database.GetDB(),
-		jwtSecret: []byte(cfg.JWTSecret),
-		jwtExpiry: time.Duration(cfg.JWTExpiryHours) * time.Hour,
+		db:           database.GetDB(),
+		jwtSecret:    []byte(cfg.JWTSecret),
+		jwtExpiry:    time.Duration(cfg.JWTExpiryHours) * time.Hour,
+		cache:        cache,
+		settings:     settings,
+		stateless:    cfg.AuthStateless,
+		accessExpiry: time.Duration(cfg.AccessTokenMinutes) * time.Minute,
 	}
 }
 
+// sessionCacheKey keys cached sessions by a hash so tokens never reach the cache
+func sessionCacheKey(token string) string {
+	return "session:" + hashToken(token)
+}
+
 // Login authenticates a user and returns tokens
 func (s *AuthService) Login(req models.LoginRequest, userAgent, ipAddress string) (*models.AuthResponse, error) {
 	var user models.User
 	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, ErrInvalidCredentials
 	}
 
 	if !user.IsActive {
-		return nil, errors.New("account is disabled")
+		return nil, ErrAccountDisabled
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return nil, fmt.Errorf("%w until %s after too many failed logins", ErrAccountLocked, user.LockedUntil.Format(time.RFC3339))
 	}
 
 	if !user.CheckPassword(req.Password) {
-		return nil, errors.New("invalid email or password")
+		s.recordFailedLogin(&user)
+		return nil, ErrInvalidCredentials
 	}
 
-	// Update last login
+	// Update last login and clear failed attempts
 	now := time.Now()
-	s.db.Model(&user).Update("last_login", now)
+	s.db.Model(&user).Updates(map[string]interface{}{
+		"last_login":    now,
+		"failed_logins": 0,
+		"locked_until":  nil,
+	})
 
 	// Generate tokens
 	authResponse, err := s.generateAuthResponse(&user)
@@ -70,13 +117,82 @@ func (s *AuthService) Login(req models.LoginRequest, userAgent, ipAddress string
 		IPAddress: ipAddress,
 		ExpiresAt: authResponse.ExpiresAt,
 	}
+	if s.stateless {
+		refreshToken, hash := newToken(RefreshTokenPrefix)
+		authResponse.RefreshToken = refreshToken
+		session.RefreshToken = hash
+		session.ExpiresAt = time.Now().Add(s.jwtExpiry)
+	}
 	s.db.Create(&session)
 
 	return authResponse, nil
 }
 
+// recordFailedLogin counts a wrong password and locks the account once the
+// policy's limit is reached. The count is incremented in the database, so
+// concurrent attempts can't overwrite each other's increments.
+func (s *AuthService) recordFailedLogin(user *models.User) {
+	policy := s.settings.PasswordPolicy()
+	s.db.Model(&models.User{}).Where("id = ?", user.ID).
+		Updates(map[string]interface{}{"failed_logins": gorm.Expr("failed_logins + 1")})
+	if policy.MaxFailedLogins <= 0 {
+		return
+	}
+
+	// Only the attempt that reaches the limit locks the account and resets
+	// the count
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND failed_logins >= ?", user.ID, policy.MaxFailedLogins).
+		Updates(map[string]interface{}{
+			"locked_until":  time.Now().Add(time.Duration(policy.LockoutMinutes) * time.Minute),
+			"failed_logins": 0,
+		})
+	if result.RowsAffected > 0 {
+		log.Printf("Locked account %s for %d minutes after %d failed logins", user.Email, policy.LockoutMinutes, policy.MaxFailedLogins)
+	}
+}
+
+// Refresh exchanges a refresh token for a new access token. The refresh token
+// is rotated, so each one can only be used once.
+func (s *AuthService) Refresh(refreshToken, userAgent, ipAddress string) (*models.AuthResponse, error) {
+	if !s.stateless {
+		return nil, errors.New("refresh tokens are only issued in stateless mode")
+	}
+
+	var session models.Session
+	if err := s.db.Where("refresh_token = ? AND expires_at > ?", hashToken(refreshToken), time.Now()).First(&session).Error; err != nil {
+		return nil, errors.New("refresh token expired or invalid")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, session.UserID).Error; err != nil || !user.IsActive {
+		s.db.Delete(&session)
+		return nil, ErrAccountDisabled
+	}
+
+	authResponse, err := s.generateAuthResponse(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	newRefresh, hash := newToken(RefreshTokenPrefix)
+	authResponse.RefreshToken = newRefresh
+	if err := s.db.Model(&session).Updates(map[string]interface{}{
+		"token":         authResponse.AccessToken,
+		"refresh_token": hash,
+		"user_agent":    userAgent,
+		"ip_address":    ipAddress,
+		"expires_at":    time.Now().Add(s.jwtExpiry),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return authResponse, nil
+}
+
 // Logout invalidates a user session
 func (s *AuthService) Logout(token string) error {
+	s.cache.Delete(sessionCacheKey(token))
 	return s.db.Where("token = ?", token).Delete(&models.Session{}).Error
 }
 
@@ -94,11 +210,28 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	}
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+		// Stateless tokens are short-lived and trusted until they expire
+		if s.stateless {
+			return claims, nil
+		}
+
 		// Check if session exists and is not expired
+		key := sessionCacheKey(tokenString)
+		var expiresAt time.Time
+		if s.cache.Get(key, &expiresAt) && time.Now().Before(expiresAt) {
+			return claims, nil
+		}
+
 		var session models.Session
 		if err := s.db.Where("token = ? AND expires_at > ?", tokenString, time.Now()).First(&session).Error; err != nil {
 			return nil, errors.New("session expired or invalid")
 		}
+
+		ttl := sessionCacheTTL
+		if remaining := time.Until(session.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+		s.cache.Set(key, session.ExpiresAt, ttl)
 		return claims, nil
 	}
 
@@ -146,30 +279,86 @@ func (s *AuthService) ChangePassword(userID uint, req models.ChangePasswordReque
 		return errors.New("current password is incorrect")
 	}
 
+	policy := s.settings.PasswordPolicy()
+	if err := checkPasswordComplexity(policy, req.NewPassword); err != nil {
+		return err
+	}
+
+	previous := user.Password
+	if policy.HistoryCount > 0 {
+		// The current password counts as the most recent one
+		var history []string
+		s.db.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).
+			Order("created_at DESC").Limit(policy.HistoryCount).Pluck("hash", &history)
+		if len(history) > policy.HistoryCount-1 {
+			history = history[:policy.HistoryCount-1]
+		}
+		if passwordReused(req.NewPassword, append(history, previous)) {
+			return fmt.Errorf("%w: must not match any of the last %d passwords", ErrPasswordPolicy, policy.HistoryCount)
+		}
+	}
+
 	user.Password = req.NewPassword
 	if err := user.HashPassword(); err != nil {
 		return err
 	}
+	now := time.Now()
+	user.PasswordChangedAt = &now
 
 	// Invalidate all sessions except current
+	var tokens []string
+	s.db.Model(&models.Session{}).Where("user_id = ?", userID).Pluck("token", &tokens)
+	for _, token := range tokens {
+		s.cache.Delete(sessionCacheKey(token))
+	}
 	s.db.Where("user_id = ?", userID).Delete(&models.Session{})
 
-	return s.db.Save(&user).Error
+	if err := s.db.Save(&user).Error; err != nil {
+		return err
+	}
+	s.rememberPassword(userID, previous, policy.HistoryCount)
+	return nil
+}
+
+// rememberPassword adds a replaced password hash to the user's history and
+// drops entries beyond the policy's history length
+func (s *AuthService) rememberPassword(userID uint, hash string, keep int) {
+	if keep <= 0 {
+		s.db.Where("user_id = ?", userID).Delete(&models.PasswordHistory{})
+		return
+	}
+	s.db.Create(&models.PasswordHistory{UserID: userID, Hash: hash})
+
+	var ids []uint
+	s.db.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("created_at DESC").Pluck("id", &ids)
+	if len(ids) > keep {
+		s.db.Delete(&models.PasswordHistory{}, ids[keep:])
+	}
 }
 
 // generateAuthResponse creates tokens and auth response
 func (s *AuthService) generateAuthResponse(user *models.User) (*models.AuthResponse, error) {
 	expiresAt := time.Now().Add(s.jwtExpiry)
+	if s.stateless {
+		expiresAt = time.Now().Add(s.accessExpiry)
+	}
+	// A unique ID keeps tokens issued within the same second distinct
+	tokenID, _ := newToken("")
+
+	expired := passwordExpired(s.settings.PasswordPolicy(), user)
 
 	claims := JWTClaims{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:          user.ID,
+		Email:           user.Email,
+		Username:        user.Username,
+		Role:            user.Role,
+		PasswordExpired: expired,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.Email,
+			ID:        tokenID,
 		},
 	}
 
@@ -180,8 +369,9 @@ func (s *AuthService) generateAuthResponse(user *models.User) (*models.AuthRespo
 	}
 
 	return &models.AuthResponse{
-		User:        user.ToResponse(),
-		AccessToken: tokenString,
-		ExpiresAt:   expiresAt,
+		User:            user.ToResponse(),
+		AccessToken:     tokenString,
+		ExpiresAt:       expiresAt,
+		PasswordExpired: expired,
 	}, nil
 }
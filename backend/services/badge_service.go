@@ -0,0 +1,164 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// BadgeService renders shields.io-style SVG badges for services
+type BadgeService struct {
+	db *gorm.DB
+}
+
+// Badge colors, matching the shields.io palette
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeOrange = "#fe7d37"
+	badgeRed    = "#e05d44"
+	badgeGrey   = "#9f9f9f"
+)
+
+// NewBadgeService creates a new BadgeService
+func NewBadgeService() *BadgeService {
+	return &BadgeService{db: database.GetDB()}
+}
+
+// GenerateBadgeToken returns a random token for private badge access
+func GenerateBadgeToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// loadService returns the service if the badge may be shown with the given token
+func (s *BadgeService) loadService(id uint, token string) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.First(&svc, id).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	if svc.BadgePublic {
+		return &svc, nil
+	}
+	if svc.BadgeToken == "" || subtle.ConstantTimeCompare([]byte(svc.BadgeToken), []byte(token)) != 1 {
+		// Don't reveal whether the service exists
+		return nil, fmt.Errorf("service not found")
+	}
+	return &svc, nil
+}
+
+// StatusBadge renders the latest recorded status of a service.
+// Uses stored check results so public embeds never trigger live checks.
+func (s *BadgeService) StatusBadge(id uint, token, label string) (string, error) {
+	svc, err := s.loadService(id, token)
+	if err != nil {
+		return "", err
+	}
+	if label == "" {
+		label = svc.Name
+	}
+
+	if !svc.IsActive {
+		return RenderBadge(label, "disabled", badgeGrey), nil
+	}
+
+	var status string
+	if HasRemoteLocations(*svc) || svc.SkipLocal {
+		status = computeConsensus(s.db, *svc).Status
+	} else {
+		var check models.ServiceCheck
+		if err := s.db.Where("service_id = ? AND location = ?", svc.ID, "").Order("checked_at DESC").First(&check).Error; err != nil {
+			return RenderBadge(label, "unknown", badgeGrey), nil
+		}
+		status = check.Status
+	}
+
+	switch status {
+	case "online":
+		return RenderBadge(label, "up", badgeGreen), nil
+	case "degraded":
+		return RenderBadge(label, "degraded", badgeYellow), nil
+	case "unknown":
+		return RenderBadge(label, "unknown", badgeGrey), nil
+	case "error":
+		return RenderBadge(label, "error", badgeOrange), nil
+	case "host_down":
+		return RenderBadge(label, "host down", badgeRed), nil
+	default:
+		return RenderBadge(label, "down", badgeRed), nil
+	}
+}
+
+// UptimeBadge renders the uptime percentage of a service over the window
+func (s *BadgeService) UptimeBadge(id uint, token, label string, window time.Duration) (string, error) {
+	svc, err := s.loadService(id, token)
+	if err != nil {
+		return "", err
+	}
+	if label == "" {
+		label = "uptime"
+	}
+
+	var total, online int64
+	since := time.Now().Add(-window)
+	s.db.Model(&models.ServiceCheck{}).Where("service_id = ? AND location = ? AND checked_at >= ?", svc.ID, "", since).Count(&total)
+	if total == 0 {
+		return RenderBadge(label, "unknown", badgeGrey), nil
+	}
+	s.db.Model(&models.ServiceCheck{}).Where("service_id = ? AND location = ? AND checked_at >= ? AND status = ?", svc.ID, "", since, "online").Count(&online)
+
+	percent := float64(online) / float64(total) * 100
+	color := badgeRed
+	switch {
+	case percent >= 99:
+		color = badgeGreen
+	case percent >= 95:
+		color = badgeYellow
+	case percent >= 90:
+		color = badgeOrange
+	}
+
+	return RenderBadge(label, formatPercent(percent), color), nil
+}
+
+// formatPercent formats uptime without a trailing ".00" for whole numbers
+func formatPercent(p float64) string {
+	if p == 100 {
+		return "100%"
+	}
+	return fmt.Sprintf("%.2f%%", p)
+}
+
+// badgeTextWidth approximates the rendered width of Verdana 11px text
+func badgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+// RenderBadge renders a flat two-part badge as SVG
+func RenderBadge(label, message, color string) string {
+	lw := badgeTextWidth(label)
+	mw := badgeTextWidth(message)
+	total := lw + mw
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]d" y="14">%[4]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>`, total, lw, mw, label, message, color, lw/2, lw+mw/2)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is a short-lived key/value store for values that are expensive to rebuild.
+// Values are JSON encoded so every backend behaves the same.
+type Cache interface {
+	// Get decodes the cached value into dest and reports whether it was found
+	Get(key string, dest interface{}) bool
+	Set(key string, value interface{}, ttl time.Duration)
+	Delete(keys ...string)
+	// DeletePrefix removes every key starting with prefix
+	DeletePrefix(prefix string)
+}
+
+// cacheTimeout bounds every Redis call so a slow cache never delays a request
+const cacheTimeout = 500 * time.Millisecond
+
+// NewCache creates the cache selected by CACHE_BACKEND. An unreachable Redis
+// falls back to no caching so the server still starts.
+func NewCache() Cache {
+	cfg := config.AppConfig
+
+	switch strings.ToLower(cfg.CacheBackend) {
+	case "memory":
+		return newMemoryCache()
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Printf("Warning: invalid REDIS_URL, caching disabled: %v", err)
+			return noopCache{}
+		}
+		client := redis.NewClient(opts)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			log.Printf("Warning: Redis unavailable, caching disabled: %v", err)
+			client.Close()
+			return noopCache{}
+		}
+		log.Printf("Response cache using Redis at %s", opts.Addr)
+		return &redisCache{client: client}
+	}
+	return noopCache{}
+}
+
+// noopCache never stores anything
+type noopCache struct{}
+
+func (noopCache) Get(string, interface{}) bool           { return false }
+func (noopCache) Set(string, interface{}, time.Duration) {}
+func (noopCache) Delete(...string)                       {}
+func (noopCache) DeletePrefix(string)                    {}
+
+// memoryCache keeps entries in process, for single-instance deployments
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func newMemoryCache() *memoryCache {
+	c := &memoryCache{entries: make(map[string]memoryEntry)}
+	go c.sweep()
+	return c
+}
+
+// sweep drops expired entries once a minute
+func (c *memoryCache) sweep() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		c.mu.Lock()
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *memoryCache) Get(key string, dest interface{}) bool {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || time.Now().After(entry.expires) {
+		return false
+	}
+	return json.Unmarshal(entry.data, dest) == nil
+}
+
+func (c *memoryCache) Set(key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil || ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.entries[key] = memoryEntry{data: data, expires: time.Now().Add(ttl)}
+	c.mu.Unlock()
+}
+
+func (c *memoryCache) Delete(keys ...string) {
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+}
+
+func (c *memoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// redisCache shares entries between backend instances. Errors are treated as
+// cache misses.
+type redisCache struct {
+	client *redis.Client
+}
+
+// redisKeyPrefix namespaces the backend's keys in a shared Redis
+const redisKeyPrefix = "homelab:"
+
+func (c *redisCache) Get(key string, dest interface{}) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+func (c *redisCache) Set(key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil || ttl <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	c.client.Set(ctx, redisKeyPrefix+key, data, ttl)
+}
+
+func (c *redisCache) Delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	c.client.Del(ctx, prefixed...)
+}
+
+func (c *redisCache) DeletePrefix(prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*cacheTimeout)
+	defer cancel()
+
+	iter := c.client.Scan(ctx, 0, redisKeyPrefix+prefix+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if len(keys) > 0 {
+		c.client.Del(ctx, keys...)
+	}
+}
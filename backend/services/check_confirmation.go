@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// maxCheckThreshold is the most consecutive checks a service may require
+// to confirm a status change
+const maxCheckThreshold = 20
+
+// checkStreak tracks a service's reported status and a change the latest
+// checks see but have not yet confirmed
+type checkStreak struct {
+	status  string // reported status
+	pending string // status of the latest checks, when it differs from status
+	count   int    // consecutive checks seeing pending
+}
+
+// statusDown reports whether a check status counts as the service being down
+func statusDown(status string) bool {
+	return status == "offline" || status == "error" || status == "host_down"
+}
+
+// failureThreshold returns how many consecutive failed checks mark the service down
+func failureThreshold(svc models.ServiceConfig) int {
+	if svc.FailureThreshold > 0 {
+		return svc.FailureThreshold
+	}
+	return max(config.AppConfig.CheckFailureThreshold, 1)
+}
+
+// recoveryThreshold returns how many consecutive successful checks mark the service up again
+func recoveryThreshold(svc models.ServiceConfig) int {
+	if svc.RecoveryThreshold > 0 {
+		return svc.RecoveryThreshold
+	}
+	return max(config.AppConfig.CheckRecoveryThreshold, 1)
+}
+
+// confirm holds back a change between up and down until enough consecutive
+// checks agree, so a single dropped check neither raises nor clears alerts.
+// Until then the status keeps the reported value and describes the change
+// in Pending. Changes between two down statuses pass straight through.
+func (s *ServiceConfigService) confirm(svc models.ServiceConfig, status *ServiceStatus) {
+	s.streakMu.Lock()
+	defer s.streakMu.Unlock()
+
+	streak, ok := s.streaks[svc.ID]
+	if !ok {
+		// The first check since startup is reported as is
+		s.streaks[svc.ID] = &checkStreak{status: status.Status}
+		return
+	}
+	if statusDown(status.Status) == statusDown(streak.status) {
+		*streak = checkStreak{status: status.Status}
+		return
+	}
+
+	required := recoveryThreshold(svc)
+	if statusDown(status.Status) {
+		required = failureThreshold(svc)
+	}
+	streak.pending = status.Status
+	streak.count++
+	if streak.count >= required {
+		*streak = checkStreak{status: status.Status}
+		return
+	}
+
+	status.Pending = &models.PendingStatus{Status: status.Status, Checks: streak.count, Required: required}
+	status.Status = streak.status
+}
+
+// retryDelay returns how soon to check a service again while a failure
+// awaits confirmation, doubling from minCheckInterval with each failed check
+func (s *ServiceConfigService) retryDelay(id uint) (time.Duration, bool) {
+	s.streakMu.Lock()
+	defer s.streakMu.Unlock()
+
+	streak, ok := s.streaks[id]
+	if !ok || streak.count == 0 || !statusDown(streak.pending) {
+		return 0, false
+	}
+	return minCheckInterval << min(streak.count-1, 6), true
+}
+
+// forgetStreak drops a service's confirmation state
+func (s *ServiceConfigService) forgetStreak(id uint) {
+	s.streakMu.Lock()
+	delete(s.streaks, id)
+	s.streakMu.Unlock()
+}
+
+// UpdateCheckThresholds sets how many consecutive failed checks mark a
+// service down and how many successful ones mark it up again; 0 uses the
+// CHECK_FAILURE_THRESHOLD and CHECK_RECOVERY_THRESHOLD defaults
+func (s *ServiceConfigService) UpdateCheckThresholds(id uint, userID uint, failure, recovery int) (*models.ServiceConfig, error) {
+	if failure < 0 || failure > maxCheckThreshold || recovery < 0 || recovery > maxCheckThreshold {
+		return nil, fmt.Errorf("thresholds must be between 0 and %d", maxCheckThreshold)
+	}
+
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	svc.FailureThreshold = failure
+	svc.RecoveryThreshold = recovery
+	if err := s.db.Model(&svc).Select("failure_threshold", "recovery_threshold").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+const (
+	// schedulerTick is how often the scheduler looks for due checks
+	schedulerTick = time.Second
+	// schedulerReload is how often the list of services is re-read, picking
+	// up new services and changed intervals
+	schedulerReload = 15 * time.Second
+	// minCheckInterval is the shortest interval a service is checked on
+	minCheckInterval = 10 * time.Second
+)
+
+// checkInterval returns the service's check interval, at least minCheckInterval
+func checkInterval(svc models.ServiceConfig) time.Duration {
+	interval := time.Duration(svc.CheckInterval) * time.Second
+	if interval < minCheckInterval {
+		return minCheckInterval
+	}
+	return interval
+}
+
+// StartScheduler runs active services' checks in the background on their
+// CheckInterval, using CHECK_WORKERS workers. Call it once status listeners
+// are registered.
+func (s *ServiceConfigService) StartScheduler() {
+	workers := config.AppConfig.CheckWorkers
+	if workers <= 0 {
+		return
+	}
+
+	jobs := make(chan models.ServiceConfig)
+	done := make(chan uint)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for svc := range jobs {
+				s.checkService(svc)
+				done <- svc.ID
+			}
+		}()
+	}
+	go s.schedule(jobs, done)
+}
+
+// schedule hands due services to the workers. A service is never queued
+// again while its previous check is still running.
+func (s *ServiceConfigService) schedule(jobs chan<- models.ServiceConfig, done <-chan uint) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	var services []models.ServiceConfig
+	nextRun := make(map[uint]time.Time)
+	running := make(map[uint]bool)
+	lastReload := time.Time{}
+	lastCleanup := time.Time{}
+
+	// finish frees a service for its next check, bringing the check forward
+	// while a failure awaits confirmation
+	finish := func(id uint) {
+		delete(running, id)
+		if delay, ok := s.retryDelay(id); ok {
+			if retry := time.Now().Add(delay); retry.Before(nextRun[id]) {
+				nextRun[id] = retry
+			}
+		}
+	}
+
+	for {
+		select {
+		case id := <-done:
+			finish(id)
+			continue
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		if now.Sub(lastReload) >= schedulerReload {
+			services = services[:0]
+			if err := s.db.Preload("Tags").Where("is_active = ?", true).Find(&services).Error; err != nil {
+				log.Printf("Check scheduler failed to load services: %v", err)
+			}
+			lastReload = now
+		}
+
+		// Purge check history beyond retention once a day
+		if now.Sub(lastCleanup) > 24*time.Hour {
+			s.db.Where("checked_at < ?", now.Add(-serviceCheckRetention)).Delete(&models.ServiceCheck{})
+			lastCleanup = now
+		}
+
+		for _, svc := range services {
+			if running[svc.ID] || now.Before(nextRun[svc.ID]) {
+				continue
+			}
+
+			// Hand the job over without blocking, so finished checks are still collected
+			select {
+			case jobs <- svc:
+				running[svc.ID] = true
+				nextRun[svc.ID] = now.Add(checkInterval(svc))
+			case id := <-done:
+				finish(id)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ContainerSizingService samples container usage and compares it with the
+// configured limits to help right-size compose files
+type ContainerSizingService struct {
+	db     *gorm.DB
+	docker *DockerService
+}
+
+const (
+	// containerUsageInterval is how often running containers are sampled
+	containerUsageInterval = 5 * time.Minute
+	// containerUsageRetention is how long usage samples are kept
+	containerUsageRetention = 30 * 24 * time.Hour
+	// minSizingSamples is the history needed before judging a container (one hour)
+	minSizingSamples = 12
+	// DefaultSizingDays is the history compared when no range is given
+	DefaultSizingDays = 7
+
+	sizingMinCPUs   = 0.25
+	sizingMinMemory = 32 << 20
+	sizingMemStep   = 16 << 20
+)
+
+// NewContainerSizingService creates a new ContainerSizingService and starts sampling
+func NewContainerSizingService(docker *DockerService) *ContainerSizingService {
+	s := &ContainerSizingService{
+		db:     database.GetDB(),
+		docker: docker,
+	}
+	if docker.IsConnected() {
+		go s.sampleBackground()
+	}
+	return s
+}
+
+// sampleBackground stores the usage of running containers every containerUsageInterval
+func (s *ContainerSizingService) sampleBackground() {
+	ticker := time.NewTicker(containerUsageInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for range ticker.C {
+		s.sample()
+
+		// Purge samples beyond retention once a day
+		if time.Since(lastCleanup) > 24*time.Hour {
+			s.db.Where("sampled_at < ?", time.Now().Add(-containerUsageRetention)).Delete(&models.ContainerUsage{})
+			lastCleanup = time.Now()
+		}
+	}
+}
+
+// sample records one usage sample per running container
+func (s *ContainerSizingService) sample() {
+	now := time.Now()
+	samples := make([]models.ContainerUsage, 0)
+	for _, c := range s.docker.GetContainers() {
+		if c.State != "running" {
+			continue
+		}
+		// MemoryPercent leaves out the page cache, which the kernel reclaims before an OOM kill
+		memory := c.Stats.MemoryUsage
+		if c.Stats.MemoryLimit > 0 {
+			memory = int64(c.Stats.MemoryPercent / 100 * float64(c.Stats.MemoryLimit))
+		}
+		samples = append(samples, models.ContainerUsage{
+			Container:   c.Name,
+			CPUPercent:  c.Stats.CPUPercent,
+			MemoryUsage: memory,
+			SampledAt:   now,
+		})
+	}
+	if len(samples) == 0 {
+		return
+	}
+	if err := s.db.Create(&samples).Error; err != nil {
+		log.Printf("Failed to store container usage: %v", err)
+	}
+}
+
+// Report compares every container's limits with its P95 usage over the last
+// days days, worst verdicts first
+func (s *ContainerSizingService) Report(days int) ([]models.ContainerSizing, error) {
+	if !s.docker.IsConnected() {
+		return nil, fmt.Errorf("docker not connected")
+	}
+	if days <= 0 || days > 30 {
+		days = DefaultSizingDays
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	var samples []models.ContainerUsage
+	if err := s.db.Where("sampled_at >= ?", since).Find(&samples).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]models.ContainerUsage)
+	for _, sample := range samples {
+		byName[sample.Container] = append(byName[sample.Container], sample)
+	}
+
+	var kills []models.OOMKill
+	s.db.Where("scope = ? AND killed_at >= ?", models.OOMScopeContainer, since).Find(&kills)
+	oomKills := make(map[string]int)
+	for _, kill := range kills {
+		oomKills[kill.Container]++
+	}
+
+	report := make([]models.ContainerSizing, 0)
+	for _, c := range s.docker.GetContainersBasic() {
+		resources, err := s.docker.GetResources(c.ID)
+		if err != nil {
+			continue
+		}
+		sizing := sizeContainer(resources, byName[c.Name], oomKills[c.Name])
+		sizing.Container = c.Name
+		sizing.Image = c.Image
+		sizing.State = c.State
+		report = append(report, sizing)
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if sizingRank(report[i].Status) != sizingRank(report[j].Status) {
+			return sizingRank(report[i].Status) < sizingRank(report[j].Status)
+		}
+		return report[i].Container < report[j].Container
+	})
+	return report, nil
+}
+
+// sizeContainer judges one container's CPU and memory limits against its samples
+func sizeContainer(resources models.ContainerResources, samples []models.ContainerUsage, oomKills int) models.ContainerSizing {
+	sizing := models.ContainerSizing{
+		Resources:    resources,
+		Samples:      len(samples),
+		CPUStatus:    models.SizingNoData,
+		MemoryStatus: models.SizingNoData,
+		Status:       models.SizingNoData,
+		Notes:        make([]string, 0),
+	}
+	if oomKills > 0 {
+		sizing.Notes = append(sizing.Notes, fmt.Sprintf("killed by the OOM killer %d times in this period", oomKills))
+	}
+	if len(samples) < minSizingSamples {
+		if oomKills > 0 {
+			sizing.MemoryStatus = models.SizingAtRisk
+			sizing.Status = models.SizingAtRisk
+		}
+		return sizing
+	}
+
+	cpu := make([]float64, len(samples))
+	memory := make([]float64, len(samples))
+	for i, sample := range samples {
+		cpu[i] = sample.CPUPercent / 100
+		memory[i] = float64(sample.MemoryUsage)
+	}
+	sort.Float64s(cpu)
+	sort.Float64s(memory)
+	sizing.CPUP95 = math.Round(percentile(cpu, 95)*1000) / 1000
+	sizing.CPUMax = math.Round(cpu[len(cpu)-1]*1000) / 1000
+	sizing.MemoryP95 = int64(percentile(memory, 95))
+	sizing.MemoryMax = int64(memory[len(memory)-1])
+
+	// CPU limits throttle rather than kill, so only a sustained P95 near the limit is a risk
+	limit := resources.CPULimit
+	switch {
+	case limit == 0:
+		sizing.CPUStatus = models.SizingUnbounded
+	case sizing.CPUP95 >= 0.9*limit:
+		sizing.CPUStatus = models.SizingAtRisk
+		sizing.Notes = append(sizing.Notes, fmt.Sprintf("CPU P95 %.2f of %.2f cores, likely throttled", sizing.CPUP95, limit))
+	case sizing.CPUP95 < 0.25*limit && sizing.CPUMax < 0.5*limit:
+		sizing.CPUStatus = models.SizingOverProvisioned
+	default:
+		sizing.CPUStatus = models.SizingOK
+	}
+
+	memLimit := float64(resources.MemoryLimit)
+	switch {
+	case oomKills > 0:
+		sizing.MemoryStatus = models.SizingAtRisk
+	case memLimit == 0:
+		sizing.MemoryStatus = models.SizingUnbounded
+	case float64(sizing.MemoryP95) >= 0.85*memLimit || float64(sizing.MemoryMax) >= 0.95*memLimit:
+		sizing.MemoryStatus = models.SizingAtRisk
+		sizing.Notes = append(sizing.Notes, fmt.Sprintf("memory peaked at %.0f%% of the limit", float64(sizing.MemoryMax)/memLimit*100))
+	case float64(sizing.MemoryP95) < 0.25*memLimit && float64(sizing.MemoryMax) < 0.5*memLimit:
+		sizing.MemoryStatus = models.SizingOverProvisioned
+	default:
+		sizing.MemoryStatus = models.SizingOK
+	}
+	if resources.MemoryReservation > 0 && float64(sizing.MemoryP95) < 0.5*float64(resources.MemoryReservation) {
+		sizing.Notes = append(sizing.Notes, "memory reservation is more than twice the P95 usage")
+	}
+
+	sizing.Status = sizing.CPUStatus
+	if sizingRank(sizing.MemoryStatus) < sizingRank(sizing.Status) {
+		sizing.Status = sizing.MemoryStatus
+	}
+
+	// Leave headroom above P95 and the peak, rounded to compose-friendly steps
+	sizing.SuggestedCPUs = math.Max(sizingMinCPUs, math.Ceil(sizing.CPUP95*1.5/sizingMinCPUs)*sizingMinCPUs)
+	memTarget := math.Max(float64(sizing.MemoryP95)*1.3, float64(sizing.MemoryMax)*1.1)
+	sizing.SuggestedMemory = int64(math.Max(sizingMinMemory, math.Ceil(memTarget/sizingMemStep)*sizingMemStep))
+	return sizing
+}
+
+// sizingRank orders verdicts from most to least urgent
+func sizingRank(status string) int {
+	switch status {
+	case models.SizingAtRisk:
+		return 0
+	case models.SizingOverProvisioned:
+		return 1
+	case models.SizingUnbounded:
+		return 2
+	case models.SizingOK:
+		return 3
+	}
+	return 4
+}
@@ -0,0 +1,776 @@
+package services
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// DeployService builds deploy apps from git with the Docker SDK and rolls
+// their container over to the new image
+type DeployService struct {
+	db     *gorm.DB
+	docker *DockerService
+	audit  *AuditService
+	events *EventService
+
+	mu     sync.Mutex
+	runs   map[uint]*buildRun // running builds by build ID
+	active map[uint]uint      // running build ID by app ID
+}
+
+// buildRun is the live log of a running build and its followers
+type buildRun struct {
+	mu   sync.Mutex
+	log  strings.Builder
+	subs map[chan string]bool
+}
+
+const (
+	// deployBuildTimeout bounds a whole build, from clone to rollout
+	deployBuildTimeout = 30 * time.Minute
+	// maxBuildLog is how much of a build's log is stored
+	maxBuildLog = 60 << 10
+	// maxLiveBuildLog is how much of a running build's log is kept for new followers
+	maxLiveBuildLog = 512 << 10
+	// deployAppLabel marks containers and images built by a deploy app
+	deployAppLabel = "homelab.deploy.app"
+)
+
+// containerNamePattern is the name Docker accepts for a container
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ErrBuildRunning is returned when an app already has a build in progress
+var ErrBuildRunning = fmt.Errorf("a build is already running for this app")
+
+// ErrWebhookUnauthorized is returned for webhooks without a valid token or signature
+var ErrWebhookUnauthorized = fmt.Errorf("invalid webhook token or signature")
+
+// NewDeployService creates a new DeployService
+func NewDeployService(docker *DockerService, audit *AuditService, events *EventService) *DeployService {
+	s := &DeployService{
+		db:     database.GetDB(),
+		docker: docker,
+		audit:  audit,
+		events: events,
+		runs:   make(map[uint]*buildRun),
+		active: make(map[uint]uint),
+	}
+
+	// Builds cut short by a restart will never finish
+	now := time.Now()
+	s.db.Model(&models.DeployBuild{}).Where("status = ?", models.BuildRunning).Updates(map[string]interface{}{
+		"status":      models.BuildFailed,
+		"error":       "interrupted by a backend restart",
+		"finished_at": now,
+	})
+	s.db.Model(&models.DeployApp{}).Where("last_status = ?", models.BuildRunning).Update("last_status", models.BuildFailed)
+	return s
+}
+
+// decodeApps fills the ports and env of apps loaded from the database
+func decodeApps(apps ...*models.DeployApp) {
+	for _, app := range apps {
+		app.Ports = []string{}
+		app.Env = []string{}
+		if app.RawPorts != "" {
+			json.Unmarshal([]byte(app.RawPorts), &app.Ports)
+		}
+		if app.RawEnv != "" {
+			json.Unmarshal([]byte(app.RawEnv), &app.Env)
+		}
+	}
+}
+
+// applyAppRequest copies a validated request onto an app
+func applyAppRequest(app *models.DeployApp, req models.DeployAppRequest) {
+	app.Name = req.Name
+	app.RepoURL = req.RepoURL
+	app.Branch = req.Branch
+	app.Dockerfile = req.Dockerfile
+	app.ContextDir = req.ContextDir
+	app.Image = req.Image
+	app.Container = req.Container
+	app.Ports = req.Ports
+	app.Env = req.Env
+
+	ports, _ := json.Marshal(app.Ports)
+	env, _ := json.Marshal(app.Env)
+	app.RawPorts = string(ports)
+	app.RawEnv = string(env)
+}
+
+// validate checks an app request and fills in defaults
+func (s *DeployService) validate(req *models.DeployAppRequest) error {
+	u, err := url.Parse(req.RepoURL)
+	switch {
+	case strings.HasPrefix(req.RepoURL, "git@"):
+	case err != nil || u.Host == "":
+		return fmt.Errorf("invalid repository URL")
+	case u.Scheme != "https" && u.Scheme != "http" && u.Scheme != "ssh":
+		return fmt.Errorf("unsupported repository URL scheme %q (use https or ssh)", u.Scheme)
+	}
+
+	if req.Branch == "" {
+		req.Branch = "main"
+	}
+	if strings.HasPrefix(req.Branch, "-") {
+		return fmt.Errorf("invalid branch")
+	}
+	if req.Dockerfile == "" {
+		req.Dockerfile = "Dockerfile"
+	}
+	for _, path := range []string{req.Dockerfile, req.ContextDir} {
+		if filepath.IsAbs(path) || strings.Contains(path, "..") {
+			return fmt.Errorf("paths must be relative to the repository")
+		}
+	}
+
+	if !containerNamePattern.MatchString(req.Container) {
+		return fmt.Errorf("invalid container name")
+	}
+	if req.Image == "" {
+		req.Image = "homelab-deploy/" + strings.ToLower(req.Container) + ":latest"
+	}
+	if _, _, err := nat.ParsePortSpecs(req.Ports); err != nil {
+		return fmt.Errorf("invalid port mapping: %v", err)
+	}
+	for _, kv := range req.Env {
+		if !strings.Contains(kv, "=") {
+			return fmt.Errorf("environment variables must be KEY=value")
+		}
+	}
+	return nil
+}
+
+// ListApps returns the user's deploy apps
+func (s *DeployService) ListApps(userID uint) ([]models.DeployApp, error) {
+	var apps []models.DeployApp
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&apps).Error; err != nil {
+		return nil, err
+	}
+	for i := range apps {
+		decodeApps(&apps[i])
+	}
+	return apps, nil
+}
+
+// GetApp returns one of the user's deploy apps
+func (s *DeployService) GetApp(id uint, userID uint) (*models.DeployApp, error) {
+	var app models.DeployApp
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&app).Error; err != nil {
+		return nil, fmt.Errorf("deploy app not found")
+	}
+	decodeApps(&app)
+	return &app, nil
+}
+
+// CreateApp adds a deploy app and returns it with its webhook secret, which
+// is only shown here and when rotated
+func (s *DeployService) CreateApp(userID uint, req models.DeployAppRequest) (*models.DeployApp, string, error) {
+	if err := s.validate(&req); err != nil {
+		return nil, "", err
+	}
+
+	secret := GenerateBadgeToken()
+	encrypted, err := EncryptSecret(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	app := models.DeployApp{UserID: userID, WebhookSecret: encrypted}
+	applyAppRequest(&app, req)
+	if err := s.db.Create(&app).Error; err != nil {
+		return nil, "", err
+	}
+	return &app, secret, nil
+}
+
+// UpdateApp replaces a deploy app's settings
+func (s *DeployService) UpdateApp(id uint, userID uint, req models.DeployAppRequest) (*models.DeployApp, error) {
+	app, err := s.GetApp(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(&req); err != nil {
+		return nil, err
+	}
+
+	applyAppRequest(app, req)
+	if err := s.db.Save(app).Error; err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// RotateWebhookSecret replaces an app's webhook secret and returns the new one
+func (s *DeployService) RotateWebhookSecret(id uint, userID uint) (string, error) {
+	app, err := s.GetApp(id, userID)
+	if err != nil {
+		return "", err
+	}
+
+	secret := GenerateBadgeToken()
+	encrypted, err := EncryptSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.Model(app).Update("webhook_secret", encrypted).Error; err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// DeleteApp removes a deploy app and its build history. The container and
+// image are left in place.
+func (s *DeployService) DeleteApp(id uint, userID uint) error {
+	s.mu.Lock()
+	_, running := s.active[id]
+	s.mu.Unlock()
+	if running {
+		return ErrBuildRunning
+	}
+
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.DeployApp{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("deploy app not found")
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return s.db.Where("app_id = ?", id).Delete(&models.DeployBuild{}).Error
+}
+
+// ListBuilds returns an app's builds, newest first, without their logs
+func (s *DeployService) ListBuilds(appID uint, userID uint) ([]models.DeployBuild, error) {
+	if _, err := s.GetApp(appID, userID); err != nil {
+		return nil, err
+	}
+
+	builds := make([]models.DeployBuild, 0)
+	if err := s.db.Omit("log").Where("app_id = ?", appID).Order("id DESC").Limit(50).Find(&builds).Error; err != nil {
+		return nil, err
+	}
+	return builds, nil
+}
+
+// GetBuild returns a build with its log, if it belongs to one of the user's apps
+func (s *DeployService) GetBuild(id uint, userID uint) (*models.DeployBuild, error) {
+	var build models.DeployBuild
+	if err := s.db.Joins("JOIN deploy_apps ON deploy_apps.id = deploy_builds.app_id").
+		Where("deploy_builds.id = ? AND deploy_apps.user_id = ?", id, userID).
+		First(&build).Error; err != nil {
+		return nil, fmt.Errorf("build not found")
+	}
+
+	// A running build's log only lives in memory until it finishes
+	s.mu.Lock()
+	run := s.runs[build.ID]
+	s.mu.Unlock()
+	if run != nil {
+		run.mu.Lock()
+		build.Log = run.log.String()
+		run.mu.Unlock()
+	}
+	return &build, nil
+}
+
+// Build starts a build of one of the user's apps
+func (s *DeployService) Build(appID uint, userID uint) (*models.DeployBuild, error) {
+	app, err := s.GetApp(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	build, err := s.start(app, models.BuildTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(userID, fmt.Sprintf("user:%d", userID), "deploy.build", "deploy_app", strconv.FormatUint(uint64(app.ID), 10), true,
+		map[string]interface{}{"buildId": build.ID, "app": app.Name})
+	return build, nil
+}
+
+// Webhook starts a build when a push webhook carries the app's token or a
+// valid signature. Pushes to other branches return a nil build.
+// GitHub and Gitea sign the body with HMAC-SHA256; GitLab sends the token.
+func (s *DeployService) Webhook(appID uint, token, signature string, body []byte) (*models.DeployBuild, error) {
+	var app models.DeployApp
+	if err := s.db.First(&app, appID).Error; err != nil {
+		return nil, ErrWebhookUnauthorized
+	}
+	decodeApps(&app)
+
+	secret, err := DecryptSecret(app.WebhookSecret)
+	if err != nil || secret == "" || !webhookAuthorized(secret, token, signature, body) {
+		return nil, ErrWebhookUnauthorized
+	}
+
+	var push struct {
+		Ref string `json:"ref"`
+	}
+	if json.Unmarshal(body, &push) == nil && push.Ref != "" && push.Ref != "refs/heads/"+app.Branch {
+		return nil, nil
+	}
+
+	build, err := s.start(&app, models.BuildTriggerWebhook)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(app.UserID, "webhook", "deploy.build", "deploy_app", strconv.FormatUint(uint64(app.ID), 10), true,
+		map[string]interface{}{"buildId": build.ID, "app": app.Name, "ref": push.Ref})
+	return build, nil
+}
+
+// webhookAuthorized checks a plain token or a hex HMAC-SHA256 of the body
+func webhookAuthorized(secret, token, signature string, body []byte) bool {
+	if token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	if signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(strings.TrimPrefix(signature, "sha256=")), []byte(expected))
+}
+
+// start records a new build and runs it in the background
+func (s *DeployService) start(app *models.DeployApp, trigger string) (*models.DeployBuild, error) {
+	if !s.docker.IsConnected() {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	s.mu.Lock()
+	if _, running := s.active[app.ID]; running {
+		s.mu.Unlock()
+		return nil, ErrBuildRunning
+	}
+
+	build := models.DeployBuild{
+		AppID:     app.ID,
+		Trigger:   trigger,
+		Status:    models.BuildRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.db.Create(&build).Error; err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	run := &buildRun{subs: make(map[chan string]bool)}
+	s.runs[build.ID] = run
+	s.active[app.ID] = build.ID
+	s.mu.Unlock()
+
+	s.db.Model(app).Updates(map[string]interface{}{
+		"last_build_id": build.ID,
+		"last_status":   models.BuildRunning,
+		"last_build_at": build.StartedAt,
+	})
+
+	go s.run(*app, build, run)
+	return &build, nil
+}
+
+// Follow returns a running build's log so far and a channel of the lines that
+// follow, closed when the build ends. ok is false when the build is not running.
+func (s *DeployService) Follow(buildID uint) (backlog string, lines <-chan string, stop func(), ok bool) {
+	s.mu.Lock()
+	run := s.runs[buildID]
+	s.mu.Unlock()
+	if run == nil {
+		return "", nil, nil, false
+	}
+
+	ch := make(chan string, 256)
+	run.mu.Lock()
+	backlog = run.log.String()
+	run.subs[ch] = true
+	run.mu.Unlock()
+
+	stop = func() {
+		run.mu.Lock()
+		if run.subs[ch] {
+			delete(run.subs, ch)
+			close(ch)
+		}
+		run.mu.Unlock()
+	}
+	return backlog, ch, stop, true
+}
+
+// logf appends a line to a running build's log and passes it to followers
+func (r *buildRun) logf(format string, args ...interface{}) {
+	line := strings.TrimRight(fmt.Sprintf(format, args...), "\n") + "\n"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log.WriteString(line)
+	if r.log.Len() > maxLiveBuildLog {
+		tail := r.log.String()[r.log.Len()-maxLiveBuildLog/2:]
+		r.log.Reset()
+		r.log.WriteString(tail)
+	}
+	for ch := range r.subs {
+		select {
+		case ch <- line:
+		default:
+			// Drop followers that cannot keep up rather than stall the build
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// run clones, builds and rolls out an app, then records the outcome
+func (s *DeployService) run(app models.DeployApp, build models.DeployBuild, run *buildRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), deployBuildTimeout)
+	defer cancel()
+
+	commit, imageID, err := s.pipeline(ctx, app, run)
+	if err != nil {
+		run.logf("ERROR: %v", err)
+	} else {
+		run.logf("Deployed %s (%s) to container %s", app.Image, shortID(commit), app.Container)
+	}
+
+	run.mu.Lock()
+	logText := run.log.String()
+	run.mu.Unlock()
+	if len(logText) > maxBuildLog {
+		logText = logText[len(logText)-maxBuildLog:]
+	}
+
+	now := time.Now()
+	status := models.BuildSucceeded
+	errText := ""
+	if err != nil {
+		status = models.BuildFailed
+		errText = err.Error()
+		if len(errText) > 1000 {
+			errText = errText[:1000]
+		}
+	}
+	s.db.Model(&build).Updates(map[string]interface{}{
+		"status":      status,
+		"commit":      commit,
+		"image_id":    imageID,
+		"error":       errText,
+		"log":         logText,
+		"finished_at": now,
+	})
+	s.db.Model(&models.DeployApp{}).Where("id = ?", app.ID).Update("last_status", status)
+
+	s.mu.Lock()
+	delete(s.runs, build.ID)
+	delete(s.active, app.ID)
+	s.mu.Unlock()
+
+	// Followers read the outcome from the database once their channel closes
+	run.mu.Lock()
+	for ch := range run.subs {
+		close(ch)
+	}
+	run.subs = map[chan string]bool{}
+	run.mu.Unlock()
+
+	eventType := "deploy_succeeded"
+	severity := models.SeverityInfo
+	title := "Deploy succeeded"
+	message := fmt.Sprintf("%s deployed %s", app.Name, shortID(commit))
+	if err != nil {
+		eventType = "deploy_failed"
+		severity = models.SeverityWarning
+		title = "Deploy failed"
+		message = fmt.Sprintf("%s: %s", app.Name, errText)
+	}
+	s.events.RecordFor(app.UserID, eventType, severity, "deploy", title, message,
+		map[string]interface{}{"appId": app.ID, "buildId": build.ID, "trigger": build.Trigger, "commit": commit})
+}
+
+// pipeline runs the build steps and returns the commit and image built
+func (s *DeployService) pipeline(ctx context.Context, app models.DeployApp, run *buildRun) (string, string, error) {
+	dir, err := os.MkdirTemp("", "homelab-deploy-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(dir)
+
+	repo := redactURL(app.RepoURL)
+	run.logf("Cloning %s (%s)", repo, app.Branch)
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--single-branch", "--branch", app.Branch, "--", app.RepoURL, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if out := strings.TrimSpace(strings.ReplaceAll(string(output), app.RepoURL, repo)); out != "" {
+		run.logf("%s", out)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("git clone failed: %v", err)
+	}
+
+	rev, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("git rev-parse failed: %v", err)
+	}
+	commit := strings.TrimSpace(string(rev))
+	run.logf("Checked out %s", commit)
+
+	imageID, err := s.buildImage(ctx, app, filepath.Join(dir, app.ContextDir), commit, run)
+	if err != nil {
+		return commit, "", err
+	}
+	if err := s.rollContainer(ctx, app, run); err != nil {
+		return commit, imageID, err
+	}
+	return commit, imageID, nil
+}
+
+// buildImage builds the app's Dockerfile from the context directory
+func (s *DeployService) buildImage(ctx context.Context, app models.DeployApp, contextDir, commit string, run *buildRun) (string, error) {
+	if _, err := os.Stat(filepath.Join(contextDir, app.Dockerfile)); err != nil {
+		return "", fmt.Errorf("dockerfile %s not found in the repository", app.Dockerfile)
+	}
+
+	// Also tag the commit so earlier builds can be rolled back to by hand
+	repo := app.Image
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	tags := []string{app.Image, repo + ":" + shortID(commit)}
+	run.logf("Building %s", strings.Join(tags, ", "))
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(tarDirectory(contextDir, writer))
+	}()
+	defer reader.Close()
+
+	resp, err := s.docker.client.ImageBuild(ctx, reader, types.ImageBuildOptions{
+		Tags:        tags,
+		Dockerfile:  filepath.ToSlash(app.Dockerfile),
+		Remove:      true,
+		ForceRemove: true,
+		PullParent:  true,
+		Labels:      map[string]string{deployAppLabel: app.Name, "org.opencontainers.image.revision": commit},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var imageID string
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Stream   string `json:"stream"`
+			Status   string `json:"status"`
+			Progress string `json:"progress"`
+			Error    string `json:"error"`
+			Aux      struct {
+				ID string `json:"ID"`
+			} `json:"aux"`
+		}
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		switch {
+		case msg.Error != "":
+			return "", fmt.Errorf("build failed: %s", msg.Error)
+		case msg.Stream != "":
+			run.logf("%s", msg.Stream)
+		case msg.Status != "" && msg.Progress == "":
+			// Pull progress bars are left out
+			run.logf("%s", msg.Status)
+		}
+		if msg.Aux.ID != "" {
+			imageID = msg.Aux.ID
+		}
+	}
+	return imageID, nil
+}
+
+// tarDirectory writes dir as a tar archive, leaving out .git
+func tarDirectory(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// rollContainer replaces the app's container with one running the new image,
+// keeping the old container's settings. The old container is stopped first
+// so ports can be rebound, and restored if the new one fails to start.
+// Anonymous volumes are not carried over; use named volumes or bind mounts.
+func (s *DeployService) rollContainer(ctx context.Context, app models.DeployApp, run *buildRun) error {
+	cli := s.docker.client
+	exposed, bindings, err := nat.ParsePortSpecs(app.Ports)
+	if err != nil {
+		return err
+	}
+
+	old, err := cli.ContainerInspect(ctx, app.Container)
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			return err
+		}
+		run.logf("Creating container %s", app.Container)
+		created, err := cli.ContainerCreate(ctx,
+			&container.Config{Image: app.Image, Env: app.Env, ExposedPorts: exposed, Labels: map[string]string{deployAppLabel: app.Name}},
+			&container.HostConfig{PortBindings: bindings, RestartPolicy: container.RestartPolicy{Name: "unless-stopped"}},
+			nil, nil, app.Container)
+		if err != nil {
+			return err
+		}
+		return cli.ContainerStart(ctx, created.ID, container.StartOptions{})
+	}
+
+	config := old.Config
+	config.Image = app.Image
+	if len(old.ID) >= 12 && config.Hostname == old.ID[:12] {
+		config.Hostname = ""
+	}
+	if len(app.Env) > 0 {
+		config.Env = app.Env
+	}
+	hostConfig := old.HostConfig
+	if len(app.Ports) > 0 {
+		config.ExposedPorts = exposed
+		hostConfig.PortBindings = bindings
+	}
+
+	// Older daemons accept a single network at creation; the rest are connected after
+	var primary *network.NetworkingConfig
+	extra := make(map[string]*network.EndpointSettings)
+	if old.NetworkSettings != nil {
+		for name, ep := range old.NetworkSettings.Networks {
+			settings := &network.EndpointSettings{Aliases: ep.Aliases, IPAMConfig: ep.IPAMConfig, Links: ep.Links}
+			if primary == nil && (name == string(hostConfig.NetworkMode) || len(old.NetworkSettings.Networks) == 1) {
+				primary = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{name: settings}}
+				continue
+			}
+			extra[name] = settings
+		}
+	}
+
+	previous := app.Container + "-previous"
+	cli.ContainerRemove(ctx, previous, container.RemoveOptions{Force: true})
+	if err := cli.ContainerRename(ctx, old.ID, previous); err != nil {
+		return fmt.Errorf("rename old container: %v", err)
+	}
+	wasRunning := old.State != nil && old.State.Running
+	if wasRunning {
+		run.logf("Stopping old container")
+		timeout := 10
+		cli.ContainerStop(ctx, old.ID, container.StopOptions{Timeout: &timeout})
+	}
+
+	restore := func(cause error) error {
+		run.logf("Rolling back to the previous container")
+		cli.ContainerRemove(ctx, app.Container, container.RemoveOptions{Force: true})
+		if err := cli.ContainerRename(ctx, old.ID, app.Container); err != nil {
+			log.Printf("Failed to restore container %s: %v", app.Container, err)
+		}
+		if wasRunning {
+			cli.ContainerStart(ctx, old.ID, container.StartOptions{})
+		}
+		return cause
+	}
+
+	run.logf("Starting new container %s", app.Container)
+	created, err := cli.ContainerCreate(ctx, config, hostConfig, primary, nil, app.Container)
+	if err != nil {
+		return restore(err)
+	}
+	for name, settings := range extra {
+		if err := cli.NetworkConnect(ctx, name, created.ID, settings); err != nil {
+			return restore(fmt.Errorf("connect network %s: %v", name, err))
+		}
+	}
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		return restore(err)
+	}
+
+	if err := cli.ContainerRemove(ctx, old.ID, container.RemoveOptions{Force: true}); err != nil {
+		run.logf("Could not remove the old container: %v", err)
+	}
+	return nil
+}
+
+// redactURL hides credentials in a repository URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	// Tokens are often passed as the user name, so hide both parts
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://***@", 1)
+}
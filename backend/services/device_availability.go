@@ -0,0 +1,300 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+const (
+	// devicePingRetention is how long ping and status history is kept
+	devicePingRetention = 90 * 24 * time.Hour
+	// availabilityMaxGap caps how long a ping result is assumed to hold;
+	// longer gaps in the history count as unobserved rather than up or down
+	availabilityMaxGap = 5 * time.Minute
+	// MaxAvailabilityDays is the longest availability calendar returned
+	MaxAvailabilityDays = 90
+)
+
+// availabilityWindows are the trailing windows summarized for every device
+var availabilityWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// recordPing stores a ping result for availability history
+func (s *DeviceService) recordPing(deviceID uint, online bool) {
+	if err := s.db.Create(&models.DevicePing{DeviceID: deviceID, Online: online, CheckedAt: time.Now()}).Error; err != nil {
+		log.Printf("Failed to store ping for device %d: %v", deviceID, err)
+	}
+}
+
+// recordStatusChange stores a device's online/offline transitions. The last
+// known state is cached, falling back to the latest stored change.
+func (s *DeviceService) recordStatusChange(deviceID uint, online bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	last, ok := s.lastStatus[deviceID]
+	if !ok {
+		var change models.DeviceStatusChange
+		if err := s.db.Where("device_id = ?", deviceID).Order("changed_at DESC").First(&change).Error; err == nil {
+			last, ok = change.Online, true
+		}
+	}
+	if !ok || last != online {
+		change := models.DeviceStatusChange{DeviceID: deviceID, Online: online, ChangedAt: time.Now()}
+		if err := s.db.Create(&change).Error; err != nil {
+			log.Printf("Failed to store status change for device %d: %v", deviceID, err)
+			return
+		}
+	}
+	s.lastStatus[deviceID] = online
+}
+
+// GetStatusHistory returns a device's online/offline changes over the last
+// days days, newest first
+func (s *DeviceService) GetStatusHistory(id uint, userID uint, days int) ([]models.DeviceStatusChange, error) {
+	device, err := s.GetDevice(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 || days > MaxAvailabilityDays {
+		days = 7
+	}
+
+	var changes []models.DeviceStatusChange
+	if err := s.db.Where("device_id = ? AND changed_at >= ?", device.ID, time.Now().AddDate(0, 0, -days)).
+		Order("changed_at DESC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	for i := range changes {
+		changes[i].Duration = int64(end.Sub(changes[i].ChangedAt).Seconds())
+		end = changes[i].ChangedAt
+	}
+	return changes, nil
+}
+
+// pingBackground pings every active device on DEVICE_PING_INTERVAL so
+// availability history and status listeners do not depend on someone
+// viewing the dashboard
+func (s *DeviceService) pingBackground() {
+	ticker := time.NewTicker(time.Duration(config.AppConfig.DevicePingInterval) * time.Second)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for range ticker.C {
+		var ids []uint
+		s.db.Model(&models.Device{}).Where("is_active = ?", true).Pluck("id", &ids)
+
+		var wg sync.WaitGroup
+		for _, id := range ids {
+			wg.Add(1)
+			go func(deviceID uint) {
+				defer wg.Done()
+				s.HostOnline(deviceID)
+			}(id)
+		}
+		wg.Wait()
+
+		// Purge history beyond retention once a day
+		if time.Since(lastCleanup) > 24*time.Hour {
+			s.db.Where("checked_at < ?", time.Now().Add(-devicePingRetention)).Delete(&models.DevicePing{})
+			s.db.Where("changed_at < ?", time.Now().Add(-devicePingRetention)).Delete(&models.DeviceStatusChange{})
+			lastCleanup = time.Now()
+		}
+	}
+}
+
+// availabilitySpan is a stretch of time with a known device state
+type availabilitySpan struct {
+	from, to time.Time
+	online   bool
+}
+
+// GetAvailability returns availability for the trailing windows plus a daily
+// calendar covering the last days days
+func (s *DeviceService) GetAvailability(id uint, userID uint, days int) (*models.DeviceAvailability, error) {
+	device, err := s.GetDevice(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 || days > MaxAvailabilityDays {
+		days = 30
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	calendarStart := today.AddDate(0, 0, -(days - 1))
+	from := now.Add(-availabilityWindows[len(availabilityWindows)-1].length)
+	if calendarStart.Before(from) {
+		from = calendarStart
+	}
+
+	spans, err := s.availabilitySpans(device.ID, from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DeviceAvailability{DeviceID: device.ID}
+	for _, w := range availabilityWindows {
+		start := now.Add(-w.length)
+		up, down, outages := summarizeSpans(spans, start, now)
+		result.Windows = append(result.Windows, models.AvailabilityWindow{
+			Window:       w.name,
+			Availability: availabilityPercent(up, down),
+			Downtime:     int64(down.Seconds()),
+			Outages:      outages,
+			Coverage:     roundPercent(float64(up+down) / float64(w.length) * 100),
+		})
+	}
+
+	for day := calendarStart; !day.After(today); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+		up, down, outages := summarizeSpans(spans, day, end)
+		result.Calendar = append(result.Calendar, models.AvailabilityDay{
+			Date:         day.Format("2006-01-02"),
+			Availability: availabilityPercent(up, down),
+			Downtime:     int64(down.Seconds()),
+			Outages:      outages,
+		})
+	}
+
+	var notes []models.Note
+	s.db.Where("device_id = ? AND occurred_at >= ?", device.ID, from).Order("occurred_at ASC").Find(&notes)
+	result.Annotations = toAnnotations(notes)
+
+	return result, nil
+}
+
+// availabilitySpans turns the device's ping history into merged spans of known state
+func (s *DeviceService) availabilitySpans(deviceID uint, from, to time.Time) ([]availabilitySpan, error) {
+	var pings []models.DevicePing
+	if err := s.db.Select("online", "checked_at").
+		Where("device_id = ? AND checked_at >= ? AND checked_at <= ?", deviceID, from.Add(-availabilityMaxGap), to).
+		Order("checked_at ASC").Find(&pings).Error; err != nil {
+		return nil, err
+	}
+
+	var spans []availabilitySpan
+	for i, ping := range pings {
+		end := to
+		if i+1 < len(pings) {
+			end = pings[i+1].CheckedAt
+		}
+		if end.Sub(ping.CheckedAt) > availabilityMaxGap {
+			end = ping.CheckedAt.Add(availabilityMaxGap)
+		}
+
+		if n := len(spans); n > 0 && spans[n-1].online == ping.Online && spans[n-1].to.Equal(ping.CheckedAt) {
+			spans[n-1].to = end
+			continue
+		}
+		spans = append(spans, availabilitySpan{from: ping.CheckedAt, to: end, online: ping.Online})
+	}
+	return spans, nil
+}
+
+// summarizeSpans returns the observed up and down time between from and to and
+// the number of outages overlapping it
+func summarizeSpans(spans []availabilitySpan, from, to time.Time) (up, down time.Duration, outages int) {
+	for _, span := range spans {
+		start, end := span.from, span.to
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		if span.online {
+			up += end.Sub(start)
+		} else {
+			down += end.Sub(start)
+			outages++
+		}
+	}
+	return up, down, outages
+}
+
+// availabilityPercent returns the online share of observed time, or nil without observations
+func availabilityPercent(up, down time.Duration) *float64 {
+	if up+down == 0 {
+		return nil
+	}
+	percent := roundPercent(float64(up) / float64(up+down) * 100)
+	return &percent
+}
+
+// roundPercent rounds to two decimals
+func roundPercent(p float64) float64 {
+	return math.Round(p*100) / 100
+}
+
+// StaleDevices returns active devices not seen for more than days days, longest
+// unseen first. userID 0 covers every user. Devices never seen count from creation.
+func (s *DeviceService) StaleDevices(userID uint, days int) ([]models.StaleDevice, error) {
+	if days <= 0 {
+		days = config.AppConfig.StaleDeviceDays
+	}
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+
+	query := s.db.Where("is_active = ?", true).
+		Where("(last_seen < ?) OR (last_seen IS NULL AND created_at < ?)", cutoff, cutoff)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var devices []models.Device
+	if err := query.Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	stale := make([]models.StaleDevice, 0, len(devices))
+	for _, device := range devices {
+		since := device.CreatedAt
+		if device.LastSeen != nil {
+			since = *device.LastSeen
+		}
+		stale = append(stale, models.StaleDevice{
+			ID:         device.ID,
+			UserID:     device.UserID,
+			Name:       device.Name,
+			IP:         device.IP,
+			Type:       device.Type,
+			LastSeen:   device.LastSeen,
+			DaysUnseen: int(now.Sub(since).Hours() / 24),
+			NeverSeen:  device.LastSeen == nil,
+		})
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].DaysUnseen > stale[j].DaysUnseen })
+	return stale, nil
+}
+
+// ArchiveDevices deactivates the given devices and marks them archived
+func (s *DeviceService) ArchiveDevices(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.db.Model(&models.Device{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"is_active":   false,
+		"archived_at": time.Now(),
+	}).Error
+}
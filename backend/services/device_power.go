@@ -0,0 +1,486 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"github.com/masterzen/winrm"
+	"golang.org/x/crypto/ssh"
+)
+
+// Power action errors, kept apart so a wrong password is not reported as an
+// unreachable host
+var (
+	ErrHostUnreachable  = errors.New("host unreachable")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// ErrInvalidPowerSettings is returned for an unknown power method or WinRM auth
+var ErrInvalidPowerSettings = errors.New("invalid power settings")
+
+// Power methods; an empty method picks one from the open ports
+const (
+	PowerMethodSSH   = "ssh"
+	PowerMethodWinRM = "winrm"
+)
+
+// Operating systems a device's default SSH power commands are chosen for;
+// an empty OS is treated as Linux
+const (
+	PowerOSLinux   = "linux"
+	PowerOSWindows = "windows"
+)
+
+// WinRM authentication schemes. Basic is only accepted by Windows over HTTPS
+// unless AllowUnencrypted is set on the WinRM service.
+const (
+	WinRMAuthNTLM  = "ntlm"
+	WinRMAuthBasic = "basic"
+)
+
+// Power action timeouts
+const (
+	sshConnectTimeout = 10 * time.Second
+	powerTimeout      = 15 * time.Second
+)
+
+// sudoDenied are the sudo messages meaning the password was wrong or the user
+// may not run the command
+var sudoDenied = []string{
+	"incorrect password",
+	"sorry, try again",
+	"a password is required",
+	"a terminal is required",
+	"no tty present",
+	"not in the sudoers",
+	"is not allowed to execute",
+}
+
+// ErrInvalidSSHCredentials is returned for a device SSH key that cannot be used
+var ErrInvalidSSHCredentials = errors.New("invalid SSH credentials")
+
+// hasSSHCredentials reports whether a device has an SSH user with a password
+// or private key
+func hasSSHCredentials(device models.Device) bool {
+	return device.SSHUser != "" && (device.SSHPassword != "" || device.SSHKey != "" || device.SSHKeyPath != "")
+}
+
+// serverKeyPath resolves a device's key file name inside SSH_KEY_DIR
+func serverKeyPath(name string) (string, error) {
+	dir := config.AppConfig.SSHKeyDir
+	if dir == "" {
+		return "", fmt.Errorf("%w: server-side SSH keys are disabled, set SSH_KEY_DIR", ErrInvalidSSHCredentials)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Clean(name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: SSH key file must be inside SSH_KEY_DIR", ErrInvalidSSHCredentials)
+	}
+	return path, nil
+}
+
+// deviceSSHSigner returns the signer of a device's stored or server-side
+// private key, or nil when it has neither
+func deviceSSHSigner(device models.Device) (ssh.Signer, error) {
+	var pem []byte
+	switch {
+	case device.SSHKey != "":
+		key, err := DecryptSecret(device.SSHKey)
+		if err != nil {
+			return nil, err
+		}
+		pem = []byte(key)
+	case device.SSHKeyPath != "":
+		path, err := serverKeyPath(device.SSHKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		if pem, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("%w: cannot read SSH key file %s", ErrInvalidSSHCredentials, device.SSHKeyPath)
+		}
+	default:
+		return nil, nil
+	}
+
+	passphrase, err := DecryptSecret(device.SSHKeyPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pem, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(pem)
+	}
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		return nil, fmt.Errorf("%w: the SSH private key is encrypted, set its passphrase", ErrInvalidSSHCredentials)
+	case err != nil:
+		return nil, fmt.Errorf("%w: invalid SSH private key: %v", ErrInvalidSSHCredentials, err)
+	}
+	return signer, nil
+}
+
+// validateSSHCredentials checks that a device's private key can be loaded,
+// so a bad key is reported when it is saved rather than at shutdown
+func validateSSHCredentials(device models.Device) error {
+	if device.SSHKey != "" && device.SSHKeyPath != "" {
+		return fmt.Errorf("%w: give a private key or a key file, not both", ErrInvalidSSHCredentials)
+	}
+	if device.CollectMetrics {
+		if !hasSSHCredentials(device) {
+			return fmt.Errorf("%w: collecting metrics needs an SSH user with a password or key", ErrInvalidSSHCredentials)
+		}
+		if device.PowerOS == PowerOSWindows {
+			return fmt.Errorf("%w: metrics are collected from Linux hosts only", ErrInvalidSSHCredentials)
+		}
+	}
+	_, err := deviceSSHSigner(device)
+	return err
+}
+
+// dialDeviceSSH opens an SSH connection with the device's private key and
+// password, trying the key first. The host key is pinned on the first
+// connection and must match on every later one.
+func dialDeviceSSH(device models.Device) (*ssh.Client, error) {
+	port := device.SSHPort
+	if port == 0 {
+		port = 22
+	}
+
+	var auth []ssh.AuthMethod
+	signer, err := deviceSSHSigner(device)
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	password, err := DecryptSecret(device.SSHPassword)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" {
+		auth = append(auth,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+	pin, err := newHostKeyPin(device.SSHHostKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &ssh.ClientConfig{
+		User:            device.SSHUser,
+		Auth:            auth,
+		HostKeyCallback: pin.callback,
+		Timeout:         sshConnectTimeout,
+	}
+
+	client, err := ssh.Dial("tcp", net.JoinHostPort(device.IP, strconv.Itoa(port)), cfg)
+	if err != nil {
+		var netErr net.Error
+		switch {
+		case errors.Is(err, ErrHostKeyMismatch):
+			return nil, err
+		case strings.Contains(err.Error(), "unable to authenticate"):
+			return nil, fmt.Errorf("%w: SSH login as %s was rejected", ErrPermissionDenied, device.SSHUser)
+		case errors.As(err, &netErr):
+			return nil, fmt.Errorf("%w: %v", ErrHostUnreachable, err)
+		}
+		return nil, fmt.Errorf("SSH connection failed: %v", err)
+	}
+	pin.record(database.GetDB(), &models.Device{}, device.ID, "device "+device.Name)
+	return client, nil
+}
+
+// sudoPassword returns the password sudo is given: the dedicated sudo
+// password, or the SSH password. With key-only logins it is empty, which
+// suits passwordless sudo.
+func sudoPassword(device models.Device) (string, error) {
+	if device.SudoPassword == "" {
+		return DecryptSecret(device.SSHPassword)
+	}
+	return DecryptSecret(device.SudoPassword)
+}
+
+// runPowerCommand runs a power command over SSH through sudo. The password is
+// written to sudo's stdin (sudo -S), so passwordless sudo is not required.
+// A connection dropped by the shutdown itself counts as success.
+func runPowerCommand(device models.Device, command string) error {
+	if device.SSHUser != "root" {
+		password, err := sudoPassword(device)
+		if err != nil {
+			return err
+		}
+		return runSSHCommand(device, "sudo -S -p '' "+command, password+"\n")
+	}
+	return runSSHCommand(device, command, "")
+}
+
+// runSSHCommand runs command on the device, feeding it stdin
+func runSSHCommand(device models.Device, command, stdin string) error {
+	client, err := dialDeviceSSH(device)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("SSH session failed: %v", err)
+	}
+	defer session.Close()
+
+	var output bytes.Buffer
+	session.Stdout = &output
+	session.Stderr = &output
+	session.Stdin = strings.NewReader(stdin)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(powerTimeout):
+		return fmt.Errorf("power command timed out")
+	}
+
+	var exitMissing *ssh.ExitMissingError
+	if err == nil || errors.As(err, &exitMissing) {
+		return nil
+	}
+
+	message := strings.TrimSpace(output.String())
+	lower := strings.ToLower(message)
+	for _, denied := range sudoDenied {
+		if strings.Contains(lower, denied) {
+			return fmt.Errorf("%w: sudo refused the command (%s)", ErrPermissionDenied, message)
+		}
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return fmt.Errorf("power command failed: %s", message)
+}
+
+// validatePowerSettings rejects unknown power methods, OSes and WinRM auth
+// schemes, and multi-line power commands
+func validatePowerSettings(device models.Device) error {
+	switch device.PowerMethod {
+	case "", PowerMethodSSH, PowerMethodWinRM:
+	default:
+		return fmt.Errorf("%w: power method must be ssh or winrm", ErrInvalidPowerSettings)
+	}
+	switch device.WinRMAuth {
+	case "", WinRMAuthNTLM, WinRMAuthBasic:
+	default:
+		return fmt.Errorf("%w: WinRM auth must be ntlm or basic", ErrInvalidPowerSettings)
+	}
+	switch device.PowerOS {
+	case "", PowerOSLinux, PowerOSWindows:
+	default:
+		return fmt.Errorf("%w: power OS must be linux or windows", ErrInvalidPowerSettings)
+	}
+	for _, command := range []string{device.ShutdownCommand, device.RebootCommand} {
+		if len(command) > 255 || strings.ContainsAny(command, "\r\n") {
+			return fmt.Errorf("%w: power commands must be a single line of at most 255 characters", ErrInvalidPowerSettings)
+		}
+	}
+	if device.WinRMPort < 0 || device.WinRMPort > 65535 {
+		return fmt.Errorf("%w: WinRM port out of range", ErrInvalidPowerSettings)
+	}
+	if device.PowerWatts < 0 {
+		return fmt.Errorf("%w: power draw must not be negative", ErrInvalidPowerSettings)
+	}
+	return nil
+}
+
+// powerMethod returns how power actions reach the device, or "" when it has
+// no credentials. Without an explicit method SSH is used, unless the SSH port
+// is closed and the WinRM port answers.
+func powerMethod(device models.Device) string {
+	if !hasSSHCredentials(device) {
+		return ""
+	}
+	if device.PowerMethod != "" {
+		return device.PowerMethod
+	}
+
+	sshPort := device.SSHPort
+	if sshPort == 0 {
+		sshPort = 22
+	}
+	if !portOpen(device.IP, sshPort) && portOpen(device.IP, winrmPort(device)) {
+		return PowerMethodWinRM
+	}
+	return PowerMethodSSH
+}
+
+// portOpen reports whether a TCP connection to the port succeeds
+func portOpen(ip string, port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// winrmPort returns the device's WinRM port, defaulting per scheme
+func winrmPort(device models.Device) int {
+	switch {
+	case device.WinRMPort != 0:
+		return device.WinRMPort
+	case device.WinRMHTTPS:
+		return 5986
+	}
+	return 5985
+}
+
+// runWinRMCommand runs command on a Windows device over WinRM, logging in
+// with NTLM (domain accounts as DOMAIN\user) or Basic auth
+func runWinRMCommand(device models.Device, command string) error {
+	endpoint := winrm.NewEndpoint(device.IP, winrmPort(device), device.WinRMHTTPS, device.WinRMInsecure, nil, nil, nil, powerTimeout)
+	params := winrm.NewParameters("PT15S", "en-US", 153600)
+	if device.WinRMAuth != WinRMAuthBasic {
+		params.TransportDecorator = func() winrm.Transporter { return &winrm.ClientNTLM{} }
+	}
+
+	password, err := DecryptSecret(device.SSHPassword)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return fmt.Errorf("%w: WinRM needs the device's password, SSH keys are not supported", ErrPermissionDenied)
+	}
+	client, err := winrm.NewClientWithParameters(endpoint, device.SSHUser, password, params)
+	if err != nil {
+		return fmt.Errorf("WinRM client failed: %v", err)
+	}
+
+	stdout, stderr, exitCode, err := client.RunWithString(command, "")
+	if err != nil {
+		message := err.Error()
+		switch {
+		case strings.Contains(message, "401"):
+			return fmt.Errorf("%w: WinRM login as %s was rejected", ErrPermissionDenied, device.SSHUser)
+		case strings.Contains(message, "dial tcp"), strings.Contains(message, "timeout"):
+			return fmt.Errorf("%w: %v", ErrHostUnreachable, err)
+		}
+		return fmt.Errorf("WinRM command failed: %v", err)
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	// Exit code 5 is ERROR_ACCESS_DENIED: the account lacks the shutdown privilege
+	message := strings.TrimSpace(stderr + stdout)
+	if exitCode == 5 || strings.Contains(strings.ToLower(message), "access is denied") {
+		return fmt.Errorf("%w: Windows refused the command (%s)", ErrPermissionDenied, message)
+	}
+	if message == "" {
+		message = fmt.Sprintf("exit code %d", exitCode)
+	}
+	return fmt.Errorf("power command failed: %s", message)
+}
+
+// Power-on methods
+const (
+	PowerOnWOL  = "wol"
+	PowerOnIPMI = "ipmi"
+)
+
+// PowerOnDevice turns a device on with Wake-on-LAN or its IPMI BMC. An
+// empty method uses IPMI when the device has a BMC configured.
+func (s *DeviceService) PowerOnDevice(id uint, userID uint, method string) (string, error) {
+	var device models.Device
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&device).Error; err != nil {
+		return "", fmt.Errorf("device not found")
+	}
+
+	if method == "" {
+		method = PowerOnWOL
+		if device.IPMIHost != "" {
+			method = PowerOnIPMI
+		}
+	}
+
+	switch method {
+	case PowerOnWOL:
+		if err := s.WakeDevice(id, userID); err != nil {
+			return "", err
+		}
+		return "sent Wake-on-LAN", nil
+	case PowerOnIPMI:
+		if err := ipmiPowerOn(device); err != nil {
+			return "", err
+		}
+		return "sent IPMI power on", nil
+	}
+	return "", fmt.Errorf("%w: power-on method must be wol or ipmi", ErrInvalidPowerSettings)
+}
+
+// ipmiPowerOn runs "ipmitool chassis power on" against the device's BMC.
+// The password goes through IPMI_PASSWORD (-E) to keep it out of the process list.
+func ipmiPowerOn(device models.Device) error {
+	if device.IPMIHost == "" {
+		return fmt.Errorf("device has no IPMI host")
+	}
+	password, err := DecryptSecret(device.IPMIPassword)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), powerTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ipmitool", "-I", "lanplus", "-H", device.IPMIHost, "-U", device.IPMIUser, "-E", "chassis", "power", "on")
+	cmd.Env = append(os.Environ(), "IPMI_PASSWORD="+password)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	message := strings.TrimSpace(string(output))
+	lower := strings.ToLower(message)
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("ipmitool is not installed")
+	case ctx.Err() != nil, strings.Contains(lower, "unable to establish"):
+		return fmt.Errorf("%w: BMC %s did not answer", ErrHostUnreachable, device.IPMIHost)
+	case strings.Contains(lower, "unauthorized") || strings.Contains(lower, "password"):
+		return fmt.Errorf("%w: BMC login as %s was rejected", ErrPermissionDenied, device.IPMIUser)
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return fmt.Errorf("IPMI power on failed: %s", message)
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"os/exec"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,19 +17,42 @@ import (
 // DeviceService handles device operations
 type DeviceService struct {
 	db *gorm.DB
+
+	hostMu sync.Mutex
+	hosts  map[uint]hostState // recent host pings used to cascade service status
+
+	statusMu   sync.Mutex
+	lastStatus map[uint]bool // last recorded online state per device
+
+	listeners []func(models.Device, bool)
 }
 
-// NewDeviceService creates a new DeviceService
+// hostState is the cached reachability of a device hosting services
+type hostState struct {
+	online    bool
+	name      string
+	checkedAt time.Time
+}
+
+// hostStateTTL is how long a host ping is reused across service checks
+const hostStateTTL = 30 * time.Second
+
+// NewDeviceService creates a new DeviceService and starts background pinging
 func NewDeviceService() *DeviceService {
-	return &DeviceService{
-		db: database.GetDB(),
+	s := &DeviceService{
+		db:         database.GetDB(),
+		hosts:      make(map[uint]hostState),
+		lastStatus: make(map[uint]bool),
 	}
+	go s.pingBackground()
+	return s
 }
 
-// GetDevices returns all devices for a user (fast - no ping)
-func (s *DeviceService) GetDevices(userID uint) ([]models.Device, error) {
+// GetDevices returns all devices for a user (fast - no ping),
+// optionally limited to devices carrying any of the given tags
+func (s *DeviceService) GetDevices(userID uint, tags []string) ([]models.Device, error) {
 	var devices []models.Device
-	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&devices).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("user_id = ?", userID).Scopes(DeviceTagScope(tags)).Order("name ASC").Find(&devices).Error; err != nil {
 		return nil, err
 	}
 	// Return devices with last known online status from database
@@ -37,9 +61,9 @@ func (s *DeviceService) GetDevices(userID uint) ([]models.Device, error) {
 }
 
 // GetDevicesWithPing returns all devices with live ping check (slower)
-func (s *DeviceService) GetDevicesWithPing(userID uint) ([]models.Device, error) {
+func (s *DeviceService) GetDevicesWithPing(userID uint, tags []string) ([]models.Device, error) {
 	var devices []models.Device
-	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&devices).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("user_id = ?", userID).Scopes(DeviceTagScope(tags)).Order("name ASC").Find(&devices).Error; err != nil {
 		return nil, err
 	}
 
@@ -61,6 +85,7 @@ func (s *DeviceService) GetDevicesWithPing(userID uint) ([]models.Device, error)
 			} else {
 				s.db.Model(&devices[idx]).Update("is_online", false)
 			}
+			s.notify(devices[idx], devices[idx].IsOnline)
 		}(i)
 	}
 	wg.Wait()
@@ -68,21 +93,137 @@ func (s *DeviceService) GetDevicesWithPing(userID uint) ([]models.Device, error)
 	return devices, nil
 }
 
+// HostOnline reports whether the device hosting a service is reachable.
+// The device is pinged at most once per hostStateTTL and its stored status
+// updated. Unknown or inactive devices count as online so their services
+// are still probed directly.
+func (s *DeviceService) HostOnline(deviceID uint) (bool, string) {
+	s.hostMu.Lock()
+	state, ok := s.hosts[deviceID]
+	s.hostMu.Unlock()
+	if ok && time.Since(state.checkedAt) < hostStateTTL {
+		return state.online, state.name
+	}
+
+	var device models.Device
+	if err := s.db.First(&device, deviceID).Error; err != nil || !device.IsActive {
+		return true, ""
+	}
+
+	state = hostState{online: s.pingDeviceFast(device.IP), name: device.Name, checkedAt: time.Now()}
+	if state.online {
+		s.db.Model(&device).Updates(map[string]interface{}{
+			"is_online": true,
+			"last_seen": state.checkedAt,
+		})
+	} else {
+		s.db.Model(&device).Update("is_online", false)
+	}
+	s.notify(device, state.online)
+
+	s.hostMu.Lock()
+	s.hosts[deviceID] = state
+	s.hostMu.Unlock()
+	return state.online, state.name
+}
+
+// GetDeviceDetail returns a device with the services it hosts and their last known status
+func (s *DeviceService) GetDeviceDetail(id uint, userID uint) (*models.DeviceDetail, error) {
+	device, err := s.GetDevice(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []models.ServiceConfig
+	if err := s.db.Where("device_id = ? AND user_id = ?", device.ID, userID).Order("name ASC").Find(&configs).Error; err != nil {
+		return nil, err
+	}
+
+	detail := &models.DeviceDetail{Device: *device, Services: make([]models.HostedService, 0, len(configs))}
+	for _, svc := range configs {
+		hosted := models.HostedService{
+			ID:       svc.ID,
+			Name:     svc.Name,
+			URL:      svc.URL,
+			Icon:     svc.Icon,
+			IsActive: svc.IsActive,
+			Status:   "unknown",
+		}
+		var check models.ServiceCheck
+		if err := s.db.Where("service_id = ? AND location = ?", svc.ID, "").Order("checked_at DESC").First(&check).Error; err == nil {
+			hosted.Status = check.Status
+			hosted.LastCheck = &check.CheckedAt
+		}
+		detail.Services = append(detail.Services, hosted)
+	}
+
+	if availability, err := s.GetAvailability(device.ID, userID, 30); err == nil {
+		detail.Availability = availability
+	}
+	return detail, nil
+}
+
 // GetDevice returns a single device by ID (no ping for speed)
 func (s *DeviceService) GetDevice(id uint, userID uint) (*models.Device, error) {
 	var device models.Device
-	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&device).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("id = ? AND user_id = ?", id, userID).First(&device).Error; err != nil {
 		return nil, fmt.Errorf("device not found")
 	}
 	return &device, nil
 }
 
+// ResetSSHHostKey forgets a device's pinned SSH host key, so the next
+// connection pins the key the host presents then
+func (s *DeviceService) ResetSSHHostKey(id uint, userID uint) (*models.Device, error) {
+	device, err := s.GetDevice(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(device).UpdateColumns(map[string]interface{}{
+		"ssh_host_key":             "",
+		"ssh_host_key_fingerprint": "",
+	}).Error; err != nil {
+		return nil, err
+	}
+	device.SSHHostKey = ""
+	device.SSHHostKeyFingerprint = ""
+	return device, nil
+}
+
 // CreateDevice creates a new device
 func (s *DeviceService) CreateDevice(userID uint, req models.CreateDeviceRequest) (*models.Device, error) {
 	sshPort := req.SSHPort
 	if sshPort == 0 {
 		sshPort = 22
 	}
+	purchaseDate, err := parseInventoryDate("purchase date", req.PurchaseDate)
+	if err != nil {
+		return nil, err
+	}
+	warrantyExpiry, err := parseInventoryDate("warranty expiry", req.WarrantyExpiry)
+	if err != nil {
+		return nil, err
+	}
+	sudoPassword, err := EncryptSecret(req.SudoPassword)
+	if err != nil {
+		return nil, err
+	}
+	ipmiPassword, err := EncryptSecret(req.IPMIPassword)
+	if err != nil {
+		return nil, err
+	}
+	sshPassword, err := EncryptSecret(req.SSHPassword)
+	if err != nil {
+		return nil, err
+	}
+	sshKey, err := EncryptSecret(strings.TrimSpace(req.SSHKey))
+	if err != nil {
+		return nil, err
+	}
+	sshKeyPassphrase, err := EncryptSecret(req.SSHKeyPassphrase)
+	if err != nil {
+		return nil, err
+	}
 	device := models.Device{
 		UserID:      userID,
 		Name:        req.Name,
@@ -95,10 +236,53 @@ func (s *DeviceService) CreateDevice(userID uint, req models.CreateDeviceRequest
 		Location:    req.Location,
 		Description: req.Description,
 		SSHUser:     req.SSHUser,
-		SSHPassword: req.SSHPassword,
 		SSHPort:     sshPort,
 		IsActive:    true,
 		IsOnline:    false, // Will be updated when user pings
+
+		SSHPassword:      sshPassword,
+		SSHKey:           sshKey,
+		SSHKeyPassphrase: sshKeyPassphrase,
+		SSHKeyPath:       strings.TrimSpace(req.SSHKeyPath),
+
+		SerialNumber:   req.SerialNumber,
+		PurchaseDate:   purchaseDate,
+		WarrantyExpiry: warrantyExpiry,
+		Price:          req.Price,
+		Notes:          req.Notes,
+
+		SudoPassword: sudoPassword,
+
+		PowerMethod:   req.PowerMethod,
+		WinRMPort:     req.WinRMPort,
+		WinRMHTTPS:    req.WinRMHTTPS,
+		WinRMInsecure: req.WinRMInsecure,
+		WinRMAuth:     req.WinRMAuth,
+
+		PowerOS:         req.PowerOS,
+		ShutdownCommand: strings.TrimSpace(req.ShutdownCommand),
+		RebootCommand:   strings.TrimSpace(req.RebootCommand),
+
+		IPMIHost:     req.IPMIHost,
+		IPMIUser:     req.IPMIUser,
+		IPMIPassword: ipmiPassword,
+
+		PowerWatts: req.PowerWatts,
+
+		Criticality: req.Criticality,
+
+		CollectMetrics: req.CollectMetrics,
+	}
+	if err := validatePowerSettings(device); err != nil {
+		return nil, err
+	}
+	if err := validateSSHCredentials(device); err != nil {
+		return nil, err
+	}
+	if device.Criticality == "" {
+		device.Criticality = models.ImpactMedium
+	} else if !ValidImpact(device.Criticality) {
+		return nil, fmt.Errorf("invalid criticality %q", device.Criticality)
 	}
 
 	// Set default icon based on type
@@ -160,16 +344,105 @@ func (s *DeviceService) UpdateDevice(id uint, userID uint, req models.UpdateDevi
 	}
 	if req.IsActive != nil {
 		device.IsActive = *req.IsActive
+		if device.IsActive {
+			device.ArchivedAt = nil
+		}
 	}
 	if req.SSHUser != nil {
 		device.SSHUser = *req.SSHUser
 	}
-	if req.SSHPassword != nil {
-		device.SSHPassword = *req.SSHPassword
+	if err := applySSHCredentials(&device, req); err != nil {
+		return nil, err
 	}
 	if req.SSHPort != nil {
 		device.SSHPort = *req.SSHPort
 	}
+	if req.SudoPassword != nil {
+		encrypted, err := EncryptSecret(*req.SudoPassword)
+		if err != nil {
+			return nil, err
+		}
+		device.SudoPassword = encrypted
+	}
+	if req.PowerMethod != nil {
+		device.PowerMethod = *req.PowerMethod
+	}
+	if req.WinRMPort != nil {
+		device.WinRMPort = *req.WinRMPort
+	}
+	if req.WinRMHTTPS != nil {
+		device.WinRMHTTPS = *req.WinRMHTTPS
+	}
+	if req.WinRMInsecure != nil {
+		device.WinRMInsecure = *req.WinRMInsecure
+	}
+	if req.WinRMAuth != nil {
+		device.WinRMAuth = *req.WinRMAuth
+	}
+	if req.PowerOS != nil {
+		device.PowerOS = *req.PowerOS
+	}
+	if req.ShutdownCommand != nil {
+		device.ShutdownCommand = strings.TrimSpace(*req.ShutdownCommand)
+	}
+	if req.RebootCommand != nil {
+		device.RebootCommand = strings.TrimSpace(*req.RebootCommand)
+	}
+	if req.IPMIHost != nil {
+		device.IPMIHost = *req.IPMIHost
+	}
+	if req.IPMIUser != nil {
+		device.IPMIUser = *req.IPMIUser
+	}
+	if req.IPMIPassword != nil {
+		encrypted, err := EncryptSecret(*req.IPMIPassword)
+		if err != nil {
+			return nil, err
+		}
+		device.IPMIPassword = encrypted
+	}
+	if req.PowerWatts != nil {
+		device.PowerWatts = *req.PowerWatts
+	}
+	if req.CollectMetrics != nil {
+		device.CollectMetrics = *req.CollectMetrics
+	}
+	if err := validatePowerSettings(device); err != nil {
+		return nil, err
+	}
+	if err := validateSSHCredentials(device); err != nil {
+		return nil, err
+	}
+	if req.Criticality != nil {
+		if !ValidImpact(*req.Criticality) {
+			return nil, fmt.Errorf("invalid criticality %q", *req.Criticality)
+		}
+		device.Criticality = *req.Criticality
+	}
+	if req.SerialNumber != nil {
+		device.SerialNumber = *req.SerialNumber
+	}
+	if req.PurchaseDate != nil {
+		date, err := parseInventoryDate("purchase date", *req.PurchaseDate)
+		if err != nil {
+			return nil, err
+		}
+		device.PurchaseDate = date
+	}
+	if req.WarrantyExpiry != nil {
+		date, err := parseInventoryDate("warranty expiry", *req.WarrantyExpiry)
+		if err != nil {
+			return nil, err
+		}
+		device.WarrantyExpiry = date
+		device.WarrantyNotice = ""
+	}
+	if req.Price != nil {
+		device.Price = *req.Price
+	}
+	if req.Notes != nil {
+		device.Notes = *req.Notes
+	}
 
 	if err := s.db.Save(&device).Error; err != nil {
 		return nil, err
@@ -178,6 +451,49 @@ func (s *DeviceService) UpdateDevice(id uint, userID uint, req models.UpdateDevi
 	return &device, nil
 }
 
+// applySSHCredentials applies an update's SSH secrets to a device. The form
+// cannot show stored secrets, so an empty password or key keeps the stored
+// one and the Clear flags remove them.
+func applySSHCredentials(device *models.Device, req models.UpdateDeviceRequest) error {
+	if req.ClearSSHPassword {
+		device.SSHPassword = ""
+	}
+	if req.ClearSSHKey {
+		device.SSHKey = ""
+		device.SSHKeyPassphrase = ""
+	}
+	if req.SSHPassword != nil && *req.SSHPassword != "" {
+		encrypted, err := EncryptSecret(*req.SSHPassword)
+		if err != nil {
+			return err
+		}
+		device.SSHPassword = encrypted
+	}
+	if req.SSHKey != nil && strings.TrimSpace(*req.SSHKey) != "" {
+		encrypted, err := EncryptSecret(strings.TrimSpace(*req.SSHKey))
+		if err != nil {
+			return err
+		}
+		device.SSHKey = encrypted
+		// A new key comes with its own passphrase, or none
+		device.SSHKeyPassphrase = ""
+	}
+	if req.SSHKeyPassphrase != nil && *req.SSHKeyPassphrase != "" {
+		encrypted, err := EncryptSecret(*req.SSHKeyPassphrase)
+		if err != nil {
+			return err
+		}
+		device.SSHKeyPassphrase = encrypted
+	}
+	if req.SSHKeyPath != nil {
+		device.SSHKeyPath = strings.TrimSpace(*req.SSHKeyPath)
+	}
+	if device.SSHKey == "" && device.SSHKeyPath == "" {
+		device.SSHKeyPassphrase = ""
+	}
+	return nil
+}
+
 // DeleteDevice deletes a device
 func (s *DeviceService) DeleteDevice(id uint, userID uint) error {
 	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Device{})
@@ -206,10 +522,25 @@ func (s *DeviceService) PingDevice(id uint, userID uint) (bool, error) {
 	} else {
 		s.db.Model(&device).Update("is_online", false)
 	}
+	s.notify(device, isOnline)
 
 	return isOnline, nil
 }
 
+// OnStatus registers a function called with the result of every device ping
+func (s *DeviceService) OnStatus(fn func(models.Device, bool)) {
+	s.listeners = append(s.listeners, fn)
+}
+
+// notify records a ping result and passes it to the registered status listeners
+func (s *DeviceService) notify(device models.Device, online bool) {
+	s.recordPing(device.ID, online)
+	s.recordStatusChange(device.ID, online)
+	for _, fn := range s.listeners {
+		fn(device, online)
+	}
+}
+
 // WakeDevice sends a Wake-on-LAN magic packet to the device
 func (s *DeviceService) WakeDevice(id uint, userID uint) error {
 	var device models.Device
@@ -258,8 +589,18 @@ func (s *DeviceService) WakeDevice(id uint, userID uint) error {
 	return nil
 }
 
-// ShutdownDevice sends a shutdown command to the device via SSH or system command
+// ShutdownDevice sends a shutdown command to the device via SSH, WinRM or system command
 func (s *DeviceService) ShutdownDevice(id uint, userID uint) error {
+	return s.powerAction(id, userID, false)
+}
+
+// RebootDevice sends a reboot command to the device via SSH, WinRM or system command
+func (s *DeviceService) RebootDevice(id uint, userID uint) error {
+	return s.powerAction(id, userID, true)
+}
+
+// powerAction shuts down or reboots a device with its configured power method
+func (s *DeviceService) powerAction(id uint, userID uint, reboot bool) error {
 	var device models.Device
 	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&device).Error; err != nil {
 		return fmt.Errorf("device not found")
@@ -267,77 +608,68 @@ func (s *DeviceService) ShutdownDevice(id uint, userID uint) error {
 
 	// Check if device is online first
 	if !s.pingDeviceFast(device.IP) {
-		return fmt.Errorf("device is offline")
+		return fmt.Errorf("%w: device is offline", ErrHostUnreachable)
 	}
 
-	// For Windows PC, try using net rpc shutdown (no SSH required if on same domain)
-	// For Linux/Mac, use SSH
-	if device.SSHUser != "" && device.SSHPassword != "" {
-		return s.shutdownViaSSH(device)
+	switch method := powerMethod(device); method {
+	case PowerMethodSSH:
+		if device.PowerOS == PowerOSWindows {
+			// Windows' OpenSSH server has no sudo; the account needs the
+			// shutdown privilege itself
+			return runSSHCommand(device, powerCommand(device, method, reboot), "")
+		}
+		return runPowerCommand(device, powerCommand(device, method, reboot))
+	case PowerMethodWinRM:
+		return runWinRMCommand(device, powerCommand(device, method, reboot))
 	}
 
-	// Try Windows RPC shutdown as fallback (works for Windows PCs on same network)
-	return s.shutdownViaRPC(device)
+	// Without credentials, try Windows RPC shutdown (works for Windows PCs on same network)
+	return s.shutdownViaRPC(device, reboot)
 }
 
-// shutdownViaSSH sends shutdown command via SSH
-func (s *DeviceService) shutdownViaSSH(device models.Device) error {
-	port := device.SSHPort
-	if port == 0 {
-		port = 22
-	}
-
-	// Use sshpass for password authentication (simpler than Go SSH library)
-	// Determine shutdown command based on common OS types
-	// Try Linux shutdown first (works for most Linux/Mac)
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		// From Windows, use plink (PuTTY) or ssh if available
-		// Try native Windows SSH client first
-		sshCmd := fmt.Sprintf("echo y | plink -ssh -pw %s %s@%s -P %d \"sudo shutdown -h now\" 2>&1 || ssh -o StrictHostKeyChecking=no -o ConnectTimeout=10 %s@%s -p %d \"sudo shutdown -h now\" 2>&1",
-			device.SSHPassword, device.SSHUser, device.IP, port,
-			device.SSHUser, device.IP, port)
-		cmd = exec.Command("cmd", "/C", sshCmd)
-	} else {
-		// From Linux/Mac, use sshpass
-		cmd = exec.Command("sshpass", "-p", device.SSHPassword,
-			"ssh", "-o", "StrictHostKeyChecking=no", "-o", "ConnectTimeout=10",
-			fmt.Sprintf("%s@%s", device.SSHUser, device.IP),
-			"-p", fmt.Sprintf("%d", port),
-			"sudo shutdown -h now")
-	}
+// Remote power commands; the SSH ones work on both Linux and macOS
+const (
+	remoteShutdownCommand = "shutdown -h now"
+	remoteRebootCommand   = "shutdown -r now"
+	winrmShutdownCommand  = "shutdown /s /t 0 /f"
+	winrmRebootCommand    = "shutdown /r /t 0 /f"
+)
 
-	// Run with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+// powerCommand returns the device's custom shutdown or reboot command, or
+// the default for its power method and OS
+func powerCommand(device models.Device, method string, reboot bool) string {
+	if reboot && device.RebootCommand != "" {
+		return device.RebootCommand
+	}
+	if !reboot && device.ShutdownCommand != "" {
+		return device.ShutdownCommand
+	}
 
-	select {
-	case err := <-done:
-		// SSH might return error because connection drops on shutdown, which is expected
-		if err != nil {
-			// Check if it's a connection drop (expected on successful shutdown)
-			errStr := err.Error()
-			if !contains(errStr, "closed") && !contains(errStr, "Connection") && !contains(errStr, "exit status") {
-				return fmt.Errorf("SSH shutdown failed: %v", err)
-			}
-		}
-		return nil
-	case <-time.After(15 * time.Second):
-		return fmt.Errorf("shutdown command timed out")
+	windows := method == PowerMethodWinRM || device.PowerOS == PowerOSWindows
+	switch {
+	case windows && reboot:
+		return winrmRebootCommand
+	case windows:
+		return winrmShutdownCommand
+	case reboot:
+		return remoteRebootCommand
 	}
+	return remoteShutdownCommand
 }
 
 // shutdownViaRPC uses Windows net rpc or shutdown command for remote Windows PCs
-func (s *DeviceService) shutdownViaRPC(device models.Device) error {
+func (s *DeviceService) shutdownViaRPC(device models.Device, reboot bool) error {
 	if runtime.GOOS != "windows" {
-		return fmt.Errorf("RPC shutdown only available from Windows to Windows devices")
+		return fmt.Errorf("no SSH or WinRM credentials set; RPC shutdown only works when the backend runs on Windows")
 	}
 
 	// Try Windows remote shutdown command
 	// shutdown /s /m \\<IP> /t 0 /f
-	cmd := exec.Command("shutdown", "/s", "/m", fmt.Sprintf("\\\\%s", device.IP), "/t", "0", "/f")
+	action := "/s"
+	if reboot {
+		action = "/r"
+	}
+	cmd := exec.Command("shutdown", action, "/m", fmt.Sprintf("\\\\%s", device.IP), "/t", "0", "/f")
 
 	done := make(chan error, 1)
 	go func() {
@@ -355,20 +687,6 @@ func (s *DeviceService) shutdownViaRPC(device models.Device) error {
 	}
 }
 
-// Helper function to check if string contains substring
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
-}
-
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
-
 // pingDeviceFast performs a quick TCP ping with common ports including CCTV
 // Falls back to ICMP ping if all TCP ports fail
 func (s *DeviceService) pingDeviceFast(ip string) bool {
@@ -414,19 +732,10 @@ func (s *DeviceService) pingDeviceFast(ip string) bool {
 	return s.icmpPing(ip)
 }
 
-// icmpPing performs an ICMP ping using the system ping command
+// icmpPing performs a native ICMP echo, falling back to the system ping binary
+// when no ICMP socket is available
 func (s *DeviceService) icmpPing(ip string) bool {
-	// Use ping command with 1 packet and short timeout
-	// Linux: ping -c 1 -W 1 <ip>
-	// Windows: ping -n 1 -w 1000 <ip>
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("ping", "-n", "1", "-w", "1000", ip)
-	} else {
-		cmd = exec.Command("ping", "-c", "1", "-W", "1", ip)
-	}
-
-	err := cmd.Run()
+	_, err := icmpEcho(ip, time.Second)
 	return err == nil
 }
 
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DeviceShell is an interactive login shell on a device over SSH, with a PTY
+type DeviceShell struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.WriteCloser
+	output  *io.PipeReader
+
+	done     chan struct{}
+	exitCode int
+	exited   bool
+}
+
+// OpenShell logs in to a device with its SSH credentials and starts a shell
+// on a PTY of the given size
+func (s *DeviceService) OpenShell(id uint, userID uint, cols, rows uint) (*DeviceShell, error) {
+	device, err := s.GetDevice(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSSHCredentials(*device) {
+		return nil, fmt.Errorf("device has no SSH credentials")
+	}
+	if cols == 0 || rows == 0 {
+		cols, rows = 80, 24
+	}
+
+	client, err := dialDeviceSSH(*device)
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("SSH session failed: %v", err)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty("xterm-256color", int(rows), int(cols), modes); err != nil {
+		session.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to allocate a terminal: %v", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		return nil, err
+	}
+	// With a PTY stdout and stderr arrive interleaved as the user sees them
+	output, writer := io.Pipe()
+	session.Stdout = writer
+	session.Stderr = writer
+
+	if err := session.Shell(); err != nil {
+		session.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to start shell: %v", err)
+	}
+
+	shell := &DeviceShell{client: client, session: session, stdin: stdin, output: output, done: make(chan struct{})}
+	go func() {
+		err := session.Wait()
+		var exitErr *ssh.ExitError
+		switch {
+		case err == nil:
+			shell.exited = true
+		case errors.As(err, &exitErr):
+			shell.exitCode, shell.exited = exitErr.ExitStatus(), true
+		}
+		close(shell.done)
+		writer.Close()
+	}()
+	return shell, nil
+}
+
+// Read reads the shell's output
+func (d *DeviceShell) Read(p []byte) (int, error) {
+	return d.output.Read(p)
+}
+
+// Write sends input to the shell
+func (d *DeviceShell) Write(p []byte) (int, error) {
+	return d.stdin.Write(p)
+}
+
+// Resize changes the PTY size
+func (d *DeviceShell) Resize(cols, rows uint) error {
+	if cols == 0 || rows == 0 {
+		return fmt.Errorf("invalid size %dx%d", cols, rows)
+	}
+	return d.session.WindowChange(int(rows), int(cols))
+}
+
+// ExitCode returns the shell's exit code once it has finished
+func (d *DeviceShell) ExitCode() (int, bool) {
+	select {
+	case <-d.done:
+		return d.exitCode, d.exited
+	default:
+		return 0, false
+	}
+}
+
+// Close ends the session and the SSH connection
+func (d *DeviceShell) Close() {
+	d.session.Close()
+	d.client.Close()
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidScan is returned for a network scan or import with invalid input
+var ErrInvalidScan = errors.New("invalid network scan")
+
+const (
+	// minScanBits limits a scan to a /22, 1022 addresses
+	minScanBits = 22
+	// maxScanPorts limits the ports probed per host
+	maxScanPorts = 64
+	// scanConcurrency is how many hosts are probed at once
+	scanConcurrency = 64
+	// scanDialTimeout bounds each port probe; LAN hosts answer well within it
+	scanDialTimeout = 500 * time.Millisecond
+	// scanLookupTimeout bounds each reverse DNS lookup
+	scanLookupTimeout = time.Second
+)
+
+// defaultScanPorts are common homelab service ports: SSH, DNS, HTTP(S), SMB,
+// RTSP, MQTT, RDP, Synology, Proxmox, Plex, printers and VNC
+var defaultScanPorts = []int{22, 53, 80, 139, 443, 445, 554, 1883, 3389, 5000, 5900, 8006, 8080, 8443, 9100, 32400}
+
+// ouiVendors maps MAC prefixes to the vendors common in homelabs
+var ouiVendors = map[string]string{
+	"b8:27:eb": "Raspberry Pi", "dc:a6:32": "Raspberry Pi", "e4:5f:01": "Raspberry Pi", "d8:3a:dd": "Raspberry Pi", "2c:cf:67": "Raspberry Pi",
+	"00:11:32": "Synology",
+	"24:5e:be": "QNAP", "00:08:9b": "QNAP",
+	"24:a4:3c": "Ubiquiti", "78:8a:20": "Ubiquiti", "f0:9f:c2": "Ubiquiti", "fc:ec:da": "Ubiquiti", "74:83:c2": "Ubiquiti", "e0:63:da": "Ubiquiti",
+	"50:c7:bf": "TP-Link", "ec:08:6b": "TP-Link", "98:da:c4": "TP-Link", "60:32:b1": "TP-Link",
+	"e4:8d:8c": "MikroTik", "4c:5e:0c": "MikroTik", "cc:2d:e0": "MikroTik", "48:8f:5a": "MikroTik",
+	"00:1b:21": "Intel", "3c:fd:fe": "Intel", "a0:36:9f": "Intel",
+	"00:e0:4c": "Realtek", "52:54:00": "QEMU/KVM", "bc:24:11": "Proxmox", "00:0c:29": "VMware", "00:50:56": "VMware", "08:00:27": "VirtualBox", "00:15:5d": "Hyper-V",
+	"02:42:ac": "Docker",
+	"3c:22:fb": "Apple", "f0:18:98": "Apple", "a4:83:e7": "Apple", "ac:de:48": "Apple",
+	"18:b4:30": "Nest", "44:07:0b": "Google", "f4:f5:d8": "Google",
+	"24:0a:c4": "Espressif", "30:ae:a4": "Espressif", "84:cc:a8": "Espressif", "a4:cf:12": "Espressif",
+	"c0:56:e3": "Hikvision", "44:19:b6": "Hikvision", "3c:ef:8c": "Dahua", "e0:50:8b": "Dahua",
+	"00:1e:06": "Hardkernel",
+	"70:85:c2": "ASRock", "04:d9:f5": "ASUS", "d8:bb:c1": "Micro-Star", "18:c0:4d": "Gigabyte",
+}
+
+// DiscoveryService finds hosts on a subnet and imports them as devices
+type DiscoveryService struct {
+	db      *gorm.DB
+	devices *DeviceService
+}
+
+// NewDiscoveryService creates a new DiscoveryService
+func NewDiscoveryService(devices *DeviceService) *DiscoveryService {
+	return &DiscoveryService{db: database.GetDB(), devices: devices}
+}
+
+// macVendor returns the vendor of a MAC address from its OUI. Locally
+// administered addresses are randomized or virtual and have no vendor.
+func macVendor(mac string) string {
+	if len(mac) < 8 {
+		return ""
+	}
+	if vendor, ok := ouiVendors[mac[:8]]; ok {
+		return vendor
+	}
+	if first, err := strconv.ParseUint(mac[:2], 16, 8); err == nil && first&0x02 != 0 {
+		return "Private (randomized)"
+	}
+	return ""
+}
+
+// guessDeviceType suggests a device type from a host's open ports
+func guessDeviceType(ports []int) string {
+	has := func(port int) bool { return slices.Contains(ports, port) }
+	switch {
+	case has(554):
+		return "cctv"
+	case has(53) && (has(80) || has(443)):
+		return "router"
+	case has(3389):
+		return "pc"
+	case has(22) || has(8006) || has(5000) || has(445) || has(32400):
+		return "server"
+	}
+	return "other"
+}
+
+// Scan sweeps a subnet: every address is probed on TCP ports, falling back
+// to an ICMP echo, and hosts that only show up in the ARP cache the probes
+// filled count as well. Hosts get their MAC, vendor and reverse DNS name.
+func (s *DiscoveryService) Scan(userID uint, req models.NetworkScanRequest) (*models.NetworkScanResult, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(req.CIDR))
+	if err != nil || !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("%w: invalid IPv4 CIDR %q", ErrInvalidScan, req.CIDR)
+	}
+	if prefix.Bits() < minScanBits || prefix.Bits() > 32 {
+		return nil, fmt.Errorf("%w: scan at most a /%d", ErrInvalidScan, minScanBits)
+	}
+	prefix = prefix.Masked()
+
+	ports := req.Ports
+	if len(ports) == 0 {
+		ports = defaultScanPorts
+	}
+	if len(ports) > maxScanPorts {
+		return nil, fmt.Errorf("%w: probe at most %d ports", ErrInvalidScan, maxScanPorts)
+	}
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%w: invalid port %d", ErrInvalidScan, port)
+		}
+	}
+
+	first, last := prefix.Addr(), prefix.Addr()
+	if prefix.Bits() < 31 {
+		first, last = hostRange(prefix)
+	} else if prefix.Bits() == 31 {
+		last = first.Next()
+	}
+
+	result := &models.NetworkScanResult{CIDR: prefix.String(), StartedAt: time.Now(), Hosts: []models.DiscoveredHost{}}
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		found = make(map[netip.Addr][]int)
+		sem   = make(chan struct{}, scanConcurrency)
+	)
+	for addr := first; !last.Less(addr); addr = addr.Next() {
+		result.Scanned++
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(addr netip.Addr) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if open, alive := probeHost(addr, ports); alive {
+				mu.Lock()
+				found[addr] = open
+				mu.Unlock()
+			}
+		}(addr)
+	}
+	wg.Wait()
+
+	// Probing an on-link address makes the kernel ARP for it, so hosts that
+	// drop every probe still leave their MAC behind
+	neighbors := neighborTable()
+	for addr := range neighbors {
+		if _, ok := found[addr]; !ok && prefix.Contains(addr) {
+			found[addr] = []int{}
+		}
+	}
+
+	existing, err := s.existingDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+	for addr, open := range found {
+		slices.Sort(open)
+		host := models.DiscoveredHost{
+			IP:        addr.String(),
+			MAC:       neighbors[addr],
+			OpenPorts: open,
+			Type:      guessDeviceType(open),
+		}
+		host.Vendor = macVendor(host.MAC)
+		if id, ok := existing[host.IP]; ok {
+			host.DeviceID = &id
+		} else if id, ok := existing[host.MAC]; ok && host.MAC != "" {
+			host.DeviceID = &id
+		}
+		result.Hosts = append(result.Hosts, host)
+	}
+	lookupHostnames(result.Hosts)
+
+	slices.SortFunc(result.Hosts, func(a, b models.DiscoveredHost) int {
+		return netip.MustParseAddr(a.IP).Compare(netip.MustParseAddr(b.IP))
+	})
+	result.Duration = time.Since(result.StartedAt).Milliseconds()
+	return result, nil
+}
+
+// probeHost connects to each port at once and reports the open ones. A
+// refused connection still proves the host is up; without any answer the
+// host gets an ICMP echo.
+func probeHost(addr netip.Addr, ports []int) ([]int, bool) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		open  = []int{}
+		alive bool
+	)
+	for _, port := range ports {
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(addr.String(), strconv.Itoa(port)), scanDialTimeout)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				conn.Close()
+				open = append(open, port)
+				alive = true
+			case errors.Is(err, syscall.ECONNREFUSED):
+				alive = true
+			}
+		}(port)
+	}
+	wg.Wait()
+
+	if !alive {
+		_, err := icmpEcho(addr.String(), scanDialTimeout)
+		alive = err == nil
+	}
+	return open, alive
+}
+
+// lookupHostnames fills in the hosts' reverse DNS names concurrently
+func lookupHostnames(hosts []models.DiscoveredHost) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, scanConcurrency)
+	for i := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(host *models.DiscoveredHost) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ctx, cancel := context.WithTimeout(context.Background(), scanLookupTimeout)
+			defer cancel()
+			if names, err := net.DefaultResolver.LookupAddr(ctx, host.IP); err == nil && len(names) > 0 {
+				host.Hostname = strings.TrimSuffix(names[0], ".")
+			}
+		}(&hosts[i])
+	}
+	wg.Wait()
+}
+
+// existingDevices maps the IPs and MACs of the user's devices to their IDs
+func (s *DiscoveryService) existingDevices(userID uint) (map[string]uint, error) {
+	var devices []models.Device
+	if err := s.db.Select("id", "ip", "mac").Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[string]uint, 2*len(devices))
+	for _, device := range devices {
+		existing[strings.TrimSpace(device.IP)] = device.ID
+		if mac := normalizeMAC(device.MAC); mac != "" {
+			existing[mac] = device.ID
+		}
+	}
+	return existing, nil
+}
+
+// ImportHosts creates devices for discovered hosts, skipping addresses the
+// user already has a device for
+func (s *DiscoveryService) ImportHosts(userID uint, req models.ImportHostsRequest) (*models.ImportHostsResult, error) {
+	existing, err := s.existingDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ImportHostsResult{Created: []models.Device{}, Skipped: []models.SkippedHost{}}
+	for _, host := range req.Hosts {
+		addr, err := netip.ParseAddr(strings.TrimSpace(host.IP))
+		if err != nil {
+			result.Skipped = append(result.Skipped, models.SkippedHost{IP: host.IP, Reason: "invalid IP address"})
+			continue
+		}
+		ip, mac := addr.String(), normalizeMAC(host.MAC)
+		if _, ok := existing[ip]; ok {
+			result.Skipped = append(result.Skipped, models.SkippedHost{IP: ip, Reason: "a device with this IP exists"})
+			continue
+		}
+		if _, ok := existing[mac]; ok && mac != "" {
+			result.Skipped = append(result.Skipped, models.SkippedHost{IP: ip, Reason: "a device with this MAC exists"})
+			continue
+		}
+
+		deviceType := host.Type
+		if !slices.Contains(models.DeviceTypes, deviceType) {
+			deviceType = "other"
+		}
+		name := strings.TrimSpace(host.Name)
+		if name == "" {
+			name = ip
+		}
+		device, err := s.devices.CreateDevice(userID, models.CreateDeviceRequest{
+			Name:        name,
+			IP:          ip,
+			MAC:         mac,
+			Type:        deviceType,
+			Description: "Imported from network scan",
+		})
+		if err != nil {
+			result.Skipped = append(result.Skipped, models.SkippedHost{IP: ip, Reason: err.Error()})
+			continue
+		}
+		existing[ip] = device.ID
+		if mac != "" {
+			existing[mac] = device.ID
+		}
+		result.Created = append(result.Created, *device)
+	}
+	return result, nil
+}
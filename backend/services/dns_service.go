@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// DNSService checks expected DNS records against the configured resolvers so
+// internal DNS and the reverse proxy do not drift apart
+type DNSService struct {
+	db        *gorm.DB
+	events    *EventService
+	resolvers []string
+}
+
+// dnsLookupTimeout bounds a single lookup against one resolver
+const dnsLookupTimeout = 5 * time.Second
+
+// systemResolver names the host's own resolver in results
+const systemResolver = "system"
+
+// NewDNSService creates a new DNSService and starts the periodic check
+func NewDNSService(events *EventService) *DNSService {
+	cfg := config.AppConfig
+	s := &DNSService{
+		db:        database.GetDB(),
+		events:    events,
+		resolvers: parseResolvers(cfg.DNSResolvers),
+	}
+
+	if cfg.DNSCheckInterval > 0 {
+		go s.checkBackground(time.Duration(cfg.DNSCheckInterval) * time.Second)
+	}
+
+	return s
+}
+
+// parseResolvers parses "192.168.1.1,10.0.0.53:5353" into host:port addresses
+func parseResolvers(value string) []string {
+	resolvers := make([]string, 0)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(entry); err != nil {
+			entry = net.JoinHostPort(entry, "53")
+		}
+		resolvers = append(resolvers, entry)
+	}
+	if len(resolvers) == 0 {
+		resolvers = append(resolvers, systemResolver)
+	}
+	return resolvers
+}
+
+// checkBackground checks every record on the interval
+func (s *DNSService) checkBackground(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var records []models.DNSRecord
+		if err := s.db.Find(&records).Error; err != nil {
+			log.Printf("Failed to load DNS records: %v", err)
+			continue
+		}
+		for i := range records {
+			s.check(&records[i])
+		}
+	}
+}
+
+// decodeResults fills Results from the stored JSON
+func decodeResults(records ...*models.DNSRecord) {
+	for _, record := range records {
+		record.Results = make([]models.DNSResolverAnswer, 0)
+		if record.RawResult != "" {
+			json.Unmarshal([]byte(record.RawResult), &record.Results)
+		}
+	}
+}
+
+// List returns the user's DNS records with their last results
+func (s *DNSService) List(userID uint) ([]models.DNSRecord, error) {
+	var records []models.DNSRecord
+	if err := s.db.Where("user_id = ?", userID).Order("name").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for i := range records {
+		decodeResults(&records[i])
+	}
+	return records, nil
+}
+
+// get loads one of the user's records
+func (s *DNSService) get(id, userID uint) (*models.DNSRecord, error) {
+	var record models.DNSRecord
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+		return nil, fmt.Errorf("DNS record not found")
+	}
+	decodeResults(&record)
+	return &record, nil
+}
+
+// validate normalizes a request and checks the linked service belongs to the user
+func (s *DNSService) validate(userID uint, req *models.DNSRecordRequest) error {
+	req.Name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(req.Name)), ".")
+	req.Type = strings.ToUpper(strings.TrimSpace(req.Type))
+	req.Expected = strings.TrimSpace(req.Expected)
+	if req.Type == "" {
+		req.Type = "A"
+	}
+
+	valid := false
+	for _, t := range models.DNSRecordTypes {
+		valid = valid || t == req.Type
+	}
+	if !valid {
+		return fmt.Errorf("record type must be one of %s", strings.Join(models.DNSRecordTypes, ", "))
+	}
+
+	if req.ServiceID != nil {
+		var count int64
+		s.db.Model(&models.ServiceConfig{}).Where("id = ? AND user_id = ?", *req.ServiceID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("service not found")
+		}
+	}
+	if req.Expected == "" && (req.ServiceID == nil || req.Type == "CNAME") {
+		return fmt.Errorf("expected value is required")
+	}
+	if req.Type != "CNAME" {
+		for _, value := range splitExpected(req.Expected) {
+			if net.ParseIP(value) == nil {
+				return fmt.Errorf("invalid IP address %q", value)
+			}
+		}
+	}
+	return nil
+}
+
+// Create adds a record and checks it right away
+func (s *DNSService) Create(userID uint, req models.DNSRecordRequest) (*models.DNSRecord, error) {
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+	record := models.DNSRecord{
+		UserID:    userID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Expected:  req.Expected,
+		ServiceID: req.ServiceID,
+		Status:    models.DNSStatusUnknown,
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	s.check(&record)
+	return &record, nil
+}
+
+// Update changes a record and checks it again
+func (s *DNSService) Update(id, userID uint, req models.DNSRecordRequest) (*models.DNSRecord, error) {
+	record, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(record).Updates(map[string]interface{}{
+		"name":       req.Name,
+		"type":       req.Type,
+		"expected":   req.Expected,
+		"service_id": req.ServiceID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	record.Name, record.Type, record.Expected, record.ServiceID = req.Name, req.Type, req.Expected, req.ServiceID
+	s.check(record)
+	return record, nil
+}
+
+// Delete removes a record
+func (s *DNSService) Delete(id, userID uint) error {
+	record, err := s.get(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(record).Error
+}
+
+// Check resolves one record now
+func (s *DNSService) Check(id, userID uint) (*models.DNSRecord, error) {
+	record, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.check(record)
+	return record, nil
+}
+
+// splitExpected splits a comma-separated expected value
+func splitExpected(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// expectedAnswers returns the normalized answers a record should resolve to
+func (s *DNSService) expectedAnswers(record *models.DNSRecord) ([]string, error) {
+	values := splitExpected(record.Expected)
+	if len(values) == 0 && record.ServiceID != nil {
+		s.db.Model(&models.Device{}).
+			Joins("JOIN service_configs ON service_configs.device_id = devices.id").
+			Where("service_configs.id = ?", *record.ServiceID).Pluck("devices.ip", &values)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("linked service has no device to take the address from")
+		}
+	}
+	return normalizeAnswers(record.Type, values), nil
+}
+
+// normalizeAnswers makes answers comparable: canonical IPs, or lowercase
+// names without the trailing dot
+func normalizeAnswers(recordType string, values []string) []string {
+	normalized := make([]string, 0, len(values))
+	for _, v := range values {
+		if recordType == "CNAME" {
+			normalized = append(normalized, strings.TrimSuffix(strings.ToLower(v), "."))
+		} else if ip := net.ParseIP(v); ip != nil {
+			normalized = append(normalized, ip.String())
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// lookup resolves a record against one resolver
+func lookup(resolverAddr, name, recordType string) ([]string, error) {
+	resolver := net.DefaultResolver
+	if resolverAddr != systemResolver {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, resolverAddr)
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	switch recordType {
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return []string{cname}, nil
+	default:
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(ips))
+		for i, ip := range ips {
+			answers[i] = ip.String()
+		}
+		return answers, nil
+	}
+}
+
+// check resolves a record against every resolver, stores the outcome and
+// records an event when the status changes
+func (s *DNSService) check(record *models.DNSRecord) {
+	previous := record.Status
+	expected, expectedErr := s.expectedAnswers(record)
+
+	results := make([]models.DNSResolverAnswer, 0, len(s.resolvers))
+	status := models.DNSStatusOK
+	for _, resolver := range s.resolvers {
+		result := models.DNSResolverAnswer{Resolver: resolver, Answers: make([]string, 0)}
+		answers, err := lookup(resolver, record.Name, record.Type)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			if status == models.DNSStatusOK {
+				status = models.DNSStatusError
+			}
+		default:
+			result.Answers = normalizeAnswers(record.Type, answers)
+			result.Match = expectedErr == nil && strings.Join(result.Answers, ",") == strings.Join(expected, ",")
+			if !result.Match {
+				status = models.DNSStatusMismatch
+			}
+		}
+		results = append(results, result)
+	}
+	if expectedErr != nil {
+		status = models.DNSStatusError
+		results = append(results, models.DNSResolverAnswer{Resolver: "expected", Answers: make([]string, 0), Error: expectedErr.Error()})
+	}
+
+	data, _ := json.Marshal(results)
+	now := time.Now()
+	record.Status = status
+	record.Results = results
+	record.RawResult = string(data)
+	record.LastCheck = &now
+	s.db.Model(record).Updates(map[string]interface{}{
+		"status":     status,
+		"results":    record.RawResult,
+		"last_check": now,
+	})
+
+	if status == previous || (status == models.DNSStatusOK && previous == models.DNSStatusUnknown) {
+		return
+	}
+	details := map[string]interface{}{"recordId": record.ID, "name": record.Name, "type": record.Type, "expected": expected, "results": results}
+	switch status {
+	case models.DNSStatusOK:
+		s.events.RecordFor(record.UserID, "dns_resolved", models.SeverityInfo, "dns",
+			"DNS record matches again", fmt.Sprintf("%s %s resolves as expected", record.Type, record.Name), details)
+	case models.DNSStatusMismatch:
+		s.events.RecordFor(record.UserID, "dns_mismatch", models.SeverityWarning, "dns",
+			"DNS record mismatch", fmt.Sprintf("%s %s does not resolve to %s", record.Type, record.Name, strings.Join(expected, ", ")), details)
+	case models.DNSStatusError:
+		s.events.RecordFor(record.UserID, "dns_error", models.SeverityWarning, "dns",
+			"DNS record lookup failed", fmt.Sprintf("%s %s could not be checked", record.Type, record.Name), details)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/homelab/backend/models"
+)
+
+// containerEventActions are the container events passed on to clients. The
+// daemon matches health_status against "health_status: <status>" too.
+var containerEventActions = []events.Action{
+	events.ActionStart,
+	events.ActionStop,
+	events.ActionDie,
+	events.ActionHealthStatus,
+}
+
+// WatchContainerEvents calls fn with each container start, stop, die and
+// health_status event until ctx is cancelled, fn fails or the daemon closes
+// the event stream
+func (s *DockerService) WatchContainerEvents(ctx context.Context, fn func(models.ContainerEvent) error) error {
+	if s.client == nil {
+		return fmt.Errorf("docker not connected")
+	}
+
+	args := filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)))
+	for _, action := range containerEventActions {
+		args.Add("event", string(action))
+	}
+	msgs, errs := s.client.Events(ctx, types.EventsOptions{Filters: args})
+
+	for {
+		select {
+		case msg := <-msgs:
+			if err := fn(containerEvent(msg)); err != nil {
+				return err
+			}
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("docker event stream closed: %w", err)
+		}
+	}
+}
+
+// containerEvent converts a daemon event message
+func containerEvent(msg events.Message) models.ContainerEvent {
+	event := models.ContainerEvent{
+		Action:      string(msg.Action),
+		ContainerID: shortID(msg.Actor.ID),
+		Name:        msg.Actor.Attributes["name"],
+		Image:       msg.Actor.Attributes["image"],
+		Time:        time.Unix(0, msg.TimeNano),
+	}
+
+	switch {
+	case msg.Action == events.ActionStart:
+		event.State = "running"
+	case msg.Action == events.ActionStop:
+		event.State = "exited"
+	case msg.Action == events.ActionDie:
+		event.State = "exited"
+		if code, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+			event.ExitCode = &code
+		}
+	case strings.HasPrefix(string(msg.Action), string(events.ActionHealthStatus)):
+		event.Action = string(events.ActionHealthStatus)
+		event.Health = strings.TrimSpace(strings.TrimPrefix(string(msg.Action), string(events.ActionHealthStatus)+":"))
+	}
+	return event
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// defaultExecShell starts bash when the image has it and sh otherwise
+var defaultExecShell = []string{"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"}
+
+// ContainerExec is an interactive TTY process running inside a container
+type ContainerExec struct {
+	ID     string
+	conn   types.HijackedResponse
+	docker *DockerService
+}
+
+// ExecShell starts an interactive shell in a running container with a TTY of
+// the given size. shell overrides the default of bash, falling back to sh.
+func (s *DockerService) ExecShell(id, shell string, cols, rows uint) (*ContainerExec, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	info, err := s.client.ContainerInspect(s.ctx, id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("container not found: %s", id)
+		}
+		return nil, err
+	}
+	if info.State == nil || !info.State.Running {
+		return nil, fmt.Errorf("container is not running")
+	}
+
+	cmd := defaultExecShell
+	if shell = strings.TrimSpace(shell); shell != "" {
+		cmd = []string{shell}
+	}
+	var size *[2]uint
+	if cols > 0 && rows > 0 {
+		size = &[2]uint{rows, cols}
+	}
+
+	created, err := s.client.ContainerExecCreate(s.ctx, info.ID, types.ExecConfig{
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		ConsoleSize:  size,
+		Env:          []string{"TERM=xterm-256color"},
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %v", err)
+	}
+
+	conn, err := s.client.ContainerExecAttach(s.ctx, created.ID, types.ExecStartCheck{Tty: true, ConsoleSize: size})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach exec: %v", err)
+	}
+	return &ContainerExec{ID: created.ID, conn: conn, docker: s}, nil
+}
+
+// Read reads the process's output; with a TTY stdout and stderr are combined
+func (e *ContainerExec) Read(p []byte) (int, error) {
+	return e.conn.Reader.Read(p)
+}
+
+// Write sends input to the process
+func (e *ContainerExec) Write(p []byte) (int, error) {
+	return e.conn.Conn.Write(p)
+}
+
+// Resize changes the TTY size
+func (e *ContainerExec) Resize(cols, rows uint) error {
+	if cols == 0 || rows == 0 {
+		return fmt.Errorf("invalid size %dx%d", cols, rows)
+	}
+	return e.docker.client.ContainerExecResize(e.docker.ctx, e.ID, container.ResizeOptions{Width: cols, Height: rows})
+}
+
+// ExitCode returns the process's exit code once it has finished
+func (e *ContainerExec) ExitCode() (int, bool) {
+	info, err := e.docker.client.ContainerExecInspect(e.docker.ctx, e.ID)
+	if err != nil || info.Running {
+		return 0, false
+	}
+	return info.ExitCode, true
+}
+
+// Close detaches from the process. A shell still running gets a hangup
+// when its TTY closes.
+func (e *ContainerExec) Close() {
+	e.conn.Close()
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	// dockerDialTimeout bounds connecting to a remote daemon
+	dockerDialTimeout = 10 * time.Second
+	// dockerPingTimeout bounds the status check of each host
+	dockerPingTimeout = 5 * time.Second
+	// defaultDockerSocket is the daemon socket reached over SSH
+	defaultDockerSocket = "/var/run/docker.sock"
+)
+
+// LocalDockerHost selects the daemon the backend runs next to
+const LocalDockerHost = "local"
+
+// DockerHostService manages remote Docker hosts and hands out a
+// DockerService per host, connecting on first use
+type DockerHostService struct {
+	db    *gorm.DB
+	local *DockerService
+
+	mu      sync.Mutex
+	clients map[uint]*DockerService
+}
+
+// NewDockerHostService creates a new DockerHostService
+func NewDockerHostService(local *DockerService) *DockerHostService {
+	return &DockerHostService{
+		db:      database.GetDB(),
+		local:   local,
+		clients: make(map[uint]*DockerService),
+	}
+}
+
+// Local returns the local Docker daemon
+func (s *DockerHostService) Local() *DockerService {
+	return s.local
+}
+
+// Resolve returns the DockerService for a host reference: "" or "local" for
+// the local daemon, otherwise a Docker host ID
+func (s *DockerHostService) Resolve(ref string) (*DockerService, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || ref == LocalDockerHost {
+		return s.local, nil
+	}
+	id, err := strconv.ParseUint(ref, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q", ref)
+	}
+
+	s.mu.Lock()
+	svc, ok := s.clients[uint(id)]
+	s.mu.Unlock()
+	if ok {
+		return svc, nil
+	}
+
+	var host models.DockerHost
+	if err := s.db.First(&host, id).Error; err != nil {
+		return nil, fmt.Errorf("docker host not found")
+	}
+	// Connect without holding the lock, an unreachable host can take a while
+	svc, err = newRemoteDockerService(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker host %s: %w", host.Name, err)
+	}
+
+	s.mu.Lock()
+	if existing, ok := s.clients[host.ID]; ok {
+		s.mu.Unlock()
+		svc.Close()
+		return existing, nil
+	}
+	s.clients[host.ID] = svc
+	s.mu.Unlock()
+
+	if tunnel, ok := svc.tunnel.(*ssh.Client); ok {
+		// Reconnect on the next request once the SSH connection drops
+		go func() {
+			tunnel.Wait()
+			s.forgetService(host.ID, svc)
+		}()
+	}
+	return svc, nil
+}
+
+// forget closes a host's connection so the next request reconnects
+func (s *DockerHostService) forget(id uint) {
+	s.mu.Lock()
+	svc, ok := s.clients[id]
+	delete(s.clients, id)
+	s.mu.Unlock()
+	if ok {
+		svc.Close()
+	}
+}
+
+// forgetService forgets a host's connection if it is still the given one
+func (s *DockerHostService) forgetService(id uint, svc *DockerService) {
+	s.mu.Lock()
+	current, ok := s.clients[id]
+	if ok && current == svc {
+		delete(s.clients, id)
+	}
+	s.mu.Unlock()
+	svc.Close()
+}
+
+// List returns the local daemon followed by the remote hosts, each with
+// whether it answers
+func (s *DockerHostService) List() ([]models.DockerHostStatus, error) {
+	var hosts []models.DockerHost
+	if err := s.db.Order("name ASC").Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]models.DockerHostStatus, len(hosts)+1)
+	result[0] = models.DockerHostStatus{
+		DockerHost: models.DockerHost{Name: LocalDockerHost, Endpoint: "unix://" + defaultDockerSocket},
+		Local:      true,
+	}
+	s.local.status(&result[0])
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		result[i+1] = models.DockerHostStatus{DockerHost: host}
+		wg.Add(1)
+		go func(status *models.DockerHostStatus) {
+			defer wg.Done()
+			svc, err := s.Resolve(strconv.FormatUint(uint64(status.ID), 10))
+			if err != nil {
+				status.Error = err.Error()
+				return
+			}
+			if !svc.status(status) {
+				// Reconnect next time, e.g. after the host rebooted
+				s.forget(status.ID)
+			}
+		}(&result[i+1])
+	}
+	wg.Wait()
+	return result, nil
+}
+
+// Get returns a Docker host with its status
+func (s *DockerHostService) Get(id uint) (*models.DockerHostStatus, error) {
+	var host models.DockerHost
+	if err := s.db.First(&host, id).Error; err != nil {
+		return nil, fmt.Errorf("docker host not found")
+	}
+
+	status := &models.DockerHostStatus{DockerHost: host}
+	svc, err := s.Resolve(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+	if !svc.status(status) {
+		s.forget(id)
+	}
+	return status, nil
+}
+
+// Create adds a Docker host
+func (s *DockerHostService) Create(req models.DockerHostRequest) (*models.DockerHostStatus, error) {
+	var host models.DockerHost
+	if err := applyDockerHostRequest(&host, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkName(host.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&host).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(host.ID)
+}
+
+// Update changes a Docker host and reconnects to it
+func (s *DockerHostService) Update(id uint, req models.DockerHostRequest) (*models.DockerHostStatus, error) {
+	var host models.DockerHost
+	if err := s.db.First(&host, id).Error; err != nil {
+		return nil, fmt.Errorf("docker host not found")
+	}
+	if err := applyDockerHostRequest(&host, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkName(host.Name, host.ID); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&host).Error; err != nil {
+		return nil, err
+	}
+	s.forget(host.ID)
+	return s.Get(host.ID)
+}
+
+// ResetSSHHostKey forgets a host's pinned SSH host key and reconnects, which
+// pins the key the host presents now
+func (s *DockerHostService) ResetSSHHostKey(id uint) (*models.DockerHostStatus, error) {
+	var host models.DockerHost
+	if err := s.db.First(&host, id).Error; err != nil {
+		return nil, fmt.Errorf("docker host not found")
+	}
+	if err := s.db.Model(&host).UpdateColumns(map[string]interface{}{
+		"ssh_host_key":             "",
+		"ssh_host_key_fingerprint": "",
+	}).Error; err != nil {
+		return nil, err
+	}
+	s.forget(host.ID)
+	return s.Get(host.ID)
+}
+
+// Delete removes a Docker host and closes its connection
+func (s *DockerHostService) Delete(id uint) error {
+	result := s.db.Delete(&models.DockerHost{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("docker host not found")
+	}
+	s.forget(id)
+	return nil
+}
+
+func (s *DockerHostService) checkName(name string, id uint) error {
+	if name == LocalDockerHost {
+		return fmt.Errorf("invalid name: %q is reserved for the local daemon", name)
+	}
+	var count int64
+	s.db.Model(&models.DockerHost{}).Where("name = ? AND id <> ?", name, id).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("docker host %q already exists", name)
+	}
+	return nil
+}
+
+// applyDockerHostRequest validates the endpoint and stores the secrets
+// encrypted; empty secrets keep the current ones
+func applyDockerHostRequest(host *models.DockerHost, req models.DockerHostRequest) error {
+	endpoint, err := url.Parse(strings.TrimSpace(req.Endpoint))
+	if err != nil || endpoint.Host == "" {
+		return fmt.Errorf("invalid endpoint: expected tcp://host:port or ssh://user@host")
+	}
+	switch endpoint.Scheme {
+	case "tcp":
+		if endpoint.Port() == "" {
+			return fmt.Errorf("invalid endpoint: tcp endpoints need a port, usually 2376 with TLS")
+		}
+	case "ssh":
+		if endpoint.User.Username() == "" {
+			return fmt.Errorf("invalid endpoint: ssh endpoints need a user, ssh://user@host")
+		}
+		if _, ok := endpoint.User.Password(); ok {
+			return fmt.Errorf("invalid endpoint: give the SSH password separately, not in the URL")
+		}
+	default:
+		return fmt.Errorf("invalid endpoint: scheme must be tcp or ssh")
+	}
+
+	host.Name = strings.TrimSpace(req.Name)
+	host.Endpoint = endpoint.String()
+	host.TLSCACert = strings.TrimSpace(req.TLSCACert)
+	host.TLSCert = strings.TrimSpace(req.TLSCert)
+	host.TLSSkipVerify = req.TLSSkipVerify
+	host.SocketPath = strings.TrimSpace(req.SocketPath)
+
+	secrets := []struct {
+		value string
+		field *string
+	}{
+		{req.TLSKey, &host.TLSKey},
+		{req.SSHPassword, &host.SSHPassword},
+		{req.SSHKey, &host.SSHKey},
+	}
+	for _, secret := range secrets {
+		if secret.value == "" {
+			continue
+		}
+		encrypted, err := EncryptSecret(strings.TrimSpace(secret.value))
+		if err != nil {
+			return err
+		}
+		*secret.field = encrypted
+	}
+
+	if (host.TLSCert == "") != (host.TLSKey == "") {
+		return fmt.Errorf("invalid TLS settings: the client certificate and key go together")
+	}
+	if endpoint.Scheme == "ssh" && host.SSHPassword == "" && host.SSHKey == "" {
+		return fmt.Errorf("invalid SSH settings: a password or private key is required")
+	}
+	return nil
+}
+
+// newRemoteDockerService connects to a remote daemon over TCP or through an
+// SSH tunnel to its socket
+func newRemoteDockerService(host models.DockerHost) (*DockerService, error) {
+	endpoint, err := url.Parse(host.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := &DockerService{ctx: context.Background(), statsCache: make(map[string]cachedStats)}
+	switch endpoint.Scheme {
+	case "tcp":
+		transport := &http.Transport{}
+		if host.TLSCACert != "" || host.TLSCert != "" || host.TLSSkipVerify || endpoint.Port() == "2376" {
+			tlsConfig, err := dockerTLSConfig(host)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = tlsConfig
+		}
+		svc.client, err = client.NewClientWithOpts(
+			client.WithHTTPClient(&http.Client{Transport: transport}),
+			client.WithHost(host.Endpoint),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+	case "ssh":
+		tunnel, err := dialDockerSSH(host, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		socket := host.SocketPath
+		if socket == "" {
+			socket = defaultDockerSocket
+		}
+		svc.client, err = client.NewClientWithOpts(
+			client.WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+			// The address is a placeholder; every connection goes through the tunnel
+			client.WithHost("tcp://docker.ssh:2375"),
+			client.WithDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return tunnel.Dial("unix", socket)
+			}),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			tunnel.Close()
+			return nil, err
+		}
+		svc.tunnel = tunnel
+
+	default:
+		return nil, fmt.Errorf("unsupported endpoint scheme %q", endpoint.Scheme)
+	}
+	return svc, nil
+}
+
+// dockerTLSConfig builds the TLS settings from the host's PEM certificates
+func dockerTLSConfig(host models.DockerHost) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: host.TLSSkipVerify}
+	if host.TLSCACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(host.TLSCACert)) {
+			return nil, fmt.Errorf("invalid CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+	if host.TLSCert != "" {
+		key, err := DecryptSecret(host.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair([]byte(host.TLSCert), []byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// dialDockerSSH logs in to a Docker host over SSH. The host key is pinned on
+// the first connection, as for devices.
+func dialDockerSSH(host models.DockerHost, endpoint *url.URL) (*ssh.Client, error) {
+	var auth []ssh.AuthMethod
+	if host.SSHKey != "" {
+		key, err := DecryptSecret(host.SSHKey)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH private key: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if host.SSHPassword != "" {
+		password, err := DecryptSecret(host.SSHPassword)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.Password(password))
+	}
+
+	port := endpoint.Port()
+	if port == "" {
+		port = "22"
+	}
+	pin, err := newHostKeyPin(host.SSHHostKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &ssh.ClientConfig{
+		User:            endpoint.User.Username(),
+		Auth:            auth,
+		HostKeyCallback: pin.callback,
+		Timeout:         dockerDialTimeout,
+	}
+	tunnel, err := ssh.Dial("tcp", net.JoinHostPort(endpoint.Hostname(), port), cfg)
+	if err != nil {
+		return nil, err
+	}
+	pin.record(database.GetDB(), &models.DockerHost{}, host.ID, "docker host "+host.Name)
+	return tunnel, nil
+}
+
+// Close disconnects from a remote daemon
+func (s *DockerService) Close() {
+	if s.client != nil {
+		s.client.Close()
+	}
+	if s.tunnel != nil {
+		s.tunnel.Close()
+	}
+}
+
+// status fills in whether the daemon answers, its version and container
+// counts, and reports whether it answered
+func (s *DockerService) status(status *models.DockerHostStatus) bool {
+	if s.client == nil {
+		status.Error = "docker not connected"
+		return false
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, dockerPingTimeout)
+	defer cancel()
+
+	info, err := s.client.Info(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		return false
+	}
+	status.Connected = true
+	status.Version = info.ServerVersion
+	status.Containers = info.Containers
+	status.Running = info.ContainersRunning
+	return true
+}
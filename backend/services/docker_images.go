@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/homelab/backend/models"
+)
+
+// composeProjectLabel is set by docker compose on the containers and volumes it creates
+const composeProjectLabel = "com.docker.compose.project"
+
+// GetImageLayers returns an image's layers with their sizes and the build
+// commands that created them. ref may be an image ID or a name.
+func (s *DockerService) GetImageLayers(ref string) (*models.ImageLayers, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	info, _, err := s.client.ImageInspectWithRaw(s.ctx, ref)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("image not found: %s", ref)
+		}
+		return nil, err
+	}
+	history, err := s.client.ImageHistory(s.ctx, info.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &models.ImageLayers{
+		ID:           info.ID,
+		Tags:         info.RepoTags,
+		Size:         info.Size,
+		Architecture: info.Architecture,
+		OS:           info.Os,
+		Created:      info.Created,
+		Layers:       make([]models.ImageLayer, 0, len(history)),
+	}
+	if image.Tags == nil {
+		image.Tags = []string{}
+	}
+
+	// The daemon lists history newest first
+	for i := len(history) - 1; i >= 0; i-- {
+		h := history[i]
+		layer := models.ImageLayer{
+			CreatedBy: cleanCreatedBy(h.CreatedBy),
+			Created:   time.Unix(h.Created, 0),
+			Size:      h.Size,
+			Comment:   h.Comment,
+			Tags:      h.Tags,
+		}
+		if h.ID != "<missing>" {
+			layer.ID = h.ID
+		}
+		image.Layers = append(image.Layers, layer)
+	}
+	return image, nil
+}
+
+// cleanCreatedBy strips the shell wrapper the builder records around RUN and
+// metadata instructions
+func cleanCreatedBy(cmd string) string {
+	cmd = strings.TrimPrefix(cmd, "/bin/sh -c ")
+	cmd = strings.TrimPrefix(cmd, "#(nop) ")
+	return strings.TrimSpace(cmd)
+}
+
+// GetDiskUsage attributes the space used by images, container writable
+// layers and volumes to the compose projects using them
+func (s *DockerService) GetDiskUsage() (*models.DiskUsageReport, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	df, err := s.client.DiskUsage(s.ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DiskUsageReport{Projects: make([]models.ProjectDiskUsage, 0)}
+	projects := make(map[string]*models.ProjectDiskUsage)
+	project := func(name string) *models.ProjectDiskUsage {
+		if p, ok := projects[name]; ok {
+			return p
+		}
+		p := &models.ProjectDiskUsage{Project: name, Images: make([]string, 0)}
+		projects[name] = p
+		return p
+	}
+
+	// Which projects use each image; standalone containers count as ""
+	users := make(map[string]map[string]bool)
+	for _, c := range df.Containers {
+		name := c.Labels[composeProjectLabel]
+		if users[c.ImageID] == nil {
+			users[c.ImageID] = make(map[string]bool)
+		}
+		users[c.ImageID][name] = true
+
+		if name == "" {
+			report.StandaloneSize += c.SizeRw
+			continue
+		}
+		p := project(name)
+		p.Containers++
+		p.ContainerSize += c.SizeRw
+	}
+
+	var uniqueTotal int64
+	for _, image := range df.Images {
+		unique := image.Size
+		if image.SharedSize > 0 {
+			unique -= image.SharedSize
+		}
+		uniqueTotal += unique
+
+		using := users[image.ID]
+		if len(using) == 0 {
+			report.UnusedImageSize += unique
+			continue
+		}
+		share := unique / int64(len(using))
+		for name := range using {
+			if name == "" {
+				report.StandaloneSize += share
+				continue
+			}
+			p := project(name)
+			p.Images = append(p.Images, imageName(image.RepoTags, image.ID))
+			p.ImageSize += share
+			if image.SharedSize > 0 {
+				p.SharedLayerSize += image.SharedSize
+			}
+		}
+	}
+	if df.LayersSize > uniqueTotal {
+		report.SharedLayerSize = df.LayersSize - uniqueTotal
+	}
+
+	var volumeTotal int64
+	for _, v := range df.Volumes {
+		if v.UsageData == nil || v.UsageData.Size < 0 {
+			continue
+		}
+		volumeTotal += v.UsageData.Size
+		if name := v.Labels[composeProjectLabel]; name != "" {
+			project(name).VolumeSize += v.UsageData.Size
+		} else {
+			report.StandaloneSize += v.UsageData.Size
+		}
+	}
+
+	var containerTotal int64
+	for _, c := range df.Containers {
+		containerTotal += c.SizeRw
+	}
+	for _, cache := range df.BuildCache {
+		report.BuildCacheSize += cache.Size
+	}
+	report.TotalSize = df.LayersSize + containerTotal + volumeTotal + report.BuildCacheSize
+
+	for _, p := range projects {
+		sort.Strings(p.Images)
+		p.TotalSize = p.ImageSize + p.ContainerSize + p.VolumeSize
+		report.Projects = append(report.Projects, *p)
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		if report.Projects[i].TotalSize != report.Projects[j].TotalSize {
+			return report.Projects[i].TotalSize > report.Projects[j].TotalSize
+		}
+		return report.Projects[i].Project < report.Projects[j].Project
+	})
+	return report, nil
+}
+
+// imageName returns an image's first tag, or its short ID when untagged
+func imageName(tags []string, id string) string {
+	for _, tag := range tags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/homelab/backend/models"
+)
+
+const (
+	// maxLogTail is the most lines a log request may ask for
+	maxLogTail = 5000
+	// defaultLogTail is used when a request sets no tail
+	defaultLogTail = "200"
+	// maxLogBytes caps the output returned by GetContainerLogs; the oldest
+	// lines are dropped past it
+	maxLogBytes = 4 << 20
+	// maxLogLine splits lines longer than this
+	maxLogLine = 64 << 10
+)
+
+// ContainerLogOptions selects which part of a container's output to read
+type ContainerLogOptions struct {
+	Tail       string // number of lines from the end, or "all"
+	Since      string // RFC 3339 time, Unix timestamp or duration such as 15m
+	Timestamps bool
+}
+
+// normalize validates the options and fills in defaults
+func (o *ContainerLogOptions) normalize() error {
+	switch o.Tail {
+	case "":
+		o.Tail = defaultLogTail
+	case "all":
+	default:
+		n, err := strconv.Atoi(o.Tail)
+		if err != nil || n < 0 || n > maxLogTail {
+			return fmt.Errorf("invalid tail: must be \"all\" or 0 to %d", maxLogTail)
+		}
+	}
+
+	since, err := parseLogSince(o.Since, time.Now())
+	if err != nil {
+		return err
+	}
+	o.Since = since
+	return nil
+}
+
+// parseLogSince turns a since value into the Unix timestamp Docker expects
+func parseLogSince(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid since: use an RFC 3339 time, Unix timestamp or duration such as 15m")
+}
+
+// GetContainerLogs returns the tail of a container's stdout and stderr
+func (s *DockerService) GetContainerLogs(id string, opts ContainerLogOptions) (*models.ContainerLogs, error) {
+	result := &models.ContainerLogs{ContainerID: id, Lines: []models.ContainerLogLine{}}
+	size := 0
+	err := s.readContainerLogs(s.ctx, id, opts, false, func(line models.ContainerLogLine) error {
+		result.Lines = append(result.Lines, line)
+		size += len(line.Line)
+		// Tail keeps the newest lines, so drop from the front
+		for size > maxLogBytes && len(result.Lines) > 1 {
+			size -= len(result.Lines[0].Line)
+			result.Lines = result.Lines[1:]
+			result.Truncated = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FollowContainerLogs calls fn with the tail of a container's output and
+// then each new line until ctx is cancelled, fn fails or the container stops
+func (s *DockerService) FollowContainerLogs(ctx context.Context, id string, opts ContainerLogOptions, fn func(models.ContainerLogLine) error) error {
+	return s.readContainerLogs(ctx, id, opts, true, fn)
+}
+
+// readContainerLogs streams a container's logs line by line
+func (s *DockerService) readContainerLogs(ctx context.Context, id string, opts ContainerLogOptions, follow bool, fn func(models.ContainerLogLine) error) error {
+	if s.client == nil {
+		return fmt.Errorf("docker not connected")
+	}
+	if err := opts.normalize(); err != nil {
+		return err
+	}
+
+	info, err := s.client.ContainerInspect(ctx, id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("container not found: %s", id)
+		}
+		return err
+	}
+
+	reader, err := s.client.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      opts.Since,
+		Timestamps: opts.Timestamps,
+		Follow:     follow,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	splitter := newLogSplitter(opts.Timestamps, fn)
+	// TTY containers send raw output; others multiplex stdout and stderr
+	if info.Config != nil && info.Config.Tty {
+		err = splitRawLogs(reader, splitter)
+	} else {
+		err = splitMultiplexedLogs(reader, splitter)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return splitter.flush()
+}
+
+// splitRawLogs feeds TTY output, which is all stdout, to the splitter
+func splitRawLogs(r io.Reader, splitter *logSplitter) error {
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if werr := splitter.write("stdout", buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// splitMultiplexedLogs decodes Docker's stream framing: an 8-byte header
+// with the stream type in the first byte and the payload size in the last four
+func splitMultiplexedLogs(r io.Reader, splitter *logSplitter) error {
+	br := bufio.NewReaderSize(r, 32<<10)
+	header := make([]byte, 8)
+	var payload []byte
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		size := int(binary.BigEndian.Uint32(header[4:]))
+		if cap(payload) < size {
+			payload = make([]byte, size)
+		}
+		payload = payload[:size]
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return err
+		}
+
+		switch header[0] {
+		case 0, 1:
+			if err := splitter.write("stdout", payload); err != nil {
+				return err
+			}
+		case 2:
+			if err := splitter.write("stderr", payload); err != nil {
+				return err
+			}
+		case 3:
+			// The daemon reports errors reading the log on its own stream
+			return fmt.Errorf("docker: %s", strings.TrimSpace(string(payload)))
+		}
+	}
+}
+
+// logSplitter buffers partial lines per stream and emits complete ones
+type logSplitter struct {
+	timestamps bool
+	fn         func(models.ContainerLogLine) error
+	pending    map[string][]byte
+}
+
+func newLogSplitter(timestamps bool, fn func(models.ContainerLogLine) error) *logSplitter {
+	return &logSplitter{timestamps: timestamps, fn: fn, pending: map[string][]byte{}}
+}
+
+// write adds output from a stream, emitting each line it completes
+func (l *logSplitter) write(stream string, data []byte) error {
+	buf := append(l.pending[stream], data...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			if len(buf) < maxLogLine {
+				break
+			}
+			i = maxLogLine
+		}
+		if err := l.emit(stream, buf[:i]); err != nil {
+			return err
+		}
+		if i < len(buf) && buf[i] == '\n' {
+			i++
+		}
+		buf = buf[i:]
+	}
+	l.pending[stream] = append(l.pending[stream][:0], buf...)
+	return nil
+}
+
+// flush emits any unterminated lines left at the end of the output
+func (l *logSplitter) flush() error {
+	for _, stream := range []string{"stdout", "stderr"} {
+		if len(l.pending[stream]) > 0 {
+			if err := l.emit(stream, l.pending[stream]); err != nil {
+				return err
+			}
+			l.pending[stream] = nil
+		}
+	}
+	return nil
+}
+
+// emit hands one line to the callback, splitting off Docker's timestamp prefix
+func (l *logSplitter) emit(stream string, raw []byte) error {
+	line := models.ContainerLogLine{Stream: stream, Line: strings.TrimSuffix(string(raw), "\r")}
+	if l.timestamps {
+		if prefix, rest, ok := strings.Cut(line.Line, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+				line.Timestamp = &t
+				line.Line = rest
+			}
+		}
+	}
+	return l.fn(line)
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/homelab/backend/models"
+)
+
+// builtInNetworks are created by the daemon and can't be removed
+var builtInNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// ListVolumes returns all volumes with their sizes and the containers mounting them
+func (s *DockerService) ListVolumes() ([]models.DockerVolume, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	// Disk usage lists every volume along with its size
+	usage, err := s.client.DiskUsage(s.ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := s.client.ContainerList(s.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := make([]models.DockerVolume, 0, len(usage.Volumes))
+	for _, v := range usage.Volumes {
+		if v != nil {
+			volumes = append(volumes, toDockerVolume(*v, containers))
+		}
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// GetVolume returns a volume with the containers mounting it
+func (s *DockerService) GetVolume(name string) (*models.DockerVolume, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	v, err := s.client.VolumeInspect(s.ctx, name)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("volume not found: %s", name)
+		}
+		return nil, err
+	}
+	containers, err := s.client.ContainerList(s.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	vol := toDockerVolume(v, containers)
+	// Inspect doesn't include the size, so look it up in the disk usage
+	if vol.Size < 0 {
+		usage, err := s.client.DiskUsage(s.ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+		if err == nil {
+			for _, u := range usage.Volumes {
+				if u != nil && u.Name == vol.Name && u.UsageData != nil {
+					vol.Size = u.UsageData.Size
+				}
+			}
+		}
+	}
+	return &vol, nil
+}
+
+// CreateVolume creates a volume, with the local driver unless another is given
+func (s *DockerService) CreateVolume(req models.VolumeCreateRequest) (*models.DockerVolume, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	driver := strings.TrimSpace(req.Driver)
+	if driver == "" {
+		driver = "local"
+	}
+	v, err := s.client.VolumeCreate(s.ctx, volume.CreateOptions{
+		Name:       strings.TrimSpace(req.Name),
+		Driver:     driver,
+		DriverOpts: req.Options,
+		Labels:     req.Labels,
+	})
+	if err != nil {
+		if errdefs.IsInvalidParameter(err) {
+			return nil, fmt.Errorf("invalid volume: %v", err)
+		}
+		return nil, err
+	}
+
+	vol := toDockerVolume(v, nil)
+	return &vol, nil
+}
+
+// RemoveVolume removes a volume. A volume used by a container, even a stopped
+// one, is only removed with force.
+func (s *DockerService) RemoveVolume(name string, force bool) error {
+	if s.client == nil {
+		return fmt.Errorf("docker not connected")
+	}
+
+	if err := s.client.VolumeRemove(s.ctx, name, force); err != nil {
+		switch {
+		case client.IsErrNotFound(err):
+			return fmt.Errorf("volume not found: %s", name)
+		case errdefs.IsConflict(err):
+			return fmt.Errorf("volume is in use: %s", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// toDockerVolume converts a volume and finds the containers mounting it
+func toDockerVolume(v volume.Volume, containers []types.Container) models.DockerVolume {
+	vol := models.DockerVolume{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Scope:      v.Scope,
+		Created:    v.CreatedAt,
+		Project:    v.Labels[composeProjectLabel],
+		Labels:     v.Labels,
+		Options:    v.Options,
+		Size:       -1,
+		Containers: []models.VolumeMount{},
+	}
+	if v.UsageData != nil {
+		vol.Size = v.UsageData.Size
+	}
+
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Type != mount.TypeVolume || m.Name != v.Name {
+				continue
+			}
+			vol.Containers = append(vol.Containers, models.VolumeMount{
+				ContainerID:   shortID(c.ID),
+				ContainerName: containerName(c.Names),
+				State:         c.State,
+				Destination:   m.Destination,
+				ReadOnly:      !m.RW,
+			})
+		}
+	}
+	return vol
+}
+
+// ListNetworks returns all networks with the containers attached to them
+func (s *DockerService) ListNetworks() ([]models.DockerNetwork, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	list, err := s.client.NetworkList(s.ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := s.client.ContainerList(s.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]models.DockerNetwork, 0, len(list))
+	for _, n := range list {
+		networks = append(networks, toDockerNetwork(n, containers))
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+	return networks, nil
+}
+
+// GetNetwork returns a network by ID or name with the containers attached to it
+func (s *DockerService) GetNetwork(id string) (*models.DockerNetwork, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	n, err := s.client.NetworkInspect(s.ctx, id, types.NetworkInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("network not found: %s", id)
+		}
+		return nil, err
+	}
+	containers, err := s.client.ContainerList(s.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	nw := toDockerNetwork(n, containers)
+	return &nw, nil
+}
+
+// CreateNetwork creates a network, a bridge unless another driver is given
+func (s *DockerService) CreateNetwork(req models.NetworkCreateRequest) (*models.DockerNetwork, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if builtInNetworks[name] {
+		return nil, fmt.Errorf("invalid network name: %s is reserved", name)
+	}
+	driver := strings.TrimSpace(req.Driver)
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	options := types.NetworkCreate{
+		Driver:     driver,
+		Internal:   req.Internal,
+		Attachable: req.Attachable,
+		EnableIPv6: req.IPv6,
+		Labels:     req.Labels,
+		Options:    req.Options,
+	}
+	if req.Subnet != "" {
+		ipam, err := networkIPAM(req)
+		if err != nil {
+			return nil, err
+		}
+		options.IPAM = ipam
+	} else if req.Gateway != "" || req.IPRange != "" {
+		return nil, fmt.Errorf("invalid network: gateway and ipRange need a subnet")
+	}
+
+	created, err := s.client.NetworkCreate(s.ctx, name, options)
+	if err != nil {
+		switch {
+		case errdefs.IsConflict(err):
+			return nil, fmt.Errorf("network already exists: %s", name)
+		case errdefs.IsInvalidParameter(err), errdefs.IsForbidden(err):
+			return nil, fmt.Errorf("invalid network: %v", err)
+		}
+		return nil, err
+	}
+	if created.Warning != "" {
+		log.Printf("Network %s created with warning: %s", name, created.Warning)
+	}
+	return s.GetNetwork(created.ID)
+}
+
+// networkIPAM checks that the gateway and IP range lie in the subnet
+func networkIPAM(req models.NetworkCreateRequest) (*network.IPAM, error) {
+	_, subnet, err := net.ParseCIDR(req.Subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet: %s", req.Subnet)
+	}
+	if req.Gateway != "" {
+		ip := net.ParseIP(req.Gateway)
+		if ip == nil || !subnet.Contains(ip) {
+			return nil, fmt.Errorf("invalid gateway: %s is not in %s", req.Gateway, subnet)
+		}
+	}
+	if req.IPRange != "" {
+		ip, _, err := net.ParseCIDR(req.IPRange)
+		if err != nil || !subnet.Contains(ip) {
+			return nil, fmt.Errorf("invalid ipRange: %s is not in %s", req.IPRange, subnet)
+		}
+	}
+	return &network.IPAM{
+		Driver: "default",
+		Config: []network.IPAMConfig{{Subnet: subnet.String(), Gateway: req.Gateway, IPRange: req.IPRange}},
+	}, nil
+}
+
+// RemoveNetwork removes a network. The daemon refuses while containers are
+// attached, and the built-in networks can't be removed.
+func (s *DockerService) RemoveNetwork(id string) error {
+	if s.client == nil {
+		return fmt.Errorf("docker not connected")
+	}
+
+	n, err := s.client.NetworkInspect(s.ctx, id, types.NetworkInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("network not found: %s", id)
+		}
+		return err
+	}
+	if builtInNetworks[n.Name] {
+		return fmt.Errorf("cannot remove built-in network: %s", n.Name)
+	}
+
+	if err := s.client.NetworkRemove(s.ctx, n.ID); err != nil {
+		switch {
+		case client.IsErrNotFound(err):
+			return fmt.Errorf("network not found: %s", id)
+		case errdefs.IsForbidden(err), errdefs.IsConflict(err):
+			return fmt.Errorf("network is in use: %s", n.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// toDockerNetwork converts a network and finds the containers attached to it.
+// The container list is used rather than the network's own endpoints so
+// stopped containers are included too.
+func toDockerNetwork(n types.NetworkResource, containers []types.Container) models.DockerNetwork {
+	nw := models.DockerNetwork{
+		ID:         shortID(n.ID),
+		Name:       n.Name,
+		Driver:     n.Driver,
+		Scope:      n.Scope,
+		Internal:   n.Internal,
+		Attachable: n.Attachable,
+		IPv6:       n.EnableIPv6,
+		BuiltIn:    builtInNetworks[n.Name],
+		Created:    n.Created.Format(time.RFC3339),
+		Project:    n.Labels[composeProjectLabel],
+		Subnets:    []models.NetworkSubnet{},
+		Labels:     n.Labels,
+		Options:    n.Options,
+		Containers: []models.NetworkEndpoint{},
+	}
+	for _, cfg := range n.IPAM.Config {
+		nw.Subnets = append(nw.Subnets, models.NetworkSubnet{
+			Subnet:  cfg.Subnet,
+			Gateway: cfg.Gateway,
+			IPRange: cfg.IPRange,
+		})
+	}
+
+	for _, c := range containers {
+		if c.NetworkSettings == nil {
+			continue
+		}
+		for name, ep := range c.NetworkSettings.Networks {
+			if ep == nil || (ep.NetworkID != n.ID && name != n.Name) {
+				continue
+			}
+			endpoint := models.NetworkEndpoint{
+				ContainerID:   shortID(c.ID),
+				ContainerName: containerName(c.Names),
+				State:         c.State,
+				IPv4Address:   ep.IPAddress,
+				IPv6Address:   ep.GlobalIPv6Address,
+				MacAddress:    ep.MacAddress,
+				Aliases:       ep.Aliases,
+			}
+			if ep.IPAddress != "" && ep.IPPrefixLen > 0 {
+				endpoint.IPv4Address = fmt.Sprintf("%s/%d", ep.IPAddress, ep.IPPrefixLen)
+			}
+			nw.Containers = append(nw.Containers, endpoint)
+		}
+	}
+	sort.Slice(nw.Containers, func(i, j int) bool {
+		return nw.Containers[i].ContainerName < nw.Containers[j].ContainerName
+	})
+	return nw
+}
+
+// containerName returns a container's primary name without the leading slash
+func containerName(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(names[0], "/")
+}
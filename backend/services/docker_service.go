@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/homelab/backend/models"
 )
 
@@ -20,6 +22,7 @@ type DockerService struct {
 	ctx        context.Context
 	statsCache map[string]cachedStats
 	cacheMutex sync.RWMutex
+	tunnel     io.Closer // SSH connection of a remote host
 }
 
 type cachedStats struct {
@@ -50,6 +53,18 @@ func (s *DockerService) IsConnected() bool {
 	return s.client != nil
 }
 
+// ServerVersion returns the Docker engine version, or "" if unavailable
+func (s *DockerService) ServerVersion() string {
+	if s.client == nil {
+		return ""
+	}
+	version, err := s.client.ServerVersion(s.ctx)
+	if err != nil {
+		return ""
+	}
+	return version.Version
+}
+
 // GetContainers returns all containers (optimized - no stats by default)
 func (s *DockerService) GetContainers() []models.Container {
 	if s.client == nil {
@@ -104,6 +119,29 @@ func (s *DockerService) GetContainersBasic() []models.Container {
 	return result
 }
 
+// FindContainer finds a container by name or by a "label:key=value" reference
+func (s *DockerService) FindContainer(ref string) (*models.Container, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	labelKey, labelValue, byLabel := "", "", strings.HasPrefix(ref, "label:")
+	if byLabel {
+		labelKey, labelValue, _ = strings.Cut(strings.TrimPrefix(ref, "label:"), "=")
+	}
+
+	for _, c := range s.GetContainersBasic() {
+		if byLabel {
+			if value, ok := c.Labels[labelKey]; ok && value == labelValue {
+				return &c, nil
+			}
+		} else if c.Name == strings.TrimPrefix(ref, "/") {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("container %q not found", ref)
+}
+
 // getCachedStats returns cached stats or fetches new ones
 func (s *DockerService) getCachedStats(containerID string) models.ContainerStats {
 	s.cacheMutex.RLock()
@@ -147,6 +185,32 @@ func (s *DockerService) GetContainer(id string) (*models.Container, error) {
 	return &container, nil
 }
 
+// GetResources returns the CPU and memory limits configured on a container
+func (s *DockerService) GetResources(id string) (models.ContainerResources, error) {
+	if s.client == nil {
+		return models.ContainerResources{}, fmt.Errorf("docker not connected")
+	}
+
+	info, err := s.client.ContainerInspect(s.ctx, id)
+	if err != nil {
+		return models.ContainerResources{}, fmt.Errorf("container not found: %s", id)
+	}
+
+	var resources models.ContainerResources
+	if info.HostConfig != nil {
+		hc := info.HostConfig.Resources
+		switch {
+		case hc.NanoCPUs > 0:
+			resources.CPULimit = float64(hc.NanoCPUs) / 1e9
+		case hc.CPUQuota > 0 && hc.CPUPeriod > 0:
+			resources.CPULimit = float64(hc.CPUQuota) / float64(hc.CPUPeriod)
+		}
+		resources.MemoryLimit = hc.Memory
+		resources.MemoryReservation = hc.MemoryReservation
+	}
+	return resources, nil
+}
+
 // StartContainer starts a container
 func (s *DockerService) StartContainer(id string) error {
 	if s.client == nil {
@@ -176,6 +240,91 @@ func (s *DockerService) RestartContainer(id string) error {
 	return s.client.ContainerRestart(s.ctx, id, container.StopOptions{Timeout: &timeout})
 }
 
+// containerState inspects a container for the lifecycle actions below
+func (s *DockerService) containerState(id string) (*types.ContainerState, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+
+	info, err := s.client.ContainerInspect(s.ctx, id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("container not found: %s", id)
+		}
+		return nil, err
+	}
+	if info.State == nil {
+		return &types.ContainerState{}, nil
+	}
+	return info.State, nil
+}
+
+// PauseContainer freezes a running container's processes
+func (s *DockerService) PauseContainer(id string) error {
+	state, err := s.containerState(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case state.Paused:
+		return fmt.Errorf("container is already paused: %s", id)
+	case !state.Running:
+		return fmt.Errorf("container is not running: %s", id)
+	}
+
+	return s.client.ContainerPause(s.ctx, id)
+}
+
+// UnpauseContainer resumes a paused container
+func (s *DockerService) UnpauseContainer(id string) error {
+	state, err := s.containerState(id)
+	if err != nil {
+		return err
+	}
+	if !state.Paused {
+		return fmt.Errorf("container is not paused: %s", id)
+	}
+
+	return s.client.ContainerUnpause(s.ctx, id)
+}
+
+// KillContainer sends a signal to a running container's main process,
+// SIGKILL when signal is empty
+func (s *DockerService) KillContainer(id, signal string) error {
+	state, err := s.containerState(id)
+	if err != nil {
+		return err
+	}
+	if !state.Running {
+		return fmt.Errorf("container is not running: %s", id)
+	}
+	if signal == "" {
+		signal = "SIGKILL"
+	}
+
+	if err := s.client.ContainerKill(s.ctx, id, signal); err != nil {
+		if errdefs.IsInvalidParameter(err) {
+			return fmt.Errorf("invalid signal: %s", signal)
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveContainer deletes a container. A running container is only removed
+// with force, which kills it first; volumes also removes its anonymous volumes.
+func (s *DockerService) RemoveContainer(id string, force, volumes bool) error {
+	state, err := s.containerState(id)
+	if err != nil {
+		return err
+	}
+	if state.Running && !force {
+		return fmt.Errorf("container is running: %s", id)
+	}
+
+	return s.client.ContainerRemove(s.ctx, id, container.RemoveOptions{Force: force, RemoveVolumes: volumes})
+}
+
 // convertContainer converts Docker API container to our model
 func (s *DockerService) convertContainer(c types.Container) models.Container {
 	name := ""
@@ -378,6 +527,63 @@ func (s *DockerService) getContainerStats(containerID string) models.ContainerSt
 	}
 }
 
+// containerNetCounters is a container's cumulative network counters
+type containerNetCounters struct {
+	name        string
+	networkMode string
+	rx, tx      uint64
+}
+
+// networkCounters reads the network counters of running containers with
+// their own network stack, uncached so that rates between two reads are
+// exact. Containers on the host's network are returned by name in hostNetwork.
+func (s *DockerService) networkCounters() (counters map[string]containerNetCounters, hostNetwork []string) {
+	counters = make(map[string]containerNetCounters)
+	if s.client == nil {
+		return counters, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range s.GetContainersBasic() {
+		// Containers joined to another one's network report its counters
+		if c.State != "running" || c.NetworkMode == "none" || strings.HasPrefix(c.NetworkMode, "container:") {
+			continue
+		}
+		if c.NetworkMode == "host" {
+			hostNetwork = append(hostNetwork, c.Name)
+			continue
+		}
+		wg.Add(1)
+		go func(c models.Container) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+			defer cancel()
+
+			stats, err := s.client.ContainerStatsOneShot(ctx, c.ID)
+			if err != nil {
+				return
+			}
+			defer stats.Body.Close()
+			var statsJSON types.StatsJSON
+			if err := json.NewDecoder(stats.Body).Decode(&statsJSON); err != nil {
+				return
+			}
+
+			entry := containerNetCounters{name: c.Name, networkMode: c.NetworkMode}
+			for _, v := range statsJSON.Networks {
+				entry.rx += v.RxBytes
+				entry.tx += v.TxBytes
+			}
+			mu.Lock()
+			counters[c.ID] = entry
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return counters, hostNetwork
+}
+
 // formatDuration formats a duration in a human-readable way
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/homelab/backend/models"
+)
+
+// containerUpdateTimeout bounds pulling the new image and recreating a container
+const containerUpdateTimeout = 10 * time.Minute
+
+// updatableReference returns an error unless an image reference names a
+// tag that can move to a newer image
+func updatableReference(ref string) error {
+	if ref == "" || strings.HasPrefix(ref, "sha256:") || strings.Contains(ref, "@") {
+		return fmt.Errorf("cannot update a container not created from an image tag: %s", ref)
+	}
+	return nil
+}
+
+// RemoteDigest returns the digest a registry serves for an image reference
+func (s *DockerService) RemoteDigest(ctx context.Context, ref string) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("docker not connected")
+	}
+	inspect, err := s.client.DistributionInspect(ctx, ref, "")
+	if err != nil {
+		return "", err
+	}
+	return inspect.Descriptor.Digest.String(), nil
+}
+
+// imageDigests returns the registry digests of a local image, without the
+// repository part. Images built locally have none.
+func (s *DockerService) imageDigests(ctx context.Context, imageID string) ([]string, error) {
+	image, _, err := s.client.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]string, 0, len(image.RepoDigests))
+	for _, repoDigest := range image.RepoDigests {
+		if _, digest, ok := strings.Cut(repoDigest, "@"); ok {
+			digests = append(digests, digest)
+		}
+	}
+	return digests, nil
+}
+
+// pullImage pulls an image reference and waits for the pull to finish
+func (s *DockerService) pullImage(ctx context.Context, ref string) error {
+	reader, err := s.client.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return jsonmessage.DisplayJSONMessagesStream(reader, io.Discard, 0, false, nil)
+}
+
+// UpdateContainer pulls the container's image tag and, when it moved to a
+// new image, recreates the container from it with the same name, settings,
+// networks and volumes. The old container is kept until the new one starts
+// and restored if anything fails.
+func (s *DockerService) UpdateContainer(id string) (*models.Container, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("docker not connected")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, containerUpdateTimeout)
+	defer cancel()
+
+	info, err := s.client.ContainerInspect(ctx, id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("container not found: %s", id)
+		}
+		return nil, err
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+	ref := info.Config.Image
+	if err := updatableReference(ref); err != nil {
+		return nil, err
+	}
+	if info.HostConfig.AutoRemove {
+		return nil, fmt.Errorf("cannot update a container that is removed when it stops: %s", name)
+	}
+
+	if err := s.pullImage(ctx, ref); err != nil {
+		return nil, fmt.Errorf("failed to pull %s: %v", ref, err)
+	}
+	pulled, _, err := s.client.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if pulled.ID == info.Image {
+		return nil, fmt.Errorf("container is already up to date: %s", name)
+	}
+
+	cfg := *info.Config
+	if old, _, err := s.client.ImageInspectWithRaw(ctx, info.Image); err == nil && old.Config != nil {
+		withoutImageDefaults(&cfg, old.Config)
+	}
+	if cfg.Hostname == shortID(info.ID) {
+		// The default hostname is the container ID; let the new one get its own
+		cfg.Hostname = ""
+	}
+	hostConfig := *info.HostConfig
+	keepAnonymousVolumes(&hostConfig, info.Mounts)
+	primary, extra := containerEndpoints(info)
+
+	running := info.State != nil && (info.State.Running || info.State.Paused)
+	if running {
+		timeout := 10
+		if err := s.client.ContainerStop(ctx, info.ID, container.StopOptions{Timeout: &timeout}); err != nil {
+			return nil, err
+		}
+	}
+	backup := fmt.Sprintf("%s-%s", name, shortID(info.ID))
+	if err := s.client.ContainerRename(ctx, info.ID, backup); err != nil {
+		s.restoreContainer(info.ID, "", running)
+		return nil, err
+	}
+
+	created, err := s.client.ContainerCreate(ctx, &cfg, &hostConfig, primary, nil, name)
+	if err != nil {
+		s.restoreContainer(info.ID, name, running)
+		return nil, fmt.Errorf("failed to create container: %v", err)
+	}
+	fail := func(err error) (*models.Container, error) {
+		s.client.ContainerRemove(s.ctx, created.ID, container.RemoveOptions{Force: true})
+		s.restoreContainer(info.ID, name, running)
+		return nil, err
+	}
+	for networkName, endpoint := range extra {
+		if err := s.client.NetworkConnect(ctx, networkName, created.ID, endpoint); err != nil {
+			return fail(fmt.Errorf("failed to connect network %s: %v", networkName, err))
+		}
+	}
+	if running {
+		if err := s.client.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+			return fail(fmt.Errorf("failed to start container: %v", err))
+		}
+	}
+
+	if err := s.client.ContainerRemove(ctx, info.ID, container.RemoveOptions{}); err != nil {
+		log.Printf("Failed to remove old container %s after updating %s: %v", backup, name, err)
+	}
+	return s.GetContainer(created.ID)
+}
+
+// restoreContainer puts the original container back after a failed update
+func (s *DockerService) restoreContainer(id, name string, start bool) {
+	if name != "" {
+		if err := s.client.ContainerRename(s.ctx, id, name); err != nil {
+			log.Printf("Failed to restore name of container %s: %v", shortID(id), err)
+		}
+	}
+	if start {
+		if err := s.client.ContainerStart(s.ctx, id, container.StartOptions{}); err != nil {
+			log.Printf("Failed to restart container %s: %v", shortID(id), err)
+		}
+	}
+}
+
+// withoutImageDefaults drops the settings a container inherited from its old
+// image, so the new image's command, environment and labels take effect
+func withoutImageDefaults(cfg *container.Config, image *container.Config) {
+	if slices.Equal(cfg.Cmd, image.Cmd) {
+		cfg.Cmd = nil
+	}
+	if slices.Equal(cfg.Entrypoint, image.Entrypoint) {
+		cfg.Entrypoint = nil
+	}
+	if cfg.WorkingDir == image.WorkingDir {
+		cfg.WorkingDir = ""
+	}
+	if cfg.User == image.User {
+		cfg.User = ""
+	}
+	if cfg.StopSignal == image.StopSignal {
+		cfg.StopSignal = ""
+	}
+	if image.Healthcheck != nil && cfg.Healthcheck != nil && slices.Equal(cfg.Healthcheck.Test, image.Healthcheck.Test) {
+		cfg.Healthcheck = nil
+	}
+
+	env := make([]string, 0, len(cfg.Env))
+	for _, e := range cfg.Env {
+		if !slices.Contains(image.Env, e) {
+			env = append(env, e)
+		}
+	}
+	cfg.Env = env
+
+	labels := make(map[string]string, len(cfg.Labels))
+	for k, v := range cfg.Labels {
+		if value, ok := image.Labels[k]; !ok || value != v {
+			labels[k] = v
+		}
+	}
+	cfg.Labels = labels
+
+	for port := range image.ExposedPorts {
+		delete(cfg.ExposedPorts, port)
+	}
+	for path := range image.Volumes {
+		delete(cfg.Volumes, path)
+	}
+}
+
+// keepAnonymousVolumes mounts the old container's anonymous volumes on the
+// new one by name, so their data survives the update
+func keepAnonymousVolumes(hostConfig *container.HostConfig, mounts []types.MountPoint) {
+	mounted := make(map[string]bool)
+	for _, bind := range hostConfig.Binds {
+		if parts := strings.Split(bind, ":"); len(parts) >= 2 {
+			mounted[parts[1]] = true
+		}
+	}
+	for _, m := range hostConfig.Mounts {
+		mounted[m.Target] = true
+	}
+
+	for _, m := range mounts {
+		if m.Type != mount.TypeVolume || m.Name == "" || mounted[m.Destination] {
+			continue
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   m.Name,
+			Target:   m.Destination,
+			ReadOnly: !m.RW,
+		})
+	}
+}
+
+// containerEndpoints returns the network settings to create a copy of a
+// container with: its network mode's endpoint, and the other networks to
+// connect once it exists
+func containerEndpoints(info types.ContainerJSON) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	extra := make(map[string]*network.EndpointSettings)
+	mode := info.HostConfig.NetworkMode
+	if mode.IsHost() || mode.IsNone() || mode.IsContainer() || info.NetworkSettings == nil {
+		return nil, extra
+	}
+
+	var primary *network.NetworkingConfig
+	for name, endpoint := range info.NetworkSettings.Networks {
+		if endpoint == nil {
+			continue
+		}
+		settings := &network.EndpointSettings{
+			IPAMConfig: endpoint.IPAMConfig,
+			Links:      endpoint.Links,
+			DriverOpts: endpoint.DriverOpts,
+		}
+		// Docker adds the short container ID as an alias; the new one gets its own
+		for _, alias := range endpoint.Aliases {
+			if alias != shortID(info.ID) {
+				settings.Aliases = append(settings.Aliases, alias)
+			}
+		}
+
+		if name == mode.NetworkName() {
+			primary = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{name: settings}}
+		} else {
+			extra[name] = settings
+		}
+	}
+	return primary, extra
+}
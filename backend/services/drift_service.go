@@ -0,0 +1,244 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// DriftService detects when a container is recreated with a different
+// configuration than the one it was last seen with
+type DriftService struct {
+	db     *gorm.DB
+	docker *DockerService
+	events *EventService
+
+	seen map[string]string // container name -> container ID already compared
+}
+
+const driftCheckInterval = time.Minute
+
+// NewDriftService creates a new DriftService and starts watching containers
+func NewDriftService(docker *DockerService, events *EventService) *DriftService {
+	s := &DriftService{
+		db:     database.GetDB(),
+		docker: docker,
+		events: events,
+		seen:   make(map[string]string),
+	}
+
+	if docker.IsConnected() {
+		go s.watchBackground()
+	}
+
+	return s
+}
+
+func (s *DriftService) watchBackground() {
+	ticker := time.NewTicker(driftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.check()
+		<-ticker.C
+	}
+}
+
+// check inspects containers whose ID changed since the last pass.
+// A new ID under the same name means the container was recreated.
+func (s *DriftService) check() {
+	for _, c := range s.docker.GetContainersBasic() {
+		if c.Name == "" || s.seen[c.Name] == c.ID {
+			continue
+		}
+
+		inspect, err := s.docker.client.ContainerInspect(s.docker.ctx, c.ID)
+		if err != nil {
+			continue
+		}
+		s.compare(c.Name, c.ID, containerSpec(inspect))
+		s.seen[c.Name] = c.ID
+	}
+}
+
+// compare checks spec against the stored baseline and records drift
+func (s *DriftService) compare(name, containerID string, spec models.ContainerSpec) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	var baseline models.ContainerBaseline
+	if err := s.db.Where("name = ?", name).First(&baseline).Error; err != nil {
+		// First time we see this container: record the baseline
+		baseline = models.ContainerBaseline{Name: name, ContainerID: containerID, ConfigHash: hash, Config: string(data)}
+		if err := s.db.Create(&baseline).Error; err != nil {
+			log.Printf("Failed to store config baseline for %s: %v", name, err)
+		}
+		return
+	}
+
+	if baseline.ConfigHash != hash {
+		var previous models.ContainerSpec
+		json.Unmarshal([]byte(baseline.Config), &previous)
+		drift := diffContainerSpecs(previous, spec)
+
+		fields := make([]string, 0, len(drift))
+		for _, d := range drift {
+			if len(fields) == 0 || fields[len(fields)-1] != d.Field {
+				fields = append(fields, d.Field)
+			}
+		}
+
+		s.events.Record("container_drift", models.SeverityWarning, "docker",
+			"Container configuration drift",
+			fmt.Sprintf("%s was recreated with a different configuration (%s)", name, strings.Join(fields, ", ")),
+			map[string]interface{}{
+				"container":   name,
+				"containerId": containerID,
+				"previousId":  baseline.ContainerID,
+				"changes":     drift,
+			})
+	}
+
+	s.db.Model(&baseline).Updates(map[string]interface{}{
+		"container_id": containerID,
+		"config_hash":  hash,
+		"config":       string(data),
+	})
+}
+
+// containerSpec extracts the drift-relevant configuration from an inspect result
+func containerSpec(c types.ContainerJSON) models.ContainerSpec {
+	spec := models.ContainerSpec{
+		Env:    make(map[string]string),
+		Ports:  []string{},
+		Mounts: []string{},
+		CapAdd: []string{},
+	}
+
+	if c.Config != nil {
+		spec.Image = c.Config.Image
+		spec.Command = strings.Join(c.Config.Cmd, " ")
+		for _, entry := range c.Config.Env {
+			key, value, _ := strings.Cut(entry, "=")
+			sum := sha256.Sum256([]byte(value))
+			spec.Env[key] = hex.EncodeToString(sum[:8])
+		}
+	}
+
+	if c.HostConfig != nil {
+		for port, bindings := range c.HostConfig.PortBindings {
+			for _, b := range bindings {
+				host := b.HostPort
+				if b.HostIP != "" {
+					host = b.HostIP + ":" + host
+				}
+				spec.Ports = append(spec.Ports, fmt.Sprintf("%s->%s", host, port))
+			}
+		}
+		spec.NetworkMode = string(c.HostConfig.NetworkMode)
+		spec.Privileged = c.HostConfig.Privileged
+		spec.CapAdd = append(spec.CapAdd, c.HostConfig.CapAdd...)
+	}
+
+	for _, m := range c.Mounts {
+		source := m.Source
+		if m.Name != "" {
+			source = m.Name
+		}
+		mode := "ro"
+		if m.RW {
+			mode = "rw"
+		}
+		spec.Mounts = append(spec.Mounts, fmt.Sprintf("%s:%s:%s", source, m.Destination, mode))
+	}
+
+	sort.Strings(spec.Ports)
+	sort.Strings(spec.Mounts)
+	sort.Strings(spec.CapAdd)
+	return spec
+}
+
+// diffContainerSpecs lists field-level differences between two specs.
+// Env changes report keys only, never values.
+func diffContainerSpecs(a, b models.ContainerSpec) []models.ConfigDrift {
+	drift := make([]models.ConfigDrift, 0)
+
+	if a.Image != b.Image {
+		drift = append(drift, models.ConfigDrift{Field: "image", Change: "changed", From: a.Image, To: b.Image})
+	}
+	if a.Command != b.Command {
+		drift = append(drift, models.ConfigDrift{Field: "command", Change: "changed", From: a.Command, To: b.Command})
+	}
+
+	envKeys := make([]string, 0, len(a.Env)+len(b.Env))
+	for k := range a.Env {
+		envKeys = append(envKeys, k)
+	}
+	for k := range b.Env {
+		if _, ok := a.Env[k]; !ok {
+			envKeys = append(envKeys, k)
+		}
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		oldValue, hadOld := a.Env[k]
+		newValue, hasNew := b.Env[k]
+		switch {
+		case !hadOld:
+			drift = append(drift, models.ConfigDrift{Field: "env", Key: k, Change: "added"})
+		case !hasNew:
+			drift = append(drift, models.ConfigDrift{Field: "env", Key: k, Change: "removed"})
+		case oldValue != newValue:
+			drift = append(drift, models.ConfigDrift{Field: "env", Key: k, Change: "changed"})
+		}
+	}
+
+	drift = append(drift, diffStringSets("ports", a.Ports, b.Ports)...)
+	drift = append(drift, diffStringSets("mounts", a.Mounts, b.Mounts)...)
+
+	if a.NetworkMode != b.NetworkMode {
+		drift = append(drift, models.ConfigDrift{Field: "networkMode", Change: "changed", From: a.NetworkMode, To: b.NetworkMode})
+	}
+	if a.Privileged != b.Privileged {
+		drift = append(drift, models.ConfigDrift{Field: "privileged", Change: "changed", From: fmt.Sprint(a.Privileged), To: fmt.Sprint(b.Privileged)})
+	}
+	drift = append(drift, diffStringSets("capAdd", a.CapAdd, b.CapAdd)...)
+
+	return drift
+}
+
+// diffStringSets reports entries added to or removed from a set
+func diffStringSets(field string, a, b []string) []models.ConfigDrift {
+	drift := make([]models.ConfigDrift, 0)
+	old := make(map[string]bool, len(a))
+	for _, v := range a {
+		old[v] = true
+	}
+	for _, v := range b {
+		if old[v] {
+			delete(old, v)
+			continue
+		}
+		drift = append(drift, models.ConfigDrift{Field: field, Change: "added", To: v})
+	}
+	for _, v := range a {
+		if old[v] {
+			drift = append(drift, models.ConfigDrift{Field: field, Change: "removed", From: v})
+		}
+	}
+	return drift
+}
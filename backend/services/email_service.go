@@ -0,0 +1,318 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// EmailService sends alert emails and daily digests over SMTP
+type EmailService struct {
+	db      *gorm.DB
+	reports *ReportService
+}
+
+// smtpTimeout bounds connecting to and talking with the SMTP server
+const smtpTimeout = 20 * time.Second
+
+// NewEmailService creates a new EmailService, subscribes it to alerts and
+// starts the digest sender
+func NewEmailService(alerts *AlertService, reports *ReportService) *EmailService {
+	s := &EmailService{
+		db:      database.GetDB(),
+		reports: reports,
+	}
+	alerts.OnAlert(func(alert models.Alert) {
+		go s.alertEmail(alert)
+	})
+	if s.Configured() {
+		go s.digestBackground()
+	}
+	return s
+}
+
+// Configured reports whether an SMTP server is set up
+func (s *EmailService) Configured() bool {
+	return config.AppConfig.SMTPHost != ""
+}
+
+// Send delivers a plain-text email
+func (s *EmailService) Send(to []string, subject, body string) error {
+	cfg := config.AppConfig
+	if !s.Configured() {
+		return fmt.Errorf("email is not configured")
+	}
+	from, err := mail.ParseAddress(cfg.SMTPFrom)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %v", err)
+	}
+
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: cfg.SMTPHost}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	if cfg.SMTPTLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.SMTPTLS == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %v", err)
+		}
+	}
+	if cfg.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)); err != nil {
+			return fmt.Errorf("auth: %v", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s: %v", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildEmail(from, to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail renders the headers and body of a plain-text message
+func buildEmail(from *mail.Address, to []string, subject, body string) []byte {
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := "homelab"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	// Bare newlines are not allowed in SMTP data
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// GetPreferences returns the user's email settings, with defaults when none are saved
+func (s *EmailService) GetPreferences(userID uint) (*models.EmailStatus, error) {
+	pref := models.EmailPreference{UserID: userID, Digest: models.DigestOff, DigestHour: 8}
+	s.db.Where("user_id = ?", userID).First(&pref)
+
+	to, err := s.recipient(pref)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EmailStatus{EmailPreference: pref, Configured: s.Configured(), SendsTo: to}, nil
+}
+
+// UpdatePreferences saves the user's email settings
+func (s *EmailService) UpdatePreferences(userID uint, req models.EmailPreferenceRequest) (*models.EmailStatus, error) {
+	if req.Address != "" {
+		addr, err := mail.ParseAddress(req.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address")
+		}
+		req.Address = addr.Address
+	}
+	if req.Digest == "" {
+		req.Digest = models.DigestOff
+	}
+	if req.Digest != models.DigestOff && req.Digest != models.DigestDaily {
+		return nil, fmt.Errorf("digest must be off or daily")
+	}
+	if req.DigestHour < 0 || req.DigestHour > 23 {
+		return nil, fmt.Errorf("digestHour must be between 0 and 23")
+	}
+
+	var pref models.EmailPreference
+	if err := s.db.Where("user_id = ?", userID).FirstOrInit(&pref, models.EmailPreference{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+	pref.Address = req.Address
+	pref.Digest = req.Digest
+	pref.DigestHour = req.DigestHour
+	if err := s.db.Save(&pref).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(userID)
+}
+
+// SendTest emails the user to confirm the SMTP settings work
+func (s *EmailService) SendTest(userID uint) error {
+	status, err := s.GetPreferences(userID)
+	if err != nil {
+		return err
+	}
+	return s.Send([]string{status.SendsTo}, "Homelab Monitor test email",
+		"This is a test email from Homelab Monitor. Email notifications are working.")
+}
+
+// recipient returns the address a user's emails go to
+func (s *EmailService) recipient(pref models.EmailPreference) (string, error) {
+	if pref.Address != "" {
+		return pref.Address, nil
+	}
+	var user models.User
+	if err := s.db.Select("id", "email").First(&user, pref.UserID).Error; err != nil {
+		return "", fmt.Errorf("user not found")
+	}
+	return user.Email, nil
+}
+
+// alertEmail emails the owner of a rule with email enabled when its alert
+// fires or resolves
+func (s *EmailService) alertEmail(alert models.Alert) {
+	if !s.Configured() || alert.RuleID == 0 || alert.State == models.AlertAcknowledged {
+		return
+	}
+	var rule models.AlertRule
+	if err := s.db.Select("id", "email_notify").First(&rule, alert.RuleID).Error; err != nil || !rule.EmailNotify {
+		return
+	}
+
+	status, err := s.GetPreferences(alert.UserID)
+	if err != nil {
+		return
+	}
+
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Name)
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", alert.Message)
+	fmt.Fprintf(&body, "Started:  %s\n", alert.StartedAt.Format(time.RFC1123))
+	if alert.State == models.AlertResolved && alert.ResolvedAt != nil {
+		subject = "[RESOLVED] " + alert.Name
+		fmt.Fprintf(&body, "Resolved: %s (after %s)\n", alert.ResolvedAt.Format(time.RFC1123),
+			alert.ResolvedAt.Sub(alert.StartedAt).Round(time.Second))
+	}
+	fmt.Fprintf(&body, "Severity: %s\n", alert.Severity)
+
+	if err := s.Send([]string{status.SendsTo}, subject, body.String()); err != nil {
+		log.Printf("Failed to email alert %d: %v", alert.ID, err)
+	}
+}
+
+// digestBackground sends due daily digests, checking every few minutes
+func (s *EmailService) digestBackground() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var prefs []models.EmailPreference
+		if err := s.db.Where("digest = ?", models.DigestDaily).Find(&prefs).Error; err != nil {
+			log.Printf("Failed to load email digests: %v", err)
+			continue
+		}
+
+		now := time.Now()
+		for _, pref := range prefs {
+			// Today's slot, or yesterday's until this hour comes round
+			slot := time.Date(now.Year(), now.Month(), now.Day(), pref.DigestHour, 0, 0, 0, now.Location())
+			if now.Before(slot) {
+				slot = slot.AddDate(0, 0, -1)
+			}
+			if pref.LastDigestAt != nil && !pref.LastDigestAt.Before(slot) {
+				continue
+			}
+			if err := s.sendDigest(pref, now); err != nil {
+				log.Printf("Failed to send digest to user %d: %v", pref.UserID, err)
+				continue
+			}
+			s.db.Model(&pref).Update("last_digest_at", now)
+		}
+	}
+}
+
+// sendDigest emails a summary of the last day's outages and alerts
+func (s *EmailService) sendDigest(pref models.EmailPreference, now time.Time) error {
+	to, err := s.recipient(pref)
+	if err != nil {
+		return err
+	}
+	from := now.Add(-24 * time.Hour)
+
+	incidents, err := s.reports.Incidents(pref.UserID, nil, from, now)
+	if err != nil {
+		return err
+	}
+	var alerts []models.Alert
+	s.db.Where("user_id = ? AND (started_at >= ? OR state <> ?)", pref.UserID, from, models.AlertResolved).
+		Order("started_at DESC").Limit(50).Find(&alerts)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Homelab summary for %s to %s\n\n", from.Format("Mon Jan 2 15:04"), now.Format("Mon Jan 2 15:04"))
+
+	if len(incidents) == 0 {
+		body.WriteString("Outages: none\n")
+	} else {
+		fmt.Fprintf(&body, "Outages (%d):\n", len(incidents))
+		for _, incident := range incidents {
+			ongoing := ""
+			if incident.EndedAt == nil {
+				ongoing = ", ongoing"
+			}
+			fmt.Fprintf(&body, "  - %s down %s from %s%s", incident.ServiceName, formatDowntime(incident.Duration),
+				incident.StartedAt.Format("15:04"), ongoing)
+			if incident.LastError != "" {
+				fmt.Fprintf(&body, ": %s", incident.LastError)
+			}
+			body.WriteString("\n")
+		}
+	}
+
+	if len(alerts) == 0 {
+		body.WriteString("\nAlerts: none\n")
+	} else {
+		fmt.Fprintf(&body, "\nAlerts (%d):\n", len(alerts))
+		for _, alert := range alerts {
+			fmt.Fprintf(&body, "  - [%s] %s: %s (%s)\n", alert.Severity, alert.Name, alert.Message, alert.State)
+		}
+	}
+
+	subject := fmt.Sprintf("Homelab daily digest: %d outage(s), %d alert(s)", len(incidents), len(alerts))
+	return s.Send([]string{to}, subject, body.String())
+}
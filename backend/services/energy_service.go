@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"github.com/shirou/gopsutil/v3/net"
+	"gorm.io/gorm"
+)
+
+// EnergyService samples device activity and suggests windows where devices
+// could be powered down
+type EnergyService struct {
+	db     *gorm.DB
+	docker *DockerService
+
+	mu    sync.Mutex
+	flows map[string]uint64 // conntrack byte counters from the previous sample
+}
+
+const (
+	// activityRetention is how long activity samples are kept
+	activityRetention = 90 * 24 * time.Hour
+	// idleContainerCPU is the container CPU % below which a device counts as idle
+	idleContainerCPU = 5.0
+	// idleNetBytes is the traffic per sample below which a device counts as idle
+	idleNetBytes = 1 << 20
+	// idleSlotRatio is the share of idle samples an hour of the week needs
+	idleSlotRatio = 0.9
+	// minSlotDays is how many different days an hour of the week must be seen on
+	minSlotDays = 2
+	// DefaultEnergyDays is the history analyzed when no range is given
+	DefaultEnergyDays = 14
+	// DefaultIdleHours is the shortest window suggested when none is given
+	DefaultIdleHours = 2
+)
+
+// defaultWatts is the assumed draw per device type when none is set
+var defaultWatts = map[string]float64{
+	"server": 60,
+	"pc":     80,
+	"laptop": 20,
+	"tablet": 5,
+	"phone":  3,
+	"other":  15,
+}
+
+// NewEnergyService creates a new EnergyService and starts activity sampling
+func NewEnergyService(docker *DockerService) *EnergyService {
+	s := &EnergyService{
+		db:     database.GetDB(),
+		docker: docker,
+		flows:  make(map[string]uint64),
+	}
+	if config.AppConfig.ActivitySampleInterval > 0 {
+		go s.sampleBackground()
+	}
+	return s
+}
+
+// sampleBackground records an activity sample for every active device
+func (s *EnergyService) sampleBackground() {
+	ticker := time.NewTicker(time.Duration(config.AppConfig.ActivitySampleInterval) * time.Minute)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for range ticker.C {
+		s.sample()
+
+		// Purge samples beyond retention once a day
+		if time.Since(lastCleanup) > 24*time.Hour {
+			s.db.Where("sampled_at < ?", time.Now().Add(-activityRetention)).Delete(&models.DeviceActivity{})
+			lastCleanup = time.Now()
+		}
+	}
+}
+
+// sample stores one activity sample per active device
+func (s *EnergyService) sample() {
+	var devices []models.Device
+	if err := s.db.Where("is_active = ?", true).Find(&devices).Error; err != nil || len(devices) == 0 {
+		return
+	}
+
+	connections := s.connectionsByIP()
+	traffic := s.trafficByIP()
+	containerCPU := s.containerCPUByDevice()
+
+	now := time.Now()
+	samples := make([]models.DeviceActivity, 0, len(devices))
+	for _, device := range devices {
+		samples = append(samples, models.DeviceActivity{
+			DeviceID:     device.ID,
+			Online:       device.IsOnline,
+			Connections:  connections[device.IP],
+			NetBytes:     traffic[device.IP],
+			ContainerCPU: containerCPU[device.ID],
+			SampledAt:    now,
+		})
+	}
+	if err := s.db.Create(&samples).Error; err != nil {
+		log.Printf("Failed to store device activity: %v", err)
+	}
+}
+
+// connectionsByIP counts established TCP connections per remote address,
+// leaving out the backend's own health checks
+func (s *EnergyService) connectionsByIP() map[string]int {
+	counts := make(map[string]int)
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		return counts
+	}
+	self := int32(os.Getpid())
+	for _, c := range conns {
+		if c.Status != "ESTABLISHED" || c.Pid == self {
+			continue
+		}
+		counts[c.Raddr.IP]++
+	}
+	return counts
+}
+
+// trafficByIP returns the bytes each address moved since the previous sample,
+// from conntrack accounting. It is empty when this host does not track
+// connections (nf_conntrack_acct off or not the gateway).
+func (s *EnergyService) trafficByIP() map[string]uint64 {
+	traffic := make(map[string]uint64)
+	file, err := os.Open("/proc/net/nf_conntrack")
+	if err != nil {
+		return traffic
+	}
+	defer file.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var src, dst, key string
+		var bytes uint64
+		for _, field := range strings.Fields(scanner.Text()) {
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch name {
+			case "src":
+				if src == "" {
+					src = value
+				}
+			case "dst":
+				if dst == "" {
+					dst = value
+				}
+			case "sport", "dport":
+				key += field + " "
+			case "bytes":
+				var n uint64
+				fmt.Sscan(value, &n)
+				bytes += n
+			}
+		}
+		if src == "" || dst == "" {
+			continue
+		}
+
+		key = src + " " + dst + " " + key
+		current[key] = bytes
+		delta := bytes
+		if previous, ok := s.flows[key]; ok && previous <= bytes {
+			delta = bytes - previous
+		}
+		traffic[src] += delta
+		traffic[dst] += delta
+	}
+	s.flows = current
+	return traffic
+}
+
+// containerCPUByDevice sums the CPU of running containers linked to services
+// hosted on each device
+func (s *EnergyService) containerCPUByDevice() map[uint]float64 {
+	usage := make(map[uint]float64)
+	if s.docker == nil || !s.docker.IsConnected() {
+		return usage
+	}
+
+	var configs []models.ServiceConfig
+	s.db.Select("device_id", "container").Where("device_id IS NOT NULL AND container <> ?", "").Find(&configs)
+	for _, svc := range configs {
+		c, err := s.docker.FindContainer(svc.Container)
+		if err != nil || c.State != "running" {
+			continue
+		}
+		usage[*svc.DeviceID] += s.docker.getCachedStats(c.ID).CPUPercent
+	}
+	return usage
+}
+
+// Suggestions analyzes the last days of activity and proposes weekly windows
+// of at least minHours where each device was on but idle, with the energy a
+// shutdown schedule over those windows would save
+func (s *EnergyService) Suggestions(userID uint, days, minHours int) (*models.EnergyReport, error) {
+	if days <= 0 || days > MaxAvailabilityDays {
+		days = DefaultEnergyDays
+	}
+	if minHours <= 0 {
+		minHours = DefaultIdleHours
+	}
+
+	report := &models.EnergyReport{
+		Days:        days,
+		PricePerKWh: config.AppConfig.EnergyPricePerKWh,
+		Currency:    config.AppConfig.EnergyCurrency,
+		Suggestions: make([]models.EnergySuggestion, 0),
+	}
+
+	var devices []models.Device
+	if err := s.db.Where("user_id = ? AND is_active = ?", userID, true).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	for _, device := range devices {
+		// Routers and cameras must stay on
+		if device.Type == "router" || device.Type == "cctv" {
+			continue
+		}
+		watts := device.PowerWatts
+		if watts == 0 {
+			watts = defaultWatts[device.Type]
+		}
+		if watts == 0 {
+			watts = defaultWatts["other"]
+		}
+
+		var samples []models.DeviceActivity
+		if err := s.db.Where("device_id = ? AND sampled_at >= ?", device.ID, since).Find(&samples).Error; err != nil {
+			return nil, err
+		}
+		windows := idleWindows(samples, minHours)
+		if len(windows) == 0 {
+			continue
+		}
+
+		hours := 0
+		for _, w := range windows {
+			hours += w.Hours
+		}
+		kwh := float64(hours) * watts / 1000 * 52 / 12
+		suggestion := models.EnergySuggestion{
+			DeviceID:     device.ID,
+			DeviceName:   device.Name,
+			Type:         device.Type,
+			Watts:        watts,
+			Samples:      len(samples),
+			Windows:      windows,
+			HoursPerWeek: hours,
+			KWhPerMonth:  round2(kwh),
+			CostPerMonth: round2(kwh * report.PricePerKWh),
+		}
+		report.Suggestions = append(report.Suggestions, suggestion)
+		report.TotalKWhPerMonth += kwh
+	}
+
+	sort.Slice(report.Suggestions, func(i, j int) bool {
+		return report.Suggestions[i].KWhPerMonth > report.Suggestions[j].KWhPerMonth
+	})
+	report.TotalCostPerMonth = round2(report.TotalKWhPerMonth * report.PricePerKWh)
+	report.TotalKWhPerMonth = round2(report.TotalKWhPerMonth)
+	return report, nil
+}
+
+// idleWindows buckets samples into the 168 hours of the week and returns the
+// runs of at least minHours hours in which the device was on and nearly
+// always idle. Runs may wrap from Saturday night into Sunday.
+func idleWindows(samples []models.DeviceActivity, minHours int) []models.IdleWindow {
+	const slots = 7 * 24
+	var online, idle [slots]int
+	var dates [slots]map[string]bool
+
+	for _, sample := range samples {
+		if !sample.Online {
+			continue
+		}
+		t := sample.SampledAt.Local()
+		slot := int(t.Weekday())*24 + t.Hour()
+		online[slot]++
+		if sample.Connections == 0 && sample.ContainerCPU < idleContainerCPU && sample.NetBytes < idleNetBytes {
+			idle[slot]++
+		}
+		if dates[slot] == nil {
+			dates[slot] = make(map[string]bool)
+		}
+		dates[slot][t.Format("2006-01-02")] = true
+	}
+
+	qualifies := func(slot int) bool {
+		return len(dates[slot]) >= minSlotDays && float64(idle[slot]) >= idleSlotRatio*float64(online[slot])
+	}
+
+	// Start scanning just after a busy hour so no run is split at the week boundary
+	start := -1
+	for slot := 0; slot < slots; slot++ {
+		if !qualifies(slot) {
+			start = slot + 1
+			break
+		}
+	}
+	if start == -1 {
+		return []models.IdleWindow{idleWindow(0, slots, online[:], idle[:])}
+	}
+
+	windows := make([]models.IdleWindow, 0)
+	runStart, runLength := 0, 0
+	for i := 0; i <= slots; i++ {
+		slot := (start + i) % slots
+		if i < slots && qualifies(slot) {
+			if runLength == 0 {
+				runStart = slot
+			}
+			runLength++
+			continue
+		}
+		if runLength >= minHours {
+			windows = append(windows, idleWindow(runStart, runLength, online[:], idle[:]))
+		}
+		runLength = 0
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].StartDay*24+windows[i].StartHour < windows[j].StartDay*24+windows[j].StartHour
+	})
+	return windows
+}
+
+// idleWindow describes a run of hours starting at slot
+func idleWindow(slot, hours int, online, idle []int) models.IdleWindow {
+	var on, off int
+	for i := 0; i < hours; i++ {
+		on += online[(slot+i)%len(online)]
+		off += idle[(slot+i)%len(idle)]
+	}
+	confidence := 0.0
+	if on > 0 {
+		confidence = round2(float64(off) / float64(on))
+	}
+
+	end := (slot + hours) % len(online)
+	return models.IdleWindow{
+		Start:      weekSlotLabel(slot),
+		End:        weekSlotLabel(end),
+		StartDay:   slot / 24,
+		StartHour:  slot % 24,
+		Hours:      hours,
+		Confidence: confidence,
+	}
+}
+
+// weekSlotLabel formats an hour of the week as "Mon 01:00"
+func weekSlotLabel(slot int) string {
+	return fmt.Sprintf("%s %02d:00", time.Weekday(slot / 24).String()[:3], slot%24)
+}
+
+// round2 rounds to two decimals
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// EventService records and lists system events
+type EventService struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	listeners []func(models.Event)
+}
+
+// NewEventService creates a new EventService
+func NewEventService() *EventService {
+	return &EventService{
+		db: database.GetDB(),
+	}
+}
+
+// Record stores a new lab-wide event, encoding details as JSON when provided
+func (s *EventService) Record(eventType, severity, source, title, message string, details interface{}) {
+	s.RecordFor(0, eventType, severity, source, title, message, details)
+}
+
+// RecordFor stores a new event about something a user owns, such as their
+// alert rules or devices
+func (s *EventService) RecordFor(userID uint, eventType, severity, source, title, message string, details interface{}) {
+	event := models.Event{
+		Type:     eventType,
+		Severity: severity,
+		Source:   source,
+		Title:    title,
+		Message:  message,
+		UserID:   userID,
+	}
+
+	if details != nil {
+		if data, err := json.Marshal(details); err == nil {
+			event.Details = string(data)
+		}
+	}
+
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record event %s: %v", eventType, err)
+		return
+	}
+
+	s.mu.Lock()
+	listeners := append([]func(models.Event){}, s.listeners...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// OnEvent registers a function called with every recorded event
+func (s *EventService) OnEvent(fn func(models.Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// List returns the most recent lab-wide events and those of the user,
+// optionally filtered by severity and source
+func (s *EventService) List(userID uint, limit int, severity, source string) ([]models.Event, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := s.db.Where("user_id IN ?", []uint{0, userID}).Order("created_at DESC").Limit(limit)
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var events []models.Event
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,679 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// FirewallService provides read-only access to the host (ufw/nftables) or
+// network (OPNsense/pfSense) firewall
+type FirewallService struct {
+	provider   string
+	apiURL     string
+	apiKey     string
+	apiSecret  string
+	httpClient *http.Client
+	events     *EventService
+
+	mu          sync.RWMutex
+	lastEnabled *bool
+}
+
+// Kernel log files that may contain ufw/nftables block entries
+var firewallLogFiles = []string{"/var/log/ufw.log", "/var/log/kern.log", "/var/log/messages"}
+
+var kernelBlockFieldRe = regexp.MustCompile(`(IN|SRC|DST|PROTO|SPT|DPT)=(\S*)`)
+
+// NewFirewallService creates a new FirewallService and starts the status monitor
+func NewFirewallService(events *EventService) *FirewallService {
+	cfg := config.AppConfig
+	s := &FirewallService{
+		provider:  strings.ToLower(cfg.FirewallProvider),
+		apiURL:    strings.TrimRight(cfg.FirewallAPIURL, "/"),
+		apiKey:    cfg.FirewallAPIKey,
+		apiSecret: cfg.FirewallAPISecret,
+		events:    events,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.FirewallAPIInsecure},
+			},
+		},
+	}
+
+	if s.provider == "auto" {
+		s.provider = detectFirewallProvider()
+	}
+
+	if s.IsConfigured() {
+		log.Printf("Firewall integration enabled (provider: %s)", s.provider)
+		go s.monitorBackground()
+	}
+
+	return s
+}
+
+// detectFirewallProvider looks for a local firewall tool
+func detectFirewallProvider() string {
+	if runtime.GOOS != "linux" {
+		return "none"
+	}
+	if _, err := exec.LookPath("ufw"); err == nil {
+		return "ufw"
+	}
+	if _, err := exec.LookPath("nft"); err == nil {
+		return "nftables"
+	}
+	return "none"
+}
+
+// IsConfigured returns true if a firewall provider is available
+func (s *FirewallService) IsConfigured() bool {
+	return s.provider != "" && s.provider != "none"
+}
+
+// Provider returns the active provider name
+func (s *FirewallService) Provider() string {
+	return s.provider
+}
+
+// monitorBackground periodically checks firewall state and records an event
+// whenever the firewall is found disabled or re-enabled
+func (s *FirewallService) monitorBackground() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		status, err := s.GetStatus()
+		if err != nil {
+			log.Printf("Firewall status check failed: %v", err)
+		} else {
+			s.recordTransition(status.Enabled)
+		}
+		<-ticker.C
+	}
+}
+
+func (s *FirewallService) recordTransition(enabled bool) {
+	s.mu.Lock()
+	previous := s.lastEnabled
+	s.lastEnabled = &enabled
+	s.mu.Unlock()
+
+	if previous != nil && *previous == enabled {
+		return
+	}
+
+	if !enabled {
+		s.events.Record("firewall_disabled", models.SeverityCritical, "firewall",
+			"Firewall is disabled",
+			fmt.Sprintf("The %s firewall is not filtering traffic", s.provider), nil)
+	} else if previous != nil {
+		s.events.Record("firewall_enabled", models.SeverityInfo, "firewall",
+			"Firewall is enabled",
+			fmt.Sprintf("The %s firewall is filtering traffic again", s.provider), nil)
+	}
+}
+
+// GetStatus returns the current firewall status
+func (s *FirewallService) GetStatus() (*models.FirewallStatus, error) {
+	var status *models.FirewallStatus
+	var err error
+
+	switch s.provider {
+	case "ufw":
+		status, err = s.ufwStatus()
+	case "nftables":
+		status, err = s.nftStatus()
+	case "opnsense":
+		status, err = s.opnsenseStatus()
+	case "pfsense":
+		status, err = s.pfsenseStatus()
+	default:
+		return nil, fmt.Errorf("firewall integration not configured")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	status.Provider = s.provider
+	status.CheckedAt = time.Now()
+	return status, nil
+}
+
+// GetRules returns the configured firewall rules
+func (s *FirewallService) GetRules() ([]models.FirewallRule, error) {
+	switch s.provider {
+	case "ufw":
+		_, rules, err := s.ufwStatusAndRules()
+		return rules, err
+	case "nftables":
+		_, rules, err := s.nftStatusAndRules()
+		return rules, err
+	case "opnsense":
+		return s.opnsenseRules()
+	case "pfsense":
+		return s.pfsenseRules()
+	default:
+		return nil, fmt.Errorf("firewall integration not configured")
+	}
+}
+
+// GetRecentBlocks returns the most recent blocked packets
+func (s *FirewallService) GetRecentBlocks(limit int) ([]models.FirewallBlock, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	switch s.provider {
+	case "ufw", "nftables":
+		return readKernelBlocks(limit)
+	case "opnsense":
+		return s.opnsenseBlocks(limit)
+	case "pfsense":
+		return s.pfsenseBlocks(limit)
+	default:
+		return nil, fmt.Errorf("firewall integration not configured")
+	}
+}
+
+// runFirewallCommand runs a local firewall tool with a timeout
+func runFirewallCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// ---- ufw ----
+
+func (s *FirewallService) ufwStatus() (*models.FirewallStatus, error) {
+	status, _, err := s.ufwStatusAndRules()
+	return status, err
+}
+
+func (s *FirewallService) ufwStatusAndRules() (*models.FirewallStatus, []models.FirewallRule, error) {
+	out, err := runFirewallCommand("ufw", "status", "verbose")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status := &models.FirewallStatus{}
+	rules := make([]models.FirewallRule, 0)
+	inRules := false
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			status.Enabled = strings.Contains(line, "active") && !strings.Contains(line, "inactive")
+		case strings.HasPrefix(line, "Default:"):
+			// Default: deny (incoming), allow (outgoing), disabled (routed)
+			for _, part := range strings.Split(strings.TrimPrefix(line, "Default:"), ",") {
+				part = strings.TrimSpace(part)
+				if strings.HasSuffix(part, "(incoming)") {
+					status.DefaultIncoming = strings.Fields(part)[0]
+				} else if strings.HasSuffix(part, "(outgoing)") {
+					status.DefaultOutgoing = strings.Fields(part)[0]
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			inRules = true
+		case inRules && line != "":
+			if rule, ok := parseUFWRule(line); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	status.RuleCount = len(rules)
+	return status, rules, nil
+}
+
+var ufwRuleRe = regexp.MustCompile(`^(.+?)\s{2,}((?:ALLOW|DENY|REJECT|LIMIT)(?: IN| OUT| FWD)?)\s{2,}(.+?)(?:\s+#\s*(.*))?$`)
+
+// parseUFWRule parses a line such as "22/tcp   ALLOW IN   Anywhere   # ssh"
+func parseUFWRule(line string) (models.FirewallRule, bool) {
+	m := ufwRuleRe.FindStringSubmatch(line)
+	if m == nil {
+		return models.FirewallRule{}, false
+	}
+
+	to := strings.TrimSpace(m[1])
+	port, protocol := to, ""
+	if idx := strings.Index(to, "/"); idx > 0 {
+		port, protocol = to[:idx], to[idx+1:]
+	}
+
+	actionParts := strings.Fields(m[2])
+	direction := "IN"
+	if len(actionParts) > 1 {
+		direction = actionParts[1]
+	}
+
+	return models.FirewallRule{
+		Chain:       strings.ToLower(direction),
+		Action:      strings.ToLower(actionParts[0]),
+		Protocol:    protocol,
+		Source:      strings.TrimSpace(m[3]),
+		Port:        port,
+		Description: m[4],
+		Enabled:     true,
+	}, true
+}
+
+// ---- nftables ----
+
+func (s *FirewallService) nftStatus() (*models.FirewallStatus, error) {
+	status, _, err := s.nftStatusAndRules()
+	return status, err
+}
+
+func (s *FirewallService) nftStatusAndRules() (*models.FirewallStatus, []models.FirewallRule, error) {
+	out, err := runFirewallCommand("nft", "-j", "list", "ruleset")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ruleset struct {
+		Nftables []map[string]json.RawMessage `json:"nftables"`
+	}
+	if err := json.Unmarshal([]byte(out), &ruleset); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse nft output: %v", err)
+	}
+
+	status := &models.FirewallStatus{}
+	rules := make([]models.FirewallRule, 0)
+
+	for _, item := range ruleset.Nftables {
+		if raw, ok := item["chain"]; ok {
+			var chain struct {
+				Name   string `json:"name"`
+				Hook   string `json:"hook"`
+				Policy string `json:"policy"`
+			}
+			if json.Unmarshal(raw, &chain) != nil || chain.Hook == "" {
+				continue
+			}
+			// Any base chain attached to a netfilter hook means filtering is active
+			status.Enabled = true
+			switch chain.Hook {
+			case "input":
+				status.DefaultIncoming = chain.Policy
+			case "output":
+				status.DefaultOutgoing = chain.Policy
+			}
+		}
+
+		if raw, ok := item["rule"]; ok {
+			var rule struct {
+				Family  string                       `json:"family"`
+				Table   string                       `json:"table"`
+				Chain   string                       `json:"chain"`
+				Handle  int                          `json:"handle"`
+				Comment string                       `json:"comment"`
+				Expr    []map[string]json.RawMessage `json:"expr"`
+			}
+			if json.Unmarshal(raw, &rule) != nil {
+				continue
+			}
+
+			action := "continue"
+			for _, expr := range rule.Expr {
+				for _, verdict := range []string{"accept", "drop", "reject", "jump", "goto", "return"} {
+					if _, ok := expr[verdict]; ok {
+						action = verdict
+					}
+				}
+			}
+
+			rules = append(rules, models.FirewallRule{
+				ID:          fmt.Sprintf("%s/%s/%d", rule.Family, rule.Table, rule.Handle),
+				Chain:       rule.Chain,
+				Action:      action,
+				Description: rule.Comment,
+				Enabled:     true,
+			})
+		}
+	}
+
+	status.RuleCount = len(rules)
+	return status, rules, nil
+}
+
+// readKernelBlocks reads blocked packets logged by ufw/nftables to the kernel log
+func readKernelBlocks(limit int) ([]models.FirewallBlock, error) {
+	for _, path := range firewallLogFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		blocks := make([]models.FirewallBlock, 0, limit)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.Contains(line, "BLOCK") && !strings.Contains(line, "DROP") && !strings.Contains(line, "REJECT") {
+				continue
+			}
+			if !strings.Contains(line, "SRC=") {
+				continue
+			}
+
+			block := models.FirewallBlock{}
+			if len(line) >= 15 {
+				block.Timestamp = line[:15]
+			}
+			for _, m := range kernelBlockFieldRe.FindAllStringSubmatch(line, -1) {
+				switch m[1] {
+				case "IN":
+					block.Interface = m[2]
+				case "SRC":
+					block.Source = m[2]
+				case "DST":
+					block.Destination = m[2]
+				case "PROTO":
+					block.Protocol = strings.ToLower(m[2])
+				case "SPT":
+					block.SourcePort = m[2]
+				case "DPT":
+					block.DestinationPort = m[2]
+				}
+			}
+
+			blocks = append(blocks, block)
+			if len(blocks) > limit {
+				blocks = blocks[1:]
+			}
+		}
+		file.Close()
+
+		reverseBlocks(blocks)
+		return blocks, nil
+	}
+
+	return []models.FirewallBlock{}, nil
+}
+
+// reverseBlocks orders blocks newest first
+func reverseBlocks(blocks []models.FirewallBlock) {
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+}
+
+// ---- OPNsense / pfSense ----
+
+// apiGet performs an authenticated GET against the firewall appliance API
+func (s *FirewallService) apiGet(path string, out interface{}) ([]byte, error) {
+	if s.apiURL == "" {
+		return nil, fmt.Errorf("FIREWALL_API_URL is not configured")
+	}
+
+	req, err := http.NewRequest("GET", s.apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.provider == "pfsense" {
+		// pfSense REST API token authentication
+		req.Header.Set("Authorization", s.apiKey+" "+s.apiSecret)
+	} else {
+		req.SetBasicAuth(s.apiKey, s.apiSecret)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("firewall API returned %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return nil, fmt.Errorf("failed to parse firewall API response: %v", err)
+		}
+	}
+	return body, nil
+}
+
+func (s *FirewallService) opnsenseStatus() (*models.FirewallStatus, error) {
+	body, err := s.apiGet("/api/diagnostics/firewall/pf_statistics/info", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.opnsenseRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// pfctl -si reports "Status: Enabled for ..." when packet filtering is on
+	return &models.FirewallStatus{
+		Enabled:   strings.Contains(string(body), "Enabled"),
+		RuleCount: len(rules),
+	}, nil
+}
+
+func (s *FirewallService) opnsenseRules() ([]models.FirewallRule, error) {
+	var resp struct {
+		Rows []struct {
+			UUID            string `json:"uuid"`
+			Enabled         string `json:"enabled"`
+			Action          string `json:"action"`
+			Interface       string `json:"interface"`
+			Protocol        string `json:"protocol"`
+			SourceNet       string `json:"source_net"`
+			DestinationNet  string `json:"destination_net"`
+			DestinationPort string `json:"destination_port"`
+			Description     string `json:"description"`
+		} `json:"rows"`
+	}
+	if _, err := s.apiGet("/api/firewall/filter/searchRule", &resp); err != nil {
+		return nil, err
+	}
+
+	rules := make([]models.FirewallRule, 0, len(resp.Rows))
+	for _, r := range resp.Rows {
+		rules = append(rules, models.FirewallRule{
+			ID:          r.UUID,
+			Chain:       r.Interface,
+			Action:      r.Action,
+			Protocol:    r.Protocol,
+			Source:      r.SourceNet,
+			Destination: r.DestinationNet,
+			Port:        r.DestinationPort,
+			Description: r.Description,
+			Enabled:     r.Enabled == "1",
+		})
+	}
+	return rules, nil
+}
+
+func (s *FirewallService) opnsenseBlocks(limit int) ([]models.FirewallBlock, error) {
+	var entries []struct {
+		Action    string `json:"action"`
+		Interface string `json:"interface"`
+		Protoname string `json:"protoname"`
+		Src       string `json:"src"`
+		Srcport   string `json:"srcport"`
+		Dst       string `json:"dst"`
+		Dstport   string `json:"dstport"`
+		Timestamp string `json:"__timestamp__"`
+	}
+	if _, err := s.apiGet(fmt.Sprintf("/api/diagnostics/firewall/log?limit=%d", limit*4), &entries); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]models.FirewallBlock, 0, limit)
+	for _, e := range entries {
+		if e.Action != "block" {
+			continue
+		}
+		blocks = append(blocks, models.FirewallBlock{
+			Timestamp:       e.Timestamp,
+			Interface:       e.Interface,
+			Protocol:        e.Protoname,
+			Source:          e.Src,
+			SourcePort:      e.Srcport,
+			Destination:     e.Dst,
+			DestinationPort: e.Dstport,
+		})
+		if len(blocks) >= limit {
+			break
+		}
+	}
+	return blocks, nil
+}
+
+func (s *FirewallService) pfsenseStatus() (*models.FirewallStatus, error) {
+	rules, err := s.pfsenseRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// The pfSense API has no explicit pf status; treat the firewall as
+	// enabled when at least one active rule is loaded
+	enabled := false
+	for _, r := range rules {
+		if r.Enabled {
+			enabled = true
+			break
+		}
+	}
+
+	return &models.FirewallStatus{
+		Enabled:   enabled,
+		RuleCount: len(rules),
+	}, nil
+}
+
+func (s *FirewallService) pfsenseRules() ([]models.FirewallRule, error) {
+	type endpoint struct {
+		Address string `json:"address"`
+		Network string `json:"network"`
+		Any     *bool  `json:"any"`
+		Port    string `json:"port"`
+	}
+	var resp struct {
+		Data []struct {
+			Tracker     json.Number `json:"tracker"`
+			Type        string      `json:"type"`
+			Interface   string      `json:"interface"`
+			Protocol    string      `json:"protocol"`
+			Source      endpoint    `json:"source"`
+			Destination endpoint    `json:"destination"`
+			Descr       string      `json:"descr"`
+			Disabled    *bool       `json:"disabled"`
+		} `json:"data"`
+	}
+	if _, err := s.apiGet("/api/v1/firewall/rule", &resp); err != nil {
+		return nil, err
+	}
+
+	describe := func(e endpoint) string {
+		switch {
+		case e.Any != nil:
+			return "any"
+		case e.Address != "":
+			return e.Address
+		default:
+			return e.Network
+		}
+	}
+
+	rules := make([]models.FirewallRule, 0, len(resp.Data))
+	for _, r := range resp.Data {
+		rules = append(rules, models.FirewallRule{
+			ID:          r.Tracker.String(),
+			Chain:       r.Interface,
+			Action:      r.Type,
+			Protocol:    r.Protocol,
+			Source:      describe(r.Source),
+			Destination: describe(r.Destination),
+			Port:        r.Destination.Port,
+			Description: r.Descr,
+			Enabled:     r.Disabled == nil || !*r.Disabled,
+		})
+	}
+	return rules, nil
+}
+
+func (s *FirewallService) pfsenseBlocks(limit int) ([]models.FirewallBlock, error) {
+	var resp struct {
+		Data []string `json:"data"`
+	}
+	if _, err := s.apiGet("/api/v1/status/log/firewall", &resp); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]models.FirewallBlock, 0, limit)
+	for i := len(resp.Data) - 1; i >= 0 && len(blocks) < limit; i-- {
+		if block, ok := parseFilterlogLine(resp.Data[i]); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// parseFilterlogLine parses a pfSense filterlog CSV entry, keeping only blocks
+func parseFilterlogLine(line string) (models.FirewallBlock, bool) {
+	idx := strings.Index(line, "filterlog")
+	if idx < 0 {
+		return models.FirewallBlock{}, false
+	}
+	colon := strings.Index(line[idx:], ": ")
+	if colon < 0 {
+		return models.FirewallBlock{}, false
+	}
+
+	fields := strings.Split(line[idx+colon+2:], ",")
+	// IPv4 layout: rule,subrule,anchor,tracker,iface,reason,action,dir,4,tos,ecn,ttl,id,off,flags,protoid,proto,len,src,dst[,sport,dport]
+	if len(fields) < 20 || fields[6] != "block" || fields[8] != "4" {
+		return models.FirewallBlock{}, false
+	}
+
+	block := models.FirewallBlock{
+		Interface:   fields[4],
+		Protocol:    fields[16],
+		Source:      fields[18],
+		Destination: fields[19],
+	}
+	if idx > 0 {
+		block.Timestamp = strings.TrimSpace(line[:idx])
+	}
+	if len(fields) > 21 && (block.Protocol == "tcp" || block.Protocol == "udp") {
+		block.SourcePort = fields[20]
+		block.DestinationPort = fields[21]
+	}
+	return block, true
+}
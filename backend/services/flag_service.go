@@ -0,0 +1,188 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Known feature flags for experimental subsystems
+const (
+	FlagIntegrations = "integrations"
+	FlagProbeAgents  = "probe_agents"
+)
+
+// defaultFlags are created (disabled) on startup if missing
+var defaultFlags = []models.FeatureFlag{
+	{Key: FlagIntegrations, Description: "Third-party integration plugins (Proxmox, UniFi, Pi-hole, ...)"},
+	{Key: FlagProbeAgents, Description: "Remote probe agents that run service checks from other locations"},
+}
+
+// FlagService evaluates feature flags from an in-memory cache kept in sync with the database
+type FlagService struct {
+	db *gorm.DB
+
+	mu        sync.RWMutex
+	flags     map[string]bool
+	overrides map[string]map[uint]bool // flag key -> user ID -> enabled
+}
+
+// NewFlagService creates a new FlagService, seeding default flags
+func NewFlagService() *FlagService {
+	s := &FlagService{db: database.GetDB()}
+
+	for _, flag := range defaultFlags {
+		f := flag
+		s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&f)
+	}
+
+	if err := s.reload(); err != nil {
+		log.Printf("Failed to load feature flags: %v", err)
+	}
+	return s
+}
+
+// reload refreshes the cache from the database
+func (s *FlagService) reload() error {
+	var flags []models.FeatureFlag
+	if err := s.db.Find(&flags).Error; err != nil {
+		return err
+	}
+	var overrides []models.FeatureFlagOverride
+	if err := s.db.Find(&overrides).Error; err != nil {
+		return err
+	}
+
+	flagMap := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		flagMap[f.Key] = f.Enabled
+	}
+	overrideMap := make(map[string]map[uint]bool)
+	for _, o := range overrides {
+		if overrideMap[o.FlagKey] == nil {
+			overrideMap[o.FlagKey] = make(map[uint]bool)
+		}
+		overrideMap[o.FlagKey][o.UserID] = o.Enabled
+	}
+
+	s.mu.Lock()
+	s.flags = flagMap
+	s.overrides = overrideMap
+	s.mu.Unlock()
+	return nil
+}
+
+// IsEnabled evaluates a flag for a user; user overrides win over the instance value.
+// Pass userID 0 for instance-wide checks in background services.
+func (s *FlagService) IsEnabled(key string, userID uint) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if userID != 0 {
+		if enabled, ok := s.overrides[key][userID]; ok {
+			return enabled
+		}
+	}
+	return s.flags[key]
+}
+
+// Evaluate returns every flag evaluated for a user
+func (s *FlagService) Evaluate(userID uint) map[string]bool {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.flags))
+	for key := range s.flags {
+		keys = append(keys, key)
+	}
+	s.mu.RUnlock()
+
+	result := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		result[key] = s.IsEnabled(key, userID)
+	}
+	return result
+}
+
+// List returns all flags with their user overrides
+func (s *FlagService) List() ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	if err := s.db.Order("flag_key ASC").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	var overrides []models.FeatureFlagOverride
+	if err := s.db.Order("user_id ASC").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range flags {
+		for _, o := range overrides {
+			if o.FlagKey == flags[i].Key {
+				flags[i].Overrides = append(flags[i].Overrides, o)
+			}
+		}
+	}
+	return flags, nil
+}
+
+// Update changes a flag's instance-wide value, creating the flag if needed
+func (s *FlagService) Update(key string, req models.UpdateFlagRequest) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	if err := s.db.Where("flag_key = ?", key).FirstOrCreate(&flag, models.FeatureFlag{Key: key}).Error; err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		flag.Enabled = *req.Enabled
+	}
+	if req.Description != nil {
+		flag.Description = *req.Description
+	}
+	if err := s.db.Save(&flag).Error; err != nil {
+		return nil, err
+	}
+
+	return &flag, s.reload()
+}
+
+// Delete removes a flag and its overrides
+func (s *FlagService) Delete(key string) error {
+	result := s.db.Where("flag_key = ?", key).Delete(&models.FeatureFlag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("flag not found")
+	}
+	s.db.Where("flag_key = ?", key).Delete(&models.FeatureFlagOverride{})
+	return s.reload()
+}
+
+// SetOverride enables or disables a flag for a single user
+func (s *FlagService) SetOverride(key string, userID uint, enabled bool) error {
+	var count int64
+	s.db.Model(&models.FeatureFlag{}).Where("flag_key = ?", key).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("flag not found")
+	}
+
+	override := models.FeatureFlagOverride{FlagKey: key, UserID: userID, Enabled: enabled}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "flag_key"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&override).Error; err != nil {
+		return err
+	}
+	return s.reload()
+}
+
+// ClearOverride removes a user's override so the instance value applies again
+func (s *FlagService) ClearOverride(key string, userID uint) error {
+	if err := s.db.Where("flag_key = ? AND user_id = ?", key, userID).Delete(&models.FeatureFlagOverride{}).Error; err != nil {
+		return err
+	}
+	return s.reload()
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// Health score factor weights, adding up to 1
+var healthWeights = map[string]float64{
+	models.FactorServices: 0.4,
+	models.FactorDevices:  0.3,
+	models.FactorDisk:     0.2,
+	models.FactorUpdates:  0.1,
+}
+
+const (
+	// healthSampleInterval is how often the score is recorded for its history
+	healthSampleInterval = 5 * time.Minute
+	// healthCacheTTL is how long a computed score is shared between callers
+	healthCacheTTL = 10 * time.Second
+
+	// Disks start costing points above diskWarnPercent used and cost the
+	// whole disk factor at diskFullPercent
+	diskWarnPercent = 75.0
+	diskFullPercent = 95.0
+
+	// osUpdatesPath is written by Ubuntu's update-notifier with the number
+	// of pending package and security updates
+	osUpdatesPath = "/var/lib/update-notifier/updates-available"
+)
+
+var (
+	osUpdatesPattern  = regexp.MustCompile(`(\d+) (?:updates?|packages?) can be (?:applied|updated)`)
+	osSecurityPattern = regexp.MustCompile(`(\d+) (?:of these )?updates? (?:is a|are) (?:standard )?security updates?`)
+)
+
+// HealthService rolls services, devices, disk headroom and pending updates
+// up into a single lab health score
+type HealthService struct {
+	db      *gorm.DB
+	metrics *MetricsService
+	updates *UpdateService
+	cache   Cache
+}
+
+// NewHealthService creates a new HealthService and starts recording the score
+func NewHealthService(metrics *MetricsService, updates *UpdateService, cache Cache) *HealthService {
+	s := &HealthService{
+		db:      database.GetDB(),
+		metrics: metrics,
+		updates: updates,
+		cache:   cache,
+	}
+	go s.recordBackground()
+	return s
+}
+
+// recordBackground samples the score every healthSampleInterval and purges
+// samples beyond METRICS_RETENTION_DAYS once a day
+func (s *HealthService) recordBackground() {
+	ticker := time.NewTicker(healthSampleInterval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		score := s.GetScore()
+		sample := models.HealthScoreSample{Timestamp: score.Timestamp, Score: score.Score}
+		for _, f := range score.Factors {
+			switch f.Name {
+			case models.FactorServices:
+				sample.Services = f.Score
+			case models.FactorDevices:
+				sample.Devices = f.Score
+			case models.FactorDisk:
+				sample.Disk = f.Score
+			case models.FactorUpdates:
+				sample.Updates = f.Score
+			}
+		}
+		if err := s.db.Create(&sample).Error; err != nil {
+			log.Printf("Failed to record health score: %v", err)
+		}
+
+		if time.Since(lastPurge) > 24*time.Hour {
+			cutoff := time.Now().AddDate(0, 0, -config.AppConfig.MetricsRetentionDays)
+			if err := s.db.Where("sampled_at < ?", cutoff).Delete(&models.HealthScoreSample{}).Error; err != nil {
+				log.Printf("Failed to purge health scores: %v", err)
+			}
+			lastPurge = time.Now()
+		}
+		<-ticker.C
+	}
+}
+
+// GetScore computes the current health score. A service down because its
+// device is down counts against the device rather than twice.
+func (s *HealthService) GetScore() models.HealthScore {
+	var score models.HealthScore
+	if s.cache.Get("health_score", &score) {
+		return score
+	}
+
+	services, hosted := s.servicesFactor()
+	score = models.HealthScore{
+		Factors: []models.HealthFactor{
+			services,
+			s.devicesFactor(hosted),
+			s.diskFactor(),
+			s.updatesFactor(),
+		},
+		Timestamp: time.Now(),
+	}
+	for _, f := range score.Factors {
+		score.Score += f.Weight * f.Score
+	}
+	score.Score = roundScore(score.Score)
+	switch {
+	case score.Score >= 90:
+		score.Grade = "healthy"
+	case score.Score >= 70:
+		score.Grade = "degraded"
+	default:
+		score.Grade = "critical"
+	}
+
+	s.cache.Set("health_score", score, healthCacheTTL)
+	return score
+}
+
+// GetReport returns the current score with its history over the last
+// hours, averaged down to at most MaxMetricsHistoryPoints samples
+func (s *HealthService) GetReport(hours int) (*models.HealthScoreReport, error) {
+	to := time.Now()
+	from := to.Add(-time.Duration(hours) * time.Hour)
+
+	var samples []models.HealthScoreSample
+	if err := s.db.Where("sampled_at >= ? AND sampled_at <= ?", from, to).Order("sampled_at ASC").Find(&samples).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.HealthScoreReport{
+		HealthScore: s.GetScore(),
+		History:     downsampleHealth(samples, from, to),
+	}, nil
+}
+
+// downsampleHealth averages samples into buckets when there are more than
+// MaxMetricsHistoryPoints
+func downsampleHealth(samples []models.HealthScoreSample, from, to time.Time) []models.HealthScoreSample {
+	if len(samples) <= MaxMetricsHistoryPoints {
+		return samples
+	}
+	bucket := to.Sub(from) / MaxMetricsHistoryPoints
+
+	history := make([]models.HealthScoreSample, 0, MaxMetricsHistoryPoints)
+	var current models.HealthScoreSample
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		n := float64(count)
+		current.Score = roundScore(current.Score / n)
+		current.Services = roundScore(current.Services / n)
+		current.Devices = roundScore(current.Devices / n)
+		current.Disk = roundScore(current.Disk / n)
+		current.Updates = roundScore(current.Updates / n)
+		history = append(history, current)
+		count = 0
+	}
+
+	currentKey := int64(-1)
+	for _, sample := range samples {
+		key := sample.Timestamp.Sub(from).Nanoseconds() / bucket.Nanoseconds()
+		if key != currentKey {
+			flush()
+			currentKey = key
+			current = models.HealthScoreSample{Timestamp: from.Add(time.Duration(key) * bucket)}
+		}
+		current.Score += sample.Score
+		current.Services += sample.Services
+		current.Devices += sample.Devices
+		current.Disk += sample.Disk
+		current.Updates += sample.Updates
+		count++
+	}
+	flush()
+	return history
+}
+
+// hostedService is an active service with its latest status
+type hostedService struct {
+	ID       uint
+	Name     string
+	Impact   string
+	DeviceID *uint
+	Status   string
+}
+
+// servicesFactor weighs each down service by its impact. Services marked
+// host_down are left out and returned per device for the devices factor.
+func (s *HealthService) servicesFactor() (models.HealthFactor, map[uint][]hostedService) {
+	factor := models.HealthFactor{
+		Name:      models.FactorServices,
+		Weight:    healthWeights[models.FactorServices],
+		Score:     100,
+		Penalties: []models.HealthPenalty{},
+	}
+
+	// Latest local check per active service, as in the summary
+	var services []hostedService
+	s.db.Raw(`SELECT cfg.id, cfg.name, cfg.impact, cfg.device_id, COALESCE(sc.status, '') AS status
+		FROM service_configs cfg
+		LEFT JOIN (SELECT service_id, MAX(checked_at) AS checked_at FROM service_checks WHERE location = '' GROUP BY service_id) latest
+		ON latest.service_id = cfg.id
+		LEFT JOIN service_checks sc ON sc.service_id = latest.service_id AND sc.checked_at = latest.checked_at AND sc.location = ''
+		WHERE cfg.is_active = ? AND cfg.deleted_at IS NULL`, true).Scan(&services)
+
+	hosted := make(map[uint][]hostedService)
+	var total float64
+	for _, svc := range services {
+		if svc.DeviceID != nil {
+			hosted[*svc.DeviceID] = append(hosted[*svc.DeviceID], svc)
+		}
+		if svc.Status != "host_down" {
+			total += impactWeight(svc.Impact)
+		}
+	}
+	if total == 0 {
+		return factor, hosted
+	}
+
+	for _, svc := range services {
+		if svc.Status != "offline" && svc.Status != "error" {
+			continue
+		}
+		penalty := 100 * impactWeight(svc.Impact) / total
+		factor.Score -= penalty
+		factor.Penalties = append(factor.Penalties, models.HealthPenalty{
+			Name:    svc.Name,
+			Reason:  fmt.Sprintf("%s, %s impact", svc.Status, impactLevel(svc.Impact)),
+			Penalty: roundScore(penalty),
+		})
+	}
+	factor.Score = clampScore(factor.Score)
+	return factor, hosted
+}
+
+// devicesFactor weighs each offline device by its criticality plus the
+// impact of the services running on it. Devices never seen online are
+// left out.
+func (s *HealthService) devicesFactor(hosted map[uint][]hostedService) models.HealthFactor {
+	factor := models.HealthFactor{
+		Name:      models.FactorDevices,
+		Weight:    healthWeights[models.FactorDevices],
+		Score:     100,
+		Penalties: []models.HealthPenalty{},
+	}
+
+	var devices []models.Device
+	s.db.Select("id", "name", "is_online", "last_seen", "criticality").
+		Where("is_active = ? AND last_seen IS NOT NULL", true).Find(&devices)
+
+	weights := make([]float64, len(devices))
+	var total float64
+	for i, d := range devices {
+		weights[i] = impactWeight(d.Criticality)
+		for _, svc := range hosted[d.ID] {
+			weights[i] += impactWeight(svc.Impact)
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		return factor
+	}
+
+	for i, d := range devices {
+		if d.IsOnline {
+			continue
+		}
+		reason := fmt.Sprintf("offline, %s criticality", impactLevel(d.Criticality))
+		if n := len(hosted[d.ID]); n > 0 {
+			reason += fmt.Sprintf(", %d services depend on it", n)
+		}
+		penalty := 100 * weights[i] / total
+		factor.Score -= penalty
+		factor.Penalties = append(factor.Penalties, models.HealthPenalty{
+			Name:    d.Name,
+			Reason:  reason,
+			Penalty: roundScore(penalty),
+		})
+	}
+	factor.Score = clampScore(factor.Score)
+	return factor
+}
+
+// diskFactor is set by the fullest disk: full marks up to diskWarnPercent
+// used, falling linearly to zero at diskFullPercent
+func (s *HealthService) diskFactor() models.HealthFactor {
+	factor := models.HealthFactor{
+		Name:      models.FactorDisk,
+		Weight:    healthWeights[models.FactorDisk],
+		Score:     100,
+		Penalties: []models.HealthPenalty{},
+	}
+
+	metrics, err := s.metrics.GetSystemMetrics()
+	if err != nil {
+		return factor
+	}
+	worst := 0.0
+	for _, disk := range metrics.Disk {
+		if disk.UsedPercent <= diskWarnPercent {
+			continue
+		}
+		penalty := math.Min(100, 100*(disk.UsedPercent-diskWarnPercent)/(diskFullPercent-diskWarnPercent))
+		worst = math.Max(worst, penalty)
+		factor.Penalties = append(factor.Penalties, models.HealthPenalty{
+			Name:    disk.MountPoint,
+			Reason:  fmt.Sprintf("%.0f%% used, %.1f GB free", disk.UsedPercent, float64(disk.Free)/(1<<30)),
+			Penalty: roundScore(penalty),
+		})
+	}
+	factor.Score = clampScore(100 - worst)
+	return factor
+}
+
+// updatesFactor takes points off for a newer backend release and for
+// pending OS package updates, security updates costing more
+func (s *HealthService) updatesFactor() models.HealthFactor {
+	factor := models.HealthFactor{
+		Name:      models.FactorUpdates,
+		Weight:    healthWeights[models.FactorUpdates],
+		Score:     100,
+		Penalties: []models.HealthPenalty{},
+	}
+	add := func(name, reason string, penalty float64) {
+		factor.Score -= penalty
+		factor.Penalties = append(factor.Penalties, models.HealthPenalty{Name: name, Reason: reason, Penalty: penalty})
+	}
+
+	if s.updates != nil {
+		if info := s.updates.GetVersionInfo(); info.UpdateAvailable {
+			add("backend", fmt.Sprintf("%s is available (running %s)", info.Latest, info.Current), 20)
+		}
+	}
+
+	if packages, security, ok := pendingOSUpdates(); ok {
+		if regular := packages - security; regular > 0 {
+			add("packages", fmt.Sprintf("%d package updates pending", regular), math.Min(30, 2*float64(regular)))
+		}
+		if security > 0 {
+			add("security", fmt.Sprintf("%d security updates pending", security), math.Min(50, 10*float64(security)))
+		}
+	}
+	factor.Score = clampScore(factor.Score)
+	return factor
+}
+
+// pendingOSUpdates reads the package and security update counts left by
+// update-notifier; ok is false on hosts without it
+func pendingOSUpdates() (packages, security int, ok bool) {
+	data, err := os.ReadFile(osUpdatesPath)
+	if err != nil {
+		return 0, 0, false
+	}
+	if m := osUpdatesPattern.FindSubmatch(data); m != nil {
+		packages, _ = strconv.Atoi(string(m[1]))
+	}
+	if m := osSecurityPattern.FindSubmatch(data); m != nil {
+		security, _ = strconv.Atoi(string(m[1]))
+	}
+	return packages, security, true
+}
+
+// impactLevel returns a known impact level, defaulting to medium
+func impactLevel(impact string) string {
+	if ValidImpact(impact) {
+		return impact
+	}
+	return models.ImpactMedium
+}
+
+func clampScore(score float64) float64 {
+	return roundScore(math.Max(0, math.Min(100, score)))
+}
+
+// roundScore rounds to one decimal
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// HookService receives webhooks from external systems and maps them into
+// events and alerts
+type HookService struct {
+	db     *gorm.DB
+	alerts *AlertService
+	events *EventService
+}
+
+// maxHookItems bounds how many items one delivery may map
+const maxHookItems = 100
+
+// hookPresets are the built-in mappings for common senders
+var hookPresets = []models.HookPreset{
+	{
+		Preset:           models.HookPresetCustom,
+		Description:      "Any JSON or form payload; write the templates yourself",
+		TitleTemplate:    "{{.Payload.title}}",
+		MessageTemplate:  "{{.Payload.message}}",
+		SeverityTemplate: "{{or .Payload.severity \"info\"}}",
+		StatusTemplate:   "{{.Payload.status}}",
+		KeyTemplate:      "{{.Payload.key}}",
+	},
+	{
+		Preset:           models.HookPresetGrafana,
+		Description:      "Grafana alerting webhook contact point",
+		ItemsPath:        "alerts",
+		TitleTemplate:    "{{or .Item.labels.alertname .Payload.title}}",
+		MessageTemplate:  "{{or .Item.annotations.summary .Item.annotations.description .Payload.message}}",
+		SeverityTemplate: "{{or .Item.labels.severity \"warning\"}}",
+		StatusTemplate:   "{{.Item.status}}",
+		KeyTemplate:      "{{or .Item.fingerprint .Item.labels.alertname}}",
+	},
+	{
+		Preset:           models.HookPresetUptimeRobot,
+		Description:      "UptimeRobot webhook alert contact (JSON body or query parameters)",
+		TitleTemplate:    "{{.Payload.monitorFriendlyName}} is {{lower (print .Payload.alertTypeFriendlyName)}}",
+		MessageTemplate:  "{{.Payload.monitorURL}}{{with .Payload.alertDetails}}: {{.}}{{end}}",
+		SeverityTemplate: "{{if eq (print .Payload.alertType) \"1\"}}critical{{else}}info{{end}}",
+		StatusTemplate:   "{{if eq (print .Payload.alertType) \"1\"}}firing{{else if eq (print .Payload.alertType) \"2\"}}resolved{{end}}",
+		KeyTemplate:      "{{.Payload.monitorID}}",
+	},
+	{
+		Preset:      models.HookPresetGitHub,
+		Description: "GitHub repository webhook; failed workflow runs are warnings",
+		TitleTemplate: "{{with .Payload.repository}}{{.full_name}}: {{end}}{{index .Headers \"x-github-event\"}}" +
+			"{{with .Payload.action}} {{.}}{{end}}",
+		MessageTemplate: "{{with .Payload.workflow_run}}{{.name}} {{.conclusion}} on {{.head_branch}}" +
+			"{{else}}{{with .Payload.head_commit}}{{.message}}{{else}}{{with .Payload.sender}}by {{.login}}{{end}}{{end}}{{end}}",
+		SeverityTemplate: "{{with .Payload.workflow_run}}{{if eq (print .conclusion) \"failure\"}}warning{{else}}info{{end}}{{else}}info{{end}}",
+	},
+}
+
+// NewHookService creates a new HookService
+func NewHookService(alerts *AlertService, events *EventService) *HookService {
+	return &HookService{
+		db:     database.GetDB(),
+		alerts: alerts,
+		events: events,
+	}
+}
+
+// Presets returns the built-in mappings
+func (s *HookService) Presets() []models.HookPreset {
+	return hookPresets
+}
+
+// hookPreset returns a preset by name
+func hookPreset(name string) (models.HookPreset, bool) {
+	for _, p := range hookPresets {
+		if p.Preset == name {
+			return p, true
+		}
+	}
+	return models.HookPreset{}, false
+}
+
+// applyHookRequest validates a request and copies it onto a hook
+func applyHookRequest(hook *models.InboundHook, req models.InboundHookRequest) error {
+	if req.Preset == "" {
+		req.Preset = models.HookPresetCustom
+	}
+	if _, ok := hookPreset(req.Preset); !ok {
+		return fmt.Errorf("unknown preset: %s", req.Preset)
+	}
+	for name, source := range map[string]string{
+		"title": req.TitleTemplate, "message": req.MessageTemplate, "severity": req.SeverityTemplate,
+		"status": req.StatusTemplate, "key": req.KeyTemplate,
+	} {
+		if _, err := template.New(name).Funcs(templateFuncs).Parse(source); err != nil {
+			return fmt.Errorf("invalid %s template: %v", name, err)
+		}
+	}
+
+	hook.Name = req.Name
+	hook.Preset = req.Preset
+	hook.CreateAlerts = req.CreateAlerts
+	hook.ItemsPath = strings.TrimSpace(req.ItemsPath)
+	hook.TitleTemplate = req.TitleTemplate
+	hook.MessageTemplate = req.MessageTemplate
+	hook.SeverityTemplate = req.SeverityTemplate
+	hook.StatusTemplate = req.StatusTemplate
+	hook.KeyTemplate = req.KeyTemplate
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// ListHooks returns the user's inbound hooks
+func (s *HookService) ListHooks(userID uint) ([]models.InboundHook, error) {
+	hooks := make([]models.InboundHook, 0)
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// GetHook returns one of the user's inbound hooks
+func (s *HookService) GetHook(id uint, userID uint) (*models.InboundHook, error) {
+	var hook models.InboundHook
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error; err != nil {
+		return nil, fmt.Errorf("hook not found")
+	}
+	return &hook, nil
+}
+
+// CreateHook adds an inbound hook and returns it with its secret, which is
+// only shown here and when rotated
+func (s *HookService) CreateHook(userID uint, req models.InboundHookRequest) (*models.InboundHook, string, error) {
+	hook := models.InboundHook{UserID: userID, Enabled: true}
+	if err := applyHookRequest(&hook, req); err != nil {
+		return nil, "", err
+	}
+
+	secret := GenerateBadgeToken()
+	encrypted, err := EncryptSecret(secret)
+	if err != nil {
+		return nil, "", err
+	}
+	hook.Secret = encrypted
+
+	if err := s.db.Create(&hook).Error; err != nil {
+		return nil, "", err
+	}
+	// Create leaves false to the column default
+	if !hook.Enabled {
+		s.db.Model(&hook).Update("enabled", false)
+	}
+	return &hook, secret, nil
+}
+
+// UpdateHook replaces an inbound hook's mapping
+func (s *HookService) UpdateHook(id uint, userID uint, req models.InboundHookRequest) (*models.InboundHook, error) {
+	hook, err := s.GetHook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyHookRequest(hook, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(hook).Error; err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// RotateSecret replaces an inbound hook's secret and returns the new one
+func (s *HookService) RotateSecret(id uint, userID uint) (string, error) {
+	hook, err := s.GetHook(id, userID)
+	if err != nil {
+		return "", err
+	}
+
+	secret := GenerateBadgeToken()
+	encrypted, err := EncryptSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.Model(hook).Update("secret", encrypted).Error; err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// DeleteHook removes an inbound hook. Alerts it raised stay as they are.
+func (s *HookService) DeleteHook(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.InboundHook{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("hook not found")
+	}
+	return result.Error
+}
+
+// Receive authenticates a delivery and maps it into events or alerts.
+// token is ?token=, X-Hook-Token or a Bearer token; signature is GitHub's
+// X-Hub-Signature-256.
+func (s *HookService) Receive(id uint, token, signature string, headers http.Header, query url.Values, body []byte) (*models.HookResult, error) {
+	var hook models.InboundHook
+	if err := s.db.First(&hook, id).Error; err != nil || !hook.Enabled {
+		return nil, ErrWebhookUnauthorized
+	}
+	secret, err := DecryptSecret(hook.Secret)
+	if err != nil || secret == "" || !webhookAuthorized(secret, token, signature, body) {
+		return nil, ErrWebhookUnauthorized
+	}
+
+	result, err := s.mapDelivery(hook, headers, query, body)
+
+	now := time.Now()
+	updates := map[string]interface{}{"last_received_at": now, "last_error": ""}
+	if err != nil {
+		updates["last_error"] = truncateRunes(err.Error(), 1000)
+	}
+	s.db.Model(&hook).Updates(updates)
+	return result, err
+}
+
+// mapDelivery renders the hook's templates for each item in the payload
+func (s *HookService) mapDelivery(hook models.InboundHook, headers http.Header, query url.Values, body []byte) (*models.HookResult, error) {
+	preset, _ := hookPreset(hook.Preset)
+	pick := func(custom, fallback string) string {
+		if custom != "" {
+			return custom
+		}
+		return fallback
+	}
+	itemsPath := pick(hook.ItemsPath, preset.ItemsPath)
+	templates := map[string]string{
+		"title":    pick(hook.TitleTemplate, preset.TitleTemplate),
+		"message":  pick(hook.MessageTemplate, preset.MessageTemplate),
+		"severity": pick(hook.SeverityTemplate, preset.SeverityTemplate),
+		"status":   pick(hook.StatusTemplate, preset.StatusTemplate),
+		"key":      pick(hook.KeyTemplate, preset.KeyTemplate),
+	}
+
+	data := models.HookPayload{Payload: decodeHookBody(body, query), Headers: make(map[string]string, len(headers))}
+	for name, values := range headers {
+		switch strings.ToLower(name) {
+		case "authorization", "x-hook-token", "cookie":
+			// Keep credentials out of rendered events
+			continue
+		}
+		if len(values) > 0 {
+			data.Headers[strings.ToLower(name)] = values[0]
+		}
+	}
+
+	items := []interface{}{data.Payload}
+	if itemsPath != "" {
+		list, ok := lookupPath(data.Payload, itemsPath).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("payload has no array at %q", itemsPath)
+		}
+		items = list
+	}
+	if len(items) > maxHookItems {
+		items = items[:maxHookItems]
+	}
+
+	result := &models.HookResult{}
+	for _, item := range items {
+		data.Item = item
+		rendered := make(map[string]string, len(templates))
+		for name, source := range templates {
+			value, err := renderHookTemplate(source, data)
+			if err != nil {
+				return result, fmt.Errorf("%s template: %v", name, err)
+			}
+			rendered[name] = value
+		}
+
+		title := truncateRunes(rendered["title"], 255)
+		if title == "" {
+			title = hook.Name
+		}
+		message := truncateRunes(rendered["message"], 1000)
+		severity := hookSeverity(rendered["severity"])
+		status := strings.ToLower(rendered["status"])
+		key := truncateRunes(rendered["key"], 255)
+		if key == "" {
+			key = title
+		}
+
+		switch {
+		case hook.CreateAlerts && (status == "firing" || status == "alerting" || status == "down"):
+			if s.alerts.RaiseExternal(hook.UserID, hook.ID, key, title, severity, message) {
+				result.Fired++
+			}
+		case hook.CreateAlerts && (status == "resolved" || status == "ok" || status == "up"):
+			if s.alerts.ResolveExternal(hook.ID, key) {
+				result.Resolved++
+			}
+		default:
+			s.events.RecordFor(hook.UserID, "hook_"+hook.Preset, severity, "hooks", title, message,
+				map[string]interface{}{"hookId": hook.ID, "hook": hook.Name, "status": status, "key": key})
+			result.Events++
+		}
+	}
+	return result, nil
+}
+
+// decodeHookBody decodes a JSON body, falling back to form values. Query
+// parameters fill in keys the body lacks, for senders that only use the URL.
+func decodeHookBody(body []byte, query url.Values) interface{} {
+	// Numbers stay as written so IDs render without exponents
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if len(bytes.TrimSpace(body)) > 0 && decoder.Decode(&payload) == nil {
+		if _, isObject := payload.(map[string]interface{}); !isObject {
+			return payload
+		}
+	} else {
+		payload = map[string]interface{}{}
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key := range form {
+				payload.(map[string]interface{})[key] = form.Get(key)
+			}
+		}
+	}
+
+	object := payload.(map[string]interface{})
+	for key := range query {
+		if _, exists := object[key]; !exists && key != "token" {
+			object[key] = query.Get(key)
+		}
+	}
+	return object
+}
+
+// lookupPath follows a dotted path through maps and array indexes
+func lookupPath(value interface{}, path string) interface{} {
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// renderHookTemplate renders a mapping template, treating missing fields as empty
+func renderHookTemplate(source string, data models.HookPayload) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("hook").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(out.String(), "<no value>", "")), nil
+}
+
+// hookSeverity maps a sender's severity onto info, warning or critical
+func hookSeverity(value string) string {
+	switch strings.ToLower(value) {
+	case "critical", "crit", "error", "high", "page", "p1", "down":
+		return models.SeverityCritical
+	case "info", "informational", "low", "ok", "none", "":
+		return models.SeverityInfo
+	}
+	return models.SeverityWarning
+}
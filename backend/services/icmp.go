@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ICMP echo methods, in order of preference
+const (
+	ICMPModeRaw  = "raw"  // privileged raw socket (root or CAP_NET_RAW)
+	ICMPModeUDP  = "udp"  // unprivileged datagram socket (net.ipv4.ping_group_range)
+	ICMPModeExec = "exec" // fall back to the system ping binary
+)
+
+var (
+	icmpModeOnce sync.Once
+	icmpMode     string
+	icmpSeq      uint32
+)
+
+// ICMPMode returns the echo method available on this host, detected once
+func ICMPMode() string {
+	icmpModeOnce.Do(func() {
+		icmpMode = detectICMPMode()
+		log.Printf("ICMP echo method: %s", icmpMode)
+	})
+	return icmpMode
+}
+
+// detectICMPMode probes which kind of ICMP socket this process may open
+func detectICMPMode() string {
+	if conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+		conn.Close()
+		return ICMPModeRaw
+	}
+	// Unprivileged ICMP sockets are only supported on Linux and macOS
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		if conn, err := icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+			conn.Close()
+			return ICMPModeUDP
+		}
+	}
+	return ICMPModeExec
+}
+
+// icmpEcho sends a single echo request to host and waits for the matching reply
+func icmpEcho(host string, timeout time.Duration) (time.Duration, error) {
+	mode := ICMPMode()
+	if mode == ICMPModeExec {
+		return execEcho(host, timeout)
+	}
+
+	ipAddr, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return 0, err
+	}
+	isV6 := ipAddr.IP.To4() == nil
+
+	network, listen, proto := "ip4:icmp", "0.0.0.0", 1
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if isV6 {
+		network, listen, proto = "ip6:ipv6-icmp", "::", 58
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+	if mode == ICMPModeUDP {
+		network = "udp4"
+		if isV6 {
+			network = "udp6"
+		}
+	}
+
+	conn, err := icmp.ListenPacket(network, listen)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// A random payload lets us match our reply even when the kernel rewrites
+	// the echo ID on unprivileged sockets
+	payload := make([]byte, 16)
+	rand.Read(payload)
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+
+	msg := icmp.Message{
+		Type: echoType,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: payload},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var dst net.Addr = ipAddr
+	if mode == ICMPModeUDP {
+		dst = &net.UDPAddr{IP: ipAddr.IP, Zone: ipAddr.Zone}
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(data, dst); err != nil {
+		return 0, err
+	}
+
+	deadline := start.Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, fmt.Errorf("request timed out")
+		}
+
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || !bytes.Equal(echo.Data, payload) {
+			continue
+		}
+		return time.Since(start), nil
+	}
+}
+
+// execEcho falls back to the system ping binary for a single echo request
+func execEcho(host string, timeout time.Duration) (time.Duration, error) {
+	var cmd *exec.Cmd
+
+	// Windows: ping -n 1 -w <ms> <host>
+	// Linux: ping -c 1 -W <s> <host>
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("ping", "-n", "1", "-w", fmt.Sprintf("%d", timeout.Milliseconds()), host)
+	} else {
+		seconds := int(timeout.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		cmd = exec.Command("ping", "-c", "1", "-W", fmt.Sprintf("%d", seconds), host)
+	}
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("request timed out")
+	}
+
+	if m := pingTimeRe.FindStringSubmatch(string(out)); len(m) > 1 {
+		var ms float64
+		if _, err := fmt.Sscanf(m[1], "%g", &ms); err == nil {
+			return time.Duration(ms * float64(time.Millisecond)), nil
+		}
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ImageUpdateService compares the images of running containers with their
+// registry tags on a schedule and flags the ones with a newer image
+type ImageUpdateService struct {
+	db       *gorm.DB
+	docker   *DockerService
+	events   *EventService
+	interval time.Duration
+
+	mu      sync.Mutex
+	running bool
+}
+
+// registryCheckTimeout bounds a single registry digest lookup
+const registryCheckTimeout = 30 * time.Second
+
+// NewImageUpdateService creates a new ImageUpdateService and starts the
+// scheduler when enabled
+func NewImageUpdateService(docker *DockerService, events *EventService) *ImageUpdateService {
+	s := &ImageUpdateService{
+		db:       database.GetDB(),
+		docker:   docker,
+		events:   events,
+		interval: time.Duration(config.AppConfig.ImageUpdateIntervalHours) * time.Hour,
+	}
+	if s.interval > 0 {
+		go s.checkBackground()
+	}
+	return s
+}
+
+// IsEnabled returns true if scheduled image update checks are enabled
+func (s *ImageUpdateService) IsEnabled() bool {
+	return s.interval > 0
+}
+
+func (s *ImageUpdateService) checkBackground() {
+	// Give Docker and the database a moment before the first check
+	time.Sleep(2 * time.Minute)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.CheckImages()
+		<-ticker.C
+	}
+}
+
+// runningImages maps the image reference of each running container to the
+// IDs of the images they run and the containers' names
+func (s *ImageUpdateService) runningImages() (map[string][]string, map[string][]string) {
+	imageIDs := make(map[string][]string)
+	names := make(map[string][]string)
+	for _, c := range s.docker.GetContainersBasic() {
+		if c.State != "running" {
+			continue
+		}
+		// The list shows the image ID once the tag has moved on; the
+		// container's config keeps the reference it was created from
+		ref := c.Image
+		if info, err := s.docker.client.ContainerInspect(s.docker.ctx, c.ID); err == nil && info.Config != nil {
+			ref = info.Config.Image
+		}
+		if updatableReference(ref) != nil {
+			continue
+		}
+		if !slices.Contains(imageIDs[ref], c.ImageID) {
+			imageIDs[ref] = append(imageIDs[ref], c.ImageID)
+		}
+		names[ref] = append(names[ref], c.Name)
+	}
+	return imageIDs, names
+}
+
+// CheckImages checks every image tag used by a running container against
+// its registry. Returns false if a check is already in progress.
+func (s *ImageUpdateService) CheckImages() bool {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return false
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	if !s.docker.IsConnected() {
+		return true
+	}
+	imageIDs, names := s.runningImages()
+	for ref, ids := range imageIDs {
+		s.checkImage(ref, ids, names[ref])
+	}
+
+	// Forget images no running container uses any more
+	refs := make([]string, 0, len(imageIDs))
+	for ref := range imageIDs {
+		refs = append(refs, ref)
+	}
+	if len(refs) > 0 {
+		s.db.Where("image NOT IN ?", refs).Delete(&models.ImageUpdate{})
+	}
+	return true
+}
+
+// CheckImage re-checks a single image tag, e.g. after a container update
+func (s *ImageUpdateService) CheckImage(ref string) {
+	imageIDs, names := s.runningImages()
+	if ids, ok := imageIDs[ref]; ok {
+		s.checkImage(ref, ids, names[ref])
+		return
+	}
+	s.db.Where("image = ?", ref).Delete(&models.ImageUpdate{})
+}
+
+// checkImage compares the digests of the images containers run from ref with
+// the one the registry serves for it, and records the result
+func (s *ImageUpdateService) checkImage(ref string, imageIDs []string, containers []string) {
+	var previous models.ImageUpdate
+	hadPrevious := s.db.Where("image = ?", ref).First(&previous).Error == nil
+
+	update := models.ImageUpdate{Image: ref, CheckedAt: time.Now()}
+	if hadPrevious {
+		update.ID = previous.ID
+		update.CreatedAt = previous.CreatedAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registryCheckTimeout)
+	defer cancel()
+
+	// Containers may run older images than the tag points to locally; the
+	// update is available while any of them runs another image than the
+	// registry serves
+	digests := make(map[string][]string, len(imageIDs))
+	var local []string
+	for _, id := range imageIDs {
+		if d, err := s.docker.imageDigests(ctx, id); err == nil && len(d) > 0 {
+			digests[id] = d
+			local = append(local, d...)
+		}
+	}
+
+	remote, err := s.docker.RemoteDigest(ctx, ref)
+	switch {
+	case len(local) == 0:
+		update.Error = "image has no registry digest, it was built or loaded locally"
+	case err != nil:
+		update.Error = err.Error()
+		if len(update.Error) > 1000 {
+			update.Error = update.Error[:1000]
+		}
+	default:
+		update.LocalDigest = local[0]
+		update.RemoteDigest = remote
+		for _, id := range imageIDs {
+			if !slices.Contains(digests[id], remote) {
+				update.UpdateAvailable = true
+			}
+		}
+	}
+
+	if err := s.db.Save(&update).Error; err != nil {
+		log.Printf("Failed to save image update check for %s: %v", ref, err)
+		return
+	}
+
+	if update.UpdateAvailable && (!previous.UpdateAvailable || previous.RemoteDigest != update.RemoteDigest) {
+		sort.Strings(containers)
+		s.events.Record("image_update_available", models.SeverityInfo, "docker",
+			"Container image update available",
+			fmt.Sprintf("A newer %s is available for %s", ref, strings.Join(containers, ", ")),
+			map[string]interface{}{"image": ref, "digest": remote, "containers": containers})
+	}
+}
+
+// GetUpdates returns the latest check of every image running containers use
+func (s *ImageUpdateService) GetUpdates() ([]models.ImageUpdate, error) {
+	var updates []models.ImageUpdate
+	if err := s.db.Order("update_available DESC, image ASC").Find(&updates).Error; err != nil {
+		return nil, err
+	}
+
+	_, names := s.runningImages()
+	for i := range updates {
+		updates[i].Containers = names[updates[i].Image]
+		if updates[i].Containers == nil {
+			updates[i].Containers = []string{}
+		}
+	}
+	return updates, nil
+}
+
+// UpdatesAvailable returns the image references with a newer image in
+// their registry
+func (s *ImageUpdateService) UpdatesAvailable() map[string]bool {
+	result := make(map[string]bool)
+	var refs []string
+	if err := s.db.Model(&models.ImageUpdate{}).Where("update_available = ?", true).Pluck("image", &refs).Error; err != nil {
+		return result
+	}
+	for _, ref := range refs {
+		result[ref] = true
+	}
+	return result
+}
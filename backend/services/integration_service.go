@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/integrations"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// IntegrationService stores integration settings and dispatches calls to the registry
+type IntegrationService struct {
+	db       *gorm.DB
+	registry *integrations.Registry
+
+	mu      sync.RWMutex
+	enabled map[string]bool
+	errors  map[string]string // last Configure error per integration
+}
+
+const (
+	maskedSecret       = "********"
+	integrationTimeout = 30 * time.Second
+)
+
+// NewIntegrationService creates a new IntegrationService and configures every
+// enabled integration from the stored settings
+func NewIntegrationService(registry *integrations.Registry) *IntegrationService {
+	s := &IntegrationService{
+		db:       database.GetDB(),
+		registry: registry,
+		enabled:  make(map[string]bool),
+		errors:   make(map[string]string),
+	}
+
+	var configs []models.IntegrationConfig
+	s.db.Find(&configs)
+	for _, cfg := range configs {
+		integration, ok := registry.Get(cfg.Name)
+		if !ok {
+			continue
+		}
+		config, err := integrationConfig(integration, cfg)
+		if err != nil {
+			log.Printf("Failed to read integration %s settings: %v", cfg.Name, err)
+			s.errors[cfg.Name] = err.Error()
+			continue
+		}
+		s.encryptStoredConfig(integration, cfg, config)
+		if cfg.Enabled {
+			s.apply(integration, true, config)
+		}
+	}
+
+	return s
+}
+
+func decodeIntegrationConfig(data string) map[string]string {
+	config := make(map[string]string)
+	if data != "" {
+		json.Unmarshal([]byte(data), &config)
+	}
+	return config
+}
+
+// integrationConfig returns an integration's stored settings with secrets
+// decrypted
+func integrationConfig(integration integrations.Integration, cfg models.IntegrationConfig) (map[string]string, error) {
+	config := decodeIntegrationConfig(cfg.Config)
+	for _, field := range integration.ConfigSchema() {
+		if field.Type != models.FieldSecret {
+			continue
+		}
+		value, err := DecryptSecret(config[field.Key])
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s: %v", field.Key, err)
+		}
+		config[field.Key] = value
+	}
+	return config, nil
+}
+
+// encodeIntegrationConfig returns settings as stored, with secrets encrypted
+func encodeIntegrationConfig(integration integrations.Integration, config map[string]string) (string, error) {
+	stored := make(map[string]string, len(config))
+	for key, value := range config {
+		stored[key] = value
+	}
+	for _, field := range integration.ConfigSchema() {
+		if field.Type != models.FieldSecret || stored[field.Key] == "" {
+			continue
+		}
+		encrypted, err := EncryptSecret(stored[field.Key])
+		if err != nil {
+			return "", err
+		}
+		stored[field.Key] = encrypted
+	}
+	data, _ := json.Marshal(stored)
+	return string(data), nil
+}
+
+// encryptStoredConfig re-saves settings stored before secrets were encrypted
+func (s *IntegrationService) encryptStoredConfig(integration integrations.Integration, cfg models.IntegrationConfig, config map[string]string) {
+	stored := decodeIntegrationConfig(cfg.Config)
+	plain := false
+	for _, field := range integration.ConfigSchema() {
+		if field.Type == models.FieldSecret && stored[field.Key] != "" && !strings.HasPrefix(stored[field.Key], encryptedPrefix) {
+			plain = true
+		}
+	}
+	if !plain {
+		return
+	}
+
+	data, err := encodeIntegrationConfig(integration, config)
+	if err == nil {
+		err = s.db.Model(&cfg).UpdateColumn("config", data).Error
+	}
+	if err != nil {
+		log.Printf("Failed to encrypt integration %s secrets: %v", cfg.Name, err)
+		return
+	}
+	log.Printf("Encrypted the stored secrets of integration %s", cfg.Name)
+}
+
+// apply configures an integration and records its enabled state
+func (s *IntegrationService) apply(integration integrations.Integration, enabled bool, config map[string]string) error {
+	var err error
+	if enabled {
+		err = integration.Configure(integrations.ApplyDefaults(integration.ConfigSchema(), config))
+		if err != nil {
+			log.Printf("Failed to configure integration %s: %v", integration.Name(), err)
+		}
+	}
+	if stopper, ok := integration.(integrations.Stopper); ok && (!enabled || err != nil) {
+		stopper.Stop()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled[integration.Name()] = enabled && err == nil
+	if err != nil {
+		s.errors[integration.Name()] = err.Error()
+	} else {
+		delete(s.errors, integration.Name())
+	}
+	return err
+}
+
+// IsEnabled returns true if the integration is enabled and configured
+func (s *IntegrationService) IsEnabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled[name]
+}
+
+// List returns every registered integration with its schema and masked settings
+func (s *IntegrationService) List() []models.IntegrationInfo {
+	var configs []models.IntegrationConfig
+	s.db.Find(&configs)
+	stored := make(map[string]models.IntegrationConfig, len(configs))
+	for _, cfg := range configs {
+		stored[cfg.Name] = cfg
+	}
+
+	result := make([]models.IntegrationInfo, 0)
+	for _, integration := range s.registry.All() {
+		result = append(result, s.info(integration, stored[integration.Name()]))
+	}
+	return result
+}
+
+// Get returns a single integration
+func (s *IntegrationService) Get(name string) (*models.IntegrationInfo, error) {
+	integration, ok := s.registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("integration not found")
+	}
+
+	var cfg models.IntegrationConfig
+	s.db.Where("name = ?", name).First(&cfg)
+	info := s.info(integration, cfg)
+	return &info, nil
+}
+
+func (s *IntegrationService) info(integration integrations.Integration, cfg models.IntegrationConfig) models.IntegrationInfo {
+	schema := integration.ConfigSchema()
+	config := decodeIntegrationConfig(cfg.Config)
+	for _, field := range schema {
+		if field.Type == models.FieldSecret && config[field.Key] != "" {
+			config[field.Key] = maskedSecret
+		}
+	}
+
+	info := models.IntegrationInfo{
+		Name:        integration.Name(),
+		Description: integration.Description(),
+		Kinds:       integrations.Kinds(integration),
+		Schema:      schema,
+		Enabled:     s.IsEnabled(integration.Name()),
+		Config:      config,
+	}
+	if actor, ok := integration.(integrations.Actor); ok {
+		info.Actions = actor.Actions()
+	}
+
+	s.mu.RLock()
+	info.Error = s.errors[integration.Name()]
+	s.mu.RUnlock()
+	return info
+}
+
+// Update enables/disables an integration or changes its settings.
+// Masked secrets in the request keep their stored value; secrets are stored
+// encrypted.
+func (s *IntegrationService) Update(name string, req models.UpdateIntegrationRequest) (*models.IntegrationInfo, error) {
+	integration, ok := s.registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("integration not found")
+	}
+
+	var cfg models.IntegrationConfig
+	if err := s.db.Where("name = ?", name).FirstOrCreate(&cfg, models.IntegrationConfig{Name: name}).Error; err != nil {
+		return nil, err
+	}
+
+	config, err := integrationConfig(integration, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if req.Config != nil {
+		previous := config
+		config = make(map[string]string)
+		for _, field := range integration.ConfigSchema() {
+			value, ok := req.Config[field.Key]
+			if !ok || (field.Type == models.FieldSecret && value == maskedSecret) {
+				value = previous[field.Key]
+			}
+			if value != "" {
+				config[field.Key] = value
+			}
+		}
+	}
+	if req.Enabled != nil {
+		cfg.Enabled = *req.Enabled
+	}
+
+	if cfg.Enabled {
+		if err := integrations.ValidateConfig(integration.ConfigSchema(), integrations.ApplyDefaults(integration.ConfigSchema(), config)); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := encodeIntegrationConfig(integration, config)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Config = data
+	if err := s.db.Save(&cfg).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(integration, cfg.Enabled, config); err != nil {
+		return nil, err
+	}
+	return s.Get(name)
+}
+
+// enabledIntegration returns the integration if it exists and is enabled
+func (s *IntegrationService) enabledIntegration(name string) (integrations.Integration, error) {
+	integration, ok := s.registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("integration not found")
+	}
+	if !s.IsEnabled(name) {
+		return nil, fmt.Errorf("integration %s is not enabled", name)
+	}
+	return integration, nil
+}
+
+// Collect runs a collector integration
+func (s *IntegrationService) Collect(name string) (interface{}, error) {
+	integration, err := s.enabledIntegration(name)
+	if err != nil {
+		return nil, err
+	}
+	collector, ok := integration.(integrations.Collector)
+	if !ok {
+		return nil, fmt.Errorf("integration %s is not a collector", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+	defer cancel()
+	return collector.Collect(ctx)
+}
+
+// Execute runs an action on an actor integration
+func (s *IntegrationService) Execute(name, action string, params map[string]string) (interface{}, error) {
+	integration, err := s.enabledIntegration(name)
+	if err != nil {
+		return nil, err
+	}
+	actor, ok := integration.(integrations.Actor)
+	if !ok {
+		return nil, fmt.Errorf("integration %s does not support actions", name)
+	}
+
+	for _, a := range actor.Actions() {
+		if a.Name == action {
+			if err := integrations.ValidateConfig(a.Params, params); err != nil {
+				return nil, err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+			defer cancel()
+			return actor.Execute(ctx, action, params)
+		}
+	}
+	return nil, fmt.Errorf("unknown action: %s", action)
+}
+
+// TestNotify sends a test notification through a notifier integration
+func (s *IntegrationService) TestNotify(name string) error {
+	integration, err := s.enabledIntegration(name)
+	if err != nil {
+		return err
+	}
+	notifier, ok := integration.(integrations.Notifier)
+	if !ok {
+		return fmt.Errorf("integration %s is not a notifier", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+	defer cancel()
+	return notifier.Notify(ctx, models.Notification{
+		Title:    "Test notification",
+		Message:  "This is a test notification from Homelab Monitor",
+		Severity: models.SeverityInfo,
+		Source:   "integrations",
+	})
+}
+
+// Notify sends a notification through every enabled notifier, returning per-notifier errors
+func (s *IntegrationService) Notify(n models.Notification) map[string]error {
+	failures := make(map[string]error)
+	for _, integration := range s.registry.All() {
+		notifier, ok := integration.(integrations.Notifier)
+		if !ok || !s.IsEnabled(integration.Name()) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+		if err := notifier.Notify(ctx, n); err != nil {
+			log.Printf("Notifier %s failed: %v", integration.Name(), err)
+			failures[integration.Name()] = err
+		}
+		cancel()
+	}
+	return failures
+}
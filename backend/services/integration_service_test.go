@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+type testIntegration struct{}
+
+func (testIntegration) Name() string        { return "test" }
+func (testIntegration) Description() string { return "Test integration" }
+func (testIntegration) ConfigSchema() []models.IntegrationField {
+	return []models.IntegrationField{
+		{Key: "url", Type: models.FieldURL},
+		{Key: "token", Type: models.FieldSecret},
+	}
+}
+func (testIntegration) Configure(map[string]string) error { return nil }
+
+func TestIntegrationConfigSecrets(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{EncryptionKey: "test key"}
+	defer func() { config.AppConfig = previous }()
+
+	data, err := encodeIntegrationConfig(testIntegration{}, map[string]string{"url": "http://nas", "token": "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := decodeIntegrationConfig(data)
+	if stored["url"] != "http://nas" {
+		t.Errorf("stored url = %q", stored["url"])
+	}
+	if !strings.HasPrefix(stored["token"], encryptedPrefix) || strings.Contains(data, "s3cret") {
+		t.Errorf("token stored as %q, want it encrypted", stored["token"])
+	}
+
+	decoded, err := integrationConfig(testIntegration{}, models.IntegrationConfig{Config: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded["url"] != "http://nas" || decoded["token"] != "s3cret" {
+		t.Errorf("decoded %v", decoded)
+	}
+
+	// Settings saved before secrets were encrypted still read
+	legacy, err := integrationConfig(testIntegration{}, models.IntegrationConfig{Config: `{"token":"plain"}`})
+	if err != nil || legacy["token"] != "plain" {
+		t.Errorf("legacy settings decoded as %v, %v", legacy, err)
+	}
+
+	config.AppConfig.EncryptionKey = "other key"
+	if _, err := integrationConfig(testIntegration{}, models.IntegrationConfig{Config: data}); err == nil {
+		t.Error("decrypting with another key succeeded")
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidDate is returned for inventory dates not in YYYY-MM-DD form
+var ErrInvalidDate = errors.New("invalid date")
+
+// AttachmentTypes are the sniffed content types accepted for device attachments
+var AttachmentTypes = []string{"application/pdf", "image/png", "image/jpeg", "image/gif", "image/webp", "text/plain"}
+
+// InventoryService manages device attachments and warranty reminders
+type InventoryService struct {
+	db     *gorm.DB
+	events *EventService
+	dir    string
+}
+
+// NewInventoryService creates a new InventoryService and starts the daily warranty check
+func NewInventoryService(events *EventService) *InventoryService {
+	s := &InventoryService{
+		db:     database.GetDB(),
+		events: events,
+		dir:    config.AppConfig.AttachmentDir,
+	}
+
+	go s.warrantyBackground()
+
+	return s
+}
+
+// parseInventoryDate parses a YYYY-MM-DD date; empty means no date
+func parseInventoryDate(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s, use YYYY-MM-DD", ErrInvalidDate, field)
+	}
+	return &date, nil
+}
+
+// ownsDevice returns an error unless the device exists and belongs to the user
+func (s *InventoryService) ownsDevice(deviceID uint, userID uint) error {
+	var count int64
+	s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", deviceID, userID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("device not found")
+	}
+	return nil
+}
+
+// ListAttachments returns a device's attachments, newest first
+func (s *InventoryService) ListAttachments(deviceID uint, userID uint) ([]models.DeviceAttachment, error) {
+	if err := s.ownsDevice(deviceID, userID); err != nil {
+		return nil, err
+	}
+	var attachments []models.DeviceAttachment
+	if err := s.db.Where("device_id = ?", deviceID).Order("created_at DESC").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// AddAttachment stores a file for a device
+func (s *InventoryService) AddAttachment(deviceID uint, userID uint, filename, contentType string, data []byte) (*models.DeviceAttachment, error) {
+	if err := s.ownsDevice(deviceID, userID); err != nil {
+		return nil, err
+	}
+
+	// Files are stored under a generated name; the original name is only metadata
+	name, _ := newToken("")
+	rel := filepath.Join(strconv.FormatUint(uint64(deviceID), 10), name)
+	path := filepath.Join(s.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return nil, err
+	}
+
+	attachment := models.DeviceAttachment{
+		DeviceID:    deviceID,
+		UserID:      userID,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Path:        rel,
+	}
+	if err := s.db.Create(&attachment).Error; err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetAttachment returns an attachment and the path of its file
+func (s *InventoryService) GetAttachment(id uint, deviceID uint, userID uint) (*models.DeviceAttachment, string, error) {
+	var attachment models.DeviceAttachment
+	if err := s.db.Where("id = ? AND device_id = ? AND user_id = ?", id, deviceID, userID).First(&attachment).Error; err != nil {
+		return nil, "", fmt.Errorf("attachment not found")
+	}
+	return &attachment, filepath.Join(s.dir, attachment.Path), nil
+}
+
+// DeleteAttachment removes an attachment and its file
+func (s *InventoryService) DeleteAttachment(id uint, deviceID uint, userID uint) error {
+	attachment, path, err := s.GetAttachment(id, deviceID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(attachment).Error; err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove attachment file %s: %v", path, err)
+	}
+	return nil
+}
+
+// warrantyBackground checks warranty expiry dates once a day
+func (s *InventoryService) warrantyBackground() {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		s.checkWarranties()
+		<-ticker.C
+	}
+}
+
+// checkWarranties records an event when a device's warranty is about to
+// expire and again once it has expired
+func (s *InventoryService) checkWarranties() {
+	now := time.Now()
+	soon := now.AddDate(0, 0, config.AppConfig.WarrantyReminderDays)
+
+	var devices []models.Device
+	if err := s.db.Where("warranty_expiry IS NOT NULL AND warranty_expiry <= ? AND warranty_notice <> ?", soon, "expired").Find(&devices).Error; err != nil {
+		log.Printf("Failed to check warranties: %v", err)
+		return
+	}
+
+	for _, device := range devices {
+		expiry := device.WarrantyExpiry.Format("2006-01-02")
+		notice := "expiring"
+		if !device.WarrantyExpiry.After(now) {
+			notice = "expired"
+		}
+		if device.WarrantyNotice == notice {
+			continue
+		}
+
+		details := map[string]interface{}{"deviceId": device.ID, "warrantyExpiry": expiry, "serialNumber": device.SerialNumber}
+		if notice == "expired" {
+			s.events.RecordFor(device.UserID, "warranty_expired", models.SeverityWarning, "inventory",
+				"Warranty expired", fmt.Sprintf("The warranty of %s expired on %s", device.Name, expiry), details)
+		} else {
+			days := int(device.WarrantyExpiry.Sub(now).Hours()/24) + 1
+			s.events.RecordFor(device.UserID, "warranty_expiring", models.SeverityWarning, "inventory",
+				"Warranty expiring", fmt.Sprintf("The warranty of %s expires on %s (%d days)", device.Name, expiry, days), details)
+		}
+		s.db.Model(&device).Update("warranty_notice", notice)
+	}
+}
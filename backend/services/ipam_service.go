@@ -0,0 +1,395 @@
+package services
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// IPAMService manages the IP address plan: subnets, reserved ranges and which
+// addresses devices use
+type IPAMService struct {
+	db *gorm.DB
+}
+
+// Subnet size limits; larger networks are too big to walk address by address
+const (
+	minSubnetBits = 16
+	maxSubnetBits = 30
+)
+
+// neighborTablePath is the kernel's ARP cache, used as the record of hosts
+// actually seen on the network
+const neighborTablePath = "/proc/net/arp"
+
+// NewIPAMService creates a new IPAMService
+func NewIPAMService() *IPAMService {
+	return &IPAMService{db: database.GetDB()}
+}
+
+// ListSubnets returns the user's subnets with their reservations
+func (s *IPAMService) ListSubnets(userID uint) ([]models.Subnet, error) {
+	var subnets []models.Subnet
+	err := s.db.Preload("Reservations").Where("user_id = ?", userID).Order("name").Find(&subnets).Error
+	return subnets, err
+}
+
+// getSubnet loads one of the user's subnets
+func (s *IPAMService) getSubnet(id, userID uint) (*models.Subnet, error) {
+	var subnet models.Subnet
+	if err := s.db.Preload("Reservations").Where("id = ? AND user_id = ?", id, userID).First(&subnet).Error; err != nil {
+		return nil, fmt.Errorf("subnet not found")
+	}
+	return &subnet, nil
+}
+
+// CreateSubnet adds a subnet to the plan
+func (s *IPAMService) CreateSubnet(userID uint, req models.SubnetRequest) (*models.Subnet, error) {
+	prefix, err := validateSubnet(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	subnet := models.Subnet{
+		UserID:       userID,
+		Name:         strings.TrimSpace(req.Name),
+		CIDR:         prefix.String(),
+		Gateway:      req.Gateway,
+		VLAN:         req.VLAN,
+		Description:  req.Description,
+		Reservations: req.Reservations,
+	}
+	if err := s.db.Create(&subnet).Error; err != nil {
+		return nil, err
+	}
+	return &subnet, nil
+}
+
+// UpdateSubnet changes a subnet and replaces its reservations
+func (s *IPAMService) UpdateSubnet(id, userID uint, req models.SubnetRequest) (*models.Subnet, error) {
+	subnet, err := s.getSubnet(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := validateSubnet(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	subnet.Name = strings.TrimSpace(req.Name)
+	subnet.CIDR = prefix.String()
+	subnet.Gateway = req.Gateway
+	subnet.VLAN = req.VLAN
+	subnet.Description = req.Description
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Reservations").Save(subnet).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subnet_id = ?", subnet.ID).Delete(&models.IPReservation{}).Error; err != nil {
+			return err
+		}
+		for i := range req.Reservations {
+			req.Reservations[i].SubnetID = subnet.ID
+		}
+		if len(req.Reservations) > 0 {
+			return tx.Create(&req.Reservations).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	subnet.Reservations = req.Reservations
+	return subnet, nil
+}
+
+// DeleteSubnet removes a subnet and its reservations
+func (s *IPAMService) DeleteSubnet(id, userID uint) error {
+	subnet, err := s.getSubnet(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subnet_id = ?", subnet.ID).Delete(&models.IPReservation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(subnet).Error
+	})
+}
+
+// validateSubnet checks the CIDR, gateway and reservations, normalizing the
+// addresses in req
+func validateSubnet(req *models.SubnetRequest) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(req.CIDR))
+	if err != nil || !prefix.Addr().Is4() {
+		return netip.Prefix{}, fmt.Errorf("invalid IPv4 CIDR %q", req.CIDR)
+	}
+	if prefix.Bits() < minSubnetBits || prefix.Bits() > maxSubnetBits {
+		return netip.Prefix{}, fmt.Errorf("subnet prefix must be between /%d and /%d", minSubnetBits, maxSubnetBits)
+	}
+	prefix = prefix.Masked()
+
+	if req.Gateway = strings.TrimSpace(req.Gateway); req.Gateway != "" {
+		gateway, err := netip.ParseAddr(req.Gateway)
+		if err != nil || !prefix.Contains(gateway) {
+			return netip.Prefix{}, fmt.Errorf("gateway %q is not in %s", req.Gateway, prefix)
+		}
+		req.Gateway = gateway.String()
+	}
+
+	for i := range req.Reservations {
+		r := &req.Reservations[i]
+		start, err := netip.ParseAddr(strings.TrimSpace(r.Start))
+		if err != nil || !prefix.Contains(start) {
+			return netip.Prefix{}, fmt.Errorf("reservation start %q is not in %s", r.Start, prefix)
+		}
+		end := start
+		if strings.TrimSpace(r.End) != "" {
+			end, err = netip.ParseAddr(strings.TrimSpace(r.End))
+			if err != nil || !prefix.Contains(end) {
+				return netip.Prefix{}, fmt.Errorf("reservation end %q is not in %s", r.End, prefix)
+			}
+		}
+		if end.Less(start) {
+			return netip.Prefix{}, fmt.Errorf("reservation %s-%s ends before it starts", start, end)
+		}
+		r.ID = 0
+		r.Start = start.String()
+		r.End = end.String()
+	}
+	return prefix, nil
+}
+
+// hostRange returns the first and last usable host addresses of a prefix
+func hostRange(prefix netip.Prefix) (netip.Addr, netip.Addr) {
+	network := prefix.Masked().Addr().As4()
+	broadcast := binary.BigEndian.Uint32(network[:]) | (1<<(32-prefix.Bits()) - 1)
+	var last [4]byte
+	binary.BigEndian.PutUint32(last[:], broadcast)
+	return netip.AddrFrom4(network).Next(), netip.AddrFrom4(last).Prev()
+}
+
+// reservedAddr reports whether addr falls in any of the reservations
+func reservedAddr(addr netip.Addr, reservations []models.IPReservation) bool {
+	for _, r := range reservations {
+		start, err1 := netip.ParseAddr(r.Start)
+		end, err2 := netip.ParseAddr(r.End)
+		if err1 == nil && err2 == nil && !addr.Less(start) && !end.Less(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// neighborTable reads the IP to MAC entries the host has resolved. It is
+// empty on systems without /proc/net/arp.
+func neighborTable() map[netip.Addr]string {
+	neighbors := make(map[netip.Addr]string)
+	file, err := os.Open(neighborTablePath)
+	if err != nil {
+		return neighbors
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] == "0x0" || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(fields[0]); err == nil {
+			neighbors[addr] = normalizeMAC(fields[3])
+		}
+	}
+	return neighbors
+}
+
+// normalizeMAC lowercases a MAC address and uses colon separators
+func normalizeMAC(mac string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mac)), "-", ":")
+}
+
+// deviceAddrs loads the user's devices keyed by their parsed IP address.
+// Devices addressed by hostname are skipped.
+func (s *IPAMService) deviceAddrs(userID uint) (map[netip.Addr][]models.Device, error) {
+	var devices []models.Device
+	if err := s.db.Select("id", "name", "ip", "mac").Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	byAddr := make(map[netip.Addr][]models.Device)
+	for _, device := range devices {
+		if addr, err := netip.ParseAddr(strings.TrimSpace(device.IP)); err == nil {
+			byAddr[addr] = append(byAddr[addr], device)
+		}
+	}
+	return byAddr, nil
+}
+
+// GetSubnetUsage lists the taken addresses in a subnet and counts what is left
+func (s *IPAMService) GetSubnetUsage(id, userID uint) (*models.SubnetUsage, error) {
+	subnet, err := s.getSubnet(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.deviceAddrs(userID)
+	if err != nil {
+		return nil, err
+	}
+	neighbors := neighborTable()
+
+	usage := &models.SubnetUsage{Subnet: *subnet, Assignments: make([]models.IPAssignment, 0)}
+	first, last := hostRange(prefix)
+	for addr := first; !last.Less(addr); addr = addr.Next() {
+		usage.Total++
+		reserved := reservedAddr(addr, subnet.Reservations)
+		if reserved {
+			usage.Reserved++
+		}
+
+		var assignments []models.IPAssignment
+		for _, device := range devices[addr] {
+			assignments = append(assignments, models.IPAssignment{
+				IP: addr.String(), DeviceID: device.ID, DeviceName: device.Name, MAC: device.MAC, Source: "device", Reserved: reserved,
+			})
+		}
+		if len(assignments) == 0 && addr.String() == subnet.Gateway {
+			assignments = append(assignments, models.IPAssignment{IP: addr.String(), Source: "gateway", Reserved: reserved})
+		}
+		if mac, ok := neighbors[addr]; ok && len(assignments) == 0 {
+			assignments = append(assignments, models.IPAssignment{IP: addr.String(), MAC: mac, Source: "neighbor", Reserved: reserved})
+		}
+
+		if len(assignments) > 0 {
+			usage.Assignments = append(usage.Assignments, assignments...)
+			if !reserved {
+				usage.Assigned++
+			}
+		} else if !reserved && usage.NextFree == "" {
+			usage.NextFree = addr.String()
+		}
+	}
+	usage.Free = usage.Total - usage.Assigned - usage.Reserved
+	return usage, nil
+}
+
+// NextFreeIP suggests the lowest address in the subnet that no device,
+// gateway, reservation or seen host uses
+func (s *IPAMService) NextFreeIP(id, userID uint) (string, error) {
+	usage, err := s.GetSubnetUsage(id, userID)
+	if err != nil {
+		return "", err
+	}
+	if usage.NextFree == "" {
+		return "", fmt.Errorf("no free addresses in %s", usage.Subnet.CIDR)
+	}
+	return usage.NextFree, nil
+}
+
+// Conflicts finds devices sharing an address, devices inside reserved ranges,
+// and disagreements between the device list and hosts seen on the network
+func (s *IPAMService) Conflicts(userID uint) ([]models.IPConflict, error) {
+	subnets, err := s.ListSubnets(userID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.deviceAddrs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type planned struct {
+		subnet models.Subnet
+		prefix netip.Prefix
+	}
+	plan := make([]planned, 0, len(subnets))
+	for _, subnet := range subnets {
+		if prefix, err := netip.ParsePrefix(subnet.CIDR); err == nil {
+			plan = append(plan, planned{subnet, prefix})
+		}
+	}
+	subnetFor := func(addr netip.Addr) *models.Subnet {
+		for i := range plan {
+			if plan[i].prefix.Contains(addr) {
+				return &plan[i].subnet
+			}
+		}
+		return nil
+	}
+
+	conflicts := make([]models.IPConflict, 0)
+	add := func(conflict models.IPConflict, addr netip.Addr) {
+		conflict.IP = addr.String()
+		if subnet := subnetFor(addr); subnet != nil {
+			conflict.SubnetID = subnet.ID
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	for addr, list := range devices {
+		ids := make([]uint, len(list))
+		names := make([]string, len(list))
+		for i, device := range list {
+			ids[i] = device.ID
+			names[i] = device.Name
+		}
+
+		if len(list) > 1 {
+			add(models.IPConflict{
+				Type: models.ConflictDuplicateIP, DeviceIDs: ids,
+				Message: fmt.Sprintf("%s is used by %s", addr, strings.Join(names, ", ")),
+			}, addr)
+		}
+		if subnet := subnetFor(addr); subnet != nil && reservedAddr(addr, subnet.Reservations) {
+			add(models.IPConflict{
+				Type: models.ConflictReservedRange, DeviceIDs: ids,
+				Message: fmt.Sprintf("%s is in a reserved range of %s", addr, subnet.Name),
+			}, addr)
+		}
+	}
+
+	for addr, mac := range neighborTable() {
+		list, claimed := devices[addr]
+		if !claimed {
+			if subnetFor(addr) != nil {
+				add(models.IPConflict{
+					Type: models.ConflictUnknownHost, ObservedMAC: mac,
+					Message: fmt.Sprintf("a host with MAC %s answers at %s but no device uses it", mac, addr),
+				}, addr)
+			}
+			continue
+		}
+		for _, device := range list {
+			if device.MAC != "" && normalizeMAC(device.MAC) != mac {
+				add(models.IPConflict{
+					Type: models.ConflictMACMismatch, DeviceIDs: []uint{device.ID}, ObservedMAC: mac,
+					Message: fmt.Sprintf("%s is expected at %s with MAC %s but %s answers", device.Name, addr, device.MAC, mac),
+				}, addr)
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, _ := netip.ParseAddr(conflicts[i].IP)
+		b, _ := netip.ParseAddr(conflicts[j].IP)
+		if a == b {
+			return conflicts[i].Type < conflicts[j].Type
+		}
+		return a.Less(b)
+	})
+	return conflicts, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// KioskService manages read-only display tokens
+type KioskService struct {
+	db *gorm.DB
+}
+
+// KioskTokenPrefix marks kiosk tokens so they can be told apart from JWTs
+const KioskTokenPrefix = "hlk_"
+
+// NewKioskService creates a new KioskService
+func NewKioskService() *KioskService {
+	return &KioskService{db: database.GetDB()}
+}
+
+// IsKioskToken returns true if the token looks like a kiosk token
+func IsKioskToken(token string) bool {
+	return strings.HasPrefix(token, KioskTokenPrefix)
+}
+
+// newToken returns a random prefixed token and its hash
+func newToken(prefix string) (string, string) {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	token := prefix + hex.EncodeToString(buf)
+	return token, hashToken(token)
+}
+
+// hashToken hashes a token for storage and lookup
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// List returns all kiosk tokens
+func (s *KioskService) List() ([]models.KioskToken, error) {
+	var tokens []models.KioskToken
+	if err := s.db.Order("name ASC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Create creates a new kiosk token and returns its plaintext value once
+func (s *KioskService) Create(name string, userID uint) (*models.KioskTokenResponse, error) {
+	token, hash := newToken(KioskTokenPrefix)
+	kiosk := models.KioskToken{
+		Name:      name,
+		TokenHash: hash,
+		Prefix:    token[:len(KioskTokenPrefix)+6],
+		Enabled:   true,
+		CreatedBy: userID,
+	}
+	if err := s.db.Create(&kiosk).Error; err != nil {
+		return nil, err
+	}
+	return &models.KioskTokenResponse{KioskToken: kiosk, Token: token}, nil
+}
+
+// Update renames or enables/disables a kiosk token
+func (s *KioskService) Update(id uint, name *string, enabled *bool) (*models.KioskToken, error) {
+	var kiosk models.KioskToken
+	if err := s.db.First(&kiosk, id).Error; err != nil {
+		return nil, fmt.Errorf("kiosk token not found")
+	}
+
+	updates := make(map[string]interface{})
+	if name != nil && *name != "" {
+		updates["name"] = *name
+	}
+	if enabled != nil {
+		updates["enabled"] = *enabled
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(&kiosk).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &kiosk, nil
+}
+
+// Rotate replaces the token value, invalidating the old one immediately
+func (s *KioskService) Rotate(id uint) (*models.KioskTokenResponse, error) {
+	var kiosk models.KioskToken
+	if err := s.db.First(&kiosk, id).Error; err != nil {
+		return nil, fmt.Errorf("kiosk token not found")
+	}
+
+	token, hash := newToken(KioskTokenPrefix)
+	if err := s.db.Model(&kiosk).Updates(map[string]interface{}{
+		"token_hash": hash,
+		"prefix":     token[:len(KioskTokenPrefix)+6],
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &models.KioskTokenResponse{KioskToken: kiosk, Token: token}, nil
+}
+
+// Delete removes a kiosk token
+func (s *KioskService) Delete(id uint) error {
+	result := s.db.Delete(&models.KioskToken{}, id)
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("kiosk token not found")
+	}
+	return result.Error
+}
+
+// Validate returns the enabled kiosk token matching the plaintext token
+func (s *KioskService) Validate(token string) (*models.KioskToken, error) {
+	if !IsKioskToken(token) {
+		return nil, errors.New("not a kiosk token")
+	}
+
+	var kiosk models.KioskToken
+	if err := s.db.Where("token_hash = ? AND enabled = ?", hashToken(token), true).First(&kiosk).Error; err != nil {
+		return nil, errors.New("invalid or disabled kiosk token")
+	}
+
+	// Avoid a write on every request from a polling display
+	now := time.Now()
+	if kiosk.LastUsedAt == nil || now.Sub(*kiosk.LastUsedAt) > time.Minute {
+		s.db.Model(&kiosk).Update("last_used_at", now)
+	}
+	return &kiosk, nil
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// LogService tails journald or syslog, counting errors and warnings per
+// unit and recording an event when a unit's error rate spikes
+type LogService struct {
+	source   string
+	minSpike int
+	events   *EventService
+
+	mu    sync.RWMutex
+	units map[string]*unitLog
+}
+
+// unitLog holds per-minute counters for the last hour and recent entries
+type unitLog struct {
+	minutes   [60]int64 // unix minute each bucket belongs to
+	errors    [60]int
+	warnings  [60]int
+	recent    []models.LogEntry
+	lastSpike time.Time
+}
+
+const (
+	logRecentPerUnit = 100
+	logSpikeCooldown = 15 * time.Minute
+	logSpikeBaseline = 15 // minutes averaged to compare against
+)
+
+// Syslog files tried when journald is not available
+var syslogFiles = []string{"/var/log/syslog", "/var/log/messages"}
+
+var (
+	syslogLineRe  = regexp.MustCompile(`^(?:\w{3}\s+\d+\s+[\d:]+|\S+T\S+)\s+\S+\s+([^\s:\[]+)(?:\[\d+\])?:\s*(.*)$`)
+	syslogErrorRe = regexp.MustCompile(`(?i)\b(error|err|failed|failure|fatal|critical|panic)\b`)
+	syslogWarnRe  = regexp.MustCompile(`(?i)\bwarn(ing)?\b`)
+)
+
+// NewLogService creates a new LogService and starts tailing the configured source
+func NewLogService(events *EventService) *LogService {
+	cfg := config.AppConfig
+	s := &LogService{
+		source:   strings.ToLower(cfg.LogSource),
+		minSpike: cfg.LogSpikeMinimum,
+		events:   events,
+		units:    make(map[string]*unitLog),
+	}
+
+	if s.source == "auto" {
+		s.source = detectLogSource()
+	}
+
+	switch s.source {
+	case "journald":
+		go s.tailJournald()
+	case "syslog":
+		go s.tailSyslog()
+	default:
+		s.source = "none"
+		return s
+	}
+
+	log.Printf("Log error monitoring enabled (source: %s)", s.source)
+	go s.spikeBackground()
+	return s
+}
+
+// detectLogSource prefers journald, then a readable syslog file
+func detectLogSource() string {
+	if runtime.GOOS != "linux" {
+		return "none"
+	}
+	if _, err := exec.LookPath("journalctl"); err == nil {
+		return "journald"
+	}
+	for _, path := range syslogFiles {
+		if _, err := os.Stat(path); err == nil {
+			return "syslog"
+		}
+	}
+	return "none"
+}
+
+// IsConfigured returns true if a log source is being tailed
+func (s *LogService) IsConfigured() bool {
+	return s.source != "none"
+}
+
+// Source returns the active log source
+func (s *LogService) Source() string {
+	return s.source
+}
+
+// add counts an entry and keeps it in the unit's recent list
+func (s *LogService) add(entry models.LogEntry) {
+	minute := entry.Time.Unix() / 60
+	idx := minute % 60
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.units[entry.Unit]
+	if !ok {
+		u = &unitLog{}
+		s.units[entry.Unit] = u
+	}
+	if u.minutes[idx] != minute {
+		u.minutes[idx] = minute
+		u.errors[idx] = 0
+		u.warnings[idx] = 0
+	}
+	if entry.Priority == models.LogPriorityError {
+		u.errors[idx]++
+	} else {
+		u.warnings[idx]++
+	}
+
+	u.recent = append(u.recent, entry)
+	if len(u.recent) > logRecentPerUnit {
+		u.recent = u.recent[len(u.recent)-logRecentPerUnit:]
+	}
+}
+
+// count sums a unit's buckets for minutes in [from, to]
+func (u *unitLog) count(from, to int64) (errors, warnings int) {
+	for i := range u.minutes {
+		if u.minutes[i] >= from && u.minutes[i] <= to {
+			errors += u.errors[i]
+			warnings += u.warnings[i]
+		}
+	}
+	return errors, warnings
+}
+
+// Rates returns error and warning counts per unit, busiest first
+func (s *LogService) Rates() []models.UnitLogRate {
+	now := time.Now().Unix() / 60
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := make([]models.UnitLogRate, 0, len(s.units))
+	for name, u := range s.units {
+		rate := models.UnitLogRate{Unit: name}
+		rate.Errors5m, rate.Warnings5m = u.count(now-4, now)
+		rate.Errors1h, rate.Warnings1h = u.count(now-59, now)
+		if rate.Errors1h == 0 && rate.Warnings1h == 0 {
+			continue
+		}
+		if n := len(u.recent); n > 0 {
+			last := u.recent[n-1]
+			rate.LastMessage = last.Message
+			rate.LastAt = &last.Time
+		}
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Errors1h != rates[j].Errors1h {
+			return rates[i].Errors1h > rates[j].Errors1h
+		}
+		if rates[i].Warnings1h != rates[j].Warnings1h {
+			return rates[i].Warnings1h > rates[j].Warnings1h
+		}
+		return rates[i].Unit < rates[j].Unit
+	})
+	return rates
+}
+
+// Recent returns a unit's latest entries, newest first, optionally only one priority
+func (s *LogService) Recent(unit, priority string, limit int) []models.LogEntry {
+	if limit <= 0 || limit > logRecentPerUnit {
+		limit = 50
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]models.LogEntry, 0, limit)
+	u, ok := s.units[unit]
+	if !ok {
+		return entries
+	}
+	for i := len(u.recent) - 1; i >= 0 && len(entries) < limit; i-- {
+		if priority != "" && u.recent[i].Priority != priority {
+			continue
+		}
+		entries = append(entries, u.recent[i])
+	}
+	return entries
+}
+
+// spikeBackground compares each unit's last minute with its recent average
+func (s *LogService) spikeBackground() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.detectSpikes()
+	}
+}
+
+// detectSpikes records an event for units whose errors in the last complete
+// minute reach the minimum and are three times their baseline
+func (s *LogService) detectSpikes() {
+	last := time.Now().Unix()/60 - 1
+
+	type spike struct {
+		unit     string
+		errors   int
+		baseline float64
+		message  string
+	}
+	var spikes []spike
+
+	s.mu.Lock()
+	for name, u := range s.units {
+		errors, _ := u.count(last, last)
+		if errors < s.minSpike || time.Since(u.lastSpike) < logSpikeCooldown {
+			continue
+		}
+		previous, _ := u.count(last-logSpikeBaseline, last-1)
+		baseline := float64(previous) / logSpikeBaseline
+		if float64(errors) < 3*baseline {
+			continue
+		}
+		u.lastSpike = time.Now()
+
+		message := ""
+		for i := len(u.recent) - 1; i >= 0; i-- {
+			if u.recent[i].Priority == models.LogPriorityError {
+				message = u.recent[i].Message
+				break
+			}
+		}
+		spikes = append(spikes, spike{unit: name, errors: errors, baseline: baseline, message: message})
+	}
+	s.mu.Unlock()
+
+	for _, sp := range spikes {
+		s.events.Record("log_error_spike", models.SeverityWarning, "logs",
+			fmt.Sprintf("Error spike in %s", sp.unit),
+			fmt.Sprintf("%s logged %d errors in the last minute (usual %.1f/min). Latest: %s", sp.unit, sp.errors, sp.baseline, sp.message),
+			map[string]interface{}{
+				"unit":     sp.unit,
+				"errors":   sp.errors,
+				"baseline": sp.baseline,
+			})
+	}
+}
+
+// journalEntry is the subset of journalctl -o json fields used
+type journalEntry struct {
+	Priority   string          `json:"PRIORITY"`
+	Unit       string          `json:"_SYSTEMD_UNIT"`
+	Identifier string          `json:"SYSLOG_IDENTIFIER"`
+	Transport  string          `json:"_TRANSPORT"`
+	Message    json.RawMessage `json:"MESSAGE"`
+	Timestamp  string          `json:"__REALTIME_TIMESTAMP"`
+}
+
+// tailJournald follows new warning-and-above journal entries, restarting journalctl if it exits
+func (s *LogService) tailJournald() {
+	for {
+		cmd := exec.Command("journalctl", "-f", "-o", "json", "-p", "warning", "-n", "0", "--no-pager")
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			log.Printf("Failed to start journalctl: %v", err)
+		} else {
+			s.readJournal(stdout)
+			if err := cmd.Wait(); err != nil {
+				log.Printf("journalctl exited: %v", err)
+			}
+		}
+		time.Sleep(30 * time.Second)
+	}
+}
+
+// readJournal parses journalctl JSON lines until the stream ends
+func (s *LogService) readJournal(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var je journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &je); err != nil {
+			continue
+		}
+
+		priority, err := strconv.Atoi(je.Priority)
+		if err != nil || priority > 4 {
+			continue
+		}
+		entry := models.LogEntry{
+			Time:     time.Now(),
+			Unit:     je.Unit,
+			Priority: models.LogPriorityWarning,
+			Message:  journalMessage(je.Message),
+		}
+		if priority <= 3 {
+			entry.Priority = models.LogPriorityError
+		}
+		if usec, err := strconv.ParseInt(je.Timestamp, 10, 64); err == nil {
+			entry.Time = time.UnixMicro(usec)
+		}
+		if entry.Unit == "" {
+			entry.Unit = je.Identifier
+		}
+		if entry.Unit == "" && je.Transport == "kernel" {
+			entry.Unit = "kernel"
+		}
+		if entry.Unit == "" {
+			entry.Unit = "unknown"
+		}
+		s.add(entry)
+	}
+}
+
+// journalMessage decodes MESSAGE, which journald emits as a byte array when it is not valid UTF-8
+func journalMessage(raw json.RawMessage) string {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+	var bytes []byte
+	if err := json.Unmarshal(raw, &bytes); err == nil {
+		return strings.ToValidUTF8(string(bytes), "?")
+	}
+	return ""
+}
+
+// tailSyslog polls the syslog file for appended lines, reopening it after rotation
+func (s *LogService) tailSyslog() {
+	var file *os.File
+	var path string
+	var offset int64
+
+	for {
+		if file == nil {
+			for _, p := range syslogFiles {
+				if f, err := os.Open(p); err == nil {
+					file, path = f, p
+					// Start at the end so old lines are not counted as new errors
+					offset, _ = file.Seek(0, io.SeekEnd)
+					break
+				}
+			}
+		}
+
+		if file != nil {
+			info, err := os.Stat(path)
+			if err != nil || info.Size() < offset {
+				// Rotated or truncated
+				file.Close()
+				file = nil
+				if err == nil {
+					file, _ = os.Open(path)
+					offset = 0
+				}
+			}
+		}
+
+		if file != nil {
+			reader := bufio.NewReader(file)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					// Leave a partial line for the next poll
+					break
+				}
+				offset += int64(len(line))
+				if entry, ok := parseSyslogLine(strings.TrimRight(line, "\n")); ok {
+					s.add(entry)
+				}
+			}
+			file.Seek(offset, io.SeekStart)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// parseSyslogLine classifies a syslog line by keyword, since the file does not keep priorities
+func parseSyslogLine(line string) (models.LogEntry, bool) {
+	m := syslogLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return models.LogEntry{}, false
+	}
+
+	entry := models.LogEntry{Time: time.Now(), Unit: m[1], Message: m[2]}
+	switch {
+	case syslogErrorRe.MatchString(m[2]):
+		entry.Priority = models.LogPriorityError
+	case syslogWarnRe.MatchString(m[2]):
+		entry.Priority = models.LogPriorityWarning
+	default:
+		return entry, false
+	}
+	return entry, true
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/homelab/backend/models"
+)
+
+// ErrNotMacOS is returned by the macOS metrics when the backend runs elsewhere
+var ErrNotMacOS = errors.New("macOS metrics are only available when the backend runs on macOS")
+
+var (
+	pmsetSourceRe  = regexp.MustCompile(`Now drawing from '([^']+)'`)
+	pmsetBatteryRe = regexp.MustCompile(`InternalBattery[^\t]*\t\s*(\d+)%;\s*([^;]+);\s*(\S+)`)
+	pmsetWarningRe = regexp.MustCompile(`(?i)thermal warning level[^\n\d]*(\d+)`)
+	pmsetSpeedRe   = regexp.MustCompile(`CPU_Speed_Limit\s*=\s*(\d+)`)
+)
+
+// GetMacOSMetrics returns thermal pressure and battery state on macOS hosts
+func (s *MetricsService) GetMacOSMetrics() (*models.MacOSMetrics, error) {
+	return collectMacOSMetrics()
+}
+
+// parsePmsetBattery parses `pmset -g batt`, returning nil when there is no battery
+func parsePmsetBattery(output string) *models.BatteryStatus {
+	m := pmsetBatteryRe.FindStringSubmatch(output)
+	if m == nil {
+		return nil
+	}
+
+	percent, _ := strconv.Atoi(m[1])
+	battery := &models.BatteryStatus{
+		Percent: percent,
+		State:   strings.TrimSpace(m[2]),
+	}
+	if src := pmsetSourceRe.FindStringSubmatch(output); src != nil {
+		battery.Source = src[1]
+	}
+	// pmset prints "(no estimate)" while it is still calculating
+	if strings.Contains(m[3], ":") {
+		battery.Remaining = m[3]
+	}
+	return battery
+}
+
+// parsePmsetThermal parses `pmset -g therm`
+func parsePmsetThermal(output string) models.ThermalStatus {
+	status := models.ThermalStatus{Pressure: "nominal", CPUSpeed: 100}
+	if m := pmsetWarningRe.FindStringSubmatch(output); m != nil {
+		status.WarningLevel, _ = strconv.Atoi(m[1])
+	}
+	if m := pmsetSpeedRe.FindStringSubmatch(output); m != nil {
+		status.CPUSpeed, _ = strconv.Atoi(m[1])
+	}
+	if status.WarningLevel > 0 || status.CPUSpeed < 100 {
+		status.Pressure = "throttled"
+	}
+	return status
+}
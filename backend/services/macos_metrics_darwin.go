@@ -0,0 +1,41 @@
+//go:build darwin
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// pmset runs `pmset -g <arg>` and returns its output
+func pmset(arg string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "pmset", "-g", arg).Output()
+	if err != nil {
+		return "", fmt.Errorf("pmset -g %s: %v", arg, err)
+	}
+	return string(output), nil
+}
+
+// collectMacOSMetrics reads thermal and battery state from pmset
+func collectMacOSMetrics() (*models.MacOSMetrics, error) {
+	therm, err := pmset("therm")
+	if err != nil {
+		return nil, err
+	}
+	batt, err := pmset("batt")
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MacOSMetrics{
+		Thermal: parsePmsetThermal(therm),
+		Battery: parsePmsetBattery(batt),
+	}, nil
+}
@@ -0,0 +1,25 @@
+//go:build darwin
+
+package services
+
+import "testing"
+
+func TestCollectMacOSMetrics(t *testing.T) {
+	metrics, err := collectMacOSMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thermal := metrics.Thermal
+	if thermal.Pressure != "nominal" && thermal.Pressure != "throttled" {
+		t.Errorf("thermal pressure = %q", thermal.Pressure)
+	}
+	if thermal.CPUSpeed <= 0 || thermal.CPUSpeed > 100 {
+		t.Errorf("CPU speed limit = %d, want 1-100", thermal.CPUSpeed)
+	}
+	if battery := metrics.Battery; battery != nil {
+		if battery.Percent < 0 || battery.Percent > 100 || battery.State == "" {
+			t.Errorf("battery = %+v", *battery)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package services
+
+import "github.com/homelab/backend/models"
+
+// collectMacOSMetrics is only supported on macOS
+func collectMacOSMetrics() (*models.MacOSMetrics, error) {
+	return nil, ErrNotMacOS
+}
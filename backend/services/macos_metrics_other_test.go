@@ -0,0 +1,14 @@
+//go:build !darwin
+
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCollectMacOSMetricsElsewhere(t *testing.T) {
+	if _, err := collectMacOSMetrics(); !errors.Is(err, ErrNotMacOS) {
+		t.Errorf("collectMacOSMetrics() error = %v, want ErrNotMacOS", err)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/homelab/backend/models"
+)
+
+func TestParsePmsetBattery(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *models.BatteryStatus
+	}{
+		{
+			name: "charged on AC",
+			output: "Now drawing from 'AC Power'\n" +
+				" -InternalBattery-0 (id=4653155)\t100%; charged; 0:00 remaining present: true\n",
+			want: &models.BatteryStatus{Percent: 100, State: "charged", Source: "AC Power", Remaining: "0:00"},
+		},
+		{
+			name: "discharging",
+			output: "Now drawing from 'Battery Power'\n" +
+				" -InternalBattery-0 (id=7143523)\t87%; discharging; 5:12 remaining present: true\n",
+			want: &models.BatteryStatus{Percent: 87, State: "discharging", Source: "Battery Power", Remaining: "5:12"},
+		},
+		{
+			name: "charging",
+			output: "Now drawing from 'AC Power'\n" +
+				" -InternalBattery-0 (id=7143523)\t54%; charging; 1:05 remaining present: true\n",
+			want: &models.BatteryStatus{Percent: 54, State: "charging", Source: "AC Power", Remaining: "1:05"},
+		},
+		{
+			name: "no estimate yet",
+			output: "Now drawing from 'Battery Power'\n" +
+				" -InternalBattery-0 (id=7143523)\t86%; discharging; (no estimate) present: true\n",
+			want: &models.BatteryStatus{Percent: 86, State: "discharging", Source: "Battery Power"},
+		},
+		{
+			name: "AC attached but not charging",
+			output: "Now drawing from 'AC Power'\n" +
+				" -InternalBattery-0 (id=7143523)\t80%; AC attached; not charging present: true\n",
+			want: &models.BatteryStatus{Percent: 80, State: "AC attached", Source: "AC Power"},
+		},
+		{
+			name:   "desktop without a battery",
+			output: "Now drawing from 'AC Power'\n",
+			want:   nil,
+		},
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePmsetBattery(tt.output)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("parsePmsetBattery() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && *got != *tt.want {
+				t.Errorf("parsePmsetBattery() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePmsetThermal(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   models.ThermalStatus
+	}{
+		{
+			name: "nothing recorded",
+			output: "Note: No thermal warning level has been recorded\n" +
+				"Note: No performance warning level has been recorded\n" +
+				"Note: No CPU power status has been recorded\n",
+			want: models.ThermalStatus{Pressure: "nominal", CPUSpeed: 100},
+		},
+		{
+			name: "full speed",
+			output: "Note: No thermal warning level has been recorded\n" +
+				"Note: No performance warning level has been recorded\n" +
+				"2024-05-03 10:12:01 +0200 CPU Power notify\n" +
+				"\tCPU_Scheduler_Limit \t= 100\n" +
+				"\tCPU_Available_CPUs \t= 8\n" +
+				"\tCPU_Speed_Limit \t= 100\n",
+			want: models.ThermalStatus{Pressure: "nominal", CPUSpeed: 100},
+		},
+		{
+			name: "speed limited",
+			output: "Note: No thermal warning level has been recorded\n" +
+				"Note: No performance warning level has been recorded\n" +
+				"2024-05-03 10:14:22 +0200 CPU Power notify\n" +
+				"\tCPU_Scheduler_Limit \t= 100\n" +
+				"\tCPU_Available_CPUs \t= 8\n" +
+				"\tCPU_Speed_Limit \t= 72\n",
+			want: models.ThermalStatus{Pressure: "throttled", CPUSpeed: 72},
+		},
+		{
+			name: "thermal warning",
+			output: "2024-05-03 10:20:45 +0200 Thermal Warning Level notify\n" +
+				"Thermal warning level set to 2.\n" +
+				"Note: No performance warning level has been recorded\n",
+			want: models.ThermalStatus{Pressure: "throttled", WarningLevel: 2, CPUSpeed: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePmsetThermal(tt.output); got != tt.want {
+				t.Errorf("parsePmsetThermal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
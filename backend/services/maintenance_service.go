@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrMaintenanceRunning is returned when a maintenance run is already in progress
+var ErrMaintenanceRunning = errors.New("maintenance is already running")
+
+// maintenanceRunsKept is how many maintenance runs are kept for status
+const maintenanceRunsKept = 30
+
+// MaintenanceService keeps the database tidy on a schedule: it purges
+// soft-deleted rows, expired sessions and check history past retention, then
+// has the database reclaim the space
+type MaintenanceService struct {
+	db        *gorm.DB
+	events    *EventService
+	interval  time.Duration
+	retention time.Duration
+
+	mu      sync.Mutex
+	running bool
+}
+
+// NewMaintenanceService creates a new MaintenanceService and starts the
+// scheduler when enabled
+func NewMaintenanceService(events *EventService) *MaintenanceService {
+	cfg := config.AppConfig
+	s := &MaintenanceService{
+		db:        database.GetDB(),
+		events:    events,
+		interval:  time.Duration(cfg.MaintenanceIntervalHours) * time.Hour,
+		retention: time.Duration(cfg.SoftDeleteRetentionDays) * 24 * time.Hour,
+	}
+	if s.interval > 0 {
+		go s.maintenanceBackground()
+	}
+	return s
+}
+
+// maintenanceBackground runs maintenance whenever the latest run is older
+// than the interval, so restarts don't reset the schedule
+func (s *MaintenanceService) maintenanceBackground() {
+	time.Sleep(10 * time.Minute)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		var latest models.MaintenanceRun
+		err := s.db.Order("started_at DESC").First(&latest).Error
+		if err != nil || time.Since(latest.StartedAt) >= s.interval {
+			if _, err := s.Run(models.MaintenanceScheduled); err != nil && !errors.Is(err, ErrMaintenanceRunning) {
+				log.Printf("Database maintenance failed: %v", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// Run performs maintenance now and records the run. Steps that fail are
+// reported in the run's error without stopping the others.
+func (s *MaintenanceService) Run(trigger string) (*models.MaintenanceRun, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrMaintenanceRunning
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	run := models.MaintenanceRun{Trigger: trigger, StartedAt: time.Now()}
+	var errs []string
+
+	softDeleted, err := s.purgeSoftDeleted(run.StartedAt.Add(-s.retention))
+	run.SoftDeleted = softDeleted
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	checks, err := s.purgeCheckHistory(run.StartedAt)
+	run.CheckResults = checks
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if result, err := database.Vacuum(); err != nil {
+		errs = append(errs, "vacuum: "+err.Error())
+	} else {
+		run.ExpiredSessions = result.ExpiredSessions
+		run.SizeBefore = result.SizeBefore
+		run.SizeAfter = result.SizeAfter
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	if len(errs) > 0 {
+		run.Error = strings.Join(errs, "; ")
+		if len(run.Error) > 1000 {
+			run.Error = run.Error[:1000]
+		}
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		return nil, err
+	}
+	s.pruneRuns()
+
+	log.Printf("Database maintenance: purged %d soft-deleted rows, %d expired sessions and %d old check results",
+		run.SoftDeleted, run.ExpiredSessions, run.CheckResults)
+	if run.Error != "" {
+		s.events.Record("maintenance_failed", models.SeverityWarning, "system",
+			"Database maintenance failed",
+			run.Error,
+			map[string]interface{}{"runId": run.ID})
+		return &run, fmt.Errorf("%s", run.Error)
+	}
+	return &run, nil
+}
+
+// purgeSoftDeleted permanently deletes rows soft-deleted before cutoff,
+// with their tag links. Sessions go before users, which they reference.
+func (s *MaintenanceService) purgeSoftDeleted(cutoff time.Time) (int64, error) {
+	db := s.db.Unscoped()
+	deleted := func(model interface{}) *gorm.DB {
+		return db.Model(model).Select("id").Where("deleted_at < ?", cutoff)
+	}
+
+	var total int64
+	var errs []error
+	purge := func(name string, model interface{}) {
+		result := db.Where("deleted_at < ?", cutoff).Delete(model)
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("purging deleted %s: %w", name, result.Error))
+			return
+		}
+		total += result.RowsAffected
+	}
+
+	purge("sessions", &models.Session{})
+	if err := db.Exec("DELETE FROM service_tags WHERE service_config_id IN (?)", deleted(&models.ServiceConfig{})).Error; err != nil {
+		errs = append(errs, fmt.Errorf("purging tags of deleted services: %w", err))
+	} else {
+		purge("services", &models.ServiceConfig{})
+	}
+	if err := db.Exec("DELETE FROM device_tags WHERE device_id IN (?)", deleted(&models.Device{})).Error; err != nil {
+		errs = append(errs, fmt.Errorf("purging tags of deleted devices: %w", err))
+	} else if err := db.Where("device_id IN (?)", deleted(&models.Device{})).Delete(&models.SNMPConfig{}).Error; err != nil {
+		errs = append(errs, fmt.Errorf("purging SNMP settings of deleted devices: %w", err))
+	} else if err := db.Where("device_id IN (?)", deleted(&models.Device{})).Delete(&models.MetricsAgent{}).Error; err != nil {
+		errs = append(errs, fmt.Errorf("purging agents of deleted devices: %w", err))
+	} else {
+		purge("devices", &models.Device{})
+	}
+	purge("users", &models.User{})
+	return total, errors.Join(errs...)
+}
+
+// purgeCheckHistory deletes service checks, device pings and device status
+// history past their retention. The schedulers purge these daily too; this
+// covers installs where they have not been running.
+func (s *MaintenanceService) purgeCheckHistory(now time.Time) (int64, error) {
+	var total int64
+	purges := []struct {
+		model  interface{}
+		column string
+		cutoff time.Time
+	}{
+		{&models.ServiceCheck{}, "checked_at", now.Add(-serviceCheckRetention)},
+		{&models.DevicePing{}, "checked_at", now.Add(-devicePingRetention)},
+		{&models.DeviceStatusChange{}, "changed_at", now.Add(-devicePingRetention)},
+	}
+	for _, p := range purges {
+		result := s.db.Where(p.column+" < ?", p.cutoff).Delete(p.model)
+		if result.Error != nil {
+			return total, fmt.Errorf("purging check history: %w", result.Error)
+		}
+		total += result.RowsAffected
+	}
+	return total, nil
+}
+
+// pruneRuns keeps only the latest maintenanceRunsKept runs
+func (s *MaintenanceService) pruneRuns() {
+	var keep []uint
+	s.db.Model(&models.MaintenanceRun{}).Order("started_at DESC").Limit(maintenanceRunsKept).Pluck("id", &keep)
+	if len(keep) == maintenanceRunsKept {
+		s.db.Where("id NOT IN ?", keep).Delete(&models.MaintenanceRun{})
+	}
+}
+
+// GetStatus returns the maintenance schedule, whether a run is in progress
+// and the recent runs
+func (s *MaintenanceService) GetStatus() (*models.MaintenanceStatus, error) {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+
+	status := &models.MaintenanceStatus{
+		Enabled:                 s.interval > 0,
+		IntervalHours:           int(s.interval.Hours()),
+		SoftDeleteRetentionDays: int(s.retention.Hours() / 24),
+		Running:                 running,
+		Runs:                    []models.MaintenanceRun{},
+	}
+	if err := s.db.Order("started_at DESC").Limit(maintenanceRunsKept).Find(&status.Runs).Error; err != nil {
+		return nil, err
+	}
+	if status.Enabled {
+		next := time.Now()
+		if len(status.Runs) > 0 {
+			next = status.Runs[0].StartedAt.Add(s.interval)
+		}
+		status.NextRun = &next
+	}
+	return status, nil
+}
@@ -0,0 +1,508 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// Metrics query expressions reference a stored series and reduce its samples
+// over a trailing window with a function:
+//
+//	avg(cpu.usage, 1h)
+//	max(disk["/srv"].used_percent, 24h)
+//	rate(network.in, 5m)
+//	sensor["Living room"].value
+//
+// A bare series is last(series, 1h). Windows are a number followed by s, m,
+// h, d or w.
+
+// defaultQueryWindow is the window of a query that does not give one
+const defaultQueryWindow = time.Hour
+
+// queryFunctions reduce a window's samples, oldest first, to one value
+var queryFunctions = map[string]func([]models.MetricsQueryPoint) (float64, bool){
+	"avg": func(points []models.MetricsQueryPoint) (float64, bool) {
+		if len(points) == 0 {
+			return 0, false
+		}
+		var sum float64
+		for _, p := range points {
+			sum += p.Value
+		}
+		return sum / float64(len(points)), true
+	},
+	"min": func(points []models.MetricsQueryPoint) (float64, bool) {
+		if len(points) == 0 {
+			return 0, false
+		}
+		value := points[0].Value
+		for _, p := range points[1:] {
+			value = min(value, p.Value)
+		}
+		return value, true
+	},
+	"max": func(points []models.MetricsQueryPoint) (float64, bool) {
+		if len(points) == 0 {
+			return 0, false
+		}
+		value := points[0].Value
+		for _, p := range points[1:] {
+			value = max(value, p.Value)
+		}
+		return value, true
+	},
+	"sum": func(points []models.MetricsQueryPoint) (float64, bool) {
+		var sum float64
+		for _, p := range points {
+			sum += p.Value
+		}
+		return sum, len(points) > 0
+	},
+	"count": func(points []models.MetricsQueryPoint) (float64, bool) {
+		return float64(len(points)), true
+	},
+	"last": func(points []models.MetricsQueryPoint) (float64, bool) {
+		if len(points) == 0 {
+			return 0, false
+		}
+		return points[len(points)-1].Value, true
+	},
+	// rate is the per-second increase of a counter; a drop is taken as a
+	// reset, counting from zero
+	"rate": func(points []models.MetricsQueryPoint) (float64, bool) {
+		if len(points) < 2 {
+			return 0, false
+		}
+		elapsed := points[len(points)-1].Timestamp.Sub(points[0].Timestamp).Seconds()
+		if elapsed <= 0 {
+			return 0, false
+		}
+		var increase float64
+		for i := 1; i < len(points); i++ {
+			if delta := points[i].Value - points[i-1].Value; delta >= 0 {
+				increase += delta
+			} else {
+				increase += points[i].Value
+			}
+		}
+		return increase / elapsed, true
+	},
+}
+
+// querySample is a stored sample as read for a query
+type querySample struct {
+	T time.Time
+	V float64
+}
+
+// querySeries is a stored series and how to read its samples for a user
+type querySeries struct {
+	models.MetricsSeries
+	labelRequired bool
+	samples       querySampler
+}
+
+// querySampler returns the query selecting a series' samples as t and v, and
+// the column they are timed by
+type querySampler func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error)
+
+// storedColumn reads a series from a column of a table sampled at timeColumn
+func storedColumn(model interface{}, timeColumn, value string) querySampler {
+	return func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+		return db.Model(model).Select(timeColumn + " AS t, " + value + " AS v"), timeColumn, nil
+	}
+}
+
+// hostColumn reads a series from the backend host's metrics history
+func hostColumn(value string) querySampler {
+	return func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+		return db.Model(&models.MetricsHistory{}).Select("sampled_at AS t, " + value + " AS v").
+			Where("device_id = 0"), "sampled_at", nil
+	}
+}
+
+// diskColumn reads a filesystem's series from the backend host's disk
+// history by mount point
+func diskColumn(column string) querySampler {
+	return func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+		return db.Model(&models.DiskHistory{}).Select("sampled_at AS t, "+column+" AS v").
+			Where("device_id = 0 AND mount_point = ?", label), "sampled_at", nil
+	}
+}
+
+// sensorSamples reads the readings of the user's sensor named label, or
+// labelled device/metric
+func sensorSamples(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+	var sensor models.Sensor
+	query := db.Where("user_id = ? AND name = ?", userID, label)
+	if device, metric, ok := strings.Cut(label, "/"); ok {
+		query = db.Where("user_id = ? AND (name = ? OR (device = ? AND metric = ?))", userID, label, device, metric)
+	}
+	if err := query.First(&sensor).Error; err != nil {
+		return nil, "", fmt.Errorf("sensor not found: %s", label)
+	}
+	return db.Model(&models.SensorReading{}).Select("sampled_at AS t, value AS v").
+		Where("sensor_id = ?", sensor.ID), "sampled_at", nil
+}
+
+// serviceColumn reads a series from the local checks of the user's service
+// named label
+func serviceColumn(value string) querySampler {
+	return func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+		var svc models.ServiceConfig
+		if err := db.Select("id").Where("user_id = ? AND name = ?", userID, label).First(&svc).Error; err != nil {
+			return nil, "", fmt.Errorf("service not found: %s", label)
+		}
+		return db.Model(&models.ServiceCheck{}).Select("checked_at AS t, "+value+" AS v").
+			Where("service_id = ? AND location = ?", svc.ID, ""), "checked_at", nil
+	}
+}
+
+// latencyColumn reads a series from the probes of the ping target named
+// label, among the user's targets and the configured ones
+func latencyColumn(value, filter string) querySampler {
+	return func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+		query := db.Model(&models.LatencySample{}).Select("created_at AS t, "+value+" AS v").
+			Where("(user_id = 0 OR user_id = ?) AND target = ?", userID, label)
+		if filter != "" {
+			query = query.Where(filter)
+		}
+		return query, "created_at", nil
+	}
+}
+
+// metricsQuerySeries are the stored series queries can reference
+var metricsQuerySeries = []querySeries{
+	{MetricsSeries: models.MetricsSeries{Name: "cpu.usage", Unit: "%", Description: "Host CPU usage"},
+		samples: hostColumn("cpu_usage")},
+	{MetricsSeries: models.MetricsSeries{Name: "memory.used_percent", Unit: "%", Description: "Host memory usage"},
+		samples: hostColumn("memory_usage")},
+	{MetricsSeries: models.MetricsSeries{Name: "disk.used_percent", Label: "mount point", Unit: "%", Description: "Filesystem usage, the first filesystem without a mount point"},
+		samples: func(db *gorm.DB, userID uint, label string) (*gorm.DB, string, error) {
+			if label == "" {
+				return hostColumn("disk_usage")(db, userID, label)
+			}
+			return diskColumn("used_percent")(db, userID, label)
+		}},
+	{MetricsSeries: models.MetricsSeries{Name: "disk.used", Label: "mount point", Unit: "bytes", Description: "Filesystem space used"},
+		labelRequired: true, samples: diskColumn("used")},
+	{MetricsSeries: models.MetricsSeries{Name: "disk.free", Label: "mount point", Unit: "bytes", Description: "Filesystem space free"},
+		labelRequired: true, samples: diskColumn("free")},
+	{MetricsSeries: models.MetricsSeries{Name: "network.in", Unit: "bytes", Counter: true, Description: "Bytes received on all interfaces"},
+		samples: hostColumn("network_in")},
+	{MetricsSeries: models.MetricsSeries{Name: "network.out", Unit: "bytes", Counter: true, Description: "Bytes sent on all interfaces"},
+		samples: hostColumn("network_out")},
+	{MetricsSeries: models.MetricsSeries{Name: "health.score", Description: "Lab health score, 0 to 100"},
+		samples: storedColumn(&models.HealthScoreSample{}, "sampled_at", "score")},
+	{MetricsSeries: models.MetricsSeries{Name: "health.services", Description: "Services factor of the health score"},
+		samples: storedColumn(&models.HealthScoreSample{}, "sampled_at", "services")},
+	{MetricsSeries: models.MetricsSeries{Name: "health.devices", Description: "Devices factor of the health score"},
+		samples: storedColumn(&models.HealthScoreSample{}, "sampled_at", "devices")},
+	{MetricsSeries: models.MetricsSeries{Name: "health.disk", Description: "Disk factor of the health score"},
+		samples: storedColumn(&models.HealthScoreSample{}, "sampled_at", "disk")},
+	{MetricsSeries: models.MetricsSeries{Name: "health.updates", Description: "Updates factor of the health score"},
+		samples: storedColumn(&models.HealthScoreSample{}, "sampled_at", "updates")},
+	{MetricsSeries: models.MetricsSeries{Name: "service.response_time", Label: "service name", Unit: "ms", Description: "Service check response time"},
+		labelRequired: true, samples: serviceColumn("response_time")},
+	{MetricsSeries: models.MetricsSeries{Name: "service.up", Label: "service name", Description: "1 when a service check passed, 0 when it failed"},
+		labelRequired: true, samples: serviceColumn("CASE WHEN status IN ('offline', 'error', 'host_down') THEN 0 ELSE 1 END")},
+	{MetricsSeries: models.MetricsSeries{Name: "sensor.value", Label: "sensor name or device/metric", Description: "Ingested sensor readings"},
+		labelRequired: true, samples: sensorSamples},
+	{MetricsSeries: models.MetricsSeries{Name: "latency.ms", Label: "ping target", Unit: "ms", Description: "Average ping latency, without unreachable probes"},
+		labelRequired: true, samples: latencyColumn("latency", "latency >= 0")},
+	{MetricsSeries: models.MetricsSeries{Name: "latency.packet_loss", Label: "ping target", Unit: "%", Description: "Ping packet loss"},
+		labelRequired: true, samples: latencyColumn("packet_loss", "")},
+}
+
+// MetricsQuerySeries lists the series queries can reference
+func MetricsQuerySeries() []models.MetricsSeries {
+	series := make([]models.MetricsSeries, len(metricsQuerySeries))
+	for i, s := range metricsQuerySeries {
+		series[i] = s.MetricsSeries
+	}
+	return series
+}
+
+// MetricsQueryFunctions lists the functions queries can apply
+func MetricsQueryFunctions() []string {
+	functions := make([]string, 0, len(queryFunctions))
+	for name := range queryFunctions {
+		functions = append(functions, name)
+	}
+	sort.Strings(functions)
+	return functions
+}
+
+func findQuerySeries(name string) (querySeries, bool) {
+	for _, s := range metricsQuerySeries {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return querySeries{}, false
+}
+
+// metricsQuery is a parsed query expression
+type metricsQuery struct {
+	function   string
+	series     querySeries
+	label      string
+	window     time.Duration
+	windowText string
+}
+
+// ValidateMetricsQuery checks that an expression parses and references a
+// known series with a supported function
+func ValidateMetricsQuery(expr string) error {
+	_, err := parseMetricsQuery(expr)
+	return err
+}
+
+// QueryMetrics evaluates a query expression for a user. A step above zero
+// also evaluates it over each step of the window, capped at
+// MaxMetricsHistoryPoints points.
+func (s *MetricsService) QueryMetrics(userID uint, expr string, step time.Duration) (*models.MetricsQueryResult, error) {
+	q, err := parseMetricsQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	from := to.Add(-q.window)
+	query, timeColumn, err := q.series.samples(s.db, userID, q.label)
+	if err != nil {
+		return nil, err
+	}
+	var samples []querySample
+	if err := query.Where(timeColumn+" >= ? AND "+timeColumn+" <= ?", from, to).Order(timeColumn + " ASC").Scan(&samples).Error; err != nil {
+		return nil, err
+	}
+	points := make([]models.MetricsQueryPoint, len(samples))
+	for i, sample := range samples {
+		points[i] = models.MetricsQueryPoint{Timestamp: sample.T, Value: sample.V}
+	}
+
+	result := &models.MetricsQueryResult{
+		Query:    strings.TrimSpace(expr),
+		Function: q.function,
+		Series:   q.series.Name,
+		Label:    q.label,
+		Window:   q.windowText,
+		From:     from,
+		To:       to,
+	}
+	reduce := queryFunctions[q.function]
+	if value, ok := reduce(points); ok {
+		result.Value = &value
+	}
+	if step <= 0 {
+		return result, nil
+	}
+
+	if minimum := q.window / MaxMetricsHistoryPoints; step < minimum {
+		step = minimum
+	}
+	result.Points = make([]models.MetricsQueryPoint, 0)
+	for start, i := from, 0; start.Before(to); start = start.Add(step) {
+		end := start.Add(step)
+		j := i
+		for j < len(points) && points[j].Timestamp.Before(end) {
+			j++
+		}
+		if value, ok := reduce(points[i:j]); ok && j > i {
+			result.Points = append(result.Points, models.MetricsQueryPoint{Timestamp: start, Value: value})
+		}
+		i = j
+	}
+	return result, nil
+}
+
+// parseMetricsQuery parses function(series[, window]) or a bare series
+func parseMetricsQuery(expr string) (*metricsQuery, error) {
+	p := &queryParser{input: expr}
+	q := &metricsQuery{function: "last", window: defaultQueryWindow, windowText: "1h"}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept('(') {
+		if _, ok := queryFunctions[name]; !ok {
+			return nil, fmt.Errorf("unknown function %q (use %s)", name, strings.Join(MetricsQueryFunctions(), ", "))
+		}
+		q.function = name
+		if name, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err := p.selector(q, name); err != nil {
+			return nil, err
+		}
+		if p.accept(',') {
+			text, window, err := p.duration()
+			if err != nil {
+				return nil, err
+			}
+			q.window, q.windowText = window, text
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("expected )")
+		}
+	} else if err := p.selector(q, name); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	if q.series.labelRequired && q.label == "" {
+		return nil, fmt.Errorf("%s needs a %s, e.g. %s", q.series.Name, q.series.Label, strings.Replace(q.series.Name, ".", `["name"].`, 1))
+	}
+	if q.label != "" && q.series.Label == "" {
+		return nil, fmt.Errorf("%s takes no [...] filter", q.series.Name)
+	}
+	if q.function == "rate" && !q.series.Counter {
+		return nil, fmt.Errorf("rate needs a counter series, %s is not one", q.series.Name)
+	}
+	if retention := time.Duration(config.AppConfig.MetricsRetentionDays) * 24 * time.Hour; retention > 0 && q.window > retention {
+		return nil, fmt.Errorf("window %s is longer than the %d days of stored metrics", q.windowText, config.AppConfig.MetricsRetentionDays)
+	}
+	return q, nil
+}
+
+// queryParser reads a query expression left to right
+type queryParser struct {
+	input string
+	pos   int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid query at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is the next character
+func (p *queryParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// ident reads a name of lowercase letters, digits and underscores
+func (p *queryParser) ident() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c >= 'a' && c <= 'z' || c == '_' || c >= '0' && c <= '9' && p.pos > start {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a name")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// selector reads the rest of a series reference after its first name:
+// an optional ["label"] and .field
+func (p *queryParser) selector(q *metricsQuery, name string) error {
+	if p.accept('[') {
+		label, err := p.quoted()
+		if err != nil {
+			return err
+		}
+		if !p.accept(']') {
+			return p.errorf("expected ]")
+		}
+		q.label = label
+	}
+	if !p.accept('.') {
+		return p.errorf("expected . and a field after %s", name)
+	}
+	field, err := p.ident()
+	if err != nil {
+		return err
+	}
+
+	series, ok := findQuerySeries(name + "." + field)
+	if !ok {
+		return fmt.Errorf("unknown series %s.%s", name, field)
+	}
+	q.series = series
+	return nil
+}
+
+// quoted reads a string in double or single quotes; backslash escapes the
+// next character
+func (p *queryParser) quoted() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) || (p.input[p.pos] != '"' && p.input[p.pos] != '\'') {
+		return "", p.errorf("expected a quoted string")
+	}
+	quote := p.input[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.input):
+			b.WriteByte(p.input[p.pos])
+			p.pos++
+		case c == quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// queryDurationUnits are the window units a query accepts
+var queryDurationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// duration reads a window such as 30m or 7d
+func (p *queryParser) duration() (string, time.Duration, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	digits := p.input[start:p.pos]
+	unitStart := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= 'a' && p.input[p.pos] <= 'z' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(digits)
+	unit, ok := queryDurationUnits[p.input[unitStart:p.pos]]
+	if err != nil || !ok || n <= 0 {
+		p.pos = start
+		return "", 0, p.errorf("expected a window such as 30m, 1h or 7d")
+	}
+	return p.input[start:p.pos], time.Duration(n) * unit, nil
+}
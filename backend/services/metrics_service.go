@@ -1,261 +1,354 @@
 package services
 
 import (
-	"runtime"
+	"log"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/homelab/backend/collector"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
 	"github.com/homelab/backend/models"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+	"gorm.io/gorm"
 )
 
-// MetricsService handles system metrics collection
+// MetricsService handles system metrics collection, for the backend's own
+// host and for devices read over SSH
 type MetricsService struct {
-	history    []models.MetricsHistory
-	mu         sync.RWMutex
-	maxHistory int
+	db       *gorm.DB
+	settings *SettingsService
+
+	remoteMu sync.Mutex
+	remote   map[uint]remoteReading // latest reading per device
+
+	listenersMu sync.RWMutex
+	listeners   []func(deviceID uint, metrics *models.SystemMetrics, at time.Time)
 }
 
+const (
+	// metricsHistoryInterval is how often a history sample is stored
+	metricsHistoryInterval = 30 * time.Second
+	// MaxMetricsHistoryPoints caps the points returned for a time range;
+	// longer ranges are averaged into buckets
+	MaxMetricsHistoryPoints = 300
+	// MaxMetricsExportRange caps the time range of a raw history export
+	MaxMetricsExportRange = 90 * 24 * time.Hour
+)
+
 // NewMetricsService creates a new MetricsService
-func NewMetricsService() *MetricsService {
+func NewMetricsService(settings *SettingsService) *MetricsService {
 	ms := &MetricsService{
-		history:    make([]models.MetricsHistory, 0),
-		maxHistory: 100,
+		db:       database.GetDB(),
+		settings: settings,
+		remote:   make(map[uint]remoteReading),
 	}
 
 	// Start background collection
 	go ms.collectHistoryBackground()
+	if interval := config.AppConfig.RemoteMetricsInterval; interval > 0 {
+		go ms.collectRemoteBackground(time.Duration(interval) * time.Second)
+	}
 
 	return ms
 }
 
+// collectHistoryBackground stores a history sample every metricsHistoryInterval
+// and purges samples beyond METRICS_RETENTION_DAYS once a day
 func (s *MetricsService) collectHistoryBackground() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(metricsHistoryInterval)
 	defer ticker.Stop()
 
+	lastCleanup := time.Time{}
 	for {
 		<-ticker.C
-		metrics, err := s.GetSystemMetrics()
-		if err != nil {
-			continue
+		if metrics, err := s.GetSystemMetrics(); err == nil {
+			s.storeHistory(0, metrics)
 		}
 
-		var diskUsage float64
-		if len(metrics.Disk) > 0 {
-			diskUsage = metrics.Disk[0].UsedPercent
-		}
-
-		var networkIn, networkOut uint64
-		for _, n := range metrics.Network {
-			networkIn += n.BytesRecv
-			networkOut += n.BytesSent
+		if time.Since(lastCleanup) > 24*time.Hour {
+			cutoff := time.Now().AddDate(0, 0, -config.AppConfig.MetricsRetentionDays)
+			s.db.Where("sampled_at < ?", cutoff).Delete(&models.MetricsHistory{})
+			s.db.Where("sampled_at < ?", cutoff).Delete(&models.DiskHistory{})
+			lastCleanup = time.Now()
 		}
+	}
+}
 
-		history := models.MetricsHistory{
-			Timestamp:   time.Now(),
-			CPUUsage:    metrics.CPU.UsagePercent,
-			MemoryUsage: metrics.Memory.UsedPercent,
-			DiskUsage:   diskUsage,
-			NetworkIn:   networkIn,
-			NetworkOut:  networkOut,
-		}
+// OnSample registers a function called with every history sample, for
+// exporting them elsewhere. It must not block.
+func (s *MetricsService) OnSample(fn func(deviceID uint, metrics *models.SystemMetrics, at time.Time)) {
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.listenersMu.Unlock()
+}
 
-		s.mu.Lock()
-		s.history = append(s.history, history)
-		if len(s.history) > s.maxHistory {
-			s.history = s.history[1:]
-		}
-		s.mu.Unlock()
+// storeHistory stores a history sample of a host's metrics; device 0 is the
+// backend's own host. With METRICS_SINK_ONLY the sample only goes to the
+// sample listeners.
+func (s *MetricsService) storeHistory(deviceID uint, metrics *models.SystemMetrics) {
+	now := time.Now()
+	s.listenersMu.RLock()
+	for _, fn := range s.listeners {
+		fn(deviceID, metrics, now)
+	}
+	s.listenersMu.RUnlock()
+	if config.AppConfig.MetricsSinkOnly {
+		return
 	}
-}
 
-// GetSystemMetrics returns comprehensive system metrics
-func (s *MetricsService) GetSystemMetrics() (*models.SystemMetrics, error) {
-	cpuMetrics, err := s.GetCPUMetrics()
-	if err != nil {
-		return nil, err
+	var diskUsage float64
+	if len(metrics.Disk) > 0 {
+		diskUsage = metrics.Disk[0].UsedPercent
 	}
 
-	memMetrics, err := s.GetMemoryMetrics()
-	if err != nil {
-		return nil, err
+	var networkIn, networkOut uint64
+	for _, n := range metrics.Network {
+		networkIn += n.BytesRecv
+		networkOut += n.BytesSent
 	}
 
-	diskMetrics, err := s.GetDiskMetrics()
-	if err != nil {
-		return nil, err
+	history := models.MetricsHistory{
+		DeviceID:    deviceID,
+		Timestamp:   now,
+		CPUUsage:    metrics.CPU.UsagePercent,
+		MemoryUsage: metrics.Memory.UsedPercent,
+		DiskUsage:   diskUsage,
+		NetworkIn:   networkIn,
+		NetworkOut:  networkOut,
 	}
 
-	netMetrics, err := s.GetNetworkMetrics()
-	if err != nil {
-		return nil, err
+	if err := s.db.Create(&history).Error; err != nil {
+		log.Printf("Failed to store metrics history: %v", err)
 	}
 
-	uptime, _ := host.Uptime()
+	disks := make([]models.DiskHistory, 0, len(metrics.Disk))
+	for _, d := range metrics.Disk {
+		disks = append(disks, models.DiskHistory{
+			DeviceID:    deviceID,
+			Timestamp:   history.Timestamp,
+			MountPoint:  d.MountPoint,
+			Used:        d.Used,
+			Free:        d.Free,
+			UsedPercent: d.UsedPercent,
+		})
+	}
+	if len(disks) > 0 {
+		if err := s.db.Create(&disks).Error; err != nil {
+			log.Printf("Failed to store disk history: %v", err)
+		}
+	}
+}
 
-	return &models.SystemMetrics{
-		CPU:       *cpuMetrics,
-		Memory:    *memMetrics,
-		Disk:      diskMetrics,
-		Network:   netMetrics,
-		Uptime:    uptime,
-		Timestamp: time.Now(),
-	}, nil
+// GetSystemMetrics returns comprehensive system metrics
+func (s *MetricsService) GetSystemMetrics() (*models.SystemMetrics, error) {
+	return collector.System(s.settings.NetworkInterfaces())
 }
 
 // GetCPUMetrics returns CPU-specific metrics
 func (s *MetricsService) GetCPUMetrics() (*models.CPUMetrics, error) {
-	percentages, err := cpu.Percent(time.Millisecond*200, true)
+	return collector.CPU()
+}
+
+// GetMemoryMetrics returns memory-specific metrics
+func (s *MetricsService) GetMemoryMetrics() (*models.MemoryMetrics, error) {
+	return collector.Memory()
+}
+
+// GetDiskMetrics returns disk-specific metrics
+func (s *MetricsService) GetDiskMetrics() ([]models.DiskMetrics, error) {
+	return collector.Disks()
+}
+
+// GetNetworkMetrics returns network-specific metrics of the interfaces the
+// interface settings show, with their friendly names
+func (s *MetricsService) GetNetworkMetrics() ([]models.NetworkMetrics, error) {
+	interfaces, err := s.ListNetworkInterfaces()
 	if err != nil {
 		return nil, err
 	}
+	return collector.Shown(interfaces), nil
+}
 
-	overallPercent, err := cpu.Percent(time.Millisecond*200, false)
-	if err != nil {
+// ListNetworkInterfaces returns the metrics of every interface, marking the
+// ones the interface settings hide, so they can be picked
+func (s *MetricsService) ListNetworkInterfaces() ([]models.NetworkMetrics, error) {
+	return collector.NetworkInterfaces(s.settings.NetworkInterfaces())
+}
+
+// GetMetricsHistory returns a host's latest limit samples, oldest first.
+// Device 0 is the backend's own host.
+func (s *MetricsService) GetMetricsHistory(deviceID uint, limit int) ([]models.MetricsHistory, error) {
+	if limit <= 0 || limit > MaxMetricsHistoryPoints {
+		limit = MaxMetricsHistoryPoints
+	}
+
+	history := make([]models.MetricsHistory, 0, limit)
+	if err := s.db.Where("device_id = ?", deviceID).Order("sampled_at DESC").Limit(limit).Find(&history).Error; err != nil {
 		return nil, err
 	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
 
-	var usagePercent float64
-	if len(overallPercent) > 0 {
-		usagePercent = overallPercent[0]
+// GetMetricsHistoryRange returns a host's samples between from and to,
+// averaged into buckets of bucket length. Buckets are widened so the range
+// has at most limit points (MaxMetricsHistoryPoints when zero or larger).
+// Network counters keep the bucket's last value.
+func (s *MetricsService) GetMetricsHistoryRange(deviceID uint, from, to time.Time, bucket time.Duration, limit int) ([]models.MetricsHistory, error) {
+	if limit <= 0 || limit > MaxMetricsHistoryPoints {
+		limit = MaxMetricsHistoryPoints
+	}
+	if minimum := to.Sub(from) / time.Duration(limit); bucket < minimum {
+		bucket = minimum
+	}
+	if bucket < metricsHistoryInterval {
+		bucket = metricsHistoryInterval
 	}
 
-	info, _ := cpu.Info()
-	var modelName string
-	var frequency float64
-	if len(info) > 0 {
-		modelName = info[0].ModelName
-		frequency = info[0].Mhz
+	history := make([]models.MetricsHistory, 0)
+	var current models.MetricsHistory
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		current.CPUUsage /= float64(count)
+		current.MemoryUsage /= float64(count)
+		current.DiskUsage /= float64(count)
+		history = append(history, current)
+		count = 0
 	}
 
-	cores, _ := cpu.Counts(false)
-	logicalCores, _ := cpu.Counts(true)
-
-	return &models.CPUMetrics{
-		UsagePercent: usagePercent,
-		Cores:        cores,
-		LogicalCores: logicalCores,
-		ModelName:    modelName,
-		Frequency:    frequency,
-		PerCoreUsage: percentages,
-	}, nil
+	currentKey := int64(-1)
+	err := s.StreamMetricsHistory(deviceID, from, to, func(sample models.MetricsHistory) error {
+		key := sample.Timestamp.Sub(from).Nanoseconds() / bucket.Nanoseconds()
+		if key != currentKey {
+			flush()
+			currentKey = key
+			current = models.MetricsHistory{DeviceID: deviceID, Timestamp: from.Add(time.Duration(key) * bucket)}
+		}
+		current.CPUUsage += sample.CPUUsage
+		current.MemoryUsage += sample.MemoryUsage
+		current.DiskUsage += sample.DiskUsage
+		current.NetworkIn = sample.NetworkIn
+		current.NetworkOut = sample.NetworkOut
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+
+	return history, nil
 }
 
-// GetMemoryMetrics returns memory-specific metrics
-func (s *MetricsService) GetMemoryMetrics() (*models.MemoryMetrics, error) {
-	vmem, err := mem.VirtualMemory()
+// StreamMetricsHistory calls fn with every stored sample of a host between
+// from and to, oldest first, reading rows one at a time so long ranges are
+// never held in memory. It stops at the first error fn returns.
+func (s *MetricsService) StreamMetricsHistory(deviceID uint, from, to time.Time, fn func(models.MetricsHistory) error) error {
+	rows, err := s.db.Model(&models.MetricsHistory{}).
+		Where("device_id = ? AND sampled_at >= ? AND sampled_at <= ?", deviceID, from, to).Order("sampled_at ASC").Rows()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rows.Close()
 
-	swap, _ := mem.SwapMemory()
-
-	return &models.MemoryMetrics{
-		Total:       vmem.Total,
-		Used:        vmem.Used,
-		Free:        vmem.Free,
-		Available:   vmem.Available,
-		UsedPercent: vmem.UsedPercent,
-		SwapTotal:   swap.Total,
-		SwapUsed:    swap.Used,
-		SwapFree:    swap.Free,
-		SwapPercent: swap.UsedPercent,
-	}, nil
+	for rows.Next() {
+		var sample models.MetricsHistory
+		if err := s.db.ScanRows(rows, &sample); err != nil {
+			return err
+		}
+		if err := fn(sample); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
-// GetDiskMetrics returns disk-specific metrics
-func (s *MetricsService) GetDiskMetrics() ([]models.DiskMetrics, error) {
-	partitions, err := disk.Partitions(false)
+// GetConnections returns active TCP/UDP connections matching the filter
+func (s *MetricsService) GetConnections(filter models.ConnectionFilter) ([]models.ConnectionInfo, error) {
+	conns, err := net.Connections("inet")
 	if err != nil {
 		return nil, err
 	}
 
-	var metrics []models.DiskMetrics
-	ioStats, _ := disk.IOCounters()
+	// Resolve process names once per PID
+	names := make(map[int32]string)
+	processName := func(pid int32) string {
+		if pid <= 0 {
+			return ""
+		}
+		if name, ok := names[pid]; ok {
+			return name
+		}
+		name := ""
+		if p, err := process.NewProcess(pid); err == nil {
+			name, _ = p.Name()
+		}
+		names[pid] = name
+		return name
+	}
 
-	for _, p := range partitions {
-		usage, err := disk.Usage(p.Mountpoint)
-		if err != nil {
+	result := make([]models.ConnectionInfo, 0, len(conns))
+	for _, c := range conns {
+		protocol := connectionProtocol(c.Family, c.Type)
+		if filter.Protocol != "" && !strings.HasPrefix(protocol, strings.ToLower(filter.Protocol)) {
 			continue
 		}
 
-		// Skip special filesystems
-		if usage.Total == 0 {
+		// UDP sockets report "NONE" as their state
+		state := c.Status
+		if state == "NONE" {
+			state = ""
+		}
+		if filter.State != "" && !strings.EqualFold(state, filter.State) {
 			continue
 		}
-
-		dm := models.DiskMetrics{
-			Device:      p.Device,
-			MountPoint:  p.Mountpoint,
-			Fstype:      p.Fstype,
-			Total:       usage.Total,
-			Used:        usage.Used,
-			Free:        usage.Free,
-			UsedPercent: usage.UsedPercent,
+		if filter.PID != 0 && c.Pid != filter.PID {
+			continue
 		}
-
-		// Add IO stats if available
-		if io, ok := ioStats[p.Device]; ok {
-			dm.ReadBytes = io.ReadBytes
-			dm.WriteBytes = io.WriteBytes
+		if filter.Port != 0 && c.Laddr.Port != filter.Port && c.Raddr.Port != filter.Port {
+			continue
 		}
-
-		metrics = append(metrics, dm)
-	}
-
-	return metrics, nil
-}
-
-// GetNetworkMetrics returns network-specific metrics
-func (s *MetricsService) GetNetworkMetrics() ([]models.NetworkMetrics, error) {
-	interfaces, err := net.IOCounters(true)
-	if err != nil {
-		return nil, err
-	}
-
-	var metrics []models.NetworkMetrics
-	for _, iface := range interfaces {
-		// Skip loopback on non-Windows systems
-		if runtime.GOOS != "windows" && iface.Name == "lo" {
+		if filter.RemoteOnly && c.Raddr.IP == "" {
 			continue
 		}
-		// Skip virtual interfaces
-		if iface.BytesSent == 0 && iface.BytesRecv == 0 {
+
+		name := processName(c.Pid)
+		if filter.Process != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(filter.Process)) {
 			continue
 		}
 
-		metrics = append(metrics, models.NetworkMetrics{
-			Interface:   iface.Name,
-			BytesSent:   iface.BytesSent,
-			BytesRecv:   iface.BytesRecv,
-			PacketsSent: iface.PacketsSent,
-			PacketsRecv: iface.PacketsRecv,
-			ErrorsIn:    iface.Errin,
-			ErrorsOut:   iface.Errout,
-			DropIn:      iface.Dropin,
-			DropOut:     iface.Dropout,
+		result = append(result, models.ConnectionInfo{
+			Protocol:      protocol,
+			LocalAddress:  c.Laddr.IP,
+			LocalPort:     c.Laddr.Port,
+			RemoteAddress: c.Raddr.IP,
+			RemotePort:    c.Raddr.Port,
+			State:         state,
+			PID:           c.Pid,
+			Process:       name,
 		})
 	}
 
-	return metrics, nil
+	return result, nil
 }
 
-// GetMetricsHistory returns historical metrics data
-func (s *MetricsService) GetMetricsHistory(limit int) []models.MetricsHistory {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if limit <= 0 || limit > len(s.history) {
-		limit = len(s.history)
+// connectionProtocol maps socket family/type to a protocol name
+func connectionProtocol(family, sockType uint32) string {
+	protocol := "tcp"
+	if sockType == syscall.SOCK_DGRAM {
+		protocol = "udp"
 	}
-
-	start := len(s.history) - limit
-	result := make([]models.MetricsHistory, limit)
-	copy(result, s.history[start:])
-
-	return result
+	if family == syscall.AF_INET6 {
+		protocol += "6"
+	}
+	return protocol
 }
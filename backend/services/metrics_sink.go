@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// sinkQueueSize is how many samples wait for a slow sink before new
+	// ones are dropped
+	sinkQueueSize = 32
+	// sinkWriteTimeout bounds one write to the sink
+	sinkWriteTimeout = 15 * time.Second
+)
+
+// sinkMeasurements are the tags and fields of each measurement, in column
+// order. Hosts are tagged with the device ID, 0 for the backend's own.
+var sinkMeasurements = map[string]struct{ tags, fields []string }{
+	"host": {
+		tags:   []string{"device_id"},
+		fields: []string{"cpu_percent", "memory_percent", "memory_used", "disk_percent", "network_in", "network_out", "load1"},
+	},
+	"container": {
+		tags:   []string{"name", "image"},
+		fields: []string{"cpu_percent", "memory_usage", "memory_limit", "network_rx", "network_tx", "block_read", "block_write", "pids"},
+	},
+}
+
+// sinkPoint is one sample of a measurement
+type sinkPoint struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]float64
+	at          time.Time
+}
+
+// metricsSink is an external time series database
+type metricsSink interface {
+	// setup prepares the database and applies the retention
+	setup(ctx context.Context) error
+	write(ctx context.Context, points []sinkPoint) error
+}
+
+// hostSample is a history sample waiting to be exported
+type hostSample struct {
+	deviceID uint
+	metrics  *models.SystemMetrics
+	at       time.Time
+}
+
+// MetricsSinkService exports the history samples of hosts, and of the local
+// containers alongside the backend's own, to InfluxDB or TimescaleDB
+type MetricsSinkService struct {
+	sink   metricsSink
+	docker *DockerService
+	queue  chan hostSample
+}
+
+// NewMetricsSinkService creates a new MetricsSinkService and starts
+// exporting when METRICS_SINK is set
+func NewMetricsSinkService(metrics *MetricsService, docker *DockerService) *MetricsSinkService {
+	s := &MetricsSinkService{docker: docker, queue: make(chan hostSample, sinkQueueSize)}
+
+	cfg := config.AppConfig
+	switch cfg.MetricsSink {
+	case "influxdb":
+		s.sink = &influxSink{
+			url:       strings.TrimRight(cfg.MetricsSinkURL, "/"),
+			token:     cfg.MetricsSinkToken,
+			org:       cfg.MetricsSinkOrg,
+			bucket:    cfg.MetricsSinkBucket,
+			retention: cfg.MetricsSinkRetentionDays,
+			client:    &http.Client{Timeout: sinkWriteTimeout},
+		}
+	case "timescaledb":
+		db, err := gorm.Open(postgres.Open(cfg.MetricsSinkURL), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			log.Printf("Metrics sink: failed to connect to TimescaleDB: %v", err)
+			return s
+		}
+		s.sink = &timescaleSink{db: db, retention: cfg.MetricsSinkRetentionDays}
+	default:
+		return s
+	}
+
+	metrics.OnSample(s.enqueue)
+	go s.run()
+	log.Printf("Exporting metrics to %s", cfg.MetricsSink)
+	return s
+}
+
+// enqueue queues a sample without blocking the history collection
+func (s *MetricsSinkService) enqueue(deviceID uint, metrics *models.SystemMetrics, at time.Time) {
+	select {
+	case s.queue <- hostSample{deviceID: deviceID, metrics: metrics, at: at}:
+	default:
+		log.Printf("Metrics sink: queue full, dropping a sample")
+	}
+}
+
+// run writes queued samples, setting the sink up first and again after
+// a failed setup
+func (s *MetricsSinkService) run() {
+	ready := false
+	for sample := range s.queue {
+		points := s.points(sample)
+		ctx, cancel := context.WithTimeout(context.Background(), sinkWriteTimeout)
+		if !ready {
+			if err := s.sink.setup(ctx); err != nil {
+				log.Printf("Metrics sink: setup failed: %v", err)
+				cancel()
+				continue
+			}
+			ready = true
+		}
+		if err := s.sink.write(ctx, points); err != nil {
+			log.Printf("Metrics sink: write failed: %v", err)
+		}
+		cancel()
+	}
+}
+
+// points converts a sample, adding the local containers to the backend's own
+func (s *MetricsSinkService) points(sample hostSample) []sinkPoint {
+	m := sample.metrics
+	var diskPercent, networkIn, networkOut, load1 float64
+	if len(m.Disk) > 0 {
+		diskPercent = m.Disk[0].UsedPercent
+	}
+	for _, n := range m.Network {
+		networkIn += float64(n.BytesRecv)
+		networkOut += float64(n.BytesSent)
+	}
+	if len(m.CPU.LoadAverage) > 0 {
+		load1 = m.CPU.LoadAverage[0]
+	}
+
+	points := []sinkPoint{{
+		measurement: "host",
+		tags:        map[string]string{"device_id": strconv.FormatUint(uint64(sample.deviceID), 10)},
+		fields: map[string]float64{
+			"cpu_percent":    m.CPU.UsagePercent,
+			"memory_percent": m.Memory.UsedPercent,
+			"memory_used":    float64(m.Memory.Used),
+			"disk_percent":   diskPercent,
+			"network_in":     networkIn,
+			"network_out":    networkOut,
+			"load1":          load1,
+		},
+		at: sample.at,
+	}}
+	if sample.deviceID != 0 || !s.docker.IsConnected() {
+		return points
+	}
+
+	for _, c := range s.docker.GetContainers() {
+		if c.State != "running" {
+			continue
+		}
+		points = append(points, sinkPoint{
+			measurement: "container",
+			tags:        map[string]string{"name": c.Name, "image": c.Image},
+			fields: map[string]float64{
+				"cpu_percent":  c.Stats.CPUPercent,
+				"memory_usage": float64(c.Stats.MemoryUsage),
+				"memory_limit": float64(c.Stats.MemoryLimit),
+				"network_rx":   float64(c.Stats.NetworkRx),
+				"network_tx":   float64(c.Stats.NetworkTx),
+				"block_read":   float64(c.Stats.BlockRead),
+				"block_write":  float64(c.Stats.BlockWrite),
+				"pids":         float64(c.Stats.PIDs),
+			},
+			at: sample.at,
+		})
+	}
+	return points
+}
+
+// influxSink writes line protocol to the InfluxDB v2 write API
+type influxSink struct {
+	url, token, org, bucket string
+	retention               int // days, 0 leaves the bucket's retention alone
+	client                  *http.Client
+}
+
+// influxEscaper escapes measurement names, tag values and field keys
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// setup sets the bucket's retention
+func (s *influxSink) setup(ctx context.Context) error {
+	if s.retention == 0 {
+		return nil
+	}
+
+	var buckets struct {
+		Buckets []struct {
+			ID string `json:"id"`
+		} `json:"buckets"`
+	}
+	query := url.Values{"name": {s.bucket}, "org": {s.org}}
+	if err := s.do(ctx, "GET", "/api/v2/buckets?"+query.Encode(), "", nil, &buckets); err != nil {
+		return err
+	}
+	if len(buckets.Buckets) == 0 {
+		return fmt.Errorf("bucket %q not found", s.bucket)
+	}
+
+	rules := map[string]interface{}{
+		"retentionRules": []map[string]interface{}{
+			{"type": "expire", "everySeconds": s.retention * 24 * 60 * 60},
+		},
+	}
+	body, _ := json.Marshal(rules)
+	return s.do(ctx, "PATCH", "/api/v2/buckets/"+buckets.Buckets[0].ID, "application/json", body, nil)
+}
+
+// write posts the points in line protocol with second precision
+func (s *influxSink) write(ctx context.Context, points []sinkPoint) error {
+	var body bytes.Buffer
+	for _, p := range points {
+		schema := sinkMeasurements[p.measurement]
+		body.WriteString(influxEscaper.Replace(p.measurement))
+		for _, tag := range schema.tags {
+			// InfluxDB rejects empty tag values
+			if value := p.tags[tag]; value != "" {
+				fmt.Fprintf(&body, ",%s=%s", tag, influxEscaper.Replace(value))
+			}
+		}
+		for i, field := range schema.fields {
+			sep := ","
+			if i == 0 {
+				sep = " "
+			}
+			fmt.Fprintf(&body, "%s%s=%s", sep, field, strconv.FormatFloat(p.fields[field], 'f', -1, 64))
+		}
+		fmt.Fprintf(&body, " %d\n", p.at.Unix())
+	}
+
+	query := url.Values{"org": {s.org}, "bucket": {s.bucket}, "precision": {"s"}}
+	return s.do(ctx, "POST", "/api/v2/write?"+query.Encode(), "text/plain; charset=utf-8", body.Bytes(), nil)
+}
+
+// do sends an authenticated request, decoding a JSON response into out
+func (s *influxSink) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+s.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %d: %s", method, strings.SplitN(path, "?", 2)[0], resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// timescaleSink inserts rows into one hypertable per measurement
+type timescaleSink struct {
+	db        *gorm.DB
+	retention int // days, 0 keeps rows forever
+}
+
+// timescaleTable names a measurement's hypertable
+func timescaleTable(measurement string) string {
+	return "homelab_" + measurement + "_metrics"
+}
+
+// setup creates the hypertables and sets their retention policy
+func (s *timescaleSink) setup(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+		return err
+	}
+
+	for measurement, schema := range sinkMeasurements {
+		table := timescaleTable(measurement)
+		columns := []string{"time TIMESTAMPTZ NOT NULL"}
+		for _, tag := range schema.tags {
+			columns = append(columns, tag+" TEXT NOT NULL")
+		}
+		for _, field := range schema.fields {
+			columns = append(columns, field+" DOUBLE PRECISION")
+		}
+
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(columns, ", ")),
+			fmt.Sprintf("SELECT create_hypertable('%s', 'time', if_not_exists => TRUE)", table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_%s_time ON %s (%s, time DESC)", table, schema.tags[0], table, schema.tags[0]),
+			fmt.Sprintf("SELECT remove_retention_policy('%s', if_exists => TRUE)", table),
+		}
+		if s.retention > 0 {
+			statements = append(statements, fmt.Sprintf("SELECT add_retention_policy('%s', INTERVAL '%d days')", table, s.retention))
+		}
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// write inserts the points, one statement per measurement
+func (s *timescaleSink) write(ctx context.Context, points []sinkPoint) error {
+	byMeasurement := make(map[string][]sinkPoint)
+	for _, p := range points {
+		byMeasurement[p.measurement] = append(byMeasurement[p.measurement], p)
+	}
+
+	for measurement, rows := range byMeasurement {
+		schema := sinkMeasurements[measurement]
+		columns := append(append([]string{"time"}, schema.tags...), schema.fields...)
+		placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+		values := make([]string, 0, len(rows))
+		args := make([]interface{}, 0, len(rows)*len(columns))
+		for _, p := range rows {
+			values = append(values, placeholder)
+			args = append(args, p.at)
+			for _, tag := range schema.tags {
+				args = append(args, p.tags[tag])
+			}
+			for _, field := range schema.fields {
+				args = append(args, p.fields[field])
+			}
+		}
+
+		statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", timescaleTable(measurement), strings.Join(columns, ", "), strings.Join(values, ", "))
+		if err := s.db.WithContext(ctx).Exec(statement, args...).Error; err != nil {
+			return fmt.Errorf("%s: %w", timescaleTable(measurement), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/homelab/backend/collector"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// MountService checks that network mounts and configured mount points are
+// present and respond, recording events when one goes unhealthy
+type MountService struct {
+	paths   []string
+	timeout time.Duration
+	events  *EventService
+
+	mu     sync.Mutex
+	status map[string]string // mount point -> last status
+}
+
+// NewMountService creates a new MountService and starts the monitor
+func NewMountService(events *EventService) *MountService {
+	cfg := config.AppConfig
+	s := &MountService{
+		timeout: time.Duration(cfg.MountCheckTimeout) * time.Second,
+		events:  events,
+		status:  make(map[string]string),
+	}
+	for _, p := range strings.Split(cfg.MountCheckPaths, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			s.paths = append(s.paths, strings.TrimRight(p, "/"))
+		}
+	}
+
+	go s.monitorBackground()
+
+	return s
+}
+
+// Check returns the health of every network mount and configured mount point
+func (s *MountService) Check() ([]models.MountHealth, error) {
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		return nil, err
+	}
+
+	mounted := make(map[string]disk.PartitionStat, len(partitions))
+	for _, p := range partitions {
+		mounted[p.Mountpoint] = p
+	}
+
+	targets := make([]string, 0)
+	configured := make(map[string]bool, len(s.paths))
+	for _, p := range s.paths {
+		configured[p] = true
+		targets = append(targets, p)
+	}
+	for _, p := range partitions {
+		if collector.IsNetworkFstype(p.Fstype) && !configured[p.Mountpoint] {
+			targets = append(targets, p.Mountpoint)
+		}
+	}
+
+	results := make([]models.MountHealth, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(idx int, target string) {
+			defer wg.Done()
+			part, ok := mounted[target]
+			results[idx] = s.checkMount(target, part, ok, configured[target])
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// checkMount statfs's a single mount point
+func (s *MountService) checkMount(target string, part disk.PartitionStat, mounted, configured bool) models.MountHealth {
+	health := models.MountHealth{
+		MountPoint: target,
+		Device:     part.Device,
+		Fstype:     part.Fstype,
+		Configured: configured,
+		CheckedAt:  time.Now(),
+	}
+	if !mounted {
+		health.Status = models.MountMissing
+		health.Error = "not mounted"
+		return health
+	}
+
+	start := time.Now()
+	usage, err := collector.UsageWithTimeout(target, s.timeout)
+	health.ResponseMs = time.Since(start).Milliseconds()
+
+	switch {
+	case err == nil:
+		health.Status = models.MountOK
+		health.Total = usage.Total
+		health.Used = usage.Used
+		health.UsedPercent = usage.UsedPercent
+	case errors.Is(err, collector.ErrStatfsTimeout):
+		health.Status = models.MountTimeout
+		health.Error = fmt.Sprintf("no response within %s", s.timeout)
+	case errors.Is(err, syscall.ESTALE):
+		health.Status = models.MountStale
+		health.Error = "stale file handle"
+	default:
+		health.Status = models.MountError
+		health.Error = err.Error()
+	}
+	return health
+}
+
+// monitorBackground checks mounts every minute and records transitions
+func (s *MountService) monitorBackground() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		mounts, err := s.Check()
+		if err != nil {
+			log.Printf("Mount check failed: %v", err)
+		} else {
+			s.recordTransitions(mounts)
+		}
+		<-ticker.C
+	}
+}
+
+// recordTransitions records an event when a mount becomes unhealthy or recovers
+func (s *MountService) recordTransitions(mounts []models.MountHealth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range mounts {
+		previous, seen := s.status[m.MountPoint]
+		s.status[m.MountPoint] = m.Status
+		if previous == m.Status || (!seen && m.Status == models.MountOK) {
+			continue
+		}
+
+		details := map[string]interface{}{
+			"mountPoint": m.MountPoint,
+			"device":     m.Device,
+			"fstype":     m.Fstype,
+			"status":     m.Status,
+		}
+		if m.Status == models.MountOK {
+			s.events.Record("mount_recovered", models.SeverityInfo, "system",
+				"Mount recovered",
+				fmt.Sprintf("%s is responding again", m.MountPoint), details)
+			continue
+		}
+		s.events.Record("mount_unhealthy", models.SeverityCritical, "system",
+			fmt.Sprintf("Mount %s", m.Status),
+			fmt.Sprintf("%s is %s: %s", m.MountPoint, m.Status, m.Error), details)
+	}
+}
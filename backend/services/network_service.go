@@ -1,54 +1,271 @@
 package services
 
 import (
-	"fmt"
 	"io"
+	"log"
+	"math"
 	"net/http"
-	"os/exec"
 	"regexp"
-	"runtime"
-	"strconv"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
 )
 
-type NetworkService struct{}
+type NetworkService struct {
+	db            *gorm.DB
+	targets       []models.PingTarget
+	pingCount     int
+	probeInterval time.Duration
+	retentionDays int
+}
 
 func NewNetworkService() *NetworkService {
-	return &NetworkService{}
+	cfg := config.AppConfig
+	s := &NetworkService{
+		db:            database.GetDB(),
+		targets:       parsePingTargets(cfg.PingTargets),
+		pingCount:     cfg.PingCount,
+		probeInterval: time.Duration(cfg.LatencyProbeInterval) * time.Second,
+		retentionDays: cfg.LatencyRetentionDays,
+	}
+
+	// Start smokeping-style background probing
+	if s.probeInterval > 0 {
+		go s.probeBackground()
+	}
+
+	return s
 }
 
-// Ping google DNS 8.8.8.8
-func (s *NetworkService) Ping() (float64, error) {
-	host := "8.8.8.8"
-	var cmd *exec.Cmd
+// parsePingTargets parses "name=host,host2" into ping targets
+func parsePingTargets(value string) []models.PingTarget {
+	targets := make([]models.PingTarget, 0)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target := models.PingTarget{Name: entry, Host: entry}
+		if idx := strings.Index(entry, "="); idx > 0 {
+			target.Name = strings.TrimSpace(entry[:idx])
+			target.Host = strings.TrimSpace(entry[idx+1:])
+		}
+		targets = append(targets, target)
+	}
 
-	// Windows: ping -n 1 -w 1000 8.8.8.8
-	// Linux: ping -c 1 -W 1 8.8.8.8
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("ping", "-n", "1", "-w", "1000", host)
-	} else {
-		cmd = exec.Command("ping", "-c", "1", "-W", "1", host)
+	if len(targets) == 0 {
+		targets = append(targets, models.PingTarget{Name: "Google DNS", Host: "8.8.8.8"})
 	}
+	return targets
+}
 
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return 0, err
+// Targets returns the configured ping targets
+func (s *NetworkService) Targets() []models.PingTarget {
+	return s.targets
+}
+
+// PingTargets pings every configured target concurrently
+func (s *NetworkService) PingTargets() []models.PingResult {
+	results := make([]models.PingResult, len(s.targets))
+	var wg sync.WaitGroup
+
+	for i, target := range s.targets {
+		wg.Add(1)
+		go func(idx int, t models.PingTarget) {
+			defer wg.Done()
+			results[idx] = s.pingHost(t, s.pingCount)
+		}(i, target)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// probeBackground pings all targets on the probe interval and stores the results
+func (s *NetworkService) probeBackground() {
+	ticker := time.NewTicker(s.probeInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		<-ticker.C
+
+		now := time.Now()
+		results := s.PingTargets()
+		samples := make([]models.LatencySample, 0, len(results))
+		for _, r := range results {
+			samples = append(samples, models.LatencySample{
+				Target:     r.Name,
+				Host:       r.Host,
+				Latency:    r.Latency,
+				MinLatency: r.MinLatency,
+				MaxLatency: r.MaxLatency,
+				PacketLoss: r.PacketLoss,
+				CreatedAt:  now,
+			})
+		}
+		if err := s.db.Create(&samples).Error; err != nil {
+			log.Printf("Failed to store latency samples: %v", err)
+		}
+
+		// Purge samples beyond retention once a day
+		if time.Since(lastCleanup) > 24*time.Hour {
+			cutoff := now.AddDate(0, 0, -s.retentionDays)
+			s.db.Where("created_at < ?", cutoff).Delete(&models.LatencySample{})
+			lastCleanup = now
+		}
+	}
+}
+
+// GetLatencyHistory returns bucketed latency percentiles per target between from and to
+func (s *NetworkService) GetLatencyHistory(target string, from, to time.Time, bucket time.Duration) ([]models.LatencyHistory, error) {
+	query := s.db.Where("created_at >= ? AND created_at <= ?", from, to).Order("created_at ASC")
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	var samples []models.LatencySample
+	if err := query.Find(&samples).Error; err != nil {
+		return nil, err
+	}
+
+	type bucketData struct {
+		start     time.Time
+		latencies []float64
+		lossTotal float64
+		count     int
+	}
+
+	histories := make([]models.LatencyHistory, 0)
+	index := make(map[string]int)
+	buckets := make(map[string]map[int64]*bucketData)
+
+	for _, sample := range samples {
+		if _, ok := index[sample.Target]; !ok {
+			index[sample.Target] = len(histories)
+			histories = append(histories, models.LatencyHistory{Target: sample.Target, Host: sample.Host})
+			buckets[sample.Target] = make(map[int64]*bucketData)
+		}
+
+		key := sample.CreatedAt.Sub(from).Nanoseconds() / bucket.Nanoseconds()
+		b, ok := buckets[sample.Target][key]
+		if !ok {
+			b = &bucketData{start: from.Add(time.Duration(key) * bucket)}
+			buckets[sample.Target][key] = b
+		}
+		if sample.Latency >= 0 {
+			b.latencies = append(b.latencies, sample.Latency)
+		}
+		b.lossTotal += sample.PacketLoss
+		b.count++
+	}
+
+	for i := range histories {
+		targetBuckets := buckets[histories[i].Target]
+		keys := make([]int64, 0, len(targetBuckets))
+		for k := range targetBuckets {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+
+		result := make([]models.LatencyBucket, 0, len(keys))
+		for _, k := range keys {
+			b := targetBuckets[k]
+			lb := models.LatencyBucket{
+				Timestamp:  b.start,
+				Samples:    b.count,
+				PacketLoss: b.lossTotal / float64(b.count),
+			}
+			if len(b.latencies) > 0 {
+				sort.Float64s(b.latencies)
+				lb.Min = b.latencies[0]
+				lb.Max = b.latencies[len(b.latencies)-1]
+				lb.P50 = percentile(b.latencies, 50)
+				lb.P90 = percentile(b.latencies, 90)
+				lb.P99 = percentile(b.latencies, 99)
+			}
+			result = append(result, lb)
+		}
+		histories[i].Buckets = result
+	}
+
+	return histories, nil
+}
+
+// percentile returns the p-th percentile of sorted values using nearest rank
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Regex to extract time. Supports:
+// Windows: "time=32ms"
+// Linux: "time=32.1 ms"
+var pingTimeRe = regexp.MustCompile(`[Tt]ime[=<]([\d\.]+) ?ms`)
+
+// pingHost sends count echo requests to the target and summarizes the replies
+func (s *NetworkService) pingHost(target models.PingTarget, count int) models.PingResult {
+	result := models.PingResult{
+		Name:    target.Name,
+		Host:    target.Host,
+		Sent:    count,
+		Latency: -1,
 	}
-	output := string(out)
 
-	// Regex to extract time. Supports:
-	// Windows: "time=32ms"
-	// Linux: "time=32.1 ms"
-	re := regexp.MustCompile(`[Tt]ime[=<]([\d\.]+) ?ms`)
-	matches := re.FindStringSubmatch(output)
-	if len(matches) > 1 {
-		val, err := strconv.ParseFloat(matches[1], 64)
+	var total float64
+	var lastErr error
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		rtt, err := icmpEcho(target.Host, time.Second)
 		if err != nil {
-			return 0, err
+			lastErr = err
+			continue
 		}
-		return val, nil
+
+		val := float64(rtt.Microseconds()) / 1000
+		if result.Received == 0 || val < result.MinLatency {
+			result.MinLatency = val
+		}
+		if val > result.MaxLatency {
+			result.MaxLatency = val
+		}
+		total += val
+		result.Received++
+	}
+
+	result.PacketLoss = float64(count-result.Received) / float64(count) * 100
+
+	switch {
+	case result.Received == 0:
+		result.Status = "offline"
+		if lastErr != nil {
+			result.Error = lastErr.Error()
+		}
+	case result.Received < count:
+		result.Status = "degraded"
+		result.Latency = total / float64(result.Received)
+	default:
+		result.Status = "online"
+		result.Latency = total / float64(result.Received)
 	}
-	return 0, fmt.Errorf("could not parse ping output")
+
+	return result
 }
 
 // Simple Download Speed Test (Download ~10MB)
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// NoteService manages changelog notes on services and devices
+type NoteService struct {
+	db *gorm.DB
+}
+
+// Note feed limits
+const (
+	DefaultNoteLimit = 100
+	MaxNoteLimit     = 1000
+	maxNoteLength    = 5000
+)
+
+// NewNoteService creates a new NoteService
+func NewNoteService() *NoteService {
+	return &NoteService{db: database.GetDB()}
+}
+
+// noteScope applies a feed filter to a notes query
+func noteScope(filter models.NoteFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.ServiceID != nil {
+			db = db.Where("service_id = ?", *filter.ServiceID)
+		}
+		if filter.DeviceID != nil {
+			db = db.Where("device_id = ?", *filter.DeviceID)
+		}
+		if filter.From != nil {
+			db = db.Where("occurred_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("occurred_at <= ?", *filter.To)
+		}
+		return db
+	}
+}
+
+// List returns the user's notes, newest first
+func (s *NoteService) List(userID uint, filter models.NoteFilter) ([]models.Note, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxNoteLimit {
+		filter.Limit = DefaultNoteLimit
+	}
+	var notes []models.Note
+	err := s.db.Scopes(noteScope(filter)).Where("user_id = ?", userID).
+		Order("occurred_at DESC").Limit(filter.Limit).Find(&notes).Error
+	return notes, err
+}
+
+// Annotations returns the user's notes in a time range, oldest first, for graph overlays
+func (s *NoteService) Annotations(userID uint, filter models.NoteFilter) ([]models.Annotation, error) {
+	var notes []models.Note
+	if err := s.db.Scopes(noteScope(filter)).Where("user_id = ?", userID).
+		Order("occurred_at ASC").Limit(MaxNoteLimit).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return toAnnotations(notes), nil
+}
+
+// toAnnotations reduces notes to graph annotations
+func toAnnotations(notes []models.Note) []models.Annotation {
+	annotations := make([]models.Annotation, len(notes))
+	for i, note := range notes {
+		annotations[i] = models.Annotation{
+			Time:      note.OccurredAt,
+			Text:      note.Body,
+			NoteID:    note.ID,
+			ServiceID: note.ServiceID,
+			DeviceID:  note.DeviceID,
+		}
+	}
+	return annotations
+}
+
+// validate checks the note body and that the service or device belongs to the user
+func (s *NoteService) validate(userID uint, req *models.NoteRequest) error {
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return fmt.Errorf("note body is required")
+	}
+	if len(req.Body) > maxNoteLength {
+		return fmt.Errorf("note is longer than %d characters", maxNoteLength)
+	}
+	if req.ServiceID == nil && req.DeviceID == nil {
+		return fmt.Errorf("a note needs a serviceId or deviceId")
+	}
+
+	var count int64
+	if req.ServiceID != nil {
+		s.db.Model(&models.ServiceConfig{}).Where("id = ? AND user_id = ?", *req.ServiceID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("service not found")
+		}
+	}
+	if req.DeviceID != nil {
+		s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", *req.DeviceID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("device not found")
+		}
+	}
+	return nil
+}
+
+// Create adds a note
+func (s *NoteService) Create(userID uint, req models.NoteRequest) (*models.Note, error) {
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+	note := models.Note{
+		UserID:     userID,
+		ServiceID:  req.ServiceID,
+		DeviceID:   req.DeviceID,
+		Body:       req.Body,
+		OccurredAt: time.Now(),
+	}
+	if req.OccurredAt != nil {
+		note.OccurredAt = *req.OccurredAt
+	}
+	if err := s.db.Create(&note).Error; err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// get loads one of the user's notes
+func (s *NoteService) get(id, userID uint) (*models.Note, error) {
+	var note models.Note
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&note).Error; err != nil {
+		return nil, fmt.Errorf("note not found")
+	}
+	return &note, nil
+}
+
+// Update edits a note
+func (s *NoteService) Update(id, userID uint, req models.NoteRequest) (*models.Note, error) {
+	note, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(userID, &req); err != nil {
+		return nil, err
+	}
+	note.ServiceID = req.ServiceID
+	note.DeviceID = req.DeviceID
+	note.Body = req.Body
+	if req.OccurredAt != nil {
+		note.OccurredAt = *req.OccurredAt
+	}
+	if err := s.db.Save(note).Error; err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Delete removes a note
+func (s *NoteService) Delete(id, userID uint) error {
+	note, err := s.get(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(note).Error
+}
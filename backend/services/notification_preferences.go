@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// userSettings loads a user's settings, empty when none are saved
+func (s *NotificationService) userSettings(userID uint) models.UserSettings {
+	settings := models.UserSettings{UserID: userID}
+	if err := s.db.Where("user_id = ?", userID).First(&settings).Error; err == nil && settings.RawNotificationPreferences != "" {
+		json.Unmarshal([]byte(settings.RawNotificationPreferences), &settings.NotificationPreferences)
+	}
+	if settings.NotificationPreferences == nil {
+		settings.NotificationPreferences = []models.NotificationPreference{}
+	}
+	return settings
+}
+
+// channelPreferences returns a user's preferences by channel
+func (s *NotificationService) channelPreferences(userID uint) map[uint]models.NotificationPreference {
+	prefs := make(map[uint]models.NotificationPreference)
+	for _, pref := range s.userSettings(userID).NotificationPreferences {
+		prefs[pref.ChannelID] = pref
+	}
+	return prefs
+}
+
+// Categories returns the notification categories users can choose from
+func (s *NotificationService) Categories() []string {
+	return models.NotificationCategories
+}
+
+// GetPreferences returns which categories and severities each of the user's
+// channels receives
+func (s *NotificationService) GetPreferences(userID uint) (*models.UserSettings, error) {
+	settings := s.userSettings(userID)
+	return &settings, nil
+}
+
+// UpdatePreferences replaces the user's notification preferences. Each
+// channel may appear once and must belong to the user.
+func (s *NotificationService) UpdatePreferences(userID uint, prefs []models.NotificationPreference) (*models.UserSettings, error) {
+	var owned []uint
+	if err := s.db.Model(&models.NotificationChannel{}).Where("user_id = ?", userID).Pluck("id", &owned).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool)
+	for i := range prefs {
+		pref := &prefs[i]
+		if !slices.Contains(owned, pref.ChannelID) {
+			return nil, fmt.Errorf("notification channel not found")
+		}
+		if seen[pref.ChannelID] {
+			return nil, fmt.Errorf("channel %d appears more than once", pref.ChannelID)
+		}
+		seen[pref.ChannelID] = true
+
+		if pref.MinSeverity == "" {
+			pref.MinSeverity = models.SeverityInfo
+		}
+		if _, ok := severityRank[pref.MinSeverity]; !ok {
+			return nil, fmt.Errorf("invalid minimum severity: %s", pref.MinSeverity)
+		}
+		if pref.Categories == nil {
+			pref.Categories = []string{}
+		}
+		for _, category := range pref.Categories {
+			if !slices.Contains(models.NotificationCategories, category) {
+				return nil, fmt.Errorf("invalid category: %s", category)
+			}
+		}
+	}
+
+	data, _ := json.Marshal(prefs)
+	settings := models.UserSettings{UserID: userID, RawNotificationPreferences: string(data)}
+	if err := s.db.Save(&settings).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(userID)
+}
+
+// notifyEvent sends an event to every channel whose owner routed its
+// category there
+func (s *NotificationService) notifyEvent(event models.Event) {
+	var all []models.UserSettings
+	if err := s.db.Where("notification_preferences <> ?", "").Find(&all).Error; err != nil {
+		log.Printf("Failed to load notification preferences: %v", err)
+		return
+	}
+
+	msg := models.NotificationMessage{
+		Title:    event.Title,
+		Message:  event.Message,
+		Severity: event.Severity,
+		State:    "event",
+		Source:   event.Source,
+		Time:     time.Now(),
+		Event:    &event,
+	}
+	for _, settings := range all {
+		var prefs []models.NotificationPreference
+		if err := json.Unmarshal([]byte(settings.RawNotificationPreferences), &prefs); err != nil {
+			continue
+		}
+		for _, pref := range prefs {
+			if !slices.Contains(pref.Categories, event.Source) || severityRank[event.Severity] < severityRank[pref.MinSeverity] {
+				continue
+			}
+			var channel models.NotificationChannel
+			if err := s.db.Where("id = ? AND user_id = ? AND enabled = ?", pref.ChannelID, settings.UserID, true).First(&channel).Error; err != nil {
+				continue
+			}
+			if err := s.deliver(channel, msg); err != nil {
+				log.Printf("Notification channel %d (%s) failed: %v", channel.ID, channel.Type, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/integrations"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// NotificationService delivers alerts to users' notification channels
+type NotificationService struct {
+	db     *gorm.DB
+	client *http.Client
+
+	window  time.Duration // alert grouping window, 0 sends alerts one by one
+	batchMu sync.Mutex
+	batches map[uint]*alertBatch // alerts per user waiting out the window
+}
+
+// notificationTimeout bounds a single delivery
+const notificationTimeout = 10 * time.Second
+
+// channelTypes lists the supported channel types and their settings
+var channelTypes = []models.NotificationChannelType{
+	{
+		Type:        models.ChannelTelegram,
+		Description: "Send a message from a Telegram bot to a chat",
+		Schema: []models.IntegrationField{
+			{Key: "botToken", Label: "Bot token", Type: models.FieldSecret, Required: true,
+				Description: "From @BotFather"},
+			{Key: "chatId", Label: "Chat ID", Type: models.FieldString, Required: true,
+				Description: "User, group or @channel the bot posts to"},
+		},
+		DefaultTemplate: "{{.Title}}\n{{.Message}}",
+	},
+	{
+		Type:        models.ChannelDiscord,
+		Description: "Post a message to a Discord channel webhook",
+		Schema: []models.IntegrationField{
+			{Key: "webhookUrl", Label: "Webhook URL", Type: models.FieldSecret, Required: true,
+				Description: "Channel settings > Integrations > Webhooks"},
+			{Key: "username", Label: "Username", Type: models.FieldString, Default: "Homelab Monitor"},
+		},
+		DefaultTemplate: "**{{.Title}}**\n{{.Message}}",
+	},
+	{
+		Type:        models.ChannelWebhook,
+		Description: "Send an HTTP request with a templated body",
+		Schema: []models.IntegrationField{
+			{Key: "url", Label: "URL", Type: models.FieldURL, Required: true},
+			{Key: "method", Label: "Method", Type: models.FieldString, Default: "POST"},
+			{Key: "contentType", Label: "Content type", Type: models.FieldString, Default: "application/json"},
+			{Key: "headers", Label: "Headers", Type: models.FieldString,
+				Description: "One Name: value per line"},
+			{Key: "secret", Label: "Signing secret", Type: models.FieldSecret,
+				Description: "When set, requests carry X-Homelab-Signature: sha256=<hmac of body>"},
+		},
+		DefaultTemplate: "{{json .}}",
+	},
+}
+
+// severityRank orders event severities for channel filtering
+var severityRank = map[string]int{
+	models.SeverityInfo:     0,
+	models.SeverityWarning:  1,
+	models.SeverityCritical: 2,
+}
+
+// templateFuncs are available in channel templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {"text": {{json .Message}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NewNotificationService creates a new NotificationService and subscribes it
+// to alerts and to the events users route to their channels
+func NewNotificationService(alerts *AlertService, events *EventService) *NotificationService {
+	s := &NotificationService{
+		db:      database.GetDB(),
+		client:  &http.Client{Timeout: notificationTimeout},
+		window:  time.Duration(config.AppConfig.AlertGroupWindow) * time.Second,
+		batches: make(map[uint]*alertBatch),
+	}
+	alerts.OnAlert(func(alert models.Alert) {
+		if s.window > 0 {
+			s.queueAlert(alert)
+			return
+		}
+		// Deliveries can be slow; keep them off the evaluator
+		go s.notifyAlerts(alert.UserID, []models.Alert{alert})
+	})
+	events.OnEvent(func(event models.Event) {
+		// Alerts reach their owner's channels on their own
+		if event.Source != models.NotificationCategoryAlerts {
+			go s.notifyEvent(event)
+		}
+	})
+	return s
+}
+
+// ChannelTypes returns the supported channel types
+func (s *NotificationService) ChannelTypes() []models.NotificationChannelType {
+	return channelTypes
+}
+
+// channelType returns a channel type by name
+func channelType(name string) (models.NotificationChannelType, bool) {
+	for _, t := range channelTypes {
+		if t.Type == name {
+			return t, true
+		}
+	}
+	return models.NotificationChannelType{}, false
+}
+
+// decodeChannels fills and masks the settings of channels loaded from the database
+func decodeChannels(channels ...*models.NotificationChannel) {
+	for _, channel := range channels {
+		channel.Settings = decodeIntegrationConfig(channel.RawSettings)
+		t, _ := channelType(channel.Type)
+		for _, field := range t.Schema {
+			if field.Type == models.FieldSecret && channel.Settings[field.Key] != "" {
+				channel.Settings[field.Key] = maskedSecret
+			}
+		}
+	}
+}
+
+// channelSettings returns a channel's settings with secrets decrypted and
+// defaults applied
+func channelSettings(channel models.NotificationChannel) (map[string]string, error) {
+	settings := decodeIntegrationConfig(channel.RawSettings)
+	t, _ := channelType(channel.Type)
+	for _, field := range t.Schema {
+		if field.Type != models.FieldSecret {
+			continue
+		}
+		value, err := DecryptSecret(settings[field.Key])
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s: %v", field.Key, err)
+		}
+		settings[field.Key] = value
+	}
+	return integrations.ApplyDefaults(t.Schema, settings), nil
+}
+
+// applyChannelRequest validates a request and copies it onto a channel.
+// Masked secrets in the request keep their stored value.
+func applyChannelRequest(channel *models.NotificationChannel, req models.NotificationChannelRequest) error {
+	t, ok := channelType(req.Type)
+	if !ok {
+		return fmt.Errorf("unsupported channel type: %s", req.Type)
+	}
+	if req.MinSeverity == "" {
+		req.MinSeverity = models.SeverityInfo
+	}
+	if _, ok := severityRank[req.MinSeverity]; !ok {
+		return fmt.Errorf("invalid minimum severity: %s", req.MinSeverity)
+	}
+	if req.Template != "" {
+		if _, err := template.New("channel").Funcs(templateFuncs).Parse(req.Template); err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+	}
+
+	previous := map[string]string{}
+	if channel.Type == req.Type {
+		previous = decodeIntegrationConfig(channel.RawSettings)
+	}
+	plain := make(map[string]string)
+	stored := make(map[string]string)
+	for _, field := range t.Schema {
+		value := strings.TrimSpace(req.Settings[field.Key])
+		if field.Type == models.FieldSecret && value == maskedSecret {
+			if previous[field.Key] != "" {
+				// Keep the stored, already encrypted value
+				stored[field.Key] = previous[field.Key]
+				plain[field.Key] = maskedSecret
+				continue
+			}
+			value = ""
+		}
+		plain[field.Key] = value
+		if field.Type == models.FieldSecret {
+			encrypted, err := EncryptSecret(value)
+			if err != nil {
+				return err
+			}
+			value = encrypted
+		}
+		if value != "" {
+			stored[field.Key] = value
+		}
+	}
+	if err := integrations.ValidateConfig(t.Schema, integrations.ApplyDefaults(t.Schema, plain)); err != nil {
+		return err
+	}
+	if err := validateChannelSettings(req.Type, plain); err != nil {
+		return err
+	}
+
+	channel.Name = req.Name
+	channel.Type = req.Type
+	channel.MinSeverity = req.MinSeverity
+	channel.Template = req.Template
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	if req.SendResolved != nil {
+		channel.SendResolved = *req.SendResolved
+	}
+	data, _ := json.Marshal(stored)
+	channel.RawSettings = string(data)
+	return nil
+}
+
+// validateChannelSettings checks URLs and other type-specific settings
+func validateChannelSettings(kind string, settings map[string]string) error {
+	checkURL := func(key string) error {
+		value := settings[key]
+		if value == "" || value == maskedSecret {
+			return nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s must be an http or https URL", key)
+		}
+		return nil
+	}
+
+	switch kind {
+	case models.ChannelDiscord:
+		return checkURL("webhookUrl")
+	case models.ChannelWebhook:
+		if err := checkURL("url"); err != nil {
+			return err
+		}
+		switch strings.ToUpper(settings["method"]) {
+		case "", "POST", "PUT", "PATCH":
+		default:
+			return fmt.Errorf("method must be POST, PUT or PATCH")
+		}
+		if _, err := parseHeaders(settings["headers"]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseHeaders parses "Name: value" lines
+func parseHeaders(raw string) (http.Header, error) {
+	headers := http.Header{}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header line: %q", line)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// ListChannels returns the user's notification channels
+func (s *NotificationService) ListChannels(userID uint) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	for i := range channels {
+		decodeChannels(&channels[i])
+	}
+	return channels, nil
+}
+
+// GetChannel returns one of the user's notification channels
+func (s *NotificationService) GetChannel(id uint, userID uint) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&channel).Error; err != nil {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+	decodeChannels(&channel)
+	return &channel, nil
+}
+
+// CreateChannel adds a notification channel
+func (s *NotificationService) CreateChannel(userID uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	channel := models.NotificationChannel{UserID: userID, Enabled: true, SendResolved: true}
+	if err := applyChannelRequest(&channel, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&channel).Error; err != nil {
+		return nil, err
+	}
+	// Create leaves false to the column defaults
+	if !channel.Enabled || !channel.SendResolved {
+		s.db.Model(&channel).Updates(map[string]interface{}{"enabled": channel.Enabled, "send_resolved": channel.SendResolved})
+	}
+	decodeChannels(&channel)
+	return &channel, nil
+}
+
+// UpdateChannel replaces a notification channel's settings
+func (s *NotificationService) UpdateChannel(id uint, userID uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&channel).Error; err != nil {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+	if err := applyChannelRequest(&channel, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&channel).Error; err != nil {
+		return nil, err
+	}
+	decodeChannels(&channel)
+	return &channel, nil
+}
+
+// DeleteChannel removes a notification channel
+func (s *NotificationService) DeleteChannel(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.NotificationChannel{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+
+	// Drop the channel from the user's preferences
+	settings := s.userSettings(userID)
+	prefs := slices.DeleteFunc(settings.NotificationPreferences, func(pref models.NotificationPreference) bool {
+		return pref.ChannelID == id
+	})
+	if len(prefs) != len(settings.NotificationPreferences) {
+		s.UpdatePreferences(userID, prefs)
+	}
+	return result.Error
+}
+
+// TestChannel sends a test message through a channel, enabled or not
+func (s *NotificationService) TestChannel(id uint, userID uint) error {
+	var channel models.NotificationChannel
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&channel).Error; err != nil {
+		return fmt.Errorf("notification channel not found")
+	}
+
+	return s.deliver(channel, models.NotificationMessage{
+		Title:    "Test notification",
+		Message:  fmt.Sprintf("This is a test of the %q channel from Homelab Monitor", channel.Name),
+		Severity: models.SeverityInfo,
+		State:    "test",
+		Source:   "notifications",
+		Time:     time.Now(),
+	})
+}
+
+// notifyAlerts sends a user's alerts to each of their matching channels,
+// one message per dependency group
+func (s *NotificationService) notifyAlerts(userID uint, alerts []models.Alert) {
+	var channels []models.NotificationChannel
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).Find(&channels).Error; err != nil {
+		log.Printf("Failed to load notification channels: %v", err)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	groups := s.groupAlerts(alerts)
+	prefs := s.channelPreferences(userID)
+	for _, channel := range channels {
+		minSeverity := channel.MinSeverity
+		if pref, ok := prefs[channel.ID]; ok {
+			if !slices.Contains(pref.Categories, models.NotificationCategoryAlerts) {
+				continue
+			}
+			minSeverity = pref.MinSeverity
+		}
+		for _, group := range groups {
+			matching := make([]models.Alert, 0, len(group.alerts))
+			for _, alert := range group.alerts {
+				if severityRank[alert.Severity] < severityRank[minSeverity] {
+					continue
+				}
+				if alert.State == models.AlertResolved && !channel.SendResolved {
+					continue
+				}
+				matching = append(matching, alert)
+			}
+			if len(matching) == 0 {
+				continue
+			}
+			if err := s.deliver(channel, groupNotification(group.name, matching)); err != nil {
+				log.Printf("Notification channel %d (%s) failed: %v", channel.ID, channel.Type, err)
+			}
+		}
+	}
+}
+
+// deliver renders and sends a message through a channel and records the outcome
+func (s *NotificationService) deliver(channel models.NotificationChannel, msg models.NotificationMessage) error {
+	err := s.send(channel, msg)
+
+	updates := map[string]interface{}{"last_error": ""}
+	if err != nil {
+		errText := err.Error()
+		if len(errText) > 1000 {
+			errText = errText[:1000]
+		}
+		updates["last_error"] = errText
+	} else {
+		updates["last_sent_at"] = time.Now()
+	}
+	s.db.Model(&models.NotificationChannel{}).Where("id = ?", channel.ID).Updates(updates)
+	return err
+}
+
+// send renders the channel's template and posts it
+func (s *NotificationService) send(channel models.NotificationChannel, msg models.NotificationMessage) error {
+	settings, err := channelSettings(channel)
+	if err != nil {
+		return err
+	}
+	t, ok := channelType(channel.Type)
+	if !ok {
+		return fmt.Errorf("unsupported channel type: %s", channel.Type)
+	}
+
+	source := channel.Template
+	if source == "" {
+		source = t.DefaultTemplate
+	}
+	tmpl, err := template.New("channel").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, msg); err != nil {
+		return fmt.Errorf("render template: %v", err)
+	}
+	text := rendered.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+	defer cancel()
+
+	switch channel.Type {
+	case models.ChannelTelegram:
+		body, _ := json.Marshal(map[string]interface{}{
+			"chat_id":                  settings["chatId"],
+			"text":                     truncateRunes(text, 4096),
+			"disable_web_page_preview": true,
+		})
+		return s.post(ctx, "POST", "https://api.telegram.org/bot"+settings["botToken"]+"/sendMessage", "application/json", nil, body)
+
+	case models.ChannelDiscord:
+		body, _ := json.Marshal(map[string]interface{}{
+			"username": settings["username"],
+			"content":  truncateRunes(text, 2000),
+		})
+		return s.post(ctx, "POST", settings["webhookUrl"], "application/json", nil, body)
+
+	case models.ChannelWebhook:
+		headers, err := parseHeaders(settings["headers"])
+		if err != nil {
+			return err
+		}
+		body := []byte(text)
+		if secret := settings["secret"]; secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			headers.Set("X-Homelab-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		return s.post(ctx, strings.ToUpper(settings["method"]), settings["url"], settings["contentType"], headers, body)
+	}
+	return nil
+}
+
+// post sends a request and reports non-2xx responses with the start of their body
+func (s *NotificationService) post(ctx context.Context, method, target, contentType string, headers http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// Telegram bot tokens are part of the URL; keep them out of errors
+		if urlErr, ok := err.(*url.Error); ok {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
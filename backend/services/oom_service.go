@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// OOMService detects processes and containers killed by the OOM killer,
+// from the kernel's oom_kill counter and log and from Docker oom events
+type OOMService struct {
+	db     *gorm.DB
+	docker *DockerService
+	events *EventService
+
+	mu       sync.Mutex
+	lastKill uint64          // last /proc/vmstat oom_kill value
+	seen     map[string]bool // kernel log lines already recorded
+}
+
+const (
+	oomPollInterval = 30 * time.Second
+	// oomMergeWindow is how close a Docker oom event and a kernel log entry
+	// for the same container must be to be merged into one record
+	oomMergeWindow = time.Minute
+)
+
+var (
+	oomKilledRe = regexp.MustCompile(`Killed process (\d+) \(([^)]+)\)`)
+	oomTaskRe   = regexp.MustCompile(`oom-kill:.*task_memcg=([^,\s]+).*task=([^,\s]+),pid=(\d+)`)
+	cgroupIDRe  = regexp.MustCompile(`(?:docker[-/]|/)([0-9a-f]{64})`)
+)
+
+// NewOOMService creates a new OOMService and starts the kernel and Docker watchers
+func NewOOMService(docker *DockerService, events *EventService) *OOMService {
+	s := &OOMService{
+		db:     database.GetDB(),
+		docker: docker,
+		events: events,
+		seen:   make(map[string]bool),
+	}
+
+	if count, ok := readOOMKillCount(); ok {
+		s.lastKill = count
+		go s.kernelBackground()
+	}
+	if docker.IsConnected() {
+		go s.dockerBackground()
+	}
+
+	return s
+}
+
+// List returns recent OOM kills, newest first, optionally for one container
+func (s *OOMService) List(limit int, container string) ([]models.OOMKill, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := s.db.Order("killed_at DESC").Limit(limit)
+	if container != "" {
+		query = query.Where("container = ? OR container_id = ?", container, container)
+	}
+
+	var kills []models.OOMKill
+	if err := query.Find(&kills).Error; err != nil {
+		return nil, err
+	}
+	return kills, nil
+}
+
+// readOOMKillCount reads the kernel's cumulative OOM kill counter (Linux 4.13+)
+func readOOMKillCount() (uint64, bool) {
+	file, err := os.Open("/proc/vmstat")
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.ParseUint(fields[1], 10, 64)
+			return count, err == nil
+		}
+	}
+	return 0, false
+}
+
+// kernelBackground polls the oom_kill counter and reads the kernel log when it grows
+func (s *OOMService) kernelBackground() {
+	ticker := time.NewTicker(oomPollInterval)
+	defer ticker.Stop()
+
+	// Mark existing log entries as seen so old kills are not reported at startup
+	for _, line := range kernelOOMLines() {
+		s.seen[line] = true
+	}
+
+	for range ticker.C {
+		count, ok := readOOMKillCount()
+		if !ok || count <= s.lastKill {
+			continue
+		}
+		kills := count - s.lastKill
+		s.lastKill = count
+
+		// A kill logs an "oom-kill:" line followed by "Killed process" for the same PID
+		found, lastPID := 0, -1
+		for _, line := range kernelOOMLines() {
+			if s.seen[line] {
+				continue
+			}
+			s.seen[line] = true
+			kill := parseOOMLine(line)
+			if kill == nil || kill.PID == lastPID {
+				continue
+			}
+			lastPID = kill.PID
+			s.resolveContainer(kill)
+			s.record(kill)
+			found++
+		}
+
+		// The kernel log may be unreadable without privileges; still report the kill
+		if found == 0 {
+			s.record(&models.OOMKill{
+				Scope:    models.OOMScopeHost,
+				Message:  fmt.Sprintf("%d process(es) killed by the OOM killer (kernel log unavailable)", kills),
+				KilledAt: time.Now(),
+			})
+		}
+
+		if len(s.seen) > 1000 {
+			s.seen = make(map[string]bool)
+		}
+	}
+}
+
+// kernelOOMLines returns recent OOM kill lines from the journal or kernel log files
+func kernelOOMLines() []string {
+	var lines []string
+	keep := func(line string) {
+		if strings.Contains(line, "Killed process") || strings.Contains(line, "oom-kill:") {
+			lines = append(lines, line)
+		}
+	}
+
+	if path, err := exec.LookPath("journalctl"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, path, "-k", "--since", "-1h", "--no-pager", "-o", "short-iso").Output()
+		if err == nil {
+			for _, line := range strings.Split(string(output), "\n") {
+				keep(line)
+			}
+			return lines
+		}
+	}
+
+	for _, path := range firewallLogFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			keep(scanner.Text())
+		}
+		file.Close()
+		if len(lines) > 200 {
+			lines = lines[len(lines)-200:]
+		}
+		return lines
+	}
+	return lines
+}
+
+// parseOOMLine extracts the killed process and cgroup from a kernel log line
+func parseOOMLine(line string) *models.OOMKill {
+	kill := &models.OOMKill{Scope: models.OOMScopeHost, Message: strings.TrimSpace(line), KilledAt: time.Now()}
+	if len(kill.Message) > 1000 {
+		kill.Message = kill.Message[:1000]
+	}
+
+	if m := oomTaskRe.FindStringSubmatch(line); m != nil {
+		kill.Cgroup = m[1]
+		kill.Process = m[2]
+		kill.PID, _ = strconv.Atoi(m[3])
+		return kill
+	}
+	if m := oomKilledRe.FindStringSubmatch(line); m != nil {
+		kill.PID, _ = strconv.Atoi(m[1])
+		kill.Process = m[2]
+		return kill
+	}
+	return nil
+}
+
+// resolveContainer maps a kill's cgroup to the Docker container it belongs to
+func (s *OOMService) resolveContainer(kill *models.OOMKill) {
+	m := cgroupIDRe.FindStringSubmatch(kill.Cgroup)
+	if m == nil {
+		return
+	}
+	kill.Scope = models.OOMScopeContainer
+	kill.ContainerID = m[1]
+	if c, err := s.docker.GetContainer(m[1]); err == nil {
+		kill.Container = c.Name
+	}
+}
+
+// dockerBackground listens for container oom events, reconnecting on errors
+func (s *OOMService) dockerBackground() {
+	for {
+		msgs, errs := s.docker.client.Events(context.Background(), types.EventsOptions{
+			Filters: filters.NewArgs(filters.Arg("type", "container"), filters.Arg("event", "oom")),
+		})
+
+	loop:
+		for {
+			select {
+			case msg := <-msgs:
+				s.record(&models.OOMKill{
+					Scope:       models.OOMScopeContainer,
+					Container:   msg.Actor.Attributes["name"],
+					ContainerID: msg.Actor.ID,
+					Message:     fmt.Sprintf("Container %s ran out of memory", msg.Actor.Attributes["name"]),
+					KilledAt:    time.Unix(0, msg.TimeNano),
+				})
+			case err := <-errs:
+				log.Printf("Docker event stream closed: %v", err)
+				break loop
+			}
+		}
+		time.Sleep(30 * time.Second)
+	}
+}
+
+// record stores a kill and raises an event. Container kills seen by both
+// the kernel log and Docker are merged into one record.
+func (s *OOMService) record(kill *models.OOMKill) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kill.ContainerID != "" {
+		var existing models.OOMKill
+		err := s.db.Where("container_id = ? AND killed_at >= ?", kill.ContainerID, kill.KilledAt.Add(-oomMergeWindow)).
+			Order("killed_at DESC").First(&existing).Error
+		if err == nil {
+			updates := map[string]interface{}{}
+			if existing.Process == "" && kill.Process != "" {
+				updates["process"] = kill.Process
+				updates["pid"] = kill.PID
+				updates["cgroup"] = kill.Cgroup
+				updates["message"] = kill.Message
+			}
+			if existing.Container == "" && kill.Container != "" {
+				updates["container"] = kill.Container
+			}
+			if len(updates) > 0 {
+				s.db.Model(&existing).Updates(updates)
+			}
+			return
+		}
+	}
+
+	if err := s.db.Create(kill).Error; err != nil {
+		log.Printf("Failed to record OOM kill: %v", err)
+		return
+	}
+
+	details := map[string]interface{}{
+		"process":     kill.Process,
+		"pid":         kill.PID,
+		"container":   kill.Container,
+		"containerId": kill.ContainerID,
+		"cgroup":      kill.Cgroup,
+	}
+	if kill.Scope == models.OOMScopeContainer {
+		name := kill.Container
+		if name == "" {
+			name = shortID(kill.ContainerID)
+		}
+		s.events.Record("oom_kill", models.SeverityCritical, "docker",
+			"Container killed by OOM killer",
+			fmt.Sprintf("Container %s ran out of memory%s", name, oomProcessSuffix(kill)), details)
+		return
+	}
+
+	s.events.Record("oom_kill", models.SeverityCritical, "system",
+		"Process killed by OOM killer", kill.Message, details)
+}
+
+// oomProcessSuffix describes the killed process, if known
+func oomProcessSuffix(kill *models.OOMKill) string {
+	if kill.Process == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (killed %s, pid %d)", kill.Process, kill.PID)
+}
+
+// shortID returns the first 12 characters of a container ID
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/homelab/backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// checkPasswordComplexity returns an error describing every rule the password breaks
+func checkPasswordComplexity(policy models.PasswordPolicy, password string) error {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if len([]rune(password)) < policy.MinLength {
+		missing = append(missing, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.RequireUpper && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLower && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: must contain %s", ErrPasswordPolicy, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// passwordReused reports whether password matches any of the given bcrypt hashes
+func passwordReused(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordExpired reports whether the user's password is older than the policy allows
+func passwordExpired(policy models.PasswordPolicy, user *models.User) bool {
+	if policy.ExpiryDays <= 0 {
+		return false
+	}
+	changed := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changed = *user.PasswordChangedAt
+	}
+	return time.Since(changed) > time.Duration(policy.ExpiryDays)*24*time.Hour
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// PiService reads Raspberry Pi throttling, voltage and temperature through
+// vcgencmd and records events when under-voltage or throttling starts
+type PiService struct {
+	vcgencmd string
+	events   *EventService
+
+	mu   sync.Mutex
+	last *models.PiFlags
+}
+
+// NewPiService creates a new PiService and starts the monitor when vcgencmd is available
+func NewPiService(events *EventService) *PiService {
+	s := &PiService{events: events}
+
+	if path, err := exec.LookPath("vcgencmd"); err == nil {
+		s.vcgencmd = path
+		log.Println("Raspberry Pi monitoring enabled (vcgencmd found)")
+		go s.monitorBackground()
+	}
+
+	return s
+}
+
+// IsAvailable returns true when running on a Raspberry Pi with vcgencmd
+func (s *PiService) IsAvailable() bool {
+	return s.vcgencmd != ""
+}
+
+// run executes a vcgencmd subcommand and returns the value after "="
+func (s *PiService) run(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, s.vcgencmd, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("vcgencmd %s: %v", strings.Join(args, " "), err)
+	}
+	// Output looks like throttled=0x50005, volt=0.8500V or temp=48.3'C
+	value := strings.TrimSpace(string(output))
+	if i := strings.Index(value, "="); i >= 0 {
+		value = value[i+1:]
+	}
+	return value, nil
+}
+
+// GetMetrics reads the current throttling flags, core voltage and SoC temperature
+func (s *PiService) GetMetrics() (*models.PiMetrics, error) {
+	if !s.IsAvailable() {
+		return nil, fmt.Errorf("vcgencmd not found")
+	}
+
+	throttled, err := s.run("get_throttled")
+	if err != nil {
+		return nil, err
+	}
+	mask, err := strconv.ParseUint(strings.TrimPrefix(throttled, "0x"), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected get_throttled output %q", throttled)
+	}
+
+	metrics := &models.PiMetrics{
+		Throttled: throttled,
+		Flags:     decodePiThrottled(mask),
+		CheckedAt: time.Now(),
+	}
+
+	if volts, err := s.run("measure_volts", "core"); err == nil {
+		metrics.CoreVoltage, _ = strconv.ParseFloat(strings.TrimSuffix(volts, "V"), 64)
+	}
+	metrics.Temperature = s.temperature()
+
+	return metrics, nil
+}
+
+// temperature reads the SoC temperature, falling back to the thermal zone
+func (s *PiService) temperature() float64 {
+	if temp, err := s.run("measure_temp"); err == nil {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(temp, "'C"), 64); err == nil {
+			return v
+		}
+	}
+	if data, err := os.ReadFile("/sys/class/thermal/thermal_zone0/temp"); err == nil {
+		if milli, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return float64(milli) / 1000
+		}
+	}
+	return 0
+}
+
+// decodePiThrottled maps the get_throttled bitmask to flags
+func decodePiThrottled(mask uint64) models.PiFlags {
+	bit := func(n uint) bool { return mask&(1<<n) != 0 }
+	return models.PiFlags{
+		UnderVoltage:         bit(0),
+		FrequencyCapped:      bit(1),
+		Throttled:            bit(2),
+		SoftTempLimit:        bit(3),
+		UnderVoltageOccurred: bit(16),
+		FrequencyOccurred:    bit(17),
+		ThrottledOccurred:    bit(18),
+		SoftTempOccurred:     bit(19),
+	}
+}
+
+// monitorBackground checks the throttling flags every minute
+func (s *PiService) monitorBackground() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		metrics, err := s.GetMetrics()
+		if err != nil {
+			log.Printf("Raspberry Pi check failed: %v", err)
+		} else {
+			s.recordTransitions(metrics)
+		}
+		<-ticker.C
+	}
+}
+
+// recordTransitions records an event when under-voltage or throttling starts or clears
+func (s *PiService) recordTransitions(metrics *models.PiMetrics) {
+	s.mu.Lock()
+	previous := s.last
+	flags := metrics.Flags
+	s.last = &flags
+	s.mu.Unlock()
+
+	details := map[string]interface{}{
+		"throttled":   metrics.Throttled,
+		"coreVoltage": metrics.CoreVoltage,
+		"temperature": metrics.Temperature,
+	}
+	wasUnder := previous != nil && previous.UnderVoltage
+	wasThrottled := previous != nil && previous.Throttled
+
+	if flags.UnderVoltage && !wasUnder {
+		s.events.Record("pi_under_voltage", models.SeverityCritical, "system",
+			"Under-voltage detected",
+			fmt.Sprintf("The Raspberry Pi power supply is too weak (core %.2fV)", metrics.CoreVoltage), details)
+	} else if !flags.UnderVoltage && wasUnder {
+		s.events.Record("pi_under_voltage_cleared", models.SeverityInfo, "system",
+			"Under-voltage cleared", "The Raspberry Pi supply voltage is back to normal", details)
+	}
+
+	if flags.Throttled && !wasThrottled {
+		s.events.Record("pi_throttled", models.SeverityWarning, "system",
+			"CPU throttled",
+			fmt.Sprintf("The Raspberry Pi is throttling its CPU (SoC %.1f°C)", metrics.Temperature), details)
+	} else if !flags.Throttled && wasThrottled {
+		s.events.Record("pi_throttled_cleared", models.SeverityInfo, "system",
+			"CPU throttling cleared", "The Raspberry Pi is running at full speed again", details)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// PowerSequenceService runs ordered "shutdown lab" and "start lab" sequences
+type PowerSequenceService struct {
+	db      *gorm.DB
+	devices *DeviceService
+	docker  *DockerService
+	audit   *AuditService
+	events  *EventService
+
+	mu      sync.Mutex
+	running map[uint]bool
+}
+
+// Power sequence step defaults
+const (
+	defaultPowerStepTimeout = 120 * time.Second
+	powerVerifyInterval     = 5 * time.Second
+)
+
+// NewPowerSequenceService creates a new PowerSequenceService
+func NewPowerSequenceService(devices *DeviceService, docker *DockerService, audit *AuditService, events *EventService) *PowerSequenceService {
+	return &PowerSequenceService{
+		db:      database.GetDB(),
+		devices: devices,
+		docker:  docker,
+		audit:   audit,
+		events:  events,
+		running: make(map[uint]bool),
+	}
+}
+
+// decodeSequences fills Steps and LastLog from their JSON columns
+func decodeSequences(sequences ...*models.PowerSequence) {
+	for _, seq := range sequences {
+		seq.Steps = make([]models.PowerStep, 0)
+		seq.LastLog = make([]models.PowerStepResult, 0)
+		if seq.RawSteps != "" {
+			json.Unmarshal([]byte(seq.RawSteps), &seq.Steps)
+		}
+		if seq.RawLastLog != "" {
+			json.Unmarshal([]byte(seq.RawLastLog), &seq.LastLog)
+		}
+	}
+}
+
+// validate checks a sequence's steps against the user's devices
+func (s *PowerSequenceService) validate(userID uint, req models.PowerSequenceRequest) error {
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("a power sequence needs at least one step")
+	}
+
+	for i, step := range req.Steps {
+		n := i + 1
+		switch step.Action {
+		case models.PowerStepStopContainer:
+			if strings.TrimSpace(step.Target) == "" {
+				return fmt.Errorf("step %d: stop_container requires a container name or label:key=value", n)
+			}
+		case models.PowerStepShutdownDevice:
+			var count int64
+			s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", step.Target, userID).Count(&count)
+			if count == 0 {
+				return fmt.Errorf("step %d: shutdown_device requires the ID of one of your devices", n)
+			}
+			if step.StartMethod != "" && step.StartMethod != PowerOnWOL && step.StartMethod != PowerOnIPMI {
+				return fmt.Errorf("step %d: start method must be wol or ipmi", n)
+			}
+		case models.PowerStepRunTask:
+			if strings.TrimSpace(step.Target) == "" {
+				return fmt.Errorf("step %d: run_task requires a command", n)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", n, step.Action)
+		}
+
+		if step.Wait < 0 || step.Timeout < 0 {
+			return fmt.Errorf("step %d: wait and timeout must not be negative", n)
+		}
+	}
+	return nil
+}
+
+// List returns the user's power sequences
+func (s *PowerSequenceService) List(userID uint) ([]models.PowerSequence, error) {
+	var sequences []models.PowerSequence
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&sequences).Error; err != nil {
+		return nil, err
+	}
+	for i := range sequences {
+		decodeSequences(&sequences[i])
+	}
+	return sequences, nil
+}
+
+// Get returns a power sequence with its last run log
+func (s *PowerSequenceService) Get(id uint, userID uint) (*models.PowerSequence, error) {
+	var seq models.PowerSequence
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&seq).Error; err != nil {
+		return nil, fmt.Errorf("power sequence not found")
+	}
+	decodeSequences(&seq)
+	return &seq, nil
+}
+
+// Create adds a power sequence
+func (s *PowerSequenceService) Create(userID uint, req models.PowerSequenceRequest) (*models.PowerSequence, error) {
+	if err := s.validate(userID, req); err != nil {
+		return nil, err
+	}
+
+	steps, err := json.Marshal(req.Steps)
+	if err != nil {
+		return nil, err
+	}
+	seq := models.PowerSequence{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		RawSteps:    string(steps),
+	}
+	if err := s.db.Create(&seq).Error; err != nil {
+		return nil, err
+	}
+	decodeSequences(&seq)
+	return &seq, nil
+}
+
+// Update replaces a power sequence's name and steps
+func (s *PowerSequenceService) Update(id uint, userID uint, req models.PowerSequenceRequest) (*models.PowerSequence, error) {
+	seq, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(userID, req); err != nil {
+		return nil, err
+	}
+
+	steps, err := json.Marshal(req.Steps)
+	if err != nil {
+		return nil, err
+	}
+	seq.Name = req.Name
+	seq.Description = req.Description
+	seq.RawSteps = string(steps)
+	if err := s.db.Save(seq).Error; err != nil {
+		return nil, err
+	}
+	decodeSequences(seq)
+	return seq, nil
+}
+
+// Delete removes a power sequence
+func (s *PowerSequenceService) Delete(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PowerSequence{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("power sequence not found")
+	}
+	return result.Error
+}
+
+// Run starts a sequence in the background: shutdown runs the steps in order,
+// start runs them in reverse. It stops at the first step that fails.
+func (s *PowerSequenceService) Run(id uint, userID uint, direction string) (*models.PowerSequence, error) {
+	seq, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if direction != models.PowerDirectionShutdown && direction != models.PowerDirectionStart {
+		return nil, fmt.Errorf("unknown direction %q", direction)
+	}
+
+	s.mu.Lock()
+	if s.running[seq.ID] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("power sequence already running")
+	}
+	s.running[seq.ID] = true
+	s.mu.Unlock()
+
+	now := time.Now()
+	seq.LastDirection = direction
+	seq.LastStatus = models.PowerRunRunning
+	seq.LastRunAt = &now
+	seq.LastLog = make([]models.PowerStepResult, 0)
+	seq.RawLastLog = ""
+	s.db.Model(seq).Updates(map[string]interface{}{
+		"last_direction": direction,
+		"last_status":    models.PowerRunRunning,
+		"last_run_at":    now,
+		"last_log":       "",
+	})
+
+	s.events.RecordFor(seq.UserID, "power_sequence", models.SeverityWarning, "power", fmt.Sprintf("Lab %s started", direction),
+		fmt.Sprintf("%s: %d steps", seq.Name, len(seq.Steps)),
+		map[string]interface{}{"sequenceId": seq.ID, "direction": direction})
+
+	go s.execute(*seq, direction)
+	return seq, nil
+}
+
+// execute runs each step, verifying it before waiting and moving on, and
+// saves the log after every step so progress can be polled
+func (s *PowerSequenceService) execute(seq models.PowerSequence, direction string) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, seq.ID)
+		s.mu.Unlock()
+	}()
+
+	order := make([]int, len(seq.Steps))
+	for i := range order {
+		order[i] = i
+		if direction == models.PowerDirectionStart {
+			order[i] = len(seq.Steps) - 1 - i
+		}
+	}
+
+	results := make([]models.PowerStepResult, 0, len(order))
+	status := models.PowerRunCompleted
+	for _, i := range order {
+		step := seq.Steps[i]
+		result := models.PowerStepResult{Step: i + 1, Name: step.Name, Action: step.Action, StartedAt: time.Now()}
+
+		message, skipped, err := s.runStep(seq.UserID, step, direction)
+		result.Duration = time.Since(result.StartedAt).Seconds()
+		result.Success = err == nil
+		result.Skipped = skipped
+		result.Message = message
+		if err != nil {
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+
+		data, _ := json.Marshal(results)
+		s.db.Model(&seq).Update("last_log", string(data))
+
+		if err != nil {
+			status = models.PowerRunFailed
+			break
+		}
+		if step.Wait > 0 && !skipped {
+			time.Sleep(time.Duration(step.Wait) * time.Second)
+		}
+	}
+
+	s.db.Model(&seq).Update("last_status", status)
+
+	success := status == models.PowerRunCompleted
+	s.audit.Record(seq.UserID, fmt.Sprintf("user:%d", seq.UserID), "power_sequence."+direction, "power_sequence",
+		strconv.FormatUint(uint64(seq.ID), 10), success, map[string]interface{}{"sequence": seq.Name, "log": results})
+
+	severity := models.SeverityInfo
+	title := fmt.Sprintf("Lab %s completed", direction)
+	message := fmt.Sprintf("%s: %d steps", seq.Name, len(results))
+	if !success {
+		last := results[len(results)-1]
+		severity = models.SeverityCritical
+		title = fmt.Sprintf("Lab %s failed", direction)
+		message = fmt.Sprintf("%s: step %d (%s) failed: %s", seq.Name, last.Step, last.Name, last.Message)
+	}
+	s.events.RecordFor(seq.UserID, "power_sequence", severity, "power", title, message,
+		map[string]interface{}{"sequenceId": seq.ID, "direction": direction})
+}
+
+// runStep performs one step in the given direction and verifies the result.
+// It returns whether the step had nothing to do.
+func (s *PowerSequenceService) runStep(userID uint, step models.PowerStep, direction string) (string, bool, error) {
+	timeout := defaultPowerStepTimeout
+	if step.Timeout > 0 {
+		timeout = time.Duration(step.Timeout) * time.Second
+	}
+	starting := direction == models.PowerDirectionStart
+
+	switch step.Action {
+	case models.PowerStepStopContainer:
+		c, err := s.docker.FindContainer(step.Target)
+		if err != nil {
+			return "", false, err
+		}
+		if (c.State == "running") == starting {
+			return fmt.Sprintf("container %s already %s", c.Name, c.State), true, nil
+		}
+		if starting {
+			err = s.docker.StartContainer(c.ID)
+		} else {
+			err = s.docker.StopContainer(c.ID)
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if err := waitFor(timeout, func() bool {
+			current, err := s.docker.GetContainer(c.ID)
+			return err == nil && (current.State == "running") == starting
+		}); err != nil {
+			if starting {
+				return "", false, fmt.Errorf("container %s was not running within %s", c.Name, timeout)
+			}
+			return "", false, fmt.Errorf("container %s was still running after %s", c.Name, timeout)
+		}
+		if starting {
+			return fmt.Sprintf("started container %s", c.Name), false, nil
+		}
+		return fmt.Sprintf("stopped container %s", c.Name), false, nil
+
+	case models.PowerStepShutdownDevice:
+		deviceID, err := strconv.ParseUint(step.Target, 10, 32)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid device ID %q", step.Target)
+		}
+		id := uint(deviceID)
+		online, err := s.devices.PingDevice(id, userID)
+		if err != nil {
+			return "", false, err
+		}
+		if online && starting {
+			return fmt.Sprintf("device %d already online", id), true, nil
+		}
+		if !online && !starting {
+			return fmt.Sprintf("device %d already offline", id), true, nil
+		}
+
+		message := "sent shutdown"
+		if starting {
+			message, err = s.devices.PowerOnDevice(id, userID, step.StartMethod)
+		} else {
+			err = s.devices.ShutdownDevice(id, userID)
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if err := waitFor(timeout, func() bool {
+			online, err := s.devices.PingDevice(id, userID)
+			return err == nil && online == starting
+		}); err != nil {
+			if starting {
+				return "", false, fmt.Errorf("%s, but device %d was not online within %s", message, id, timeout)
+			}
+			return "", false, fmt.Errorf("%s, but device %d was still online after %s", message, id, timeout)
+		}
+		if starting {
+			return message + ", device is online", false, nil
+		}
+		return message + ", device is offline", false, nil
+
+	case models.PowerStepRunTask:
+		command := step.Target
+		if starting {
+			command = step.StartTarget
+		}
+		if strings.TrimSpace(command) == "" {
+			return "no start command", true, nil
+		}
+		out, err := runSequenceTask(command, timeout)
+		return out, false, err
+	}
+
+	return "", false, fmt.Errorf("unknown action %q", step.Action)
+}
+
+// waitFor polls check until it passes or the timeout expires
+func waitFor(timeout time.Duration, check func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if check() {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out")
+		}
+		time.Sleep(powerVerifyInterval)
+	}
+}
+
+// runSequenceTask runs a shell command, e.g. a switch CLI call toggling PoE
+func runSequenceTask(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	output, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		if out != "" {
+			return "", fmt.Errorf("%v: %s", err, out)
+		}
+		return "", err
+	}
+	if out == "" {
+		out = "task completed"
+	}
+	return out, nil
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ProbeService manages remote probe agents, their check assignments and reported results
+type ProbeService struct {
+	db *gorm.DB
+}
+
+// ProbeTokenPrefix marks probe agent tokens
+const ProbeTokenPrefix = "hlp_"
+
+// LocalLocation names checks run by the backend itself
+const LocalLocation = "local"
+
+// NewProbeService creates a new ProbeService
+func NewProbeService() *ProbeService {
+	return &ProbeService{db: database.GetDB()}
+}
+
+// ParseLocations splits a comma separated location list
+func ParseLocations(value string) []string {
+	locations := make([]string, 0)
+	for _, l := range strings.Split(value, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			locations = append(locations, l)
+		}
+	}
+	return locations
+}
+
+func hasLocation(value, location string) bool {
+	for _, l := range ParseLocations(value) {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAgents returns all probe agents
+func (s *ProbeService) ListAgents() ([]models.ProbeAgent, error) {
+	var agents []models.ProbeAgent
+	if err := s.db.Order("location ASC, name ASC").Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// CreateAgent registers a probe agent and returns its token once
+func (s *ProbeService) CreateAgent(req models.CreateProbeAgentRequest) (*models.ProbeAgentResponse, error) {
+	location := strings.TrimSpace(req.Location)
+	if location == LocalLocation || strings.Contains(location, ",") {
+		return nil, fmt.Errorf("invalid location name: %s", req.Location)
+	}
+
+	token, hash := newToken(ProbeTokenPrefix)
+	agent := models.ProbeAgent{
+		Name:      req.Name,
+		Location:  location,
+		TokenHash: hash,
+		Prefix:    token[:len(ProbeTokenPrefix)+6],
+		Enabled:   true,
+	}
+	if err := s.db.Create(&agent).Error; err != nil {
+		return nil, err
+	}
+	return &models.ProbeAgentResponse{ProbeAgent: agent, Token: token}, nil
+}
+
+// UpdateAgent renames or enables/disables a probe agent
+func (s *ProbeService) UpdateAgent(id uint, name *string, enabled *bool) (*models.ProbeAgent, error) {
+	var agent models.ProbeAgent
+	if err := s.db.First(&agent, id).Error; err != nil {
+		return nil, fmt.Errorf("probe agent not found")
+	}
+
+	updates := make(map[string]interface{})
+	if name != nil && *name != "" {
+		updates["name"] = *name
+	}
+	if enabled != nil {
+		updates["enabled"] = *enabled
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(&agent).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &agent, nil
+}
+
+// RotateAgent issues a new token for a probe agent
+func (s *ProbeService) RotateAgent(id uint) (*models.ProbeAgentResponse, error) {
+	var agent models.ProbeAgent
+	if err := s.db.First(&agent, id).Error; err != nil {
+		return nil, fmt.Errorf("probe agent not found")
+	}
+
+	token, hash := newToken(ProbeTokenPrefix)
+	if err := s.db.Model(&agent).Updates(map[string]interface{}{
+		"token_hash": hash,
+		"prefix":     token[:len(ProbeTokenPrefix)+6],
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &models.ProbeAgentResponse{ProbeAgent: agent, Token: token}, nil
+}
+
+// DeleteAgent removes a probe agent
+func (s *ProbeService) DeleteAgent(id uint) error {
+	result := s.db.Delete(&models.ProbeAgent{}, id)
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("probe agent not found")
+	}
+	return result.Error
+}
+
+// Authenticate returns the enabled agent owning the token
+func (s *ProbeService) Authenticate(token string) (*models.ProbeAgent, error) {
+	if !strings.HasPrefix(token, ProbeTokenPrefix) {
+		return nil, fmt.Errorf("not a probe token")
+	}
+
+	var agent models.ProbeAgent
+	if err := s.db.Where("token_hash = ? AND enabled = ?", hashToken(token), true).First(&agent).Error; err != nil {
+		return nil, fmt.Errorf("invalid or disabled probe token")
+	}
+
+	now := time.Now()
+	s.db.Model(&agent).Update("last_seen_at", now)
+	agent.LastSeenAt = &now
+	return &agent, nil
+}
+
+// Assignments returns the active services the agent's location should check
+func (s *ProbeService) Assignments(agent *models.ProbeAgent) ([]models.ProbeCheck, error) {
+	var services []models.ServiceConfig
+	if err := s.db.Where("is_active = ? AND locations <> ?", true, "").Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	checks := make([]models.ProbeCheck, 0)
+	for _, svc := range services {
+		if !hasLocation(svc.Locations, agent.Location) {
+			continue
+		}
+		check := models.ProbeCheck{
+			ServiceID:    svc.ID,
+			Name:         svc.Name,
+			URL:          svc.URL,
+			Method:       svc.Method,
+			Port:         svc.Port,
+			Timeout:      svc.Timeout,
+			ExpectedCode: svc.ExpectedCode,
+
+			TLSSkipVerify: svc.TLSSkipVerify,
+			TLSServerName: svc.TLSServerName,
+			CABundle:      svc.CABundle,
+		}
+		if svc.ClientCert != "" {
+			// Agents have no encryption key, so hand them the decrypted key
+			key, err := DecryptSecret(svc.ClientKey)
+			if err != nil {
+				log.Printf("Failed to decrypt client key for service %d: %v", svc.ID, err)
+			} else {
+				check.ClientCert = svc.ClientCert
+				check.ClientKey = key
+			}
+		}
+		if svc.DBPassword != "" {
+			password, err := DecryptSecret(svc.DBPassword)
+			if err != nil {
+				log.Printf("Failed to decrypt database password for service %d: %v", svc.ID, err)
+			} else {
+				check.DBPassword = password
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// Report stores results from an agent, ignoring services not assigned to its location
+func (s *ProbeService) Report(agent *models.ProbeAgent, report models.ProbeReport) (int, error) {
+	if report.Version != "" && report.Version != agent.Version {
+		s.db.Model(agent).Update("version", report.Version)
+	}
+
+	assigned, err := s.Assignments(agent)
+	if err != nil {
+		return 0, err
+	}
+	allowed := make(map[uint]bool, len(assigned))
+	for _, check := range assigned {
+		allowed[check.ServiceID] = true
+	}
+
+	checks := make([]models.ServiceCheck, 0, len(report.Results))
+	for _, r := range report.Results {
+		if !allowed[r.ServiceID] {
+			continue
+		}
+		checkedAt := r.CheckedAt
+		if checkedAt.IsZero() || checkedAt.After(time.Now().Add(time.Minute)) {
+			checkedAt = time.Now()
+		}
+
+		check := models.ServiceCheck{
+			ServiceID:    r.ServiceID,
+			Status:       r.Status,
+			StatusCode:   r.StatusCode,
+			ResponseTime: r.ResponseTime,
+			Error:        r.Error,
+			Location:     agent.Location,
+			CheckedAt:    checkedAt,
+		}
+		if len(check.Error) > 500 {
+			check.Error = check.Error[:500]
+		}
+		if r.Timings != nil {
+			check.DNSLookup = r.Timings.DNSLookup
+			check.TCPConnect = r.Timings.TCPConnect
+			check.TLSHandshake = r.Timings.TLSHandshake
+			check.TTFB = r.Timings.TTFB
+		}
+		checks = append(checks, check)
+	}
+
+	if len(checks) == 0 {
+		return 0, nil
+	}
+	if err := s.db.Create(&checks).Error; err != nil {
+		return 0, err
+	}
+	return len(checks), nil
+}
+
+// SelectedLocations returns every location that checks a service,
+// including "local" for the backend unless it is skipped
+func SelectedLocations(svc models.ServiceConfig) []string {
+	locations := make([]string, 0)
+	if !svc.SkipLocal {
+		locations = append(locations, LocalLocation)
+	}
+	return append(locations, ParseLocations(svc.Locations)...)
+}
+
+// HasRemoteLocations returns true if probe agents also check the service
+func HasRemoteLocations(svc models.ServiceConfig) bool {
+	return len(ParseLocations(svc.Locations)) > 0
+}
+
+// computeConsensus evaluates the latest result of every selected location.
+// The service is offline once at least MinFailingLocations recent results fail,
+// and degraded when some, but fewer, locations fail.
+func computeConsensus(db *gorm.DB, svc models.ServiceConfig) models.ConsensusStatus {
+	required := svc.MinFailingLocations
+	if required < 1 {
+		required = 1
+	}
+
+	// Results older than a few check intervals no longer count
+	freshness := 3 * time.Duration(svc.CheckInterval) * time.Second
+	if freshness < 5*time.Minute {
+		freshness = 5 * time.Minute
+	}
+	since := time.Now().Add(-freshness)
+
+	consensus := models.ConsensusStatus{Required: required, Locations: []models.LocationStatus{}}
+	for _, location := range SelectedLocations(svc) {
+		status := models.LocationStatus{Location: location, Status: "unknown"}
+		column := location
+		if location == LocalLocation {
+			column = ""
+		}
+
+		var check models.ServiceCheck
+		if err := db.Where("service_id = ? AND location = ?", svc.ID, column).
+			Order("checked_at DESC").First(&check).Error; err == nil {
+			status.Status = check.Status
+			status.StatusCode = check.StatusCode
+			status.ResponseTime = check.ResponseTime
+			status.Error = check.Error
+			status.CheckedAt = check.CheckedAt
+
+			if check.CheckedAt.After(since) {
+				consensus.Reporting++
+				if check.Status != "online" {
+					consensus.Failing++
+				}
+			}
+		}
+		consensus.Locations = append(consensus.Locations, status)
+	}
+
+	switch {
+	case consensus.Reporting == 0:
+		consensus.Status = "unknown"
+	case consensus.Failing >= required:
+		consensus.Status = "offline"
+	case consensus.Failing > 0:
+		consensus.Status = "degraded"
+	default:
+		consensus.Status = "online"
+	}
+	return consensus
+}
+
+// LocationStatuses returns the per-location results and consensus for a user's service
+func (s *ProbeService) LocationStatuses(serviceID, userID uint) (*models.ConsensusStatus, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", serviceID, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	consensus := computeConsensus(s.db, svc)
+	return &consensus, nil
+}
+
+// SetLocations selects which locations check a user's service ("local" is the
+// backend itself) and how many must fail before the service is marked down
+func (s *ProbeService) SetLocations(serviceID, userID uint, locations []string, minFailing int) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", serviceID, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	skipLocal := true
+	remote := make([]string, 0, len(locations))
+	seen := make(map[string]bool)
+	for _, l := range locations {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] || strings.Contains(l, ",") {
+			continue
+		}
+		seen[l] = true
+		if l == LocalLocation {
+			skipLocal = false
+			continue
+		}
+		remote = append(remote, l)
+	}
+
+	total := len(remote)
+	if !skipLocal {
+		total++
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one location is required")
+	}
+	if minFailing < 1 {
+		minFailing = 1
+	}
+	if minFailing > total {
+		return nil, fmt.Errorf("minFailingLocations (%d) cannot exceed the number of locations (%d)", minFailing, total)
+	}
+
+	if err := s.db.Model(&svc).Updates(map[string]interface{}{
+		"locations":             strings.Join(remote, ","),
+		"skip_local":            skipLocal,
+		"min_failing_locations": minFailing,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// PrometheusService renders the backend's metrics in the Prometheus text
+// exposition format: the host, its containers, service checks and devices
+type PrometheusService struct {
+	db       *gorm.DB
+	metrics  *MetricsService
+	docker   *DockerService
+	services *ServiceConfigService
+}
+
+// PrometheusContentType is the content type of the text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// NewPrometheusService creates a new PrometheusService
+func NewPrometheusService(metrics *MetricsService, docker *DockerService, services *ServiceConfigService) *PrometheusService {
+	return &PrometheusService{
+		db:       database.GetDB(),
+		metrics:  metrics,
+		docker:   docker,
+		services: services,
+	}
+}
+
+// promFamily is a metric and its samples
+type promFamily struct {
+	name, kind, help string
+	samples          []promSample
+}
+
+// promSample is one labelled value; labels alternate names and values
+type promSample struct {
+	labels []string
+	value  float64
+}
+
+// promSet collects families in the order they are first added
+type promSet struct {
+	families []*promFamily
+	byName   map[string]*promFamily
+}
+
+// add records a sample of the named gauge or counter
+func (p *promSet) add(name, kind, help string, value float64, labels ...string) {
+	family, ok := p.byName[name]
+	if !ok {
+		family = &promFamily{name: name, kind: kind, help: help}
+		p.families = append(p.families, family)
+		p.byName[name] = family
+	}
+	family.samples = append(family.samples, promSample{labels: labels, value: value})
+}
+
+// Write renders every family. Sections whose source fails are left out, so
+// one unreachable source doesn't fail the scrape.
+func (s *PrometheusService) Write(w io.Writer) error {
+	set := &promSet{byName: make(map[string]*promFamily)}
+	s.addSystem(set)
+	s.addContainers(set)
+	s.addServices(set)
+	s.addDevices(set)
+	return set.write(w)
+}
+
+// write renders the families in the text format
+func (p *promSet) write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	for _, family := range p.families {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind)
+		for _, sample := range family.samples {
+			out.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				out.WriteByte('{')
+				for i := 0; i+1 < len(sample.labels); i += 2 {
+					if i > 0 {
+						out.WriteByte(',')
+					}
+					fmt.Fprintf(out, "%s=\"%s\"", sample.labels[i], promEscaper.Replace(sample.labels[i+1]))
+				}
+				out.WriteByte('}')
+			}
+			out.WriteByte(' ')
+			out.WriteString(strconv.FormatFloat(sample.value, 'g', -1, 64))
+			out.WriteByte('\n')
+		}
+	}
+	return out.Flush()
+}
+
+// promEscaper escapes label values
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promBool is 1 for true and 0 for false
+func promBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// addSystem adds the backend host's CPU, memory, disk and network metrics
+func (s *PrometheusService) addSystem(set *promSet) {
+	m, err := s.metrics.GetSystemMetrics()
+	if err != nil {
+		log.Printf("Prometheus: failed to get system metrics: %v", err)
+		return
+	}
+
+	set.add("homelab_cpu_usage_percent", "gauge", "CPU usage of the host.", m.CPU.UsagePercent)
+	for i, usage := range m.CPU.PerCoreUsage {
+		set.add("homelab_cpu_core_usage_percent", "gauge", "CPU usage per logical core.", usage, "core", strconv.Itoa(i))
+	}
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(m.CPU.LoadAverage) {
+			set.add("homelab_load_average", "gauge", "System load average.", m.CPU.LoadAverage[i], "period", period)
+		}
+	}
+	if m.CPU.Temperature > 0 {
+		set.add("homelab_cpu_temperature_celsius", "gauge", "CPU temperature.", m.CPU.Temperature)
+	}
+	set.add("homelab_uptime_seconds", "gauge", "Time since the host booted.", float64(m.Uptime))
+
+	set.add("homelab_memory_total_bytes", "gauge", "Physical memory.", float64(m.Memory.Total))
+	set.add("homelab_memory_used_bytes", "gauge", "Physical memory in use.", float64(m.Memory.Used))
+	set.add("homelab_memory_available_bytes", "gauge", "Memory available without swapping.", float64(m.Memory.Available))
+	set.add("homelab_swap_total_bytes", "gauge", "Swap space.", float64(m.Memory.SwapTotal))
+	set.add("homelab_swap_used_bytes", "gauge", "Swap space in use.", float64(m.Memory.SwapUsed))
+
+	for _, d := range m.Disk {
+		labels := []string{"mountpoint", d.MountPoint, "device", d.Device, "fstype", d.Fstype}
+		set.add("homelab_disk_total_bytes", "gauge", "Filesystem size.", float64(d.Total), labels...)
+		set.add("homelab_disk_used_bytes", "gauge", "Filesystem space in use.", float64(d.Used), labels...)
+		set.add("homelab_disk_free_bytes", "gauge", "Filesystem space free.", float64(d.Free), labels...)
+	}
+
+	for _, n := range m.Network {
+		labels := []string{"interface", n.Interface}
+		set.add("homelab_network_receive_bytes_total", "counter", "Bytes received on the interface.", float64(n.BytesRecv), labels...)
+		set.add("homelab_network_transmit_bytes_total", "counter", "Bytes sent on the interface.", float64(n.BytesSent), labels...)
+		set.add("homelab_network_receive_errors_total", "counter", "Receive errors on the interface.", float64(n.ErrorsIn), labels...)
+		set.add("homelab_network_transmit_errors_total", "counter", "Transmit errors on the interface.", float64(n.ErrorsOut), labels...)
+		set.add("homelab_network_receive_drops_total", "counter", "Received packets dropped on the interface.", float64(n.DropIn), labels...)
+		set.add("homelab_network_transmit_drops_total", "counter", "Sent packets dropped on the interface.", float64(n.DropOut), labels...)
+	}
+}
+
+// addContainers adds the state and resource usage of the local Docker
+// daemon's containers
+func (s *PrometheusService) addContainers(set *promSet) {
+	set.add("homelab_docker_up", "gauge", "Whether the Docker daemon is reachable.", promBool(s.docker.IsConnected()))
+	if !s.docker.IsConnected() {
+		return
+	}
+
+	for _, c := range s.docker.GetContainers() {
+		labels := []string{"name", c.Name, "image", c.Image}
+		running := c.State == "running"
+		set.add("homelab_container_running", "gauge", "Whether the container is running.", promBool(running), labels...)
+		if !running {
+			continue
+		}
+		set.add("homelab_container_cpu_usage_percent", "gauge", "Container CPU usage, 100 per core.", c.Stats.CPUPercent, labels...)
+		set.add("homelab_container_memory_usage_bytes", "gauge", "Container memory usage.", float64(c.Stats.MemoryUsage), labels...)
+		set.add("homelab_container_memory_limit_bytes", "gauge", "Container memory limit.", float64(c.Stats.MemoryLimit), labels...)
+		set.add("homelab_container_network_receive_bytes_total", "counter", "Bytes received by the container.", float64(c.Stats.NetworkRx), labels...)
+		set.add("homelab_container_network_transmit_bytes_total", "counter", "Bytes sent by the container.", float64(c.Stats.NetworkTx), labels...)
+		set.add("homelab_container_block_read_bytes_total", "counter", "Bytes read from block devices by the container.", float64(c.Stats.BlockRead), labels...)
+		set.add("homelab_container_block_write_bytes_total", "counter", "Bytes written to block devices by the container.", float64(c.Stats.BlockWrite), labels...)
+		set.add("homelab_container_pids", "gauge", "Processes in the container.", float64(c.Stats.PIDs), labels...)
+	}
+}
+
+// addServices adds the latest local check of every active service, from
+// the checks since startup or else the stored history
+func (s *PrometheusService) addServices(set *promSet) {
+	var services []models.ServiceConfig
+	if err := s.db.Where("is_active = ?", true).Order("id ASC").Find(&services).Error; err != nil {
+		log.Printf("Prometheus: failed to load services: %v", err)
+		return
+	}
+
+	var unchecked []uint
+	for _, svc := range services {
+		if _, ok := s.services.LatestStatus(svc.ID); !ok {
+			unchecked = append(unchecked, svc.ID)
+		}
+	}
+	stored := s.services.lastChecks(unchecked)
+
+	for _, svc := range services {
+		var status string
+		var responseTime int64
+		var checkedAt float64
+		if latest, ok := s.services.LatestStatus(svc.ID); ok {
+			status, responseTime, checkedAt = latest.Status, latest.ResponseTime, float64(latest.LastCheck.Unix())
+		} else if check, ok := stored[svc.ID]; ok {
+			status, responseTime, checkedAt = check.Status, check.ResponseTime, float64(check.CheckedAt.Unix())
+		} else {
+			continue
+		}
+
+		labels := []string{"id", strconv.FormatUint(uint64(svc.ID), 10), "name", svc.Name, "category", svc.Category}
+		set.add("homelab_service_up", "gauge", "Whether the service's last check was online.", promBool(status == "online"), labels...)
+		set.add("homelab_service_response_time_seconds", "gauge", "Response time of the service's last check.", float64(responseTime)/1000, labels...)
+		set.add("homelab_service_last_check_timestamp_seconds", "gauge", "When the service was last checked.", checkedAt, labels...)
+	}
+}
+
+// addDevices adds the online status of every active device
+func (s *PrometheusService) addDevices(set *promSet) {
+	var devices []models.Device
+	if err := s.db.Where("is_active = ?", true).Order("id ASC").Find(&devices).Error; err != nil {
+		log.Printf("Prometheus: failed to load devices: %v", err)
+		return
+	}
+
+	for _, d := range devices {
+		labels := []string{"id", strconv.FormatUint(uint64(d.ID), 10), "name", d.Name, "ip", d.IP, "type", d.Type}
+		set.add("homelab_device_up", "gauge", "Whether the device was online at its last check.", promBool(d.IsOnline), labels...)
+		if d.LastSeen != nil {
+			set.add("homelab_device_last_seen_timestamp_seconds", "gauge", "When the device was last seen online.", float64(d.LastSeen.Unix()), labels...)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// QRService builds QR codes for printed labels that open a device or service
+type QRService struct {
+	db *gorm.DB
+}
+
+// QR code size limits in pixels
+const (
+	DefaultQRSize = 256
+	MinQRSize     = 64
+	MaxQRSize     = 1024
+)
+
+// ErrQRFormat is returned for output formats other than png and svg
+var ErrQRFormat = errors.New("format must be png or svg")
+
+// NewQRService creates a new QRService
+func NewQRService() *QRService {
+	return &QRService{db: database.GetDB()}
+}
+
+// frontendLink builds an absolute link to a dashboard page
+func frontendLink(path string) string {
+	return strings.TrimRight(config.AppConfig.FrontendURL, "/") + path
+}
+
+// DeviceLink returns the dashboard link for a device
+func (s *QRService) DeviceLink(id, userID uint) (string, error) {
+	var count int64
+	s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", id, userID).Count(&count)
+	if count == 0 {
+		return "", fmt.Errorf("device not found")
+	}
+	return frontendLink(fmt.Sprintf("/devices?device=%d", id)), nil
+}
+
+// ServiceLink returns the service's own URL, or its dashboard page when
+// dashboard is set
+func (s *QRService) ServiceLink(id, userID uint, dashboard bool) (string, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Select("id", "url").Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return "", fmt.Errorf("service not found")
+	}
+	if dashboard || svc.URL == "" {
+		return frontendLink(fmt.Sprintf("/services?service=%d", id)), nil
+	}
+	return svc.URL, nil
+}
+
+// RenderQR encodes content as a PNG or SVG QR code of roughly size pixels.
+// It returns the image and its content type.
+func RenderQR(content, format string, size int) ([]byte, string, error) {
+	if size < MinQRSize || size > MaxQRSize {
+		return nil, "", fmt.Errorf("size must be between %d and %d", MinQRSize, MaxQRSize)
+	}
+
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "", "png":
+		data, err := code.PNG(size)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "image/png", nil
+	case "svg":
+		return []byte(renderQRSVG(code.Bitmap(), size)), "image/svg+xml; charset=utf-8", nil
+	}
+	return nil, "", ErrQRFormat
+}
+
+// renderQRSVG draws the QR bitmap (which includes the quiet zone) as one
+// path of unit squares, scaled to size by the viewBox
+func renderQRSVG(bitmap [][]bool, size int) string {
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	n := len(bitmap)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		size, size, n, n, n, n, path.String())
+}
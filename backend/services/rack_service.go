@@ -0,0 +1,534 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRackUnits = 42
+	maxRackUnits     = 60
+	defaultRoomSize  = 10
+	maxRoomSize      = 200
+)
+
+// ErrElevationFormat is returned for export formats other than svg and csv
+var ErrElevationFormat = errors.New("format must be svg or csv")
+
+// RackService manages rooms, racks and the devices placed in them
+type RackService struct {
+	db *gorm.DB
+}
+
+// NewRackService creates a new RackService
+func NewRackService() *RackService {
+	return &RackService{db: database.GetDB()}
+}
+
+// ListRooms returns the user's rooms
+func (s *RackService) ListRooms(userID uint) ([]models.Room, error) {
+	var rooms []models.Room
+	err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&rooms).Error
+	return rooms, err
+}
+
+// CreateRoom adds a room
+func (s *RackService) CreateRoom(userID uint, req models.RoomRequest) (*models.Room, error) {
+	room := models.Room{UserID: userID}
+	if err := applyRoomRequest(&room, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&room).Error; err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// UpdateRoom renames or resizes a room
+func (s *RackService) UpdateRoom(id, userID uint, req models.RoomRequest) (*models.Room, error) {
+	room, err := s.getRoom(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRoomRequest(room, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(room).Select("name", "description", "width", "depth").Updates(room).Error; err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// DeleteRoom removes a room; its racks are kept without a room
+func (s *RackService) DeleteRoom(id, userID uint) error {
+	room, err := s.getRoom(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Rack{}).Where("room_id = ?", room.ID).Update("room_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(room).Error
+	})
+}
+
+// GetFloorPlan returns a room with its racks and how full they are
+func (s *RackService) GetFloorPlan(id, userID uint) (*models.FloorPlan, error) {
+	room, err := s.getRoom(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var racks []models.Rack
+	if err := s.db.Where("room_id = ? AND user_id = ?", room.ID, userID).Order("pos_y ASC, pos_x ASC").Find(&racks).Error; err != nil {
+		return nil, err
+	}
+	summaries, err := s.summarize(racks)
+	if err != nil {
+		return nil, err
+	}
+	return &models.FloorPlan{Room: *room, Racks: summaries}, nil
+}
+
+func (s *RackService) getRoom(id, userID uint) (*models.Room, error) {
+	var room models.Room
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&room).Error; err != nil {
+		return nil, fmt.Errorf("room not found")
+	}
+	return &room, nil
+}
+
+func applyRoomRequest(room *models.Room, req models.RoomRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("room name is required")
+	}
+	width, depth := req.Width, req.Depth
+	if width == 0 {
+		width = defaultRoomSize
+	}
+	if depth == 0 {
+		depth = defaultRoomSize
+	}
+	if width < 1 || width > maxRoomSize || depth < 1 || depth > maxRoomSize {
+		return fmt.Errorf("room width and depth must be between 1 and %d", maxRoomSize)
+	}
+
+	room.Name = name
+	room.Description = req.Description
+	room.Width = width
+	room.Depth = depth
+	return nil
+}
+
+// ListRacks returns the user's racks with how full they are
+func (s *RackService) ListRacks(userID uint) ([]models.RackSummary, error) {
+	var racks []models.Rack
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&racks).Error; err != nil {
+		return nil, err
+	}
+	return s.summarize(racks)
+}
+
+// CreateRack adds a rack
+func (s *RackService) CreateRack(userID uint, req models.RackRequest) (*models.RackLayout, error) {
+	rack := models.Rack{UserID: userID}
+	if err := s.applyRackRequest(&rack, req, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&rack).Error; err != nil {
+		return nil, err
+	}
+	return s.GetLayout(rack.ID, userID)
+}
+
+// UpdateRack renames, resizes or moves a rack. A rack can't shrink below
+// the devices mounted in it.
+func (s *RackService) UpdateRack(id, userID uint, req models.RackRequest) (*models.RackLayout, error) {
+	rack, err := s.getRack(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var topU int
+	s.db.Model(&models.RackPlacement{}).Where("rack_id = ?", rack.ID).
+		Select("COALESCE(MAX(position + height - 1), 0)").Scan(&topU)
+	if err := s.applyRackRequest(rack, req, topU); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(rack).Select("name", "description", "room_id", "units", "pos_x", "pos_y").Updates(rack).Error; err != nil {
+		return nil, err
+	}
+	return s.GetLayout(rack.ID, userID)
+}
+
+// DeleteRack removes a rack and unmounts its devices
+func (s *RackService) DeleteRack(id, userID uint) error {
+	rack, err := s.getRack(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rack_id = ?", rack.ID).Delete(&models.RackPlacement{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(rack).Error
+	})
+}
+
+func (s *RackService) getRack(id, userID uint) (*models.Rack, error) {
+	var rack models.Rack
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&rack).Error; err != nil {
+		return nil, fmt.Errorf("rack not found")
+	}
+	return &rack, nil
+}
+
+// applyRackRequest validates a rack request; topU is the highest unit used
+// by the rack's devices
+func (s *RackService) applyRackRequest(rack *models.Rack, req models.RackRequest, topU int) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("rack name is required")
+	}
+	units := req.Units
+	if units == 0 {
+		units = defaultRackUnits
+	}
+	if units < 1 || units > maxRackUnits {
+		return fmt.Errorf("units must be between 1 and %d", maxRackUnits)
+	}
+	if units < topU {
+		return fmt.Errorf("rack has devices mounted up to U%d", topU)
+	}
+	if req.PosX < 0 || req.PosY < 0 {
+		return fmt.Errorf("position must not be negative")
+	}
+	if req.RoomID != nil {
+		room, err := s.getRoom(*req.RoomID, rack.UserID)
+		if err != nil {
+			return err
+		}
+		if req.PosX >= room.Width || req.PosY >= room.Depth {
+			return fmt.Errorf("position is outside the room (%dx%d)", room.Width, room.Depth)
+		}
+	}
+
+	rack.Name = name
+	rack.Description = req.Description
+	rack.RoomID = req.RoomID
+	rack.Units = units
+	rack.PosX = req.PosX
+	rack.PosY = req.PosY
+	return nil
+}
+
+// GetLayout returns a rack with its devices and its elevation
+func (s *RackService) GetLayout(id, userID uint) (*models.RackLayout, error) {
+	rack, err := s.getRack(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.rackDevices(rack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &models.RackLayout{
+		Rack:      *rack,
+		Devices:   devices,
+		Elevation: make([]models.RackUnit, rack.Units),
+	}
+	if rack.RoomID != nil {
+		if room, err := s.getRoom(*rack.RoomID, userID); err == nil {
+			layout.Room = room
+		}
+	}
+
+	for i := range layout.Elevation {
+		layout.Elevation[i].U = rack.Units - i
+	}
+	for _, d := range devices {
+		id := d.ID
+		for u := d.Position; u <= d.TopU && u <= rack.Units; u++ {
+			unit := &layout.Elevation[rack.Units-u]
+			if d.FullDepth || d.Face != models.RackFaceRear {
+				unit.Front = &id
+			}
+			if d.FullDepth || d.Face == models.RackFaceRear {
+				unit.Rear = &id
+			}
+		}
+	}
+	for _, unit := range layout.Elevation {
+		if unit.Front != nil || unit.Rear != nil {
+			layout.UsedUnits++
+		}
+	}
+	layout.FreeUnits = rack.Units - layout.UsedUnits
+	return layout, nil
+}
+
+// rackDevices returns the devices placed in a rack, top down. Placements of
+// deleted devices are skipped.
+func (s *RackService) rackDevices(rackID uint) ([]models.RackDevice, error) {
+	var placements []models.RackPlacement
+	if err := s.db.Where("rack_id = ?", rackID).Find(&placements).Error; err != nil {
+		return nil, err
+	}
+	if len(placements) == 0 {
+		return []models.RackDevice{}, nil
+	}
+
+	ids := make([]uint, len(placements))
+	for i, p := range placements {
+		ids[i] = p.DeviceID
+	}
+	var devices []models.Device
+	if err := s.db.Where("id IN ?", ids).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Device, len(devices))
+	for _, d := range devices {
+		byID[d.ID] = d
+	}
+
+	result := make([]models.RackDevice, 0, len(placements))
+	for _, p := range placements {
+		device, ok := byID[p.DeviceID]
+		if !ok {
+			continue
+		}
+		result = append(result, models.RackDevice{
+			RackPlacement: p,
+			Name:          device.Name,
+			Type:          device.Type,
+			IP:            device.IP,
+			Model:         strings.TrimSpace(device.Brand + " " + device.Model),
+			IsOnline:      device.IsOnline,
+			TopU:          p.Position + p.Height - 1,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TopU != result[j].TopU {
+			return result[i].TopU > result[j].TopU
+		}
+		return result[i].Face < result[j].Face
+	})
+	return result, nil
+}
+
+// summarize counts the devices and used units of each rack
+func (s *RackService) summarize(racks []models.Rack) ([]models.RackSummary, error) {
+	summaries := make([]models.RackSummary, 0, len(racks))
+	for _, rack := range racks {
+		devices, err := s.rackDevices(rack.ID)
+		if err != nil {
+			return nil, err
+		}
+		used := make(map[int]bool)
+		summary := models.RackSummary{Rack: rack, Devices: len(devices)}
+		for _, d := range devices {
+			if !d.IsOnline {
+				summary.Offline++
+			}
+			for u := d.Position; u <= d.TopU; u++ {
+				used[u] = true
+			}
+		}
+		summary.UsedUnits = len(used)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// PlaceDevice mounts a device in a rack, moving it from wherever it was
+func (s *RackService) PlaceDevice(rackID, userID uint, req models.RackPlacementRequest) (*models.RackLayout, error) {
+	rack, err := s.getRack(rackID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var device models.Device
+	if err := s.db.Where("id = ? AND user_id = ?", req.DeviceID, userID).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	placement := models.RackPlacement{
+		RackID:    rack.ID,
+		DeviceID:  device.ID,
+		Position:  req.Position,
+		Height:    req.Height,
+		Face:      strings.ToLower(strings.TrimSpace(req.Face)),
+		FullDepth: req.FullDepth,
+	}
+	if placement.Height == 0 {
+		placement.Height = 1
+	}
+	if placement.Face == "" {
+		placement.Face = models.RackFaceFront
+	}
+	if placement.Face != models.RackFaceFront && placement.Face != models.RackFaceRear {
+		return nil, fmt.Errorf("face must be front or rear")
+	}
+	if placement.Height < 1 || placement.Position < 1 || placement.Position+placement.Height-1 > rack.Units {
+		return nil, fmt.Errorf("device must fit between U1 and U%d", rack.Units)
+	}
+
+	existing, err := s.rackDevices(rack.ID)
+	if err != nil {
+		return nil, err
+	}
+	top := placement.Position + placement.Height - 1
+	for _, d := range existing {
+		if d.DeviceID == device.ID {
+			continue
+		}
+		overlaps := placement.Position <= d.TopU && d.Position <= top
+		sameSide := placement.FullDepth || d.FullDepth || placement.Face == d.Face
+		if overlaps && sameSide {
+			return nil, fmt.Errorf("U%d-U%d overlaps %s at U%d-U%d", placement.Position, top, d.Name, d.Position, d.TopU)
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id = ?", device.ID).Delete(&models.RackPlacement{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&placement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLayout(rack.ID, userID)
+}
+
+// RemoveDevice unmounts a device from a rack
+func (s *RackService) RemoveDevice(rackID, deviceID, userID uint) error {
+	rack, err := s.getRack(rackID, userID)
+	if err != nil {
+		return err
+	}
+	result := s.db.Where("rack_id = ? AND device_id = ?", rack.ID, deviceID).Delete(&models.RackPlacement{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("device not found in rack")
+	}
+	return nil
+}
+
+// ExportElevation renders a rack's elevation as an SVG drawing of both
+// faces or as CSV. It returns the data and its content type.
+func (s *RackService) ExportElevation(rackID, userID uint, format string) ([]byte, string, error) {
+	layout, err := s.GetLayout(rackID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "", "svg":
+		return []byte(renderElevationSVG(layout)), "image/svg+xml; charset=utf-8", nil
+	case "csv":
+		data, err := renderElevationCSV(layout)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "text/csv; charset=utf-8", nil
+	}
+	return nil, "", ErrElevationFormat
+}
+
+// renderElevationCSV writes one row per device, top down
+func renderElevationCSV(layout *models.RackLayout) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"rack", "top_u", "bottom_u", "height", "face", "full_depth", "device", "type", "ip", "model", "online"})
+	for _, d := range layout.Devices {
+		w.Write([]string{
+			layout.Name,
+			strconv.Itoa(d.TopU),
+			strconv.Itoa(d.Position),
+			strconv.Itoa(d.Height),
+			d.Face,
+			strconv.FormatBool(d.FullDepth),
+			d.Name,
+			d.Type,
+			d.IP,
+			d.Model,
+			strconv.FormatBool(d.IsOnline),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Elevation drawing dimensions in pixels
+const (
+	elevationUnit   = 20
+	elevationLabel  = 30
+	elevationFace   = 220
+	elevationGap    = 30
+	elevationHeader = 30
+)
+
+// renderElevationSVG draws the front and rear faces side by side with the
+// unit numbers on the left, offline devices in red
+func renderElevationSVG(layout *models.RackLayout) string {
+	height := elevationHeader + layout.Units*elevationUnit + 10
+	width := elevationLabel + 2*elevationFace + elevationGap + 10
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<title>%s</title><rect width="%d" height="%d" fill="#fff"/>`, html.EscapeString(layout.Name), width, height)
+
+	faces := []string{models.RackFaceFront, models.RackFaceRear}
+	for i, face := range faces {
+		x := elevationLabel + i*(elevationFace+elevationGap)
+		fmt.Fprintf(&b, `<text x="%d" y="20" text-anchor="middle" font-weight="bold">%s %s</text>`,
+			x+elevationFace/2, html.EscapeString(layout.Name), face)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#f4f4f5" stroke="#333"/>`,
+			x, elevationHeader, elevationFace, layout.Units*elevationUnit)
+		for u := 1; u < layout.Units; u++ {
+			y := elevationHeader + u*elevationUnit
+			fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ddd"/>`, x, y, x+elevationFace, y)
+		}
+	}
+	for i, unit := range layout.Elevation {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="#666">%d</text>`,
+			elevationLabel-6, elevationHeader+i*elevationUnit+14, unit.U)
+	}
+
+	for _, d := range layout.Devices {
+		fill := "#bbf7d0"
+		if !d.IsOnline {
+			fill = "#fecaca"
+		}
+		y := elevationHeader + (layout.Units-d.TopU)*elevationUnit
+		h := d.Height * elevationUnit
+		for i, face := range faces {
+			if !d.FullDepth && d.Face != face {
+				continue
+			}
+			x := elevationLabel + i*(elevationFace+elevationGap)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s" stroke="#333"/>`,
+				x+2, y+1, elevationFace-4, h-2, fill)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`,
+				x+elevationFace/2, y+h/2+4, html.EscapeString(d.Name))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
@@ -0,0 +1,474 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// RemediationService runs remediation hooks when alert rules fire, services
+// go down or devices go offline
+type RemediationService struct {
+	db      *gorm.DB
+	docker  *DockerService
+	devices *DeviceService
+	audit   *AuditService
+	events  *EventService
+
+	mu    sync.Mutex
+	fired map[uint]bool // hooks already run for the current outage
+}
+
+// remediationTaskTimeout bounds run_task commands
+const remediationTaskTimeout = time.Minute
+
+// autoRestartCooldown is the cooldown in minutes of the hooks services'
+// auto-restart setting creates
+const autoRestartCooldown = 10
+
+// NewRemediationService creates a new RemediationService and subscribes to
+// alerts and to service and device status changes
+func NewRemediationService(serviceConfigs *ServiceConfigService, devices *DeviceService, docker *DockerService, alerts *AlertService, audit *AuditService, events *EventService) *RemediationService {
+	s := &RemediationService{
+		db:      database.GetDB(),
+		docker:  docker,
+		devices: devices,
+		audit:   audit,
+		events:  events,
+		fired:   make(map[uint]bool),
+	}
+
+	serviceConfigs.OnStatus(func(svc models.ServiceConfig, status ServiceStatus) {
+		down := statusDown(status.Status)
+		s.evaluate(models.TriggerServiceDown, svc.ID, down)
+	})
+	devices.OnStatus(func(device models.Device, online bool) {
+		s.evaluate(models.TriggerDeviceOffline, device.ID, !online)
+	})
+	alerts.OnAlert(func(alert models.Alert) {
+		if alert.State == models.AlertFiring && alert.RuleID != 0 {
+			s.runAlertHooks(alert.RuleID)
+		}
+	})
+	s.createAutoRestartHooks()
+
+	return s
+}
+
+// autoRestartHooks selects the hooks that restart a service's linked
+// container when it goes down, which its auto-restart setting stands for
+func autoRestartHooks(db *gorm.DB, serviceID uint) *gorm.DB {
+	return db.Model(&models.RemediationHook{}).Where("trigger_type = ? AND target_id = ? AND action = ? AND param = ?",
+		models.TriggerServiceDown, serviceID, models.RemediationRestartContainer, "")
+}
+
+// syncAutoRestartHook enables, or creates, the hook behind a service's
+// auto-restart setting, or removes it once the setting is off
+func syncAutoRestartHook(db *gorm.DB, svc models.ServiceConfig) error {
+	if !svc.AutoRestart {
+		return autoRestartHooks(db, svc.ID).Delete(&models.RemediationHook{}).Error
+	}
+
+	result := autoRestartHooks(db, svc.ID).Update("enabled", true)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	return db.Create(&models.RemediationHook{
+		UserID:   svc.UserID,
+		Name:     svc.Name + " auto-restart",
+		Trigger:  models.TriggerServiceDown,
+		TargetID: svc.ID,
+		Action:   models.RemediationRestartContainer,
+		Cooldown: autoRestartCooldown,
+		Enabled:  true,
+	}).Error
+}
+
+// syncAutoRestartSetting turns a service's auto-restart setting off once
+// none of its auto-restart hooks are left
+func syncAutoRestartSetting(db *gorm.DB, serviceID uint) {
+	var count int64
+	autoRestartHooks(db, serviceID).Count(&count)
+	if count == 0 {
+		db.Model(&models.ServiceConfig{}).Where("id = ?", serviceID).UpdateColumn("auto_restart", false)
+	}
+}
+
+// createAutoRestartHooks creates the hooks of services whose auto-restart
+// setting predates them
+func (s *RemediationService) createAutoRestartHooks() {
+	var services []models.ServiceConfig
+	hooked := s.db.Model(&models.RemediationHook{}).Select("target_id").
+		Where("trigger_type = ? AND action = ? AND param = ?", models.TriggerServiceDown, models.RemediationRestartContainer, "")
+	if err := s.db.Where("auto_restart = ? AND container <> ? AND id NOT IN (?)", true, "", hooked).Find(&services).Error; err != nil {
+		log.Printf("Failed to look up services to auto-restart: %v", err)
+		return
+	}
+	for _, svc := range services {
+		if err := syncAutoRestartHook(s.db, svc); err != nil {
+			log.Printf("Failed to create the auto-restart hook of service %d: %v", svc.ID, err)
+		}
+	}
+}
+
+// runAlertHooks runs the hooks of an alert rule that just fired. An alert
+// fires once per outage, so only the cooldown holds a hook back.
+func (s *RemediationService) runAlertHooks(ruleID uint) {
+	var hooks []models.RemediationHook
+	if err := s.db.Where("trigger_type = ? AND rule_id = ? AND enabled = ?", models.TriggerAlert, ruleID, true).Find(&hooks).Error; err != nil {
+		return
+	}
+	for _, hook := range hooks {
+		if !coolingDown(hook) {
+			go s.run(hook, models.ActorSystem, 0)
+		}
+	}
+}
+
+// coolingDown reports whether a hook ran within its cooldown
+func coolingDown(hook models.RemediationHook) bool {
+	return hook.LastRunAt != nil && time.Since(*hook.LastRunAt) < time.Duration(hook.Cooldown)*time.Minute
+}
+
+// hookSubject returns the kind and ID of what a hook watches
+func hookSubject(hook models.RemediationHook) (string, uint) {
+	if hook.Trigger == models.TriggerAlert {
+		return "alert_rule", hook.RuleID
+	}
+	return hook.Trigger, hook.TargetID
+}
+
+// evaluate fires the hooks for a target that is down and re-arms them once it recovers
+func (s *RemediationService) evaluate(trigger string, targetID uint, down bool) {
+	var hooks []models.RemediationHook
+	if err := s.db.Where("trigger_type = ? AND target_id = ? AND enabled = ?", trigger, targetID, true).Find(&hooks).Error; err != nil || len(hooks) == 0 {
+		return
+	}
+
+	for _, hook := range hooks {
+		s.mu.Lock()
+		if !down {
+			delete(s.fired, hook.ID)
+			s.mu.Unlock()
+			continue
+		}
+		if s.fired[hook.ID] || coolingDown(hook) {
+			s.mu.Unlock()
+			continue
+		}
+		s.fired[hook.ID] = true
+		s.mu.Unlock()
+
+		go s.run(hook, models.ActorSystem, 0)
+	}
+}
+
+// run executes a hook and records the outcome on the hook, in the audit log and as an event
+func (s *RemediationService) run(hook models.RemediationHook, actor string, userID uint) (bool, string) {
+	result, err := s.execute(hook)
+	success := err == nil
+	if err != nil {
+		result = err.Error()
+	}
+	if len(result) > 1000 {
+		result = result[:1000]
+	}
+
+	now := time.Now()
+	s.db.Model(&hook).Updates(map[string]interface{}{
+		"last_run_at":  now,
+		"last_success": success,
+		"last_result":  result,
+	})
+
+	subject, subjectID := hookSubject(hook)
+	s.audit.Record(userID, actor, "remediation."+hook.Action, subject, strconv.FormatUint(uint64(subjectID), 10), success,
+		map[string]interface{}{
+			"hookId": hook.ID,
+			"hook":   hook.Name,
+			"param":  s.param(hook),
+			"result": result,
+		})
+
+	severity := models.SeverityWarning
+	title := "Remediation ran"
+	if !success {
+		severity = models.SeverityCritical
+		title = "Remediation failed"
+	}
+	s.events.RecordFor(hook.UserID, "remediation", severity, "remediation", title,
+		fmt.Sprintf("%s (%s): %s", hook.Name, hook.Action, result),
+		map[string]interface{}{"hookId": hook.ID, "trigger": hook.Trigger, subject + "Id": subjectID})
+
+	return success, result
+}
+
+// execute performs the hook's action
+func (s *RemediationService) execute(hook models.RemediationHook) (string, error) {
+	param := s.param(hook)
+
+	switch hook.Action {
+	case models.RemediationRestartContainer:
+		if param == "" {
+			return "", fmt.Errorf("no container to restart")
+		}
+		c, err := s.docker.FindContainer(param)
+		if err != nil {
+			return "", err
+		}
+		if err := s.docker.RestartContainer(c.ID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("restarted container %s", c.Name), nil
+
+	case models.RemediationWakeDevice:
+		deviceID, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid device ID %q", param)
+		}
+		if err := s.devices.WakeDevice(uint(deviceID), hook.UserID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sent Wake-on-LAN to device %d", deviceID), nil
+
+	case models.RemediationRunTask:
+		ctx, cancel := context.WithTimeout(context.Background(), remediationTaskTimeout)
+		defer cancel()
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Param)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", hook.Param)
+		}
+		output, err := cmd.CombinedOutput()
+		out := strings.TrimSpace(string(output))
+		if err != nil {
+			if out != "" {
+				return "", fmt.Errorf("%v: %s", err, out)
+			}
+			return "", err
+		}
+		if out == "" {
+			out = "task completed"
+		}
+		return out, nil
+	}
+
+	return "", fmt.Errorf("unknown action %q", hook.Action)
+}
+
+// param returns the hook's parameter. restart_container defaults to the
+// service's linked container or the rule's container, and wake_device to
+// the watched device.
+func (s *RemediationService) param(hook models.RemediationHook) string {
+	if hook.Param != "" {
+		return hook.Param
+	}
+	target, targetID, targetName := hook.Trigger, hook.TargetID, ""
+	if hook.Trigger == models.TriggerAlert {
+		var rule models.AlertRule
+		if err := s.db.Select("target", "target_id", "target_name").First(&rule, hook.RuleID).Error; err != nil {
+			return ""
+		}
+		target, targetID, targetName = rule.Target, rule.TargetID, rule.TargetName
+	}
+
+	switch {
+	case hook.Action == models.RemediationRestartContainer && watchesService(target):
+		var svc models.ServiceConfig
+		if err := s.db.Select("container").First(&svc, targetID).Error; err == nil {
+			return svc.Container
+		}
+	case hook.Action == models.RemediationRestartContainer && target == models.AlertTargetContainer:
+		return targetName
+	case hook.Action == models.RemediationWakeDevice && watchesDevice(target):
+		return strconv.FormatUint(uint64(targetID), 10)
+	}
+	return ""
+}
+
+// watchesService reports whether a trigger or alert rule target is a service
+func watchesService(target string) bool {
+	return target == models.TriggerServiceDown || target == models.AlertTargetService
+}
+
+// watchesDevice reports whether a trigger or alert rule target is a device
+func watchesDevice(target string) bool {
+	return target == models.TriggerDeviceOffline || target == models.AlertTargetDevice
+}
+
+// validate checks a hook request against the user's alert rules, services
+// and devices
+func (s *RemediationService) validate(userID uint, req models.RemediationHookRequest) error {
+	target := req.Trigger
+	switch req.Trigger {
+	case models.TriggerAlert:
+		var rule models.AlertRule
+		if err := s.db.Select("id", "target").Where("id = ? AND user_id = ?", req.RuleID, userID).First(&rule).Error; err != nil {
+			return fmt.Errorf("alert rule not found")
+		}
+		target = rule.Target
+	case models.TriggerServiceDown:
+		var count int64
+		s.db.Model(&models.ServiceConfig{}).Where("id = ? AND user_id = ?", req.TargetID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("service not found")
+		}
+	case models.TriggerDeviceOffline:
+		var count int64
+		s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", req.TargetID, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("device not found")
+		}
+	default:
+		return fmt.Errorf("unknown trigger %q (use %s, %s or %s)", req.Trigger, models.TriggerAlert, models.TriggerServiceDown, models.TriggerDeviceOffline)
+	}
+
+	switch req.Action {
+	case models.RemediationRestartContainer:
+		if req.Param == "" && !watchesService(target) && target != models.AlertTargetContainer {
+			return fmt.Errorf("restart_container requires a container name or label:key=value")
+		}
+	case models.RemediationRunTask:
+		if strings.TrimSpace(req.Param) == "" {
+			return fmt.Errorf("run_task requires a command")
+		}
+	case models.RemediationWakeDevice:
+		if req.Param == "" && watchesDevice(target) {
+			break
+		}
+		var count int64
+		s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", req.Param, userID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("wake_device requires the ID of one of your devices")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", req.Action)
+	}
+
+	if req.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative")
+	}
+	return nil
+}
+
+// hookTarget returns the rule and target IDs a hook request keeps, only the
+// one its trigger uses
+func hookTarget(req models.RemediationHookRequest) (uint, uint) {
+	if req.Trigger == models.TriggerAlert {
+		return req.RuleID, 0
+	}
+	return 0, req.TargetID
+}
+
+// List returns the user's remediation hooks
+func (s *RemediationService) List(userID uint) ([]models.RemediationHook, error) {
+	var hooks []models.RemediationHook
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Create adds a remediation hook
+func (s *RemediationService) Create(userID uint, req models.RemediationHookRequest) (*models.RemediationHook, error) {
+	if err := s.validate(userID, req); err != nil {
+		return nil, err
+	}
+
+	ruleID, targetID := hookTarget(req)
+	hook := models.RemediationHook{
+		UserID:   userID,
+		Name:     req.Name,
+		Trigger:  req.Trigger,
+		RuleID:   ruleID,
+		TargetID: targetID,
+		Action:   req.Action,
+		Param:    req.Param,
+		Cooldown: req.Cooldown,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if hook.Cooldown == 0 {
+		hook.Cooldown = 15
+	}
+
+	if err := s.db.Create(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// Update replaces a remediation hook's settings
+func (s *RemediationService) Update(id uint, userID uint, req models.RemediationHookRequest) (*models.RemediationHook, error) {
+	var hook models.RemediationHook
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error; err != nil {
+		return nil, fmt.Errorf("remediation hook not found")
+	}
+	if err := s.validate(userID, req); err != nil {
+		return nil, err
+	}
+
+	hook.Name = req.Name
+	previous := hook
+	hook.Trigger = req.Trigger
+	hook.RuleID, hook.TargetID = hookTarget(req)
+	hook.Action = req.Action
+	hook.Param = req.Param
+	if req.Cooldown > 0 {
+		hook.Cooldown = req.Cooldown
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+
+	if err := s.db.Save(&hook).Error; err != nil {
+		return nil, err
+	}
+	if previous.Trigger == models.TriggerServiceDown {
+		syncAutoRestartSetting(s.db, previous.TargetID)
+	}
+
+	s.mu.Lock()
+	delete(s.fired, hook.ID)
+	s.mu.Unlock()
+	return &hook, nil
+}
+
+// Delete removes a remediation hook. Removing the last auto-restart hook of
+// a service turns its auto-restart setting off.
+func (s *RemediationService) Delete(id uint, userID uint) error {
+	var hook models.RemediationHook
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error; err != nil {
+		return fmt.Errorf("remediation hook not found")
+	}
+	if err := s.db.Delete(&hook).Error; err != nil {
+		return err
+	}
+	if hook.Trigger == models.TriggerServiceDown {
+		syncAutoRestartSetting(s.db, hook.TargetID)
+	}
+	return nil
+}
+
+// Run executes a hook immediately, ignoring its cooldown
+func (s *RemediationService) Run(id uint, userID uint) (*models.RemediationHook, error) {
+	var hook models.RemediationHook
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error; err != nil {
+		return nil, fmt.Errorf("remediation hook not found")
+	}
+
+	s.run(hook, fmt.Sprintf("user:%d", userID), userID)
+	s.db.First(&hook, hook.ID)
+	return &hook, nil
+}
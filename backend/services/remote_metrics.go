@@ -0,0 +1,402 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+var (
+	// ErrRemoteMetricsDisabled is returned for devices without metrics collection
+	ErrRemoteMetricsDisabled = errors.New("metrics collection is not enabled for this device")
+	// ErrRemoteMetricsUnreadable is returned when a host's output is not what
+	// a Linux host prints
+	ErrRemoteMetricsUnreadable = errors.New("could not read metrics from the host")
+)
+
+const (
+	// remoteMetricsTimeout bounds one collection, including the second it
+	// waits between CPU samples
+	remoteMetricsTimeout = 20 * time.Second
+	// remoteMetricsWorkers bounds how many hosts are read at once
+	remoteMetricsWorkers = 4
+	// remoteMetricsMinAge is how recent a reading must be to answer a refresh
+	remoteMetricsMinAge = 5 * time.Second
+)
+
+// remoteMetricsScript prints the readings of a Linux host, separated by @@
+// lines: /proc/stat twice a second apart for CPU usage, then memory
+// (what free reads), filesystems, interfaces, uptime and load, CPU model.
+const remoteMetricsScript = `export LC_ALL=C
+cat /proc/stat; sleep 1; echo @@; cat /proc/stat
+echo @@; cat /proc/meminfo
+echo @@; df -kPT 2>/dev/null || df -kP
+echo @@; cat /proc/net/dev
+echo @@; cat /proc/uptime /proc/loadavg
+echo @@; grep -m1 'model name' /proc/cpuinfo
+exit 0`
+
+// remoteFilesystems are filesystem types and devices that are not storage
+var remoteFilesystems = map[string]bool{
+	"tmpfs": true, "devtmpfs": true, "overlay": true, "squashfs": true, "efivarfs": true,
+	"udev": true, "none": true, "shm": true, "run": true,
+}
+
+// remoteReading is the latest collection of a device's metrics, read over
+// SSH or pushed by its agent
+type remoteReading struct {
+	metrics *models.SystemMetrics
+	err     error
+	at      time.Time
+	pushed  bool
+	stored  time.Time // when a pushed reading last went into the history
+}
+
+// collectRemoteBackground reads every device with metrics collection on the
+// interval and stores the readings in the history
+func (s *MetricsService) collectRemoteBackground(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var devices []models.Device
+		if err := s.db.Where("collect_metrics = ? AND is_active = ?", true, true).Find(&devices).Error; err != nil {
+			log.Printf("Failed to load devices for metrics collection: %v", err)
+			continue
+		}
+
+		sem := make(chan struct{}, remoteMetricsWorkers)
+		var wg sync.WaitGroup
+		for _, device := range devices {
+			// Devices whose agent reports are not read twice
+			if s.agentReporting(device.ID) {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(device models.Device) {
+				defer wg.Done()
+				if reading := s.readRemote(device); reading.err == nil {
+					s.storeHistory(device.ID, reading.metrics)
+				}
+				<-sem
+			}(device)
+		}
+		wg.Wait()
+	}
+}
+
+// GetDeviceMetrics returns a device's latest metrics, read over SSH or
+// pushed by its agent, in the shape of the backend's own. Without a
+// reading yet or with refresh set it reads them over SSH now, unless the
+// agent is reporting.
+func (s *MetricsService) GetDeviceMetrics(deviceID, userID uint, refresh bool) (*models.SystemMetrics, error) {
+	device, err := s.metricsDevice(deviceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.remoteMu.Lock()
+	reading, ok := s.remote[device.ID]
+	s.remoteMu.Unlock()
+	if reading.pushed && (time.Since(reading.at) < agentOfflineAfter || !device.CollectMetrics) {
+		return reading.metrics, nil
+	}
+	if !device.CollectMetrics {
+		return nil, ErrAgentNotReported
+	}
+	if !ok || reading.pushed || (refresh && time.Since(reading.at) > remoteMetricsMinAge) {
+		reading = s.readRemote(*device)
+	}
+	return reading.metrics, reading.err
+}
+
+// GetDeviceMetricsHistory returns a device's stored samples like
+// GetMetricsHistory, or GetMetricsHistoryRange when from is set
+func (s *MetricsService) GetDeviceMetricsHistory(deviceID, userID uint, from, to time.Time, bucket time.Duration, limit int) ([]models.MetricsHistory, error) {
+	device, err := s.metricsDevice(deviceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() {
+		return s.GetMetricsHistory(device.ID, limit)
+	}
+	return s.GetMetricsHistoryRange(device.ID, from, to, bucket, limit)
+}
+
+// metricsDevice loads one of the user's devices with metrics collection on
+// or an enrolled agent
+func (s *MetricsService) metricsDevice(deviceID, userID uint) (*models.Device, error) {
+	var device models.Device
+	if err := s.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("device not found")
+	}
+	if !device.CollectMetrics {
+		var agents int64
+		s.db.Model(&models.MetricsAgent{}).Where("device_id = ?", device.ID).Count(&agents)
+		if agents == 0 {
+			return nil, ErrRemoteMetricsDisabled
+		}
+	}
+	return &device, nil
+}
+
+// readRemote reads a device's metrics and caches the reading
+func (s *MetricsService) readRemote(device models.Device) remoteReading {
+	metrics, err := collectRemoteMetrics(device)
+	reading := remoteReading{metrics: metrics, err: err, at: time.Now()}
+
+	s.remoteMu.Lock()
+	s.remote[device.ID] = reading
+	s.remoteMu.Unlock()
+	return reading
+}
+
+// collectRemoteMetrics runs the metrics script on a device over SSH
+func collectRemoteMetrics(device models.Device) (*models.SystemMetrics, error) {
+	client, err := dialDeviceSSH(device)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("SSH session failed: %v", err)
+	}
+	defer session.Close()
+
+	var output bytes.Buffer
+	session.Stdout = &output
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(remoteMetricsScript)
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(remoteMetricsTimeout):
+		return nil, fmt.Errorf("%w: timed out", ErrRemoteMetricsUnreadable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemoteMetricsUnreadable, err)
+	}
+	return parseRemoteMetrics(output.String(), time.Now())
+}
+
+// parseRemoteMetrics parses the output of remoteMetricsScript
+func parseRemoteMetrics(output string, now time.Time) (*models.SystemMetrics, error) {
+	sections := make([]string, 0, 7)
+	var current []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "@@" {
+			sections = append(sections, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	sections = append(sections, strings.Join(current, "\n"))
+	if len(sections) < 7 {
+		return nil, fmt.Errorf("%w: unexpected output, is it a Linux host?", ErrRemoteMetricsUnreadable)
+	}
+
+	before, after := parseProcStat(sections[0]), parseProcStat(sections[1])
+	if before["cpu"] == nil || after["cpu"] == nil {
+		return nil, fmt.Errorf("%w: /proc/stat is missing, is it a Linux host?", ErrRemoteMetricsUnreadable)
+	}
+	metrics := &models.SystemMetrics{
+		Disk:      parseDf(sections[3]),
+		Network:   parseNetDev(sections[4]),
+		Timestamp: now,
+	}
+
+	metrics.CPU.UsagePercent, metrics.CPU.StealPercent = cpuDelta(before["cpu"], after["cpu"])
+	for i := 0; ; i++ {
+		name := "cpu" + strconv.Itoa(i)
+		if after[name] == nil {
+			break
+		}
+		usage, _ := cpuDelta(before[name], after[name])
+		metrics.CPU.PerCoreUsage = append(metrics.CPU.PerCoreUsage, usage)
+	}
+	metrics.CPU.LogicalCores = len(metrics.CPU.PerCoreUsage)
+	metrics.CPU.Cores = metrics.CPU.LogicalCores
+	if _, model, ok := strings.Cut(sections[6], ":"); ok {
+		metrics.CPU.ModelName = strings.TrimSpace(model)
+	}
+
+	metrics.Memory = parseMeminfo(sections[2])
+
+	lines := strings.Split(strings.TrimSpace(sections[5]), "\n")
+	if fields := strings.Fields(lines[0]); len(fields) > 0 {
+		uptime, _ := strconv.ParseFloat(fields[0], 64)
+		metrics.Uptime = uint64(uptime)
+	}
+	if len(lines) > 1 {
+		fields := strings.Fields(lines[1])
+		for _, field := range fields[:min(3, len(fields))] {
+			load, _ := strconv.ParseFloat(field, 64)
+			metrics.CPU.LoadAverage = append(metrics.CPU.LoadAverage, load)
+		}
+	}
+	return metrics, nil
+}
+
+// parseProcStat returns the jiffy counters of each cpu line of /proc/stat
+func parseProcStat(text string) map[string][]uint64 {
+	counters := make(map[string][]uint64)
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		values := make([]uint64, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			n, _ := strconv.ParseUint(field, 10, 64)
+			values = append(values, n)
+		}
+		counters[fields[0]] = values
+	}
+	return counters
+}
+
+// cpuDelta returns the busy and steal percentages between two samples of a
+// cpu line: user nice system idle iowait irq softirq steal. Guest time is
+// already counted in user.
+func cpuDelta(before, after []uint64) (float64, float64) {
+	sum := func(values []uint64) (total, idle, steal uint64) {
+		for i, v := range values[:min(8, len(values))] {
+			total += v
+			switch i {
+			case 3, 4:
+				idle += v
+			case 7:
+				steal = v
+			}
+		}
+		return
+	}
+	if len(before) == 0 || len(after) == 0 {
+		return 0, 0
+	}
+	total0, idle0, steal0 := sum(before)
+	total1, idle1, steal1 := sum(after)
+	if total1 <= total0 {
+		return 0, 0
+	}
+	total := float64(total1 - total0)
+	busy := total - float64(idle1-idle0)
+	return math.Round(max(busy, 0)/total*1000) / 10, math.Round(float64(steal1-steal0)/total*1000) / 10
+}
+
+// parseMeminfo reads memory and swap from /proc/meminfo, computing used
+// memory like free: total less free, buffers and cache
+func parseMeminfo(text string) models.MemoryMetrics {
+	kb := make(map[string]uint64)
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			n, _ := strconv.ParseUint(fields[0], 10, 64)
+			kb[key] = n * 1024
+		}
+	}
+
+	m := models.MemoryMetrics{
+		Total:     kb["MemTotal"],
+		Free:      kb["MemFree"],
+		Available: kb["MemAvailable"],
+		SwapTotal: kb["SwapTotal"],
+		SwapFree:  kb["SwapFree"],
+	}
+	if reclaimable := m.Free + kb["Buffers"] + kb["Cached"] + kb["SReclaimable"]; reclaimable < m.Total {
+		m.Used = m.Total - reclaimable
+	}
+	if m.Total > 0 {
+		m.UsedPercent = math.Round(float64(m.Used)/float64(m.Total)*1000) / 10
+	}
+	if m.SwapFree < m.SwapTotal {
+		m.SwapUsed = m.SwapTotal - m.SwapFree
+		m.SwapPercent = math.Round(float64(m.SwapUsed)/float64(m.SwapTotal)*1000) / 10
+	}
+	return m
+}
+
+// parseDf reads `df -kPT`, or `df -kP` where -T is unsupported, skipping
+// memory and overlay filesystems
+func parseDf(text string) []models.DiskMetrics {
+	disks := make([]models.DiskMetrics, 0)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 0 {
+		return disks
+	}
+	typed := strings.Contains(lines[0], "Type")
+	seen := make(map[string]bool)
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		fstype := ""
+		if typed && len(fields) >= 7 {
+			fstype, fields = fields[1], append(fields[:1:1], fields[2:]...)
+		}
+		if len(fields) < 6 || remoteFilesystems[fstype] || remoteFilesystems[fields[0]] || seen[fields[0]] {
+			continue
+		}
+		total, _ := strconv.ParseUint(fields[1], 10, 64)
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		free, _ := strconv.ParseUint(fields[3], 10, 64)
+		if total == 0 {
+			continue
+		}
+		// Bind mounts list the same device again
+		seen[fields[0]] = true
+		disks = append(disks, models.DiskMetrics{
+			Device:      fields[0],
+			MountPoint:  strings.Join(fields[5:], " "),
+			Fstype:      fstype,
+			Total:       total * 1024,
+			Used:        used * 1024,
+			Free:        free * 1024,
+			UsedPercent: math.Round(float64(used)/float64(used+free)*1000) / 10,
+		})
+	}
+	return disks
+}
+
+// parseNetDev reads the interface counters of /proc/net/dev, skipping loopback
+func parseNetDev(text string) []models.NetworkMetrics {
+	interfaces := make([]models.NetworkMetrics, 0)
+	for _, line := range strings.Split(text, "\n") {
+		name, counters, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(counters)
+		if !ok || name == "lo" || len(fields) < 12 {
+			continue
+		}
+		n := make([]uint64, len(fields))
+		for i, field := range fields {
+			n[i], _ = strconv.ParseUint(field, 10, 64)
+		}
+		interfaces = append(interfaces, models.NetworkMetrics{
+			Interface:   name,
+			BytesRecv:   n[0],
+			PacketsRecv: n[1],
+			ErrorsIn:    n[2],
+			DropIn:      n[3],
+			BytesSent:   n[8],
+			PacketsSent: n[9],
+			ErrorsOut:   n[10],
+			DropOut:     n[11],
+		})
+	}
+	return interfaces
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ReportService derives incidents and downtime reports from the check history
+type ReportService struct {
+	db      *gorm.DB
+	events  *EventService
+	devices *DeviceService
+}
+
+// weeklySummaryInterval is how often the downtime summary event is recorded
+const weeklySummaryInterval = 7 * 24 * time.Hour
+
+// NewReportService creates a new ReportService and starts the weekly summary
+func NewReportService(events *EventService, devices *DeviceService) *ReportService {
+	s := &ReportService{
+		db:      database.GetDB(),
+		events:  events,
+		devices: devices,
+	}
+
+	go s.summaryBackground()
+
+	return s
+}
+
+// ValidImpact returns true for a known impact level
+func ValidImpact(impact string) bool {
+	_, ok := models.ImpactWeights[impact]
+	return ok
+}
+
+// impactWeight returns the weight for an impact level, defaulting to medium
+func impactWeight(impact string) float64 {
+	if w, ok := models.ImpactWeights[impact]; ok {
+		return w
+	}
+	return models.ImpactWeights[models.ImpactMedium]
+}
+
+// reportCheck is the subset of a check needed to build incidents
+type reportCheck struct {
+	ServiceID uint
+	Status    string
+	Error     string
+	CheckedAt time.Time
+}
+
+// Incidents lists failure periods between from and to, newest first.
+// A userID of 0 includes every user's services; tags limit the services included.
+func (s *ReportService) Incidents(userID uint, tags []string, from, to time.Time) ([]models.Incident, error) {
+	incidents, _, err := s.collect(userID, tags, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+	return incidents, nil
+}
+
+// Downtime aggregates incidents per service, ranked by impact-weighted downtime
+func (s *ReportService) Downtime(userID uint, tags []string, from, to time.Time) (*models.DowntimeReport, error) {
+	incidents, services, err := s.collect(userID, tags, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DowntimeReport{From: from, To: to, Services: make([]models.ServiceDowntime, 0, len(services))}
+	byService := make(map[uint]*models.ServiceDowntime, len(services))
+	for _, svc := range services {
+		report.Services = append(report.Services, *svc)
+	}
+	for i := range report.Services {
+		byService[report.Services[i].ServiceID] = &report.Services[i]
+	}
+
+	for _, inc := range incidents {
+		entry := byService[inc.ServiceID]
+		entry.Incidents++
+		entry.Downtime += inc.Duration
+		entry.Weighted += inc.Weighted
+		report.Downtime += inc.Duration
+		report.Weighted += inc.Weighted
+	}
+
+	sort.Slice(report.Services, func(i, j int) bool {
+		a, b := report.Services[i], report.Services[j]
+		if a.Weighted != b.Weighted {
+			return a.Weighted > b.Weighted
+		}
+		if a.Downtime != b.Downtime {
+			return a.Downtime > b.Downtime
+		}
+		return a.ServiceName < b.ServiceName
+	})
+	return report, nil
+}
+
+// collect builds incidents from local checks and per-service uptime
+func (s *ReportService) collect(userID uint, tags []string, from, to time.Time) ([]models.Incident, map[uint]*models.ServiceDowntime, error) {
+	query := s.db.Model(&models.ServiceConfig{}).Scopes(ServiceTagScope(tags))
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var configs []models.ServiceConfig
+	if err := query.Find(&configs).Error; err != nil {
+		return nil, nil, err
+	}
+
+	services := make(map[uint]*models.ServiceDowntime, len(configs))
+	byID := make(map[uint]models.ServiceConfig, len(configs))
+	ids := make([]uint, 0, len(configs))
+	for _, svc := range configs {
+		byID[svc.ID] = svc
+		ids = append(ids, svc.ID)
+	}
+	if len(ids) == 0 {
+		return []models.Incident{}, services, nil
+	}
+
+	var checks []reportCheck
+	if err := s.db.Model(&models.ServiceCheck{}).
+		Select("service_id, status, error, checked_at").
+		Where("service_id IN ? AND location = ? AND checked_at >= ? AND checked_at <= ?", ids, "", from, to).
+		Order("service_id ASC, checked_at ASC").
+		Scan(&checks).Error; err != nil {
+		return nil, nil, err
+	}
+
+	end := to
+	if now := time.Now(); now.Before(end) {
+		end = now
+	}
+
+	incidents := make([]models.Incident, 0)
+	var current *models.Incident
+	online := make(map[uint]int)
+	total := make(map[uint]int)
+
+	closeIncident := func(at *time.Time) {
+		if current == nil {
+			return
+		}
+		stop := end
+		if at != nil {
+			stop = *at
+			current.EndedAt = at
+		}
+		current.Duration = int64(stop.Sub(current.StartedAt).Seconds())
+		current.Weighted = float64(current.Duration) / 60 * impactWeight(current.Impact)
+		incidents = append(incidents, *current)
+		current = nil
+	}
+
+	for i, check := range checks {
+		if i > 0 && checks[i-1].ServiceID != check.ServiceID {
+			closeIncident(nil)
+		}
+		total[check.ServiceID]++
+
+		if check.Status == "online" {
+			online[check.ServiceID]++
+			if current != nil {
+				at := check.CheckedAt
+				closeIncident(&at)
+			}
+			continue
+		}
+
+		if current == nil {
+			svc := byID[check.ServiceID]
+			current = &models.Incident{
+				ServiceID:   svc.ID,
+				ServiceName: svc.Name,
+				Impact:      svc.Impact,
+				ImpactNote:  svc.ImpactNote,
+				StartedAt:   check.CheckedAt,
+			}
+		}
+		current.Checks++
+		if check.Error != "" {
+			current.LastError = check.Error
+		}
+	}
+	closeIncident(nil)
+
+	for _, svc := range configs {
+		if total[svc.ID] == 0 {
+			continue
+		}
+		services[svc.ID] = &models.ServiceDowntime{
+			ServiceID:   svc.ID,
+			ServiceName: svc.Name,
+			Impact:      svc.Impact,
+			ImpactNote:  svc.ImpactNote,
+			Uptime:      float64(online[svc.ID]) / float64(total[svc.ID]) * 100,
+		}
+	}
+
+	return incidents, services, nil
+}
+
+// summaryBackground records a weekly downtime summary event
+func (s *ReportService) summaryBackground() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		var last models.Event
+		err := s.db.Where("type = ?", "weekly_downtime_summary").Order("created_at DESC").First(&last).Error
+		if err != nil || time.Since(last.CreatedAt) >= weeklySummaryInterval {
+			s.recordWeeklySummary()
+		}
+		<-ticker.C
+	}
+}
+
+// recordWeeklySummary stores the last week's report as an event
+func (s *ReportService) recordWeeklySummary() {
+	to := time.Now()
+	report, err := s.Downtime(0, nil, to.Add(-weeklySummaryInterval), to)
+	if err != nil {
+		log.Printf("Failed to build weekly downtime summary: %v", err)
+		return
+	}
+
+	worst := make([]string, 0, 3)
+	for _, svc := range report.Services {
+		if svc.Downtime == 0 || len(worst) == 3 {
+			break
+		}
+		worst = append(worst, fmt.Sprintf("%s (%s, %s impact)", svc.ServiceName, formatDowntime(svc.Downtime), svc.Impact))
+	}
+
+	message := "No downtime recorded in the last 7 days"
+	if len(worst) > 0 {
+		message = fmt.Sprintf("%s of downtime in the last 7 days. Most impactful: %s",
+			formatDowntime(report.Downtime), strings.Join(worst, ", "))
+	}
+
+	stale, err := s.devices.StaleDevices(0, 0)
+	if err != nil {
+		log.Printf("Failed to list stale devices: %v", err)
+	}
+	if len(stale) > 0 {
+		names := make([]string, 0, len(stale))
+		ids := make([]uint, 0, len(stale))
+		for _, device := range stale {
+			names = append(names, device.Name)
+			ids = append(ids, device.ID)
+		}
+		action := "not seen"
+		if config.AppConfig.StaleDeviceAutoArchive {
+			if err := s.devices.ArchiveDevices(ids); err != nil {
+				log.Printf("Failed to archive stale devices: %v", err)
+			} else {
+				action = "archived after not being seen"
+			}
+		}
+		message += fmt.Sprintf(". %d device(s) %s for %d+ days: %s",
+			len(stale), action, config.AppConfig.StaleDeviceDays, strings.Join(names, ", "))
+	}
+
+	s.events.Record("weekly_downtime_summary", models.SeverityInfo, "services",
+		"Weekly downtime summary", message,
+		map[string]interface{}{
+			"from":     report.From,
+			"to":       report.To,
+			"downtime": report.Downtime,
+			"weighted": report.Weighted,
+			"services": report.Services,
+			"stale":    stale,
+		})
+}
+
+// formatDowntime renders seconds as a short duration, e.g. "1h25m"
+func formatDowntime(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	if d < time.Minute {
+		return d.String()
+	}
+	return strings.TrimSuffix(d.Truncate(time.Minute).String(), "0s")
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ScanService runs Trivy against running container images on a schedule
+type ScanService struct {
+	db       *gorm.DB
+	docker   *DockerService
+	events   *EventService
+	enabled  bool
+	path     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	running bool
+}
+
+// trivyReport is the subset of Trivy's JSON output we use
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+const trivyScanTimeout = 10 * time.Minute
+
+// NewScanService creates a new ScanService and starts the scheduler when enabled
+func NewScanService(docker *DockerService, events *EventService) *ScanService {
+	cfg := config.AppConfig
+	s := &ScanService{
+		db:       database.GetDB(),
+		docker:   docker,
+		events:   events,
+		enabled:  cfg.TrivyEnabled,
+		path:     cfg.TrivyPath,
+		interval: time.Duration(cfg.TrivyIntervalHours) * time.Hour,
+	}
+
+	if s.enabled {
+		if _, err := exec.LookPath(s.path); err != nil {
+			log.Printf("Warning: Trivy scanning enabled but %s not found, scans will fail", s.path)
+		}
+		go s.scanBackground()
+	}
+
+	return s
+}
+
+// IsEnabled returns true if Trivy scanning is enabled
+func (s *ScanService) IsEnabled() bool {
+	return s.enabled
+}
+
+func (s *ScanService) scanBackground() {
+	// Give Docker and the database a moment before the first scan
+	time.Sleep(time.Minute)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.ScanRunningImages()
+		<-ticker.C
+	}
+}
+
+// ScanRunningImages scans every image used by a running container.
+// Returns false if a scan is already in progress.
+func (s *ScanService) ScanRunningImages() bool {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return false
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	images := make(map[string]bool)
+	for _, c := range s.docker.GetContainersBasic() {
+		if c.State == "running" {
+			images[c.Image] = true
+		}
+	}
+
+	for image := range images {
+		s.scanImage(image)
+	}
+	return true
+}
+
+// scanImage runs Trivy for a single image and stores the summary
+func (s *ScanService) scanImage(image string) {
+	var previous models.ImageScan
+	hadPrevious := s.db.Where("image = ?", image).First(&previous).Error == nil
+
+	scan := models.ImageScan{Image: image, ScannedAt: time.Now()}
+	if hadPrevious {
+		scan.ID = previous.ID
+		scan.CreatedAt = previous.CreatedAt
+	}
+
+	findings, err := s.runTrivy(image)
+	if err != nil {
+		log.Printf("Trivy scan failed for %s: %v", image, err)
+		scan.Error = err.Error()
+	} else {
+		for _, v := range findings {
+			switch v.Severity {
+			case "CRITICAL":
+				scan.Critical++
+			case "HIGH":
+				scan.High++
+			case "MEDIUM":
+				scan.Medium++
+			case "LOW":
+				scan.Low++
+			default:
+				scan.Unknown++
+			}
+		}
+		if data, err := json.Marshal(findings); err == nil {
+			scan.Findings = string(data)
+		}
+	}
+
+	if err := s.db.Save(&scan).Error; err != nil {
+		log.Printf("Failed to save scan for %s: %v", image, err)
+		return
+	}
+
+	if scan.Error == "" && scan.Critical > 0 && (!hadPrevious || scan.Critical > previous.Critical) {
+		s.events.Record("critical_vulnerabilities", models.SeverityCritical, "trivy",
+			"Critical vulnerabilities found",
+			fmt.Sprintf("%s has %d critical and %d high vulnerabilities", image, scan.Critical, scan.High),
+			map[string]interface{}{"image": image, "critical": scan.Critical, "high": scan.High})
+	}
+}
+
+// runTrivy executes trivy and flattens its findings
+func (s *ScanService) runTrivy(image string) ([]models.Vulnerability, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), trivyScanTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.path, "image", "--quiet", "--format", "json", "--scanners", "vuln", image)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	var report trivyReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy output: %v", err)
+	}
+
+	findings := make([]models.Vulnerability, 0)
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			findings = append(findings, models.Vulnerability{
+				ID:               v.VulnerabilityID,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         v.Severity,
+				Title:            v.Title,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// GetScans returns all stored image scans, most vulnerable first
+func (s *ScanService) GetScans() ([]models.ImageScan, error) {
+	var scans []models.ImageScan
+	if err := s.db.Order("critical DESC, high DESC, image ASC").Find(&scans).Error; err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// GetScan returns a single scan with its findings
+func (s *ScanService) GetScan(id uint) (*models.ImageScanDetail, error) {
+	var scan models.ImageScan
+	if err := s.db.First(&scan, id).Error; err != nil {
+		return nil, fmt.Errorf("scan not found")
+	}
+
+	detail := &models.ImageScanDetail{ImageScan: scan, Vulnerabilities: []models.Vulnerability{}}
+	if scan.Findings != "" {
+		json.Unmarshal([]byte(scan.Findings), &detail.Vulnerabilities)
+	}
+	return detail, nil
+}
+
+// GetSummaries returns vulnerability counts keyed by image name
+func (s *ScanService) GetSummaries() map[string]*models.VulnerabilitySummary {
+	result := make(map[string]*models.VulnerabilitySummary)
+	if !s.enabled {
+		return result
+	}
+
+	var scans []models.ImageScan
+	if err := s.db.Where("error = ?", "").Find(&scans).Error; err != nil {
+		return result
+	}
+
+	for _, scan := range scans {
+		result[scan.Image] = &models.VulnerabilitySummary{
+			Critical:  scan.Critical,
+			High:      scan.High,
+			Medium:    scan.Medium,
+			Low:       scan.Low,
+			ScannedAt: scan.ScannedAt,
+		}
+	}
+	return result
+}
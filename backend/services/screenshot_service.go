@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/homelab/backend/checker"
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ScreenshotService captures thumbnails of HTTP services with headless Chrome
+type ScreenshotService struct {
+	db        *gorm.DB
+	enabled   bool
+	dir       string
+	chromeURL string
+}
+
+// Screenshot viewport and the thumbnail scale applied to it
+const (
+	screenshotWidth   = 1280
+	screenshotHeight  = 800
+	screenshotScale   = 0.3125 // 400x250 thumbnail
+	screenshotTimeout = 30 * time.Second
+	// screenshotSettle gives client-side rendered dashboards time to draw
+	screenshotSettle = 2 * time.Second
+)
+
+// NewScreenshotService creates a new ScreenshotService and starts the
+// scheduled capture when enabled
+func NewScreenshotService() *ScreenshotService {
+	cfg := config.AppConfig
+	s := &ScreenshotService{
+		db:        database.GetDB(),
+		enabled:   cfg.ScreenshotEnabled,
+		dir:       cfg.ScreenshotDir,
+		chromeURL: cfg.ChromeURL,
+	}
+
+	if s.enabled {
+		go s.captureBackground(time.Duration(cfg.ScreenshotInterval) * time.Minute)
+	}
+
+	return s
+}
+
+// IsEnabled returns true if screenshots are enabled
+func (s *ScreenshotService) IsEnabled() bool {
+	return s.enabled
+}
+
+// isHTTPService reports whether a service points at a web page
+func isHTTPService(svc models.ServiceConfig) bool {
+	url := strings.ToLower(svc.URL)
+	return svc.Method != "TCP" && svc.Method != "PING" && !checker.IsDatabaseMethod(svc.Method) &&
+		(strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://"))
+}
+
+// captureBackground refreshes every active HTTP service's thumbnail on the interval
+func (s *ScreenshotService) captureBackground(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var svcs []models.ServiceConfig
+		if err := s.db.Where("is_active = ?", true).Find(&svcs).Error; err != nil {
+			log.Printf("Failed to load services for screenshots: %v", err)
+		}
+		for _, svc := range svcs {
+			if isHTTPService(svc) {
+				s.capture(svc)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// browserContext returns a context for a new tab in the configured browser
+func (s *ScreenshotService) browserContext() (context.Context, context.CancelFunc) {
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	if s.chromeURL != "" {
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(context.Background(), s.chromeURL)
+	} else {
+		// Homelab services commonly use self-signed certificates
+		opts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.IgnoreCertErrors,
+			chromedp.WindowSize(screenshotWidth, screenshotHeight),
+		)
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), opts...)
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(allocCtx)
+	ctx, cancelTimeout := context.WithTimeout(tabCtx, screenshotTimeout)
+	return ctx, func() {
+		cancelTimeout()
+		cancelTab()
+		cancelAlloc()
+	}
+}
+
+// capture loads the service page, stores a JPEG thumbnail and records the outcome
+func (s *ScreenshotService) capture(svc models.ServiceConfig) (*models.ServiceScreenshot, error) {
+	var shot models.ServiceScreenshot
+	s.db.Where("service_id = ?", svc.ID).FirstOrInit(&shot, models.ServiceScreenshot{ServiceID: svc.ID})
+
+	ctx, cancel := s.browserContext()
+	defer cancel()
+
+	var data []byte
+	err := chromedp.Run(ctx,
+		chromedp.EmulateViewport(screenshotWidth, screenshotHeight),
+		chromedp.Navigate(svc.URL),
+		chromedp.Sleep(screenshotSettle),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			data, err = page.CaptureScreenshot().
+				WithFormat(page.CaptureScreenshotFormatJpeg).
+				WithQuality(80).
+				WithClip(&page.Viewport{Width: screenshotWidth, Height: screenshotHeight, Scale: screenshotScale}).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err == nil {
+		err = s.write(svc.ID, data, &shot)
+	}
+
+	if err != nil {
+		shot.Error = err.Error()
+		if len(shot.Error) > 500 {
+			shot.Error = shot.Error[:500]
+		}
+		log.Printf("Failed to capture screenshot of %s: %v", svc.Name, err)
+	} else {
+		now := time.Now()
+		shot.CapturedAt = &now
+		shot.Error = ""
+	}
+	s.db.Save(&shot)
+	return &shot, err
+}
+
+// write stores a thumbnail, replacing the previous one
+func (s *ScreenshotService) write(serviceID uint, data []byte, shot *models.ServiceScreenshot) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("service-%d.jpg", serviceID))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	shot.Path = path
+	return nil
+}
+
+// ownedService loads one of the user's services
+func (s *ScreenshotService) ownedService(serviceID, userID uint) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", serviceID, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	return &svc, nil
+}
+
+// Get returns the cached thumbnail of a service and its file path
+func (s *ScreenshotService) Get(serviceID, userID uint) (*models.ServiceScreenshot, error) {
+	if _, err := s.ownedService(serviceID, userID); err != nil {
+		return nil, err
+	}
+	var shot models.ServiceScreenshot
+	if err := s.db.Where("service_id = ?", serviceID).First(&shot).Error; err != nil || shot.Path == "" {
+		return nil, fmt.Errorf("screenshot not found")
+	}
+	return &shot, nil
+}
+
+// Capture takes a new thumbnail of a service now
+func (s *ScreenshotService) Capture(serviceID, userID uint) (*models.ServiceScreenshot, error) {
+	svc, err := s.ownedService(serviceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTTPService(*svc) {
+		return nil, fmt.Errorf("screenshots are only available for HTTP services")
+	}
+	return s.capture(*svc)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSearch is returned for a history search with invalid filters
+var ErrInvalidSearch = errors.New("invalid search")
+
+// MaxHistoryResults caps the entries a history search returns
+const MaxHistoryResults = 200
+
+// SearchService searches audit entries, events and notes together
+type SearchService struct {
+	db *gorm.DB
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService() *SearchService {
+	return &SearchService{db: database.GetDB()}
+}
+
+// searchWords splits a query into the letters-and-digits words the
+// full-text indexes store
+func searchWords(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchQuery narrows a query to rows whose index matches every word as a
+// prefix
+func (s *SearchService) matchQuery(query *gorm.DB, kind string, words []string) *gorm.DB {
+	if len(words) == 0 {
+		return query
+	}
+	terms := make([]string, len(words))
+	if s.db.Dialector.Name() == "mysql" {
+		for i, word := range words {
+			terms[i] = "+" + word + "*"
+		}
+		return query.Where("MATCH("+database.HistorySearchColumns(kind)+") AGAINST (? IN BOOLEAN MODE)", strings.Join(terms, " "))
+	}
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return query.Where(database.HistorySearchVector(kind)+" @@ to_tsquery('simple', ?)", strings.Join(terms, " & "))
+}
+
+// SearchHistory searches the history kinds the user may see, newest first.
+// Audit entries are admin only; notes are always the user's own.
+func (s *SearchService) SearchHistory(userID uint, admin bool, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{models.HistoryAudit, models.HistoryEvent, models.HistoryNote}
+		if !admin {
+			kinds = kinds[1:]
+		}
+	}
+	for _, kind := range kinds {
+		if !database.HistorySearchKind(kind) {
+			return nil, fmt.Errorf("%w: unknown type %q, use audit, event or note", ErrInvalidSearch, kind)
+		}
+		if kind == models.HistoryAudit && !admin {
+			return nil, fmt.Errorf("%w: only admins can search the audit log", ErrInvalidSearch)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidSearch)
+	}
+	if filter.Limit <= 0 || filter.Limit > MaxHistoryResults {
+		filter.Limit = 50
+	}
+
+	words := searchWords(filter.Query)
+	var entries []models.HistoryEntry
+	for _, kind := range kinds {
+		found, err := s.searchKind(kind, userID, words, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, found...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	if entries == nil {
+		entries = []models.HistoryEntry{}
+	}
+	return entries, nil
+}
+
+// searchKind returns the latest filter.Limit matches of one history kind
+func (s *SearchService) searchKind(kind string, userID uint, words []string, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+	timeColumn := "created_at"
+	if kind == models.HistoryNote {
+		timeColumn = "occurred_at"
+	}
+
+	query := s.matchQuery(s.db, kind, words)
+	if filter.From != nil {
+		query = query.Where(timeColumn+" >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where(timeColumn+" < ?", *filter.To)
+	}
+	query = query.Order(timeColumn + " DESC").Limit(filter.Limit)
+
+	switch kind {
+	case models.HistoryAudit:
+		if filter.UserID != nil {
+			query = query.Where("user_id = ?", *filter.UserID)
+		}
+		var logs []models.AuditLog
+		if err := query.Find(&logs).Error; err != nil {
+			return nil, err
+		}
+		entries := make([]models.HistoryEntry, len(logs))
+		for i, l := range logs {
+			entries[i] = models.HistoryEntry{
+				Kind: kind, ID: l.ID, Time: l.CreatedAt, UserID: l.UserID,
+				Title: l.Action, Text: l.Details, Category: l.TargetType, Actor: l.Actor,
+			}
+		}
+		return entries, nil
+
+	case models.HistoryEvent:
+		// Lab-wide events and the user's own
+		query = query.Where("user_id IN ?", []uint{0, userID})
+		if filter.UserID != nil {
+			query = query.Where("user_id = ?", *filter.UserID)
+		}
+		var events []models.Event
+		if err := query.Find(&events).Error; err != nil {
+			return nil, err
+		}
+		entries := make([]models.HistoryEntry, len(events))
+		for i, e := range events {
+			entries[i] = models.HistoryEntry{
+				Kind: kind, ID: e.ID, Time: e.CreatedAt,
+				Title: e.Title, Text: e.Message, Category: e.Source, Severity: e.Severity,
+			}
+		}
+		return entries, nil
+	}
+
+	if filter.UserID != nil && *filter.UserID != userID {
+		return nil, nil
+	}
+	var notes []models.Note
+	if err := query.Where("user_id = ?", userID).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]models.HistoryEntry, len(notes))
+	for i, n := range notes {
+		title, _, _ := strings.Cut(n.Body, "\n")
+		entries[i] = models.HistoryEntry{
+			Kind: kind, ID: n.ID, Time: n.OccurredAt, UserID: n.UserID,
+			Title: title, Text: n.Body,
+		}
+	}
+	return entries, nil
+}
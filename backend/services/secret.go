@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// encryptedPrefix marks values produced by EncryptSecret
+const encryptedPrefix = "enc:v1:"
+
+// secretCipher returns an AES-256-GCM cipher keyed from ENCRYPTION_KEY
+func secretCipher() (cipher.AEAD, error) {
+	if config.AppConfig == nil || config.AppConfig.EncryptionKey == "" {
+		return nil, fmt.Errorf("encryption key is not configured")
+	}
+	key := sha256.Sum256([]byte(config.AppConfig.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret encrypts a value for storage in the database
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a value produced by EncryptSecret.
+// Values without the encrypted prefix are returned unchanged, so secrets
+// handed to remote probe agents (already decrypted) pass straight through.
+func DecryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret (was ENCRYPTION_KEY changed?)")
+	}
+	return string(plaintext), nil
+}
+
+// secretColumns are the columns holding EncryptSecret values, by model
+var secretColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&models.Device{}, []string{"ssh_password", "ssh_key", "ssh_key_passphrase", "sudo_password", "ipmi_password"}},
+	{&models.ServiceConfig{}, []string{"client_key", "db_password"}},
+	{&models.DockerHost{}, []string{"tls_key", "ssh_password", "ssh_key"}},
+	{&models.SNMPConfig{}, []string{"community", "auth_password", "priv_password"}},
+	{&models.InboundHook{}, []string{"secret"}},
+	{&models.WidgetSource{}, []string{"secret"}},
+	{&models.DeployApp{}, []string{"webhook_secret"}},
+}
+
+// EncryptStoredSecrets encrypts secrets still stored in plain text, such as
+// SSH passwords saved before credentials were encrypted at rest. DecryptSecret
+// passes plain text through, so these would otherwise stay readable until
+// they are next edited.
+func EncryptStoredSecrets(db *gorm.DB) error {
+	for _, table := range secretColumns {
+		for _, column := range table.columns {
+			if err := encryptColumn(db, table.model, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encryptColumn encrypts the plain text values of one column, soft deleted
+// rows included
+func encryptColumn(db *gorm.DB, model interface{}, column string) error {
+	var rows []struct {
+		ID    uint
+		Value string
+	}
+	err := db.Unscoped().Model(model).Select("id", column+" AS value").
+		Where(column+" <> '' AND "+column+" NOT LIKE ?", encryptedPrefix+"%").
+		Find(&rows).Error
+	if err != nil {
+		return fmt.Errorf("look up plain text %s: %w", column, err)
+	}
+
+	for _, row := range rows {
+		encrypted, err := EncryptSecret(row.Value)
+		if err != nil {
+			return err
+		}
+		err = db.Unscoped().Model(model).Where("id = ?", row.ID).UpdateColumn(column, encrypted).Error
+		if err != nil {
+			return fmt.Errorf("encrypt %s of row %d: %w", column, row.ID, err)
+		}
+	}
+	if len(rows) > 0 {
+		stmt := &gorm.Statement{DB: db}
+		stmt.Parse(model)
+		log.Printf("Encrypted %d plain text values of %s.%s", len(rows), stmt.Table, column)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// SecurityService surfaces bans from fail2ban or CrowdSec
+type SecurityService struct {
+	provider   string
+	lapiURL    string
+	apiKey     string
+	httpClient *http.Client
+	events     *EventService
+}
+
+// NewSecurityService creates a new SecurityService
+func NewSecurityService(events *EventService) *SecurityService {
+	cfg := config.AppConfig
+	s := &SecurityService{
+		provider:   strings.ToLower(cfg.BanProvider),
+		lapiURL:    strings.TrimRight(cfg.CrowdSecLAPIURL, "/"),
+		apiKey:     cfg.CrowdSecAPIKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		events:     events,
+	}
+
+	if s.provider == "auto" {
+		s.provider = s.detectProvider()
+	}
+
+	return s
+}
+
+// detectProvider looks for a local fail2ban or CrowdSec installation
+func (s *SecurityService) detectProvider() string {
+	if _, err := exec.LookPath("fail2ban-client"); err == nil {
+		return "fail2ban"
+	}
+	if _, err := exec.LookPath("cscli"); err == nil {
+		return "crowdsec"
+	}
+	if s.lapiURL != "" && s.apiKey != "" {
+		return "crowdsec"
+	}
+	return "none"
+}
+
+// IsConfigured returns true if a ban provider is available
+func (s *SecurityService) IsConfigured() bool {
+	return s.provider != "" && s.provider != "none"
+}
+
+// GetBans returns the current ban report
+func (s *SecurityService) GetBans() (*models.BanReport, error) {
+	switch s.provider {
+	case "fail2ban":
+		return s.fail2banReport()
+	case "crowdsec":
+		return s.crowdsecReport()
+	default:
+		return nil, fmt.Errorf("ban provider not configured")
+	}
+}
+
+// Unban lifts a ban for the given IP (optionally scoped to a jail)
+func (s *SecurityService) Unban(req models.UnbanRequest, username string) error {
+	var err error
+	switch s.provider {
+	case "fail2ban":
+		if req.Jail != "" {
+			_, err = runFirewallCommand("fail2ban-client", "set", req.Jail, "unbanip", req.IP)
+		} else {
+			_, err = runFirewallCommand("fail2ban-client", "unban", req.IP)
+		}
+	case "crowdsec":
+		if _, lookErr := exec.LookPath("cscli"); lookErr != nil {
+			return fmt.Errorf("unban requires cscli on the backend host")
+		}
+		_, err = runFirewallCommand("cscli", "decisions", "delete", "--ip", req.IP)
+	default:
+		return fmt.Errorf("ban provider not configured")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.events.Record("ip_unbanned", models.SeverityInfo, "security",
+		"IP unbanned",
+		fmt.Sprintf("%s lifted the %s ban on %s", username, s.provider, req.IP),
+		req)
+	return nil
+}
+
+// ---- fail2ban ----
+
+func (s *SecurityService) fail2banReport() (*models.BanReport, error) {
+	out, err := runFirewallCommand("fail2ban-client", "status")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BanReport{
+		Provider: "fail2ban",
+		Jails:    make([]models.JailSummary, 0),
+		Bans:     make([]models.BannedIP, 0),
+	}
+
+	var jails []string
+	for _, line := range strings.Split(out, "\n") {
+		if idx := strings.Index(line, "Jail list:"); idx >= 0 {
+			for _, jail := range strings.Split(line[idx+len("Jail list:"):], ",") {
+				if jail = strings.TrimSpace(jail); jail != "" {
+					jails = append(jails, jail)
+				}
+			}
+		}
+	}
+
+	for _, jail := range jails {
+		out, err := runFirewallCommand("fail2ban-client", "status", jail)
+		if err != nil {
+			continue
+		}
+
+		summary, banned := parseFail2banJail(jail, out)
+		report.Jails = append(report.Jails, summary)
+		report.TotalBanned += summary.CurrentlyBanned
+		for _, ip := range banned {
+			report.Bans = append(report.Bans, models.BannedIP{IP: ip, Jail: jail, Type: "ban"})
+		}
+	}
+
+	return report, nil
+}
+
+// parseFail2banJail parses the output of "fail2ban-client status <jail>"
+func parseFail2banJail(jail, out string) (models.JailSummary, []string) {
+	summary := models.JailSummary{Name: jail}
+	var banned []string
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimLeft(line[:idx], " |`-")
+		value := strings.TrimSpace(line[idx+1:])
+
+		switch key {
+		case "Currently failed":
+			summary.CurrentlyFailed, _ = strconv.Atoi(value)
+		case "Total failed":
+			summary.TotalFailed, _ = strconv.Atoi(value)
+		case "Currently banned":
+			summary.CurrentlyBanned, _ = strconv.Atoi(value)
+		case "Total banned":
+			summary.TotalBanned, _ = strconv.Atoi(value)
+		case "Banned IP list":
+			banned = strings.Fields(value)
+		}
+	}
+
+	return summary, banned
+}
+
+// ---- CrowdSec ----
+
+type crowdsecDecision struct {
+	ID       int    `json:"id"`
+	Origin   string `json:"origin"`
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Value    string `json:"value"`
+	Duration string `json:"duration"`
+	Scenario string `json:"scenario"`
+}
+
+func (s *SecurityService) crowdsecReport() (*models.BanReport, error) {
+	decisions, err := s.crowdsecDecisions()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BanReport{
+		Provider: "crowdsec",
+		Jails:    make([]models.JailSummary, 0),
+		Bans:     make([]models.BannedIP, 0, len(decisions)),
+	}
+
+	scenarios := make(map[string]*models.JailSummary)
+	for _, d := range decisions {
+		if !strings.EqualFold(d.Scope, "ip") {
+			continue
+		}
+		report.Bans = append(report.Bans, models.BannedIP{
+			IP:       d.Value,
+			Jail:     d.Scenario,
+			Origin:   d.Origin,
+			Type:     d.Type,
+			Duration: d.Duration,
+		})
+
+		summary, ok := scenarios[d.Scenario]
+		if !ok {
+			summary = &models.JailSummary{Name: d.Scenario}
+			scenarios[d.Scenario] = summary
+		}
+		summary.CurrentlyBanned++
+		summary.TotalBanned++
+	}
+
+	for _, summary := range scenarios {
+		report.Jails = append(report.Jails, *summary)
+	}
+	sort.Slice(report.Jails, func(i, j int) bool {
+		return report.Jails[i].CurrentlyBanned > report.Jails[j].CurrentlyBanned
+	})
+	report.TotalBanned = len(report.Bans)
+
+	return report, nil
+}
+
+// crowdsecDecisions lists active decisions via cscli, falling back to the LAPI
+func (s *SecurityService) crowdsecDecisions() ([]crowdsecDecision, error) {
+	if _, err := exec.LookPath("cscli"); err == nil {
+		out, err := runFirewallCommand("cscli", "decisions", "list", "-o", "json")
+		if err != nil {
+			return nil, err
+		}
+
+		// cscli groups decisions by the alert that created them
+		var alerts []struct {
+			Decisions []crowdsecDecision `json:"decisions"`
+		}
+		trimmed := strings.TrimSpace(out)
+		if trimmed == "" || trimmed == "null" {
+			return []crowdsecDecision{}, nil
+		}
+		if err := json.Unmarshal([]byte(trimmed), &alerts); err != nil {
+			return nil, fmt.Errorf("failed to parse cscli output: %v", err)
+		}
+
+		decisions := make([]crowdsecDecision, 0)
+		for _, a := range alerts {
+			decisions = append(decisions, a.Decisions...)
+		}
+		return decisions, nil
+	}
+
+	if s.lapiURL == "" || s.apiKey == "" {
+		return nil, fmt.Errorf("cscli not found and CROWDSEC_LAPI_URL/CROWDSEC_API_KEY not set")
+	}
+
+	req, err := http.NewRequest("GET", s.lapiURL+"/v1/decisions", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CrowdSec LAPI returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	// LAPI returns "null" when there are no active decisions
+	decisions := make([]crowdsecDecision, 0)
+	if err := json.Unmarshal(body, &decisions); err != nil {
+		return nil, fmt.Errorf("failed to parse LAPI response: %v", err)
+	}
+	if decisions == nil {
+		decisions = []crowdsecDecision{}
+	}
+	return decisions, nil
+}
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// SensorService stores readings pushed by home sensors (ESPHome, Tasmota and
+// the like) and serves their history
+type SensorService struct {
+	db *gorm.DB
+}
+
+const (
+	// IngestKeyPrefix marks sensor ingest keys
+	IngestKeyPrefix = "hli_"
+	// maxIngestReadings is the most values one ingest request may carry
+	maxIngestReadings = 500
+	// maxSensorField is the longest device or metric name
+	maxSensorField = 100
+	// sensorStaleAfter is how long a sensor's value counts for alerting
+	sensorStaleAfter = 15 * time.Minute
+)
+
+// ErrIngestUnauthorized is returned for a missing or unknown ingest key
+var ErrIngestUnauthorized = fmt.Errorf("invalid ingest key")
+
+// sensorStates maps textual states to values, so door and motion sensors can
+// send open/closed or ON/OFF
+var sensorStates = map[string]float64{
+	"on": 1, "open": 1, "true": 1, "yes": 1, "detected": 1, "motion": 1, "wet": 1, "home": 1,
+	"off": 0, "closed": 0, "false": 0, "no": 0, "clear": 0, "dry": 0, "away": 0,
+}
+
+// ingestReserved are payload keys that describe the readings rather than being one
+var ingestReserved = map[string]bool{"device": true, "timestamp": true, "unit": true, "Time": true}
+
+// NewSensorService creates a new SensorService and starts the history cleanup
+func NewSensorService() *SensorService {
+	s := &SensorService{db: database.GetDB()}
+	go s.cleanupBackground()
+	return s
+}
+
+// cleanupBackground purges readings beyond METRICS_RETENTION_DAYS once a day
+func (s *SensorService) cleanupBackground() {
+	for {
+		cutoff := time.Now().AddDate(0, 0, -config.AppConfig.MetricsRetentionDays)
+		if err := s.db.Where("sampled_at < ?", cutoff).Delete(&models.SensorReading{}).Error; err != nil {
+			log.Printf("Failed to purge sensor readings: %v", err)
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// ListKeys returns the user's ingest keys (without their values)
+func (s *SensorService) ListKeys(userID uint) ([]models.IngestKey, error) {
+	var keys []models.IngestKey
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateKey creates an ingest key and returns its plaintext value once
+func (s *SensorService) CreateKey(userID uint, name string) (*models.IngestKeyResponse, error) {
+	key, hash := newToken(IngestKeyPrefix)
+	ingestKey := models.IngestKey{
+		UserID:  userID,
+		Name:    name,
+		KeyHash: hash,
+		Prefix:  key[:len(IngestKeyPrefix)+6],
+	}
+	if err := s.db.Create(&ingestKey).Error; err != nil {
+		return nil, err
+	}
+	return &models.IngestKeyResponse{IngestKey: ingestKey, Key: key}, nil
+}
+
+// DeleteKey removes an ingest key
+func (s *SensorService) DeleteKey(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.IngestKey{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("ingest key not found")
+	}
+	return result.Error
+}
+
+// authenticate returns the ingest key matching a plaintext key
+func (s *SensorService) authenticate(key string) (*models.IngestKey, error) {
+	if !strings.HasPrefix(key, IngestKeyPrefix) {
+		return nil, ErrIngestUnauthorized
+	}
+	var ingestKey models.IngestKey
+	if err := s.db.Where("key_hash = ?", hashToken(key)).First(&ingestKey).Error; err != nil {
+		return nil, ErrIngestUnauthorized
+	}
+	return &ingestKey, nil
+}
+
+// Ingest stores the readings in a request body authenticated by an ingest key.
+// Sensors are created on their first reading.
+func (s *SensorService) Ingest(key string, body []byte, query url.Values) (*models.IngestResult, error) {
+	ingestKey, err := s.authenticate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := decodeHookBody(body, query)
+	if object, ok := payload.(map[string]interface{}); ok {
+		delete(object, "key")
+	}
+	readings, skipped, err := parseIngest(payload, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(readings) > maxIngestReadings {
+		return nil, fmt.Errorf("at most %d readings per request", maxIngestReadings)
+	}
+
+	result := &models.IngestResult{Skipped: skipped}
+	for _, reading := range readings {
+		if err := s.store(ingestKey.UserID, reading); err != nil {
+			log.Printf("Failed to store reading %s/%s: %v", reading.Device, reading.Metric, err)
+			result.Skipped = append(result.Skipped, reading.Device+"/"+reading.Metric)
+			continue
+		}
+		result.Accepted++
+	}
+	s.db.Model(ingestKey).Update("last_used_at", time.Now())
+	return result, nil
+}
+
+// store saves one reading, creating its sensor if needed
+func (s *SensorService) store(userID uint, reading models.IngestReading) error {
+	var sensor models.Sensor
+	err := s.db.Where("user_id = ? AND device = ? AND metric = ?", userID, reading.Device, reading.Metric).First(&sensor).Error
+	if err != nil {
+		sensor = models.Sensor{
+			UserID:     userID,
+			Device:     reading.Device,
+			Metric:     reading.Metric,
+			Name:       reading.Device + " " + reading.Metric,
+			Unit:       reading.Unit,
+			Value:      reading.Value,
+			LastSeenAt: reading.Timestamp,
+		}
+		if err := s.db.Create(&sensor).Error; err != nil {
+			// Created by a concurrent request
+			if s.db.Where("user_id = ? AND device = ? AND metric = ?", userID, reading.Device, reading.Metric).First(&sensor).Error != nil {
+				return err
+			}
+		}
+	}
+
+	// Late readings go into history without replacing the current value
+	if !reading.Timestamp.Before(sensor.LastSeenAt) {
+		updates := map[string]interface{}{"value": reading.Value, "last_seen_at": reading.Timestamp}
+		if reading.Unit != "" && sensor.Unit == "" {
+			updates["unit"] = reading.Unit
+		}
+		if err := s.db.Model(&sensor).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return s.db.Create(&models.SensorReading{SensorID: sensor.ID, Value: reading.Value, Timestamp: reading.Timestamp}).Error
+}
+
+// parseIngest reads the readings out of a decoded payload. Accepted shapes:
+//
+//	{"device": "hall", "metric": "temperature", "value": 21.5, "unit": "°C"}
+//	{"device": "hall", "readings": [{"metric": "humidity", "value": 40}, ...]}
+//	{"device": "hall", "values": {"temperature": 21.5, "door": "open"}}
+//	{"device": "hall", "AM2301": {"Temperature": 21.5}, ...}   Tasmota SENSOR
+//	[{...}, {...}]                                             several of the above
+//
+// Nested objects become dotted metric names. Values may be numbers, booleans
+// or states such as on/off and open/closed.
+func parseIngest(payload interface{}, now time.Time) ([]models.IngestReading, []string, error) {
+	if list, ok := payload.([]interface{}); ok {
+		var readings []models.IngestReading
+		var skipped []string
+		for _, item := range list {
+			r, sk, err := parseIngest(item, now)
+			if err != nil {
+				return nil, nil, err
+			}
+			readings = append(readings, r...)
+			skipped = append(skipped, sk...)
+		}
+		return readings, skipped, nil
+	}
+
+	object, ok := payload.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("payload must be a JSON object or array")
+	}
+	device := strings.TrimSpace(fmt.Sprint(object["device"]))
+	if object["device"] == nil || device == "" {
+		return nil, nil, fmt.Errorf("device is required")
+	}
+	if len(device) > maxSensorField {
+		return nil, nil, fmt.Errorf("device must be at most %d characters", maxSensorField)
+	}
+	timestamp, err := ingestTimestamp(object["timestamp"], now)
+	if err != nil {
+		return nil, nil, err
+	}
+	unit, _ := object["unit"].(string)
+
+	var readings []models.IngestReading
+	var skipped []string
+	add := func(metric string, raw interface{}, unit string, at time.Time) {
+		metric = strings.TrimSpace(metric)
+		if metric == "" || len(metric) > maxSensorField {
+			skipped = append(skipped, metric)
+			return
+		}
+		value, ok := sensorValue(raw)
+		if !ok {
+			skipped = append(skipped, metric)
+			return
+		}
+		readings = append(readings, models.IngestReading{Device: device, Metric: metric, Value: value, Unit: unit, Timestamp: at})
+	}
+
+	switch {
+	case object["metric"] != nil:
+		add(fmt.Sprint(object["metric"]), object["value"], unit, timestamp)
+
+	case object["readings"] != nil:
+		list, ok := object["readings"].([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("readings must be an array")
+		}
+		for _, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok || entry["metric"] == nil {
+				return nil, nil, fmt.Errorf("each reading needs a metric and value")
+			}
+			at, err := ingestTimestamp(entry["timestamp"], timestamp)
+			if err != nil {
+				return nil, nil, err
+			}
+			entryUnit, _ := entry["unit"].(string)
+			if entryUnit == "" {
+				entryUnit = unit
+			}
+			add(fmt.Sprint(entry["metric"]), entry["value"], entryUnit, at)
+		}
+
+	default:
+		values := object
+		if nested, ok := object["values"].(map[string]interface{}); ok {
+			values = nested
+		}
+		flattenIngest("", values, func(metric string, raw interface{}) {
+			add(metric, raw, unit, timestamp)
+		})
+	}
+	return readings, skipped, nil
+}
+
+// flattenIngest calls fn for each leaf of an object, in key order, naming
+// nested values with dotted paths
+func flattenIngest(prefix string, object map[string]interface{}, fn func(string, interface{})) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		if prefix == "" && ingestReserved[key] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		if nested, ok := object[key].(map[string]interface{}); ok {
+			flattenIngest(name, nested, fn)
+			continue
+		}
+		fn(name, object[key])
+	}
+}
+
+// sensorValue converts a reading to a number
+func sensorValue(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case bool:
+		return boolValue(v), true
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, true
+		}
+		value, ok := sensorStates[strings.ToLower(strings.TrimSpace(v))]
+		return value, ok
+	}
+	return 0, false
+}
+
+// ingestTimestamp reads an RFC 3339 or Unix timestamp, defaulting to fallback
+func ingestTimestamp(raw interface{}, fallback time.Time) (time.Time, error) {
+	if raw == nil {
+		return fallback, nil
+	}
+	text := strings.TrimSpace(fmt.Sprint(raw))
+	if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return t, nil
+	}
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))), nil
+	}
+	return time.Time{}, fmt.Errorf("timestamp must be RFC 3339 or Unix seconds")
+}
+
+// ListSensors returns the user's sensors
+func (s *SensorService) ListSensors(userID uint) ([]models.Sensor, error) {
+	var sensors []models.Sensor
+	if err := s.db.Where("user_id = ?", userID).Order("device ASC, metric ASC").Find(&sensors).Error; err != nil {
+		return nil, err
+	}
+	return sensors, nil
+}
+
+// GetSensor returns one of the user's sensors
+func (s *SensorService) GetSensor(id uint, userID uint) (*models.Sensor, error) {
+	var sensor models.Sensor
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&sensor).Error; err != nil {
+		return nil, fmt.Errorf("sensor not found")
+	}
+	return &sensor, nil
+}
+
+// UpdateSensor renames a sensor or changes its unit
+func (s *SensorService) UpdateSensor(id uint, userID uint, req models.SensorUpdateRequest) (*models.Sensor, error) {
+	sensor, err := s.GetSensor(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{"unit": strings.TrimSpace(req.Unit)}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates["name"] = name
+	}
+	if err := s.db.Model(sensor).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.GetSensor(id, userID)
+}
+
+// DeleteSensor removes a sensor and its history. A new reading recreates it.
+func (s *SensorService) DeleteSensor(id uint, userID uint) error {
+	sensor, err := s.GetSensor(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sensor_id = ?", sensor.ID).Delete(&models.SensorReading{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(sensor).Error
+	})
+}
+
+// GetHistory returns a sensor's readings between from and to, averaged into
+// buckets of bucket length (zero picks one giving at most
+// MaxMetricsHistoryPoints points)
+func (s *SensorService) GetHistory(id uint, userID uint, from, to time.Time, bucket time.Duration) ([]models.SensorReading, error) {
+	sensor, err := s.GetSensor(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if minimum := to.Sub(from) / MaxMetricsHistoryPoints; bucket < minimum {
+		bucket = minimum
+	}
+	if bucket < time.Second {
+		bucket = time.Second
+	}
+
+	var samples []models.SensorReading
+	if err := s.db.Where("sensor_id = ? AND sampled_at >= ? AND sampled_at <= ?", sensor.ID, from, to).
+		Order("sampled_at ASC").Find(&samples).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]models.SensorReading, 0)
+	var current models.SensorReading
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		current.Value /= float64(count)
+		history = append(history, current)
+		count = 0
+	}
+
+	currentKey := int64(-1)
+	for _, sample := range samples {
+		key := sample.Timestamp.Sub(from).Nanoseconds() / bucket.Nanoseconds()
+		if key != currentKey {
+			flush()
+			currentKey = key
+			current = models.SensorReading{Timestamp: from.Add(time.Duration(key) * bucket)}
+		}
+		current.Value += sample.Value
+		count++
+	}
+	flush()
+
+	return history, nil
+}
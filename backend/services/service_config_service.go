@@ -1,13 +1,16 @@
 package services
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
-	"net"
-	"net/http"
+	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/homelab/backend/checker"
+	"github.com/homelab/backend/config"
 	"github.com/homelab/backend/database"
 	"github.com/homelab/backend/models"
 	"gorm.io/gorm"
@@ -15,26 +18,36 @@ import (
 
 // ServiceConfigService handles service operations
 type ServiceConfigService struct {
-	db         *gorm.DB
-	httpClient *http.Client
+	db      *gorm.DB
+	clients *checker.Clients
+	devices *DeviceService
+	docker  *DockerService
+	events  *EventService
+	cache   Cache
+
+	latestMu sync.RWMutex
+	latest   map[uint]ServiceStatus // last check result per service since startup
+
+	streakMu sync.Mutex
+	streaks  map[uint]*checkStreak // status changes awaiting confirmation
+
+	listeners []func(models.ServiceConfig, ServiceStatus)
 }
 
+// serviceStatusCacheTTL is how long GetServices results are reused between requests
+const serviceStatusCacheTTL = 10 * time.Second
+
 // NewServiceConfigService creates a new ServiceConfigService
-func NewServiceConfigService() *ServiceConfigService {
+func NewServiceConfigService(devices *DeviceService, docker *DockerService, events *EventService, cache Cache) *ServiceConfigService {
 	return &ServiceConfigService{
-		db: database.GetDB(),
-		httpClient: &http.Client{
-			Timeout: 2 * time.Second, // Fast timeout for quick checks
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     30 * time.Second,
-				DisableKeepAlives:   false,
-			},
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse // Don't follow redirects
-			},
-		},
+		db:      database.GetDB(),
+		clients: checker.NewClients(config.AppConfig.CheckProxyURL),
+		devices: devices,
+		docker:  docker,
+		events:  events,
+		cache:   cache,
+		latest:  make(map[uint]ServiceStatus),
+		streaks: make(map[uint]*checkStreak),
 	}
 }
 
@@ -46,17 +59,43 @@ type ServiceStatus struct {
 	Icon         string    `json:"icon"`
 	Category     string    `json:"category"`
 	Description  string    `json:"description"`
-	Status       string    `json:"status"` // online, offline, error
+	Status       string    `json:"status"` // online, offline, error, host_down
 	StatusCode   int       `json:"statusCode"`
 	ResponseTime int64     `json:"responseTime"` // in milliseconds
 	LastCheck    time.Time `json:"lastCheck"`
 	IsActive     bool      `json:"isActive"`
+	Error        string    `json:"error,omitempty"`
+
+	// Tags are the labels attached to the service
+	Tags []models.Tag `json:"tags,omitempty"`
+	// Timings is the phase breakdown of HTTP checks
+	Timings *models.CheckTimings `json:"timings,omitempty"`
+	// Consensus combines all probe locations when remote agents also check the service
+	Consensus *models.ConsensusStatus `json:"consensus,omitempty"`
+	// Container is the linked container's state, included on single-service requests
+	Container *models.ServiceContainer `json:"container,omitempty"`
+	// Database holds latency, replication and size details of database checks
+	Database *models.DatabaseHealth `json:"database,omitempty"`
+	// Pending is a change between up and down awaiting confirmation by
+	// further checks; Status keeps the last confirmed value meanwhile
+	Pending *models.PendingStatus `json:"pending,omitempty"`
+	// UptimePercent is the share of online checks over the last 24 hours,
+	// 0 without checks; Uptime has every window
+	UptimePercent float64                `json:"uptimePercent"`
+	Uptime        []models.ServiceUptime `json:"uptime,omitempty"`
 }
 
-// GetServices returns all services for a user with their current status
-func (s *ServiceConfigService) GetServices(userID uint) ([]ServiceStatus, error) {
+// GetServices returns all services for a user with their current status,
+// optionally limited to services carrying any of the given tags
+func (s *ServiceConfigService) GetServices(userID uint, tags []string) ([]ServiceStatus, error) {
+	key := statusCacheKey(userID, tags)
+	var cached []ServiceStatus
+	if s.cache.Get(key, &cached) {
+		return cached, nil
+	}
+
 	var services []models.ServiceConfig
-	if err := s.db.Where("user_id = ?", userID).Order("category ASC, name ASC").Find(&services).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("user_id = ?", userID).Scopes(ServiceTagScope(tags)).Order("category ASC, name ASC").Find(&services).Error; err != nil {
 		return nil, err
 	}
 
@@ -73,16 +112,42 @@ func (s *ServiceConfigService) GetServices(userID uint) ([]ServiceStatus, error)
 	}
 
 	wg.Wait()
+	result = s.withUptime(result)
+	s.cache.Set(key, result, serviceStatusCacheTTL)
 	return result, nil
 }
 
-// GetServicesBasic returns all services without checking status (fast)
-func (s *ServiceConfigService) GetServicesBasic(userID uint) ([]ServiceStatus, error) {
+// statusCacheKey keys cached service lists by user and tag filter
+func statusCacheKey(userID uint, tags []string) string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("services:%d:%s", userID, strings.Join(sorted, ","))
+}
+
+// invalidateStatus drops the user's cached service lists after a change
+func (s *ServiceConfigService) invalidateStatus(userID uint) {
+	s.cache.DeletePrefix(fmt.Sprintf("services:%d:", userID))
+}
+
+// GetServicesBasic returns all services without checking them (fast), with
+// the last status from the background scheduler or the check history
+func (s *ServiceConfigService) GetServicesBasic(userID uint, tags []string) ([]ServiceStatus, error) {
 	var services []models.ServiceConfig
-	if err := s.db.Where("user_id = ?", userID).Order("category ASC, name ASC").Find(&services).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("user_id = ?", userID).Scopes(ServiceTagScope(tags)).Order("category ASC, name ASC").Find(&services).Error; err != nil {
 		return nil, err
 	}
 
+	// Services not checked since startup fall back to their last stored check
+	missing := make([]uint, 0)
+	s.latestMu.RLock()
+	for _, svc := range services {
+		if _, ok := s.latest[svc.ID]; !ok {
+			missing = append(missing, svc.ID)
+		}
+	}
+	s.latestMu.RUnlock()
+	stored := s.lastChecks(missing)
+
 	result := make([]ServiceStatus, len(services))
 	for i, svc := range services {
 		result[i] = ServiceStatus{
@@ -94,14 +159,153 @@ func (s *ServiceConfigService) GetServicesBasic(userID uint) ([]ServiceStatus, e
 			Description: svc.Description,
 			Status:      "unknown",
 			IsActive:    svc.IsActive,
+			Tags:        svc.Tags,
+		}
+
+		s.latestMu.RLock()
+		latest, ok := s.latest[svc.ID]
+		s.latestMu.RUnlock()
+		if ok {
+			result[i].Status = latest.Status
+			result[i].StatusCode = latest.StatusCode
+			result[i].ResponseTime = latest.ResponseTime
+			result[i].LastCheck = latest.LastCheck
+			result[i].Error = latest.Error
+			result[i].Timings = latest.Timings
+			result[i].Consensus = latest.Consensus
+			result[i].Database = latest.Database
+			result[i].Pending = latest.Pending
+		} else if check, ok := stored[svc.ID]; ok {
+			result[i].Status = check.Status
+			result[i].StatusCode = check.StatusCode
+			result[i].ResponseTime = check.ResponseTime
+			result[i].LastCheck = check.CheckedAt
+			result[i].Error = check.Error
+		}
+		if !svc.IsActive {
+			result[i].Status = "disabled"
 		}
 	}
 
-	return result, nil
+	return s.withUptime(result), nil
 }
 
-// checkService checks the status of a single service
+// lastChecks returns the most recent local check of each service
+func (s *ServiceConfigService) lastChecks(ids []uint) map[uint]models.ServiceCheck {
+	checks := make(map[uint]models.ServiceCheck)
+	if len(ids) == 0 {
+		return checks
+	}
+
+	latestIDs := s.db.Model(&models.ServiceCheck{}).Select("MAX(id)").
+		Where("service_id IN ? AND location = ?", ids, "").Group("service_id")
+	var rows []models.ServiceCheck
+	if err := s.db.Where("id IN (?)", latestIDs).Find(&rows).Error; err != nil {
+		log.Printf("Failed to load last service checks: %v", err)
+		return checks
+	}
+	for _, check := range rows {
+		checks[check.ServiceID] = check
+	}
+	return checks
+}
+
+// remember keeps a check result as the service's latest status
+func (s *ServiceConfigService) remember(status ServiceStatus) {
+	s.latestMu.Lock()
+	s.latest[status.ID] = status
+	s.latestMu.Unlock()
+}
+
+// LatestStatus returns the last check result of a service since startup
+func (s *ServiceConfigService) LatestStatus(id uint) (ServiceStatus, bool) {
+	s.latestMu.RLock()
+	defer s.latestMu.RUnlock()
+	status, ok := s.latest[id]
+	return status, ok
+}
+
+// checkService checks the status of a single service and records the result.
+// Services on an unreachable device are marked host_down without being probed.
+// The history keeps every result; the returned status only flips between up
+// and down once the change is confirmed.
 func (s *ServiceConfigService) checkService(svc models.ServiceConfig) ServiceStatus {
+	var status ServiceStatus
+	if host, down := s.hostDown(svc); down {
+		status = ServiceStatus{
+			ID:          svc.ID,
+			Name:        svc.Name,
+			URL:         svc.URL,
+			Icon:        svc.Icon,
+			Category:    svc.Category,
+			Description: svc.Description,
+			Status:      "host_down",
+			LastCheck:   time.Now(),
+			IsActive:    svc.IsActive,
+			Error:       fmt.Sprintf("host %s is offline", host),
+		}
+		status.Tags = svc.Tags
+		s.recordCheck(status)
+		s.confirm(svc, &status)
+		s.remember(status)
+		s.notify(svc, status)
+		return status
+	}
+
+	status = runServiceCheck(s.clients, svc)
+	status.Tags = svc.Tags
+	if !svc.IsActive {
+		return status
+	}
+	s.recordCheck(status)
+
+	if HasRemoteLocations(svc) || svc.SkipLocal {
+		consensus := computeConsensus(s.db, svc)
+		status.Consensus = &consensus
+		status.Status = consensus.Status
+	}
+	s.confirm(svc, &status)
+	s.remember(status)
+	s.notify(svc, status)
+	return status
+}
+
+// OnStatus registers a function called with the outcome of every recorded check
+func (s *ServiceConfigService) OnStatus(fn func(models.ServiceConfig, ServiceStatus)) {
+	s.listeners = append(s.listeners, fn)
+}
+
+// notify passes a check result to the registered status listeners
+func (s *ServiceConfigService) notify(svc models.ServiceConfig, status ServiceStatus) {
+	for _, fn := range s.listeners {
+		fn(svc, status)
+	}
+}
+
+// containerState returns the state of the service's linked container
+func (s *ServiceConfigService) containerState(svc models.ServiceConfig) *models.ServiceContainer {
+	if svc.Container == "" {
+		return nil
+	}
+	c, err := s.docker.FindContainer(svc.Container)
+	if err != nil {
+		return &models.ServiceContainer{Name: svc.Container, State: "unknown", Error: err.Error()}
+	}
+	return &models.ServiceContainer{ID: c.ID, Name: c.Name, State: c.State, Status: c.Status, Health: c.Health}
+}
+
+// hostDown returns the host name if the service's device is unreachable
+func (s *ServiceConfigService) hostDown(svc models.ServiceConfig) (string, bool) {
+	if !svc.IsActive || svc.DeviceID == nil || s.devices == nil {
+		return "", false
+	}
+	online, name := s.devices.HostOnline(*svc.DeviceID)
+	return name, !online
+}
+
+// runServiceCheck performs a single check without touching the database,
+// decrypting the service's secrets for it
+func runServiceCheck(clients *checker.Clients, svc models.ServiceConfig) ServiceStatus {
 	status := ServiceStatus{
 		ID:          svc.ID,
 		Name:        svc.Name,
@@ -109,88 +313,67 @@ func (s *ServiceConfigService) checkService(svc models.ServiceConfig) ServiceSta
 		Icon:        svc.Icon,
 		Category:    svc.Category,
 		Description: svc.Description,
-		Status:      "offline",
+		Status:      "error",
 		LastCheck:   time.Now(),
 		IsActive:    svc.IsActive,
 	}
 
-	if !svc.IsActive {
-		status.Status = "disabled"
+	var err error
+	if svc.ClientKey, err = DecryptSecret(svc.ClientKey); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if svc.DBPassword, err = DecryptSecret(svc.DBPassword); err != nil {
+		status.Error = err.Error()
 		return status
 	}
 
-	start := time.Now()
-
-	switch svc.Method {
-	case "TCP":
-		// TCP port check with fast timeout
-		host := svc.URL
-		if svc.Port > 0 {
-			host = fmt.Sprintf("%s:%d", svc.URL, svc.Port)
-		}
-		conn, err := net.DialTimeout("tcp", host, 1*time.Second)
-		if err == nil {
-			conn.Close()
-			status.Status = "online"
-		}
-	case "PING":
-		// Simple TCP ping to common ports
-		host := svc.URL
-		ports := []string{"80", "443", "22"}
-		for _, port := range ports {
-			conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), 500*time.Millisecond)
-			if err == nil {
-				conn.Close()
-				status.Status = "online"
-				break
-			}
-		}
-	default:
-		// HTTP/HTTPS check with fast timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-
-		req, err := http.NewRequestWithContext(ctx, "HEAD", svc.URL, nil)
-		if err != nil {
-			// Fallback to GET if HEAD fails
-			req, err = http.NewRequestWithContext(ctx, "GET", svc.URL, nil)
-			if err != nil {
-				status.Status = "error"
-				return status
-			}
-		}
-
-		// Set user agent to avoid bot detection
-		req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+	result := checker.Run(clients, svc)
+	status.Status = result.Status
+	status.StatusCode = result.StatusCode
+	status.ResponseTime = result.ResponseTime
+	status.Error = result.Error
+	status.LastCheck = result.CheckedAt
+	status.Timings = result.Timings
+	status.Database = result.Database
+	return status
+}
 
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			status.Status = "offline"
-		} else {
-			defer resp.Body.Close()
-			status.StatusCode = resp.StatusCode
-			if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-				status.Status = "online"
-			} else if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-				status.Status = "error"
-			} else {
-				status.Status = "offline"
-			}
-		}
+// recordCheck stores a check result in the service_checks history
+func (s *ServiceConfigService) recordCheck(status ServiceStatus) {
+	check := models.ServiceCheck{
+		ServiceID:    status.ID,
+		Status:       status.Status,
+		StatusCode:   status.StatusCode,
+		ResponseTime: status.ResponseTime,
+		Error:        status.Error,
+		CheckedAt:    status.LastCheck,
+	}
+	if len(check.Error) > 500 {
+		check.Error = check.Error[:500]
+	}
+	if status.Timings != nil {
+		check.DNSLookup = status.Timings.DNSLookup
+		check.TCPConnect = status.Timings.TCPConnect
+		check.TLSHandshake = status.Timings.TLSHandshake
+		check.TTFB = status.Timings.TTFB
 	}
 
-	status.ResponseTime = time.Since(start).Milliseconds()
-	return status
+	if err := s.db.Create(&check).Error; err != nil {
+		log.Printf("Failed to record check for service %d: %v", status.ID, err)
+	}
 }
 
 // GetService returns a single service by ID
 func (s *ServiceConfigService) GetService(id uint, userID uint) (*ServiceStatus, error) {
 	var svc models.ServiceConfig
-	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+	if err := s.db.Preload("Tags").Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
 		return nil, fmt.Errorf("service not found")
 	}
 
 	status := s.checkService(svc)
+	status.Container = s.containerState(svc)
+	status = s.withUptime([]ServiceStatus{status})[0]
 	return &status, nil
 }
 
@@ -210,22 +393,367 @@ func (s *ServiceConfigService) CreateService(userID uint, req models.ServiceConf
 		req.ExpectedCode = 200
 	}
 	req.IsActive = true
+	req.BadgeToken = GenerateBadgeToken()
+	if err := checker.ValidateProxyURL(req.ProxyURL); err != nil {
+		return nil, err
+	}
+	if req.Impact == "" {
+		req.Impact = models.ImpactMedium
+	} else if !ValidImpact(req.Impact) {
+		return nil, fmt.Errorf("invalid impact level %q", req.Impact)
+	}
+
+	if req.DeviceID != nil {
+		if err := s.ownsDevice(*req.DeviceID, userID); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.db.Create(&req).Error; err != nil {
+	// Tags are matched by name against the user's existing tags
+	tagNames := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	req.Tags = nil
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&req).Error; err != nil {
+			return err
+		}
+		tags, err := resolveTags(tx, userID, tagNames)
+		if err != nil {
+			return err
+		}
+		req.Tags = tags
+		return tx.Model(&req).Association("Tags").Replace(tags)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.invalidateStatus(userID)
 	return &req, nil
 }
 
-// UpdateService updates a service
-func (s *ServiceConfigService) UpdateService(id uint, userID uint, updates map[string]interface{}) (*models.ServiceConfig, error) {
+// UpdateService updates the fields of a service's check that req sets
+func (s *ServiceConfigService) UpdateService(id uint, userID uint, req models.UpdateServiceRequest) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		updates["name"] = name
+	}
+	if req.URL != nil {
+		url := strings.TrimSpace(*req.URL)
+		if url == "" {
+			return nil, fmt.Errorf("url cannot be empty")
+		}
+		updates["url"] = url
+	}
+	if req.Method != nil {
+		method := strings.ToUpper(strings.TrimSpace(*req.Method))
+		if method == "" {
+			method = "GET"
+		}
+		updates["method"] = method
+	}
+	if req.Port != nil {
+		if *req.Port < 0 || *req.Port > 65535 {
+			return nil, fmt.Errorf("invalid port %d", *req.Port)
+		}
+		updates["port"] = *req.Port
+	}
+	if req.Icon != nil {
+		updates["icon"] = *req.Icon
+	}
+	if req.Category != nil {
+		updates["category"] = *req.Category
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.CheckInterval != nil {
+		if *req.CheckInterval <= 0 {
+			return nil, fmt.Errorf("checkInterval must be positive")
+		}
+		updates["check_interval"] = *req.CheckInterval
+	}
+	if req.Timeout != nil {
+		if *req.Timeout <= 0 {
+			return nil, fmt.Errorf("timeout must be positive")
+		}
+		updates["timeout"] = *req.Timeout
+	}
+	if req.ExpectedCode != nil {
+		updates["expected_code"] = *req.ExpectedCode
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	// Tags live in a join table; accept names or {"name": ...} objects
+	var rawTags interface{}
+	if len(req.Tags) > 0 {
+		if err := json.Unmarshal(req.Tags, &rawTags); err != nil {
+			return nil, fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&svc).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		tags, err := resolveTags(s.db, userID, tagNamesFromJSON(rawTags))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.db.Model(&svc).Association("Tags").Replace(tags); err != nil {
+			return nil, err
+		}
+	}
+
+	s.db.Preload("Tags").First(&svc, svc.ID)
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
+
+// SetContainer links a service to the container running it.
+// ref is a container name or "label:key=value"; empty unlinks. Auto-restart
+// is a remediation hook restarting the container when the service goes down.
+func (s *ServiceConfigService) SetContainer(id uint, userID uint, ref string, autoRestart bool) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	ref = strings.TrimSpace(ref)
+	if ref != "" {
+		if _, err := s.docker.FindContainer(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	svc.Container = ref
+	svc.AutoRestart = autoRestart && ref != ""
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&svc).Select("container", "auto_restart").Updates(&svc).Error; err != nil {
+			return err
+		}
+		return syncAutoRestartHook(tx, svc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// RestartContainer restarts the container linked to a service
+func (s *ServiceConfigService) RestartContainer(id uint, userID uint) (*models.ServiceContainer, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	if svc.Container == "" {
+		return nil, fmt.Errorf("service has no linked container")
+	}
+
+	c, err := s.docker.FindContainer(svc.Container)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.docker.RestartContainer(c.ID); err != nil {
+		return nil, err
+	}
+	return s.containerState(svc), nil
+}
+
+// ownsDevice returns an error unless the device exists and belongs to the user
+func (s *ServiceConfigService) ownsDevice(deviceID uint, userID uint) error {
+	var count int64
+	s.db.Model(&models.Device{}).Where("id = ? AND user_id = ?", deviceID, userID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("device not found")
+	}
+	return nil
+}
+
+// SetDevice links a service to the device hosting it, or unlinks it when deviceID is nil
+func (s *ServiceConfigService) SetDevice(id uint, userID uint, deviceID *uint) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	if deviceID != nil {
+		if err := s.ownsDevice(*deviceID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	svc.DeviceID = deviceID
+	if err := s.db.Model(&svc).Select("device_id").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
+
+// UpdateBadgeSettings changes badge visibility and optionally rotates the badge token
+func (s *ServiceConfigService) UpdateBadgeSettings(id uint, userID uint, public *bool, regenerate bool) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	if public != nil {
+		svc.BadgePublic = *public
+	}
+	if regenerate || svc.BadgeToken == "" {
+		svc.BadgeToken = GenerateBadgeToken()
+	}
+
+	if err := s.db.Model(&svc).Select("badge_public", "badge_token").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+
+	return &svc, nil
+}
+
+// UpdateTLS changes the TLS options used when checking a service.
+// An empty CA bundle clears it and falls back to the system roots;
+// an empty client certificate clears the certificate and its key.
+func (s *ServiceConfigService) UpdateTLS(id uint, userID uint, req models.UpdateServiceTLSRequest) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	if req.TLSSkipVerify != nil {
+		svc.TLSSkipVerify = *req.TLSSkipVerify
+	}
+	if req.TLSServerName != nil {
+		svc.TLSServerName = strings.TrimSpace(*req.TLSServerName)
+	}
+	if req.CABundle != nil {
+		bundle := strings.TrimSpace(*req.CABundle)
+		if err := checker.ValidateCABundle(bundle); err != nil {
+			return nil, err
+		}
+		svc.CABundle = bundle
+	}
+
+	if req.ClientCert != nil {
+		cert := strings.TrimSpace(*req.ClientCert)
+		if cert == "" {
+			svc.ClientCert = ""
+			svc.ClientKey = ""
+		} else {
+			// Keep the stored key when only the certificate is renewed
+			key := ""
+			if req.ClientKey != nil {
+				key = strings.TrimSpace(*req.ClientKey)
+			} else if svc.ClientKey != "" {
+				stored, err := DecryptSecret(svc.ClientKey)
+				if err != nil {
+					return nil, err
+				}
+				key = stored
+			}
+			if err := checker.ValidateClientCertificate(cert, key); err != nil {
+				return nil, err
+			}
+
+			encrypted, err := EncryptSecret(key)
+			if err != nil {
+				return nil, err
+			}
+			svc.ClientCert = cert
+			svc.ClientKey = encrypted
+		}
+	} else if req.ClientKey != nil {
+		return nil, fmt.Errorf("a client key requires a client certificate")
+	}
+
+	if err := s.db.Model(&svc).Select("tls_skip_verify", "tls_server_name", "ca_bundle", "client_cert", "client_key").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
+
+// UpdateProxy sets the proxy used to check a service.
+// Empty uses the global check proxy; "direct" bypasses it.
+func (s *ServiceConfigService) UpdateProxy(id uint, userID uint, proxyURL string) (*models.ServiceConfig, error) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if err := checker.ValidateProxyURL(proxyURL); err != nil {
+		return nil, err
+	}
+
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	svc.ProxyURL = proxyURL
+	if err := s.db.Model(&svc).Select("proxy_url").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
+
+// UpdateDatabasePassword stores the password used by POSTGRES, MYSQL and REDIS checks.
+// An empty password clears it.
+func (s *ServiceConfigService) UpdateDatabasePassword(id uint, userID uint, password string) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	if !checker.IsDatabaseMethod(svc.Method) {
+		return nil, fmt.Errorf("service method must be %s, %s or %s", checker.MethodPostgres, checker.MethodMySQL, checker.MethodRedis)
+	}
+
+	svc.DBPassword = ""
+	if password != "" {
+		encrypted, err := EncryptSecret(password)
+		if err != nil {
+			return nil, err
+		}
+		svc.DBPassword = encrypted
+	}
+	if err := s.db.Model(&svc).Select("db_password").Updates(&svc).Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateStatus(userID)
+	return &svc, nil
+}
+
+// UpdateImpact sets how much a service's downtime matters and who it affects
+func (s *ServiceConfigService) UpdateImpact(id uint, userID uint, impact, note string) (*models.ServiceConfig, error) {
+	if !ValidImpact(impact) {
+		return nil, fmt.Errorf("invalid impact level %q", impact)
+	}
+
 	var svc models.ServiceConfig
 	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
 		return nil, fmt.Errorf("service not found")
 	}
 
-	if err := s.db.Model(&svc).Updates(updates).Error; err != nil {
+	svc.Impact = impact
+	svc.ImpactNote = strings.TrimSpace(note)
+	if err := s.db.Model(&svc).Select("impact", "impact_note").Updates(&svc).Error; err != nil {
 		return nil, err
 	}
 
@@ -238,6 +766,8 @@ func (s *ServiceConfigService) DeleteService(id uint, userID uint) error {
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("service not found")
 	}
+	s.forgetStreak(id)
+	s.invalidateStatus(userID)
 	return result.Error
 }
 
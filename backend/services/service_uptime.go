@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+const (
+	// serviceCheckRetention is how long service check history is kept
+	serviceCheckRetention = 90 * 24 * time.Hour
+	// MaxServiceHistoryHours is the longest range of checks returned at once
+	MaxServiceHistoryHours = 30 * 24
+	// MaxServiceHistoryChecks caps the number of checks returned at once
+	MaxServiceHistoryChecks = 10000
+)
+
+// uptimeCounts is the number of checks and online checks of one service
+type uptimeCounts struct {
+	ServiceID uint
+	Total     int64
+	Online    int64
+}
+
+// uptimeByService computes the uptime of the given services over every
+// availability window from their local checks
+func (s *ServiceConfigService) uptimeByService(ids []uint) (map[uint][]models.ServiceUptime, error) {
+	uptime := make(map[uint][]models.ServiceUptime, len(ids))
+	if len(ids) == 0 {
+		return uptime, nil
+	}
+
+	now := time.Now()
+	for _, w := range availabilityWindows {
+		var rows []uptimeCounts
+		if err := s.db.Model(&models.ServiceCheck{}).
+			Select("service_id, COUNT(*) AS total, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS online", "online").
+			Where("service_id IN ? AND location = ? AND checked_at >= ?", ids, "", now.Add(-w.length)).
+			Group("service_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		counts := make(map[uint]uptimeCounts, len(rows))
+		for _, row := range rows {
+			counts[row.ServiceID] = row
+		}
+
+		for _, id := range ids {
+			c := counts[id]
+			window := models.ServiceUptime{Window: w.name, Checks: c.Total, Failed: c.Total - c.Online}
+			if c.Total > 0 {
+				percent := roundPercent(float64(c.Online) / float64(c.Total) * 100)
+				window.UptimePercent = &percent
+			}
+			uptime[id] = append(uptime[id], window)
+		}
+	}
+	return uptime, nil
+}
+
+// withUptime fills the uptime windows of a list of statuses
+func (s *ServiceConfigService) withUptime(statuses []ServiceStatus) []ServiceStatus {
+	ids := make([]uint, len(statuses))
+	for i, status := range statuses {
+		ids[i] = status.ID
+	}
+	uptime, err := s.uptimeByService(ids)
+	if err != nil {
+		return statuses
+	}
+	for i := range statuses {
+		windows := uptime[statuses[i].ID]
+		statuses[i].Uptime = windows
+		if len(windows) > 0 && windows[0].UptimePercent != nil {
+			statuses[i].UptimePercent = *windows[0].UptimePercent
+		}
+	}
+	return statuses
+}
+
+// GetHistory returns the service's local checks over the last hours hours,
+// newest first and at most limit of them, with its uptime windows
+func (s *ServiceConfigService) GetHistory(id uint, userID uint, hours, limit int) (*models.ServiceHistory, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Select("id").Where("id = ? AND user_id = ?", id, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	if hours <= 0 || hours > MaxServiceHistoryHours {
+		hours = 24
+	}
+	if limit <= 0 || limit > MaxServiceHistoryChecks {
+		limit = 1000
+	}
+
+	history := &models.ServiceHistory{ServiceID: svc.ID, Checks: make([]models.ServiceCheck, 0)}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	if err := s.db.Where("service_id = ? AND location = ? AND checked_at >= ?", svc.ID, "", since).
+		Order("checked_at DESC").Limit(limit).Find(&history.Checks).Error; err != nil {
+		return nil, err
+	}
+
+	uptime, err := s.uptimeByService([]uint{svc.ID})
+	if err != nil {
+		return nil, err
+	}
+	history.Uptime = uptime[svc.ID]
+	return history, nil
+}
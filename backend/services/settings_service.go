@@ -0,0 +1,167 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettingsService stores instance-wide settings that admins change at runtime
+type SettingsService struct {
+	db *gorm.DB
+
+	// The interface settings are read on every network metrics call
+	mu                sync.RWMutex
+	networkInterfaces *models.NetworkInterfaceSettings
+}
+
+// NewSettingsService creates a new SettingsService
+func NewSettingsService() *SettingsService {
+	return &SettingsService{db: database.GetDB()}
+}
+
+// load decodes a stored setting into dest and reports whether it exists
+func (s *SettingsService) load(key string, dest interface{}) bool {
+	var setting models.AppSetting
+	if err := s.db.Where("setting_key = ?", key).First(&setting).Error; err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(setting.Value), dest) == nil
+}
+
+// save stores a setting, replacing any previous value
+func (s *SettingsService) save(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	setting := models.AppSetting{Key: key, Value: string(data)}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "setting_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&setting).Error
+}
+
+// PasswordPolicy returns the configured password policy, or the default
+func (s *SettingsService) PasswordPolicy() models.PasswordPolicy {
+	policy := models.DefaultPasswordPolicy()
+	s.load(models.SettingPasswordPolicy, &policy)
+	return policy
+}
+
+// UpdatePasswordPolicy validates and stores the password policy
+func (s *SettingsService) UpdatePasswordPolicy(policy models.PasswordPolicy) (*models.PasswordPolicy, error) {
+	switch {
+	case policy.MinLength < 6 || policy.MinLength > 128:
+		return nil, fmt.Errorf("minLength must be between 6 and 128")
+	case policy.ExpiryDays < 0:
+		return nil, fmt.Errorf("expiryDays must not be negative")
+	case policy.HistoryCount < 0 || policy.HistoryCount > 24:
+		return nil, fmt.Errorf("historyCount must be between 0 and 24")
+	case policy.MaxFailedLogins < 0:
+		return nil, fmt.Errorf("maxFailedLogins must not be negative")
+	case policy.MaxFailedLogins > 0 && policy.LockoutMinutes <= 0:
+		return nil, fmt.Errorf("lockoutMinutes must be positive when lockout is enabled")
+	}
+
+	if err := s.save(models.SettingPasswordPolicy, policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Limits of the network interface settings
+const (
+	maxInterfacePatterns = 100
+	maxInterfaceName     = 64
+	maxFriendlyName      = 50
+)
+
+// NetworkInterfaces returns the network interface selection and names, or
+// the default
+func (s *SettingsService) NetworkInterfaces() models.NetworkInterfaceSettings {
+	s.mu.RLock()
+	cached := s.networkInterfaces
+	s.mu.RUnlock()
+	if cached == nil {
+		settings := models.DefaultNetworkInterfaceSettings()
+		s.load(models.SettingNetworkInterfaces, &settings)
+		cached = &settings
+		s.mu.Lock()
+		s.networkInterfaces = cached
+		s.mu.Unlock()
+	}
+
+	// Callers get their own copy of the slices and map
+	settings := *cached
+	settings.Include = append([]string{}, cached.Include...)
+	settings.Exclude = append([]string{}, cached.Exclude...)
+	settings.Names = maps.Clone(cached.Names)
+	if settings.Names == nil {
+		settings.Names = map[string]string{}
+	}
+	return settings
+}
+
+// UpdateNetworkInterfaces validates and stores the network interface
+// selection and names. Empty names are dropped.
+func (s *SettingsService) UpdateNetworkInterfaces(settings models.NetworkInterfaceSettings) (*models.NetworkInterfaceSettings, error) {
+	cleanPatterns := func(field string, patterns []string) ([]string, error) {
+		if len(patterns) > maxInterfacePatterns {
+			return nil, fmt.Errorf("%s has more than %d patterns", field, maxInterfacePatterns)
+		}
+		clean := make([]string, 0, len(patterns))
+		for _, pattern := range patterns {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" || len(pattern) > maxInterfaceName {
+				return nil, fmt.Errorf("%s patterns must be 1 to %d characters", field, maxInterfaceName)
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid %s pattern %q", field, pattern)
+			}
+			clean = append(clean, pattern)
+		}
+		return clean, nil
+	}
+
+	var err error
+	if settings.Include, err = cleanPatterns("include", settings.Include); err != nil {
+		return nil, err
+	}
+	if settings.Exclude, err = cleanPatterns("exclude", settings.Exclude); err != nil {
+		return nil, err
+	}
+	if len(settings.Names) > maxInterfacePatterns {
+		return nil, fmt.Errorf("at most %d interfaces can be named", maxInterfacePatterns)
+	}
+	names := make(map[string]string, len(settings.Names))
+	for iface, name := range settings.Names {
+		iface, name = strings.TrimSpace(iface), strings.TrimSpace(name)
+		if iface == "" || len(iface) > maxInterfaceName {
+			return nil, fmt.Errorf("interface names must be 1 to %d characters", maxInterfaceName)
+		}
+		if len(name) > maxFriendlyName {
+			return nil, fmt.Errorf("the name of %s is longer than %d characters", iface, maxFriendlyName)
+		}
+		if name != "" {
+			names[iface] = name
+		}
+	}
+	settings.Names = names
+
+	if err := s.save(models.SettingNetworkInterfaces, settings); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.networkInterfaces = nil
+	s.mu.Unlock()
+	return &settings, nil
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"github.com/shirou/gopsutil/v3/host"
+	"gorm.io/gorm"
+)
+
+// SnapshotService captures periodic snapshots of the whole homelab state
+// (devices, services, containers, versions) and diffs them
+type SnapshotService struct {
+	db            *gorm.DB
+	devices       *DeviceService
+	services      *ServiceConfigService
+	docker        *DockerService
+	interval      time.Duration
+	retentionDays int
+
+	mu sync.Mutex
+}
+
+// NewSnapshotService creates a new SnapshotService and starts the scheduler when enabled
+func NewSnapshotService(devices *DeviceService, services *ServiceConfigService, docker *DockerService) *SnapshotService {
+	cfg := config.AppConfig
+	s := &SnapshotService{
+		db:            database.GetDB(),
+		devices:       devices,
+		services:      services,
+		docker:        docker,
+		interval:      time.Duration(cfg.SnapshotIntervalHours) * time.Hour,
+		retentionDays: cfg.SnapshotRetentionDays,
+	}
+
+	if s.interval > 0 {
+		go s.snapshotBackground()
+	}
+
+	return s
+}
+
+// snapshotBackground takes a snapshot whenever the latest one is older than
+// the interval, so restarts don't reset the schedule
+func (s *SnapshotService) snapshotBackground() {
+	time.Sleep(time.Minute)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		var latest models.Snapshot
+		err := s.db.Order("taken_at DESC").First(&latest).Error
+		if err != nil || time.Since(latest.TakenAt) >= s.interval {
+			if _, err := s.TakeSnapshot(); err != nil {
+				log.Printf("Failed to take snapshot: %v", err)
+			}
+
+			cutoff := time.Now().AddDate(0, 0, -s.retentionDays)
+			s.db.Where("taken_at < ?", cutoff).Delete(&models.Snapshot{})
+		}
+		<-ticker.C
+	}
+}
+
+// TakeSnapshot captures and stores the current homelab state
+func (s *SnapshotService) TakeSnapshot() (*models.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.capture()
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := models.Snapshot{
+		Devices:    len(data.Devices),
+		Services:   len(data.Services),
+		Containers: len(data.Containers),
+		Data:       string(encoded),
+		TakenAt:    data.TakenAt,
+	}
+	if err := s.db.Create(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// capture collects live state from every subsystem
+func (s *SnapshotService) capture() models.SnapshotData {
+	data := models.SnapshotData{
+		TakenAt:    time.Now(),
+		Versions:   map[string]string{"backend": config.Version},
+		Devices:    []models.SnapshotDevice{},
+		Services:   []models.SnapshotService{},
+		Containers: []models.SnapshotContainer{},
+	}
+
+	if info, err := host.Info(); err == nil {
+		data.Versions["hostname"] = info.Hostname
+		data.Versions["os"] = strings.TrimSpace(info.Platform + " " + info.PlatformVersion)
+		data.Versions["kernel"] = info.KernelVersion
+	}
+	if v := s.docker.ServerVersion(); v != "" {
+		data.Versions["docker"] = v
+	}
+
+	var wg sync.WaitGroup
+
+	var devices []models.Device
+	s.db.Order("name ASC").Find(&devices)
+	data.Devices = make([]models.SnapshotDevice, len(devices))
+	for i, d := range devices {
+		data.Devices[i] = models.SnapshotDevice{
+			ID: d.ID, Name: d.Name, IP: d.IP, MAC: d.MAC, Type: d.Type, IsActive: d.IsActive,
+		}
+		wg.Add(1)
+		go func(idx int, ip string) {
+			defer wg.Done()
+			data.Devices[idx].IsOnline = s.devices.pingDeviceFast(ip)
+		}(i, d.IP)
+	}
+
+	// Services keep the status of their last scheduled check, or else the
+	// stored history; checking them here would record checks, notify and
+	// restart containers
+	var configs []models.ServiceConfig
+	s.db.Order("name ASC").Find(&configs)
+	var unchecked []uint
+	for _, svc := range configs {
+		if _, ok := s.services.LatestStatus(svc.ID); !ok {
+			unchecked = append(unchecked, svc.ID)
+		}
+	}
+	stored := s.services.lastChecks(unchecked)
+	data.Services = make([]models.SnapshotService, len(configs))
+	for i, svc := range configs {
+		data.Services[i] = models.SnapshotService{ID: svc.ID, Name: svc.Name, URL: svc.URL, Status: "unknown"}
+		if latest, ok := s.services.LatestStatus(svc.ID); ok {
+			data.Services[i].Status, data.Services[i].StatusCode = latest.Status, latest.StatusCode
+		} else if check, ok := stored[svc.ID]; ok {
+			data.Services[i].Status, data.Services[i].StatusCode = check.Status, check.StatusCode
+		}
+	}
+
+	for _, c := range s.docker.GetContainersBasic() {
+		ports := make([]string, 0, len(c.Ports))
+		for _, p := range c.Ports {
+			if p.PublicPort > 0 {
+				ports = append(ports, fmt.Sprintf("%d:%d/%s", p.PublicPort, p.PrivatePort, p.Type))
+			}
+		}
+		sort.Strings(ports)
+		data.Containers = append(data.Containers, models.SnapshotContainer{
+			Name:    c.Name,
+			Image:   c.Image,
+			ImageID: c.ImageID,
+			State:   c.State,
+			Ports:   strings.Join(ports, ","),
+		})
+	}
+	sort.Slice(data.Containers, func(i, j int) bool { return data.Containers[i].Name < data.Containers[j].Name })
+
+	wg.Wait()
+	return data
+}
+
+// GetSnapshots returns snapshot summaries, newest first
+func (s *SnapshotService) GetSnapshots(limit int) ([]models.Snapshot, error) {
+	var snapshots []models.Snapshot
+	if err := s.db.Omit("data").Order("taken_at DESC").Limit(limit).Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot returns a snapshot with its decoded data
+func (s *SnapshotService) GetSnapshot(id uint) (*models.SnapshotDetail, error) {
+	var snapshot models.Snapshot
+	if err := s.db.First(&snapshot, id).Error; err != nil {
+		return nil, fmt.Errorf("snapshot not found")
+	}
+	return decodeSnapshot(snapshot)
+}
+
+// FindSnapshot returns the latest snapshot taken at or before t
+func (s *SnapshotService) FindSnapshot(t time.Time) (*models.SnapshotDetail, error) {
+	var snapshot models.Snapshot
+	if err := s.db.Where("taken_at <= ?", t).Order("taken_at DESC").First(&snapshot).Error; err != nil {
+		return nil, fmt.Errorf("no snapshot found before %s", t.Format(time.RFC3339))
+	}
+	return decodeSnapshot(snapshot)
+}
+
+func decodeSnapshot(snapshot models.Snapshot) (*models.SnapshotDetail, error) {
+	detail := &models.SnapshotDetail{Snapshot: snapshot}
+	if err := json.Unmarshal([]byte(snapshot.Data), &detail.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %v", err)
+	}
+	return detail, nil
+}
+
+// Diff compares two snapshots and lists what changed from a to b
+func (s *SnapshotService) Diff(a, b *models.SnapshotDetail) models.SnapshotDiff {
+	diff := models.SnapshotDiff{From: a.Snapshot, To: b.Snapshot, Changes: []models.SnapshotChange{}}
+
+	add := func(kind, name, change, field, from, to string) {
+		diff.Changes = append(diff.Changes, models.SnapshotChange{
+			Kind: kind, Name: name, Change: change, Field: field, From: from, To: to,
+		})
+	}
+
+	// Versions
+	for _, key := range sortedKeys(a.Data.Versions, b.Data.Versions) {
+		if a.Data.Versions[key] != b.Data.Versions[key] {
+			add("version", key, "changed", "", a.Data.Versions[key], b.Data.Versions[key])
+		}
+	}
+
+	// Devices, keyed by ID
+	oldDevices := make(map[uint]models.SnapshotDevice)
+	for _, d := range a.Data.Devices {
+		oldDevices[d.ID] = d
+	}
+	for _, d := range b.Data.Devices {
+		old, ok := oldDevices[d.ID]
+		if !ok {
+			add("device", d.Name, "added", "", "", d.IP)
+			continue
+		}
+		delete(oldDevices, d.ID)
+		diffField(add, "device", d.Name, "name", old.Name, d.Name)
+		diffField(add, "device", d.Name, "ip", old.IP, d.IP)
+		diffField(add, "device", d.Name, "mac", old.MAC, d.MAC)
+		diffField(add, "device", d.Name, "online", fmt.Sprint(old.IsOnline), fmt.Sprint(d.IsOnline))
+		diffField(add, "device", d.Name, "active", fmt.Sprint(old.IsActive), fmt.Sprint(d.IsActive))
+	}
+	for _, d := range a.Data.Devices {
+		if _, ok := oldDevices[d.ID]; ok {
+			add("device", d.Name, "removed", "", d.IP, "")
+		}
+	}
+
+	// Services, keyed by ID
+	oldServices := make(map[uint]models.SnapshotService)
+	for _, svc := range a.Data.Services {
+		oldServices[svc.ID] = svc
+	}
+	for _, svc := range b.Data.Services {
+		old, ok := oldServices[svc.ID]
+		if !ok {
+			add("service", svc.Name, "added", "", "", svc.URL)
+			continue
+		}
+		delete(oldServices, svc.ID)
+		diffField(add, "service", svc.Name, "name", old.Name, svc.Name)
+		diffField(add, "service", svc.Name, "url", old.URL, svc.URL)
+		diffField(add, "service", svc.Name, "status", old.Status, svc.Status)
+	}
+	for _, svc := range a.Data.Services {
+		if _, ok := oldServices[svc.ID]; ok {
+			add("service", svc.Name, "removed", "", svc.URL, "")
+		}
+	}
+
+	// Containers, keyed by name since IDs change on recreate
+	oldContainers := make(map[string]models.SnapshotContainer)
+	for _, c := range a.Data.Containers {
+		oldContainers[c.Name] = c
+	}
+	for _, c := range b.Data.Containers {
+		old, ok := oldContainers[c.Name]
+		if !ok {
+			add("container", c.Name, "added", "", "", c.Image)
+			continue
+		}
+		delete(oldContainers, c.Name)
+		diffField(add, "container", c.Name, "image", old.Image, c.Image)
+		if old.Image == c.Image {
+			diffField(add, "container", c.Name, "imageId", old.ImageID, c.ImageID)
+		}
+		diffField(add, "container", c.Name, "state", old.State, c.State)
+		diffField(add, "container", c.Name, "ports", old.Ports, c.Ports)
+	}
+	for _, c := range a.Data.Containers {
+		if _, ok := oldContainers[c.Name]; ok {
+			add("container", c.Name, "removed", "", c.Image, "")
+		}
+	}
+
+	return diff
+}
+
+// diffField records a "changed" entry when the values differ
+func diffField(add func(kind, name, change, field, from, to string), kind, name, field, from, to string) {
+	if from != to {
+		add(kind, name, "changed", field, from, to)
+	}
+}
+
+// sortedKeys returns the union of keys of both maps, sorted
+func sortedKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]string{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,564 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"github.com/homelab/backend/snmp"
+	"gorm.io/gorm"
+)
+
+// Objects polled from the agents
+const (
+	oidSysDescr    = "1.3.6.1.2.1.1.1.0"
+	oidSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	oidSysName     = "1.3.6.1.2.1.1.5.0"
+	oidSysLocation = "1.3.6.1.2.1.1.6.0"
+
+	oidIfTable  = "1.3.6.1.2.1.2.2.1"
+	oidIfXTable = "1.3.6.1.2.1.31.1.1.1"
+
+	// HOST-RESOURCES-MIB
+	oidHrProcessorLoad  = "1.3.6.1.2.1.25.3.3.1.2"
+	oidHrStorageTable   = "1.3.6.1.2.1.25.2.3.1"
+	oidHrStorageRAM     = "1.3.6.1.2.1.25.2.1.2"
+	oidHrStorageFixDisk = "1.3.6.1.2.1.25.2.1.4"
+
+	// UCD-SNMP-MIB (net-snmp), memory in kB
+	oidSsCPUIdle    = "1.3.6.1.4.1.2021.11.11.0"
+	oidMemTotalReal = "1.3.6.1.4.1.2021.4.5.0"
+	oidMemAvailReal = "1.3.6.1.4.1.2021.4.6.0"
+	oidMemBuffer    = "1.3.6.1.4.1.2021.4.14.0"
+	oidMemCached    = "1.3.6.1.4.1.2021.4.15.0"
+)
+
+// ifOperStatus values, indexed from 1
+var ifOperStatusNames = []string{"", "up", "down", "testing", "unknown", "dormant", "notPresent", "lowerLayerDown"}
+
+const (
+	// snmpPollWorkers bounds how many agents are polled at once
+	snmpPollWorkers = 8
+	// snmpRefreshMinAge is how recent a poll must be to answer a refresh
+	snmpRefreshMinAge = 5 * time.Second
+)
+
+var (
+	// ErrSNMPNotConfigured is returned for devices without SNMP settings
+	ErrSNMPNotConfigured = errors.New("SNMP is not configured for this device")
+	// ErrInvalidSNMPConfig is returned for SNMP settings that cannot work
+	ErrInvalidSNMPConfig = errors.New("invalid SNMP settings")
+)
+
+// SNMPService polls devices' SNMP agents for interface counters, uptime,
+// CPU and memory. The latest poll of each device is kept in memory.
+type SNMPService struct {
+	db      *gorm.DB
+	devices *DeviceService
+
+	mu     sync.Mutex
+	status map[uint]*models.SNMPStatus
+}
+
+// NewSNMPService creates a new SNMPService and starts polling
+func NewSNMPService(devices *DeviceService) *SNMPService {
+	s := &SNMPService{
+		db:      database.GetDB(),
+		devices: devices,
+		status:  make(map[uint]*models.SNMPStatus),
+	}
+
+	if interval := config.AppConfig.SNMPPollInterval; interval > 0 {
+		go s.pollBackground(time.Duration(interval) * time.Second)
+	}
+
+	return s
+}
+
+// pollBackground polls every enabled agent on the interval
+func (s *SNMPService) pollBackground(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var configs []models.SNMPConfig
+		if err := s.db.Where("enabled = ?", true).
+			Where("device_id IN (?)", s.db.Model(&models.Device{}).Select("id").Where("is_active = ?", true)).
+			Find(&configs).Error; err != nil {
+			log.Printf("Failed to load SNMP settings: %v", err)
+			continue
+		}
+
+		sem := make(chan struct{}, snmpPollWorkers)
+		var wg sync.WaitGroup
+		for _, cfg := range configs {
+			var device models.Device
+			if err := s.db.Select("id", "ip").First(&device, cfg.DeviceID).Error; err != nil {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(cfg models.SNMPConfig, host string) {
+				defer wg.Done()
+				s.poll(cfg, host)
+				<-sem
+			}(cfg, device.IP)
+		}
+		wg.Wait()
+	}
+}
+
+// GetConfig returns a device's SNMP settings
+func (s *SNMPService) GetConfig(deviceID, userID uint) (*models.SNMPConfig, error) {
+	if _, err := s.devices.GetDevice(deviceID, userID); err != nil {
+		return nil, err
+	}
+	var cfg models.SNMPConfig
+	if err := s.db.Where("device_id = ?", deviceID).First(&cfg).Error; err != nil {
+		return nil, ErrSNMPNotConfigured
+	}
+	return &cfg, nil
+}
+
+// SaveConfig creates or replaces a device's SNMP settings. An empty
+// community or password keeps the stored one; clearing a protocol drops
+// its password.
+func (s *SNMPService) SaveConfig(deviceID, userID uint, req models.SNMPConfigRequest) (*models.SNMPConfig, error) {
+	if _, err := s.devices.GetDevice(deviceID, userID); err != nil {
+		return nil, err
+	}
+
+	var cfg models.SNMPConfig
+	if err := s.db.Where("device_id = ?", deviceID).First(&cfg).Error; err != nil {
+		cfg = models.SNMPConfig{DeviceID: deviceID, Enabled: true}
+	}
+	if req.Enabled != nil {
+		cfg.Enabled = *req.Enabled
+	}
+	cfg.Version = strings.TrimSpace(req.Version)
+	if cfg.Version == "" {
+		cfg.Version = snmp.Version2c
+	}
+	cfg.Port = req.Port
+	cfg.User = strings.TrimSpace(req.User)
+	cfg.AuthProtocol = strings.ToUpper(strings.TrimSpace(req.AuthProtocol))
+	cfg.PrivProtocol = strings.ToUpper(strings.TrimSpace(req.PrivProtocol))
+
+	secrets := []struct {
+		value  string
+		stored *string
+		keep   bool
+	}{
+		{req.Community, &cfg.Community, cfg.Version == snmp.Version2c},
+		{req.AuthPassword, &cfg.AuthPassword, cfg.Version == snmp.Version3 && cfg.AuthProtocol != ""},
+		{req.PrivPassword, &cfg.PrivPassword, cfg.Version == snmp.Version3 && cfg.PrivProtocol != ""},
+	}
+	for _, secret := range secrets {
+		if !secret.keep {
+			*secret.stored = ""
+			continue
+		}
+		if secret.value != "" {
+			encrypted, err := EncryptSecret(secret.value)
+			if err != nil {
+				return nil, err
+			}
+			*secret.stored = encrypted
+		}
+	}
+
+	clientCfg, err := snmpClientConfig(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := snmp.Validate(clientCfg); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSNMPConfig, strings.TrimPrefix(err.Error(), "snmp: "))
+	}
+
+	if err := s.db.Save(&cfg).Error; err != nil {
+		return nil, err
+	}
+
+	// Counters from other credentials or another agent make no rates
+	s.mu.Lock()
+	delete(s.status, deviceID)
+	s.mu.Unlock()
+	return &cfg, nil
+}
+
+// DeleteConfig removes a device's SNMP settings
+func (s *SNMPService) DeleteConfig(deviceID, userID uint) error {
+	if _, err := s.devices.GetDevice(deviceID, userID); err != nil {
+		return err
+	}
+	result := s.db.Where("device_id = ?", deviceID).Delete(&models.SNMPConfig{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSNMPNotConfigured
+	}
+
+	s.mu.Lock()
+	delete(s.status, deviceID)
+	s.mu.Unlock()
+	return nil
+}
+
+// GetStatus returns the latest poll of a device's agent, polling it now when
+// there is none yet or refresh is set
+func (s *SNMPService) GetStatus(deviceID, userID uint, refresh bool) (*models.SNMPStatus, error) {
+	device, err := s.devices.GetDevice(deviceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var cfg models.SNMPConfig
+	if err := s.db.Where("device_id = ?", deviceID).First(&cfg).Error; err != nil {
+		return nil, ErrSNMPNotConfigured
+	}
+
+	s.mu.Lock()
+	status := s.status[deviceID]
+	s.mu.Unlock()
+	if status == nil || (refresh && time.Since(status.PolledAt) > snmpRefreshMinAge) {
+		status = s.poll(cfg, device.IP)
+	}
+	return status, nil
+}
+
+// snmpClientConfig builds the client settings from stored ones, decrypting
+// the secrets
+func snmpClientConfig(cfg models.SNMPConfig, host string) (snmp.Config, error) {
+	clientCfg := snmp.Config{
+		Host:         host,
+		Port:         cfg.Port,
+		Version:      cfg.Version,
+		User:         cfg.User,
+		AuthProtocol: cfg.AuthProtocol,
+		PrivProtocol: cfg.PrivProtocol,
+	}
+	secrets := []struct {
+		stored string
+		value  *string
+	}{
+		{cfg.Community, &clientCfg.Community},
+		{cfg.AuthPassword, &clientCfg.AuthPassword},
+		{cfg.PrivPassword, &clientCfg.PrivPassword},
+	}
+	for _, secret := range secrets {
+		value, err := DecryptSecret(secret.stored)
+		if err != nil {
+			return snmp.Config{}, err
+		}
+		*secret.value = value
+	}
+	return clientCfg, nil
+}
+
+// poll queries an agent and stores the result. A failed poll keeps the
+// previous values with the error.
+func (s *SNMPService) poll(cfg models.SNMPConfig, host string) *models.SNMPStatus {
+	s.mu.Lock()
+	previous := s.status[cfg.DeviceID]
+	s.mu.Unlock()
+
+	status, err := collectSNMP(cfg, host)
+	if err != nil {
+		failed := &models.SNMPStatus{DeviceID: cfg.DeviceID, Storage: []models.SNMPStorage{}, Interfaces: []models.SNMPInterface{}}
+		if previous != nil {
+			copied := *previous
+			failed = &copied
+		}
+		failed.PolledAt = time.Now()
+		failed.Error = err.Error()
+		status = failed
+	} else if previous != nil && previous.Error == "" {
+		interfaceRates(previous, status)
+	}
+
+	s.mu.Lock()
+	s.status[cfg.DeviceID] = status
+	s.mu.Unlock()
+	return status
+}
+
+// collectSNMP polls an agent once
+func collectSNMP(cfg models.SNMPConfig, host string) (*models.SNMPStatus, error) {
+	clientCfg, err := snmpClientConfig(cfg, host)
+	if err != nil {
+		return nil, err
+	}
+	client, err := snmp.Dial(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	status := &models.SNMPStatus{
+		DeviceID:   cfg.DeviceID,
+		Storage:    []models.SNMPStorage{},
+		Interfaces: []models.SNMPInterface{},
+		PolledAt:   time.Now(),
+	}
+
+	vars, err := client.Get(oidSysDescr, oidSysUpTime, oidSysName, oidSysLocation)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vars {
+		switch v.OID {
+		case oidSysDescr:
+			status.SysDescr = v.String()
+		case oidSysUpTime:
+			ticks, _ := v.Uint64()
+			status.UptimeSeconds = ticks / 100
+		case oidSysName:
+			status.SysName = v.String()
+		case oidSysLocation:
+			status.SysLocation = v.String()
+		}
+	}
+
+	// The rest is optional: agents expose different MIBs
+	if err := collectInterfaces(client, status); err != nil {
+		return nil, err
+	}
+	collectCPU(client, status)
+	collectMemory(client, status)
+	return status, nil
+}
+
+// tableColumn splits a table object's OID into its column and row index
+func tableColumn(oid, table string) (int, int, bool) {
+	column, index, ok := strings.Cut(strings.TrimPrefix(oid, table+"."), ".")
+	if !ok {
+		return 0, 0, false
+	}
+	c, err1 := strconv.Atoi(column)
+	i, err2 := strconv.Atoi(index)
+	return c, i, err1 == nil && err2 == nil
+}
+
+// collectInterfaces reads ifTable, then the 64-bit counters and names of
+// ifXTable where the agent has them
+func collectInterfaces(client *snmp.Client, status *models.SNMPStatus) error {
+	interfaces := make(map[int]*models.SNMPInterface)
+	var order []int
+	row := func(index int) *models.SNMPInterface {
+		iface, ok := interfaces[index]
+		if !ok {
+			iface = &models.SNMPInterface{Index: index, Status: "unknown"}
+			interfaces[index] = iface
+			order = append(order, index)
+		}
+		return iface
+	}
+
+	err := client.Walk(oidIfTable, func(v snmp.Variable) error {
+		column, index, ok := tableColumn(v.OID, oidIfTable)
+		if !ok {
+			return nil
+		}
+		n, _ := v.Uint64()
+		switch column {
+		case 2:
+			row(index).Name = v.String()
+		case 5:
+			row(index).SpeedMbps = n / 1_000_000
+		case 8:
+			if n > 0 && n < uint64(len(ifOperStatusNames)) {
+				row(index).Status = ifOperStatusNames[n]
+			}
+		case 10:
+			row(index).InOctets = n
+		case 14:
+			row(index).InErrors = n
+		case 16:
+			row(index).OutOctets = n
+		case 20:
+			row(index).OutErrors = n
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// ifXTable is optional; a failure leaves the ifTable values
+	client.Walk(oidIfXTable, func(v snmp.Variable) error {
+		column, index, ok := tableColumn(v.OID, oidIfXTable)
+		if !ok {
+			return nil
+		}
+		iface, known := interfaces[index]
+		if !known {
+			return nil
+		}
+		n, _ := v.Uint64()
+		switch column {
+		case 1:
+			if name := v.String(); name != "" {
+				iface.Name = name
+			}
+		case 6:
+			iface.InOctets = n
+			iface.HighCounter = true
+		case 10:
+			iface.OutOctets = n
+		case 15:
+			if n > 0 {
+				iface.SpeedMbps = n
+			}
+		case 18:
+			iface.Alias = v.String()
+		}
+		return nil
+	})
+
+	for _, index := range order {
+		status.Interfaces = append(status.Interfaces, *interfaces[index])
+	}
+	return nil
+}
+
+// collectCPU averages hrProcessorLoad, falling back to UCD's idle percentage
+func collectCPU(client *snmp.Client, status *models.SNMPStatus) {
+	var total, count uint64
+	client.Walk(oidHrProcessorLoad, func(v snmp.Variable) error {
+		if n, ok := v.Uint64(); ok {
+			total += n
+			count++
+		}
+		return nil
+	})
+	if count > 0 {
+		cpu := float64(total) / float64(count)
+		status.CPUPercent = &cpu
+		return
+	}
+
+	vars, err := client.Get(oidSsCPUIdle)
+	if err != nil || len(vars) == 0 || !vars[0].Exists() {
+		return
+	}
+	if idle, ok := vars[0].Uint64(); ok && idle <= 100 {
+		cpu := float64(100 - idle)
+		status.CPUPercent = &cpu
+	}
+}
+
+// collectMemory reads memory from UCD, which separates buffers and cache,
+// falling back to hrStorage's RAM entry. Fixed disks come from hrStorage.
+func collectMemory(client *snmp.Client, status *models.SNMPStatus) {
+	if vars, err := client.Get(oidMemTotalReal, oidMemAvailReal, oidMemBuffer, oidMemCached); err == nil && len(vars) == 4 && vars[0].Exists() {
+		var kb [4]uint64
+		for i, v := range vars {
+			kb[i], _ = v.Uint64()
+		}
+		if total := kb[0]; total > 0 {
+			free := min(kb[1]+kb[2]+kb[3], total)
+			status.Memory = &models.SNMPMemory{Total: total * 1024, Used: (total - free) * 1024}
+		}
+	}
+
+	type storage struct {
+		kind, descr      string
+		unit, size, used uint64
+	}
+	entries := make(map[int]*storage)
+	var order []int
+	client.Walk(oidHrStorageTable, func(v snmp.Variable) error {
+		column, index, ok := tableColumn(v.OID, oidHrStorageTable)
+		if !ok {
+			return nil
+		}
+		entry, known := entries[index]
+		if !known {
+			entry = &storage{}
+			entries[index] = entry
+			order = append(order, index)
+		}
+		n, _ := v.Uint64()
+		switch column {
+		case 2:
+			entry.kind = v.String()
+		case 3:
+			entry.descr = v.String()
+		case 4:
+			entry.unit = n
+		case 5:
+			entry.size = n
+		case 6:
+			entry.used = n
+		}
+		return nil
+	})
+
+	for _, index := range order {
+		entry := entries[index]
+		total, used := entry.size*entry.unit, entry.used*entry.unit
+		if total == 0 {
+			continue
+		}
+		switch entry.kind {
+		case oidHrStorageRAM:
+			if status.Memory == nil {
+				status.Memory = &models.SNMPMemory{Total: total, Used: used}
+			}
+		case oidHrStorageFixDisk:
+			status.Storage = append(status.Storage, models.SNMPStorage{
+				Description: entry.descr,
+				Total:       total,
+				Used:        used,
+				Percent:     math.Round(float64(used)/float64(total)*1000) / 10,
+			})
+		}
+	}
+	if status.Memory != nil && status.Memory.Total > 0 {
+		status.Memory.Percent = math.Round(float64(status.Memory.Used)/float64(status.Memory.Total)*1000) / 10
+	}
+}
+
+// interfaceRates sets byte rates from the previous poll's counters. A 32-bit
+// counter that went down wrapped once; a 64-bit one was reset.
+func interfaceRates(previous, current *models.SNMPStatus) {
+	elapsed := current.PolledAt.Sub(previous.PolledAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	before := make(map[int]models.SNMPInterface, len(previous.Interfaces))
+	for _, iface := range previous.Interfaces {
+		before[iface.Index] = iface
+	}
+
+	rate := func(old, new uint64, high bool) *float64 {
+		var delta uint64
+		switch {
+		case new >= old:
+			delta = new - old
+		case !high && old <= math.MaxUint32:
+			delta = new + (math.MaxUint32 - old) + 1
+		default:
+			return nil
+		}
+		r := math.Round(float64(delta)/elapsed*10) / 10
+		return &r
+	}
+	for i := range current.Interfaces {
+		iface := &current.Interfaces[i]
+		old, ok := before[iface.Index]
+		if !ok || old.HighCounter != iface.HighCounter {
+			continue
+		}
+		iface.InRate = rate(old.InOctets, iface.InOctets, iface.HighCounter)
+		iface.OutRate = rate(old.OutOctets, iface.OutOctets, iface.HighCounter)
+	}
+}
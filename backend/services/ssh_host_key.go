@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// ErrHostKeyMismatch is returned when an SSH server presents another host
+// key than the one pinned for it, e.g. after an IP address was reassigned
+var ErrHostKeyMismatch = errors.New("SSH host key mismatch")
+
+// hostKeyPin checks SSH servers against a host key pinned on first use. The
+// key is stored in authorized_keys format next to its SHA256 fingerprint.
+type hostKeyPin struct {
+	pinned    ssh.PublicKey
+	presented ssh.PublicKey
+}
+
+// newHostKeyPin parses a stored host key; empty means none is pinned yet
+func newHostKeyPin(stored string) (*hostKeyPin, error) {
+	p := &hostKeyPin{}
+	if stored == "" {
+		return p, nil
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(stored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pinned SSH host key: %v", err)
+	}
+	p.pinned = key
+	return p, nil
+}
+
+// callback accepts any key while none is pinned and otherwise only the
+// pinned one
+func (p *hostKeyPin) callback(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if p.pinned == nil {
+		p.presented = key
+		return nil
+	}
+	if err := ssh.FixedHostKey(p.pinned)(hostname, remote, key); err != nil {
+		return fmt.Errorf("%w: %s presented %s but %s is pinned; reset the pinned key if the host was reinstalled",
+			ErrHostKeyMismatch, hostname, ssh.FingerprintSHA256(key), ssh.FingerprintSHA256(p.pinned))
+	}
+	return nil
+}
+
+// record pins the key presented on the first connection to a row of model,
+// unless one was pinned meanwhile
+func (p *hostKeyPin) record(db *gorm.DB, model interface{}, id uint, name string) {
+	if p.pinned != nil || p.presented == nil {
+		return
+	}
+	key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(p.presented)))
+	fingerprint := ssh.FingerprintSHA256(p.presented)
+	result := db.Model(model).Where("id = ? AND (ssh_host_key IS NULL OR ssh_host_key = ?)", id, "").
+		UpdateColumns(map[string]interface{}{"ssh_host_key": key, "ssh_host_key_fingerprint": fingerprint})
+	if result.Error != nil {
+		log.Printf("Failed to pin the SSH host key of %s: %v", name, result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Pinned SSH host key %s for %s", fingerprint, name)
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// serveSSH accepts SSH connections with the given host key until the test ends
+func serveSSH(t *testing.T, signer ssh.Signer) string {
+	t.Helper()
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					ch.Reject(ssh.Prohibited, "no channels")
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func dialWithPin(addr, stored string) (*hostKeyPin, error) {
+	pin, err := newHostKeyPin(stored)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{User: "test", HostKeyCallback: pin.callback})
+	if err != nil {
+		return pin, err
+	}
+	client.Close()
+	return pin, nil
+}
+
+func TestHostKeyPin(t *testing.T) {
+	signer := newTestSigner(t)
+	addr := serveSSH(t, signer)
+
+	// The first connection accepts the key and remembers it for pinning
+	pin, err := dialWithPin(addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin.presented == nil || ssh.FingerprintSHA256(pin.presented) != ssh.FingerprintSHA256(signer.PublicKey()) {
+		t.Fatalf("presented key not recorded")
+	}
+	stored := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pin.presented)))
+
+	if _, err := dialWithPin(addr, stored); err != nil {
+		t.Errorf("pinned key rejected: %v", err)
+	}
+
+	// A host presenting another key is refused
+	other := serveSSH(t, newTestSigner(t))
+	if _, err := dialWithPin(other, stored); !errors.Is(err, ErrHostKeyMismatch) {
+		t.Errorf("dial with another host key = %v, want ErrHostKeyMismatch", err)
+	}
+
+	if _, err := newHostKeyPin("not a key"); err == nil {
+		t.Error("invalid stored key accepted")
+	}
+}
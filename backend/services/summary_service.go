@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// SummaryService builds a compact overview of the homelab
+type SummaryService struct {
+	db      *gorm.DB
+	metrics *MetricsService
+	docker  *DockerService
+	cache   Cache
+}
+
+// summaryCacheTTL is how long a built summary is shared between callers
+const summaryCacheTTL = 10 * time.Second
+
+// NewSummaryService creates a new SummaryService
+func NewSummaryService(metrics *MetricsService, docker *DockerService, cache Cache) *SummaryService {
+	return &SummaryService{
+		db:      database.GetDB(),
+		metrics: metrics,
+		docker:  docker,
+		cache:   cache,
+	}
+}
+
+// GetSummary returns host metrics plus device, service and container counts.
+// Devices, services and events are the user's own, with lab-wide events;
+// userID 0 counts the whole lab for kiosk displays. Uses last known states
+// so it stays cheap enough to stream.
+func (s *SummaryService) GetSummary(userID uint) models.Summary {
+	key := fmt.Sprintf("summary:%d", userID)
+	var summary models.Summary
+	if s.cache.Get(key, &summary) {
+		return summary
+	}
+
+	summary = models.Summary{
+		Events:    make(map[string]int64),
+		Timestamp: time.Now(),
+	}
+
+	if metrics, err := s.metrics.GetSystemMetrics(); err == nil {
+		summary.Metrics = metrics
+	}
+
+	var devices []models.Device
+	s.owned(userID).Select("is_online").Where("is_active = ?", true).Find(&devices)
+	for _, d := range devices {
+		summary.Devices.Total++
+		if d.IsOnline {
+			summary.Devices.Up++
+		}
+	}
+
+	// Latest recorded check per active service
+	var statuses []string
+	s.db.Raw(`SELECT sc.status FROM service_checks sc
+		JOIN (SELECT service_id, MAX(checked_at) AS checked_at FROM service_checks WHERE location = '' GROUP BY service_id) latest
+		ON sc.service_id = latest.service_id AND sc.checked_at = latest.checked_at AND sc.location = ''
+		JOIN service_configs cfg ON cfg.id = sc.service_id
+		WHERE cfg.is_active = ? AND cfg.deleted_at IS NULL AND (? = 0 OR cfg.user_id = ?)`, true, userID, userID).Scan(&statuses)
+	var activeServices int64
+	s.owned(userID).Model(&models.ServiceConfig{}).Where("is_active = ?", true).Count(&activeServices)
+	summary.Services.Total = int(activeServices)
+	for _, status := range statuses {
+		if status == "online" {
+			summary.Services.Up++
+		}
+	}
+
+	for _, c := range s.docker.GetContainersBasic() {
+		summary.Containers.Total++
+		if c.State == "running" {
+			summary.Containers.Up++
+		}
+	}
+
+	var counts []struct {
+		Severity string
+		Count    int64
+	}
+	events := s.db.Model(&models.Event{})
+	if userID != 0 {
+		events = events.Where("user_id IN ?", []uint{0, userID})
+	}
+	events.Select("severity, COUNT(*) AS count").
+		Where("created_at >= ?", time.Now().Add(-24*time.Hour)).
+		Group("severity").Scan(&counts)
+	for _, c := range counts {
+		summary.Events[c.Severity] = c.Count
+	}
+
+	s.cache.Set(key, summary, summaryCacheTTL)
+	return summary
+}
+
+// owned limits a query to the user's rows, or none for the whole lab
+func (s *SummaryService) owned(userID uint) *gorm.DB {
+	if userID == 0 {
+		return s.db
+	}
+	return s.db.Where("user_id = ?", userID)
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// TagService manages tags and tag-scoped bulk actions
+type TagService struct {
+	db       *gorm.DB
+	services *ServiceConfigService
+	devices  *DeviceService
+}
+
+// NewTagService creates a new TagService
+func NewTagService(services *ServiceConfigService, devices *DeviceService) *TagService {
+	return &TagService{
+		db:       database.GetDB(),
+		services: services,
+		devices:  devices,
+	}
+}
+
+// NormalizeTagName trims and lowercases a tag name
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// TagScope restricts a service_configs or devices query to rows carrying
+// any of the named tags. No names leaves the query unchanged.
+func TagScope(names []string, joinTable, foreignKey string) func(*gorm.DB) *gorm.DB {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			if n := NormalizeTagName(part); n != "" {
+				normalized = append(normalized, n)
+			}
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if len(normalized) == 0 {
+			return db
+		}
+		sub := db.Session(&gorm.Session{NewDB: true}).Table(joinTable).
+			Select(joinTable+"."+foreignKey).
+			Joins("JOIN tags ON tags.id = "+joinTable+".tag_id").
+			Where("tags.name IN ?", normalized)
+		return db.Where("id IN (?)", sub)
+	}
+}
+
+// ServiceTagScope filters service_configs by tag name
+func ServiceTagScope(names []string) func(*gorm.DB) *gorm.DB {
+	return TagScope(names, "service_tags", "service_config_id")
+}
+
+// DeviceTagScope filters devices by tag name
+func DeviceTagScope(names []string) func(*gorm.DB) *gorm.DB {
+	return TagScope(names, "device_tags", "device_id")
+}
+
+// resolveTags returns the user's tags with the given names, creating missing ones
+func resolveTags(db *gorm.DB, userID uint, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if len(name) > 100 {
+			return nil, fmt.Errorf("tag %q is too long", name)
+		}
+
+		tag := models.Tag{UserID: userID, Name: name}
+		if err := db.Where("user_id = ? AND name = ?", userID, name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// tagNamesFromJSON extracts tag names from a decoded JSON value: a list of
+// names or of {"name": ...} objects, or the legacy string format
+func tagNamesFromJSON(value interface{}) []string {
+	if str, ok := value.(string); ok {
+		return models.ParseTagList(str)
+	}
+	items, _ := value.([]interface{})
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			names = append(names, v)
+		case map[string]interface{}:
+			if name, ok := v["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// List returns the user's tags with usage counts
+func (s *TagService) List(userID uint) ([]models.TagUsage, error) {
+	var tags []models.Tag
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]models.TagUsage, len(tags))
+	for i, tag := range tags {
+		result[i].Tag = tag
+		s.db.Table("service_tags").Where("tag_id = ?", tag.ID).Count(&result[i].Services)
+		s.db.Table("device_tags").Where("tag_id = ?", tag.ID).Count(&result[i].Devices)
+	}
+	return result, nil
+}
+
+// Create adds a tag
+func (s *TagService) Create(userID uint, req models.TagRequest) (*models.Tag, error) {
+	name := NormalizeTagName(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+
+	var count int64
+	s.db.Model(&models.Tag{}).Where("user_id = ? AND name = ?", userID, name).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("tag %q already exists", name)
+	}
+
+	tag := models.Tag{UserID: userID, Name: name, Color: req.Color}
+	if err := s.db.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Update renames or recolors a tag
+func (s *TagService) Update(id uint, userID uint, req models.TagRequest) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		return nil, fmt.Errorf("tag not found")
+	}
+
+	name := NormalizeTagName(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+	if name != tag.Name {
+		var count int64
+		s.db.Model(&models.Tag{}).Where("user_id = ? AND name = ?", userID, name).Count(&count)
+		if count > 0 {
+			return nil, fmt.Errorf("tag %q already exists", name)
+		}
+	}
+
+	tag.Name = name
+	tag.Color = req.Color
+	if err := s.db.Model(&tag).Select("name", "color").Updates(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Delete removes a tag from everything it is attached to
+func (s *TagService) Delete(id uint, userID uint) error {
+	var tag models.Tag
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		return fmt.Errorf("tag not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM service_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM device_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
+
+// SetServiceTags replaces a service's tags, creating tags as needed
+func (s *TagService) SetServiceTags(serviceID uint, userID uint, names []string) (*models.ServiceConfig, error) {
+	var svc models.ServiceConfig
+	if err := s.db.Where("id = ? AND user_id = ?", serviceID, userID).First(&svc).Error; err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	tags, err := resolveTags(s.db, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&svc).Association("Tags").Replace(tags); err != nil {
+		return nil, err
+	}
+	svc.Tags = tags
+	return &svc, nil
+}
+
+// SetDeviceTags replaces a device's tags, creating tags as needed
+func (s *TagService) SetDeviceTags(deviceID uint, userID uint, names []string) (*models.Device, error) {
+	var device models.Device
+	if err := s.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	tags, err := resolveTags(s.db, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&device).Association("Tags").Replace(tags); err != nil {
+		return nil, err
+	}
+	device.Tags = tags
+	return &device, nil
+}
+
+// TagActions are the bulk actions that can be run against a tag
+var TagActions = []string{"enable", "disable", "check", "ping"}
+
+// RunAction applies a bulk action to every service and device carrying the tag.
+// enable/disable toggle monitoring, check runs service checks, ping pings devices.
+func (s *TagService) RunAction(id uint, userID uint, action string) (*models.TagActionResult, error) {
+	var tag models.Tag
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		return nil, fmt.Errorf("tag not found")
+	}
+	names := []string{tag.Name}
+
+	var svcs []models.ServiceConfig
+	if err := s.db.Where("user_id = ?", userID).Scopes(ServiceTagScope(names)).Find(&svcs).Error; err != nil {
+		return nil, err
+	}
+	var devices []models.Device
+	if err := s.db.Where("user_id = ?", userID).Scopes(DeviceTagScope(names)).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	result := &models.TagActionResult{Action: action}
+
+	switch action {
+	case "enable", "disable":
+		active := action == "enable"
+		if len(svcs) > 0 {
+			res := s.db.Model(&models.ServiceConfig{}).Where("user_id = ?", userID).Scopes(ServiceTagScope(names)).Update("is_active", active)
+			if res.Error != nil {
+				return nil, res.Error
+			}
+			result.Services = int(res.RowsAffected)
+		}
+		if len(devices) > 0 {
+			res := s.db.Model(&models.Device{}).Where("user_id = ?", userID).Scopes(DeviceTagScope(names)).Update("is_active", active)
+			if res.Error != nil {
+				return nil, res.Error
+			}
+			result.Devices = int(res.RowsAffected)
+		}
+
+	case "check":
+		statuses := make([]ServiceStatus, len(svcs))
+		var wg sync.WaitGroup
+		for i, svc := range svcs {
+			wg.Add(1)
+			go func(idx int, svc models.ServiceConfig) {
+				defer wg.Done()
+				statuses[idx] = s.services.checkService(svc)
+			}(i, svc)
+		}
+		wg.Wait()
+		result.Services = len(statuses)
+		result.Results = statuses
+
+	case "ping":
+		type pingResult struct {
+			ID     uint   `json:"id"`
+			Name   string `json:"name"`
+			Online bool   `json:"online"`
+		}
+		pings := make([]pingResult, len(devices))
+		var wg sync.WaitGroup
+		for i, device := range devices {
+			wg.Add(1)
+			go func(idx int, device models.Device) {
+				defer wg.Done()
+				online, _ := s.devices.PingDevice(device.ID, userID)
+				pings[idx] = pingResult{ID: device.ID, Name: device.Name, Online: online}
+			}(i, device)
+		}
+		wg.Wait()
+		result.Devices = len(pings)
+		result.Results = pings
+
+	default:
+		return nil, fmt.Errorf("unknown action %q (use %s)", action, strings.Join(TagActions, ", "))
+	}
+
+	return result, nil
+}
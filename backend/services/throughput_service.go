@@ -0,0 +1,249 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// ThroughputService runs iperf3-like TCP throughput tests between the backend
+// and other hosts running the same embedded test server
+type ThroughputService struct {
+	port int
+
+	mu   sync.Mutex
+	busy bool
+}
+
+// Protocol: the client sends "HLTP/1 <upload|download> <seconds>\n".
+// For upload the client streams data and half-closes; the server replies
+// "OK <bytes>\n". For download the server streams data then closes.
+const (
+	throughputProtocol    = "HLTP/1"
+	throughputMaxDuration = 30
+	throughputBufferSize  = 128 * 1024
+)
+
+// NewThroughputService creates a new ThroughputService and starts the test
+// server when enabled
+func NewThroughputService() *ThroughputService {
+	cfg := config.AppConfig
+	s := &ThroughputService{port: cfg.ThroughputPort}
+
+	if cfg.ThroughputServerEnabled {
+		go s.serve()
+	}
+
+	return s
+}
+
+// DefaultPort returns the configured test server port
+func (s *ThroughputService) DefaultPort() int {
+	return s.port
+}
+
+// serve accepts throughput test connections, one test at a time
+func (s *ThroughputService) serve() {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		log.Printf("Throughput server failed to listen on :%d: %v", s.port, err)
+		return
+	}
+	log.Printf("Throughput test server listening on :%d", s.port)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *ThroughputService) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	s.mu.Lock()
+	if s.busy {
+		s.mu.Unlock()
+		fmt.Fprintf(conn, "BUSY\n")
+		return
+	}
+	s.busy = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.busy = false
+		s.mu.Unlock()
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[0] != throughputProtocol {
+		fmt.Fprintf(conn, "ERR bad header\n")
+		return
+	}
+	seconds, err := strconv.Atoi(fields[2])
+	if err != nil || seconds <= 0 || seconds > throughputMaxDuration {
+		fmt.Fprintf(conn, "ERR bad duration\n")
+		return
+	}
+	duration := time.Duration(seconds) * time.Second
+
+	switch fields[1] {
+	case "upload":
+		// Client -> server: count bytes until the client half-closes
+		conn.SetReadDeadline(time.Now().Add(duration + 10*time.Second))
+		n, _ := io.Copy(io.Discard, reader)
+		fmt.Fprintf(conn, "OK %d\n", n)
+	case "download":
+		// Server -> client: stream for the requested duration
+		fmt.Fprintf(conn, "OK 0\n")
+		streamFor(conn, duration)
+	default:
+		fmt.Fprintf(conn, "ERR bad mode\n")
+	}
+}
+
+// streamFor writes zero-filled buffers to w until the duration elapses
+func streamFor(w io.Writer, duration time.Duration) int64 {
+	buf := make([]byte, throughputBufferSize)
+	deadline := time.Now().Add(duration)
+
+	var total int64
+	for time.Now().Before(deadline) {
+		n, err := w.Write(buf)
+		total += int64(n)
+		if err != nil {
+			break
+		}
+	}
+	return total
+}
+
+// RunTest runs a throughput test against host:port in the given direction
+func (s *ThroughputService) RunTest(host string, port int, direction string, seconds int) ([]models.ThroughputResult, error) {
+	if port <= 0 {
+		port = s.port
+	}
+	if seconds <= 0 {
+		seconds = 5
+	}
+	if seconds > throughputMaxDuration {
+		seconds = throughputMaxDuration
+	}
+
+	var directions []string
+	switch direction {
+	case "", "both":
+		directions = []string{"upload", "download"}
+	case "upload", "download":
+		directions = []string{direction}
+	default:
+		return nil, fmt.Errorf("invalid direction: %s", direction)
+	}
+
+	results := make([]models.ThroughputResult, 0, len(directions))
+	for _, d := range directions {
+		result, err := runThroughputClient(host, port, d, seconds)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// runThroughputClient performs a single-direction test
+func runThroughputClient(host string, port int, direction string, seconds int) (*models.ThroughputResult, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to throughput server at %s: %v", address, err)
+	}
+	defer conn.Close()
+
+	duration := time.Duration(seconds) * time.Second
+	conn.SetDeadline(time.Now().Add(duration + 20*time.Second))
+
+	if _, err := fmt.Fprintf(conn, "%s %s %d\n", throughputProtocol, direction, seconds); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	result := &models.ThroughputResult{Host: host, Port: port, Direction: direction}
+	start := time.Now()
+
+	if direction == "upload" {
+		streamFor(conn, duration)
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.CloseWrite()
+		}
+		elapsed := time.Since(start)
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("no response from throughput server: %v", err)
+		}
+		received, err := parseThroughputReply(line)
+		if err != nil {
+			return nil, err
+		}
+		result.Bytes = received
+		result.Seconds = elapsed.Seconds()
+	} else {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("no response from throughput server: %v", err)
+		}
+		if _, err := parseThroughputReply(line); err != nil {
+			return nil, err
+		}
+
+		start = time.Now()
+		n, _ := io.Copy(io.Discard, reader)
+		result.Bytes = n
+		result.Seconds = time.Since(start).Seconds()
+	}
+
+	if result.Seconds > 0 {
+		result.Mbps = float64(result.Bytes) * 8 / 1000000 / result.Seconds
+	}
+	return result, nil
+}
+
+// parseThroughputReply parses "OK <bytes>", "BUSY" or "ERR <reason>"
+func parseThroughputReply(line string) (int64, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty response from throughput server")
+	}
+
+	switch fields[0] {
+	case "OK":
+		if len(fields) < 2 {
+			return 0, nil
+		}
+		return strconv.ParseInt(fields[1], 10, 64)
+	case "BUSY":
+		return 0, fmt.Errorf("throughput server is busy with another test")
+	default:
+		return 0, fmt.Errorf("throughput server error: %s", strings.TrimSpace(line))
+	}
+}
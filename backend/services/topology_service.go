@@ -0,0 +1,433 @@
+package services
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// lldpStaleAfter marks an LLDP link stale when its switch stops reporting it
+const lldpStaleAfter = 24 * time.Hour
+
+// TopologyService builds the network map from devices, subnets, physical
+// links and Docker networks
+type TopologyService struct {
+	db     *gorm.DB
+	docker *DockerService
+}
+
+// NewTopologyService creates a new TopologyService
+func NewTopologyService(docker *DockerService) *TopologyService {
+	return &TopologyService{
+		db:     database.GetDB(),
+		docker: docker,
+	}
+}
+
+// ListLinks returns the user's manual and LLDP links
+func (s *TopologyService) ListLinks(userID uint) ([]models.TopologyLink, error) {
+	var links []models.TopologyLink
+	err := s.db.Where("user_id = ?", userID).Order("device_id ASC, port ASC").Find(&links).Error
+	return links, err
+}
+
+// CreateLink adds a manual link between two of the user's devices
+func (s *TopologyService) CreateLink(userID uint, req models.TopologyLinkRequest) (*models.TopologyLink, error) {
+	if req.DeviceID == req.TargetDeviceID {
+		return nil, fmt.Errorf("a link needs two different devices")
+	}
+	var count int64
+	s.db.Model(&models.Device{}).Where("id IN ? AND user_id = ?", []uint{req.DeviceID, req.TargetDeviceID}, userID).Count(&count)
+	if count != 2 {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	target := req.TargetDeviceID
+	link := models.TopologyLink{
+		UserID:         userID,
+		Source:         models.LinkSourceManual,
+		DeviceID:       req.DeviceID,
+		Port:           strings.TrimSpace(req.Port),
+		TargetDeviceID: &target,
+		TargetPort:     strings.TrimSpace(req.TargetPort),
+		Label:          strings.TrimSpace(req.Label),
+	}
+	if err := s.db.Create(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// DeleteLink removes a link
+func (s *TopologyService) DeleteLink(id, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.TopologyLink{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("link not found")
+	}
+	return result.Error
+}
+
+// ReportLLDP replaces a device's LLDP links with the neighbors it reports.
+// Neighbors are matched to devices by MAC (chassis ID), management address
+// or name.
+func (s *TopologyService) ReportLLDP(deviceID, userID uint, report models.LLDPReport) ([]models.TopologyLink, error) {
+	var device models.Device
+	if err := s.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("device not found")
+	}
+	var devices []models.Device
+	if err := s.db.Select("id", "name", "ip", "mac").Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	links := make([]models.TopologyLink, 0, len(report.Neighbors))
+	for _, n := range report.Neighbors {
+		link := models.TopologyLink{
+			UserID:     userID,
+			Source:     models.LinkSourceLLDP,
+			DeviceID:   device.ID,
+			Port:       strings.TrimSpace(n.LocalPort),
+			TargetPort: strings.TrimSpace(n.PortID),
+		}
+		match := matchNeighbor(n, devices)
+		switch {
+		case match == nil:
+			link.TargetMAC = normalizeMAC(n.ChassisID)
+			link.TargetName = firstNonEmpty(strings.TrimSpace(n.SystemName), strings.TrimSpace(n.ManagementAddress), link.TargetMAC)
+			if link.TargetName == "" {
+				continue
+			}
+		case match.ID == device.ID:
+			// A switch may see its own management interface
+			continue
+		default:
+			id := match.ID
+			link.TargetDeviceID = &id
+		}
+		links = append(links, link)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id = ? AND source = ?", device.ID, models.LinkSourceLLDP).Delete(&models.TopologyLink{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// matchNeighbor finds the device an LLDP neighbor advertises itself as
+func matchNeighbor(n models.LLDPNeighbor, devices []models.Device) *models.Device {
+	mac := normalizeMAC(n.ChassisID)
+	addr := strings.TrimSpace(n.ManagementAddress)
+	name := strings.TrimSpace(n.SystemName)
+	for i, d := range devices {
+		if mac != "" && normalizeMAC(d.MAC) == mac {
+			return &devices[i]
+		}
+	}
+	for i, d := range devices {
+		if addr != "" && strings.TrimSpace(d.IP) == addr {
+			return &devices[i]
+		}
+	}
+	for i, d := range devices {
+		// Switches often advertise a fully qualified name
+		if name != "" && (strings.EqualFold(d.Name, name) || strings.EqualFold(d.Name, strings.SplitN(name, ".", 2)[0])) {
+			return &devices[i]
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// GetTopology builds the network map. Devices connect to the subnets their
+// addresses fall in and to each other through links; containers connect to
+// their Docker networks, which hang off the Docker host.
+func (s *TopologyService) GetTopology(userID uint, includeContainers bool) (*models.Topology, error) {
+	topo := &models.Topology{
+		Nodes:       []models.TopologyNode{},
+		Edges:       []models.TopologyEdge{},
+		GeneratedAt: time.Now(),
+	}
+
+	var devices []models.Device
+	if err := s.db.Where("user_id = ? AND is_active = ?", userID, true).Order("name ASC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	status := make(map[string]string, len(devices))
+	for _, d := range devices {
+		node := models.TopologyNode{
+			ID:     deviceNodeID(d.ID),
+			Type:   models.NodeDevice,
+			Label:  d.Name,
+			Status: models.TopologyDown,
+			IP:     d.IP,
+			MAC:    normalizeMAC(d.MAC),
+			Kind:   d.Type,
+			RefID:  fmt.Sprint(d.ID),
+		}
+		if d.IsOnline {
+			node.Status = models.TopologyUp
+		}
+		status[node.ID] = node.Status
+		topo.Nodes = append(topo.Nodes, node)
+	}
+
+	if err := s.addSubnets(topo, userID, devices); err != nil {
+		return nil, err
+	}
+	if err := s.addLinks(topo, userID, status); err != nil {
+		return nil, err
+	}
+	if includeContainers {
+		s.addDocker(topo)
+	}
+	return topo, nil
+}
+
+func deviceNodeID(id uint) string {
+	return fmt.Sprintf("device:%d", id)
+}
+
+// addSubnets adds the address plan's subnets and connects each device to
+// the subnets holding its address
+func (s *TopologyService) addSubnets(topo *models.Topology, userID uint, devices []models.Device) error {
+	var subnets []models.Subnet
+	if err := s.db.Where("user_id = ?", userID).Order("cidr ASC").Find(&subnets).Error; err != nil {
+		return err
+	}
+
+	for _, subnet := range subnets {
+		prefix, err := netip.ParsePrefix(subnet.CIDR)
+		if err != nil {
+			continue
+		}
+		nodeID := fmt.Sprintf("subnet:%d", subnet.ID)
+		label := subnet.CIDR
+		if subnet.Name != "" {
+			label = subnet.Name + " (" + subnet.CIDR + ")"
+		}
+		if subnet.VLAN > 0 {
+			label += fmt.Sprintf(" VLAN %d", subnet.VLAN)
+		}
+		topo.Nodes = append(topo.Nodes, models.TopologyNode{
+			ID:     nodeID,
+			Type:   models.NodeSubnet,
+			Label:  label,
+			Status: models.TopologyUp,
+			RefID:  fmt.Sprint(subnet.ID),
+		})
+
+		for _, d := range devices {
+			addr, err := netip.ParseAddr(strings.TrimSpace(d.IP))
+			if err != nil || !prefix.Contains(addr) {
+				continue
+			}
+			edgeLabel := d.IP
+			if d.IP == subnet.Gateway {
+				edgeLabel = "gateway " + d.IP
+			}
+			edge := models.TopologyEdge{
+				ID:     fmt.Sprintf("subnet:%d:%d", subnet.ID, d.ID),
+				Source: deviceNodeID(d.ID),
+				Target: nodeID,
+				Type:   models.EdgeSubnet,
+				Status: models.TopologyDown,
+				Label:  edgeLabel,
+			}
+			if d.IsOnline {
+				edge.Status = models.TopologyUp
+			}
+			topo.Edges = append(topo.Edges, edge)
+		}
+	}
+	return nil
+}
+
+// addLinks adds manual and LLDP links. A link both switches report over
+// LLDP is shown once; links to unmatched LLDP neighbors get a node of their
+// own.
+func (s *TopologyService) addLinks(topo *models.Topology, userID uint, status map[string]string) error {
+	links, err := s.ListLinks(userID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	neighbors := make(map[string]bool)
+	for _, link := range links {
+		source := deviceNodeID(link.DeviceID)
+		if _, ok := status[source]; !ok {
+			continue
+		}
+
+		var target string
+		if link.TargetDeviceID != nil {
+			target = deviceNodeID(*link.TargetDeviceID)
+			if _, ok := status[target]; !ok {
+				continue
+			}
+		} else {
+			target = "neighbor:" + strings.ToLower(firstNonEmpty(link.TargetMAC, link.TargetName))
+			if !neighbors[target] {
+				neighbors[target] = true
+				status[target] = models.TopologyUnknown
+				topo.Nodes = append(topo.Nodes, models.TopologyNode{
+					ID:     target,
+					Type:   models.NodeNeighbor,
+					Label:  link.TargetName,
+					Status: models.TopologyUnknown,
+					MAC:    link.TargetMAC,
+				})
+			}
+		}
+
+		edgeType := models.EdgeLink
+		if link.Source == models.LinkSourceLLDP {
+			edgeType = models.EdgeLLDP
+		}
+		a, b := source, target
+		if b < a {
+			a, b = b, a
+		}
+		key := edgeType + "|" + a + "|" + b
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		edge := models.TopologyEdge{
+			ID:     fmt.Sprintf("link:%d", link.ID),
+			Source: source,
+			Target: target,
+			Type:   edgeType,
+			Status: models.TopologyUp,
+			Label:  linkLabel(link),
+		}
+		switch {
+		case status[source] == models.TopologyDown || status[target] == models.TopologyDown:
+			edge.Status = models.TopologyDown
+		case link.Source == models.LinkSourceLLDP && time.Since(link.UpdatedAt) > lldpStaleAfter:
+			edge.Status = models.TopologyStale
+		}
+		topo.Edges = append(topo.Edges, edge)
+	}
+	return nil
+}
+
+// linkLabel describes a link as "port ↔ port", with its label if it has one
+func linkLabel(link models.TopologyLink) string {
+	var parts []string
+	if link.Port != "" || link.TargetPort != "" {
+		parts = append(parts, firstNonEmpty(link.Port, "?")+" ↔ "+firstNonEmpty(link.TargetPort, "?"))
+	}
+	if link.Label != "" {
+		parts = append(parts, link.Label)
+	}
+	return strings.Join(parts, " ")
+}
+
+// addDocker adds the Docker host with its networks and their containers.
+// The null network and networks without containers are left out.
+func (s *TopologyService) addDocker(topo *models.Topology) {
+	if s.docker == nil || !s.docker.IsConnected() {
+		return
+	}
+	networks, err := s.docker.ListNetworks()
+	if err != nil {
+		return
+	}
+
+	hostID := "docker_host"
+	topo.Nodes = append(topo.Nodes, models.TopologyNode{
+		ID:     hostID,
+		Type:   models.NodeDockerHost,
+		Label:  "Docker",
+		Status: models.TopologyUp,
+		Kind:   s.docker.ServerVersion(),
+	})
+
+	containers := make(map[string]bool)
+	for _, network := range networks {
+		if network.Name == "none" || len(network.Containers) == 0 {
+			continue
+		}
+		nodeID := "docker_network:" + network.ID
+		topo.Nodes = append(topo.Nodes, models.TopologyNode{
+			ID:     nodeID,
+			Type:   models.NodeDockerNetwork,
+			Label:  network.Name,
+			Status: models.TopologyUp,
+			Kind:   network.Driver,
+			RefID:  network.ID,
+		})
+		topo.Edges = append(topo.Edges, models.TopologyEdge{
+			ID:     "docker_host:" + network.ID,
+			Source: hostID,
+			Target: nodeID,
+			Type:   models.EdgeNetwork,
+			Status: models.TopologyUp,
+			Label:  subnetList(network.Subnets),
+		})
+
+		for _, c := range network.Containers {
+			containerID := "container:" + c.ContainerID
+			if !containers[containerID] {
+				containers[containerID] = true
+				node := models.TopologyNode{
+					ID:     containerID,
+					Type:   models.NodeContainer,
+					Label:  c.ContainerName,
+					Status: models.TopologyDown,
+					Kind:   c.State,
+					RefID:  c.ContainerID,
+				}
+				if c.State == "running" {
+					node.Status = models.TopologyUp
+				}
+				topo.Nodes = append(topo.Nodes, node)
+			}
+
+			edge := models.TopologyEdge{
+				ID:     "network:" + network.ID + ":" + c.ContainerID,
+				Source: containerID,
+				Target: nodeID,
+				Type:   models.EdgeNetwork,
+				Status: models.TopologyDown,
+				Label:  c.IPv4Address,
+			}
+			if c.State == "running" {
+				edge.Status = models.TopologyUp
+			}
+			topo.Edges = append(topo.Edges, edge)
+		}
+	}
+}
+
+func subnetList(subnets []models.NetworkSubnet) string {
+	cidrs := make([]string, len(subnets))
+	for i, s := range subnets {
+		cidrs[i] = s.Subnet
+	}
+	return strings.Join(cidrs, ", ")
+}
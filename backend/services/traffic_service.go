@@ -0,0 +1,169 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// TrafficService attributes the host's network throughput to containers
+// and the host by comparing interface counters with container counters
+type TrafficService struct {
+	metrics *MetricsService
+	docker  *DockerService
+
+	mu       sync.Mutex
+	previous *trafficSample
+	latest   *models.NetworkTraffic // served again within trafficResultTTL
+}
+
+const (
+	// trafficSampleGap is the wait for a second sample when none is recent
+	trafficSampleGap = 2 * time.Second
+	// trafficMaxSampleAge is how old a previous sample may be to compute rates from
+	trafficMaxSampleAge = 5 * time.Minute
+	// trafficResultTTL keeps several dashboards from sampling Docker at once
+	trafficResultTTL = 5 * time.Second
+	// DefaultTopContainers and MaxTopContainers bound the containers listed
+	DefaultTopContainers = 10
+	MaxTopContainers     = 50
+)
+
+// trafficSample is the cumulative counters at one point in time
+type trafficSample struct {
+	at          time.Time
+	interfaces  []models.NetworkMetrics // shown by the interface settings
+	containers  map[string]containerNetCounters
+	hostNetwork []string
+}
+
+// NewTrafficService creates a new TrafficService
+func NewTrafficService(metrics *MetricsService, docker *DockerService) *TrafficService {
+	return &TrafficService{metrics: metrics, docker: docker}
+}
+
+// GetTopContainers returns the host's throughput split between containers
+// and the host, with the limit busiest containers. Rates are measured since
+// the previous call, or over a short wait when there was none recently.
+func (s *TrafficService) GetTopContainers(limit int) (*models.NetworkTraffic, error) {
+	if limit <= 0 {
+		limit = DefaultTopContainers
+	}
+	if limit > MaxTopContainers {
+		limit = MaxTopContainers
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latest == nil || time.Since(s.latest.SampledAt) >= trafficResultTTL {
+		if s.previous == nil || time.Since(s.previous.at) > trafficMaxSampleAge {
+			first, err := s.sample()
+			if err != nil {
+				return nil, err
+			}
+			s.previous = first
+			time.Sleep(trafficSampleGap)
+		}
+		current, err := s.sample()
+		if err != nil {
+			return nil, err
+		}
+		s.latest = attributeTraffic(s.previous, current)
+		s.latest.DockerConnected = s.docker.IsConnected()
+		s.previous = current
+	}
+
+	result := *s.latest
+	if len(result.Top) > limit {
+		result.Top = result.Top[:limit]
+	}
+	return &result, nil
+}
+
+// sample reads the host interface and container counters
+func (s *TrafficService) sample() (*trafficSample, error) {
+	interfaces, err := s.metrics.GetNetworkMetrics()
+	if err != nil {
+		return nil, err
+	}
+	containers, hostNetwork := s.docker.networkCounters()
+	return &trafficSample{
+		at:          time.Now(),
+		interfaces:  interfaces,
+		containers:  containers,
+		hostNetwork: hostNetwork,
+	}, nil
+}
+
+// attributeTraffic computes the rates between two samples. Interfaces and
+// containers missing from either sample, or whose counters went back
+// (a restarted container), are left out.
+func attributeTraffic(before, after *trafficSample) *models.NetworkTraffic {
+	seconds := after.at.Sub(before.at).Seconds()
+	result := &models.NetworkTraffic{
+		Interfaces:    make([]models.InterfaceTraffic, 0, len(after.interfaces)),
+		Top:           make([]models.ContainerTraffic, 0, len(after.containers)),
+		HostNetwork:   after.hostNetwork,
+		SampleSeconds: seconds,
+		SampledAt:     after.at,
+	}
+	if result.HostNetwork == nil {
+		result.HostNetwork = []string{}
+	}
+	if seconds <= 0 {
+		return result
+	}
+
+	previous := make(map[string]models.NetworkMetrics, len(before.interfaces))
+	for _, iface := range before.interfaces {
+		previous[iface.Interface] = iface
+	}
+	for _, iface := range after.interfaces {
+		old, ok := previous[iface.Interface]
+		if !ok || iface.BytesRecv < old.BytesRecv || iface.BytesSent < old.BytesSent {
+			continue
+		}
+		rate := models.TrafficRate{
+			RxRate: float64(iface.BytesRecv-old.BytesRecv) / seconds,
+			TxRate: float64(iface.BytesSent-old.BytesSent) / seconds,
+		}
+		result.Interfaces = append(result.Interfaces, models.InterfaceTraffic{Interface: iface.Interface, Name: iface.Name, TrafficRate: rate})
+		result.Total.RxRate += rate.RxRate
+		result.Total.TxRate += rate.TxRate
+	}
+
+	for id, c := range after.containers {
+		old, ok := before.containers[id]
+		if !ok || c.rx < old.rx || c.tx < old.tx {
+			continue
+		}
+		rate := models.TrafficRate{
+			RxRate: float64(c.rx-old.rx) / seconds,
+			TxRate: float64(c.tx-old.tx) / seconds,
+		}
+		result.Top = append(result.Top, models.ContainerTraffic{ID: id, Name: c.name, NetworkMode: c.networkMode, TrafficRate: rate})
+		result.Containers.RxRate += rate.RxRate
+		result.Containers.TxRate += rate.TxRate
+	}
+
+	combined := result.Containers.RxRate + result.Containers.TxRate
+	for i := range result.Top {
+		if combined > 0 {
+			result.Top[i].Share = (result.Top[i].RxRate + result.Top[i].TxRate) / combined * 100
+		}
+	}
+	sort.Slice(result.Top, func(i, j int) bool {
+		a, b := result.Top[i], result.Top[j]
+		if a.RxRate+a.TxRate != b.RxRate+b.TxRate {
+			return a.RxRate+a.TxRate > b.RxRate+b.TxRate
+		}
+		return a.Name < b.Name
+	})
+
+	result.Host.RxRate = max(result.Total.RxRate-result.Containers.RxRate, 0)
+	result.Host.TxRate = max(result.Total.TxRate-result.Containers.TxRate, 0)
+	return result
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/config"
+	"github.com/homelab/backend/models"
+)
+
+// UpdateService periodically checks GitHub releases for a newer backend version
+type UpdateService struct {
+	repo       string
+	enabled    bool
+	httpClient *http.Client
+	events     *EventService
+
+	mu       sync.RWMutex
+	info     models.VersionInfo
+	notified string // latest version an event was already recorded for
+}
+
+const updateCheckInterval = 12 * time.Hour
+
+// NewUpdateService creates a new UpdateService and starts the background check
+func NewUpdateService(events *EventService) *UpdateService {
+	cfg := config.AppConfig
+	s := &UpdateService{
+		repo:       cfg.UpdateCheckRepo,
+		enabled:    cfg.UpdateCheckEnabled && cfg.UpdateCheckRepo != "",
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		events:     events,
+		info:       models.VersionInfo{Current: config.Version},
+	}
+
+	if s.enabled {
+		go s.checkBackground()
+	}
+
+	return s
+}
+
+func (s *UpdateService) checkBackground() {
+	ticker := time.NewTicker(updateCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(); err != nil {
+			log.Printf("Update check failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// GetVersionInfo returns the last known version information
+func (s *UpdateService) GetVersionInfo() models.VersionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.info
+}
+
+// Check queries the latest GitHub release and updates the cached version info
+func (s *UpdateService) Check() error {
+	now := time.Now()
+
+	release, err := s.fetchLatestRelease()
+	if err != nil {
+		s.mu.Lock()
+		s.info.CheckedAt = &now
+		s.info.Error = err.Error()
+		s.mu.Unlock()
+		return err
+	}
+
+	info := models.VersionInfo{
+		Current:         config.Version,
+		Latest:          release.TagName,
+		UpdateAvailable: isNewerVersion(release.TagName, config.Version),
+		ReleaseName:     release.Name,
+		Changelog:       release.Body,
+		ReleaseURL:      release.HTMLURL,
+		PublishedAt:     &release.PublishedAt,
+		CheckedAt:       &now,
+	}
+
+	s.mu.Lock()
+	s.info = info
+	shouldNotify := info.UpdateAvailable && s.notified != info.Latest
+	if shouldNotify {
+		s.notified = info.Latest
+	}
+	s.mu.Unlock()
+
+	if shouldNotify {
+		s.events.Record("update_available", models.SeverityInfo, "system",
+			"Update available",
+			fmt.Sprintf("Homelab backend %s is available (running %s)", info.Latest, info.Current),
+			map[string]string{"latest": info.Latest, "releaseUrl": info.ReleaseURL})
+	}
+
+	return nil
+}
+
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+func (s *UpdateService) fetchLatestRelease() (*githubRelease, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", s.repo)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Homelab-Monitor/"+config.Version)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("no releases published for %s", s.repo)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// parseVersion parses "v1.2.3" (optionally with a "-suffix") into its numeric parts
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(v, "-+"); idx >= 0 {
+		v = v[:idx]
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// isNewerVersion returns true if latest is a higher semantic version than current.
+// Development builds (non-semver versions) never report updates.
+func isNewerVersion(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < 3; i++ {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Widget extraction paths are a small JSONPath subset with jq-style pipes:
+//
+//	$.server_state.dl_info_speed        field access ($ is optional)
+//	$.items[0].name, $["odd key"]       array index, quoted key
+//	$.torrents[*].size | sum            wildcard collects every match
+//	$.torrents[?state=="downloading"]   filter elements by a field
+//	... | length                        length, sum, min, max, avg, first, last, keys
+//
+// A wildcard or filter turns the result into an array of matches.
+
+// pathSegment is one step of a parsed path
+type pathSegment struct {
+	kind   string // key, index, wildcard, filter
+	key    string
+	index  int
+	field  []pathSegment // filter: field of the element compared
+	op     string        // filter: == or !=
+	equals interface{}   // filter: literal compared against
+}
+
+// widgetFuncs are the pipe functions
+var widgetFuncs = map[string]func(interface{}) (interface{}, error){
+	"length": func(v interface{}) (interface{}, error) {
+		switch t := v.(type) {
+		case []interface{}:
+			return float64(len(t)), nil
+		case map[string]interface{}:
+			return float64(len(t)), nil
+		case string:
+			return float64(len([]rune(t))), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("length of a %T", v)
+	},
+	"sum": func(v interface{}) (interface{}, error) { return reduceNumbers(v, "sum") },
+	"min": func(v interface{}) (interface{}, error) { return reduceNumbers(v, "min") },
+	"max": func(v interface{}) (interface{}, error) { return reduceNumbers(v, "max") },
+	"avg": func(v interface{}) (interface{}, error) { return reduceNumbers(v, "avg") },
+	"first": func(v interface{}) (interface{}, error) {
+		if list, ok := v.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil, nil
+			}
+			return list[0], nil
+		}
+		return v, nil
+	},
+	"last": func(v interface{}) (interface{}, error) {
+		if list, ok := v.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil, nil
+			}
+			return list[len(list)-1], nil
+		}
+		return v, nil
+	},
+	"keys": func(v interface{}) (interface{}, error) {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("keys of a %T", v)
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, len(keys))
+		for i, key := range keys {
+			result[i] = key
+		}
+		return result, nil
+	},
+}
+
+// extractPath evaluates an extraction expression against a decoded response
+func extractPath(data interface{}, expr string) (interface{}, error) {
+	stages := strings.Split(expr, "|")
+	segments, err := parsePath(strings.TrimSpace(stages[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := walkPath(data, segments)
+	if err != nil {
+		return nil, err
+	}
+	for _, stage := range stages[1:] {
+		name := strings.TrimSpace(stage)
+		fn, ok := widgetFuncs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", name)
+		}
+		if value, err = fn(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// validatePath checks an extraction expression without evaluating it
+func validatePath(expr string) error {
+	stages := strings.Split(expr, "|")
+	if _, err := parsePath(strings.TrimSpace(stages[0])); err != nil {
+		return err
+	}
+	for _, stage := range stages[1:] {
+		if _, ok := widgetFuncs[strings.TrimSpace(stage)]; !ok {
+			return fmt.Errorf("unknown function %q", strings.TrimSpace(stage))
+		}
+	}
+	return nil
+}
+
+// parsePath splits a path into segments
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimPrefix(path, "$")
+	var segments []pathSegment
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			if i < len(path) && path[i] == '*' {
+				segments = append(segments, pathSegment{kind: "wildcard"})
+				i++
+				continue
+			}
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("empty key at position %d", i)
+			}
+			segments = append(segments, pathSegment{kind: "key", key: path[i:end]})
+			i = end
+
+		case '[':
+			end := matchingBracket(path, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ at position %d", i)
+			}
+			inner := strings.TrimSpace(path[i+1 : end])
+			segment, err := parseBracket(inner)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			i = end + 1
+
+		default:
+			// A bare leading key, as in "items[0]" without "$."
+			if i != 0 {
+				return nil, fmt.Errorf("unexpected %q at position %d", path[i], i)
+			}
+			path = "." + path
+		}
+	}
+	return segments, nil
+}
+
+// matchingBracket returns the index of the ] closing the [ at start,
+// skipping brackets inside quotes
+func matchingBracket(path string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(path); i++ {
+		switch {
+		case quote != 0:
+			if path[i] == quote {
+				quote = 0
+			}
+		case path[i] == '"' || path[i] == '\'':
+			quote = path[i]
+		case path[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseBracket parses the inside of [...]
+func parseBracket(inner string) (pathSegment, error) {
+	switch {
+	case inner == "*":
+		return pathSegment{kind: "wildcard"}, nil
+
+	case strings.HasPrefix(inner, "?"):
+		cond := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(inner, "?"), "@"))
+		op := "=="
+		left, right, ok := strings.Cut(cond, "==")
+		if !ok {
+			op = "!="
+			if left, right, ok = strings.Cut(cond, "!="); !ok {
+				return pathSegment{}, fmt.Errorf("filter %q needs == or !=", inner)
+			}
+		}
+		field, err := parsePath(strings.TrimSpace(left))
+		if err != nil {
+			return pathSegment{}, err
+		}
+		return pathSegment{kind: "filter", field: field, op: op, equals: parseLiteral(strings.TrimSpace(right))}, nil
+
+	case len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0]:
+		return pathSegment{kind: "key", key: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return pathSegment{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return pathSegment{kind: "index", index: index}, nil
+}
+
+// parseLiteral parses a filter value: a quoted string, number, bool or null
+func parseLiteral(raw string) interface{} {
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1]
+	}
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return n
+	}
+	return raw
+}
+
+// walkPath applies segments to a value. After a wildcard or filter the
+// remaining segments apply to each match and missing matches are dropped.
+func walkPath(value interface{}, segments []pathSegment) (interface{}, error) {
+	for i, segment := range segments {
+		switch segment.kind {
+		case "key":
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%q: not an object", segment.key)
+			}
+			if value, ok = object[segment.key]; !ok {
+				return nil, fmt.Errorf("%q: no such key", segment.key)
+			}
+
+		case "index":
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d]: not an array", segment.index)
+			}
+			index := segment.index
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, fmt.Errorf("[%d]: out of range", segment.index)
+			}
+			value = list[index]
+
+		case "wildcard", "filter":
+			var items []interface{}
+			switch t := value.(type) {
+			case []interface{}:
+				items = t
+			case map[string]interface{}:
+				keys := make([]string, 0, len(t))
+				for key := range t {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					items = append(items, t[key])
+				}
+			default:
+				return nil, fmt.Errorf("wildcard over a %T", value)
+			}
+
+			matches := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				if segment.kind == "filter" && !filterMatches(item, segment) {
+					continue
+				}
+				match, err := walkPath(item, segments[i+1:])
+				if err != nil {
+					continue
+				}
+				matches = append(matches, match)
+			}
+			return matches, nil
+		}
+	}
+	return value, nil
+}
+
+// filterMatches compares an element's field against a filter's literal
+func filterMatches(item interface{}, segment pathSegment) bool {
+	field, err := walkPath(item, segment.field)
+	if err != nil {
+		field = nil
+	}
+	equal := fmt.Sprint(field) == fmt.Sprint(segment.equals)
+	if segment.op == "!=" {
+		return !equal
+	}
+	return equal
+}
+
+// reduceNumbers folds an array of numbers
+func reduceNumbers(v interface{}, op string) (interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+
+	var result float64
+	count := 0
+	for _, item := range list {
+		var n float64
+		switch t := item.(type) {
+		case float64:
+			n = t
+		case string:
+			parsed, err := strconv.ParseFloat(t, 64)
+			if err != nil {
+				continue
+			}
+			n = parsed
+		case bool:
+			if t {
+				n = 1
+			}
+		default:
+			continue
+		}
+
+		switch {
+		case count == 0:
+			result = n
+		case op == "min" && n < result, op == "max" && n > result:
+			result = n
+		case op == "sum" || op == "avg":
+			result += n
+		}
+		count++
+	}
+
+	if count == 0 {
+		if op == "sum" {
+			return float64(0), nil
+		}
+		return nil, nil
+	}
+	if op == "avg" {
+		return result / float64(count), nil
+	}
+	return result, nil
+}
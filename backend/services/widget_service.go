@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homelab/backend/database"
+	"github.com/homelab/backend/models"
+	"gorm.io/gorm"
+)
+
+// WidgetService fetches third-party JSON APIs for dashboard widgets and
+// extracts the values they display
+type WidgetService struct {
+	db       *gorm.DB
+	client   *http.Client
+	insecure *http.Client
+
+	mu      sync.Mutex
+	results map[uint]widgetResult
+}
+
+// widgetResult is a cached fetch of a source
+type widgetResult struct {
+	data      models.WidgetData
+	expiresAt time.Time
+}
+
+const (
+	// widgetFetchTimeout bounds a single upstream request
+	widgetFetchTimeout = 10 * time.Second
+	// maxWidgetResponse is the largest upstream response read
+	maxWidgetResponse = 5 << 20
+	// defaultWidgetCache is how long results are reused when a source sets no cache time
+	defaultWidgetCache = 30
+	// maxWidgetCache is the longest cache time a source may set
+	maxWidgetCache = 24 * 60 * 60
+)
+
+// NewWidgetService creates a new WidgetService
+func NewWidgetService() *WidgetService {
+	transport := func(skipVerify bool) *http.Transport {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify}
+		return t
+	}
+	return &WidgetService{
+		db:       database.GetDB(),
+		client:   &http.Client{Timeout: widgetFetchTimeout, Transport: transport(false)},
+		insecure: &http.Client{Timeout: widgetFetchTimeout, Transport: transport(true)},
+		results:  make(map[uint]widgetResult),
+	}
+}
+
+// decodeWidgetSources fills the extraction map of sources loaded from the database
+func decodeWidgetSources(sources ...*models.WidgetSource) {
+	for _, source := range sources {
+		source.Extract = map[string]string{}
+		if source.RawExtract != "" {
+			json.Unmarshal([]byte(source.RawExtract), &source.Extract)
+		}
+		source.HasSecret = source.Secret != ""
+	}
+}
+
+// applyWidgetRequest validates a request and copies it onto a source. An
+// empty secret keeps the stored one unless the auth type no longer uses one.
+func applyWidgetRequest(source *models.WidgetSource, req models.WidgetSourceRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+
+	req.Method = strings.ToUpper(req.Method)
+	switch req.Method {
+	case "":
+		req.Method = http.MethodGet
+	case http.MethodGet, http.MethodPost:
+	default:
+		return fmt.Errorf("method must be GET or POST")
+	}
+	if req.Body != "" && !json.Valid([]byte(req.Body)) {
+		return fmt.Errorf("body must be valid JSON")
+	}
+
+	if req.AuthType == "" {
+		req.AuthType = models.WidgetAuthNone
+	}
+	switch req.AuthType {
+	case models.WidgetAuthNone, models.WidgetAuthBasic, models.WidgetAuthBearer:
+	case models.WidgetAuthHeader, models.WidgetAuthQuery:
+		if strings.TrimSpace(req.AuthHeader) == "" {
+			return fmt.Errorf("authHeader is required for %s auth", req.AuthType)
+		}
+	default:
+		return fmt.Errorf("unknown auth type: %s", req.AuthType)
+	}
+
+	if req.CacheSeconds <= 0 {
+		req.CacheSeconds = defaultWidgetCache
+	}
+	if req.CacheSeconds > maxWidgetCache {
+		return fmt.Errorf("cacheSeconds must be at most %d", maxWidgetCache)
+	}
+	for name, path := range req.Extract {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("extract field names must not be empty")
+		}
+		if err := validatePath(path); err != nil {
+			return fmt.Errorf("extract %s: %v", name, err)
+		}
+	}
+
+	switch {
+	case req.AuthType == models.WidgetAuthNone:
+		source.Secret = ""
+	case req.Secret != "":
+		encrypted, err := EncryptSecret(req.Secret)
+		if err != nil {
+			return err
+		}
+		source.Secret = encrypted
+	}
+
+	source.Name = req.Name
+	source.URL = req.URL
+	source.Method = req.Method
+	source.Body = req.Body
+	source.AuthType = req.AuthType
+	source.AuthHeader = strings.TrimSpace(req.AuthHeader)
+	source.Username = req.Username
+	source.SkipTLSVerify = req.SkipTLSVerify
+	source.CacheSeconds = req.CacheSeconds
+	source.Extract = req.Extract
+	if source.Extract == nil {
+		source.Extract = map[string]string{}
+	}
+	data, _ := json.Marshal(source.Extract)
+	source.RawExtract = string(data)
+	source.HasSecret = source.Secret != ""
+	return nil
+}
+
+// ListSources returns the user's widget sources
+func (s *WidgetService) ListSources(userID uint) ([]models.WidgetSource, error) {
+	var sources []models.WidgetSource
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	for i := range sources {
+		decodeWidgetSources(&sources[i])
+	}
+	return sources, nil
+}
+
+// GetSource returns one of the user's widget sources
+func (s *WidgetService) GetSource(id uint, userID uint) (*models.WidgetSource, error) {
+	var source models.WidgetSource
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&source).Error; err != nil {
+		return nil, fmt.Errorf("widget source not found")
+	}
+	decodeWidgetSources(&source)
+	return &source, nil
+}
+
+// CreateSource adds a widget source
+func (s *WidgetService) CreateSource(userID uint, req models.WidgetSourceRequest) (*models.WidgetSource, error) {
+	source := models.WidgetSource{UserID: userID}
+	if err := applyWidgetRequest(&source, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&source).Error; err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// UpdateSource replaces a widget source's settings and drops its cached data
+func (s *WidgetService) UpdateSource(id uint, userID uint, req models.WidgetSourceRequest) (*models.WidgetSource, error) {
+	source, err := s.GetSource(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWidgetRequest(source, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(source).Error; err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.results, source.ID)
+	s.mu.Unlock()
+	return source, nil
+}
+
+// DeleteSource removes a widget source
+func (s *WidgetService) DeleteSource(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WidgetSource{})
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("widget source not found")
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+
+	s.mu.Lock()
+	delete(s.results, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// GetData returns a source's extracted data, from cache unless refresh is set
+func (s *WidgetService) GetData(id uint, userID uint, refresh bool) (*models.WidgetData, error) {
+	source, err := s.GetSource(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		s.mu.Lock()
+		cached, ok := s.results[source.ID]
+		s.mu.Unlock()
+		if ok && time.Now().Before(cached.expiresAt) {
+			data := cached.data
+			data.Cached = true
+			return &data, nil
+		}
+	}
+
+	data, err := s.fetch(*source)
+	now := time.Now()
+	updates := map[string]interface{}{"last_fetched_at": now, "last_error": ""}
+	if err != nil {
+		updates["last_error"] = truncateRunes(err.Error(), 1000)
+	}
+	s.db.Model(source).Updates(updates)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.results[source.ID] = widgetResult{data: *data, expiresAt: now.Add(time.Duration(source.CacheSeconds) * time.Second)}
+	s.mu.Unlock()
+	return data, nil
+}
+
+// Preview fetches an unsaved source, to try out extraction paths. An empty
+// secret uses the stored one of sourceID, when given.
+func (s *WidgetService) Preview(userID uint, sourceID uint, req models.WidgetSourceRequest) (*models.WidgetData, error) {
+	source := models.WidgetSource{UserID: userID}
+	if sourceID != 0 {
+		stored, err := s.GetSource(sourceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		source = *stored
+	}
+	if err := applyWidgetRequest(&source, req); err != nil {
+		return nil, err
+	}
+	return s.fetch(source)
+}
+
+// fetch requests a source's URL and applies its extraction paths
+func (s *WidgetService) fetch(source models.WidgetSource) (*models.WidgetData, error) {
+	secret, err := DecryptSecret(source.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secret: %v", err)
+	}
+
+	target, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	if source.AuthType == models.WidgetAuthQuery {
+		query := target.Query()
+		query.Set(source.AuthHeader, secret)
+		target.RawQuery = query.Encode()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), widgetFetchTimeout)
+	defer cancel()
+
+	var body io.Reader
+	if source.Method == http.MethodPost && source.Body != "" {
+		body = strings.NewReader(source.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, source.Method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Homelab-Monitor/1.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch source.AuthType {
+	case models.WidgetAuthBasic:
+		req.SetBasicAuth(source.Username, secret)
+	case models.WidgetAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+secret)
+	case models.WidgetAuthHeader:
+		req.Header.Set(source.AuthHeader, secret)
+	}
+
+	client := s.client
+	if source.SkipTLSVerify {
+		client = s.insecure
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Query auth puts the secret in the URL; keep it out of errors
+		if urlErr, ok := err.(*url.Error); ok {
+			return nil, fmt.Errorf("request to %s failed: %v", target.Host, urlErr.Err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxWidgetResponse+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s returned %d", target.Host, resp.StatusCode)
+	}
+	if len(raw) > maxWidgetResponse {
+		return nil, fmt.Errorf("response is larger than %d MB", maxWidgetResponse>>20)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %v", err)
+	}
+
+	result := &models.WidgetData{SourceID: source.ID, FetchedAt: time.Now()}
+	if len(source.Extract) == 0 {
+		result.Data = decoded
+		return result, nil
+	}
+
+	names := make([]string, 0, len(source.Extract))
+	for name := range source.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		value, err := extractPath(decoded, source.Extract[name])
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			fields[name] = nil
+			continue
+		}
+		fields[name] = value
+	}
+	result.Data = fields
+	return result, nil
+}
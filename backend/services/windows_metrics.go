@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/homelab/backend/models"
+)
+
+// ErrNotWindows is returned by the Windows metrics when the backend runs elsewhere
+var ErrNotWindows = errors.New("windows metrics are only available when the backend runs on Windows")
+
+// windowsCommandTimeout bounds each PowerShell or reg query
+const windowsCommandTimeout = 20 * time.Second
+
+// pendingRebootKeys are registry locations that exist while a restart is pending
+var pendingRebootKeys = []struct {
+	key    string
+	value  string
+	reason string
+}{
+	{`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired`, "", "Windows Update"},
+	{`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootPending`, "", "Component Based Servicing"},
+	{`HKLM\SYSTEM\CurrentControlSet\Control\Session Manager`, "PendingFileRenameOperations", "Pending file rename operations"},
+}
+
+// runPowerShell runs a script and returns its output
+func runPowerShell(script string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), windowsCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("powershell: %v", err)
+	}
+	return output, nil
+}
+
+// GetWindowsServices lists services from the Service Control Manager
+func (s *MetricsService) GetWindowsServices(filter models.WindowsServiceFilter) ([]models.WindowsService, error) {
+	if runtime.GOOS != "windows" {
+		return nil, ErrNotWindows
+	}
+
+	output, err := runPowerShell(`ConvertTo-Json -Compress -InputObject @(Get-CimInstance Win32_Service | ` +
+		`Select-Object @{n='name';e={$_.Name}}, @{n='displayName';e={$_.DisplayName}}, ` +
+		`@{n='state';e={$_.State}}, @{n='startMode';e={$_.StartMode}}, @{n='pid';e={[int]$_.ProcessId}})`)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []models.WindowsService
+	if err := json.Unmarshal(output, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse service list: %v", err)
+	}
+
+	result := make([]models.WindowsService, 0, len(all))
+	name := strings.ToLower(filter.Name)
+	for _, svc := range all {
+		if filter.State != "" && !strings.EqualFold(svc.State, filter.State) {
+			continue
+		}
+		if filter.StartMode != "" && !strings.EqualFold(svc.StartMode, filter.StartMode) {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(svc.Name), name) &&
+			!strings.Contains(strings.ToLower(svc.DisplayName), name) {
+			continue
+		}
+		result = append(result, svc)
+	}
+	return result, nil
+}
+
+// GetPendingReboot checks the registry for a pending restart
+func (s *MetricsService) GetPendingReboot() (*models.PendingReboot, error) {
+	if runtime.GOOS != "windows" {
+		return nil, ErrNotWindows
+	}
+
+	result := &models.PendingReboot{Reasons: make([]string, 0)}
+	for _, k := range pendingRebootKeys {
+		args := []string{"query", k.key}
+		if k.value != "" {
+			args = append(args, "/v", k.value)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), windowsCommandTimeout)
+		err := exec.CommandContext(ctx, "reg", args...).Run()
+		cancel()
+
+		// reg exits with 1 when the key or value does not exist
+		if err == nil {
+			result.Pending = true
+			result.Reasons = append(result.Reasons, k.reason)
+		}
+	}
+	return result, nil
+}
+
+// GetEventLogErrors counts critical and error entries in the System and
+// Application logs over the last hours, with the noisiest sources
+func (s *MetricsService) GetEventLogErrors(hours int) ([]models.EventLogSummary, error) {
+	if runtime.GOOS != "windows" {
+		return nil, ErrNotWindows
+	}
+
+	script := fmt.Sprintf(`$since = (Get-Date).AddHours(-%d)
+$r = foreach ($log in 'System','Application') {
+  $ev = @(Get-WinEvent -FilterHashtable @{LogName=$log; Level=1,2; StartTime=$since} -ErrorAction SilentlyContinue)
+  [pscustomobject]@{
+    log = $log
+    critical = @($ev | Where-Object { $_.Level -eq 1 }).Count
+    errors = @($ev | Where-Object { $_.Level -eq 2 }).Count
+    topSources = @($ev | Group-Object ProviderName | Sort-Object Count -Descending | Select-Object -First 5 | ForEach-Object { [pscustomobject]@{name=$_.Name; count=$_.Count} })
+  }
+}
+ConvertTo-Json -Compress -Depth 4 -InputObject @($r)`, hours)
+
+	output, err := runPowerShell(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.EventLogSummary
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse event log counts: %v", err)
+	}
+	return result, nil
+}
+
+// GetWindowsMetrics combines service, pending reboot and event log health
+func (s *MetricsService) GetWindowsMetrics(hours int) (*models.WindowsMetrics, error) {
+	if runtime.GOOS != "windows" {
+		return nil, ErrNotWindows
+	}
+
+	svcs, err := s.GetWindowsServices(models.WindowsServiceFilter{})
+	if err != nil {
+		return nil, err
+	}
+	reboot, err := s.GetPendingReboot()
+	if err != nil {
+		return nil, err
+	}
+	eventLog, err := s.GetEventLogErrors(hours)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &models.WindowsMetrics{
+		AutoNotRunning: make([]models.WindowsService, 0),
+		PendingReboot:  *reboot,
+		EventLogHours:  hours,
+		EventLog:       eventLog,
+	}
+	for _, svc := range svcs {
+		if svc.State == "Running" {
+			metrics.ServicesRunning++
+			continue
+		}
+		metrics.ServicesStopped++
+		if svc.StartMode == "Auto" {
+			metrics.AutoNotRunning = append(metrics.AutoNotRunning, svc)
+		}
+	}
+	return metrics, nil
+}
@@ -0,0 +1,220 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags of the SNMP types and PDUs
+const (
+	tagInteger        = 0x02
+	tagOctetString    = 0x04
+	tagNull           = 0x05
+	tagOID            = 0x06
+	tagSequence       = 0x30
+	tagIPAddress      = 0x40
+	tagCounter32      = 0x41
+	tagGauge32        = 0x42
+	tagTimeTicks      = 0x43
+	tagOpaque         = 0x44
+	tagCounter64      = 0x46
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	pduGetRequest     = 0xa0
+	pduGetNextRequest = 0xa1
+	pduResponse       = 0xa2
+	pduGetBulkRequest = 0xa5
+	pduReport         = 0xa8
+)
+
+var errMalformed = errors.New("snmp: malformed message")
+
+// encodeLength encodes a BER definite length
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// tlv encodes a tag, length and value
+func tlv(tag byte, value []byte) []byte {
+	out := append([]byte{tag}, encodeLength(len(value))...)
+	return append(out, value...)
+}
+
+// sequence encodes a SEQUENCE (or constructed PDU) of encoded elements
+func sequence(tag byte, elements ...[]byte) []byte {
+	var value []byte
+	for _, e := range elements {
+		value = append(value, e...)
+	}
+	return tlv(tag, value)
+}
+
+// encodeInt encodes a signed INTEGER
+func encodeInt(n int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		if (n >= -0x80 && n < 0x80) || len(b) == 8 {
+			break
+		}
+		n >>= 8
+	}
+	return tlv(tagInteger, b)
+}
+
+func encodeString(s []byte) []byte {
+	return tlv(tagOctetString, s)
+}
+
+func encodeNull() []byte {
+	return []byte{tagNull, 0}
+}
+
+// encodeOID encodes a dotted object identifier
+func encodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("snmp: invalid OID %q", oid)
+	}
+	ids := make([]uint64, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("snmp: invalid OID %q", oid)
+		}
+		ids[i] = n
+	}
+	if ids[0] > 2 || (ids[0] < 2 && ids[1] >= 40) {
+		return nil, fmt.Errorf("snmp: invalid OID %q", oid)
+	}
+
+	value := encodeBase128(ids[0]*40 + ids[1])
+	for _, id := range ids[2:] {
+		value = append(value, encodeBase128(id)...)
+	}
+	return tlv(tagOID, value), nil
+}
+
+func encodeBase128(n uint64) []byte {
+	b := []byte{byte(n & 0x7f)}
+	for n >>= 7; n > 0; n >>= 7 {
+		b = append([]byte{byte(n&0x7f) | 0x80}, b...)
+	}
+	return b
+}
+
+// element is a decoded BER element. Raw is the whole encoding, Value the
+// contents.
+type element struct {
+	Tag   byte
+	Value []byte
+	Raw   []byte
+}
+
+// decode reads the first element of b and returns it with the rest of b
+func decode(b []byte) (element, []byte, error) {
+	if len(b) < 2 {
+		return element{}, nil, errMalformed
+	}
+	tag := b[0]
+	length, header := int(b[1]), 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(b) < 2+n {
+			return element{}, nil, errMalformed
+		}
+		length = 0
+		for _, c := range b[2 : 2+n] {
+			length = length<<8 | int(c)
+		}
+		header += n
+	}
+	// Compared without adding, which could overflow a 32-bit int
+	if length < 0 || length > len(b)-header {
+		return element{}, nil, errMalformed
+	}
+	end := header + length
+	return element{Tag: tag, Value: b[header:end], Raw: b[:end]}, b[end:], nil
+}
+
+// decodeAll decodes the elements of a constructed value
+func decodeAll(b []byte) ([]element, error) {
+	var elements []element
+	for len(b) > 0 {
+		e, rest, err := decode(b)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		b = rest
+	}
+	return elements, nil
+}
+
+// decodeInt decodes a signed INTEGER value
+func decodeInt(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errMalformed
+	}
+	n := int64(int8(b[0]))
+	for _, c := range b[1:] {
+		n = n<<8 | int64(c)
+	}
+	return n, nil
+}
+
+// decodeUint decodes the unsigned value of a counter, gauge or time ticks
+func decodeUint(b []byte) (uint64, error) {
+	if len(b) == 0 || len(b) > 9 {
+		return 0, errMalformed
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// decodeOID decodes an object identifier to its dotted form
+func decodeOID(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", errMalformed
+	}
+	var ids []uint64
+	var n uint64
+	for i, c := range b {
+		n = n<<7 | uint64(c&0x7f)
+		if c&0x80 == 0 {
+			ids = append(ids, n)
+			n = 0
+		} else if i == len(b)-1 {
+			return "", errMalformed
+		}
+	}
+
+	parts := make([]string, 0, len(ids)+1)
+	first := ids[0]
+	switch {
+	case first < 40:
+		parts = append(parts, "0", strconv.FormatUint(first, 10))
+	case first < 80:
+		parts = append(parts, "1", strconv.FormatUint(first-40, 10))
+	default:
+		parts = append(parts, "2", strconv.FormatUint(first-80, 10))
+	}
+	for _, id := range ids[1:] {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,133 @@
+package snmp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"empty", nil},
+		{"tag only", []byte{tagSequence}},
+		{"short value", []byte{tagOctetString, 3, 'a', 'b'}},
+		{"indefinite length", []byte{tagSequence, 0x80, 0, 0}},
+		{"length of five bytes", []byte{tagOctetString, 0x85, 0, 0, 0, 0, 1, 'a'}},
+		{"truncated long length", []byte{tagOctetString, 0x82, 0x01}},
+		{"long length past the end", []byte{tagOctetString, 0x82, 0x01, 0x00, 'a'}},
+		{"largest positive length", []byte{tagOctetString, 0x84, 0x7f, 0xff, 0xff, 0xff, 'a'}},
+		{"length with the sign bit", []byte{tagOctetString, 0x84, 0xff, 0xff, 0xff, 0xff, 'a'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decode(tt.input); !errors.Is(err, errMalformed) {
+				t.Errorf("decode(%x) error = %v, want errMalformed", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestDecodeLongLength(t *testing.T) {
+	value := bytes.Repeat([]byte{'x'}, 300)
+	encoded := append(encodeString(value), 0x05, 0x00)
+
+	e, rest, err := decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Tag != tagOctetString || !bytes.Equal(e.Value, value) {
+		t.Errorf("decoded tag %#x with %d bytes, want an OCTET STRING of 300", e.Tag, len(e.Value))
+	}
+	if !bytes.Equal(rest, []byte{0x05, 0x00}) {
+		t.Errorf("rest = %x, want 0500", rest)
+	}
+}
+
+func TestDecodeAllTruncated(t *testing.T) {
+	pdu, err := encodePDU(pduGetRequest, 1, 0, 0, []string{"1.3.6.1.2.1.1.1.0", "1.3.6.1.2.1.1.3.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every prefix of a valid message must fail cleanly
+	for n := 0; n < len(pdu); n++ {
+		if _, err := decodeV2c(sequence(tagSequence, encodeInt(1), encodeString([]byte("public")), pdu[:n])); err == nil {
+			t.Errorf("decoding a message truncated to %d PDU bytes succeeded", n)
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	for _, n := range []int64{0, 1, 127, 128, -1, -128, -129, 65535, 1 << 31, -1 << 40, 1<<63 - 1, -1 << 63} {
+		e, _, err := decode(encodeInt(n))
+		if err != nil {
+			t.Fatalf("decode(encodeInt(%d)): %v", n, err)
+		}
+		got, err := decodeInt(e.Value)
+		if err != nil || got != n {
+			t.Errorf("decodeInt(encodeInt(%d)) = %d, %v", n, got, err)
+		}
+	}
+	if _, err := decodeInt(nil); err == nil {
+		t.Error("decodeInt of an empty value succeeded")
+	}
+	if _, err := decodeInt(make([]byte, 9)); err == nil {
+		t.Error("decodeInt of nine bytes succeeded")
+	}
+}
+
+func TestUint(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  uint64
+	}{
+		{[]byte{0x00, 0xff, 0xff, 0xff, 0xff}, 0xffffffff},
+		{[]byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0xffffffffffffffff},
+		{[]byte{0x2a}, 42},
+	}
+	for _, tt := range tests {
+		got, err := decodeUint(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("decodeUint(%x) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+	if _, err := decodeUint(make([]byte, 10)); err == nil {
+		t.Error("decodeUint of ten bytes succeeded")
+	}
+}
+
+func TestOID(t *testing.T) {
+	for _, oid := range []string{"1.3.6.1.2.1.2.2.1.10.1", "0.0", "2.999.1", "1.3.6.1.4.1.4294967295"} {
+		encoded, err := encodeOID(oid)
+		if err != nil {
+			t.Fatalf("encodeOID(%q): %v", oid, err)
+		}
+		e, _, err := decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeOID(e.Value)
+		if err != nil || got != oid {
+			t.Errorf("decodeOID(encodeOID(%q)) = %q, %v", oid, got, err)
+		}
+	}
+
+	encoded, _ := encodeOID(".1.3.6.1")
+	if want := []byte{tagOID, 3, 0x2b, 6, 1}; !bytes.Equal(encoded, want) {
+		t.Errorf("encodeOID(.1.3.6.1) = %x, want %x", encoded, want)
+	}
+
+	for _, oid := range []string{"1", "3.1", "1.40", "1.3.x", "1.3.4294967296"} {
+		if _, err := encodeOID(oid); err == nil {
+			t.Errorf("encodeOID(%q) succeeded", oid)
+		}
+	}
+	if _, err := decodeOID([]byte{0x2b, 0x86}); err == nil {
+		t.Error("decodeOID of an unterminated sub-identifier succeeded")
+	}
+	if _, err := decodeOID(nil); err == nil {
+		t.Error("decodeOID of an empty value succeeded")
+	}
+}
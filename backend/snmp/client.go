@@ -0,0 +1,393 @@
+// Package snmp is a small SNMP v2c and v3 (USM) client covering what the
+// collector needs: GET and GETBULK walks over UDP.
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Versions of the protocol the client speaks
+const (
+	Version2c = "2c"
+	Version3  = "3"
+)
+
+// Defaults for the agent port, request timeout and retries
+const (
+	DefaultPort    = 161
+	defaultTimeout = 2 * time.Second
+	defaultRetries = 1
+	// bulkRepetitions is how many rows each GETBULK of a walk asks for
+	bulkRepetitions = 25
+	// maxWalkRequests bounds a walk against agents that never end it
+	maxWalkRequests = 1000
+	// maxMessageSize is the largest message accepted and advertised
+	maxMessageSize = 65507
+)
+
+// ErrTimeout is returned when the agent does not answer
+var ErrTimeout = errors.New("snmp: no response from agent")
+
+// Config is how to reach and authenticate with an agent. Community is used
+// with v2c; User and the auth and priv settings with v3, where an empty
+// AuthProtocol is noAuthNoPriv and an empty PrivProtocol authNoPriv.
+type Config struct {
+	Host      string
+	Port      int
+	Version   string
+	Community string
+
+	User         string
+	AuthProtocol string // MD5 or SHA
+	AuthPassword string
+	PrivProtocol string // DES or AES
+	PrivPassword string
+
+	Timeout time.Duration
+	Retries int
+}
+
+// Variable is an object returned by an agent
+type Variable struct {
+	OID   string
+	Type  byte
+	Value interface{} // int64, uint64, []byte, or string for OIDs and IP addresses
+}
+
+// Exists reports whether the agent returned a value rather than
+// noSuchObject, noSuchInstance or endOfMibView
+func (v Variable) Exists() bool {
+	return v.Type != tagNoSuchObject && v.Type != tagNoSuchInstance && v.Type != tagEndOfMibView
+}
+
+// Uint64 returns a numeric value, false for other types and negative integers
+func (v Variable) Uint64() (uint64, bool) {
+	switch value := v.Value.(type) {
+	case uint64:
+		return value, true
+	case int64:
+		if value >= 0 {
+			return uint64(value), true
+		}
+	}
+	return 0, false
+}
+
+// String returns a value as text
+func (v Variable) String() string {
+	switch value := v.Value.(type) {
+	case []byte:
+		return strings.TrimRight(string(value), "\x00")
+	case string:
+		return value
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	}
+	return ""
+}
+
+// Client talks to a single agent. Its methods are safe for concurrent use
+// but requests are sent one at a time.
+type Client struct {
+	cfg  Config
+	conn net.Conn
+
+	mu        sync.Mutex
+	requestID int32
+	usm       *usm // v3 only
+}
+
+// Dial connects to an agent. For v3 it discovers the agent's engine and
+// derives the keys, so bad credentials fail here.
+func Dial(cfg Config) (*Client, error) {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Version == "" {
+		cfg.Version = Version2c
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("udp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{cfg: cfg, conn: conn, requestID: rand.Int32N(1 << 30)}
+	if cfg.Version == Version3 {
+		c.usm = newUSM(cfg)
+		if err := c.discover(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Validate checks a configuration without contacting the agent
+func Validate(cfg Config) error {
+	switch cfg.Version {
+	case Version2c, "":
+		if cfg.Community == "" {
+			return fmt.Errorf("snmp: a community is required for v2c")
+		}
+	case Version3:
+		if cfg.User == "" {
+			return fmt.Errorf("snmp: a user is required for v3")
+		}
+		switch strings.ToUpper(cfg.AuthProtocol) {
+		case "":
+			if cfg.PrivProtocol != "" {
+				return fmt.Errorf("snmp: privacy requires authentication")
+			}
+		case "MD5", "SHA":
+			if len(cfg.AuthPassword) < 8 {
+				return fmt.Errorf("snmp: the auth password must be at least 8 characters")
+			}
+		default:
+			return fmt.Errorf("snmp: auth protocol must be MD5 or SHA")
+		}
+		switch strings.ToUpper(cfg.PrivProtocol) {
+		case "":
+		case "DES", "AES":
+			if len(cfg.PrivPassword) < 8 {
+				return fmt.Errorf("snmp: the privacy password must be at least 8 characters")
+			}
+		default:
+			return fmt.Errorf("snmp: privacy protocol must be DES or AES")
+		}
+	default:
+		return fmt.Errorf("snmp: version must be 2c or 3")
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return fmt.Errorf("snmp: invalid port %d", cfg.Port)
+	}
+	return nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Get fetches the given objects
+func (c *Client) Get(oids ...string) ([]Variable, error) {
+	return c.request(pduGetRequest, 0, 0, oids)
+}
+
+// Walk calls fn for every object under root, in order, using GETBULK
+func (c *Client) Walk(root string, fn func(Variable) error) error {
+	root = strings.TrimPrefix(root, ".")
+	oid := root
+	for i := 0; i < maxWalkRequests; i++ {
+		vars, err := c.request(pduGetBulkRequest, 0, bulkRepetitions, []string{oid})
+		if err != nil {
+			return err
+		}
+		if len(vars) == 0 {
+			return nil
+		}
+		for _, v := range vars {
+			if !v.Exists() || !strings.HasPrefix(v.OID, root+".") {
+				return nil
+			}
+			if v.OID == oid {
+				return fmt.Errorf("snmp: agent returned %s twice in a walk", oid)
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+			oid = v.OID
+		}
+	}
+	return fmt.Errorf("snmp: walk of %s did not end", root)
+}
+
+// encodePDU encodes a request PDU. For GETBULK a and b are non-repeaters
+// and max-repetitions, otherwise zero.
+func encodePDU(pduType byte, requestID int32, a, b int, oids []string) ([]byte, error) {
+	varbinds := make([][]byte, 0, len(oids))
+	for _, oid := range oids {
+		encoded, err := encodeOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		varbinds = append(varbinds, sequence(tagSequence, encoded, encodeNull()))
+	}
+	return sequence(pduType,
+		encodeInt(int64(requestID)),
+		encodeInt(int64(a)),
+		encodeInt(int64(b)),
+		sequence(tagSequence, varbinds...),
+	), nil
+}
+
+// pdu is a decoded response or report PDU
+type pdu struct {
+	Type        byte
+	RequestID   int32
+	ErrorStatus int64
+	ErrorIndex  int64
+	Variables   []Variable
+}
+
+// decodePDU decodes a PDU element
+func decodePDU(e element) (*pdu, error) {
+	fields, err := decodeAll(e.Value)
+	if err != nil || len(fields) != 4 {
+		return nil, errMalformed
+	}
+	p := &pdu{Type: e.Tag}
+	id, err := decodeInt(fields[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	p.RequestID = int32(id)
+	if p.ErrorStatus, err = decodeInt(fields[1].Value); err != nil {
+		return nil, err
+	}
+	if p.ErrorIndex, err = decodeInt(fields[2].Value); err != nil {
+		return nil, err
+	}
+
+	varbinds, err := decodeAll(fields[3].Value)
+	if err != nil {
+		return nil, err
+	}
+	for _, vb := range varbinds {
+		parts, err := decodeAll(vb.Value)
+		if err != nil || len(parts) != 2 || parts[0].Tag != tagOID {
+			return nil, errMalformed
+		}
+		v, err := decodeVariable(parts[0].Value, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		p.Variables = append(p.Variables, v)
+	}
+	return p, nil
+}
+
+// decodeVariable decodes a varbind's value
+func decodeVariable(oid []byte, value element) (Variable, error) {
+	name, err := decodeOID(oid)
+	if err != nil {
+		return Variable{}, err
+	}
+	v := Variable{OID: name, Type: value.Tag}
+	switch value.Tag {
+	case tagInteger:
+		v.Value, err = decodeInt(value.Value)
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		v.Value, err = decodeUint(value.Value)
+	case tagOctetString, tagOpaque:
+		v.Value = value.Value
+	case tagOID:
+		v.Value, err = decodeOID(value.Value)
+	case tagIPAddress:
+		v.Value = net.IP(value.Value).String()
+	}
+	return v, err
+}
+
+// errorStatusNames names the PDU error statuses
+var errorStatusNames = []string{
+	"noError", "tooBig", "noSuchName", "badValue", "readOnly", "genErr",
+	"noAccess", "wrongType", "wrongLength", "wrongEncoding", "wrongValue",
+	"noCreation", "inconsistentValue", "resourceUnavailable", "commitFailed",
+	"undoFailed", "authorizationError", "notWritable", "inconsistentName",
+}
+
+// request sends a PDU and returns the response's variables
+func (c *Client) request(pduType byte, a, b int, oids []string) ([]Variable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requestID++
+	id := c.requestID
+	encoded, err := encodePDU(pduType, id, a, b, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *pdu
+	if c.usm != nil {
+		response, err = c.exchangeV3(encoded, id)
+	} else {
+		message := sequence(tagSequence, encodeInt(1), encodeString([]byte(c.cfg.Community)), encoded)
+		response, err = c.exchange(message, id, decodeV2c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response.ErrorStatus != 0 {
+		name := strconv.FormatInt(response.ErrorStatus, 10)
+		if response.ErrorStatus > 0 && response.ErrorStatus < int64(len(errorStatusNames)) {
+			name = errorStatusNames[response.ErrorStatus]
+		}
+		return nil, fmt.Errorf("snmp: agent returned %s", name)
+	}
+	return response.Variables, nil
+}
+
+// exchange sends a message and waits for the PDU answering request id,
+// resending it on timeout
+func (c *Client) exchange(message []byte, id int32, decodeMessage func([]byte) (*pdu, error)) (*pdu, error) {
+	buf := make([]byte, maxMessageSize)
+	for attempt := 0; attempt <= c.cfg.Retries; attempt++ {
+		if _, err := c.conn.Write(message); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(c.cfg.Timeout)
+		for {
+			c.conn.SetReadDeadline(deadline)
+			n, err := c.conn.Read(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			response, err := decodeMessage(buf[:n])
+			if err != nil {
+				return nil, err
+			}
+			// Late answers to earlier attempts are skipped
+			if response.RequestID == id {
+				return response, nil
+			}
+		}
+	}
+	return nil, ErrTimeout
+}
+
+// decodeV2c decodes a v2c message's PDU
+func decodeV2c(b []byte) (*pdu, error) {
+	message, _, err := decode(b)
+	if err != nil || message.Tag != tagSequence {
+		return nil, errMalformed
+	}
+	fields, err := decodeAll(message.Value)
+	if err != nil || len(fields) != 3 {
+		return nil, errMalformed
+	}
+	return decodePDU(fields[2])
+}
@@ -0,0 +1,249 @@
+package snmp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAgent answers GET and GETBULK from a sorted list of objects. With
+// usm set it speaks v3 and reports its engine to unauthenticated requests.
+type fakeAgent struct {
+	t       *testing.T
+	conn    net.PacketConn
+	objects []fakeObject
+	usm     *usm
+}
+
+type fakeObject struct {
+	oid   string
+	value []byte // encoded
+}
+
+func startFakeAgent(t *testing.T, u *usm, objects ...fakeObject) *fakeAgent {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &fakeAgent{t: t, conn: conn, objects: objects, usm: u}
+	t.Cleanup(func() { conn.Close() })
+	go a.serve()
+	return a
+}
+
+func (a *fakeAgent) config() Config {
+	addr := a.conn.LocalAddr().(*net.UDPAddr)
+	return Config{Host: "127.0.0.1", Port: addr.Port, Timeout: time.Second}
+}
+
+func (a *fakeAgent) serve() {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, from, err := a.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if reply := a.handle(buf[:n]); reply != nil {
+			a.conn.WriteTo(reply, from)
+		}
+	}
+}
+
+func (a *fakeAgent) handle(b []byte) []byte {
+	if a.usm == nil {
+		message, _, err := decode(b)
+		if err != nil {
+			return nil
+		}
+		fields, err := decodeAll(message.Value)
+		if err != nil || len(fields) != 3 {
+			return nil
+		}
+		request, err := decodePDU(fields[2])
+		if err != nil {
+			return nil
+		}
+		return sequence(tagSequence, encodeInt(1), encodeString(fields[1].Value), a.respond(request))
+	}
+
+	m, err := decodeV3Message(b)
+	if err != nil {
+		return nil
+	}
+	if len(m.engineID) == 0 {
+		request, err := m.pdu()
+		if err != nil {
+			return nil
+		}
+		oid, _ := encodeOID("1.3.6.1.6.3.15.1.1.4.0")
+		report := sequence(pduReport, encodeInt(int64(request.RequestID)), encodeInt(0), encodeInt(0),
+			sequence(tagSequence, sequence(tagSequence, oid, tlv(tagCounter32, []byte{1}))))
+		return encodeV3Message(m.msgID, 0, a.usm.engineID, a.usm.boots, a.usm.currentTime(), "", nil, nil,
+			sequence(tagSequence, encodeString(a.usm.engineID), encodeString(nil), report))
+	}
+	if err := a.usm.verify(b, m); err != nil {
+		a.t.Errorf("agent: %v", err)
+		return nil
+	}
+	request, err := m.pduWith(a.usm)
+	if err != nil {
+		a.t.Errorf("agent: %v", err)
+		return nil
+	}
+	a.usm.msgID = m.msgID - 1
+	reply, _, err := a.usm.encodeRequest(a.respond(request))
+	if err != nil {
+		a.t.Errorf("agent: %v", err)
+		return nil
+	}
+	return reply
+}
+
+// respond builds the response PDU to a GET or GETBULK
+func (a *fakeAgent) respond(request *pdu) []byte {
+	var varbinds [][]byte
+	add := func(oid string, value []byte) {
+		encoded, _ := encodeOID(oid)
+		varbinds = append(varbinds, sequence(tagSequence, encoded, value))
+	}
+	for _, v := range request.Variables {
+		if request.Type == pduGetRequest {
+			value := tlv(tagNoSuchObject, nil)
+			for _, o := range a.objects {
+				if o.oid == v.OID {
+					value = o.value
+				}
+			}
+			add(v.OID, value)
+			continue
+		}
+		// GETBULK carries max-repetitions where responses have the error index
+		count := 0
+		for _, o := range a.objects {
+			if oidAfter(o.oid, v.OID) && count < int(request.ErrorIndex) {
+				add(o.oid, o.value)
+				count++
+			}
+		}
+		if count < int(request.ErrorIndex) {
+			add(v.OID, tlv(tagEndOfMibView, nil))
+		}
+	}
+	return sequence(pduResponse, encodeInt(int64(request.RequestID)), encodeInt(0), encodeInt(0), sequence(tagSequence, varbinds...))
+}
+
+// oidAfter reports whether OID a sorts after b
+func oidAfter(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] != pb[i] {
+			if len(pa[i]) != len(pb[i]) {
+				return len(pa[i]) > len(pb[i])
+			}
+			return pa[i] > pb[i]
+		}
+	}
+	return len(pa) > len(pb)
+}
+
+var testObjects = []fakeObject{
+	{"1.3.6.1.2.1.1.1.0", encodeString([]byte("test agent"))},
+	{"1.3.6.1.2.1.1.3.0", tlv(tagTimeTicks, []byte{0x01, 0x00})},
+	{"1.3.6.1.2.1.2.2.1.10.1", tlv(tagCounter32, []byte{0x00, 0xff, 0xff, 0xff, 0xff})},
+	{"1.3.6.1.2.1.2.2.1.10.2", tlv(tagCounter32, []byte{0x2a})},
+	{"1.3.6.1.2.1.2.2.1.10.10", tlv(tagCounter32, []byte{0x07})},
+	{"1.3.6.1.2.1.4.1.0", encodeInt(1)},
+}
+
+func TestClientV2c(t *testing.T) {
+	agent := startFakeAgent(t, nil, testObjects...)
+	cfg := agent.config()
+	cfg.Community = "public"
+	client, err := Dial(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	vars, err := client.Get("1.3.6.1.2.1.1.1.0", "1.3.6.1.2.1.1.9.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 2 || vars[0].String() != "test agent" || vars[1].Exists() {
+		t.Errorf("Get returned %+v", vars)
+	}
+
+	var walked []string
+	err = client.Walk("1.3.6.1.2.1.2.2.1.10", func(v Variable) error {
+		n, _ := v.Uint64()
+		walked = append(walked, v.OID+"="+v.String())
+		if v.OID == "1.3.6.1.2.1.2.2.1.10.1" && n != 0xffffffff {
+			t.Errorf("ifInOctets.1 = %d", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1.3.6.1.2.1.2.2.1.10.1=4294967295 1.3.6.1.2.1.2.2.1.10.2=42 1.3.6.1.2.1.2.2.1.10.10=7"
+	if got := strings.Join(walked, " "); got != want {
+		t.Errorf("Walk = %s, want %s", got, want)
+	}
+}
+
+func TestClientV3(t *testing.T) {
+	for _, cfg := range []Config{
+		{User: "monitor"},
+		{User: "monitor", AuthProtocol: "MD5", AuthPassword: "authpass1"},
+		{User: "monitor", AuthProtocol: "MD5", AuthPassword: "authpass1", PrivProtocol: "DES", PrivPassword: "privpass1"},
+		{User: "monitor", AuthProtocol: "SHA", AuthPassword: "authpass1", PrivProtocol: "AES", PrivPassword: "privpass1"},
+	} {
+		t.Run(cfg.AuthProtocol+"/"+cfg.PrivProtocol, func(t *testing.T) {
+			agent := startFakeAgent(t, testUSM(cfg, testEngineID), testObjects...)
+			dial := agent.config()
+			dial.Version = Version3
+			dial.User, dial.AuthProtocol, dial.AuthPassword = cfg.User, cfg.AuthProtocol, cfg.AuthPassword
+			dial.PrivProtocol, dial.PrivPassword = cfg.PrivProtocol, cfg.PrivPassword
+
+			client, err := Dial(dial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			if string(client.usm.engineID) != string(testEngineID) {
+				t.Errorf("discovered engine ID %x, want %x", client.usm.engineID, testEngineID)
+			}
+
+			vars, err := client.Get("1.3.6.1.2.1.1.3.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, ok := vars[0].Uint64(); !ok || n != 256 {
+				t.Errorf("sysUpTime = %+v", vars[0])
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client, err := Dial(Config{
+		Host:      "127.0.0.1",
+		Port:      conn.LocalAddr().(*net.UDPAddr).Port,
+		Community: "public",
+		Timeout:   50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.Get("1.3.6.1.2.1.1.3.0"); err != ErrTimeout {
+		t.Errorf("Get error = %v, want ErrTimeout", err)
+	}
+}
@@ -0,0 +1,493 @@
+package snmp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// msgFlags bits of a v3 message
+const (
+	flagAuth       = 0x01
+	flagPriv       = 0x02
+	flagReportable = 0x04
+
+	securityModelUSM = 3
+	authParamsLength = 12
+)
+
+// usmReports maps the USM statistics OIDs an agent reports to the errors
+// they stand for
+var usmReports = map[string]string{
+	"1.3.6.1.6.3.15.1.1.1.0": "unsupported security level",
+	"1.3.6.1.6.3.15.1.1.2.0": "not in time window",
+	"1.3.6.1.6.3.15.1.1.3.0": "unknown user name",
+	"1.3.6.1.6.3.15.1.1.4.0": "unknown engine ID",
+	"1.3.6.1.6.3.15.1.1.5.0": "wrong digest, check the auth password",
+	"1.3.6.1.6.3.15.1.1.6.0": "decryption error, check the privacy password",
+}
+
+const notInTimeWindow = "1.3.6.1.6.3.15.1.1.2.0"
+
+// usm is the User-based Security Model state of a v3 client: the agent's
+// engine, its clock, and the keys localized to it
+type usm struct {
+	user     string
+	authHash func() hash.Hash // nil without authentication
+	authPass string
+	privProt string // DES, AES or empty
+	privPass string
+
+	engineID   []byte
+	boots      int64
+	engineTime int64
+	syncedAt   time.Time
+
+	authKey []byte
+	privKey []byte
+	salt    uint64
+	msgID   int32
+}
+
+func newUSM(cfg Config) *usm {
+	u := &usm{
+		user:     cfg.User,
+		authPass: cfg.AuthPassword,
+		privProt: strings.ToUpper(cfg.PrivProtocol),
+		privPass: cfg.PrivPassword,
+		salt:     rand.Uint64(),
+		msgID:    rand.Int32N(1 << 30),
+	}
+	switch strings.ToUpper(cfg.AuthProtocol) {
+	case "MD5":
+		u.authHash = md5.New
+	case "SHA":
+		u.authHash = sha1.New
+	}
+	return u
+}
+
+// passwordToKey derives a user key from a password as in RFC 3414 A.2.1:
+// the hash of the password repeated to one megabyte
+func passwordToKey(newHash func() hash.Hash, password string) []byte {
+	h := newHash()
+	buf := make([]byte, 64)
+	pass := []byte(password)
+	for i, count := 0, 0; count < 1<<20; count += 64 {
+		for j := range buf {
+			buf[j] = pass[i%len(pass)]
+			i++
+		}
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+// localizeKey binds a user key to an engine as in RFC 3414 A.2.2
+func localizeKey(newHash func() hash.Hash, key, engineID []byte) []byte {
+	h := newHash()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// flags returns the message flags for the configured security level
+func (u *usm) flags() byte {
+	var flags byte
+	if u.authHash != nil {
+		flags |= flagAuth
+	}
+	if u.privProt != "" {
+		flags |= flagPriv
+	}
+	return flags
+}
+
+// currentTime estimates the agent's engine time from when it was synced
+func (u *usm) currentTime() int64 {
+	return u.engineTime + int64(time.Since(u.syncedAt).Seconds())
+}
+
+// sync adopts the engine boots and time an agent reported
+func (u *usm) sync(boots, engineTime int64) {
+	u.boots, u.engineTime, u.syncedAt = boots, engineTime, time.Now()
+}
+
+// discover learns the agent's engine ID, boots and time from the report it
+// sends for an unauthenticated request, then localizes the keys
+func (c *Client) discover() error {
+	u := c.usm
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requestID++
+	encoded, err := encodePDU(pduGetRequest, c.requestID, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+	u.msgID++
+	message := encodeV3Message(u.msgID, flagReportable, nil, 0, 0, "", nil, nil,
+		sequence(tagSequence, encodeString(nil), encodeString(nil), encoded))
+
+	id, msgID := c.requestID, u.msgID
+	response, err := c.exchange(message, id, func(b []byte) (*pdu, error) {
+		m, err := decodeV3Message(b)
+		if err != nil {
+			return nil, err
+		}
+		if m.msgID != msgID {
+			return &pdu{}, nil
+		}
+		if len(m.engineID) == 0 {
+			return nil, fmt.Errorf("snmp: agent sent no engine ID")
+		}
+		u.engineID = m.engineID
+		u.sync(m.boots, m.engineTime)
+		return matched(id)(m.pdu())
+	})
+	if err != nil {
+		return err
+	}
+	if response.Type != pduReport && response.Type != pduResponse {
+		return errMalformed
+	}
+
+	if u.authHash != nil {
+		u.authKey = localizeKey(u.authHash, passwordToKey(u.authHash, u.authPass), u.engineID)
+		if u.privProt != "" {
+			u.privKey = localizeKey(u.authHash, passwordToKey(u.authHash, u.privPass), u.engineID)
+		}
+	}
+	return nil
+}
+
+// exchangeV3 sends a PDU in a v3 message, resyncing the engine clock once
+// if the agent reports the request out of its time window
+func (c *Client) exchangeV3(encoded []byte, id int32) (*pdu, error) {
+	u := c.usm
+	for attempt := 0; ; attempt++ {
+		message, msgID, err := u.encodeRequest(encoded)
+		if err != nil {
+			return nil, err
+		}
+		var reported *v3Message
+		response, err := c.exchange(message, id, func(b []byte) (*pdu, error) {
+			m, err := decodeV3Message(b)
+			if err != nil {
+				return nil, err
+			}
+			if m.msgID != msgID {
+				return &pdu{}, nil
+			}
+			if err := u.verify(b, m); err != nil {
+				return nil, err
+			}
+			reported = m
+			return matched(id)(m.pduWith(u))
+		})
+		if err != nil {
+			return nil, err
+		}
+		if response.Type != pduReport {
+			return response, nil
+		}
+
+		reason := "report"
+		if len(response.Variables) > 0 {
+			oid := response.Variables[0].OID
+			if oid == notInTimeWindow && attempt == 0 {
+				u.sync(reported.boots, reported.engineTime)
+				continue
+			}
+			if r, ok := usmReports[oid]; ok {
+				reason = r
+			} else {
+				reason = "report " + oid
+			}
+		}
+		return nil, fmt.Errorf("snmp: agent refused the request: %s", reason)
+	}
+}
+
+// encodeRequest wraps a PDU in an authenticated and encrypted v3 message
+// and returns it with its message ID
+func (u *usm) encodeRequest(encoded []byte) ([]byte, int32, error) {
+	scoped := sequence(tagSequence, encodeString(u.engineID), encodeString(nil), encoded)
+	boots, engineTime := u.boots, u.currentTime()
+
+	var privParams []byte
+	if u.privProt != "" {
+		var err error
+		if scoped, privParams, err = u.encrypt(scoped, boots, engineTime); err != nil {
+			return nil, 0, err
+		}
+		scoped = encodeString(scoped)
+	}
+	var authParams []byte
+	if u.authHash != nil {
+		authParams = make([]byte, authParamsLength)
+	}
+
+	u.msgID++
+	message := encodeV3Message(u.msgID, u.flags()|flagReportable, u.engineID, boots, engineTime, u.user, authParams, privParams, scoped)
+	if u.authHash != nil {
+		offset := bytes.Index(message, append([]byte{tagOctetString, authParamsLength}, authParams...))
+		if offset < 0 {
+			return nil, 0, errMalformed
+		}
+		copy(message[offset+2:], u.digest(message))
+	}
+	return message, u.msgID, nil
+}
+
+// digest returns the truncated HMAC of a message
+func (u *usm) digest(message []byte) []byte {
+	mac := hmac.New(u.authHash, u.authKey)
+	mac.Write(message)
+	return mac.Sum(nil)[:authParamsLength]
+}
+
+// verify checks a response's HMAC when the client authenticates
+func (u *usm) verify(raw []byte, m *v3Message) error {
+	if u.authHash == nil || m.flags&flagAuth == 0 {
+		if u.authHash != nil && m.isReport() {
+			// Reports of authentication failures are sent unauthenticated
+			return nil
+		}
+		if u.authHash != nil {
+			return fmt.Errorf("snmp: agent sent an unauthenticated response")
+		}
+		return nil
+	}
+	if len(m.authParams) != authParamsLength {
+		return errMalformed
+	}
+
+	field := append([]byte{tagOctetString, authParamsLength}, m.authParams...)
+	offset := bytes.Index(raw, field)
+	if offset < 0 {
+		return errMalformed
+	}
+	zeroed := append([]byte(nil), raw...)
+	clear(zeroed[offset+2 : offset+2+authParamsLength])
+	if !hmac.Equal(u.digest(zeroed), m.authParams) {
+		return fmt.Errorf("snmp: response failed authentication")
+	}
+	return nil
+}
+
+// encrypt encrypts a scoped PDU and returns it with the privacy parameters
+func (u *usm) encrypt(plain []byte, boots, engineTime int64) ([]byte, []byte, error) {
+	u.salt++
+	switch u.privProt {
+	case "DES":
+		salt := make([]byte, 8)
+		binary.BigEndian.PutUint32(salt, uint32(boots))
+		binary.BigEndian.PutUint32(salt[4:], uint32(u.salt))
+		block, err := des.NewCipher(u.privKey[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = u.privKey[8+i] ^ salt[i]
+		}
+		if pad := len(plain) % 8; pad != 0 {
+			plain = append(plain, make([]byte, 8-pad)...)
+		}
+		out := make([]byte, len(plain))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+		return out, salt, nil
+	case "AES":
+		salt := binary.BigEndian.AppendUint64(nil, u.salt)
+		block, err := aes.NewCipher(u.privKey[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		out := make([]byte, len(plain))
+		cipher.NewCFBEncrypter(block, aesIV(boots, engineTime, salt)).XORKeyStream(out, plain)
+		return out, salt, nil
+	}
+	return nil, nil, fmt.Errorf("snmp: unknown privacy protocol %q", u.privProt)
+}
+
+// decrypt decrypts a response's scoped PDU
+func (u *usm) decrypt(data []byte, m *v3Message) ([]byte, error) {
+	if len(m.privParams) != 8 {
+		return nil, errMalformed
+	}
+	switch u.privProt {
+	case "DES":
+		if len(data)%8 != 0 {
+			return nil, errMalformed
+		}
+		block, err := des.NewCipher(u.privKey[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = u.privKey[8+i] ^ m.privParams[i]
+		}
+		out := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+		return out, nil
+	case "AES":
+		block, err := aes.NewCipher(u.privKey[:16])
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(data))
+		cipher.NewCFBDecrypter(block, aesIV(m.boots, m.engineTime, m.privParams)).XORKeyStream(out, data)
+		return out, nil
+	}
+	return nil, fmt.Errorf("snmp: unknown privacy protocol %q", u.privProt)
+}
+
+// aesIV builds the AES-CFB IV of RFC 3826: engine boots, engine time, salt
+func aesIV(boots, engineTime int64, salt []byte) []byte {
+	iv := make([]byte, 0, 16)
+	iv = binary.BigEndian.AppendUint32(iv, uint32(boots))
+	iv = binary.BigEndian.AppendUint32(iv, uint32(engineTime))
+	return append(iv, salt...)
+}
+
+// encodeV3Message encodes a v3 message around an encoded scoped PDU (or its
+// encrypted OCTET STRING)
+func encodeV3Message(msgID int32, flags byte, engineID []byte, boots, engineTime int64, user string, authParams, privParams, scoped []byte) []byte {
+	security := sequence(tagSequence,
+		encodeString(engineID),
+		encodeInt(boots),
+		encodeInt(engineTime),
+		encodeString([]byte(user)),
+		encodeString(authParams),
+		encodeString(privParams),
+	)
+	header := sequence(tagSequence,
+		encodeInt(int64(msgID)),
+		encodeInt(maxMessageSize),
+		encodeString([]byte{flags}),
+		encodeInt(securityModelUSM),
+	)
+	return sequence(tagSequence, encodeInt(3), header, encodeString(security), scoped)
+}
+
+// v3Message is a decoded v3 message with its scoped PDU still encoded
+type v3Message struct {
+	msgID      int32
+	flags      byte
+	engineID   []byte
+	boots      int64
+	engineTime int64
+	authParams []byte
+	privParams []byte
+	scoped     element
+}
+
+// decodeV3Message decodes a v3 message's header and security parameters
+func decodeV3Message(b []byte) (*v3Message, error) {
+	message, _, err := decode(b)
+	if err != nil || message.Tag != tagSequence {
+		return nil, errMalformed
+	}
+	fields, err := decodeAll(message.Value)
+	if err != nil || len(fields) != 4 {
+		return nil, errMalformed
+	}
+	if version, err := decodeInt(fields[0].Value); err != nil || version != 3 {
+		return nil, errMalformed
+	}
+
+	header, err := decodeAll(fields[1].Value)
+	if err != nil || len(header) != 4 || len(header[2].Value) != 1 {
+		return nil, errMalformed
+	}
+	m := &v3Message{flags: header[2].Value[0], scoped: fields[3]}
+	msgID, err := decodeInt(header[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	m.msgID = int32(msgID)
+
+	security, _, err := decode(fields[2].Value)
+	if err != nil {
+		return nil, errMalformed
+	}
+	params, err := decodeAll(security.Value)
+	if err != nil || len(params) != 6 {
+		return nil, errMalformed
+	}
+	m.engineID = params[0].Value
+	if m.boots, err = decodeInt(params[1].Value); err != nil {
+		return nil, err
+	}
+	if m.engineTime, err = decodeInt(params[2].Value); err != nil {
+		return nil, err
+	}
+	m.authParams = params[4].Value
+	m.privParams = params[5].Value
+	return m, nil
+}
+
+// matched marks a PDU as answering request id. v3 matches responses by
+// message ID: an agent that cannot read the request reports with request ID 0.
+func matched(id int32) func(*pdu, error) (*pdu, error) {
+	return func(p *pdu, err error) (*pdu, error) {
+		if err != nil {
+			return nil, err
+		}
+		p.RequestID = id
+		return p, nil
+	}
+}
+
+// isReport peeks whether an unencrypted message carries a report
+func (m *v3Message) isReport() bool {
+	p, err := m.pdu()
+	return err == nil && p.Type == pduReport
+}
+
+// pdu decodes the PDU of an unencrypted scoped PDU
+func (m *v3Message) pdu() (*pdu, error) {
+	if m.scoped.Tag != tagSequence {
+		return nil, errMalformed
+	}
+	fields, err := decodeAll(m.scoped.Value)
+	if err != nil || len(fields) != 3 {
+		return nil, errMalformed
+	}
+	return decodePDU(fields[2])
+}
+
+// pduWith decodes the PDU, decrypting the scoped PDU when it is encrypted
+func (m *v3Message) pduWith(u *usm) (*pdu, error) {
+	if m.flags&flagPriv == 0 {
+		return m.pdu()
+	}
+	if m.scoped.Tag != tagOctetString || u.privProt == "" {
+		return nil, errMalformed
+	}
+	plain, err := u.decrypt(m.scoped.Value, m)
+	if err != nil {
+		return nil, err
+	}
+	// DES pads the plaintext; only the first element is the scoped PDU
+	scoped, _, err := decode(plain)
+	if err != nil {
+		return nil, fmt.Errorf("snmp: could not decrypt the response, check the privacy password")
+	}
+	m.scoped = scoped
+	m.flags &^= flagPriv
+	return m.pdu()
+}
@@ -0,0 +1,145 @@
+package snmp
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+// TestKeyLocalization checks the password to key and localization vectors
+// of RFC 3414 A.3.1 and A.3.2
+func TestKeyLocalization(t *testing.T) {
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+	tests := []struct {
+		name      string
+		newHash   func() hash.Hash
+		key       string
+		localized string
+	}{
+		{"MD5", md5.New, "9faf3283884e92834ebc9847d8edd963", "526f5eed9fcce26f8964c2930787d82b"},
+		{"SHA", sha1.New, "9fb5cc0381497b3793528939ff788d5d79145211", "6695febc9288e36282235fc7151f128497b38f3f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := passwordToKey(tt.newHash, "maplesyrup")
+			if got := hex.EncodeToString(key); got != tt.key {
+				t.Errorf("passwordToKey = %s, want %s", got, tt.key)
+			}
+			if got := hex.EncodeToString(localizeKey(tt.newHash, key, engineID)); got != tt.localized {
+				t.Errorf("localizeKey = %s, want %s", got, tt.localized)
+			}
+		})
+	}
+}
+
+// testUSM returns a synced USM with keys localized to engineID
+func testUSM(cfg Config, engineID []byte) *usm {
+	u := newUSM(cfg)
+	u.engineID = engineID
+	u.sync(3, 1000)
+	if u.authHash != nil {
+		u.authKey = localizeKey(u.authHash, passwordToKey(u.authHash, cfg.AuthPassword), engineID)
+		if u.privProt != "" {
+			u.privKey = localizeKey(u.authHash, passwordToKey(u.authHash, cfg.PrivPassword), engineID)
+		}
+	}
+	return u
+}
+
+var testEngineID = []byte{0x80, 0x00, 0x1f, 0x88, 0x04, 'h', 'o', 'm', 'e'}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plain := sequence(tagSequence, encodeString(testEngineID), encodeString(nil), encodeNull())
+	for _, cfg := range []Config{
+		{User: "u", AuthProtocol: "MD5", AuthPassword: "authpass1", PrivProtocol: "DES", PrivPassword: "privpass1"},
+		{User: "u", AuthProtocol: "SHA", AuthPassword: "authpass1", PrivProtocol: "AES", PrivPassword: "privpass1"},
+	} {
+		t.Run(cfg.AuthProtocol+"/"+cfg.PrivProtocol, func(t *testing.T) {
+			u := testUSM(cfg, testEngineID)
+			encrypted, salt, err := u.encrypt(plain, u.boots, u.engineTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(encrypted, plain) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+
+			decrypted, err := u.decrypt(encrypted, &v3Message{boots: u.boots, engineTime: u.engineTime, privParams: salt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// DES pads to the block size
+			if !bytes.HasPrefix(decrypted, plain) {
+				t.Errorf("decrypted %x, want %x", decrypted, plain)
+			}
+
+			if _, err := u.decrypt(encrypted, &v3Message{privParams: salt[:4]}); err == nil {
+				t.Error("decrypting with short privacy parameters succeeded")
+			}
+		})
+	}
+}
+
+func TestAuthenticatedRequest(t *testing.T) {
+	cfg := Config{User: "monitor", AuthProtocol: "SHA", AuthPassword: "authpass1", PrivProtocol: "AES", PrivPassword: "privpass1"}
+	client := testUSM(cfg, testEngineID)
+	agent := testUSM(cfg, testEngineID)
+
+	get, _ := encodePDU(pduGetRequest, 7, 0, 0, []string{"1.3.6.1.2.1.1.3.0"})
+	message, msgID, err := client.encodeRequest(get)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := decodeV3Message(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.msgID != msgID || m.flags != flagAuth|flagPriv|flagReportable {
+		t.Errorf("decoded msgID %d flags %#x, want %d and %#x", m.msgID, m.flags, msgID, flagAuth|flagPriv|flagReportable)
+	}
+	if err := agent.verify(message, m); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	p, err := m.pduWith(agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != pduGetRequest || p.RequestID != 7 || len(p.Variables) != 1 || p.Variables[0].OID != "1.3.6.1.2.1.1.3.0" {
+		t.Errorf("decrypted PDU %+v", p)
+	}
+
+	// Any change to the message fails authentication
+	tampered := append([]byte(nil), message...)
+	tampered[len(tampered)-1] ^= 0xff
+	if m, err := decodeV3Message(tampered); err != nil {
+		t.Fatal(err)
+	} else if err := agent.verify(tampered, m); err == nil {
+		t.Error("verify accepted a tampered message")
+	}
+
+	wrong := testUSM(Config{User: "monitor", AuthProtocol: "SHA", AuthPassword: "otherpass", PrivProtocol: "AES", PrivPassword: "privpass1"}, testEngineID)
+	if err := wrong.verify(message, m); err == nil {
+		t.Error("verify accepted a message signed with another password")
+	}
+}
+
+func TestDecodeV3MessageMalformed(t *testing.T) {
+	cfg := Config{User: "monitor", AuthProtocol: "MD5", AuthPassword: "authpass1"}
+	get, _ := encodePDU(pduGetRequest, 1, 0, 0, []string{"1.3.6.1.2.1.1.3.0"})
+	message, _, err := testUSM(cfg, testEngineID).encodeRequest(get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(message); n++ {
+		if _, err := decodeV3Message(message[:n]); err == nil {
+			t.Errorf("decoding a message truncated to %d bytes succeeded", n)
+		}
+	}
+	if _, err := decodeV3Message(sequence(tagSequence, encodeInt(1), encodeString(nil), encodeNull())); err == nil {
+		t.Error("decoding a v2c message as v3 succeeded")
+	}
+}
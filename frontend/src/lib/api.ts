@@ -132,6 +132,17 @@ export interface Device {
     sshUser?: string;
     sshPassword?: string;
     sshPort?: number;
+    // Power actions over SSH or WinRM (WinRM uses the SSH credentials)
+    powerMethod?: "" | "ssh" | "winrm";
+    winrmPort?: number;
+    winrmHttps?: boolean;
+    winrmInsecure?: boolean;
+    winrmAuth?: "" | "ntlm" | "basic";
+    // IPMI BMC used to power the device on
+    ipmiHost?: string;
+    ipmiUser?: string;
+    // Average power draw in watts, for energy estimates
+    powerWatts?: number;
     createdAt: string;
     updatedAt: string;
 }
@@ -150,6 +161,17 @@ export interface CreateDeviceRequest {
     sshUser?: string;
     sshPassword?: string;
     sshPort?: number;
+    // Power actions over SSH or WinRM (WinRM uses the SSH credentials)
+    powerMethod?: "" | "ssh" | "winrm";
+    winrmPort?: number;
+    winrmHttps?: boolean;
+    winrmInsecure?: boolean;
+    winrmAuth?: "" | "ntlm" | "basic";
+    // IPMI BMC used to power the device on
+    ipmiHost?: string;
+    ipmiUser?: string;
+    ipmiPassword?: string;
+    powerWatts?: number;
 }
 
 export interface Server {
@@ -181,10 +203,34 @@ export interface Service {
     statusCode: number;
     responseTime: number;
     uptimePercent: number;
+    uptime?: ServiceUptime[];
     lastCheck: string;
     isActive: boolean;
 }
 
+export interface ServiceUptime {
+    window: string; // 24h, 7d, 30d
+    uptimePercent: number | null;
+    checks: number;
+    failed: number;
+}
+
+export interface ServiceCheck {
+    id: number;
+    serviceId: number;
+    status: string;
+    statusCode: number;
+    responseTime: number;
+    error?: string;
+    checkedAt: string;
+}
+
+export interface ServiceHistory {
+    serviceId: number;
+    uptime: ServiceUptime[];
+    checks: ServiceCheck[];
+}
+
 export interface CreateServiceRequest {
     name: string;
     url: string;
@@ -239,9 +285,28 @@ export interface AuthResponse {
     expiresAt: string;
 }
 
+// ApiError carries the backend's error code so callers can branch on it
+// instead of matching messages
+export class ApiError extends Error {
+    status: number;
+    code?: string;
+    details?: string;
+    requestId?: string;
+
+    constructor(status: number, body: { error?: string; code?: string; details?: string; requestId?: string }) {
+        super(body.error || `API Error: ${status}`);
+        this.name = "ApiError";
+        this.status = status;
+        this.code = body.code;
+        this.details = body.details;
+        this.requestId = body.requestId;
+    }
+}
+
 // Token storage
 const TOKEN_KEY = "homelab_token";
 const USER_KEY = "homelab_user";
+const REFRESH_KEY = "homelab_refresh_token";
 
 export function getStoredToken(): string | null {
     return localStorage.getItem(TOKEN_KEY);
@@ -254,6 +319,16 @@ export function setStoredToken(token: string): void {
 export function removeStoredToken(): void {
     localStorage.removeItem(TOKEN_KEY);
     localStorage.removeItem(USER_KEY);
+    localStorage.removeItem(REFRESH_KEY);
+}
+
+// Refresh tokens are only issued when the backend runs in stateless mode
+function storeAuthResponse(response: AuthResponse): void {
+    setStoredToken(response.accessToken);
+    setStoredUser(response.user);
+    if (response.refreshToken) {
+        localStorage.setItem(REFRESH_KEY, response.refreshToken);
+    }
 }
 
 export function getStoredUser(): User | null {
@@ -282,7 +357,37 @@ class ApiClient {
         return token ? { Authorization: `Bearer ${token}` } : {};
     }
 
-    private async request<T>(endpoint: string, options?: RequestInit): Promise<T> {
+    private refreshing: Promise<boolean> | null = null;
+
+    // refreshAccessToken trades the stored refresh token for a new access token,
+    // sharing one request between concurrent callers
+    private refreshAccessToken(): Promise<boolean> {
+        const refreshToken = localStorage.getItem(REFRESH_KEY);
+        if (!refreshToken) {
+            return Promise.resolve(false);
+        }
+        if (!this.refreshing) {
+            this.refreshing = fetch(`${this.baseUrl}/auth/refresh`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ refreshToken }),
+            })
+                .then(async (response) => {
+                    if (!response.ok) {
+                        return false;
+                    }
+                    storeAuthResponse(await response.json());
+                    return true;
+                })
+                .catch(() => false)
+                .finally(() => {
+                    this.refreshing = null;
+                });
+        }
+        return this.refreshing;
+    }
+
+    private async request<T>(endpoint: string, options?: RequestInit, retried = false): Promise<T> {
         const response = await fetch(`${this.baseUrl}${endpoint}`, {
             ...options,
             headers: {
@@ -293,6 +398,9 @@ class ApiClient {
         });
 
         if (!response.ok) {
+            if (response.status === 401 && !retried && endpoint !== "/auth/login" && (await this.refreshAccessToken())) {
+                return this.request<T>(endpoint, options, true);
+            }
             if (response.status === 401) {
                 const currentPath = window.location.pathname;
                 if (currentPath !== "/login" && currentPath !== "/register") {
@@ -301,7 +409,10 @@ class ApiClient {
                 }
             }
             const error = await response.json().catch(() => ({}));
-            throw new Error(error.error || `API Error: ${response.status} ${response.statusText}`);
+            throw new ApiError(response.status, {
+                ...error,
+                error: error.error || `API Error: ${response.status} ${response.statusText}`,
+            });
         }
 
         return response.json();
@@ -313,8 +424,7 @@ class ApiClient {
             method: "POST",
             body: JSON.stringify(data),
         });
-        setStoredToken(response.accessToken);
-        setStoredUser(response.user);
+        storeAuthResponse(response);
         return response;
     }
 
@@ -323,8 +433,7 @@ class ApiClient {
             method: "POST",
             body: JSON.stringify(data),
         });
-        setStoredToken(response.accessToken);
-        setStoredUser(response.user);
+        storeAuthResponse(response);
         return response;
     }
 
@@ -369,6 +478,12 @@ class ApiClient {
         return this.request<MetricsHistory[]>(`/metrics/history?limit=${limit}`);
     }
 
+    async getMetricsHistoryRange(from: string, to: string, bucket?: number): Promise<MetricsHistory[]> {
+        const params = new URLSearchParams({ from, to });
+        if (bucket) params.set("bucket", String(bucket));
+        return this.request<MetricsHistory[]>(`/metrics/history?${params}`);
+    }
+
     // Containers
     async getContainers(): Promise<Container[]> {
         return this.request<Container[]>("/containers");
@@ -443,6 +558,10 @@ class ApiClient {
         await this.request(`/devices/${id}/shutdown`, { method: "POST" });
     }
 
+    async rebootDevice(id: number): Promise<void> {
+        await this.request(`/devices/${id}/reboot`, { method: "POST" });
+    }
+
     // Services
     async getServices(refresh = false): Promise<Service[]> {
         const query = refresh ? "?refresh=true" : "";
@@ -475,6 +594,10 @@ class ApiClient {
         return this.request<ServiceHealth>(`/services/${id}/health`);
     }
 
+    async getServiceHistory(id: number, hours = 24): Promise<ServiceHistory> {
+        return this.request<ServiceHistory>(`/services/${id}/history?hours=${hours}`);
+    }
+
     async getServiceCategories(): Promise<{ value: string; label: string; icon: string }[]> {
         return this.request(`/services/categories`);
     }
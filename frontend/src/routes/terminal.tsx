@@ -84,8 +84,18 @@ function TerminalPage() {
             }
         };
 
-        socket.onclose = () => {
-            if (ws.current === socket) setIsConnected(false);
+        socket.onclose = (event) => {
+            if (ws.current !== socket) return;
+            setIsConnected(false);
+            // 4xxx closes carry a JSON reason, e.g. when the token expires
+            if (event.code >= 4000 && event.reason) {
+                try {
+                    const reason = JSON.parse(event.reason) as { error?: string };
+                    toast.error(`Terminal closed: ${reason.error ?? event.reason}`);
+                } catch {
+                    toast.error(`Terminal closed: ${event.reason}`);
+                }
+            }
         };
 
         socket.onerror = () => {